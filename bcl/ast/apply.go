@@ -0,0 +1,163 @@
+package ast
+
+import "slices"
+
+// An ApplyFunc is called by Apply for each node, with a cursor at the node.
+type ApplyFunc func(*Cursor) bool
+
+// Apply traverses the tree rooted at root, calling pre for each node before
+// its children and post after them, either may be nil. Through the cursor,
+// the functions may replace or delete the node, or insert statements around
+// it. Apply returns the root, which may have been replaced.
+//
+// When pre returns false the children of the node and post are skipped for
+// it. When post returns false, Apply stops and returns.
+func Apply(root Node, pre, post ApplyFunc) (result Node) {
+	defer func() {
+		if r := recover(); r != nil && r != abort {
+			panic(r)
+		}
+	}()
+
+	result = root
+	a := &application{pre: pre, post: post}
+	a.apply(&Cursor{
+		node: root,
+		root: &result,
+		iter: &iterator{step: 1},
+	})
+	return result
+}
+
+var abort = new(int)
+
+// A Cursor describes a node reached by Apply, and the statement list which
+// holds it.
+type Cursor struct {
+	node   Node
+	parent Node
+	blocks []*Block
+
+	body *Body // the statements holding the node, nil at the root
+	root *Node // the result of Apply, at the root
+	iter *iterator
+}
+
+// iterator is the position in the statement list being walked.
+type iterator struct {
+	index, step int
+
+	// replaced is set when the node at index is not the one walked any more,
+	// its children are not walked.
+	replaced bool
+}
+
+// Node is the current node.
+func (c *Cursor) Node() Node {
+	return c.node
+}
+
+// Parent is the *File or *Block holding the current node, nil
+// at the root.
+func (c *Cursor) Parent() Node {
+	return c.parent
+}
+
+// Blocks are the blocks around the current node, outermost first.
+func (c *Cursor) Blocks() []*Block {
+	return slices.Clone(c.blocks)
+}
+
+// Index is the index of the current node in the statements of its parent.
+func (c *Cursor) Index() int {
+	return c.iter.index
+}
+
+// Replace replaces the current node. The new node is not walked.
+func (c *Cursor) Replace(node Node) {
+	if c.body == nil {
+		*c.root = node
+	} else {
+		c.body.Statements[c.iter.index] = node.(Statement)
+	}
+	c.node = node
+	c.iter.replaced = true
+}
+
+// Delete removes the current node from its parent. The root can't be deleted.
+func (c *Cursor) Delete() {
+	if c.body == nil {
+		panic("ast: the root can't be deleted")
+	}
+	statements := c.body.Statements
+	c.body.Statements = append(statements[:c.iter.index:c.iter.index], statements[c.iter.index+1:]...)
+	c.iter.step--
+	c.iter.replaced = true
+}
+
+// InsertBefore inserts a statement before the current node. It is not
+// walked.
+func (c *Cursor) InsertBefore(statement Statement) {
+	if c.body == nil {
+		panic("ast: statements can't be inserted around the root")
+	}
+	c.body.Statements = insertStatement(c.body.Statements, c.iter.index, statement)
+	c.iter.index++
+}
+
+// InsertAfter inserts a statement after the current node. It is not walked.
+func (c *Cursor) InsertAfter(statement Statement) {
+	if c.body == nil {
+		panic("ast: statements can't be inserted around the root")
+	}
+	c.body.Statements = insertStatement(c.body.Statements, c.iter.index+1, statement)
+	c.iter.step++
+}
+
+func insertStatement(statements []Statement, idx int, statement Statement) []Statement {
+	statements = append(statements, nil)
+	copy(statements[idx+1:], statements[idx:])
+	statements[idx] = statement
+	return statements
+}
+
+type application struct {
+	pre, post ApplyFunc
+	blocks    []*Block
+}
+
+func (a *application) apply(cursor *Cursor) {
+	if a.pre != nil && !a.pre(cursor) {
+		return
+	}
+
+	if children := bodyOf(cursor.node); children != nil && !cursor.iter.replaced {
+		if block, ok := cursor.node.(*Block); ok {
+			a.blocks = append(a.blocks, block)
+			a.body(block, children)
+			a.blocks = a.blocks[:len(a.blocks)-1]
+		} else {
+			a.body(cursor.node, children)
+		}
+	}
+
+	if a.post != nil && !a.post(cursor) {
+		panic(abort)
+	}
+}
+
+// body applies to each statement of the body in turn, following the
+// deletions and insertions made through the cursors.
+func (a *application) body(parent Node, body *Body) {
+	for idx := 0; idx < len(body.Statements); {
+		cursor := &Cursor{
+			node:   body.Statements[idx],
+			parent: parent,
+			blocks: a.blocks,
+			body:   body,
+			iter:   &iterator{index: idx, step: 1},
+		}
+		a.apply(cursor)
+		idx = cursor.iter.index + cursor.iter.step
+	}
+}
@@ -0,0 +1,167 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+func parse(t *testing.T, lines ...string) *File {
+	t.Helper()
+	tree, err := Parse(strings.Join(lines, "\n") + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+// describe names the node for comparing traversals.
+func describe(node Node) string {
+	switch n := node.(type) {
+	case nil:
+		return "end"
+	case *parser.File:
+		return "file"
+	case *parser.Block:
+		return "block " + n.Type.String()
+	case *parser.Assignment:
+		return "assign " + n.Key.String()
+	case *parser.Description:
+		return "description"
+	default:
+		return "other"
+	}
+}
+
+func statements(body parser.Body) []string {
+	out := []string{}
+	for _, statement := range body.Statements {
+		out = append(out, describe(statement))
+	}
+	return out
+}
+
+func assertList(t *testing.T, want []string, got []string) {
+	t.Helper()
+	if strings.Join(want, ", ") != strings.Join(got, ", ") {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tree := parse(t,
+		`a = 1`,
+		`b {`,
+		`	c = 2`,
+		`	skip {`,
+		`		d = 3`,
+		`	}`,
+		`}`,
+	)
+
+	got := []string{}
+	Inspect(tree, func(node Node) bool {
+		got = append(got, describe(node))
+		if block, ok := node.(*parser.Block); ok && block.Type.String() == "skip" {
+			return false
+		}
+		return true
+	})
+	assertList(t, []string{
+		"file",
+		"assign a", "end",
+		"block b",
+		"assign c", "end",
+		"block skip",
+		"end",
+		"end",
+	}, got)
+}
+
+func TestApplyBlocks(t *testing.T) {
+	tree := parse(t,
+		`a {`,
+		`	b.c {`,
+		`		d = 1`,
+		`	}`,
+		`}`,
+	)
+
+	var path []string
+	var parent Node
+	Apply(tree, func(c *Cursor) bool {
+		if _, ok := c.Node().(*parser.Assignment); ok {
+			for _, block := range c.Blocks() {
+				path = append(path, block.Type.String())
+			}
+			parent = c.Parent()
+		}
+		return true
+	}, nil)
+	assertList(t, []string{"a", "b.c"}, path)
+	if describe(parent) != "block b.c" {
+		t.Errorf("parent is %s", describe(parent))
+	}
+}
+
+func TestApplyEdit(t *testing.T) {
+	tree := parse(t,
+		`a = 1`,
+		`drop = 2`,
+		`b {`,
+		`	c = 3`,
+		`}`,
+		`e = 4`,
+	)
+	extra := parse(t, `x = 1`, `y = 2`, `z {`, `}`).Body.Statements
+
+	visited := []string{}
+	Apply(tree, func(c *Cursor) bool {
+		visited = append(visited, describe(c.Node()))
+		switch describe(c.Node()) {
+		case "assign a":
+			c.InsertBefore(extra[0])
+			c.InsertAfter(extra[1])
+		case "assign drop":
+			c.Delete()
+		case "block b":
+			c.Replace(extra[2])
+		}
+		return true
+	}, nil)
+
+	// Inserted and replacing nodes are not walked, nor are the children of
+	// the replaced block
+	assertList(t, []string{"file", "assign a", "assign drop", "block b", "assign e"}, visited)
+	assertList(t, []string{"assign x", "assign a", "assign y", "block z", "assign e"}, statements(tree.Body))
+}
+
+func TestApplyStop(t *testing.T) {
+	tree := parse(t, `a = 1`, `b = 2`, `c = 3`)
+
+	visited := []string{}
+	root := Apply(tree, nil, func(c *Cursor) bool {
+		visited = append(visited, describe(c.Node()))
+		return describe(c.Node()) != "assign b"
+	})
+	assertList(t, []string{"assign a", "assign b"}, visited)
+	if root != Node(tree) {
+		t.Error("expected the root to be returned")
+	}
+}
+
+func TestApplyReplaceRoot(t *testing.T) {
+	tree := parse(t, `a = 1`)
+	other := parse(t, `b = 1`)
+
+	root := Apply(tree, func(c *Cursor) bool {
+		if c.Parent() == nil {
+			c.Replace(other)
+		}
+		return true
+	}, nil)
+	if root != Node(other) {
+		t.Error("expected the replaced root")
+	}
+}
@@ -0,0 +1,29 @@
+package ast
+
+import (
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// The nodes of the tree, as the parser builds them.
+type (
+	File        = parser.File
+	Body        = parser.Body
+	Statement   = parser.Statement
+	Block       = parser.Block
+	BlockHeader = parser.BlockHeader
+	Assignment  = parser.Assignment
+	Description = parser.Description
+	Declaration = parser.Declaration
+	Comment     = parser.Comment
+	Reference   = parser.Reference
+	Ident       = parser.Ident
+	Value       = parser.Value
+	TagValue    = parser.TagValue
+	SourceNode  = parser.SourceNode
+)
+
+// Parse parses the syntax of a BCL file, without a schema, returning its tree
+// for Walk, Inspect, Apply and selectors.
+func Parse(data string) (*File, error) {
+	return parser.ParseFile(data, true)
+}
@@ -0,0 +1,313 @@
+package ast
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Selector matches statements by their kind, properties and the blocks
+// around them, a small form of CSS selectors:
+//
+//	block[type=foo] > attr[name=tag.*]
+//
+// Each step is a kind, block, attr, description or * for any, followed by
+// any number of [property=pattern] filters. The kind may be left out before
+// a filter for any. Patterns are globs as for
+// path.Match, quoted when they hold spaces or brackets, and != negates one.
+// A filter without a pattern, [property], requires the property to be set.
+// Steps separated by > match a block and a statement directly in it, steps
+// separated by spaces match a block and a statement at any depth in it.
+//
+// Blocks have the properties type, the dotted type of the block, tag, any of
+// its tags, and description. Attributes have name, the dotted key, and
+// value, the value for scalars or any element of arrays, compared as text.
+type Selector struct {
+	expr  string
+	steps []selectorStep
+}
+
+type selectorStep struct {
+	kind    string // block, attr, description or *
+	filters []selectorFilter
+
+	// child is true when the step is joined to the one before it with >.
+	child bool
+}
+
+type selectorFilter struct {
+	property string
+	pattern  string // empty when only the presence of the property is tested
+	negate   bool
+}
+
+var selectorProperties = map[string][]string{
+	"block":       {"type", "tag", "description"},
+	"attr":        {"name", "value"},
+	"description": {"value"},
+	"*":           {"type", "tag", "description", "name", "value"},
+}
+
+// ParseSelector parses a selector expression.
+func ParseSelector(expr string) (*Selector, error) {
+	sel := &Selector{expr: expr}
+	rest := strings.TrimSpace(expr)
+	if rest == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+	child := false
+	for rest != "" {
+		step, remaining, err := parseStep(rest)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", expr, err)
+		}
+		if len(sel.steps) == 0 && child {
+			return nil, fmt.Errorf("%s: '>' needs a step before it", expr)
+		}
+		step.child = child
+		sel.steps = append(sel.steps, step)
+
+		rest = strings.TrimLeft(remaining, " \t")
+		child = strings.HasPrefix(rest, ">")
+		if child {
+			rest = strings.TrimLeft(rest[1:], " \t")
+			if rest == "" {
+				return nil, fmt.Errorf("%s: '>' needs a step after it", expr)
+			}
+		}
+	}
+	return sel, nil
+}
+
+func parseStep(rest string) (selectorStep, string, error) {
+	step := selectorStep{}
+	end := strings.IndexAny(rest, "[ \t>")
+	if end < 0 {
+		end = len(rest)
+	}
+	step.kind = rest[:end]
+	if step.kind == "" {
+		if !strings.HasPrefix(rest, "[") {
+			return step, "", fmt.Errorf("expected a step at %q", rest)
+		}
+		step.kind = "*"
+	}
+	known, ok := selectorProperties[step.kind]
+	if !ok {
+		return step, "", fmt.Errorf("unknown kind %q, one of block, attr, description and *", step.kind)
+	}
+	rest = rest[end:]
+
+	for strings.HasPrefix(rest, "[") {
+		filter, remaining, err := parseFilter(rest[1:])
+		if err != nil {
+			return step, "", err
+		}
+		found := false
+		for _, property := range known {
+			found = found || property == filter.property
+		}
+		if !found {
+			return step, "", fmt.Errorf("%s has no property %q, one of %s", step.kind, filter.property, strings.Join(known, ", "))
+		}
+		step.filters = append(step.filters, filter)
+		rest = remaining
+	}
+	return step, rest, nil
+}
+
+// parseFilter parses the inside of a [property=pattern] filter, returning the
+// rest after the closing bracket.
+func parseFilter(rest string) (selectorFilter, string, error) {
+	filter := selectorFilter{}
+	end := strings.IndexAny(rest, "!=]")
+	if end < 0 {
+		return filter, "", fmt.Errorf("unclosed '['")
+	}
+	filter.property = strings.TrimSpace(rest[:end])
+	rest = rest[end:]
+	switch {
+	case strings.HasPrefix(rest, "]"):
+		return filter, rest[1:], nil
+	case strings.HasPrefix(rest, "!="):
+		filter.negate = true
+		rest = rest[2:]
+	case strings.HasPrefix(rest, "="):
+		rest = rest[1:]
+	default:
+		return filter, "", fmt.Errorf("expected = or != after %q", filter.property)
+	}
+
+	rest = strings.TrimLeft(rest, " ")
+	if strings.HasPrefix(rest, `"`) {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return filter, "", fmt.Errorf("pattern for %q: %w", filter.property, err)
+		}
+		filter.pattern, _ = strconv.Unquote(quoted)
+		rest = strings.TrimLeft(rest[len(quoted):], " ")
+		if !strings.HasPrefix(rest, "]") {
+			return filter, "", fmt.Errorf("unclosed '['")
+		}
+	} else {
+		end := strings.Index(rest, "]")
+		if end < 0 {
+			return filter, "", fmt.Errorf("unclosed '['")
+		}
+		filter.pattern = strings.TrimSpace(rest[:end])
+		rest = rest[end:]
+	}
+	if filter.pattern == "" {
+		return filter, "", fmt.Errorf("empty pattern for %q", filter.property)
+	}
+	if _, err := path.Match(filter.pattern, ""); err != nil {
+		return filter, "", fmt.Errorf("pattern %q: %w", filter.pattern, err)
+	}
+	return filter, rest[1:], nil
+}
+
+// String returns the selector as it was parsed.
+func (sel *Selector) String() string {
+	return sel.expr
+}
+
+// Match reports whether the node, inside the blocks, outermost first, is
+// selected.
+func (sel *Selector) Match(node Node, blocks []*Block) bool {
+	last := len(sel.steps) - 1
+	if !sel.steps[last].matches(node) {
+		return false
+	}
+	return sel.matchBlocks(last, blocks)
+}
+
+// matchBlocks reports whether the steps before step match the blocks, the
+// innermost block holding the node matched by step.
+func (sel *Selector) matchBlocks(step int, blocks []*Block) bool {
+	if step == 0 {
+		return true
+	}
+	prev := sel.steps[step-1]
+	if sel.steps[step].child {
+		if len(blocks) == 0 {
+			return false
+		}
+		inner := len(blocks) - 1
+		return prev.matches(blocks[inner]) && sel.matchBlocks(step-1, blocks[:inner])
+	}
+	for inner := len(blocks) - 1; inner >= 0; inner-- {
+		if prev.matches(blocks[inner]) && sel.matchBlocks(step-1, blocks[:inner]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the statements in the tree which the selector matches, in
+// the order of the file.
+func (sel *Selector) Select(root Node) []Node {
+	matches := []Node{}
+	Apply(root, func(c *Cursor) bool {
+		if sel.Match(c.Node(), c.Blocks()) {
+			matches = append(matches, c.Node())
+		}
+		return true
+	}, nil)
+	return matches
+}
+
+func (step selectorStep) matches(node Node) bool {
+	switch node.(type) {
+	case *Block:
+		if step.kind != "block" && step.kind != "*" {
+			return false
+		}
+	case *Assignment:
+		if step.kind != "attr" && step.kind != "*" {
+			return false
+		}
+	case *Description:
+		if step.kind != "description" && step.kind != "*" {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, filter := range step.filters {
+		if filter.matches(nodeProperty(node, filter.property)) == filter.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether any of the values match the pattern, or for a
+// presence filter whether there are any.
+func (filter selectorFilter) matches(values []string) bool {
+	if filter.pattern == "" {
+		return len(values) > 0
+	}
+	for _, value := range values {
+		if ok, _ := path.Match(filter.pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeProperty returns the values of a property of the node, empty when the
+// node doesn't have it.
+func nodeProperty(node Node, property string) []string {
+	switch n := node.(type) {
+	case *Block:
+		switch property {
+		case "type":
+			return []string{n.Type.String()}
+		case "tag":
+			tags := []string{}
+			for _, tag := range n.Tags {
+				if str, err := tag.AsString(); err == nil {
+					tags = append(tags, str)
+				} else if tag.Value != nil {
+					tags = append(tags, tag.Value.Token().Lit)
+				}
+			}
+			return tags
+		case "description":
+			if n.Description == nil {
+				return nil
+			}
+			return []string{n.Description.Value}
+		}
+	case *Assignment:
+		switch property {
+		case "name":
+			return []string{n.Key.String()}
+		case "value":
+			return valueStrings(n.Value)
+		}
+	case *Description:
+		if property == "value" {
+			return []string{n.Value}
+		}
+	}
+	return nil
+}
+
+func valueStrings(value Value) []string {
+	if elems, ok := value.AsArray(); ok {
+		out := []string{}
+		for _, elem := range elems {
+			out = append(out, valueStrings(elem.(Value))...)
+		}
+		return out
+	}
+	if str, err := value.AsString(); err == nil {
+		return []string{str}
+	}
+	if lit := value.Token().Lit; lit != "" {
+		return []string{lit}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package ast
+
+import (
+	"testing"
+)
+
+func TestSelector(t *testing.T) {
+	tree := parse(t,
+		`a = 1`,
+		`tag.root = "r"`,
+		`foo first {`,
+		`	| About first`,
+		`	tag.x = "1"`,
+		`	name = "x"`,
+		`	bar {`,
+		`		tag.y = ["p", "q"]`,
+		`	}`,
+		`}`,
+		`foo second {`,
+		`	other = 2`,
+		`}`,
+	)
+
+	for _, tc := range []struct {
+		selector string
+		want     []string
+	}{
+		{`attr`, []string{"assign a", "assign tag.root", "assign tag.x", "assign name", "assign tag.y", "assign other"}},
+		{`block[type=foo] > attr[name=tag.*]`, []string{"assign tag.x"}},
+		{`block[type=foo] attr[name=tag.*]`, []string{"assign tag.x", "assign tag.y"}},
+		{`block[type=foo]>block>attr`, []string{"assign tag.y"}},
+		{`block[tag=second] attr`, []string{"assign other"}},
+		{`block[tag!=second]`, []string{"block foo", "block bar"}},
+		{`attr[value=q]`, []string{"assign tag.y"}},
+		{`attr[value="1"]`, []string{"assign a", "assign tag.x"}},
+		{`[name=a]`, []string{"assign a"}},
+		{`description[value="About *"]`, []string{"description"}},
+		{`block[description]`, []string{}},
+		{`*[name=a]`, []string{"assign a"}},
+		{`block > block`, []string{"block bar"}},
+	} {
+		t.Run(tc.selector, func(t *testing.T) {
+			sel, err := ParseSelector(tc.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, node := range sel.Select(tree) {
+				got = append(got, describe(node))
+			}
+			assertList(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`> attr`,
+		`attr >`,
+		`thing`,
+		`attr[type=x]`,
+		`attr[name=x`,
+		`attr[name=]`,
+		`attr[name~x]`,
+		`attr[name=[x]`,
+	} {
+		if _, err := ParseSelector(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
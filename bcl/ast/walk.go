@@ -0,0 +1,66 @@
+// Package ast traverses and rewrites the syntax tree of a BCL file, for lint
+// rules, fixes and migrations which work on the statements of a file rather
+// than the messages it parses into.
+package ast
+
+import (
+	"fmt"
+)
+
+// Node is a *File, or a statement: *Block, *Assignment, *Description,
+// *Declaration or *Comment.
+type Node any
+
+// A Visitor's Visit method is called for each node Walk reaches. When it
+// returns a visitor w, Walk visits the children of the node with w, then
+// calls w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree in depth-first order, calling v.Visit(node) and
+// then walking the statements of the node's body, for a file or a block.
+func Walk(node Node, v Visitor) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	if body := bodyOf(node); body != nil {
+		for _, statement := range body.Statements {
+			Walk(statement, v)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree as Walk does, calling f(node) for each node and
+// walking the children when it returns true. Each node is followed by a call
+// of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(node, inspector(f))
+}
+
+// bodyOf returns the body holding the children of the node, nil for nodes
+// without one.
+func bodyOf(node Node) *Body {
+	switch n := node.(type) {
+	case *File:
+		return &n.Body
+	case *Block:
+		return &n.Body
+	case *Assignment, *Description, *Declaration, *Comment, nil:
+		return nil
+	default:
+		panic(fmt.Sprintf("ast: unexpected node type %T", n))
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"os"
 
 	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/project"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
 	"github.com/pentops/bcl.go/internal/linter"
 	"github.com/pentops/bcl.go/internal/lsp"
+	"github.com/pentops/bcl.go/internal/parser"
 	"github.com/pentops/log.go/log"
 	"github.com/sourcegraph/jsonrpc2"
 	"google.golang.org/protobuf/reflect/protoreflect"
@@ -18,6 +20,14 @@ type Config struct {
 	ProjectRoot string
 	Schema      *bcl_j5pb.Schema
 	FileFactory func(filename string) protoreflect.Message
+
+	// Progress, when set, is called as each file is linted, e.g. to log slow
+	// files.
+	Progress func(bcl.Progress)
+
+	// Dictionary, when set, checks the spelling of descriptions and of the
+	// string fields the schema marks as prose.
+	Dictionary bcl.Dictionary
 }
 
 type logWrapper struct {
@@ -45,6 +55,13 @@ func RunLSP(ctx context.Context, config Config) error {
 	}
 	ctx = log.WithField(ctx, "ProjectRoot", config.ProjectRoot)
 
+	// Naming conventions and the format layout are read from the project
+	// config
+	projectConfig, err := project.LoadConfig(os.DirFS(config.ProjectRoot))
+	if err != nil {
+		return err
+	}
+
 	handlers := lsp.LSPHandlers{}
 
 	if config.Schema != nil && config.FileFactory != nil {
@@ -52,12 +69,18 @@ func RunLSP(ctx context.Context, config Config) error {
 		if err != nil {
 			return err
 		}
-		handlers.Linter = linter.New(parser, config.FileFactory)
+		parser.Progress = config.Progress
+		fileLinter := linter.New(parser, config.FileFactory)
+		fileLinter.Naming = projectConfig.Naming
+		fileLinter.Dictionary = config.Dictionary
+		handlers.Linter = fileLinter
 	} else {
 		handlers.Linter = linter.NewGeneric()
 	}
 
-	handlers.Fmter = lsp.ASTFormatter{}
+	handlers.Fmter = lsp.ASTFormatter{
+		Options: parser.ConfigOptions(projectConfig.Format),
+	}
 
 	log.Info(ctx, "Starting LSP server")
 
@@ -0,0 +1,174 @@
+// Package bcltest helps owners of a BCL schema test their files: parse a file
+// into a message, compare the message to a golden file, and assert the
+// diagnostics of invalid files.
+//
+// Run the tests with -bcltest.update to rewrite the golden files from the
+// current output.
+package bcltest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+var update = flag.Bool("bcltest.update", false, "rewrite bcltest golden files")
+
+// Update reports if golden files are being rewritten, either with the
+// -bcltest.update flag or BCLTEST_UPDATE=1
+func Update() bool {
+	return *update || os.Getenv("BCLTEST_UPDATE") == "1"
+}
+
+// ParseFile parses the file into msg, failing the test on any error.
+func ParseFile(t testing.TB, parser *bcl.Parser, filename string, msg proto.Message) {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ParseString(t, parser, filename, string(data), msg)
+}
+
+// ParseString parses the content into msg, failing the test on any error.
+func ParseString(t testing.TB, parser *bcl.Parser, filename string, content string, msg proto.Message) {
+	t.Helper()
+	_, err := parser.ParseFile(filename, content, msg.ProtoReflect())
+	if err != nil {
+		t.Fatalf("parsing %s:\n%s", filename, err.Error())
+	}
+}
+
+// AssertGolden compares msg to the golden file, which is protojson for .json
+// files and prototext otherwise. The files are compared as messages, so
+// formatting in the golden file doesn't matter.
+func AssertGolden(t testing.TB, msg proto.Message, goldenFile string) {
+	t.Helper()
+
+	if Update() {
+		data, err := marshalGolden(msg, goldenFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(goldenFile, append(data, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(goldenFile)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -bcltest.update to create): %s", err)
+	}
+
+	want := msg.ProtoReflect().New().Interface()
+	if err := unmarshalGolden(data, want, goldenFile); err != nil {
+		t.Fatalf("golden file %s: %s", goldenFile, err)
+	}
+
+	if !proto.Equal(want, msg) {
+		got, _ := marshalGolden(msg, goldenFile)
+		t.Errorf("message does not match golden file %s (run with -bcltest.update to accept)\nwant:\n%s\ngot:\n%s", goldenFile, string(data), string(got))
+	}
+}
+
+func isJSON(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".json")
+}
+
+func marshalGolden(msg proto.Message, filename string) ([]byte, error) {
+	if isJSON(filename) {
+		return protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+	}
+	return prototext.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(msg)
+}
+
+func unmarshalGolden(data []byte, msg proto.Message, filename string) error {
+	if isJSON(filename) {
+		return protojson.Unmarshal(data, msg)
+	}
+	return prototext.Unmarshal(data, msg)
+}
+
+// Diagnostic is an expected error. Line and Column are 1 based, as printed in
+// error messages, zero values are not checked.
+type Diagnostic struct {
+	Code   errpos.Code
+	Line   int
+	Column int
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s at %d:%d", d.Code, d.Line, d.Column)
+}
+
+func (d Diagnostic) matches(err *errpos.Err) bool {
+	if d.Code != "" && d.Code != err.ErrorCode() {
+		return false
+	}
+	if d.Line == 0 && d.Column == 0 {
+		return true
+	}
+	if err.Pos == nil {
+		return false
+	}
+	if d.Line != 0 && d.Line != err.Pos.Start.Line+1 {
+		return false
+	}
+	if d.Column != 0 && d.Column != err.Pos.Start.Column+1 {
+		return false
+	}
+	return true
+}
+
+// AssertDiagnostics checks that the error contains exactly the expected
+// diagnostics, in any order.
+func AssertDiagnostics(t testing.TB, err error, want ...Diagnostic) {
+	t.Helper()
+
+	if err == nil {
+		if len(want) > 0 {
+			t.Errorf("expected diagnostics %v, got no error", want)
+		}
+		return
+	}
+
+	var errs errpos.Errors
+	if withSource, ok := errpos.AsErrorsWithSource(err); ok {
+		errs = withSource.Errors
+	} else if asErrs, ok := errpos.AsErrors(err); ok {
+		errs = asErrs
+	} else {
+		t.Fatalf("expected positioned errors, got %T: %s", err, err)
+	}
+
+	remaining := append(errpos.Errors{}, errs...)
+	for _, wantDiag := range want {
+		found := false
+		for idx, got := range remaining {
+			if wantDiag.matches(got) {
+				remaining = append(remaining[:idx], remaining[idx+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing diagnostic %s", wantDiag)
+		}
+	}
+	for _, got := range remaining {
+		t.Errorf("unexpected diagnostic %s: %s", got.ErrorCode(), got.Error())
+	}
+}
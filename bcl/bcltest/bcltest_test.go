@@ -0,0 +1,132 @@
+package bcltest
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func testParser(t testing.TB) *bcl.Parser {
+	parser, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parser
+}
+
+func TestGolden(t *testing.T) {
+	parser := testParser(t)
+
+	for _, golden := range []string{"testdata/file.golden.json", "testdata/file.golden.txt"} {
+		msg := &test_pb.File{}
+		ParseFile(t, parser, "testdata/file.bcl", msg)
+		AssertGolden(t, msg, golden)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	parser := testParser(t)
+	msg := &test_pb.File{}
+	_, err := parser.ParseFile("in.bcl", "sString = \"a\"\nunknown = 1\n", msg.ProtoReflect())
+	AssertDiagnostics(t, err, Diagnostic{
+		Code:   errpos.CodeUnknownBlock,
+		Line:   2,
+		Column: 1,
+	})
+}
+
+func TestExample(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		spec *bcl_j5pb.Schema
+		msg  func() proto.Message
+	}{{
+		name: "test file",
+		spec: &bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+			}},
+		},
+		msg: func() proto.Message { return &test_pb.File{} },
+	}, {
+		name: "schema file",
+		spec: &bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "j5.bcl.v1.Block",
+				Name:       &bcl_j5pb.Tag{FieldName: "schemaName"},
+			}},
+		},
+		msg: func() proto.Message { return &bcl_j5pb.SchemaFile{} },
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := bcl.NewParser(tc.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			desc := tc.msg().ProtoReflect().Descriptor()
+
+			opts := []ExampleOptions{{}}
+			for seed := int64(0); seed < 20; seed++ {
+				opts = append(opts, ExampleOptions{Rand: rand.New(rand.NewSource(seed))})
+			}
+			for _, opt := range opts {
+				example, err := Example(tc.spec, desc, opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ParseString(t, parser, "example.bcl", example, tc.msg())
+			}
+		})
+	}
+}
+
+// marshalTestFile writes files as the encoder of the parser writes them.
+func marshalTestFile(parser *bcl.Parser) MarshalFunc {
+	return func(msg proto.Message) (string, error) {
+		out := &strings.Builder{}
+		err := parser.NewEncoder(out, "").Encode(msg)
+		return out.String(), err
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+		}},
+	}
+
+	parser := testParser(t)
+	AssertRoundTrip(t, parser, marshalTestFile(parser), &test_pb.File{
+		SString: "a \"quoted\" value",
+		RString: []string{"a", "b"},
+		Tags:    map[string]string{"k": "v"},
+	})
+	CheckRoundTrips(t, spec, marshalTestFile(parser), &test_pb.File{}, 10)
+}
+
+func TestLoadFixture(t *testing.T) {
+	msg := &test_pb.File{}
+	LoadFixture(t, "testdata/file.bcl", msg)
+	AssertGolden(t, msg, "testdata/file.golden.txt")
+}
+
+func TestLoadFixtureParallel(t *testing.T) {
+	for idx := range 4 {
+		t.Run(strconv.Itoa(idx), func(t *testing.T) {
+			t.Parallel()
+			LoadFixture(t, "testdata/file.bcl", &test_pb.File{})
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package bcltest
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ExampleOptions controls the files built by Example.
+type ExampleOptions struct {
+	// Rand, when set, picks random values, a random subset of optional fields
+	// and a random oneof member. When nil, the example is exhaustive and
+	// deterministic: every field is set, and arrays of oneofs get one element
+	// for each member.
+	Rand *rand.Rand
+
+	// MaxDepth stops recursive schemas, default 5.
+	MaxDepth int
+}
+
+// Example builds a valid BCL file for the root message using the block specs
+// in spec, for fuzzing systems which consume the files and for documentation.
+//
+// Blocks with a qualifier, scalar split or type select tag are only given the
+// tags which are required, the generated values are placeholders which do not
+// satisfy validation rules such as patterns.
+func Example(spec *bcl_j5pb.Schema, root protoreflect.MessageDescriptor, opts ExampleOptions) (string, error) {
+	rootSchema, err := j5schema.NewSchemaCache().Schema(root)
+	if err != nil {
+		return "", err
+	}
+	obj, ok := rootSchema.(*j5schema.ObjectSchema)
+	if !ok {
+		return "", fmt.Errorf("root schema %s is not an object", root.FullName())
+	}
+
+	if opts.MaxDepth == 0 {
+		opts.MaxDepth = 5
+	}
+
+	gen := &exampleGen{
+		opts:   opts,
+		blocks: map[string]*bcl_j5pb.Block{},
+		out:    &strings.Builder{},
+	}
+	for _, block := range spec.Blocks {
+		gen.blocks[block.SchemaName] = block
+	}
+
+	if err := gen.body(obj.Properties, gen.blocks[obj.FullName()], 0); err != nil {
+		return "", err
+	}
+	return gen.out.String(), nil
+}
+
+type exampleGen struct {
+	opts    ExampleOptions
+	blocks  map[string]*bcl_j5pb.Block
+	out     *strings.Builder
+	counter int
+}
+
+func (g *exampleGen) line(depth int, format string, args ...interface{}) {
+	g.out.WriteString(strings.Repeat("\t", depth))
+	fmt.Fprintf(g.out, format, args...)
+	g.out.WriteString("\n")
+}
+
+func (g *exampleGen) include(prop *j5schema.ObjectProperty) bool {
+	if g.opts.Rand == nil || prop.Required {
+		return true
+	}
+	return g.opts.Rand.Intn(2) == 0
+}
+
+// blockTags resolves the header tags for a block of the schema, which is the
+// given block spec merged with the automatic 'name' and 'description' tags.
+type blockTags struct {
+	name        *bcl_j5pb.Tag
+	typeSelect  *bcl_j5pb.Tag
+	typeAttr    *bcl_j5pb.TypeAttribute
+	qualifier   *bcl_j5pb.Tag
+	description string
+	onlyDefined bool
+}
+
+func tagsFor(props j5schema.PropertySet, block *bcl_j5pb.Block) blockTags {
+	tags := blockTags{}
+	if block != nil {
+		tags.name = block.Name
+		tags.typeSelect = block.TypeSelect
+		tags.typeAttr = block.TypeAttribute
+		tags.qualifier = block.Qualifier
+		tags.description = block.GetDescriptionField()
+		tags.onlyDefined = block.OnlyExplicit
+	}
+	if tags.onlyDefined {
+		return tags
+	}
+	if prop := props.ByJSONName("name"); prop != nil && tags.name == nil && isString(prop.Schema) {
+		tags.name = &bcl_j5pb.Tag{FieldName: "name", Optional: !prop.Required}
+	}
+	if prop := props.ByJSONName("description"); prop != nil && tags.description == "" && isString(prop.Schema) {
+		tags.description = "description"
+	}
+	return tags
+}
+
+func isString(field j5schema.FieldSchema) bool {
+	scalar, ok := field.(*j5schema.ScalarSchema)
+	if !ok {
+		return false
+	}
+	_, ok = scalar.Proto.Type.(*schema_j5pb.Field_String_)
+	return ok
+}
+
+func (g *exampleGen) block(depth int, header string, field j5schema.FieldSchema) error {
+	var props j5schema.PropertySet
+	var schemaName string
+	switch ft := field.(type) {
+	case *j5schema.ObjectField:
+		props = ft.Schema().Properties
+		schemaName = ft.Schema().FullName()
+	case *j5schema.OneofField:
+		props = ft.Schema().Properties
+		schemaName = ft.Schema().FullName()
+	default:
+		return fmt.Errorf("%T is not a block", field)
+	}
+
+	block := g.blocks[schemaName]
+	tags := tagsFor(props, block)
+
+	if tags.name != nil {
+		g.counter++
+		header += fmt.Sprintf(" Name%d", g.counter)
+	}
+	if tags.typeSelect != nil && !tags.typeSelect.Optional {
+		member, err := firstMember(props, tags.typeSelect.FieldName)
+		if err != nil {
+			return fmt.Errorf("%s type select: %w", schemaName, err)
+		}
+		header += " " + member
+	}
+	if tags.qualifier != nil && !tags.qualifier.Optional {
+		return fmt.Errorf("%s: required qualifier tags are not supported", schemaName)
+	}
+
+	if depth >= g.opts.MaxDepth || tags.onlyDefined {
+		g.line(depth, "%s", header)
+		return nil
+	}
+
+	g.line(depth, "%s {", header)
+	if err := g.body(props, block, depth+1); err != nil {
+		return err
+	}
+	g.line(depth, "}")
+	return nil
+}
+
+func firstMember(props j5schema.PropertySet, fieldName string) (string, error) {
+	prop := props.ByJSONName(fieldName)
+	if prop == nil {
+		return "", fmt.Errorf("no field %q", fieldName)
+	}
+	container, ok := prop.Schema.AsContainer()
+	if !ok {
+		return "", fmt.Errorf("field %q is not a container", fieldName)
+	}
+	members, ok := container.(j5schema.PropertySet)
+	if !ok || len(members) == 0 {
+		return "", fmt.Errorf("field %q has no members", fieldName)
+	}
+	return members[0].JSONName, nil
+}
+
+func (g *exampleGen) body(props j5schema.PropertySet, block *bcl_j5pb.Block, depth int) error {
+	tags := tagsFor(props, block)
+	if tags.onlyDefined {
+		return nil
+	}
+
+	if tags.description != "" {
+		g.line(depth, "| Example description")
+	}
+	if tags.typeAttr != nil {
+		member, err := firstMember(props, tags.typeAttr.FieldName)
+		if err != nil {
+			return fmt.Errorf("type attribute: %w", err)
+		}
+		g.line(depth, "%s = %q", tags.typeAttr.Attribute, member)
+	}
+
+	for _, prop := range props {
+		name := prop.JSONName
+		if tags.name != nil && name == tags.name.FieldName {
+			continue
+		}
+		if tags.typeSelect != nil && name == tags.typeSelect.FieldName {
+			continue
+		}
+		if tags.typeAttr != nil && name == tags.typeAttr.FieldName {
+			continue
+		}
+		if name == tags.description {
+			continue
+		}
+		if !g.include(prop) {
+			continue
+		}
+		if err := g.property(depth, name, prop.Schema); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (g *exampleGen) property(depth int, name string, field j5schema.FieldSchema) error {
+	switch ft := field.(type) {
+	case *j5schema.ObjectField:
+		if ft.Schema().FullName() == "j5.bcl.v1.SourceLocation" {
+			return nil
+		}
+		return g.block(depth, name, ft)
+
+	case *j5schema.OneofField:
+		members := ft.Schema().Properties
+		if len(members) == 0 {
+			return nil
+		}
+		member := members[0]
+		if g.opts.Rand != nil {
+			member = members[g.opts.Rand.Intn(len(members))]
+		}
+		return g.property(depth, name+"."+member.JSONName, member.Schema)
+
+	case *j5schema.ArrayField:
+		return g.array(depth, name, ft)
+
+	case *j5schema.MapField:
+		if _, ok := ft.Schema.AsContainer(); ok {
+			return g.property(depth, name+".key1", ft.Schema)
+		}
+		value, ok := g.scalar(ft.Schema)
+		if !ok {
+			return nil
+		}
+		g.line(depth, "%s.key1 = %s", name, value)
+		return nil
+
+	case *j5schema.AnyField:
+		return nil
+
+	default:
+		value, ok := g.scalar(field)
+		if !ok {
+			return nil
+		}
+		g.line(depth, "%s = %s", name, value)
+		return nil
+	}
+}
+
+func (g *exampleGen) array(depth int, name string, field *j5schema.ArrayField) error {
+	switch items := field.Schema.(type) {
+	case *j5schema.ObjectField:
+		count := 1
+		if g.opts.Rand != nil {
+			count = g.opts.Rand.Intn(3)
+		}
+		for ; count > 0; count-- {
+			if err := g.block(depth, name, items); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *j5schema.OneofField:
+		members := items.Schema().Properties
+		if g.opts.Rand != nil {
+			picked := j5schema.PropertySet{}
+			for count := g.opts.Rand.Intn(3); count > 0 && len(members) > 0; count-- {
+				picked = append(picked, members[g.opts.Rand.Intn(len(members))])
+			}
+			members = picked
+		}
+		// Exhaustive gives one element for each member
+		for _, member := range members {
+			if err := g.property(depth, name+"."+member.JSONName, member.Schema); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *j5schema.ScalarSchema, *j5schema.EnumField:
+		count := 2
+		if g.opts.Rand != nil {
+			count = 1 + g.opts.Rand.Intn(3)
+		}
+		values := make([]string, 0, count)
+		for idx := 0; idx < count; idx++ {
+			value, ok := g.scalar(items)
+			if !ok {
+				return nil
+			}
+			values = append(values, value)
+		}
+		g.line(depth, "%s = [%s]", name, strings.Join(values, ", "))
+		return nil
+	}
+	return nil
+}
+
+func (g *exampleGen) scalar(field j5schema.FieldSchema) (string, bool) {
+	g.counter++
+	n := g.counter
+	if g.opts.Rand != nil {
+		n = g.opts.Rand.Intn(1000)
+	}
+
+	switch ft := field.(type) {
+	case *j5schema.EnumField:
+		options := ft.Schema().Options
+		if len(options) == 0 {
+			return "", false
+		}
+		idx := 0
+		if len(options) > 1 {
+			idx = 1 // skip UNSPECIFIED
+			if g.opts.Rand != nil {
+				idx = 1 + g.opts.Rand.Intn(len(options)-1)
+			}
+		}
+		return strconv.Quote(options[idx].Name()), true
+
+	case *j5schema.ScalarSchema:
+		switch ft.Proto.Type.(type) {
+		case *schema_j5pb.Field_String_:
+			return strconv.Quote(fmt.Sprintf("value%d", n)), true
+		case *schema_j5pb.Field_Bool:
+			return strconv.FormatBool(n%2 == 1), true
+		case *schema_j5pb.Field_Integer:
+			return strconv.Itoa(n), true
+		case *schema_j5pb.Field_Float:
+			return fmt.Sprintf("%d.5", n), true
+		case *schema_j5pb.Field_Decimal:
+			return strconv.Quote(fmt.Sprintf("%d.5", n)), true
+		case *schema_j5pb.Field_Key:
+			return strconv.Quote(fmt.Sprintf("00000000-0000-0000-0000-%012d", n)), true
+		case *schema_j5pb.Field_Date:
+			return strconv.Quote(fmt.Sprintf("2024-01-%02d", n%28+1)), true
+		case *schema_j5pb.Field_Timestamp:
+			return strconv.Quote(fmt.Sprintf("2024-01-%02dT00:00:00Z", n%28+1)), true
+		case *schema_j5pb.Field_Bytes:
+			return strconv.Quote("ZXhhbXBsZQ=="), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,49 @@
+package bcltest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// LoadFixture parses the BCL file at path into msg, with a schema derived from
+// the message itself, so proto fixtures can be written in BCL without a schema
+// file. Errors fail the test, rendered with the source lines. Each fixture
+// has a parser of its own, as parsers can't be shared by parallel tests.
+func LoadFixture(t testing.TB, path string, msg proto.Message) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := fixtureParser(msg.ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parser.ParseFile(path, string(data), msg.ProtoReflect())
+	if err == nil {
+		return
+	}
+	if withSource, ok := errpos.AsErrorsWithSource(err); ok {
+		t.Fatalf("loading fixture %s:\n%s", path, withSource.RenderString(errpos.RenderOptions{
+			ContextLines: 2,
+		}))
+	}
+	t.Fatalf("loading fixture %s: %s", path, err)
+}
+
+func fixtureParser(desc protoreflect.MessageDescriptor) (*bcl.Parser, error) {
+	return bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: string(desc.FullName()),
+		}},
+	})
+}
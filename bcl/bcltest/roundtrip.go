@@ -0,0 +1,73 @@
+package bcltest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MarshalFunc writes a message as a BCL file, the inverse of parsing.
+type MarshalFunc func(proto.Message) (string, error)
+
+// AssertRoundTrip checks that Parse(Marshal(msg)) reproduces msg.
+func AssertRoundTrip(t testing.TB, parser *bcl.Parser, marshal MarshalFunc, msg proto.Message) {
+	t.Helper()
+
+	written, err := marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %s", err)
+	}
+
+	got := msg.ProtoReflect().New().Interface()
+	if _, err := parser.ParseFile("roundtrip.bcl", written, got.ProtoReflect()); err != nil {
+		t.Fatalf("parsing marshalled message:\n%s\n%s", written, err.Error())
+	}
+
+	if !proto.Equal(msg, got) {
+		t.Errorf("round trip changed the message\nwritten:\n%s\ndiff:\n%s", written, diff(msg, got))
+	}
+}
+
+// AssertStable checks that Marshal(Parse(content)) parses to the same message
+// as content, i.e. that the writer doesn't lose anything the reader accepts.
+func AssertStable(t testing.TB, parser *bcl.Parser, marshal MarshalFunc, content string, empty proto.Message) {
+	t.Helper()
+
+	first := empty.ProtoReflect().New().Interface()
+	ParseString(t, parser, "input.bcl", content, first)
+	AssertRoundTrip(t, parser, marshal, first)
+}
+
+// CheckRoundTrips runs AssertStable over count random Example files for the
+// root message, seeded from 0 so that failures reproduce.
+func CheckRoundTrips(t *testing.T, spec *bcl_j5pb.Schema, marshal MarshalFunc, empty proto.Message, count int) {
+	t.Helper()
+
+	parser, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := empty.ProtoReflect().Descriptor()
+	for seed := int64(0); seed < int64(count); seed++ {
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			example, err := Example(spec, desc, ExampleOptions{
+				Rand: rand.New(rand.NewSource(seed)),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertStable(t, parser, marshal, example, empty)
+		})
+	}
+}
+
+func diff(want, got proto.Message) string {
+	wantText, _ := marshalGolden(want, "want.txt")
+	gotText, _ := marshalGolden(got, "got.txt")
+	return fmt.Sprintf("want:\n%s\ngot:\n%s", wantText, gotText)
+}
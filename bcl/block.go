@@ -0,0 +1,62 @@
+package bcl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// blockFilename names the source of ParseBlock in error positions.
+const blockFilename = "<block>"
+
+// ParseBlock parses the body of a single block, e.g. 'name = "A"', into a new
+// message of the named schema, without the rest of a file around it, for
+// forms and snippets. The schema is a j5 name, as in BlockSpec, and the
+// message type is found with AnyTypes. Policies and validation of the block
+// apply as they would in a file.
+func (p *Parser) ParseBlock(src string, schemaName string) (proto.Message, error) {
+	mt, err := p.SchemaType(schemaName)
+	if err != nil {
+		err = errpos.WithCode(err, errpos.CodeUnknownSchema)
+		err = errpos.AddPosition(err, errpos.Position{})
+		return nil, errpos.AddSourceFile(err, blockFilename, src)
+	}
+	msg := mt.New()
+	if _, err := p.Parse(blockFilename, src, msg); err != nil {
+		return nil, err
+	}
+	return msg.Interface(), nil
+}
+
+// SchemaType resolves a j5 schema name, which joins nested messages with '_',
+// to its message type with AnyTypes.
+func (p *Parser) SchemaType(schemaName string) (protoreflect.MessageType, error) {
+	resolver := p.AnyTypes
+	if resolver == nil {
+		resolver = protoregistry.GlobalTypes
+	}
+	mt, err := resolver.FindMessageByName(protoreflect.FullName(schemaName))
+	if err == nil {
+		return mt, nil
+	} else if !errors.Is(err, protoregistry.NotFound) {
+		return nil, err
+	}
+
+	if types, ok := resolver.(*protoregistry.Types); ok {
+		types.RangeMessages(func(found protoreflect.MessageType) bool {
+			if j5SchemaName(found.Descriptor()) == schemaName {
+				mt = found
+				return false
+			}
+			return true
+		})
+		if mt != nil {
+			return mt, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown schema %s", schemaName)
+}
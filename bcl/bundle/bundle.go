@@ -0,0 +1,224 @@
+// Package bundle packs a BCL project and its compiled schema into a single zip
+// archive, for shipping configs to targets without the source tree.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Version is the bundle format version written by Write.
+const Version = 1
+
+const (
+	ManifestFilename = "manifest.json"
+	schemaFilename   = "schema.bin"
+	filesDir         = "files"
+)
+
+// Manifest lists the contents of a bundle.
+type Manifest struct {
+	Version int    `json:"version"`
+	Files   []File `json:"files"`
+}
+
+// File is a project file in the bundle, by its slash separated path relative
+// to the project root.
+type File struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// Write packs the named files from root, and the schema, as a bundle.
+func Write(w io.Writer, schema *bcl.CompiledSchema, root fs.FS, files []string) error {
+	schemaData, err := schema.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := Manifest{
+		Version: Version,
+		Files:   make([]File, 0, len(files)),
+	}
+
+	for _, pathname := range files {
+		if !fs.ValidPath(pathname) {
+			return fmt.Errorf("invalid file path %q", pathname)
+		}
+		data, err := fs.ReadFile(root, pathname)
+		if err != nil {
+			return err
+		}
+		if err := writeEntry(zw, path.Join(filesDir, pathname), data); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, File{
+			Path:   pathname,
+			SHA256: checksum(data),
+		})
+	}
+
+	if err := writeEntry(zw, schemaFilename, schemaData); err != nil {
+		return err
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeEntry(zw, ManifestFilename, manifestData); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func writeEntry(zw *zip.Writer, name string, data []byte) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Bundle is a loaded bundle.
+type Bundle struct {
+	Manifest Manifest
+	Schema   *bcl.CompiledSchema
+
+	files fs.FS
+}
+
+// Open reads the bundle file into memory.
+func Open(filename string) (*Bundle, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Read(bytes.NewReader(data), int64(len(data)))
+}
+
+// Read loads a bundle written by Write.
+func Read(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle: %w", err)
+	}
+
+	manifestData, err := fs.ReadFile(zr, ManifestFilename)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle manifest: %w", err)
+	}
+	bundle := &Bundle{}
+	if err := json.Unmarshal(manifestData, &bundle.Manifest); err != nil {
+		return nil, fmt.Errorf("reading bundle manifest: %w", err)
+	}
+	if bundle.Manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Manifest.Version)
+	}
+
+	schemaData, err := fs.ReadFile(zr, schemaFilename)
+	if err != nil {
+		return nil, fmt.Errorf("reading bundle schema: %w", err)
+	}
+	bundle.Schema = &bcl.CompiledSchema{}
+	if err := bundle.Schema.UnmarshalBinary(schemaData); err != nil {
+		return nil, err
+	}
+
+	bundle.files, err = fs.Sub(zr, filesDir)
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// FS returns the project files, by their path in the manifest.
+func (b *Bundle) FS() fs.FS {
+	return b.files
+}
+
+// Resolver returns a resolver confined to the project files.
+func (b *Bundle) Resolver() project.Resolver {
+	return project.NewFSResolver(b.files)
+}
+
+// ReadFile returns the content of a project file, checking it against the
+// manifest.
+func (b *Bundle) ReadFile(pathname string) ([]byte, error) {
+	for _, file := range b.Manifest.Files {
+		if file.Path != pathname {
+			continue
+		}
+		data, err := fs.ReadFile(b.files, pathname)
+		if err != nil {
+			return nil, err
+		}
+		if checksum(data) != file.SHA256 {
+			return nil, fmt.Errorf("%s: checksum does not match the manifest", pathname)
+		}
+		return data, nil
+	}
+	return nil, &fs.PathError{Op: "read", Path: pathname, Err: fs.ErrNotExist}
+}
+
+// ParsedFile is the result of parsing one file of the bundle.
+type ParsedFile struct {
+	Path           string
+	Message        protoreflect.Message
+	SourceLocation *bcl_j5pb.SourceLocation
+}
+
+// ParseAll parses every file in the manifest with the bundled schema, into a
+// message from newMsg. Errors are returned as errpos.Diagnostics, grouped by
+// the file they came from, after all files are parsed.
+func (b *Bundle) ParseAll(newMsg func(pathname string) protoreflect.Message) ([]ParsedFile, error) {
+	parser, err := bcl.NewCompiledParser(b.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]ParsedFile, 0, len(b.Manifest.Files))
+	diags := &errpos.Diagnostics{}
+	for _, file := range b.Manifest.Files {
+		data, err := b.ReadFile(file.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		msg := newMsg(file.Path)
+		locs, err := parser.ParseFile(file.Path, string(data), msg)
+		if err != nil {
+			diags.Add(file.Path, err)
+			continue
+		}
+		parsed = append(parsed, ParsedFile{
+			Path:           file.Path,
+			Message:        msg,
+			SourceLocation: locs,
+		})
+	}
+
+	return parsed, diags.Err()
+}
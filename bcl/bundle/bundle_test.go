@@ -0,0 +1,68 @@
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestBundle(t *testing.T) {
+	schema, err := bcl.CompileSchema(&bcl_j5pb.Schema{}, (&test_pb.File{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := fstest.MapFS{
+		"a.bcl":     {Data: []byte("sString = \"a\"\n")},
+		"sub/b.bcl": {Data: []byte("sString = \"b\"\nunknown = 1\n")},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Write(buf, schema, root, []string{"a.bcl", "sub/b.bcl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, bundle.Manifest.Files, 2)
+
+	parsed, err := bundle.ParseAll(func(string) protoreflect.Message {
+		return (&test_pb.File{}).ProtoReflect()
+	})
+	if len(parsed) != 1 {
+		t.Fatalf("expected one good file, got %d", len(parsed))
+	}
+	assert.Equal(t, "a.bcl", parsed[0].Path)
+	assert.Equal(t, "a", parsed[0].Message.Interface().(*test_pb.File).SString)
+
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok || len(withSource.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", err)
+	}
+	pos := withSource.Errors[0].Pos
+	if pos == nil || pos.Filename == nil {
+		t.Fatalf("expected a file position, got %v", withSource.Errors[0])
+	}
+	assert.Equal(t, "sub/b.bcl", *pos.Filename)
+	assert.Equal(t, 1, pos.Start.Line)
+
+	resolved, err := bundle.Resolver().Resolve("sub/b.bcl", "../a.bcl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a.bcl", resolved)
+
+	_, err = bundle.ReadFile("missing.bcl")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+}
@@ -0,0 +1,31 @@
+package bcl
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// canonicalFilename names the source of Canonical in error positions.
+const canonicalFilename = "<canonical>"
+
+// Canonical parses src as a file of the parser's schema into a message of
+// msgType and writes the message back as BCL in a normal form, so two files
+// which set the same values compare equal byte for byte. The message is
+// written as the Encoder writes a block body, by the tags and aliases of the
+// schema in field number order, with map keys sorted. Blocks whose spec is
+// unordered have their attributes sorted by name and their repeated fields
+// sorted, while other repeated fields keep their order. Scalars equal to the
+// default of their field aren't written, and literals are written one way, so
+// `a` and "a" are the same. Defaults blocks, splats and the other sugar of the
+// file are resolved, and comments are dropped.
+func (p *Parser) Canonical(src []byte, msgType protoreflect.MessageType) ([]byte, error) {
+	msg := msgType.New()
+	if _, err := p.ParseFile(canonicalFilename, string(src), msg); err != nil {
+		return nil, err
+	}
+	enc := newBlockEncoder(p)
+	enc.canonical = true
+	if err := enc.root(msg); err != nil {
+		return nil, err
+	}
+	return []byte(enc.out.String()), nil
+}
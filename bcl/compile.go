@@ -0,0 +1,60 @@
+package bcl
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"github.com/pentops/j5/lib/j5schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CompiledSchema is a schema with the block specs for a set of root messages
+// already derived. It can be marshaled at build time and loaded with
+// UnmarshalBinary, then passed to NewCompiledParser.
+type CompiledSchema struct {
+	set *schema.SchemaSet
+}
+
+// CompileSchema derives the block specs of every message reachable from the
+// roots.
+func CompileSchema(schemaSpec *bcl_j5pb.Schema, roots ...protoreflect.MessageDescriptor) (*CompiledSchema, error) {
+	ss, err := schema.NewSchemaSet(schemaSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := j5schema.NewSchemaCache()
+	rootSchemas := make([]j5schema.RootSchema, 0, len(roots))
+	for _, root := range roots {
+		rootSchema, err := cache.Schema(root)
+		if err != nil {
+			return nil, fmt.Errorf("schema for %s: %w", root.FullName(), err)
+		}
+		rootSchemas = append(rootSchemas, rootSchema)
+	}
+
+	if err := ss.Precompile(rootSchemas...); err != nil {
+		return nil, err
+	}
+	return &CompiledSchema{set: ss}, nil
+}
+
+func (cs *CompiledSchema) MarshalBinary() ([]byte, error) {
+	if cs.set == nil {
+		return nil, fmt.Errorf("compiled schema is empty")
+	}
+	return cs.set.MarshalBinary()
+}
+
+func (cs *CompiledSchema) UnmarshalBinary(data []byte) error {
+	ss, err := schema.NewSchemaSet(&bcl_j5pb.Schema{})
+	if err != nil {
+		return err
+	}
+	if err := ss.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	cs.set = ss
+	return nil
+}
@@ -0,0 +1,32 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/internal/walker"
+)
+
+// ComputeFunc produces the value of a computed child of a block, as the Go
+// type its scalar field is set from: string, int64, float64, bool, or
+// time.Time for a timestamp.
+type ComputeFunc = walker.ComputeFunc
+
+// ComputeContext is the block a computed child is produced for, with the
+// file, the block's schema, its path of field names and its position.
+type ComputeContext = walker.ComputeContext
+
+// RegisterComputed makes a scalar field, by its JSON name, a computed child of
+// every block of the schema, and of the file itself when it is the root. The
+// function is called as each block closes, and its value is set unless the
+// file set the field, e.g. for a generatedAt timestamp or a sequence number.
+// The field's source location is the block's, with ORIGIN_SYNTHESIZED.
+//
+// A failed call fails the parse with errpos.CodeFunction at the block.
+// Children are computed in the order they were registered.
+func (p *Parser) RegisterComputed(schemaName, field string, fn ComputeFunc) {
+	if p.computed == nil {
+		p.computed = map[string][]walker.ComputedChild{}
+	}
+	p.computed[schemaName] = append(p.computed[schemaName], walker.ComputedChild{
+		Name:    field,
+		Compute: fn,
+	})
+}
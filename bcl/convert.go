@@ -0,0 +1,40 @@
+package bcl
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+// Format is an output encoding for parsed messages.
+type Format string
+
+const (
+	FormatJSON      Format = "json"
+	FormatPrototext Format = "prototext"
+	FormatBinary    Format = "binary" // proto wire format
+)
+
+// Marshal encodes a parsed message in the format, so that BCL can be used to
+// author JSON, prototext or binary proto fixtures. Sensitive fields are
+// Redacted, see MarshalUnredacted for output which needs their values.
+func Marshal(msg proto.Message, format Format) ([]byte, error) {
+	return MarshalUnredacted(redact(msg), format)
+}
+
+// MarshalUnredacted encodes the message as Marshal, including the values of
+// sensitive fields.
+func MarshalUnredacted(msg proto.Message, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return protojson.MarshalOptions{Multiline: true}.Marshal(msg)
+	case FormatPrototext:
+		return prototext.MarshalOptions{Multiline: true}.Marshal(msg)
+	case FormatBinary:
+		return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,60 @@
+package bcl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// Decrypter decrypts the ciphertext of enc"..." values when a file is parsed,
+// so that secrets can be kept in the repository next to the rest of the
+// config, e.g. encrypted with age or a KMS key. Errors must not include the
+// plaintext.
+//
+// Decrypted values are kept out of parse errors and logs, as secret() values
+// are.
+type Decrypter interface {
+	Decrypt(ciphertext string) (string, error)
+}
+
+// DecrypterFunc adapts a function to a Decrypter.
+type DecrypterFunc func(ciphertext string) (string, error)
+
+func (fn DecrypterFunc) Decrypt(ciphertext string) (string, error) {
+	return fn(ciphertext)
+}
+
+// DecrypterPrefixes decrypts each ciphertext with the decrypter for the
+// longest prefix it starts with, e.g. "AGE-" for armored age ciphertext.
+type DecrypterPrefixes map[string]Decrypter
+
+func (dp DecrypterPrefixes) Decrypt(ciphertext string) (string, error) {
+	var best string
+	var decrypter Decrypter
+	for prefix, candidate := range dp {
+		if strings.HasPrefix(ciphertext, prefix) && (decrypter == nil || len(prefix) > len(best)) {
+			best, decrypter = prefix, candidate
+		}
+	}
+	if decrypter == nil {
+		return "", fmt.Errorf("no decrypter for the ciphertext")
+	}
+	return decrypter.Decrypt(ciphertext)
+}
+
+// decryptFunction returns the enc function, which enc"..." values call. It
+// isn't subject to the allowlist, as it is not written as a call.
+func (p *Parser) decryptFunction() (Function, error) {
+	if p.Decrypter == nil {
+		return Function{}, fmt.Errorf("%s\"...\" values need a decrypter, none is configured", parser.EncryptedPrefix)
+	}
+	return Function{
+		Params:    []ValueType{StringType},
+		Result:    StringType,
+		Sensitive: true,
+		Call: func(args []interface{}) (interface{}, error) {
+			return p.Decrypter.Decrypt(args[0].(string))
+		},
+	}, nil
+}
@@ -0,0 +1,390 @@
+package bcl
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DeriveFunc produces the value of a derived field, as the Go type its scalar
+// field is set from: string, bool, int64, uint64, float64 or []byte, or the
+// name or number of an enum value.
+type DeriveFunc func(DeriveContext) (interface{}, error)
+
+// DeriveContext is the message a field is derived for.
+type DeriveContext struct {
+	Filename string
+	Schema   string
+
+	// This is the message of the block, with its own fields set, and Parent
+	// the message holding it, nil at the root. The parent's derived fields
+	// are already set.
+	This   protoreflect.Message
+	Parent protoreflect.Message
+}
+
+// RegisterDerived makes a scalar field of the schema, by its JSON name,
+// derived by the function, after the derived fields of the block spec. Call it
+// before parsing.
+func (p *Parser) RegisterDerived(schemaName, field string, fn DeriveFunc) {
+	p.derived.register(schemaName, field, fn)
+	for _, route := range p.routes {
+		route.derived.register(schemaName, field, fn)
+	}
+}
+
+// derivedSet compiles the derived fields of the schema against the message
+// types as they are first seen, with the type of their parent.
+type derivedSet struct {
+	derived  map[string][]schema.Derived
+	hooks    map[string][]derivedHook
+	compiled programCache[derivedKey, []derivedField]
+}
+
+type derivedHook struct {
+	field string
+	fn    DeriveFunc
+}
+
+type derivedKey struct {
+	this   protoreflect.FullName
+	parent protoreflect.FullName // empty at the root
+}
+
+type derivedField struct {
+	field      protoreflect.FieldDescriptor
+	expression string
+	program    cel.Program // nil for a hook
+	hook       DeriveFunc
+}
+
+func newDerivedSet(ss *schema.SchemaSet) (*derivedSet, error) {
+	derived := ss.Derived()
+	env, err := cel.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+	for name, list := range derived {
+		for _, field := range list {
+			if _, iss := env.Parse(field.Expression); iss.Err() != nil {
+				return nil, fmt.Errorf("derived %s.%s: %w", name, field.Field, iss.Err())
+			}
+		}
+	}
+	return &derivedSet{
+		derived: derived,
+		hooks:   map[string][]derivedHook{},
+	}, nil
+}
+
+func (ds *derivedSet) register(schemaName, field string, fn DeriveFunc) {
+	ds.hooks[schemaName] = append(ds.hooks[schemaName], derivedHook{field: field, fn: fn})
+	ds.compiled.reset()
+}
+
+func (ds *derivedSet) fields(desc, parent protoreflect.MessageDescriptor) ([]derivedField, error) {
+	key := derivedKey{this: desc.FullName()}
+	if parent != nil {
+		key.parent = parent.FullName()
+	}
+	return ds.compiled.get(key, func() ([]derivedField, error) {
+		return ds.compile(desc, parent)
+	})
+}
+
+func (ds *derivedSet) compile(desc, parent protoreflect.MessageDescriptor) ([]derivedField, error) {
+	name := j5SchemaName(desc)
+	derived := ds.derived[name]
+	hooks := ds.hooks[name]
+	if len(derived) == 0 && len(hooks) == 0 {
+		return nil, nil
+	}
+
+	fields := make([]derivedField, 0, len(derived)+len(hooks))
+	var env *cel.Env
+	for _, spec := range derived {
+		field, err := derivedFieldByName(desc, spec.Field)
+		if err != nil {
+			return nil, err
+		}
+		if env == nil {
+			env, err = derivedEnv(desc, parent)
+			if err != nil {
+				return nil, err
+			}
+		}
+		ast, iss := env.Compile(spec.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("derived %s.%s: %w", desc.FullName(), spec.Field, iss.Err())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("derived %s.%s: %w", desc.FullName(), spec.Field, err)
+		}
+		fields = append(fields, derivedField{field: field, expression: spec.Expression, program: program})
+	}
+	for _, hook := range hooks {
+		field, err := derivedFieldByName(desc, hook.field)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, derivedField{field: field, hook: hook.fn})
+	}
+	return fields, nil
+}
+
+func derivedEnv(desc, parent protoreflect.MessageDescriptor) (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.TypeDescs(desc.ParentFile()),
+		cel.Variable("this", cel.ObjectType(string(desc.FullName()))),
+		cel.Variable("file", cel.StringType),
+	}
+	if parent != nil {
+		opts = append(opts,
+			cel.TypeDescs(parent.ParentFile()),
+			cel.Variable("parent", cel.ObjectType(string(parent.FullName()))),
+		)
+	} else {
+		opts = append(opts, cel.Variable("parent", cel.NullType))
+	}
+	return cel.NewEnv(opts...)
+}
+
+func derivedFieldByName(desc protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, error) {
+	field := desc.Fields().ByJSONName(name)
+	if field == nil {
+		field = desc.Fields().ByName(protoreflect.Name(name))
+	}
+	if field == nil {
+		return nil, fmt.Errorf("derived field %s.%s does not exist", desc.FullName(), name)
+	}
+	if field.IsList() || field.IsMap() || field.Message() != nil {
+		return nil, fmt.Errorf("derived field %s.%s is not a singular scalar", desc.FullName(), name)
+	}
+	return field, nil
+}
+
+// deriveFields sets the derived fields of every message in the file, parents
+// before their children, reporting failures at the block of the message.
+func (p *Parser) deriveFields(filename string, msg protoreflect.Message, source *bcl_j5pb.SourceLocation, suppressions parser.Suppressions) error {
+	if len(p.derived.derived) == 0 && len(p.derived.hooks) == 0 {
+		return nil
+	}
+	if source == nil {
+		source = &bcl_j5pb.SourceLocation{}
+	}
+	base := &baseSet{}
+	err := visitMessages(msg, source, func(node messageNode) error {
+		return p.derived.derive(filename, node, base)
+	})
+	if err != nil {
+		return err
+	}
+	errs := suppressions.Filter(base.errors)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// derive sets the derived fields of the message of the node, recording
+// them in its source location.
+func (ds *derivedSet) derive(filename string, node messageNode, base *baseSet) error {
+	msg, parent := node.msg, node.parent
+	var parentDesc protoreflect.MessageDescriptor
+	if parent != nil {
+		parentDesc = parent.Descriptor()
+	}
+	fields, err := ds.fields(msg.Descriptor(), parentDesc)
+	if err != nil {
+		return err
+	}
+	sources := node.sources(base)
+	for _, df := range fields {
+		jsonName := df.field.JSONName()
+		if msg.Has(df.field) {
+			node.child(jsonName).sources(base).err(&errpos.Err{
+				Code: errpos.CodeDerived,
+				Err:  fmt.Errorf("%s is derived, it can't be set in the file", jsonName),
+			})
+			continue
+		}
+		if err := df.set(filename, msg, parent); err != nil {
+			sources.err(&errpos.Err{
+				Code: errpos.CodeDerived,
+				Err:  fmt.Errorf("derived %s: %w", jsonName, err),
+			})
+			continue
+		}
+		if node.loc == nil {
+			continue
+		}
+		if node.loc.Children == nil {
+			node.loc.Children = map[string]*bcl_j5pb.SourceLocation{}
+		}
+		node.loc.Children[jsonName] = &bcl_j5pb.SourceLocation{
+			StartLine:   node.loc.StartLine,
+			StartColumn: node.loc.StartColumn,
+			EndLine:     node.loc.EndLine,
+			EndColumn:   node.loc.EndColumn,
+			Origin:      bcl_j5pb.Origin_ORIGIN_DERIVED,
+		}
+	}
+	return nil
+}
+
+func (df derivedField) set(filename string, msg, parent protoreflect.Message) error {
+	value, err := df.eval(filename, msg, parent)
+	if err != nil {
+		return err
+	}
+	converted, err := derivedValue(df.field, value)
+	if err != nil {
+		return err
+	}
+	msg.Set(df.field, converted)
+	return nil
+}
+
+func (df derivedField) eval(filename string, msg, parent protoreflect.Message) (interface{}, error) {
+	if df.hook != nil {
+		return df.hook(DeriveContext{
+			Filename: filename,
+			Schema:   j5SchemaName(msg.Descriptor()),
+			This:     msg,
+			Parent:   parent,
+		})
+	}
+	var parentValue any = types.NullValue
+	if parent != nil {
+		parentValue = parent.Interface()
+	}
+	out, _, err := df.program.Eval(map[string]any{
+		"this":   msg.Interface(),
+		"parent": parentValue,
+		"file":   filename,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", df.expression, err)
+	}
+	return out.Value(), nil
+}
+
+// derivedValue converts the value of an expression or hook to the kind of the
+// field.
+func derivedValue(fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if str, ok := value.(string); ok {
+			return protoreflect.ValueOfString(str), nil
+		}
+
+	case protoreflect.BoolKind:
+		if b, ok := value.(bool); ok {
+			return protoreflect.ValueOfBool(b), nil
+		}
+
+	case protoreflect.BytesKind:
+		switch bv := value.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(bv), nil
+		case string:
+			return protoreflect.ValueOfBytes([]byte(bv)), nil
+		}
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if num, ok := derivedInt(value); ok && num >= math.MinInt32 && num <= math.MaxInt32 {
+			return protoreflect.ValueOfInt32(int32(num)), nil
+		}
+
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if num, ok := derivedInt(value); ok {
+			return protoreflect.ValueOfInt64(num), nil
+		}
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if num, ok := derivedUint(value); ok && num <= math.MaxUint32 {
+			return protoreflect.ValueOfUint32(uint32(num)), nil
+		}
+
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if num, ok := derivedUint(value); ok {
+			return protoreflect.ValueOfUint64(num), nil
+		}
+
+	case protoreflect.FloatKind:
+		if num, ok := derivedFloat(value); ok {
+			return protoreflect.ValueOfFloat32(float32(num)), nil
+		}
+
+	case protoreflect.DoubleKind:
+		if num, ok := derivedFloat(value); ok {
+			return protoreflect.ValueOfFloat64(num), nil
+		}
+
+	case protoreflect.EnumKind:
+		if name, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(name)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+			return protoreflect.Value{}, fmt.Errorf("%q is not a value of %s", name, fd.Enum().FullName())
+		}
+		if num, ok := derivedInt(value); ok && num >= math.MinInt32 && num <= math.MaxInt32 {
+			return protoreflect.ValueOfEnum(protoreflect.EnumNumber(num)), nil
+		}
+	}
+	return protoreflect.Value{}, fmt.Errorf("%v (%T) can't be set on a %s field", value, value, fd.Kind())
+}
+
+func derivedInt(value interface{}) (int64, bool) {
+	switch nv := value.(type) {
+	case int64:
+		return nv, true
+	case int:
+		return int64(nv), true
+	case int32:
+		return int64(nv), true
+	case uint64:
+		if nv <= math.MaxInt64 {
+			return int64(nv), true
+		}
+	}
+	return 0, false
+}
+
+func derivedFloat(value interface{}) (float64, bool) {
+	switch nv := value.(type) {
+	case float64:
+		return nv, true
+	case float32:
+		return float64(nv), true
+	default:
+		if num, ok := derivedInt(value); ok {
+			return float64(num), true
+		}
+	}
+	return 0, false
+}
+
+func derivedUint(value interface{}) (uint64, bool) {
+	switch nv := value.(type) {
+	case uint64:
+		return nv, true
+	case uint:
+		return uint64(nv), true
+	case uint32:
+		return uint64(nv), true
+	default:
+		if num, ok := derivedInt(value); ok && num >= 0 {
+			return uint64(num), true
+		}
+	}
+	return 0, false
+}
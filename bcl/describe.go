@@ -0,0 +1,361 @@
+package bcl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DocFormat is an output format for a SchemaDoc.
+type DocFormat string
+
+const (
+	DocFormatText     DocFormat = "text"
+	DocFormatMarkdown DocFormat = "markdown"
+	DocFormatJSON     DocFormat = "json"
+)
+
+// SchemaDoc is a reference for the language of a schema: every block which
+// can be written in a file, with the names usable inside it.
+type SchemaDoc struct {
+	Root string `json:"root"`
+
+	// Blocks are ordered from the root, each after the block it is first
+	// found in.
+	Blocks []*BlockDoc `json:"blocks"`
+}
+
+// BlockDoc describes the statements allowed in one block schema.
+type BlockDoc struct {
+	Schema string `json:"schema"`
+
+	// The fields set by the tags of the block, e.g. `foo <name> { ... }`,
+	// empty when the block has no such tag.
+	NameTag       string `json:"nameTag,omitempty"`
+	TypeSelectTag string `json:"typeSelectTag,omitempty"`
+	QualifierTag  string `json:"qualifierTag,omitempty"`
+
+	// TypeAttribute is the attribute of the body which selects the type of
+	// the block, e.g. `kind = "http"`.
+	TypeAttribute string `json:"typeAttribute,omitempty"`
+
+	// DescriptionField is set by the block's doc comment.
+	DescriptionField string `json:"descriptionField,omitempty"`
+
+	Children []ChildDoc `json:"children"`
+}
+
+// ChildDoc describes a name usable in a block.
+type ChildDoc struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Attribute   bool   `json:"attribute,omitempty"`
+	Block       bool   `json:"block,omitempty"`
+	Flag        bool   `json:"flag,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+	Constraint  string `json:"constraint,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Default is the value the field takes when the file doesn't set it, as
+	// it would be written, for fields with an explicit default in the proto.
+	Default string `json:"default,omitempty"`
+
+	// Schema is the block schema opened by the name, described among the
+	// blocks of the doc.
+	Schema string `json:"schema,omitempty"`
+}
+
+// DescribeSchema builds the reference of the files parsed into root with the
+// block specs of schemaSpec.
+func DescribeSchema(schemaSpec *bcl_j5pb.Schema, root protoreflect.MessageDescriptor) (*SchemaDoc, error) {
+	parser, err := NewParser(schemaSpec)
+	if err != nil {
+		return nil, err
+	}
+	rootScope, err := parser.ScopeAt(root)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &SchemaDoc{
+		Root: rootScope.SchemaName(),
+	}
+	messages := map[string]protoreflect.MessageDescriptor{}
+	collectMessages(root, messages)
+	seen := map[string]bool{doc.Root: true}
+	queue := []*schema.Scope{rootScope.scope}
+	for len(queue) > 0 {
+		scope := queue[0]
+		queue = queue[1:]
+
+		block := newBlockDoc(scope)
+		desc := messages[block.Schema]
+		for _, child := range scope.Children() {
+			childDoc := ChildDoc{
+				Name:        child.Name,
+				Type:        child.Type,
+				Attribute:   child.Attribute,
+				Block:       child.Block,
+				Flag:        child.Flag,
+				Required:    child.Required,
+				Constraint:  describeConstraint(child.Constraint),
+				Description: child.Description,
+			}
+			if desc != nil {
+				childDoc.Default = describeDefault(desc.Fields().ByJSONName(child.Name))
+			}
+			if child.Block {
+				childScope, walkErr := scope.ChildBlock(child.Name, errpos.Position{})
+				if walkErr == nil {
+					childDoc.Schema = childScope.SchemaName()
+					if !seen[childDoc.Schema] {
+						seen[childDoc.Schema] = true
+						queue = append(queue, childScope)
+					}
+				}
+			}
+			block.Children = append(block.Children, childDoc)
+		}
+		doc.Blocks = append(doc.Blocks, block)
+	}
+	return doc, nil
+}
+
+func newBlockDoc(scope *schema.Scope) *BlockDoc {
+	block := &BlockDoc{
+		Schema:   scope.SchemaName(),
+		Children: []ChildDoc{},
+	}
+	spec := scope.BlockSpec()
+	if spec == nil {
+		return block
+	}
+	if spec.Name != nil {
+		block.NameTag = spec.Name.FieldName
+	}
+	if spec.TypeSelect != nil {
+		block.TypeSelectTag = spec.TypeSelect.FieldName
+	}
+	if spec.Qualifier != nil {
+		block.QualifierTag = spec.Qualifier.FieldName
+	}
+	if spec.TypeAttribute != nil {
+		block.TypeAttribute = spec.TypeAttribute.Attribute
+	}
+	if spec.Description != nil {
+		block.DescriptionField = *spec.Description
+	}
+	return block
+}
+
+// collectMessages adds the message and those of its fields, by j5 schema
+// name, which is how the blocks of the doc are named.
+func collectMessages(desc protoreflect.MessageDescriptor, messages map[string]protoreflect.MessageDescriptor) {
+	name := j5SchemaName(desc)
+	if _, ok := messages[name]; ok {
+		return
+	}
+	messages[name] = desc
+	fields := desc.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		if fd.IsMap() {
+			fd = fd.MapValue()
+		}
+		if fd.Message() != nil {
+			collectMessages(fd.Message(), messages)
+		}
+	}
+}
+
+// describeDefault writes the explicit default of a scalar field as a file
+// would, empty when it has none.
+func describeDefault(fd protoreflect.FieldDescriptor) string {
+	if fd == nil || !fd.HasDefault() {
+		return ""
+	}
+	value := fd.Default()
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		enumValue := fd.DefaultEnumValue()
+		if enumValue == nil {
+			return ""
+		}
+		return strconv.Quote(enumName(fd.Enum(), enumValue))
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.BytesKind:
+		return ""
+	default:
+		return value.String()
+	}
+}
+
+func describeConstraint(c *schema.Constraint) string {
+	if c == nil {
+		return ""
+	}
+	parts := []string{}
+	if c.Pattern != nil {
+		parts = append(parts, fmt.Sprintf("pattern %s", *c.Pattern))
+	}
+	if c.Min != nil {
+		op := ">="
+		if c.ExclusiveMin {
+			op = ">"
+		}
+		parts = append(parts, op+" "+strconv.FormatFloat(*c.Min, 'g', -1, 64))
+	}
+	if c.Max != nil {
+		op := "<="
+		if c.ExclusiveMax {
+			op = "<"
+		}
+		parts = append(parts, op+" "+strconv.FormatFloat(*c.Max, 'g', -1, 64))
+	}
+	if c.MinLength != nil {
+		parts = append(parts, fmt.Sprintf("length >= %d", *c.MinLength))
+	}
+	if c.MaxLength != nil {
+		parts = append(parts, fmt.Sprintf("length <= %d", *c.MaxLength))
+	}
+	if len(c.Allowed) > 0 {
+		allowed := append([]string{}, c.Allowed...)
+		sort.Strings(allowed)
+		parts = append(parts, "one of "+strings.Join(allowed, ", "))
+	}
+	if rules := describeConstraint(c.Rules); rules != "" {
+		parts = append(parts, rules)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Render writes the doc in the format.
+func (doc *SchemaDoc) Render(format DocFormat) ([]byte, error) {
+	switch format {
+	case DocFormatText:
+		return []byte(doc.text()), nil
+	case DocFormatMarkdown:
+		return []byte(doc.markdown()), nil
+	case DocFormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown doc format %q", format)
+	}
+}
+
+func (doc *SchemaDoc) text() string {
+	out := &strings.Builder{}
+	for idx, block := range doc.Blocks {
+		if idx > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(block.Schema)
+		out.WriteString("\n")
+		for _, tag := range block.tags() {
+			fmt.Fprintf(out, "  %s\n", tag)
+		}
+		for _, child := range block.Children {
+			fmt.Fprintf(out, "  %s %s %s", child.Name, child.kind(), child.Type)
+			if notes := child.notes(); notes != "" {
+				fmt.Fprintf(out, " (%s)", notes)
+			}
+			out.WriteString("\n")
+			if child.Description != "" {
+				for _, line := range strings.Split(child.Description, "\n") {
+					fmt.Fprintf(out, "    %s\n", line)
+				}
+			}
+		}
+	}
+	return out.String()
+}
+
+func (doc *SchemaDoc) markdown() string {
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "# %s\n", doc.Root)
+	for _, block := range doc.Blocks {
+		fmt.Fprintf(out, "\n## %s\n\n", block.Schema)
+		for _, tag := range block.tags() {
+			fmt.Fprintf(out, "- %s\n", tag)
+		}
+		if len(block.tags()) > 0 {
+			out.WriteString("\n")
+		}
+		if len(block.Children) == 0 {
+			out.WriteString("No attributes or blocks.\n")
+			continue
+		}
+		out.WriteString("| Name | Kind | Type | Notes | Description |\n")
+		out.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, child := range block.Children {
+			fmt.Fprintf(out, "| `%s` | %s | `%s` | %s | %s |\n",
+				child.Name,
+				child.kind(),
+				child.Type,
+				markdownCell(child.notes()),
+				markdownCell(child.Description),
+			)
+		}
+	}
+	return out.String()
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func (block *BlockDoc) tags() []string {
+	tags := []string{}
+	if block.NameTag != "" {
+		tags = append(tags, "name tag: "+block.NameTag)
+	}
+	if block.TypeSelectTag != "" {
+		tags = append(tags, "type select tag: "+block.TypeSelectTag)
+	}
+	if block.QualifierTag != "" {
+		tags = append(tags, "qualifier tag: "+block.QualifierTag)
+	}
+	if block.TypeAttribute != "" {
+		tags = append(tags, "type attribute: "+block.TypeAttribute)
+	}
+	if block.DescriptionField != "" {
+		tags = append(tags, "description: "+block.DescriptionField)
+	}
+	return tags
+}
+
+func (child ChildDoc) kind() string {
+	switch {
+	case child.Flag:
+		return "flag"
+	case child.Attribute && child.Block:
+		return "attribute/block"
+	case child.Block:
+		return "block"
+	default:
+		return "attribute"
+	}
+}
+
+func (child ChildDoc) notes() string {
+	notes := []string{}
+	if child.Required {
+		notes = append(notes, "required")
+	}
+	if child.Default != "" {
+		notes = append(notes, "default "+child.Default)
+	}
+	if child.Constraint != "" {
+		notes = append(notes, child.Constraint)
+	}
+	return strings.Join(notes, ", ")
+}
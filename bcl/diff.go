@@ -0,0 +1,143 @@
+package bcl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Diff describes the differences between two messages of the same type, one
+// line for each changed value as "path: from -> to", with paths as for
+// Explain. Sensitive fields show that they changed, but not their values. The
+// diff is empty when the messages are equal.
+func Diff(from, to proto.Message) (string, error) {
+	fromMsg, toMsg := from.ProtoReflect(), to.ProtoReflect()
+	if fromMsg.Descriptor().FullName() != toMsg.Descriptor().FullName() {
+		return "", fmt.Errorf("cannot diff %s with %s", fromMsg.Descriptor().FullName(), toMsg.Descriptor().FullName())
+	}
+	out := &strings.Builder{}
+	diffMessage(out, "", fromMsg, toMsg, false)
+	return out.String(), nil
+}
+
+func diffMessage(out *strings.Builder, prefix string, from, to protoreflect.Message, sensitive bool) {
+	fields := from.Descriptor().Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		field := fields.Get(idx)
+		hasFrom, hasTo := from.Has(field), to.Has(field)
+		if !hasFrom && !hasTo {
+			continue
+		}
+		path := prefix + field.JSONName()
+		fieldSensitive := sensitive || isSensitive(field)
+
+		switch {
+		case field.IsList():
+			fromList, toList := from.Get(field).List(), to.Get(field).List()
+			for el := 0; el < max(fromList.Len(), toList.Len()); el++ {
+				d := valueDiff{field: field, sensitive: fieldSensitive}
+				if el < fromList.Len() {
+					d.from, d.hasFrom = fromList.Get(el), true
+				}
+				if el < toList.Len() {
+					d.to, d.hasTo = toList.Get(el), true
+				}
+				d.write(out, path+"."+strconv.Itoa(el))
+			}
+
+		case field.IsMap():
+			fromMap, toMap := from.Get(field).Map(), to.Get(field).Map()
+			keys := map[string]protoreflect.MapKey{}
+			for _, m := range []protoreflect.Map{fromMap, toMap} {
+				m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+					keys[key.String()] = key
+					return true
+				})
+			}
+			names := make([]string, 0, len(keys))
+			for name := range keys {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				key := keys[name]
+				d := valueDiff{field: field, sensitive: fieldSensitive}
+				if fromMap.Has(key) {
+					d.from, d.hasFrom = fromMap.Get(key), true
+				}
+				if toMap.Has(key) {
+					d.to, d.hasTo = toMap.Get(key), true
+				}
+				d.write(out, path+"."+name)
+			}
+
+		default:
+			d := valueDiff{
+				field:     field,
+				sensitive: fieldSensitive,
+				from:      from.Get(field),
+				hasFrom:   hasFrom,
+				to:        to.Get(field),
+				hasTo:     hasTo,
+			}
+			d.write(out, path)
+		}
+	}
+}
+
+// valueDiff is a singular value, or an element of a list or map, on each side
+// of a diff.
+type valueDiff struct {
+	field     protoreflect.FieldDescriptor
+	sensitive bool
+
+	from, to       protoreflect.Value
+	hasFrom, hasTo bool
+}
+
+func (d valueDiff) write(out *strings.Builder, path string) {
+	if d.valueField().Message() == nil {
+		if d.hasFrom == d.hasTo && d.from.Equal(d.to) {
+			return
+		}
+		fmt.Fprintf(out, "%s: %s -> %s\n", path, d.format(d.from, d.hasFrom), d.format(d.to, d.hasTo))
+		return
+	}
+
+	// Messages which are added or removed are listed, then compared with an
+	// empty message to list their fields.
+	if d.hasFrom != d.hasTo {
+		fmt.Fprintf(out, "%s: %s -> %s\n", path, d.format(d.from, d.hasFrom), d.format(d.to, d.hasTo))
+	}
+	from, to := d.from, d.to
+	if !d.hasFrom {
+		from = protoreflect.ValueOfMessage(to.Message().Type().Zero())
+	}
+	if !d.hasTo {
+		to = protoreflect.ValueOfMessage(from.Message().Type().Zero())
+	}
+	diffMessage(out, path+".", from.Message(), to.Message(), d.sensitive)
+}
+
+// valueField is the field of the value, which is the map value for a map.
+func (d valueDiff) valueField() protoreflect.FieldDescriptor {
+	if d.field.IsMap() {
+		return d.field.MapValue()
+	}
+	return d.field
+}
+
+func (d valueDiff) format(value protoreflect.Value, isSet bool) string {
+	switch {
+	case !isSet:
+		return "not set"
+	case d.sensitive && d.valueField().Message() == nil:
+		return Redacted
+	default:
+		return formatExplainValue(d.field, value, true)
+	}
+}
@@ -0,0 +1,102 @@
+package bcl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ParseDocuments parses a file of several documents of the parser's schema,
+// separated by '---' lines, into a new message of msgType for each, in order.
+// Each document has its own result and source locations, with positions in
+// the whole file, and the header of the file applies to all of them.
+// Documents without statements are skipped, so a file may start or end with a
+// separator.
+func (p *Parser) ParseDocuments(filename string, data string, msgType protoreflect.MessageType) ([]protoreflect.Message, []*ParseResult, error) {
+	ctx := context.Background()
+	tree, err := p.parseTree(ctx, filename, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bodies, err := documentBodies(tree)
+	if err != nil {
+		return nil, nil, fileErr(err, filename, data, tree)
+	}
+
+	msgs := make([]protoreflect.Message, 0, len(bodies))
+	results := make([]*ParseResult, 0, len(bodies))
+	for _, body := range bodies {
+		subtree := *tree
+		subtree.Body = body
+		subtree.Separators = nil
+		msg := msgType.New()
+		result, err := p.parseAST(ctx, filename, &subtree, msg)
+		msgs = append(msgs, msg)
+		results = append(results, result)
+		if err != nil {
+			return msgs, results, fileErr(err, filename, data, tree)
+		}
+	}
+	return msgs, results, nil
+}
+
+// DecodeDocuments parses the documents of a file into new messages of type T,
+// as ParseDocuments, returning the source locations of each.
+func DecodeDocuments[T proto.Message](parser *Parser, filename, src string) ([]T, []*bcl_j5pb.SourceLocation, error) {
+	msgs, results, err := parser.ParseDocuments(filename, src, newMessage[T]().ProtoReflect().Type())
+	decoded := make([]T, len(msgs))
+	for idx, msg := range msgs {
+		decoded[idx] = msg.Interface().(T)
+	}
+	locs := make([]*bcl_j5pb.SourceLocation, len(results))
+	for idx, result := range results {
+		if result != nil {
+			locs[idx] = result.SourceLocation
+		}
+	}
+	return decoded, locs, err
+}
+
+// documentBodies splits the statements of the file at its separators.
+func documentBodies(tree *parser.File) ([]parser.Body, error) {
+	bodies := []parser.Body{}
+	current := parser.Body{IsRoot: true}
+	next := 0
+	for _, stmt := range tree.Body.Statements {
+		pos := stmt.Source().Position()
+		for next < len(tree.Separators) && tree.Separators[next].Line < pos.Start.Line {
+			if len(current.Statements) > 0 {
+				bodies = append(bodies, current)
+				current = parser.Body{IsRoot: true}
+			}
+			next++
+		}
+		if next < len(tree.Separators) && tree.Separators[next].Line <= statementEnd(stmt).Line {
+			return nil, separatorError(tree.Separators[next], "a document separator can't be inside a block")
+		}
+		current.Statements = append(current.Statements, stmt)
+	}
+	if len(current.Statements) > 0 {
+		bodies = append(bodies, current)
+	}
+	return bodies, nil
+}
+
+// statementEnd is the end of the statement, including the body of a block.
+func statementEnd(stmt parser.Statement) parser.Position {
+	if block, ok := stmt.(*parser.Block); ok && block.Close != nil {
+		return block.Close.Position().End
+	}
+	return stmt.Source().Position().End
+}
+
+func separatorError(sep parser.Position, message string) error {
+	err := errpos.WithCode(fmt.Errorf("%s", message), errpos.CodeDocuments)
+	return errpos.AddPosition(err, errpos.Position{Start: sep, End: sep})
+}
@@ -0,0 +1,135 @@
+package bcl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CheckDuplicateBlocks checks that no two blocks of a schema with identity
+// fields have the same values for them, across all files. Parsing a file
+// checks the file on its own, this is the check for a project. Each block of
+// a duplicate set is returned as an entry, naming where the others are, as
+// CheckUnused returns its findings, and is allowed with a bcl:ignore comment
+// in the files which have their Source set.
+func CheckDuplicateBlocks(spec *bcl_j5pb.Schema, files []SourceFile) (errpos.Errors, error) {
+	identities := map[string][]string{}
+	for _, block := range spec.Blocks {
+		if len(block.Identity) > 0 {
+			identities[block.SchemaName] = block.Identity
+		}
+	}
+	errs, err := duplicateBlocks(identities, files)
+	if err != nil {
+		return nil, err
+	}
+	suppressions, err := sourceSuppressions(files)
+	if err != nil {
+		return nil, err
+	}
+	return filterSuppressed(errs, suppressions), nil
+}
+
+// checkDuplicates checks the blocks of one file for duplicates, see
+// CheckDuplicateBlocks.
+func (p *Parser) checkDuplicates(filename string, msg protoreflect.Message, source *bcl_j5pb.SourceLocation, suppressions parser.Suppressions) error {
+	errs, err := duplicateBlocks(p.identity, []SourceFile{{
+		Filename:       filename,
+		Message:        msg,
+		SourceLocation: source,
+	}})
+	if err != nil {
+		return err
+	}
+	errs = suppressions.Filter(errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+type identifiedBlock struct {
+	identity string
+	position errpos.Position
+}
+
+type duplicateFinder struct {
+	identities map[string][]string // schema name to identity fields
+	keys       []string
+	blocks     map[string][]identifiedBlock
+}
+
+func duplicateBlocks(identities map[string][]string, files []SourceFile) (errpos.Errors, error) {
+	if len(identities) == 0 {
+		return nil, nil
+	}
+	df := &duplicateFinder{
+		identities: identities,
+		blocks:     map[string][]identifiedBlock{},
+	}
+	for _, file := range files {
+		err := visitMessages(file.Message, file.SourceLocation, func(node messageNode) error {
+			return df.check(file.Filename, node.msg, node.located)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Filename, err)
+		}
+	}
+
+	var errs errpos.Errors
+	for _, key := range df.keys {
+		blocks := df.blocks[key]
+		if len(blocks) < 2 {
+			continue
+		}
+		for idx, block := range blocks {
+			others := make([]string, 0, len(blocks)-1)
+			for otherIdx, other := range blocks {
+				if otherIdx != idx {
+					others = append(others, other.position.String())
+				}
+			}
+			pos := block.position
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeDuplicateBlock,
+				Err:  fmt.Errorf("%s is also defined at %s", block.identity, strings.Join(others, ", ")),
+			})
+		}
+	}
+	return errs, nil
+}
+
+// check records the identity of the message, when its schema has one.
+func (df *duplicateFinder) check(filename string, msg protoreflect.Message, loc *bcl_j5pb.SourceLocation) error {
+	desc := msg.Descriptor()
+	schemaName := j5SchemaName(desc)
+
+	if fieldNames, ok := df.identities[schemaName]; ok {
+		values := make([]string, 0, len(fieldNames))
+		set := false
+		for _, fieldName := range fieldNames {
+			fd := desc.Fields().ByJSONName(fieldName)
+			if fd == nil || fd.Message() != nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("identity field %q of %s is not a scalar", fieldName, schemaName)
+			}
+			set = set || msg.Has(fd)
+			values = append(values, fmt.Sprintf("%s=%q", fieldName, msg.Get(fd).String()))
+		}
+		if set {
+			identity := fmt.Sprintf("%s %s", schemaName, strings.Join(values, " "))
+			if _, ok := df.blocks[identity]; !ok {
+				df.keys = append(df.keys, identity)
+			}
+			df.blocks[identity] = append(df.blocks[identity], identifiedBlock{
+				identity: identity,
+				position: locPosition(filename, loc),
+			})
+		}
+	}
+	return nil
+}
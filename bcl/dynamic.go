@@ -0,0 +1,37 @@
+package bcl
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// FindMessage builds the files of a descriptor set and returns the named
+// message, for parsing into schemas without generated Go code.
+func FindMessage(fds *descriptorpb.FileDescriptorSet, name string) (protoreflect.MessageDescriptor, error) {
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, fmt.Errorf("building descriptor set: %w", err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("message %s: %w", name, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, not a message", name, desc)
+	}
+	return msgDesc, nil
+}
+
+// ParseDynamic parses the file into a new dynamic message of the root type.
+func (p *Parser) ParseDynamic(filename string, data string, root protoreflect.MessageDescriptor) (*dynamicpb.Message, *bcl_j5pb.SourceLocation, error) {
+	msg := dynamicpb.NewMessage(root)
+	locs, err := p.ParseFile(filename, data, msg)
+	return msg, locs, err
+}
@@ -0,0 +1,88 @@
+package bcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// SetValue sets the attribute at the dotted path in a file to value, BCL
+// source such as 30, "abc" or ["a", "b"], keeping the formatting and comments
+// of the rest of the file. An existing assignment has its value replaced,
+// otherwise one is added at the end of the innermost block on the path. Blocks
+// are on the path by their type and tags, 'service.api.port' is set in
+// 'service api { port = 80 }'. LiteralValue converts a plain string to a
+// value.
+func SetValue(data string, path string, value string) (string, error) {
+	return editFile(data, func(file *parser.File) ([]errpos.Edit, error) {
+		// The value may use the syntax of the file's edition
+		if _, err := parseEditionValue(value, file.Edition); err != nil {
+			return nil, err
+		}
+		return parser.SetEdits(data, file, strings.Split(path, "."), value)
+	})
+}
+
+// UnsetValue removes the assignment at the dotted path in a file, as found by
+// SetValue, returning the file unchanged when the path is not assigned.
+func UnsetValue(data string, path string) (string, error) {
+	return editFile(data, func(file *parser.File) ([]errpos.Edit, error) {
+		return parser.UnsetEdits(file, strings.Split(path, "."))
+	})
+}
+
+// LiteralValue returns s when it is a BCL value, a number, bool, string,
+// identifier or array, and otherwise s quoted as a string, so that scripts can
+// pass values such as 30 and 30s alike.
+func LiteralValue(s string) string {
+	if _, err := parseValue(s); err == nil {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+func editFile(data string, edits func(*parser.File) ([]errpos.Edit, error)) (string, error) {
+	file, err := parser.ParseFile(data, true)
+	if err != nil {
+		return "", errpos.AddSourceFile(err, "", data)
+	}
+	fileEdits, err := edits(file)
+	if err != nil {
+		return "", err
+	}
+	edited, err := errpos.ApplyEdits(data, fileEdits)
+	if err != nil {
+		return "", err
+	}
+	if _, err := parser.ParseFile(edited, true); err != nil {
+		return "", fmt.Errorf("edited file does not parse: %w", err)
+	}
+	return edited, nil
+}
+
+func parseValue(value string) (*parser.Value, error) {
+	return parseEditionValue(value, parser.Edition1)
+}
+
+// parseEditionValue parses a value with the syntax of the edition.
+func parseEditionValue(value string, edition parser.Edition) (*parser.Value, error) {
+	source := "value = " + value
+	if edition > parser.Edition1 {
+		source = fmt.Sprintf("!bcl %d\n%s", edition, source)
+	}
+	file, err := parser.ParseFile(source, true)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %s: %w", value, err)
+	}
+	if len(file.Body.Statements) != 1 {
+		return nil, fmt.Errorf("invalid value %s", value)
+	}
+	assign, ok := file.Body.Statements[0].(*parser.Assignment)
+	if !ok {
+		return nil, fmt.Errorf("invalid value %s", value)
+	}
+	return &assign.Value, nil
+}
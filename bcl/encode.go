@@ -0,0 +1,709 @@
+package bcl
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Encoder writes messages one at a time as blocks of a type, in the layout
+// of Fmt, the counterpart of Decoder. Blocks are written as the schema reads
+// them: name and type-select tags in the header, fields by the alias or name
+// the walker resolves to them, in field number order, so the same message
+// always gives the same text.
+type Encoder struct {
+	writer    io.Writer
+	blockType string
+	parser    *Parser
+
+	// Unredacted writes the values of sensitive fields, which are otherwise
+	// Redacted as by Marshal.
+	Unredacted bool
+}
+
+// NewEncoder returns an encoder which appends each message to w as a block
+// of blockType, e.g. "event" for a Decoder whose schema has a repeated event
+// field of the message. An empty blockType writes each message as the body
+// of a file instead. Blocks are written for the default schema of the
+// messages, use Parser.NewEncoder for a schema with block specs.
+func NewEncoder(w io.Writer, blockType string) *Encoder {
+	return &Encoder{
+		writer:    w,
+		blockType: blockType,
+	}
+}
+
+// NewEncoder returns an encoder which writes blocks as the parser's schema
+// reads them, see the package function NewEncoder.
+func (p *Parser) NewEncoder(w io.Writer, blockType string) *Encoder {
+	return &Encoder{
+		writer:    w,
+		blockType: blockType,
+		parser:    p,
+	}
+}
+
+// Encode writes the message as a block in a single write, flushing the writer
+// when it has a Flush method, such as a bufio.Writer.
+func (e *Encoder) Encode(msg proto.Message) error {
+	if e.parser == nil {
+		pp, err := NewParser(&bcl_j5pb.Schema{})
+		if err != nil {
+			return err
+		}
+		e.parser = pp
+	}
+	if !e.Unredacted {
+		msg = redact(msg)
+	}
+	enc := newBlockEncoder(e.parser)
+	if e.blockType == "" {
+		if err := enc.root(msg.ProtoReflect()); err != nil {
+			return err
+		}
+	} else if err := enc.block(0, e.blockType, "", msg.ProtoReflect()); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(e.writer, enc.out.String()); err != nil {
+		return err
+	}
+	if flusher, ok := e.writer.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// EncodeError is a field whose value has no BCL form.
+type EncodeError struct {
+	Field  protoreflect.FullName
+	Reason string
+}
+
+func (ee *EncodeError) Error() string {
+	return fmt.Sprintf("can't encode %s: %s", ee.Field, ee.Reason)
+}
+
+// blockEncoder writes messages by the block specs of a parser's schema,
+// mirroring how the walker reads blocks.
+type blockEncoder struct {
+	parser *Parser
+	out    *strings.Builder
+	specs  map[protoreflect.FullName]*schema.BlockSpec
+
+	// canonical sorts the attributes and repeated fields of unordered blocks
+	// and leaves out values equal to the default of their field.
+	canonical bool
+}
+
+func newBlockEncoder(p *Parser) *blockEncoder {
+	return &blockEncoder{
+		parser: p,
+		out:    &strings.Builder{},
+		specs:  map[protoreflect.FullName]*schema.BlockSpec{},
+	}
+}
+
+// spec is the block spec of the message type, as the walker builds it.
+func (be *blockEncoder) spec(desc protoreflect.MessageDescriptor) (*schema.BlockSpec, error) {
+	if spec, ok := be.specs[desc.FullName()]; ok {
+		return spec, nil
+	}
+	walked, refl := desc, be.parser.refl
+	view, err := be.parser.views.view(desc)
+	if err != nil {
+		return nil, err
+	}
+	if view != nil {
+		walked, refl = view, be.parser.views.refl
+	}
+	root, err := refl.NewRoot(dynamicpb.NewMessage(walked))
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, &EncodeError{Field: desc.FullName(), Reason: "no j5 schema for the message"}
+	}
+	spec, err := be.parser.schema.BlockSpec(root)
+	if err != nil {
+		return nil, err
+	}
+	be.specs[desc.FullName()] = spec
+	return spec, nil
+}
+
+// encodeScope is a message in the scope of a block, as the walker merges the
+// scope of a block with the type its type-select tag selects.
+type encodeScope struct {
+	msg  protoreflect.Message
+	spec *schema.BlockSpec
+
+	// prefix is the path to the message from the first in the scope.
+	prefix []string
+}
+
+// fieldKey is a field of a message which a tag already sets.
+type fieldKey struct {
+	msg  protoreflect.Message
+	name protoreflect.Name
+}
+
+// blockHeader is what the header of a block sets, as the walker reads it.
+type blockHeader struct {
+	scope    []encodeScope
+	tags     []string
+	consumed map[fieldKey]bool
+
+	// scopeName is the last name tag of the block, qualified
+	scopeName string
+
+	// attribute is the assignment of a type attribute, the first statement
+	// of the body
+	attribute string
+}
+
+// root writes the message as the body of a file.
+func (be *blockEncoder) root(msg protoreflect.Message) error {
+	spec, err := be.spec(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	// The root has no header, so nothing is set by tags
+	scope := []encodeScope{{msg: msg, spec: spec}}
+	return be.body(0, scope, map[fieldKey]bool{}, "")
+}
+
+// block writes the message as a block opened by header. scopeName is the
+// name of the nearest enclosing named block, which qualifies name tags with a
+// scope separator.
+func (be *blockEncoder) block(indent int, header string, scopeName string, msg protoreflect.Message) error {
+	hdr, err := be.header(msg, scopeName)
+	if err != nil {
+		return err
+	}
+	line := header
+	for _, tag := range hdr.tags {
+		line += " " + tag
+	}
+	writeLine(be.out, indent, line+" {")
+	if hdr.attribute != "" {
+		writeLine(be.out, indent+1, hdr.attribute)
+	}
+	if err := be.body(indent+1, hdr.scope, hdr.consumed, hdr.scopeName); err != nil {
+		return err
+	}
+	writeLine(be.out, indent, "}")
+	return nil
+}
+
+// header returns the tags of the block of the message, as walkTags reads
+// them, with the scope they build and the fields they set.
+func (be *blockEncoder) header(msg protoreflect.Message, scopeName string) (*blockHeader, error) {
+	spec, err := be.spec(msg.Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	cur := encodeScope{msg: msg, spec: spec}
+	hdr := &blockHeader{
+		scope:     []encodeScope{cur},
+		tags:      []string{},
+		consumed:  map[fieldKey]bool{},
+		scopeName: scopeName,
+	}
+
+	for {
+		if cur.spec.Name != nil {
+			tagSpec := cur.spec.Name
+			fd := jsonField(cur.msg, tagSpec.FieldName)
+			if fd == nil || fd.IsList() || (fd.Message() != nil && !isWrapper(fd)) {
+				return nil, &EncodeError{Field: cur.msg.Descriptor().FullName(), Reason: fmt.Sprintf("name tag %s is not a scalar field", tagSpec.FieldName)}
+			}
+			if !cur.msg.Has(fd) {
+				if tagSpec.IsOptional {
+					// the walker reads no further tags without a name
+					break
+				}
+				hdr.tags = append(hdr.tags, `""`)
+			} else {
+				scalar, value := fd, cur.msg.Get(fd)
+				if isWrapper(fd) {
+					scalar, value = wrappedValue(value.Message())
+				}
+				tag, err := be.nameTag(scalar, value, tagSpec, hdr.scopeName)
+				if err != nil {
+					return nil, err
+				}
+				hdr.tags = append(hdr.tags, tag)
+				hdr.consumed[fieldKey{cur.msg, fd.Name()}] = true
+				if scalar.Kind() == protoreflect.StringKind {
+					hdr.scopeName = value.String()
+				}
+			}
+		}
+
+		if cur.spec.TypeSelect == nil {
+			break
+		}
+		next, tag, err := be.selected(cur, cur.spec.TypeSelect.FieldName, hdr.consumed)
+		if err != nil {
+			return nil, err
+		}
+		hdr.tags = append(hdr.tags, tag)
+		hdr.scope = append(hdr.scope, next)
+		cur = next
+	}
+
+	if attr := cur.spec.TypeAttribute; attr != nil {
+		next, typeName, err := be.selected(cur, attr.FieldName, hdr.consumed)
+		if err != nil {
+			return nil, err
+		}
+		hdr.attribute = fmt.Sprintf("%s = %s", attr.Attribute, strconv.Quote(typeName))
+		hdr.scope = append(hdr.scope, next)
+	}
+	return hdr, nil
+}
+
+// nameTag is the literal of a name tag, without the scope the walker adds to
+// it when the tag has a scope separator.
+func (be *blockEncoder) nameTag(fd protoreflect.FieldDescriptor, value protoreflect.Value, tagSpec *schema.Tag, scopeName string) (string, error) {
+	if fd.Kind() != protoreflect.StringKind {
+		return be.literal(fd, value)
+	}
+	name := value.String()
+	if tagSpec.ScopeSeparator != nil && scopeName != "" {
+		prefix := scopeName + *tagSpec.ScopeSeparator
+		if !strings.HasPrefix(name, prefix) {
+			return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("name %q is not in the scope of %q", name, scopeName)}
+		}
+		name = strings.TrimPrefix(name, prefix)
+	}
+	if parser.IsName(name) && !parser.IsReserved(name) {
+		return name, nil
+	}
+	return quoteString(fd, name)
+}
+
+// selected is the scope of the type the message of cur has set at
+// fieldName, for a type-select tag or type attribute, and the name of the
+// type.
+func (be *blockEncoder) selected(cur encodeScope, fieldName string, consumed map[fieldKey]bool) (encodeScope, string, error) {
+	target := cur.msg
+	prefix := cur.prefix
+	if fieldName != "" && fieldName != "." {
+		fd := jsonField(target, fieldName)
+		if fd == nil || fd.Message() == nil || !target.Has(fd) {
+			return encodeScope{}, "", &EncodeError{Field: cur.msg.Descriptor().FullName(), Reason: fmt.Sprintf("no type is set at %s", fieldName)}
+		}
+		target = target.Get(fd).Message()
+		prefix = append(prefix, fieldName)
+	}
+
+	var member protoreflect.FieldDescriptor
+	fields := target.Descriptor().Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() || !target.Has(fd) || consumed[fieldKey{target, fd.Name()}] {
+			continue
+		}
+		if member == nil || (fd.ContainingOneof() != nil && member.ContainingOneof() == nil) {
+			member = fd
+		}
+	}
+	if member == nil {
+		return encodeScope{}, "", &EncodeError{Field: target.Descriptor().FullName(), Reason: "no type is set"}
+	}
+	consumed[fieldKey{target, member.Name()}] = true
+
+	msg := target.Get(member).Message()
+	spec, err := be.spec(msg.Descriptor())
+	if err != nil {
+		return encodeScope{}, "", err
+	}
+	return encodeScope{
+		msg:    msg,
+		spec:   spec,
+		prefix: append(prefix, member.JSONName()),
+	}, member.JSONName(), nil
+}
+
+// encodedField is a field of a message of a block's scope with the name it
+// is written by, and its text, built before it is written so that unordered
+// blocks can be sorted.
+type encodedField struct {
+	name  string
+	lines []string
+}
+
+// body writes the fields of each message of the block's scope which the tags
+// didn't set.
+func (be *blockEncoder) body(indent int, scope []encodeScope, consumed map[fieldKey]bool, scopeName string) error {
+	for idx, entry := range scope {
+		fields, err := be.fields(indent, scope, idx, consumed, scopeName)
+		if err != nil {
+			return err
+		}
+		if be.canonical && entry.spec.Unordered {
+			sort.SliceStable(fields, func(i, j int) bool {
+				return fields[i].name < fields[j].name
+			})
+		}
+		for _, field := range fields {
+			for _, line := range field.lines {
+				be.out.WriteString(line)
+			}
+		}
+	}
+	return nil
+}
+
+func (be *blockEncoder) fields(indent int, scope []encodeScope, idx int, consumed map[fieldKey]bool, scopeName string) ([]encodedField, error) {
+	entry := scope[idx]
+	msg := entry.msg
+	fields := msg.Descriptor().Fields()
+	encoded := []encodedField{}
+	for fieldIdx := 0; fieldIdx < fields.Len(); fieldIdx++ {
+		fd := fields.Get(fieldIdx)
+		if !msg.Has(fd) || consumed[fieldKey{msg, fd.Name()}] || be.isDefault(fd, msg.Get(fd)) {
+			continue
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !be.hasContent(msg.Get(fd).Message(), consumed) {
+			continue
+		}
+		name, err := be.nameIn(scope, idx, fd)
+		if err != nil {
+			return nil, err
+		}
+		lines, err := be.field(indent, scope, idx, name, fd, msg.Get(fd), consumed, scopeName)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, encodedField{name: name, lines: lines})
+	}
+	return encoded, nil
+}
+
+// isDefault is true for a canonical scalar equal to the default of its
+// field, which is the zero value unless the field declares another.
+func (be *blockEncoder) isDefault(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+	if !be.canonical || fd.IsList() || fd.IsMap() || fd.Message() != nil {
+		return false
+	}
+	return value.Equal(fd.Default())
+}
+
+// hasContent is false for a message whose set fields are all set by tags, so
+// it has nothing left to write.
+func (be *blockEncoder) hasContent(msg protoreflect.Message, consumed map[fieldKey]bool) bool {
+	empty := true
+	content := false
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		empty = false
+		if !consumed[fieldKey{msg, fd.Name()}] {
+			if fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+				content = be.hasContent(value.Message(), consumed)
+			} else {
+				content = true
+			}
+		}
+		return !content
+	})
+	return empty || content
+}
+
+// resolve returns the index of the message of the scope and the path to the
+// child which the walker finds for the name, aliases first, as findBlock.
+func (be *blockEncoder) resolve(scope []encodeScope, name string) (int, []string, bool) {
+	for idx, entry := range scope {
+		if path, ok := entry.spec.Aliases[name]; ok {
+			return idx, path, true
+		}
+		if jsonField(entry.msg, name) != nil {
+			return idx, []string{name}, true
+		}
+	}
+	return 0, nil, false
+}
+
+// nameIn is the name which resolves to the field of the message at idx of
+// the scope: its JSON name, an alias of it, or a dotted path to it from the
+// first message of the scope when both are shadowed.
+func (be *blockEncoder) nameIn(scope []encodeScope, idx int, fd protoreflect.FieldDescriptor) (string, error) {
+	want := []string{fd.JSONName()}
+	if found, path, ok := be.resolve(scope, fd.JSONName()); ok && found == idx && pathEqual(path, want) {
+		return fd.JSONName(), nil
+	}
+	if alias, ok := be.aliasFor(scope, idx, want); ok {
+		return alias, nil
+	}
+	if prefix := scope[idx].prefix; len(prefix) > 0 {
+		if found, path, ok := be.resolve(scope, prefix[0]); ok && found == 0 && pathEqual(path, prefix[:1]) {
+			return strings.Join(append(append([]string{}, prefix...), fd.JSONName()), "."), nil
+		}
+	}
+	return "", &EncodeError{Field: fd.FullName(), Reason: "the name is shadowed by another field or alias of the block"}
+}
+
+func pathEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// field returns the lines of a field of the message at idx of the scope,
+// written by name.
+func (be *blockEncoder) field(indent int, scope []encodeScope, idx int, name string, fd protoreflect.FieldDescriptor, value protoreflect.Value, consumed map[fieldKey]bool, scopeName string) ([]string, error) {
+	switch {
+	case fd.IsMap():
+		return be.mapField(indent, name, fd, value.Map(), scopeName)
+
+	case fd.IsList() && fd.Message() != nil && !isWrapper(fd):
+		list := value.List()
+		elements := make([]string, 0, list.Len())
+		for elementIdx := 0; elementIdx < list.Len(); elementIdx++ {
+			text, err := be.element(indent, scope, idx, name, fd, list.Get(elementIdx).Message(), scopeName)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, text)
+		}
+		if be.canonical && scope[idx].spec.Unordered {
+			sort.Strings(elements)
+		}
+		return elements, nil
+
+	case fd.IsList():
+		list := value.List()
+		lits := make([]string, list.Len())
+		for elementIdx := range lits {
+			lit, err := be.valueLiteral(fd, list.Get(elementIdx))
+			if err != nil {
+				return nil, err
+			}
+			lits[elementIdx] = lit
+		}
+		if be.canonical && scope[idx].spec.Unordered {
+			sort.Strings(lits)
+		}
+		return []string{line(indent, fmt.Sprintf("%s = [%s]", name, strings.Join(lits, ", ")))}, nil
+
+	case fd.Message() != nil:
+		if isWrapper(fd) {
+			// Files set a wrapper as the scalar it wraps, as the walker reads it
+			lit, err := be.valueLiteral(fd, value)
+			if err != nil {
+				return nil, err
+			}
+			return []string{line(indent, fmt.Sprintf("%s = %s", name, lit))}, nil
+		}
+		text, err := be.message(indent, name, fd, value.Message(), scopeName)
+		if err != nil {
+			return nil, err
+		}
+		return []string{text}, nil
+
+	default:
+		lit, err := be.literal(fd, value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{line(indent, fmt.Sprintf("%s = %s", name, lit))}, nil
+	}
+}
+
+// element returns the text of an element of a repeated message field. An
+// element with a single message set is written by an alias for the path to
+// it when the block has one, as `foo a { }` for an alias foo of
+// elements.foo, and other elements by an alias of the field, which is
+// usually its singular form, before its name.
+func (be *blockEncoder) element(indent int, scope []encodeScope, idx int, name string, fd protoreflect.FieldDescriptor, msg protoreflect.Message, scopeName string) (string, error) {
+	var only protoreflect.FieldDescriptor
+	count := 0
+	msg.Range(func(field protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		only = field
+		count++
+		return true
+	})
+	if count == 1 && only.Message() != nil && !only.IsList() && !only.IsMap() && !isWrapper(only) {
+		if alias, ok := be.aliasFor(scope, idx, []string{fd.JSONName(), only.JSONName()}); ok {
+			return be.message(indent, alias, only, msg.Get(only).Message(), scopeName)
+		}
+	}
+	if alias, ok := be.aliasFor(scope, idx, []string{fd.JSONName()}); ok {
+		name = alias
+	}
+	return be.message(indent, name, fd, msg, scopeName)
+}
+
+// aliasFor is the first alias, by name, of the message at idx of the scope
+// which the walker resolves to the path.
+func (be *blockEncoder) aliasFor(scope []encodeScope, idx int, want []string) (string, bool) {
+	aliases := []string{}
+	for alias, path := range scope[idx].spec.Aliases {
+		if pathEqual(path, want) {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if found, path, ok := be.resolve(scope, alias); ok && found == idx && pathEqual(path, want) {
+			return alias, true
+		}
+	}
+	return "", false
+}
+
+// mapField returns the lines of a map, by key. Message values are blocks
+// opened by the dotted path to the key.
+func (be *blockEncoder) mapField(indent int, name string, fd protoreflect.FieldDescriptor, value protoreflect.Map, scopeName string) ([]string, error) {
+	keys := make([]protoreflect.MapKey, 0, value.Len())
+	value.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sortMapKeys(keys)
+	lines := make([]string, 0, len(keys))
+	valueField := fd.MapValue()
+	for _, key := range keys {
+		keyName := key.String()
+		if !parser.IsName(keyName) {
+			return nil, &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("map key %q is not an identifier", keyName)}
+		}
+		path := name + "." + keyName
+		if valueField.Message() != nil && !isWrapper(valueField) {
+			text, err := be.message(indent, path, valueField, value.Get(key).Message(), scopeName)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, text)
+			continue
+		}
+		lit, err := be.valueLiteral(valueField, value.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line(indent, fmt.Sprintf("%s = %s", path, lit)))
+	}
+	return lines, nil
+}
+
+// message returns the text of a message field as a block.
+func (be *blockEncoder) message(indent int, name string, fd protoreflect.FieldDescriptor, msg protoreflect.Message, scopeName string) (string, error) {
+	if msg.Descriptor().ParentFile().Package() == "google.protobuf" {
+		return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("well-known type %s", msg.Descriptor().FullName())}
+	}
+	inner := &blockEncoder{
+		parser:    be.parser,
+		out:       &strings.Builder{},
+		specs:     be.specs,
+		canonical: be.canonical,
+	}
+	if err := inner.block(indent, name, scopeName, msg); err != nil {
+		return "", err
+	}
+	return inner.out.String(), nil
+}
+
+// valueLiteral is the literal of a scalar, or of a wrapper, which is written
+// as the scalar it wraps.
+func (be *blockEncoder) valueLiteral(fd protoreflect.FieldDescriptor, value protoreflect.Value) (string, error) {
+	if isWrapper(fd) {
+		inner, value := wrappedValue(value.Message())
+		return be.literal(inner, value)
+	}
+	return be.literal(fd, value)
+}
+
+// wrappedValue is the value field of a wrapper message, and its value.
+func wrappedValue(msg protoreflect.Message) (protoreflect.FieldDescriptor, protoreflect.Value) {
+	inner := msg.Descriptor().Fields().ByName("value")
+	return inner, msg.Get(inner)
+}
+
+// literal is the literal of a scalar, as the walker sets the field from it.
+// Bytes are written base64 encoded.
+func (be *blockEncoder) literal(fd protoreflect.FieldDescriptor, value protoreflect.Value) (string, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return quoteString(fd, value.String())
+	case protoreflect.BytesKind:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(value.Bytes())), nil
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool()), nil
+	case protoreflect.EnumKind:
+		enumValue := fd.Enum().Values().ByNumber(value.Enum())
+		if enumValue == nil {
+			return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("unknown enum number %d", value.Enum())}
+		}
+		return strconv.Quote(enumName(fd.Enum(), enumValue)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		float := value.Float()
+		if math.IsNaN(float) || math.IsInf(float, 0) {
+			return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("number %v", float)}
+		}
+		bitSize := 64
+		if fd.Kind() == protoreflect.FloatKind {
+			bitSize = 32
+		}
+		return strconv.FormatFloat(float, 'f', -1, bitSize), nil
+	default:
+		return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("%s value", fd.Kind())}
+	}
+}
+
+// quoteString is the string literal of a string field. The parser reads
+// string literals as UTF-8, so other bytes have no literal to write.
+func quoteString(fd protoreflect.FieldDescriptor, str string) (string, error) {
+	if !utf8.ValidString(str) {
+		return "", &EncodeError{Field: fd.FullName(), Reason: fmt.Sprintf("string %q is not valid UTF-8", str)}
+	}
+	return strconv.Quote(str), nil
+}
+
+// jsonField is the field of the message with the JSON name, as the walker
+// names fields.
+func jsonField(msg protoreflect.Message, name string) protoreflect.FieldDescriptor {
+	return msg.Descriptor().Fields().ByJSONName(name)
+}
+
+func line(indent int, text string) string {
+	return strings.Repeat("\t", indent) + text + "\n"
+}
+
+func writeLine(out *strings.Builder, indent int, text string) {
+	out.WriteString(line(indent, text))
+}
+
+// enumName is the name of the value as j5 gives it, without the prefix of the
+// enum, which is how files write it. The prefix is that of the first value,
+// which ends in UNSPECIFIED, enums without one keep their names.
+func enumName(enum protoreflect.EnumDescriptor, value protoreflect.EnumValueDescriptor) string {
+	first := string(enum.Values().Get(0).Name())
+	if !strings.HasSuffix(first, "UNSPECIFIED") {
+		return string(value.Name())
+	}
+	return strings.TrimPrefix(string(value.Name()), strings.TrimSuffix(first, "UNSPECIFIED"))
+}
@@ -0,0 +1,188 @@
+package errpos
+
+import (
+	"errors"
+)
+
+// Code is a stable identifier for a class of error. Codes never change meaning
+// once published, so tools may gate or suppress on them.
+type Code string
+
+// Schema structure errors, the file doesn't match the shape of the schema.
+const (
+	CodeUnknownBlock        Code = "BCL1001" // no block or attribute with the name in scope
+	CodeNotContainer        Code = "BCL1002" // block syntax used for a scalar field
+	CodeExpectedTag         Code = "BCL1003" // a required block header tag is missing
+	CodeUnexpectedTag       Code = "BCL1004" // more block header tags than the block defines
+	CodeUnexpectedQualifier Code = "BCL1005" // more qualifiers than the block defines
+	CodeNoDescription       Code = "BCL1006" // description given for a block without a description field
+	CodeUnresolvedReference Code = "BCL1007" // a reference field names no block in the project
+	CodeAmbiguousReference  Code = "BCL1008" // a reference field names more than one block
+	CodeReferenceCycle      Code = "BCL1009" // blocks which depend on each other through references
+	CodeDuplicateName       Code = "BCL1010" // a scoped name defined more than once in the project
+	CodeNotFlag             Code = "BCL1011" // a negated statement which doesn't name a flag
+	CodeUnknownType         Code = "BCL1012" // the type tag of an Any block names no known message
+	CodeUnknownSchema       Code = "BCL1013" // the !schema header names no registered schema
+	CodeOverrideBlock       Code = "BCL1014" // a block in an override file which is not in its base file
+	CodeDuplicateBlock      Code = "BCL1015" // blocks of a schema with the same identity fields
+	CodeMaxRecursion        Code = "BCL1016" // blocks of a schema nested in each other deeper than the limit
+	CodeTypeAttribute       Code = "BCL1017" // the attribute selecting a block's type is missing, or after other statements
+	CodeDefaults            Code = "BCL1018" // a statement in a defaults block other than setting a value
+	CodeNamespace           Code = "BCL1019" // a statement outside the namespace blocks of a namespaced file, or a namespace without a name
+	CodeDocuments           Code = "BCL1020" // a '---' separator inside a block, or in a file parsed as one document
+)
+
+// Value errors, the shape is right but the value is not.
+const (
+	CodeAlreadySet    Code = "BCL2001" // a scalar was set twice
+	CodeInvalidValue  Code = "BCL2002" // the value could not be parsed for the field
+	CodeTypeMismatch  Code = "BCL2003" // the value type doesn't match the field
+	CodeValueCount    Code = "BCL2004" // wrong number of values for a scalar split
+	CodeConstraint    Code = "BCL2005" // the value violates a constraint in the block spec
+	CodeInterpolation Code = "BCL2006" // a ${} in a block name which can't be evaluated
+	CodeFunction      Code = "BCL2007" // a call to an unknown or disallowed function, or which failed
+	CodeOneofConflict Code = "BCL2008" // a second member of a oneof set in the same block
+	CodeDerived       Code = "BCL2009" // a derived field which the file set, or whose expression or hook failed
+	CodeSensitive     Code = "BCL2010" // a sensitive value which doesn't suit its field or argument, the reason redacted
+)
+
+// Syntax errors from the lexer and parser.
+const (
+	CodeUnexpectedChar  Code = "BCL3001"
+	CodeUnexpectedEOF   Code = "BCL3002"
+	CodeInvalidEscape   Code = "BCL3003"
+	CodeUnexpectedToken Code = "BCL3004"
+	CodeUnbalancedBlock Code = "BCL3005"
+	CodeInvalidNumber   Code = "BCL3006"
+	CodeUnexpectedEOL   Code = "BCL3007"
+	CodeLimitExceeded   Code = "BCL3008" // a size limit set by the caller was exceeded
+	CodeEdition         Code = "BCL3009" // syntax which is not in the file's edition
+)
+
+// Validation errors raised after the file is walked.
+const (
+	CodeValidation Code = "BCL4001" // protovalidate rule violation
+	CodePolicy     Code = "BCL4002" // a CEL policy of the block spec is false
+)
+
+// Lint findings, which are reported by the linter but do not fail a parse.
+const (
+	CodeUnusedSuppression Code = "BCL5001" // a bcl:ignore comment which matched nothing
+	CodeUnreferenced      Code = "BCL5002" // a reference target which nothing references
+	CodeReservedWord      Code = "BCL5003" // a reserved word used as a bare name
+	CodeNaming            Code = "BCL5004" // a block name or map key not in the case configured for it
+	CodeSpelling          Code = "BCL5005" // a word of a description or prose field not in the dictionary
+	CodeDeprecated        Code = "BCL5006" // a name written as an alias which the block spec deprecates
+	CodeEnumName          Code = "BCL5007" // an enum value written other than as the name of its option
+)
+
+// Internal errors, which indicate a problem with the schema or the parser
+// rather than the file.
+const (
+	CodeSchemaError Code = "BCL9001"
+)
+
+var codeNames = map[Code]string{
+	CodeUnknownBlock:        "unknown-block",
+	CodeNotContainer:        "not-container",
+	CodeExpectedTag:         "expected-tag",
+	CodeUnexpectedTag:       "unexpected-tag",
+	CodeUnexpectedQualifier: "unexpected-qualifier",
+	CodeNoDescription:       "no-description",
+	CodeUnresolvedReference: "unresolved-reference",
+	CodeAmbiguousReference:  "ambiguous-reference",
+	CodeReferenceCycle:      "reference-cycle",
+	CodeUnknownType:         "unknown-type",
+	CodeUnknownSchema:       "unknown-schema",
+	CodeOverrideBlock:       "override-block",
+	CodeDuplicateBlock:      "duplicate-block",
+	CodeDuplicateName:       "duplicate-name",
+	CodeNotFlag:             "not-flag",
+	CodeMaxRecursion:        "max-recursion",
+	CodeTypeAttribute:       "type-attribute",
+	CodeDefaults:            "defaults",
+	CodeNamespace:           "namespace",
+	CodeDocuments:           "documents",
+	CodeAlreadySet:          "already-set",
+	CodeInvalidValue:        "invalid-value",
+	CodeTypeMismatch:        "type-mismatch",
+	CodeValueCount:          "value-count",
+	CodeConstraint:          "constraint",
+	CodeInterpolation:       "interpolation",
+	CodeFunction:            "function",
+	CodeOneofConflict:       "oneof-conflict",
+	CodeDerived:             "derived",
+	CodeSensitive:           "sensitive-value",
+	CodeUnexpectedChar:      "unexpected-character",
+	CodeUnexpectedEOF:       "unexpected-eof",
+	CodeInvalidEscape:       "invalid-escape",
+	CodeUnexpectedToken:     "unexpected-token",
+	CodeUnbalancedBlock:     "unbalanced-block",
+	CodeInvalidNumber:       "invalid-number",
+	CodeUnexpectedEOL:       "unexpected-eol",
+	CodeLimitExceeded:       "limit-exceeded",
+	CodeEdition:             "edition",
+	CodeValidation:          "validation",
+	CodePolicy:              "policy",
+	CodeUnusedSuppression:   "unused-suppression",
+	CodeUnreferenced:        "unreferenced",
+	CodeReservedWord:        "reserved-word",
+	CodeNaming:              "naming",
+	CodeSpelling:            "spelling",
+	CodeDeprecated:          "deprecated",
+	CodeEnumName:            "enum-name",
+	CodeSchemaError:         "schema-error",
+}
+
+// Name returns the short human name of the code, e.g. unknown-block, or an
+// empty string for codes not defined in this package.
+func (c Code) Name() string {
+	return codeNames[c]
+}
+
+func (c Code) String() string {
+	return string(c)
+}
+
+// HasCode is implemented by errors which identify their own Code
+type HasCode interface {
+	error
+	ErrorCode() Code
+}
+
+// GetErrorCode returns the first code found in the error chain, or an empty
+// code.
+func GetErrorCode(err error) Code {
+	var coded HasCode
+	if errors.As(err, &coded) {
+		return coded.ErrorCode()
+	}
+	return ""
+}
+
+// WithCode adds a code to an error.
+// If the error is nil, returns nil.
+// If the code is empty, or the error already has a code, it is returned
+// unmodified, as the existing value is likely more specific.
+func WithCode(err error, code Code) error {
+	if err == nil {
+		return nil
+	}
+
+	if code == "" || GetErrorCode(err) != "" {
+		return err
+	}
+
+	existing := &Err{}
+	if !errors.As(err, &existing) {
+		return &Err{
+			Pos:  GetErrorPosition(err),
+			Code: code,
+			Err:  err,
+		}
+	}
+
+	existing.mergeErr(err, "Code")
+	existing.Code = code
+	return existing
+}
@@ -0,0 +1,176 @@
+package errpos
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The columns of a Point count the runes before it on its line, after any
+// byte order mark. LSP clients count UTF-16 code units by default, and
+// terminals count cells, the functions here convert between them.
+
+// UTF16Column converts a column of the line, in runes, to UTF-16 code units,
+// where runes outside the basic multilingual plane, such as emoji, count two.
+// Columns past the end of the line count one unit each, for the end of line.
+func UTF16Column(line string, column int) int {
+	units := 0
+	for _, r := range line {
+		if column <= 0 {
+			return units
+		}
+		units += utf16Len(r)
+		column--
+	}
+	return units + column
+}
+
+// RuneColumn converts a column of the line in UTF-16 code units, as from an
+// LSP client, back to runes. A column inside a surrogate pair is the rune.
+func RuneColumn(line string, utf16Column int) int {
+	column := 0
+	for _, r := range line {
+		if utf16Column <= 0 {
+			return column
+		}
+		utf16Column -= utf16Len(r)
+		column++
+	}
+	return column + max(utf16Column, 0)
+}
+
+// DisplayColumn converts a column of the line, in runes, to the terminal
+// cell it is shown in. Tabs advance to the next multiple of tabWidth, East
+// Asian wide runes take two cells and combining marks none.
+func DisplayColumn(line string, column int, tabWidth int) int {
+	cells := 0
+	for _, r := range line {
+		if column <= 0 {
+			return cells
+		}
+		cells += runeCells(r, cells, tabWidth)
+		column--
+	}
+	return cells + column
+}
+
+// utf16Len is the number of UTF-16 code units of the rune.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// LineIndex converts the points of one source between column units, e.g.
+// for the positions of diagnostics sent to an editor.
+type LineIndex struct {
+	lines []string
+}
+
+// NewLineIndex indexes the lines of the source, as SplitLines counts them.
+func NewLineIndex(source string) *LineIndex {
+	return &LineIndex{lines: sourceLines(source)}
+}
+
+func (li *LineIndex) line(line int) string {
+	if line < 0 || line >= len(li.lines) {
+		return ""
+	}
+	return li.lines[line]
+}
+
+// ToUTF16 converts the column of the point to UTF-16 code units.
+func (li *LineIndex) ToUTF16(p Point) Point {
+	return Point{Line: p.Line, Column: UTF16Column(li.line(p.Line), p.Column)}
+}
+
+// FromUTF16 converts a point with a column in UTF-16 code units to runes.
+func (li *LineIndex) FromUTF16(p Point) Point {
+	return Point{Line: p.Line, Column: RuneColumn(li.line(p.Line), p.Column)}
+}
+
+// Display converts the column of the point to the terminal cell it is shown
+// in, with tab stops every tabWidth cells.
+func (li *LineIndex) Display(p Point, tabWidth int) Point {
+	return Point{Line: p.Line, Column: DisplayColumn(li.line(p.Line), p.Column, tabWidth)}
+}
+
+// runeCells is the number of cells the rune takes when shown at the cell.
+func runeCells(r rune, cell int, tabWidth int) int {
+	switch {
+	case r == '\t':
+		if tabWidth < 1 {
+			tabWidth = 1
+		}
+		return tabWidth - cell%tabWidth
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	}
+	return 1
+}
+
+// isWide is true for the East Asian wide and fullwidth runes, and emoji,
+// which terminals show in two cells.
+func isWide(r rune) bool {
+	if r < 0x1100 {
+		return false
+	}
+	for _, span := range wideRunes {
+		if r >= span[0] && r <= span[1] {
+			return true
+		}
+	}
+	return false
+}
+
+var wideRunes = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals and punctuation
+	{0x3041, 0x33FF},   // Kana and CJK compatibility
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental symbols and pictographs
+	{0x20000, 0x3FFFD}, // CJK extensions B and later
+}
+
+// expandTabs returns the line as shown in a terminal, with tabs to the next
+// tab stop.
+func expandTabs(line string, tabWidth int) string {
+	if !strings.ContainsRune(line, '\t') {
+		return line
+	}
+	out := &strings.Builder{}
+	cells := 0
+	for _, r := range line {
+		width := runeCells(r, cells, tabWidth)
+		if r == '\t' {
+			out.WriteString(strings.Repeat(" ", width))
+		} else {
+			out.WriteRune(r)
+		}
+		cells += width
+	}
+	return out.String()
+}
+
+// markLine returns the marker line for the runes of line from from to to (0
+// based, to is exclusive), aligned with the cells of expandTabs. Empty spans
+// are marked with a single caret at from.
+func markLine(line string, from, to int, tabWidth int) string {
+	from = max(from, 0)
+	start := DisplayColumn(line, from, tabWidth)
+	if to <= from {
+		return strings.Repeat(" ", start) + "^"
+	}
+	end := DisplayColumn(line, to, tabWidth)
+	return strings.Repeat(" ", start) + strings.Repeat("^", max(end-start, 1))
+}
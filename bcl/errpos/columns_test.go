@@ -0,0 +1,61 @@
+package errpos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	for _, tc := range []struct {
+		line    string
+		column  int
+		utf16   int
+		display int
+	}{
+		{line: "a = 1", column: 4, utf16: 4, display: 4},
+		{line: "é = 1", column: 2, utf16: 2, display: 2},
+		{line: "x = \"😀\" y", column: 7, utf16: 8, display: 8},
+		{line: "名前 = 1", column: 3, utf16: 3, display: 5},
+		{line: "\tkey", column: 1, utf16: 1, display: 4},
+		{line: "ab\tkey", column: 3, utf16: 3, display: 4},
+		{line: "ab", column: 3, utf16: 3, display: 3},
+	} {
+		if got := UTF16Column(tc.line, tc.column); got != tc.utf16 {
+			t.Errorf("UTF16Column(%q, %d) = %d, want %d", tc.line, tc.column, got, tc.utf16)
+		}
+		if got := RuneColumn(tc.line, tc.utf16); got != tc.column {
+			t.Errorf("RuneColumn(%q, %d) = %d, want %d", tc.line, tc.utf16, got, tc.column)
+		}
+		if got := DisplayColumn(tc.line, tc.column, 4); got != tc.display {
+			t.Errorf("DisplayColumn(%q, %d) = %d, want %d", tc.line, tc.column, got, tc.display)
+		}
+	}
+
+	index := NewLineIndex("a = 1\r\n😀 = \"é\"\n")
+	if got := index.ToUTF16(Point{Line: 1, Column: 4}); got != (Point{Line: 1, Column: 5}) {
+		t.Errorf("ToUTF16 = %v", got)
+	}
+	if got := index.FromUTF16(Point{Line: 1, Column: 5}); got != (Point{Line: 1, Column: 4}) {
+		t.Errorf("FromUTF16 = %v", got)
+	}
+}
+
+func TestRenderWide(t *testing.T) {
+	filename := "in.bcl"
+	ews := &ErrorsWithSource{
+		lines: []string{"名前 = x"},
+		Errors: Errors{{
+			Pos: &Position{
+				Filename: &filename,
+				Start:    Point{Line: 0, Column: 5},
+				End:      Point{Line: 0, Column: 5},
+			},
+			Err: errors.New("bad value"),
+		}},
+	}
+	got := ews.RenderString(RenderOptions{})
+	want := "1 | 名前 = x\n  |        ^\n"
+	if len(got) < len(want) || got[len(got)-len(want):] != want {
+		t.Fatalf("render mismatch\nGOT:\n%s\nWANT suffix:\n%s", got, want)
+	}
+}
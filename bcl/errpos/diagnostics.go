@@ -0,0 +1,194 @@
+package errpos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FileErrors is the errors of one file among Diagnostics, with the source of
+// the file for rendering when it was added.
+type FileErrors struct {
+	Filename string
+	ErrorsWithSource
+}
+
+// Diagnostics collects the errors from parsing many files into one error,
+// grouped by file. Files are ordered by filename and the errors of each file
+// by position, so the output is stable whatever order the files were parsed
+// in.
+type Diagnostics struct {
+	Files []*FileErrors
+}
+
+// Add adds the errors from parsing filename. Errors from AddSourceFile keep
+// their source. Other errors are positioned in the file, without one.
+func (d *Diagnostics) Add(filename string, err error) {
+	if err == nil {
+		return
+	}
+
+	var errs Errors
+	var lines []string
+	if withSource, ok := AsErrorsWithSource(err); ok {
+		errs = withSource.Errors
+		lines = withSource.lines
+	} else if asErrs, ok := AsErrors(err); ok {
+		errs = setFilenames(asErrs, filename)
+	} else {
+		errs = setFilenames(Errors{{Err: err}}, filename)
+	}
+
+	file := d.file(filename)
+	file.Errors = append(file.Errors, errs...)
+	if file.lines == nil {
+		file.lines = lines
+	}
+	sortErrors(file.Errors)
+}
+
+func (d *Diagnostics) file(filename string) *FileErrors {
+	idx := sort.Search(len(d.Files), func(i int) bool {
+		return d.Files[i].Filename >= filename
+	})
+	if idx < len(d.Files) && d.Files[idx].Filename == filename {
+		return d.Files[idx]
+	}
+	file := &FileErrors{Filename: filename}
+	d.Files = append(d.Files, nil)
+	copy(d.Files[idx+1:], d.Files[idx:])
+	d.Files[idx] = file
+	return file
+}
+
+func sortErrors(errs Errors) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].Pos, errs[j].Pos
+		switch {
+		case a == nil || b == nil:
+			return a == nil && b != nil
+		case a.Start.Line != b.Start.Line:
+			return a.Start.Line < b.Start.Line
+		case a.Start.Column != b.Start.Column:
+			return a.Start.Column < b.Start.Column
+		default:
+			return errs[i].Code < errs[j].Code
+		}
+	})
+}
+
+// Err returns the diagnostics as an error, or nil when no file had errors.
+func (d *Diagnostics) Err() error {
+	if len(d.Files) == 0 {
+		return nil
+	}
+	return d
+}
+
+// Errors returns the errors of every file, in order.
+func (d *Diagnostics) Errors() Errors {
+	var all Errors
+	for _, file := range d.Files {
+		all = append(all, file.Errors...)
+	}
+	return all
+}
+
+func (d *Diagnostics) Error() string {
+	errs := d.Errors()
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	lines := make([]string, 0, len(errs)+1)
+	lines = append(lines, d.Summary().String())
+	for _, err := range errs {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ErrorCode returns the code of the first error, matching Error()
+func (d *Diagnostics) ErrorCode() Code {
+	return d.Errors().ErrorCode()
+}
+
+// Unwrap returns the errors of each file, so AsErrorsWithSource finds those
+// of the first file.
+func (d *Diagnostics) Unwrap() []error {
+	errs := make([]error, len(d.Files))
+	for idx, file := range d.Files {
+		errs[idx] = &file.ErrorsWithSource
+	}
+	return errs
+}
+
+// HumanString prints the errors of every file as ErrorsWithSource.HumanString,
+// each file under its name.
+func (d *Diagnostics) HumanString(contextLines int) string {
+	out := &strings.Builder{}
+	for _, file := range d.Files {
+		fmt.Fprintf(out, "== %s ==\n", file.Filename)
+		out.WriteString(file.HumanString(contextLines))
+		out.WriteString("\n")
+	}
+	out.WriteString(d.Summary().String())
+	return out.String()
+}
+
+// Summary counts the errors of the diagnostics.
+func (d *Diagnostics) Summary() Summary {
+	summary := Summary{
+		Files:      len(d.Files),
+		BySeverity: map[Severity]int{},
+		ByCode:     map[Code]int{},
+	}
+	for _, err := range d.Errors() {
+		summary.Total++
+		summary.BySeverity[err.Severity]++
+		summary.ByCode[err.ErrorCode()]++
+	}
+	return summary
+}
+
+// Summary is the count of diagnostics, by severity and by code. Errors without
+// a code are counted under the empty code.
+type Summary struct {
+	Files      int
+	Total      int
+	BySeverity map[Severity]int
+	ByCode     map[Code]int
+}
+
+// String is e.g. "3 errors, 1 warning in 2 files".
+func (s Summary) String() string {
+	parts := []string{}
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		count := s.BySeverity[severity]
+		if count == 0 {
+			continue
+		}
+		name := severity.String()
+		if count > 1 {
+			name += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", count, name))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "no errors")
+	}
+	files := "files"
+	if s.Files == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("%s in %d %s", strings.Join(parts, ", "), s.Files, files)
+}
+
+// AsDiagnostics returns the diagnostics of a multi file parse.
+func AsDiagnostics(err error) (*Diagnostics, bool) {
+	var diags *Diagnostics
+	if errors.As(err, &diags) {
+		return diags, true
+	}
+	return nil, false
+}
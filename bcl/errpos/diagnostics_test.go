@@ -0,0 +1,55 @@
+package errpos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiagnostics(t *testing.T) {
+	at := func(line int, code Code, severity Severity) *Err {
+		return &Err{
+			Pos:      &Position{Start: Point{Line: line}},
+			Code:     code,
+			Severity: severity,
+			Err:      errors.New("bad"),
+		}
+	}
+
+	diags := &Diagnostics{}
+	if diags.Err() != nil {
+		t.Fatal("empty diagnostics should not be an error")
+	}
+	diags.Add("b.bcl", AddSourceFile(Errors{
+		at(4, CodeTypeMismatch, SeverityError),
+		at(1, CodeUnknownBlock, SeverityWarning),
+	}, "b.bcl", "a\nb\nc\nd\ne"))
+	diags.Add("a.bcl", errors.New("unreadable"))
+	diags.Add("b.bcl", Errors{at(2, CodeTypeMismatch, SeverityError)})
+	diags.Add("c.bcl", nil)
+
+	if len(diags.Files) != 2 || diags.Files[0].Filename != "a.bcl" || diags.Files[1].Filename != "b.bcl" {
+		t.Fatalf("files not grouped in order: %v", diags.Files)
+	}
+	lines := []int{}
+	for _, err := range diags.Files[1].Errors {
+		lines = append(lines, err.Pos.Start.Line)
+		if err.Pos.Filename == nil || *err.Pos.Filename != "b.bcl" {
+			t.Errorf("error at line %d not in b.bcl", err.Pos.Start.Line)
+		}
+	}
+	if want := []int{1, 2, 4}; len(lines) != 3 || lines[0] != want[0] || lines[1] != want[1] || lines[2] != want[2] {
+		t.Errorf("errors not sorted by position: %v", lines)
+	}
+
+	summary := diags.Summary()
+	if got, want := summary.String(), "3 errors, 1 warning in 2 files"; got != want {
+		t.Errorf("summary %q, want %q", got, want)
+	}
+	if summary.ByCode[CodeTypeMismatch] != 2 || summary.ByCode[""] != 1 {
+		t.Errorf("unexpected counts by code: %v", summary.ByCode)
+	}
+
+	if got, ok := AsDiagnostics(diags.Err()); !ok || got != diags {
+		t.Error("AsDiagnostics did not find the diagnostics")
+	}
+}
@@ -97,6 +97,14 @@ func (e Errors) Error() string {
 	return "multiple syntax errors"
 }
 
+// ErrorCode returns the code of the first error, matching Error()
+func (e Errors) ErrorCode() Code {
+	if len(e) > 0 {
+		return e[0].ErrorCode()
+	}
+	return ""
+}
+
 func AsError(err error) (*Err, bool) {
 	if err == nil {
 		return nil, false
@@ -163,15 +171,61 @@ func AsErrors(err error) (Errors, bool) {
 	return nil, false
 }
 
+// Severity of an error, the zero value is an error, lower severities are
+// reported by linters but do not fail a parse.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
 // Error wraps it all together.
 // Short names are annoying but - duck typing.
 type Err struct {
 	Pos *Position
-	Ctx Context
-	Err error
+
+	// Generated is the position in the file which was parsed, when Pos was
+	// mapped to the source the file was generated from, see MapPositions.
+	Generated *Position
+
+	Ctx      Context
+	Code     Code
+	Severity Severity
+	Fixes    []Fix
+	Err      error
+
+	// Related are other places in the source which explain the error, such
+	// as where an unclosed block was opened.
+	Related []Related
+}
+
+// Related is a place in the source which explains an error.
+type Related struct {
+	Pos     Position
+	Message string
+}
+
+func (r Related) String() string {
+	return fmt.Sprintf("%s at %s", r.Message, r.Pos.String())
 }
 
 var _ HasPosition = &Err{}
+var _ HasCode = &Err{}
 
 func (e *Err) Error() string {
 	parts := make([]string, 0)
@@ -193,6 +247,59 @@ func (e *Err) ErrorPosition() *Position {
 	return e.Pos
 }
 
+// sourcePosition is the position in the parsed file, which the lines of
+// ErrorsWithSource are from.
+func (e *Err) sourcePosition() *Position {
+	if e.Generated != nil {
+		return e.Generated
+	}
+	return e.Pos
+}
+
+// MapPositions moves each error to the position returned by mapPos, keeping
+// the original as Generated. It places errors in a generated file at the
+// source it was generated from.
+func MapPositions(err error, mapPos func(Position) (Position, bool)) error {
+	if err == nil {
+		return nil
+	}
+	var errs Errors
+	if withSource, ok := AsErrorsWithSource(err); ok {
+		errs = withSource.Errors
+	} else if asErrors, ok := AsErrors(err); ok {
+		errs = asErrors
+	}
+	for _, single := range errs {
+		if single.Pos == nil || single.Generated != nil {
+			continue
+		}
+		for idx, related := range single.Related {
+			if mapped, ok := mapPos(related.Pos); ok {
+				single.Related[idx].Pos = mapped
+			}
+		}
+		mapped, ok := mapPos(*single.Pos)
+		if !ok {
+			continue
+		}
+		single.Generated = single.Pos
+		single.Pos = &mapped
+	}
+	return err
+}
+
+// ErrorCode returns the code set on the error, falling back to any code in the
+// wrapped error chain.
+func (e *Err) ErrorCode() Code {
+	if e.Code != "" {
+		return e.Code
+	}
+	if e.Err == nil {
+		return ""
+	}
+	return GetErrorCode(e.Err)
+}
+
 // Unwrap implements errors.Wrapper, but is also useful to get the 'context
 // free' error message.
 func (e *Err) Unwrap() error {
@@ -0,0 +1,122 @@
+package errpos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edit replaces the text from Start up to, but not including, End with
+// NewText. Unlike Position, End is exclusive so that an insert can be
+// expressed with Start == End.
+type Edit struct {
+	Start   Point
+	End     Point
+	NewText string
+}
+
+// Fix is a machine-applicable change which resolves an error.
+type Fix struct {
+	Title string
+	Edits []Edit
+
+	// Safe fixes may be applied in bulk without review, e.g. by bcl lint
+	// --fix, others are only offered as suggestions.
+	Safe bool
+}
+
+// AddFix attaches a fix to the error, wrapping it in an Err if required.
+// If the error is nil, returns nil.
+func AddFix(err error, fix Fix) error {
+	if err == nil {
+		return nil
+	}
+
+	existing := &Err{}
+	if !errors.As(err, &existing) {
+		return &Err{
+			Pos:   GetErrorPosition(err),
+			Fixes: []Fix{fix},
+			Err:   err,
+		}
+	}
+
+	existing.mergeErr(err, "Fix")
+	existing.Fixes = append(existing.Fixes, fix)
+	return existing
+}
+
+// ReplaceFix builds a single edit fix replacing the span of pos, where the end
+// of pos is inclusive as it is for tokens.
+func ReplaceFix(title string, pos Position, newText string) Fix {
+	return Fix{
+		Title: title,
+		Edits: []Edit{{
+			Start:   pos.Start,
+			End:     Point{Line: pos.End.Line, Column: pos.End.Column + 1},
+			NewText: newText,
+		}},
+	}
+}
+
+// ApplyEdits applies the edits to the source text. Edits must not overlap.
+// Points count lines and columns as SplitLines does.
+func ApplyEdits(source string, edits []Edit) (string, error) {
+	lines := SplitLines(source)
+	bom := len(source) - len(strings.TrimPrefix(source, ByteOrderMark))
+	offset := func(p Point) (int, error) {
+		if p.Line < 0 || p.Line > len(lines) {
+			return 0, fmt.Errorf("line %d out of range", p.Line+1)
+		}
+		total := bom
+		for _, line := range lines[:p.Line] {
+			total += len(line)
+		}
+		if p.Line == len(lines) {
+			return total, nil
+		}
+		runes := []rune(lines[p.Line])
+		if p.Column > len(runes) {
+			return 0, fmt.Errorf("column %d out of range on line %d", p.Column+1, p.Line+1)
+		}
+		return total + len(string(runes[:p.Column])), nil
+	}
+
+	type byteEdit struct {
+		start, end int
+		text       string
+	}
+	byteEdits := make([]byteEdit, 0, len(edits))
+	for _, edit := range edits {
+		start, err := offset(edit.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := offset(edit.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("edit ends before it starts at %s", edit.Start)
+		}
+		byteEdits = append(byteEdits, byteEdit{start: start, end: end, text: edit.NewText})
+	}
+
+	sort.SliceStable(byteEdits, func(i, j int) bool {
+		return byteEdits[i].start < byteEdits[j].start
+	})
+
+	out := &strings.Builder{}
+	last := 0
+	for _, edit := range byteEdits {
+		if edit.start < last {
+			return "", fmt.Errorf("overlapping edits")
+		}
+		out.WriteString(source[last:edit.start])
+		out.WriteString(edit.text)
+		last = edit.end
+	}
+	out.WriteString(source[last:])
+	return out.String(), nil
+}
@@ -0,0 +1,229 @@
+package errpos
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type jsonPoint struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type jsonErr struct {
+	Code     Code       `json:"code,omitempty"`
+	Severity string     `json:"severity"`
+	Message  string     `json:"message"`
+	Filename string     `json:"filename,omitempty"`
+	Start    *jsonPoint `json:"start,omitempty"`
+	End      *jsonPoint `json:"end,omitempty"`
+	Context  string     `json:"context,omitempty"`
+	Fixes    []jsonFix  `json:"fixes,omitempty"`
+	Related  []jsonRel  `json:"related,omitempty"`
+}
+
+type jsonRel struct {
+	Message  string    `json:"message"`
+	Filename string    `json:"filename,omitempty"`
+	Start    jsonPoint `json:"start"`
+	End      jsonPoint `json:"end"`
+}
+
+type jsonFix struct {
+	Title string     `json:"title"`
+	Edits []jsonEdit `json:"edits"`
+}
+
+// jsonEdit uses 1 based points like the error, with an exclusive end.
+type jsonEdit struct {
+	Start   jsonPoint `json:"start"`
+	End     jsonPoint `json:"end"`
+	NewText string    `json:"newText"`
+}
+
+// MarshalJSON encodes the error as a flat object. Lines and columns are 1
+// based, matching the String output, and the end point is inclusive.
+func (e *Err) MarshalJSON() ([]byte, error) {
+	out := jsonErr{
+		Code:     e.ErrorCode(),
+		Severity: e.Severity.String(),
+		Message:  "<nil error>",
+		Context:  e.Ctx.String(),
+	}
+	if e.Err != nil {
+		out.Message = e.Err.Error()
+	}
+	if e.Pos != nil {
+		if e.Pos.Filename != nil {
+			out.Filename = *e.Pos.Filename
+		}
+		out.Start = &jsonPoint{Line: e.Pos.Start.Line + 1, Column: e.Pos.Start.Column + 1}
+		out.End = &jsonPoint{Line: e.Pos.End.Line + 1, Column: e.Pos.End.Column + 1}
+	}
+	for _, fix := range e.Fixes {
+		jf := jsonFix{Title: fix.Title}
+		for _, edit := range fix.Edits {
+			jf.Edits = append(jf.Edits, jsonEdit{
+				Start:   jsonPoint{Line: edit.Start.Line + 1, Column: edit.Start.Column + 1},
+				End:     jsonPoint{Line: edit.End.Line + 1, Column: edit.End.Column + 1},
+				NewText: edit.NewText,
+			})
+		}
+		out.Fixes = append(out.Fixes, jf)
+	}
+	for _, related := range e.Related {
+		jr := jsonRel{
+			Message: related.Message,
+			Start:   jsonPoint{Line: related.Pos.Start.Line + 1, Column: related.Pos.Start.Column + 1},
+			End:     jsonPoint{Line: related.Pos.End.Line + 1, Column: related.Pos.End.Column + 1},
+		}
+		if related.Pos.Filename != nil {
+			jr.Filename = *related.Pos.Filename
+		}
+		out.Related = append(out.Related, jr)
+	}
+	return json.Marshal(out)
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRule struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               *int                  `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation,omitempty"`
+	Region           sarifRegion            `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func sarifLocationOf(pos Position) sarifLocation {
+	loc := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			Region: sarifRegion{
+				StartLine:   pos.Start.Line + 1,
+				StartColumn: pos.Start.Column + 1,
+				EndLine:     pos.End.Line + 1,
+				// SARIF end columns are exclusive
+				EndColumn: pos.End.Column + 2,
+			},
+		},
+	}
+	if pos.Filename != nil {
+		loc.PhysicalLocation.ArtifactLocation = &sarifArtifactLocation{
+			URI: *pos.Filename,
+		}
+	}
+	return loc
+}
+
+// WriteSARIF writes the errors as a SARIF 2.1.0 log with a single run for the
+// named tool.
+func WriteSARIF(w io.Writer, toolName, toolVersion string, errs Errors) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:    toolName,
+				Version: toolVersion,
+			},
+		},
+		Results: make([]sarifResult, 0, len(errs)),
+	}
+
+	seenRules := map[Code]bool{}
+	for _, err := range errs {
+		code := err.ErrorCode()
+		if code != "" && !seenRules[code] {
+			seenRules[code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+				ID:   code.String(),
+				Name: code.Name(),
+			})
+		}
+
+		level := "error"
+		switch err.Severity {
+		case SeverityWarning:
+			level = "warning"
+		case SeverityInfo:
+			level = "note"
+		}
+
+		result := sarifResult{
+			RuleID:  code.String(),
+			Level:   level,
+			Message: sarifMessage{Text: "<nil error>"},
+		}
+		if err.Err != nil {
+			result.Message.Text = err.Err.Error()
+		}
+
+		if err.Pos != nil {
+			result.Locations = []sarifLocation{sarifLocationOf(*err.Pos)}
+		}
+		for idx, related := range err.Related {
+			loc := sarifLocationOf(related.Pos)
+			loc.ID = &idx
+			loc.Message = &sarifMessage{Text: related.Message}
+			result.RelatedLocations = append(result.RelatedLocations, loc)
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	})
+}
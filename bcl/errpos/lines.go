@@ -0,0 +1,56 @@
+package errpos
+
+import "strings"
+
+// ByteOrderMark may start a file. It is not part of the first line, so
+// columns are counted from after it.
+const ByteOrderMark = "\ufeff"
+
+// SplitLines splits the source after each line break, which is "\n", "\r\n"
+// or a lone "\r", counting lines as the lexer does. Like strings.SplitAfter,
+// the lines keep their line breaks and a source ending with one has an empty
+// last line. A byte order mark at the start is left out of the first line.
+func SplitLines(source string) []string {
+	source = strings.TrimPrefix(source, ByteOrderMark)
+	lines := make([]string, 0, strings.Count(source, "\n")+1)
+	start := 0
+	for idx := 0; idx < len(source); idx++ {
+		switch source[idx] {
+		case '\r':
+			if idx+1 < len(source) && source[idx+1] == '\n' {
+				idx++
+			}
+		case '\n':
+		default:
+			continue
+		}
+		lines = append(lines, source[start:idx+1])
+		start = idx + 1
+	}
+	return append(lines, source[start:])
+}
+
+// sourceLines are the lines of the source without their line breaks.
+func sourceLines(source string) []string {
+	lines := SplitLines(source)
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, "\r\n")
+	}
+	return lines
+}
+
+// SourceText is the text of the source from start to the inclusive end, as
+// the positions of tokens are, counting lines and columns as SplitLines does.
+func SourceText(source string, start, end Point) string {
+	lines := SplitLines(source)
+	if start.Line == end.Line {
+		return string([]rune(lines[start.Line])[start.Column : end.Column+1])
+	}
+	out := &strings.Builder{}
+	out.WriteString(string([]rune(lines[start.Line])[start.Column:]))
+	for _, line := range lines[start.Line+1 : end.Line] {
+		out.WriteString(line)
+	}
+	out.WriteString(string([]rune(lines[end.Line])[:end.Column+1]))
+	return out.String()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
 type ErrorsWithSource struct {
@@ -40,6 +41,10 @@ func (e ErrorsWithSource) Error() string {
 	return "<ErrorsWithWource[]>"
 }
 
+func (e ErrorsWithSource) ErrorCode() Code {
+	return e.Errors.ErrorCode()
+}
+
 func AsErrorsWithSource(err error) (*ErrorsWithSource, bool) {
 	var posErr *ErrorsWithSource
 	ok := errors.As(err, &posErr)
@@ -63,8 +68,11 @@ func humanString(err *Err, lines []string, context int) string {
 			return
 		}
 		out.WriteString(fmt.Sprintf("Position: %s\n", err.Pos.String()))
+		if err.Generated != nil {
+			out.WriteString(fmt.Sprintf("Generated: %s\n", err.Generated.String()))
+		}
 
-		pos := *err.Pos
+		pos := *err.sourcePosition()
 
 		startLine := pos.Start.Line + 1
 		startCol := pos.Start.Column + 1
@@ -80,7 +88,7 @@ func humanString(err *Err, lines []string, context int) string {
 			}
 			line := lines[lineNum-1]
 			out.WriteString(fmt.Sprintf("  > %03d: ", lineNum))
-			out.WriteString(tabsToSpaces(line))
+			out.WriteString(expandTabs(line, 2))
 			out.WriteString("\n")
 			context--
 		}
@@ -96,15 +104,16 @@ func humanString(err *Err, lines []string, context int) string {
 		prefix := fmt.Sprintf("  > %03d", startLine)
 		out.WriteString(prefix)
 		out.WriteString(": ")
-		out.WriteString(tabsToSpaces(errLine))
+		out.WriteString(expandTabs(errLine, 2))
 		out.WriteString("\n")
 
-		if startCol == len(errLine)+1 {
+		lineLen := utf8.RuneCountInString(errLine)
+		if startCol == lineLen+1 {
 			// allows for the column to reference the EOF or EOL
-			errLine += " "
+			lineLen++
 		}
 
-		if startCol < 1 || startCol > len(errLine) {
+		if startCol < 1 || startCol > lineLen {
 			// negative columns should not occur but let's not crash.
 			out.WriteString(strings.Repeat(">", len(prefix)))
 			out.WriteString(": ")
@@ -113,24 +122,27 @@ func humanString(err *Err, lines []string, context int) string {
 			return
 		}
 
-		errCol := replaceRunes(errLine[:startCol-1], func(r string) string {
-			if r == "\t" {
-				return "  "
-			}
-			return " "
-		})
-
 		out.WriteString(strings.Repeat(">", len(prefix)))
 		out.WriteString(": ")
-		out.WriteString(errCol)
-		out.WriteString("^\n")
+		out.WriteString(markLine(errLine, startCol-1, startCol-1, 2))
+		out.WriteString("\n")
 
 	}()
+	for _, related := range err.Related {
+		out.WriteString("Related: ")
+		out.WriteString(related.String())
+		out.WriteString("\n")
+	}
 	if err.Ctx != nil {
 		out.WriteString("Context: ")
 		out.WriteString(err.Ctx.String())
 		out.WriteString("\n")
 	}
+	if code := err.ErrorCode(); code != "" {
+		out.WriteString("Code: ")
+		out.WriteString(code.String())
+		out.WriteString("\n")
+	}
 	if err.Err != nil {
 		out.WriteString("Message: ")
 		out.WriteString(err.Err.Error())
@@ -139,24 +151,6 @@ func humanString(err *Err, lines []string, context int) string {
 	return out.String()
 }
 
-func tabsToSpaces(s string) string {
-	return replaceRunes(s, func(r string) string {
-		if r == "\t" {
-			return "  "
-		}
-		return r
-	})
-}
-
-func replaceRunes(s string, cb func(string) string) string {
-	runes := []rune(s)
-	out := make([]string, 0, len(runes))
-	for i := 0; i < len(runes); i++ {
-		out = append(out, cb(string(runes[i])))
-	}
-	return strings.Join(out, "")
-}
-
 func MustAddSource(err error, fileSource string) (*ErrorsWithSource, error) {
 	input, ok := AsErrors(err)
 	if !ok {
@@ -164,20 +158,27 @@ func MustAddSource(err error, fileSource string) (*ErrorsWithSource, error) {
 	}
 
 	return &ErrorsWithSource{
-		lines:  strings.Split(fileSource, "\n"),
+		lines:  sourceLines(fileSource),
 		Errors: input,
 	}, nil
 }
 
 func setFilenames(input Errors, filename string) Errors {
 	for idx, err := range input {
-		if err.Pos == nil {
+		if err.Generated != nil {
+			err.Generated.Filename = &filename
+		} else if err.Pos == nil {
 			err.Pos = &Position{
 				Filename: &filename,
 			}
 		} else {
 			err.Pos.Filename = &filename
 		}
+		for related := range err.Related {
+			if err.Related[related].Pos.Filename == nil {
+				err.Related[related].Pos.Filename = &filename
+			}
+		}
 		input[idx] = err
 	}
 
@@ -188,7 +189,7 @@ func AddSourceFile(err error, filename string, fileData string) error {
 	if withSource, ok := AsErrorsWithSource(err); ok {
 		errors := setFilenames(withSource.Errors, filename)
 		return &ErrorsWithSource{
-			lines:  strings.Split(fileData, "\n"),
+			lines:  sourceLines(fileData),
 			Errors: errors,
 		}
 	}
@@ -202,7 +203,7 @@ func AddSourceFile(err error, filename string, fileData string) error {
 	input = setFilenames(input, filename)
 
 	return &ErrorsWithSource{
-		lines:  strings.Split(fileData, "\n"),
+		lines:  sourceLines(fileData),
 		Errors: input,
 	}
 }
@@ -214,7 +215,7 @@ func AddSource(err error, fileData string) error {
 	}
 
 	return &ErrorsWithSource{
-		lines:  strings.Split(fileData, "\n"),
+		lines:  sourceLines(fileData),
 		Errors: input,
 	}
 }
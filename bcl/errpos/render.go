@@ -0,0 +1,184 @@
+package errpos
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[31m"
+	ansiBlue   = "\x1b[34m"
+	ansiYellow = "\x1b[33m"
+)
+
+// RenderOptions controls the compiler-style output of Render.
+type RenderOptions struct {
+	// Color wraps the headings, gutter and markers in ANSI escape codes.
+	Color bool
+
+	// ContextLines is the number of lines printed before the first line of
+	// the error.
+	ContextLines int
+
+	// TabWidth is the distance between tab stops when source lines are
+	// printed, the markers line up with the cells of each rune. Zero uses
+	// two.
+	TabWidth int
+}
+
+func (o RenderOptions) tabWidth() int {
+	if o.TabWidth < 1 {
+		return 2
+	}
+	return o.TabWidth
+}
+
+type renderer struct {
+	out   io.Writer
+	opts  RenderOptions
+	lines []string
+}
+
+func (r renderer) style(code string, s string) string {
+	if !r.opts.Color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// Render writes each error with the offending source lines, underlining the
+// span of the error, in the style of modern compiler output:
+//
+//	error[BCL3005]: unexpected close block
+//	  --> file.bcl:3:1
+//	   |
+//	 2 |     key = "value"
+//	 3 | }}
+//	   | ^
+func (e ErrorsWithSource) Render(w io.Writer, opts RenderOptions) error {
+	r := renderer{
+		out:   w,
+		opts:  opts,
+		lines: e.lines,
+	}
+	for idx, err := range e.Errors {
+		if idx > 0 {
+			if _, wErr := fmt.Fprintln(w); wErr != nil {
+				return wErr
+			}
+		}
+		if wErr := r.renderErr(err); wErr != nil {
+			return wErr
+		}
+	}
+	return nil
+}
+
+// RenderString is a convenience for Render into a string.
+func (e ErrorsWithSource) RenderString(opts RenderOptions) string {
+	out := &strings.Builder{}
+	_ = e.Render(out, opts)
+	return out.String()
+}
+
+func (r renderer) renderErr(err *Err) error {
+	out := &strings.Builder{}
+
+	msg := "<nil error>"
+	if err.Err != nil {
+		msg = err.Err.Error()
+	}
+	heading := err.Severity.String()
+	if code := err.ErrorCode(); code != "" {
+		heading += "[" + code.String() + "]"
+	}
+	headingColor := ansiRed
+	if err.Severity != SeverityError {
+		headingColor = ansiYellow
+	}
+	out.WriteString(r.style(ansiBold+headingColor, heading))
+	out.WriteString(r.style(ansiBold, ": "+msg))
+	out.WriteString("\n")
+
+	if err.Pos == nil {
+		r.writeContext(out, err, "")
+		_, wErr := io.WriteString(r.out, out.String())
+		return wErr
+	}
+
+	pos := *err.sourcePosition()
+	if pos.End.Line < pos.Start.Line || (pos.End.Line == pos.Start.Line && pos.End.Column < pos.Start.Column) {
+		// No (valid) end, mark the start point only.
+		pos.End = pos.Start
+	}
+	startLine := pos.Start.Line + 1
+	endLine := pos.End.Line + 1
+
+	gutterWidth := len(strconv.Itoa(endLine))
+	gutter := strings.Repeat(" ", gutterWidth)
+
+	fmt.Fprintf(out, "%s%s %s\n", gutter, r.style(ansiBlue, "-->"), err.Pos.String())
+	if err.Generated != nil {
+		fmt.Fprintf(out, "%s %s %s\n", gutter, r.style(ansiBlue, "="), "generated at "+err.Generated.String())
+	}
+
+	if startLine < 1 || startLine > len(r.lines) {
+		fmt.Fprintf(out, "%s %s <line %d out of range (len %d)>\n", gutter, r.style(ansiBlue, "|"), startLine, len(r.lines))
+		r.writeContext(out, err, gutter)
+		_, wErr := io.WriteString(r.out, out.String())
+		return wErr
+	}
+	if endLine > len(r.lines) {
+		endLine = len(r.lines)
+	}
+
+	fmt.Fprintf(out, "%s %s\n", gutter, r.style(ansiBlue, "|"))
+
+	for lineNum := startLine - r.opts.ContextLines; lineNum < startLine; lineNum++ {
+		if lineNum < 1 {
+			continue
+		}
+		r.writeSourceLine(out, lineNum, gutterWidth)
+	}
+
+	for lineNum := startLine; lineNum <= endLine; lineNum++ {
+		r.writeSourceLine(out, lineNum, gutterWidth)
+
+		line := r.lines[lineNum-1]
+		fromCol := 0
+		if lineNum == startLine {
+			fromCol = pos.Start.Column
+		}
+		toCol := len([]rune(line))
+		if lineNum == pos.End.Line+1 {
+			// End columns are inclusive
+			toCol = pos.End.Column + 1
+		}
+		marker := markLine(line, fromCol, toCol, r.opts.tabWidth())
+		fmt.Fprintf(out, "%s %s %s\n", gutter, r.style(ansiBlue, "|"), r.style(ansiBold+ansiRed, marker))
+	}
+
+	r.writeContext(out, err, gutter)
+
+	_, wErr := io.WriteString(r.out, out.String())
+	return wErr
+}
+
+func (r renderer) writeSourceLine(out *strings.Builder, lineNum int, gutterWidth int) {
+	num := fmt.Sprintf("%*d", gutterWidth, lineNum)
+	fmt.Fprintf(out, "%s %s %s\n", r.style(ansiBlue, num), r.style(ansiBlue, "|"), expandTabs(r.lines[lineNum-1], r.opts.tabWidth()))
+}
+
+func (r renderer) writeContext(out *strings.Builder, err *Err, gutter string) {
+	for _, related := range err.Related {
+		fmt.Fprintf(out, "%s %s %s\n", gutter, r.style(ansiBlue, "="), "note: "+related.String())
+	}
+	if len(err.Ctx) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s %s %s\n", gutter, r.style(ansiBlue, "="), r.style(ansiYellow, "context: "+err.Ctx.String()))
+}
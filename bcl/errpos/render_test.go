@@ -0,0 +1,88 @@
+package errpos
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	filename := "in.bcl"
+	ews := &ErrorsWithSource{
+		lines: []string{
+			"a = 1",
+			"\tkey = \"value\"",
+			"b = 2",
+		},
+		Errors: Errors{{
+			Pos: &Position{
+				Filename: &filename,
+				Start:    Point{Line: 1, Column: 1},
+				End:      Point{Line: 1, Column: 3},
+			},
+			Ctx: Context{"root", "key"},
+			Err: errors.New("bad key"),
+		}},
+	}
+
+	got := ews.RenderString(RenderOptions{ContextLines: 1})
+	want := strings.Join([]string{
+		"error: bad key",
+		" --> in.bcl:2:2",
+		"  |",
+		"1 | a = 1",
+		"2 |   key = \"value\"",
+		"  |   ^^^",
+		"  = context: root.key",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("render mismatch\nGOT:\n%s\nWANT:\n%s", got, want)
+	}
+
+	colored := ews.RenderString(RenderOptions{Color: true})
+	if !strings.Contains(colored, ansiRed) {
+		t.Errorf("expected ANSI codes in colored output: %q", colored)
+	}
+}
+
+func TestRenderGenerated(t *testing.T) {
+	filename := "out.bcl"
+	ews := &ErrorsWithSource{
+		lines: []string{
+			"#line lists.tmpl:10",
+			"b = x",
+		},
+		Errors: Errors{{
+			Pos: &Position{
+				Filename: &filename,
+				Start:    Point{Line: 1, Column: 4},
+				End:      Point{Line: 1, Column: 4},
+			},
+			Err: errors.New("bad value"),
+		}},
+	}
+	err := MapPositions(ews, func(pos Position) (Position, bool) {
+		template := "lists.tmpl"
+		pos.Filename = &template
+		pos.Start.Line += 8
+		pos.End.Line += 8
+		return pos, true
+	})
+
+	got := err.(*ErrorsWithSource).RenderString(RenderOptions{})
+	want := strings.Join([]string{
+		"error: bad value",
+		" --> lists.tmpl:10:5",
+		"  = generated at out.bcl:2:5",
+		"  |",
+		"2 | b = x",
+		"  |     ^",
+		"",
+	}, "\n")
+
+	if got != want {
+		t.Fatalf("render mismatch\nGOT:\n%s\nWANT:\n%s", got, want)
+	}
+}
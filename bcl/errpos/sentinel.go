@@ -0,0 +1,71 @@
+package errpos
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinel errors, to branch on the kind of an error with errors.Is rather
+// than matching its message. Errors match the sentinel of their code, and
+// every syntax error matches ErrSyntax.
+var (
+	ErrUnknownBlock  = errors.New("unknown block")   // CodeUnknownBlock
+	ErrNotContainer  = errors.New("not a container") // CodeNotContainer
+	ErrTypeMismatch  = errors.New("type mismatch")   // CodeTypeMismatch
+	ErrAlreadySet    = errors.New("already set")     // CodeAlreadySet
+	ErrInvalidValue  = errors.New("invalid value")   // CodeInvalidValue
+	ErrUnexpectedEOF = errors.New("unexpected EOF")  // CodeUnexpectedEOF
+	ErrLimitExceeded = errors.New("limit exceeded")  // CodeLimitExceeded
+	ErrValidation    = errors.New("validation")      // CodeValidation
+
+	// ErrSyntax matches every syntax error from the lexer and parser, the
+	// BCL3xxx codes.
+	ErrSyntax = errors.New("syntax error")
+
+	// ErrNotScalar matches a value set on a field which is not a scalar, or
+	// not an array of scalars, a kind of ErrTypeMismatch.
+	ErrNotScalar = errors.New("not a scalar")
+)
+
+var codeSentinels = map[Code]error{
+	CodeUnknownBlock:  ErrUnknownBlock,
+	CodeNotContainer:  ErrNotContainer,
+	CodeTypeMismatch:  ErrTypeMismatch,
+	CodeAlreadySet:    ErrAlreadySet,
+	CodeInvalidValue:  ErrInvalidValue,
+	CodeUnexpectedEOF: ErrUnexpectedEOF,
+	CodeLimitExceeded: ErrLimitExceeded,
+	CodeValidation:    ErrValidation,
+}
+
+// is matches the sentinel error of the code.
+func (c Code) is(target error) bool {
+	if c == "" {
+		return false
+	}
+	if target == ErrSyntax {
+		return strings.HasPrefix(string(c), "BCL3")
+	}
+	sentinel, ok := codeSentinels[c]
+	return ok && sentinel == target
+}
+
+// Is matches the sentinel errors of the error's code.
+func (e *Err) Is(target error) bool {
+	return e.ErrorCode().is(target)
+}
+
+// Is reports whether any of the errors is target.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is reports whether any of the errors is target.
+func (e ErrorsWithSource) Is(target error) bool {
+	return e.Errors.Is(target)
+}
@@ -0,0 +1,208 @@
+package bcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Explain describes where the value at path in a parsed message came from,
+// from the source location tree returned with it. The path is dotted, naming
+// fields by their JSON or proto name, list elements by index and map entries
+// by key, e.g. "elements.0.foo.name".
+func Explain(msg proto.Message, locs *bcl_j5pb.SourceLocation, path string) (string, error) {
+	return explain(nil, msg, locs, path)
+}
+
+// Explain describes where the value at path in the file came from, as Explain.
+func (lf LoadedFile[T]) Explain(path string) (string, error) {
+	return explain(&lf.Path, lf.Message, lf.SourceLocation, path)
+}
+
+func explain(filename *string, msg proto.Message, locs *bcl_j5pb.SourceLocation, path string) (string, error) {
+	if locs == nil {
+		return "", fmt.Errorf("no source locations, the file was parsed without them")
+	}
+
+	value := protoreflect.ValueOfMessage(msg.ProtoReflect())
+	var field protoreflect.FieldDescriptor
+	isSet := true
+	element := false
+	sensitive := false
+	loc := locs
+
+	for _, name := range strings.Split(path, ".") {
+		key := name
+		switch {
+		case field != nil && field.IsList() && !element:
+			idx, err := strconv.Atoi(name)
+			if err != nil {
+				return "", fmt.Errorf("%s is a list, %q is not an index", field.Name(), name)
+			}
+			if isSet && idx >= 0 && idx < value.List().Len() {
+				value = value.List().Get(idx)
+			} else {
+				isSet = false
+			}
+			element = true
+
+		case field != nil && field.IsMap() && !element:
+			mapKey, err := parseMapKey(field, name)
+			if err != nil {
+				return "", err
+			}
+			if isSet && value.Map().Has(mapKey) {
+				value = value.Map().Get(mapKey)
+			} else {
+				isSet = false
+			}
+			element = true
+
+		default:
+			var desc protoreflect.MessageDescriptor
+			if field != nil && field.IsMap() {
+				field = field.MapValue()
+			}
+			if field == nil {
+				desc = msg.ProtoReflect().Descriptor()
+			} else if field.Message() != nil {
+				desc = field.Message()
+			} else {
+				return "", fmt.Errorf("%s is a scalar, it has no field %q", field.Name(), name)
+			}
+			field = desc.Fields().ByJSONName(name)
+			if field == nil {
+				field = desc.Fields().ByName(protoreflect.Name(name))
+			}
+			if field == nil {
+				return "", fmt.Errorf("%s has no field %q", desc.FullName(), name)
+			}
+			if isSet {
+				parent := value.Message()
+				isSet = parent.Has(field)
+				value = parent.Get(field)
+			}
+			element = false
+			sensitive = sensitive || isSensitive(field)
+			key = field.JSONName()
+		}
+
+		if loc != nil {
+			loc = loc.Children[key]
+		}
+	}
+
+	out := &strings.Builder{}
+	if isSet && sensitive {
+		fmt.Fprintf(out, "%s = %s\n", path, Redacted)
+	} else if isSet {
+		fmt.Fprintf(out, "%s = %s\n", path, formatExplainValue(field, value, element))
+	} else {
+		fmt.Fprintf(out, "%s is not set\n", path)
+	}
+
+	if loc == nil {
+		out.WriteString("  not mentioned in the file, the schema default applies\n")
+		return out.String(), nil
+	}
+
+	pos := errpos.Position{
+		Filename: filename,
+		Start: errpos.Point{
+			Line:   int(loc.StartLine),
+			Column: int(loc.StartColumn),
+		},
+	}
+	switch loc.Origin {
+	case bcl_j5pb.Origin_ORIGIN_EXPLICIT:
+		fmt.Fprintf(out, "  set at %s\n", pos)
+	case bcl_j5pb.Origin_ORIGIN_SCHEMA:
+		fmt.Fprintf(out, "  set by the schema at %s, on the path to a value set there\n", pos)
+	case bcl_j5pb.Origin_ORIGIN_SYNTHESIZED:
+		fmt.Fprintf(out, "  copied from another value by the statement at %s\n", pos)
+	case bcl_j5pb.Origin_ORIGIN_DERIVED:
+		fmt.Fprintf(out, "  derived from the other fields of the block at %s\n", pos)
+	case bcl_j5pb.Origin_ORIGIN_INHERITED:
+		fmt.Fprintf(out, "  inherited from the defaults at %s\n", pos)
+	default:
+		fmt.Fprintf(out, "  located at %s, origin unknown\n", pos)
+	}
+	return out.String(), nil
+}
+
+func formatExplainValue(field protoreflect.FieldDescriptor, value protoreflect.Value, element bool) string {
+	if field == nil {
+		return "{}"
+	}
+	if !element && field.IsList() {
+		return fmt.Sprintf("[%d elements]", value.List().Len())
+	}
+	if !element && field.IsMap() {
+		return fmt.Sprintf("{%d entries}", value.Map().Len())
+	}
+	if field.IsMap() {
+		field = field.MapValue()
+	}
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return string(enumValue.Name())
+		}
+		return strconv.Itoa(int(value.Enum()))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return fmt.Sprintf("{%s}", field.Message().FullName())
+	case protoreflect.BytesKind:
+		return fmt.Sprintf("%x", value.Bytes())
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// parseMapKey parses a key written in a path as a key of the map field.
+func parseMapKey(field protoreflect.FieldDescriptor, key string) (protoreflect.MapKey, error) {
+	var value protoreflect.Value
+	switch kind := field.MapKey().Kind(); kind {
+	case protoreflect.StringKind:
+		value = protoreflect.ValueOfString(key)
+	case protoreflect.BoolKind:
+		parsed, err := strconv.ParseBool(key)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("%s has bool keys, %q is not one", field.Name(), key)
+		}
+		value = protoreflect.ValueOfBool(parsed)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		parsed, err := strconv.ParseInt(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("%s has %s keys, %q is not one", field.Name(), kind, key)
+		}
+		value = protoreflect.ValueOfInt32(int32(parsed))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		parsed, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("%s has %s keys, %q is not one", field.Name(), kind, key)
+		}
+		value = protoreflect.ValueOfInt64(parsed)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		parsed, err := strconv.ParseUint(key, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("%s has %s keys, %q is not one", field.Name(), kind, key)
+		}
+		value = protoreflect.ValueOfUint32(uint32(parsed))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		parsed, err := strconv.ParseUint(key, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("%s has %s keys, %q is not one", field.Name(), kind, key)
+		}
+		value = protoreflect.ValueOfUint64(parsed)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("%s has %s keys, which are not supported", field.Name(), kind)
+	}
+	return value.MapKey(), nil
+}
@@ -0,0 +1,58 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/internal/walker"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// extensionSet resolves the extensions of Parser.Extensions for the walker.
+type extensionSet struct {
+	p *Parser
+}
+
+func (p *Parser) extensions() walker.Extensions {
+	if p.Extensions == nil {
+		return nil
+	}
+	return extensionSet{p: p}
+}
+
+func (es extensionSet) FindExtension(extendee protoreflect.MessageDescriptor, name string) (protoreflect.ExtensionType, bool) {
+	var found protoreflect.ExtensionType
+	es.p.Extensions.RangeExtensionsByMessage(extendee.FullName(), func(xt protoreflect.ExtensionType) bool {
+		if jsonCamelCase(string(xt.TypeDescriptor().Name())) == name {
+			found = xt
+			return false
+		}
+		return true
+	})
+	return found, found != nil
+}
+
+func (es extensionSet) NewObject(msg protoreflect.Message) (j5reflect.Object, func(), error) {
+	walked, obj, err := es.p.objectFor(msg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return obj, func() { fromView(msg, walked) }, nil
+}
+
+// jsonCamelCase is the JSON name protoc gives a field of the name. The
+// JSONName of an extension is its bracketed full name instead, which a file
+// can't write as a key.
+func jsonCamelCase(name string) string {
+	out := make([]byte, 0, len(name))
+	wasUnderscore := false
+	for idx := 0; idx < len(name); idx++ {
+		c := name[idx]
+		if c != '_' {
+			if wasUnderscore && 'a' <= c && c <= 'z' {
+				c -= 'a' - 'A'
+			}
+			out = append(out, c)
+		}
+		wasUnderscore = c == '_'
+	}
+	return string(out)
+}
@@ -0,0 +1,58 @@
+package bcl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// fieldMask converts the source location tree of a parse into the paths of the
+// fields it set. Repeated and map fields, and messages the tree doesn't
+// descend into, are set as a whole.
+func fieldMask(desc protoreflect.MessageDescriptor, loc *bcl_j5pb.SourceLocation) *fieldmaskpb.FieldMask {
+	paths := []string{}
+	if fields, ok := locationFields(desc, loc); ok {
+		addFieldPaths(&paths, nil, fields, loc)
+	}
+	sort.Strings(paths)
+	return &fieldmaskpb.FieldMask{Paths: paths}
+}
+
+func addFieldPaths(paths *[]string, prefix []string, fields map[string]protoreflect.FieldDescriptor, loc *bcl_j5pb.SourceLocation) {
+	for key, field := range fields {
+		child := loc.Children[key]
+		path := append(prefix[:len(prefix):len(prefix)], string(field.Name()))
+		if field.IsList() || field.IsMap() || field.Message() == nil {
+			*paths = append(*paths, strings.Join(path, "."))
+			continue
+		}
+		childFields, ok := locationFields(field.Message(), child)
+		if !ok || len(childFields) == 0 {
+			*paths = append(*paths, strings.Join(path, "."))
+			continue
+		}
+		addFieldPaths(paths, path, childFields, child)
+	}
+}
+
+// locationFields resolves the keys of the location's children, which are the
+// JSON names of the fields, to the fields of the message. It is false when a
+// key isn't a field, e.g. the children of an Any are those of the packed
+// message.
+func locationFields(desc protoreflect.MessageDescriptor, loc *bcl_j5pb.SourceLocation) (map[string]protoreflect.FieldDescriptor, bool) {
+	fields := make(map[string]protoreflect.FieldDescriptor, len(loc.Children))
+	for key := range loc.Children {
+		field := desc.Fields().ByJSONName(key)
+		if field == nil {
+			field = desc.Fields().ByName(protoreflect.Name(key))
+		}
+		if field == nil {
+			return nil, false
+		}
+		fields[key] = field
+	}
+	return fields, true
+}
@@ -0,0 +1,103 @@
+package bcl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Fingerprint identifies a block of a parsed file, for systems which follow
+// blocks across edits to the file.
+type Fingerprint struct {
+	// Path is the JSON names and indexes of the fields from the root message
+	// to the block, e.g. elements.0.foo
+	Path   string
+	Schema string
+
+	// ID hashes the schema name with the values of the identity fields of the
+	// schema, so it is stable while the other fields change. Schemas without
+	// identity fields are identified by the path, which moving the block
+	// changes.
+	ID string
+
+	// Content hashes the message without its identity fields, so it is
+	// stable while the block moves or is renamed, and changes with any other
+	// field.
+	Content string
+
+	Pos errpos.Position
+}
+
+// fingerprints returns the fingerprint of each message in the tree below the
+// root, in field order. Well known types are values, not blocks, and are left
+// out.
+func (p *Parser) fingerprints(filename string, msg protoreflect.Message, source *bcl_j5pb.SourceLocation) ([]Fingerprint, error) {
+	out := []Fingerprint{}
+	err := visitMessages(msg, source, func(node messageNode) error {
+		if len(node.path) == 0 || node.msg.Descriptor().ParentFile().Package() == "google.protobuf" {
+			return nil
+		}
+		fp, err := p.fingerprint(filename, node.msg, node.located, node.path)
+		if err != nil {
+			return err
+		}
+		out = append(out, fp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (p *Parser) fingerprint(filename string, msg protoreflect.Message, loc *bcl_j5pb.SourceLocation, path []string) (Fingerprint, error) {
+	schemaName := j5SchemaName(msg.Descriptor())
+	fp := Fingerprint{
+		Path:   strings.Join(path, "."),
+		Schema: schemaName,
+		Pos:    locPosition(filename, loc),
+	}
+
+	id := []string{schemaName}
+	identified := false
+	rest := proto.Clone(msg.Interface()).ProtoReflect()
+	for _, fieldName := range p.identity[schemaName] {
+		fd := msg.Descriptor().Fields().ByJSONName(fieldName)
+		if fd == nil {
+			continue
+		}
+		identified = identified || msg.Has(fd)
+		id = append(id, fieldName, msg.Get(fd).String())
+		rest.Clear(fd)
+	}
+	if !identified {
+		id = []string{schemaName, fp.Path}
+	}
+	fp.ID = hashString(strings.Join(id, "\x00"))
+
+	content, err := proto.MarshalOptions{Deterministic: true}.Marshal(rest.Interface())
+	if err != nil {
+		return fp, err
+	}
+	fp.Content = hashString(schemaName + "\x00" + string(content))
+	return fp, nil
+}
+
+// sortMapKeys orders map keys by their string form, for a stable output.
+func sortMapKeys(keys []protoreflect.MapKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+}
+
+// hashString is the first half of the SHA-256 of the string, in hex.
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:16])
+}
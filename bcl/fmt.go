@@ -1,6 +1,12 @@
 package bcl
 
-import "github.com/pentops/bcl.go/internal/parser"
+import (
+	"sort"
+
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
 
 func Fmt(data string) (string, error) {
 	fixed, err := parser.Fmt(string(data))
@@ -9,3 +15,104 @@ func Fmt(data string) (string, error) {
 	}
 	return fixed, nil
 }
+
+// FmtRange formats the statements which overlap the bytes of data from start
+// to end, as Fmt would, and leaves the rest of the data as it is.
+func FmtRange(data string, start, end int) (string, error) {
+	return parser.FmtOptions{}.FmtRange(data, start, end)
+}
+
+// FormatStable returns an error when Fmt drops or adds a comment of the data,
+// or when formatting the output again would change it. It is meant for tests
+// of files and generators which rely on the formatter.
+func FormatStable(data string) error {
+	return parser.CheckFmt(data, Fmt)
+}
+
+// FormatStable is the package level FormatStable for Parser.Fmt with the
+// config. Comments may move with the statements they are above.
+func (p *Parser) FormatStable(data string, root protoreflect.MessageDescriptor, config project.FormatConfig) error {
+	return parser.CheckFmt(data, func(data string) (string, error) {
+		return p.Fmt(data, root, config)
+	})
+}
+
+// Fmt formats a file parsed into messages of type root as the package level
+// Fmt does, with the layout options of the config, first ordering the
+// statements of each block as the config asks, by the block specs of the
+// schema. Blocks which aren't in the schema are only grouped, attributes
+// before blocks.
+func (p *Parser) Fmt(data string, root protoreflect.MessageDescriptor, config project.FormatConfig) (string, error) {
+	if !config.Reorders() {
+		return parser.ConfigOptions(config).Fmt(data)
+	}
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return "", err
+	}
+	return parser.ConfigOptions(config).Fmt(parser.Reorder(data, tree, p.sectionOrder(root, config)))
+}
+
+func (p *Parser) sectionOrder(root protoreflect.MessageDescriptor, config project.FormatConfig) parser.SectionOrder {
+	return func(blocks []*parser.Block, section []parser.Statement) []parser.Statement {
+		path := blockPath(blocks)
+		tags := map[string]bool{}
+		unordered := false
+		if scope, err := p.ScopeAt(root, path...); err == nil {
+			if spec := scope.scope.BlockSpec(); spec != nil {
+				if spec.Name != nil {
+					tags[spec.Name.FieldName] = true
+				}
+				if spec.TypeSelect != nil {
+					tags[spec.TypeSelect.FieldName] = true
+				}
+				unordered = spec.Unordered
+			}
+		}
+
+		attributes := []*parser.Assignment{}
+		for _, statement := range section {
+			if assign, ok := statement.(*parser.Assignment); ok {
+				attributes = append(attributes, assign)
+			}
+		}
+		rank := func(assign *parser.Assignment) int {
+			if config.TagsFirst && tags[assign.Key.String()] {
+				return 0
+			}
+			return 1
+		}
+		sort.SliceStable(attributes, func(i, j int) bool {
+			a, b := attributes[i], attributes[j]
+			if rank(a) != rank(b) {
+				return rank(a) < rank(b)
+			}
+			// Appends to one attribute keep their order, the sort is stable.
+			return config.SortAttributes && unordered && a.Key.String() < b.Key.String()
+		})
+
+		ordered := make([]parser.Statement, 0, len(section))
+		if config.AttributesFirst {
+			for _, assign := range attributes {
+				ordered = append(ordered, assign)
+			}
+			for _, statement := range section {
+				if _, ok := statement.(*parser.Assignment); !ok {
+					ordered = append(ordered, statement)
+				}
+			}
+			return ordered
+		}
+
+		// Attributes move between the places attributes had
+		next := 0
+		for _, statement := range section {
+			if _, ok := statement.(*parser.Assignment); ok {
+				statement = attributes[next]
+				next++
+			}
+			ordered = append(ordered, statement)
+		}
+		return ordered
+	}
+}
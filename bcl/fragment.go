@@ -0,0 +1,50 @@
+package bcl
+
+import (
+	"context"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fragmentFilename names a fragment in the Generated position of its errors.
+const fragmentFilename = "<fragment>"
+
+// FragmentOffset is where a fragment of BCL starts in the document it is
+// embedded in, such as a fenced block in Markdown or a template. Line and
+// Column are 0-based, as in errpos.Point, and the column only offsets the
+// first line of the fragment.
+type FragmentOffset struct {
+	Filename string
+	Line     int
+	Column   int
+}
+
+// Map places a position in the fragment in the host document.
+func (fo FragmentOffset) Map(pos errpos.Position) (errpos.Position, bool) {
+	filename := fo.Filename
+	return errpos.Position{
+		Filename: &filename,
+		Start:    fo.point(pos.Start),
+		End:      fo.point(pos.End),
+	}, true
+}
+
+func (fo FragmentOffset) point(pt errpos.Point) errpos.Point {
+	if pt.Line == 0 {
+		pt.Column += fo.Column
+	}
+	pt.Line += fo.Line
+	return pt
+}
+
+// ParseFragment parses and validates a fragment of BCL embedded in another
+// document, as Parse, with the errors placed in the host document at the
+// offset. The Generated position of each error is its position in the
+// fragment, which the rendered error quotes. A '#line' directive in the
+// fragment takes precedence. Source locations in the result are relative to
+// the fragment.
+func (p *Parser) ParseFragment(at FragmentOffset, data string, msg protoreflect.Message) (*ParseResult, error) {
+	result, err := p.ParseContext(context.Background(), fragmentFilename, data, msg)
+	return result, errpos.MapPositions(err, at.Map)
+}
@@ -0,0 +1,213 @@
+package bcl
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker"
+)
+
+// Function is a pure function which files can call in value position, as
+// name(args...), once registered with Parser.RegisterFunction. Arguments are
+// passed as the Go types of Params, and Call must return the Go type of
+// Result. The result is set on the field as if it were written in the file,
+// so a string result can set an enum or a timestamp.
+type Function = walker.Function
+
+// ValueType is the type of a function argument or result.
+type ValueType = walker.ValueType
+
+const (
+	StringType = walker.StringType // Go string
+	IntType    = walker.IntType    // Go int64
+	FloatType  = walker.FloatType  // Go float64
+	BoolType   = walker.BoolType   // Go bool
+)
+
+// RegisterFunction makes the function callable from files by name. Calls need
+// edition 2 of the language.
+func (p *Parser) RegisterFunction(name string, fn Function) {
+	if p.functions == nil {
+		p.functions = map[string]Function{}
+	}
+	p.functions[name] = fn
+}
+
+// RegisterFunctions registers each function in the map by its key, e.g. the
+// StandardFunctions.
+func (p *Parser) RegisterFunctions(fns map[string]Function) {
+	for name, fn := range fns {
+		p.RegisterFunction(name, fn)
+	}
+}
+
+// AllowFunctions limits the functions which files may call to the names
+// given, calling any other fails with errpos.CodeFunction. Without an
+// allowlist every function may be called.
+func (p *Parser) AllowFunctions(names ...string) {
+	p.allowedFunctions = map[string]bool{}
+	for _, name := range names {
+		p.allowedFunctions[name] = true
+	}
+}
+
+// functionsFor resolves the calls from the file at filename. Registered
+// functions come before the file and secret functions, and enc"..." values
+// are always decrypted.
+func (p *Parser) functionsFor(filename string) walker.FunctionResolver {
+	return func(name string) (Function, error) {
+		if name == parser.EncryptedPrefix {
+			return p.decryptFunction()
+		}
+		fn, ok := p.functions[name]
+		if !ok {
+			fn, ok = p.fileFunction(filename, name)
+		}
+		if !ok {
+			fn, ok = p.secretFunction(name)
+		}
+		if !ok {
+			return Function{}, fmt.Errorf("unknown function %s", name)
+		}
+		if p.allowedFunctions != nil && !p.allowedFunctions[name] {
+			return Function{}, fmt.Errorf("function %s is not allowed", name)
+		}
+		return fn, nil
+	}
+}
+
+// fileFunction returns file(path), which is the content of the file as a
+// string, or filebase64(path), which is the content base64 encoded, for
+// binary files. Content over the string length limit is an error.
+func (p *Parser) fileFunction(filename string, name string) (Function, bool) {
+	var encode func([]byte) string
+	switch name {
+	case "file":
+		encode = func(data []byte) string { return string(data) }
+	case "filebase64":
+		encode = base64.StdEncoding.EncodeToString
+	default:
+		return Function{}, false
+	}
+	if p.Resolver == nil {
+		return Function{}, false
+	}
+
+	return Function{
+		Params: []ValueType{StringType},
+		Result: StringType,
+		Call: func(args []interface{}) (interface{}, error) {
+			pathname, err := p.Resolver.Resolve(filename, args[0].(string))
+			if err != nil {
+				return nil, err
+			}
+			data, err := p.Resolver.ReadFile(pathname)
+			if err != nil {
+				return nil, err
+			}
+			content := encode(data)
+			if max := p.Limits.MaxStringLength; max > 0 && len(content) > max {
+				return nil, fmt.Errorf("%s is %d bytes, over the string length limit of %d", pathname, len(content), max)
+			}
+			return content, nil
+		},
+	}, true
+}
+
+// StandardFunctions are functions which embedders can register. None are
+// registered by default.
+//
+//	upper(s)                         s in upper case
+//	lower(s)                         s in lower case
+//	uuidv5(namespace, name)          the name based UUID of name in the namespace UUID
+//	cidrsubnet(prefix, newbits, num) subnet num of prefix, extended by newbits
+func StandardFunctions() map[string]Function {
+	return map[string]Function{
+		"upper": {
+			Params: []ValueType{StringType},
+			Result: StringType,
+			Call: func(args []interface{}) (interface{}, error) {
+				return strings.ToUpper(args[0].(string)), nil
+			},
+		},
+		"lower": {
+			Params: []ValueType{StringType},
+			Result: StringType,
+			Call: func(args []interface{}) (interface{}, error) {
+				return strings.ToLower(args[0].(string)), nil
+			},
+		},
+		"uuidv5": {
+			Params: []ValueType{StringType, StringType},
+			Result: StringType,
+			Call: func(args []interface{}) (interface{}, error) {
+				return uuidv5(args[0].(string), args[1].(string))
+			},
+		},
+		"cidrsubnet": {
+			Params: []ValueType{StringType, IntType, IntType},
+			Result: StringType,
+			Call: func(args []interface{}) (interface{}, error) {
+				return cidrSubnet(args[0].(string), args[1].(int64), args[2].(int64))
+			},
+		},
+	}
+}
+
+// uuidv5 is the RFC 9562 version 5 UUID of name in the namespace.
+func uuidv5(namespace string, name string) (string, error) {
+	ns, err := hex.DecodeString(strings.ReplaceAll(namespace, "-", ""))
+	if err != nil || len(ns) != 16 {
+		return "", fmt.Errorf("namespace %q is not a UUID", namespace)
+	}
+
+	hash := sha1.New()
+	hash.Write(ns)
+	hash.Write([]byte(name))
+	uuid := hash.Sum(nil)[:16]
+	uuid[6] = (uuid[6] & 0x0f) | 0x50 // version 5
+	uuid[8] = (uuid[8] & 0x3f) | 0x80 // RFC variant
+
+	str := hex.EncodeToString(uuid)
+	return str[0:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:], nil
+}
+
+// cidrSubnet numbers the subnets of the prefix with newbits more bits, and
+// returns subnet num.
+func cidrSubnet(prefix string, newbits int64, num int64) (string, error) {
+	parent, err := netip.ParsePrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	parent = parent.Masked()
+	addr := parent.Addr()
+
+	bits := int64(parent.Bits()) + newbits
+	if newbits < 0 || bits > int64(addr.BitLen()) {
+		return "", fmt.Errorf("cannot extend /%d by %d bits", parent.Bits(), newbits)
+	}
+	if num < 0 || (newbits < 63 && num >= 1<<newbits) {
+		return "", fmt.Errorf("prefix extended by %d bits has no subnet %d", newbits, num)
+	}
+
+	// IPv4 addresses are the last 4 bytes of the 16 byte form.
+	bytes := addr.As16()
+	offset := int64(128-addr.BitLen()) + int64(parent.Bits())
+	for idx := int64(0); idx < newbits; idx++ {
+		if (num>>(newbits-1-idx))&1 == 1 {
+			pos := offset + idx
+			bytes[pos/8] |= 0x80 >> (pos % 8)
+		}
+	}
+
+	subnet := netip.AddrFrom16(bytes)
+	if addr.Is4() {
+		subnet = subnet.Unmap()
+	}
+	return netip.PrefixFrom(subnet, int(bits)).String(), nil
+}
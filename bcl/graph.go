@@ -0,0 +1,119 @@
+package bcl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// GraphFormat is an output format of Graph.Write.
+type GraphFormat string
+
+const (
+	GraphDOT  GraphFormat = "dot"
+	GraphJSON GraphFormat = "json"
+)
+
+// Graph is the dependency graph of a project, the named blocks and the
+// references between them, for visualizing how the files relate.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a named block.
+type GraphNode struct {
+	ID       string `json:"id"`
+	Schema   string `json:"schema"`
+	Name     string `json:"name"`
+	File     string `json:"file"`
+	Position string `json:"position"`
+}
+
+// GraphEdge is a reference from the block holding it to the block it names.
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Position string `json:"position"`
+}
+
+// Graph returns the dependency graph of the table, the nodes in file order.
+func (st *SymbolTable) Graph() *Graph {
+	graph := &Graph{
+		Nodes: make([]GraphNode, 0, len(st.Symbols)),
+		Edges: []GraphEdge{},
+	}
+	ids := make(map[*Symbol]string, len(st.Symbols))
+	for idx, symbol := range st.Symbols {
+		id := fmt.Sprintf("n%d", idx)
+		ids[symbol] = id
+		node := GraphNode{
+			ID:       id,
+			Schema:   symbol.Schema,
+			Name:     symbol.Name,
+			Position: symbol.Position.String(),
+		}
+		if symbol.Position.Filename != nil {
+			node.File = *symbol.Position.Filename
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, ref := range st.Dependencies() {
+		graph.Edges = append(graph.Edges, GraphEdge{
+			From:     ids[ref.From],
+			To:       ids[ref.To],
+			Position: ref.Position.String(),
+		})
+	}
+	return graph
+}
+
+// WriteDOT writes the dependency graph in Graphviz DOT format, see
+// Graph.Write.
+func (st *SymbolTable) WriteDOT(w io.Writer) error {
+	return st.Graph().Write(w, GraphDOT)
+}
+
+// Write writes the graph as JSON, or in Graphviz DOT format with the blocks
+// of each file in a cluster labelled with the filename.
+func (g *Graph) Write(w io.Writer, format GraphFormat) error {
+	switch format {
+	case GraphJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(g)
+	case GraphDOT:
+		return g.writeDOT(w)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+}
+
+func (g *Graph) writeDOT(w io.Writer) error {
+	files := []string{}
+	byFile := map[string][]GraphNode{}
+	for _, node := range g.Nodes {
+		if _, ok := byFile[node.File]; !ok {
+			files = append(files, node.File)
+		}
+		byFile[node.File] = append(byFile[node.File], node)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph bcl {\n")
+	for idx, file := range files {
+		fmt.Fprintf(bw, "  subgraph cluster_%d {\n", idx)
+		fmt.Fprintf(bw, "    label=%s;\n", strconv.Quote(file))
+		for _, node := range byFile[file] {
+			fmt.Fprintf(bw, "    %s [label=%s];\n", node.ID, strconv.Quote(node.Name+"\n"+node.Schema))
+		}
+		bw.WriteString("  }\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s;\n", edge.From, edge.To)
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
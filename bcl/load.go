@@ -0,0 +1,241 @@
+package bcl
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// SchemaFileSpec is the block schema for BCL schema files, which parse to
+// j5.bcl.v1.SchemaFile.
+func SchemaFileSpec() *bcl_j5pb.Schema {
+	return &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "j5.bcl.v1.Block",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "schemaName",
+			},
+		}, {
+			SchemaName: "j5.bcl.v1.ScalarSplit",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "required",
+				Path: &bcl_j5pb.Path{Path: []string{"requiredFields", "path"}},
+			}, {
+				Name: "optional",
+				Path: &bcl_j5pb.Path{Path: []string{"optionalFields", "path"}},
+			}, {
+				Name: "remainder",
+				Path: &bcl_j5pb.Path{Path: []string{"remainderField", "path"}},
+			}},
+		}, {
+			SchemaName: "j5.bcl.v1.Tag",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "path",
+				Path: &bcl_j5pb.Path{
+					Path: []string{"path", "path"},
+				},
+			}},
+		}},
+	}
+}
+
+// Decode parses src into a new message of type T.
+func Decode[T proto.Message](parser *Parser, filename, src string) (T, *bcl_j5pb.SourceLocation, error) {
+	msg := newMessage[T]()
+	locs, err := parser.ParseFile(filename, src, msg.ProtoReflect())
+	return msg, locs, err
+}
+
+// newMessage returns an empty message of type T, which must be a generated
+// message pointer type.
+func newMessage[T proto.Message]() T {
+	var zero T
+	return zero.ProtoReflect().New().Interface().(T)
+}
+
+// LoadedFile is a file parsed by LoadFS.
+type LoadedFile[T proto.Message] struct {
+	Path           string
+	Message        T
+	SourceLocation *bcl_j5pb.SourceLocation
+	Metadata       Metadata
+
+	// Source is the text which was parsed, with any override applied.
+	Source string
+}
+
+// LoadFS parses every project file in fsys, such as an embed.FS of configs,
+// into a message of type T.
+//
+// The project config is read from bcl.yaml at the root when there is one. The
+// block schema is read from the config's schema file, otherwise it is derived
+// from T alone. Errors from all files are returned together as
+// errpos.Diagnostics, grouped by file. Files can inline other files in fsys with
+// file() and filebase64().
+//
+// Override files, named by the config's OverrideSuffix, are applied to their
+// base file with ApplyOverride before it is parsed, so a.override.bcl changes
+// the message loaded from a.bcl. Errors are reported in the file they come
+// from, those in the applied values in the override file.
+func LoadFS[T proto.Message](fsys fs.FS) ([]LoadedFile[T], error) {
+	return LoadFSWithProgress[T](fsys, nil)
+}
+
+// LoadFSWithProgress loads the project as LoadFS, calling progress, when not
+// nil, with the number of files discovered then as each is parsed and
+// validated.
+func LoadFSWithProgress[T proto.Message](fsys fs.FS, progress func(Progress)) ([]LoadedFile[T], error) {
+	config, err := project.LoadConfig(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &bcl_j5pb.Schema{}
+	if config.Schema != "" {
+		spec, err = LoadSchemaFile(fsys, config.Schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var zero T
+	compiled, err := CompileSchema(spec, zero.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, err
+	}
+	parser, err := NewCompiledParser(compiled)
+	if err != nil {
+		return nil, err
+	}
+	parser.Progress = progress
+	return loadFiles(parser, fsys, config, newMessage[T])
+}
+
+// LoadFSWith parses every project file in fsys as LoadFS, with the parser and
+// its schema rather than the config's, into messages from newMsg, such as
+// dynamic messages of a descriptor read at run time. Files inline other files
+// in fsys unless the parser has a Resolver.
+func LoadFSWith[T proto.Message](parser *Parser, fsys fs.FS, newMsg func() T) ([]LoadedFile[T], error) {
+	config, err := project.LoadConfig(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return loadFiles(parser, fsys, config, newMsg)
+}
+
+func loadFiles[T proto.Message](parser *Parser, fsys fs.FS, config *project.Config, newMsg func() T) ([]LoadedFile[T], error) {
+	if parser.Resolver == nil {
+		parser.Resolver = project.NewFSResolver(fsys)
+	}
+
+	files, err := config.Files(fsys)
+	if err != nil {
+		return nil, err
+	}
+	parser.reportProgress(Progress{Stage: ProgressDiscovered, Files: len(files)})
+
+	// Override files are applied to their base file, not loaded themselves.
+	isFile := map[string]bool{}
+	for _, pathname := range files {
+		isFile[pathname] = true
+	}
+	overrides := map[string]string{}
+	diags := &errpos.Diagnostics{}
+	for _, pathname := range files {
+		base, ok := config.BaseFile(pathname)
+		if !ok {
+			continue
+		}
+		if !isFile[base] {
+			diags.Add(pathname, fmt.Errorf("override file has no base file %s", base))
+		}
+		overrides[base] = pathname
+	}
+
+	loaded := make([]LoadedFile[T], 0, len(files))
+	for _, pathname := range files {
+		if _, ok := config.BaseFile(pathname); ok {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, pathname)
+		if err != nil {
+			return nil, err
+		}
+		source := string(data)
+
+		var applied *appliedOverride
+		if overridePath, ok := overrides[pathname]; ok {
+			overrideData, err := fs.ReadFile(fsys, overridePath)
+			if err != nil {
+				return nil, err
+			}
+			applied, err = applyOverride(pathname, source, overridePath, string(overrideData))
+			if err != nil {
+				diags.Add(errorFile(err, overridePath), err)
+				continue
+			}
+			source = applied.merged
+		}
+
+		msg := newMsg()
+		result, err := parser.Parse(pathname, source, msg.ProtoReflect())
+		if err != nil {
+			if applied == nil {
+				diags.Add(pathname, err)
+				continue
+			}
+			baseErr, overrideErr := applied.splitErrors(err)
+			diags.Add(pathname, baseErr)
+			diags.Add(applied.overrideName, overrideErr)
+			continue
+		}
+		loaded = append(loaded, LoadedFile[T]{
+			Path:           pathname,
+			Message:        msg,
+			SourceLocation: result.SourceLocation,
+			Metadata:       result.Metadata,
+			Source:         source,
+		})
+	}
+
+	return loaded, diags.Err()
+}
+
+// errorFile is the file the errors are positioned in, or fallback.
+func errorFile(err error, fallback string) string {
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok || len(withSource.Errors) == 0 {
+		return fallback
+	}
+	if pos := withSource.Errors[0].Pos; pos != nil && pos.Filename != nil {
+		return *pos.Filename
+	}
+	return fallback
+}
+
+// LoadSchemaFile parses a BCL schema file, as named by the schema field of the
+// project config.
+func LoadSchemaFile(fsys fs.FS, pathname string) (*bcl_j5pb.Schema, error) {
+	data, err := fs.ReadFile(fsys, pathname)
+	if err != nil {
+		return nil, fmt.Errorf("schema file: %w", err)
+	}
+
+	parser, err := NewParser(SchemaFileSpec())
+	if err != nil {
+		return nil, err
+	}
+
+	file := &bcl_j5pb.SchemaFile{}
+	if _, err := parser.ParseFile(pathname, string(data), file.ProtoReflect()); err != nil {
+		return nil, err
+	}
+	if file.Schema == nil {
+		return &bcl_j5pb.Schema{}, nil
+	}
+	return file.Schema, nil
+}
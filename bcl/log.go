@@ -0,0 +1,65 @@
+package bcl
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/pentops/bcl.go/internal/walker"
+)
+
+// LogEntry is a debug event from parsing a file, with the file, block path
+// and position it is about.
+type LogEntry = walker.LogEntry
+
+// Logger receives debug events from the parser, set as Parser.Logger.
+type Logger = walker.Logger
+
+// LoggerFunc is a function which is a Logger.
+type LoggerFunc func(entry LogEntry)
+
+func (lf LoggerFunc) Log(entry LogEntry) {
+	lf(entry)
+}
+
+// SlogLogger logs events to the slog.Logger at debug level, with the fields of
+// the entry as the attributes event, file, path and position.
+func SlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (sl slogLogger) Log(entry LogEntry) {
+	ctx := context.Background()
+	if !sl.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{slog.String("event", entry.Event)}
+	if entry.File != "" {
+		attrs = append(attrs, slog.String("file", entry.File))
+	}
+	if entry.Path != "" {
+		attrs = append(attrs, slog.String("path", entry.Path))
+	}
+	if entry.Position != nil {
+		attrs = append(attrs, slog.String("position", entry.Position.Start.String()))
+	}
+	sl.logger.LogAttrs(ctx, slog.LevelDebug, entry.Message, attrs...)
+}
+
+// logger is the Logger for a parse, logging to stderr when Verbose is set
+// without one.
+func (p *Parser) logger() Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	if p.Verbose {
+		return SlogLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		})))
+	}
+	return nil
+}
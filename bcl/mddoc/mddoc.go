@@ -0,0 +1,232 @@
+// Package mddoc checks and formats the BCL examples in Markdown documents, the
+// ```bcl fenced code blocks, so documentation doesn't drift from the schema
+// it describes.
+package mddoc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// Language is the info string which marks a fence as BCL.
+const Language = "bcl"
+
+// NoCheck is the fence argument, "```bcl nocheck", which leaves out an example
+// which is wrong on purpose, or only a part of a file.
+const NoCheck = "nocheck"
+
+// Fence is a fenced BCL code block of a document.
+type Fence struct {
+	Args   []string // The words of the info string after the language
+	Line   int      // 0-based line of the first line of the body
+	Indent int      // Spaces before the opening fence, stripped from the body by Format
+	Body   string
+
+	start, end int // byte offsets of the body in the document
+}
+
+// Checked is false for fences marked NoCheck.
+func (f Fence) Checked() bool {
+	for _, arg := range f.Args {
+		if arg == NoCheck {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract returns the BCL fences of a Markdown document, in order. A fence
+// which isn't closed runs to the end of the document, as in CommonMark.
+func Extract(data string) []Fence {
+	fences := []Fence{}
+	var open *Fence
+	var marker string
+	offset := 0
+	for lineNum, line := range strings.SplitAfter(data, "\n") {
+		start := offset
+		offset += len(line)
+		text := strings.TrimRight(line, "\r\n")
+		indent := len(text) - len(strings.TrimLeft(text, " "))
+		trimmed := text[indent:]
+
+		if open != nil {
+			if indent < 4 && strings.HasPrefix(trimmed, marker) && strings.Trim(trimmed, marker[:1]+" \t") == "" {
+				if open.Line > 0 {
+					open.end = start
+					open.Body = data[open.start:open.end]
+					fences = append(fences, *open)
+				}
+				open = nil
+			}
+			continue
+		}
+
+		if indent >= 4 {
+			continue
+		}
+		fenceMarker := fenceMarker(trimmed)
+		if fenceMarker == "" {
+			continue
+		}
+		info := strings.Fields(trimmed[len(fenceMarker):])
+		if len(info) == 0 || info[0] != Language {
+			// Other fences are skipped whole, a ```bcl line inside one is text.
+			open = &Fence{}
+			marker = fenceMarker
+			continue
+		}
+		marker = fenceMarker
+		open = &Fence{
+			Args:   info[1:],
+			Line:   lineNum + 1,
+			Indent: indent,
+			start:  offset,
+		}
+	}
+	if open != nil && open.Line > 0 {
+		open.end = len(data)
+		open.Body = data[open.start:]
+		fences = append(fences, *open)
+	}
+	return fences
+}
+
+// fenceMarker returns the run of three or more backticks or tildes which
+// opens a fence, or "" for a line which doesn't open one.
+func fenceMarker(line string) string {
+	if len(line) < 3 || (line[0] != '`' && line[0] != '~') {
+		return ""
+	}
+	end := 0
+	for end < len(line) && line[end] == line[0] {
+		end++
+	}
+	if end < 3 {
+		return ""
+	}
+	if line[0] == '`' && strings.Contains(line[end:], "`") {
+		return ""
+	}
+	return line[:end]
+}
+
+// Checker checks the examples of documents against a schema.
+type Checker struct {
+	Parser *bcl.Parser
+
+	// Schema is the j5 name of the root message of each example, as for
+	// Parser.ParseBlock.
+	Schema string
+}
+
+// Check parses each checked fence of the document as a file of the schema.
+// Errors are positioned in the document, with its source.
+func (c *Checker) Check(filename string, data string) error {
+	msgType, err := c.Parser.SchemaType(c.Schema)
+	if err != nil {
+		return err
+	}
+
+	errs := errpos.Errors{}
+	for _, fence := range Extract(data) {
+		if !fence.Checked() {
+			continue
+		}
+		at := bcl.FragmentOffset{Filename: filename, Line: fence.Line}
+		_, err := c.Parser.ParseFragment(at, fence.Body, msgType.New())
+		if err == nil {
+			continue
+		}
+		fenceErrs, ok := fenceErrors(filename, err)
+		if !ok {
+			return fmt.Errorf("%s:%d: %w", filename, fence.Line, err)
+		}
+		errs = append(errs, fenceErrs...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errpos.AddSourceFile(errs, filename, data)
+}
+
+// fenceErrors returns the positioned errors of a fence. The document is their
+// source, so the position in the fence is dropped.
+func fenceErrors(filename string, err error) (errpos.Errors, bool) {
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok {
+		return nil, false
+	}
+	for _, fenceErr := range withSource.Errors {
+		if fenceErr.Pos != nil && fenceErr.Pos.Filename != nil && *fenceErr.Pos.Filename == filename {
+			fenceErr.Generated = nil
+		}
+	}
+	return withSource.Errors, true
+}
+
+// Format formats the body of each checked fence of the document with bcl.Fmt,
+// keeping the indent of the fence, and leaves the rest of the document as it
+// is. Fences which don't parse are errors, positioned in the document.
+func Format(filename string, data string) (string, error) {
+	out := &strings.Builder{}
+	last := 0
+	errs := errpos.Errors{}
+	for _, fence := range Extract(data) {
+		if !fence.Checked() || strings.TrimSpace(fence.Body) == "" {
+			continue
+		}
+		formatted, err := bcl.Fmt(dedent(fence.Body, fence.Indent))
+		if err != nil {
+			err = errpos.AddSourceFile(err, filename, fence.Body)
+			at := bcl.FragmentOffset{Filename: filename, Line: fence.Line}
+			fenceErrs, ok := fenceErrors(filename, errpos.MapPositions(err, at.Map))
+			if !ok {
+				return "", fmt.Errorf("%s:%d: %w", filename, fence.Line, err)
+			}
+			errs = append(errs, fenceErrs...)
+			continue
+		}
+		out.WriteString(data[last:fence.start])
+		out.WriteString(indent(formatted, fence.Indent))
+		last = fence.end
+	}
+	if len(errs) > 0 {
+		return "", errpos.AddSourceFile(errs, filename, data)
+	}
+	out.WriteString(data[last:])
+	return out.String(), nil
+}
+
+// dedent removes up to n leading spaces from each line.
+func dedent(body string, n int) string {
+	if n == 0 {
+		return body
+	}
+	lines := strings.SplitAfter(body, "\n")
+	for idx, line := range lines {
+		strip := 0
+		for strip < n && strip < len(line) && line[strip] == ' ' {
+			strip++
+		}
+		lines[idx] = line[strip:]
+	}
+	return strings.Join(lines, "")
+}
+
+// indent adds n spaces to the start of each line which isn't blank.
+func indent(body string, n int) string {
+	if n == 0 {
+		return body
+	}
+	prefix := strings.Repeat(" ", n)
+	lines := strings.SplitAfter(body, "\n")
+	for idx, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[idx] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,146 @@
+package mddoc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	_ "github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func doc(lines ...string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestExtract(t *testing.T) {
+	fences := Extract(doc(
+		"# Title",
+		"```bcl",
+		`sString = "a"`,
+		"```",
+		"````md",
+		"```bcl",
+		"not an example",
+		"```",
+		"````",
+		"  ~~~bcl nocheck",
+		"  broken {",
+		"  ~~~",
+		"```bcl",
+		"unclosed = true",
+	))
+	if !assert.Len(t, fences, 3) {
+		return
+	}
+
+	assert.Equal(t, 2, fences[0].Line)
+	assert.Equal(t, "sString = \"a\"\n", fences[0].Body)
+	assert.True(t, fences[0].Checked())
+
+	assert.Equal(t, 10, fences[1].Line)
+	assert.Equal(t, 2, fences[1].Indent)
+	assert.Equal(t, []string{"nocheck"}, fences[1].Args)
+	assert.False(t, fences[1].Checked())
+
+	assert.Equal(t, "unclosed = true\n", fences[2].Body)
+}
+
+func TestCheck(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := &Checker{Parser: pp, Schema: "test.v1.File"}
+
+	err = checker.Check("README.md", doc(
+		"# Title",
+		"```bcl",
+		`sString = "a"`,
+		"foo A",
+		"```",
+		"",
+		"```bcl nocheck",
+		"foo {",
+		"```",
+		"",
+		"```bcl",
+		`sString = "a"`,
+		`missing = "b"`,
+		"```",
+	))
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 13, Column: 1})
+
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok {
+		t.Fatal("expected errors with source")
+	}
+	assert.Equal(t, "README.md:13:1", withSource.Errors[0].Pos.String())
+	assert.Nil(t, withSource.Errors[0].Generated)
+	assert.Contains(t, withSource.HumanString(0), `missing = "b"`)
+
+	err = checker.Check("README.md", doc("```bcl", `sString = "a"`, "```"))
+	assert.NoError(t, err)
+}
+
+func TestFormat(t *testing.T) {
+	input := doc(
+		"Text",
+		"```bcl",
+		`sString   =   "a"`,
+		"```",
+		"",
+		"- item",
+		"",
+		"  ```bcl",
+		"  foo A {",
+		"  name = \"b\"",
+		"  }",
+		"  ```",
+		"",
+		"```bcl nocheck",
+		"x   =   1",
+		"```",
+	)
+	formatted, err := Format("README.md", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, doc(
+		"Text",
+		"```bcl",
+		`sString = "a"`,
+		"```",
+		"",
+		"- item",
+		"",
+		"  ```bcl",
+		"  foo A {",
+		"  \tname = \"b\"",
+		"  }",
+		"  ```",
+		"",
+		"```bcl nocheck",
+		"x   =   1",
+		"```",
+	), formatted)
+
+	_, err = Format("README.md", doc("Text", "```bcl", "x = = 1", "```"))
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 3})
+}
@@ -0,0 +1,89 @@
+package bcl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// namespaceKeyword starts a block of a namespaced file, `namespace registry {`,
+// whose body is a file of the schema registered under the name.
+const namespaceKeyword = "namespace"
+
+// ParseNamespaces parses a file of namespace blocks, each holding the
+// statements of one vocabulary, into a new message per namespace, keyed by the
+// name. Each namespace names a schema registered with RegisterSchema, as an
+// identifier or a string, and its body is parsed as a file of that schema.
+// Blocks of the same namespace are combined in order. Statements outside a
+// namespace block are an error.
+func (p *Parser) ParseNamespaces(filename string, data string) (map[string]protoreflect.Message, map[string]*ParseResult, error) {
+	ctx := context.Background()
+	tree, err := p.parseTree(ctx, filename, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names, bodies, err := p.namespaceBodies(tree)
+	if err != nil {
+		return nil, nil, fileErr(err, filename, data, tree)
+	}
+
+	msgs := make(map[string]protoreflect.Message, len(names))
+	results := make(map[string]*ParseResult, len(names))
+	for _, name := range names {
+		route := p.routes[name]
+		routed := p.routed(route)
+		msg := route.msgType.New()
+
+		subtree := *tree
+		subtree.Body = bodies[name]
+		result, err := routed.parseAST(ctx, filename, &subtree, msg)
+		msgs[name] = msg
+		results[name] = result
+		if err != nil {
+			return msgs, results, fileErr(err, filename, data, tree)
+		}
+	}
+	return msgs, results, nil
+}
+
+// namespaceBodies splits the statements of the file by namespace, returning
+// the names in the order they first appear.
+func (p *Parser) namespaceBodies(tree *parser.File) ([]string, map[string]parser.Body, error) {
+	names := []string{}
+	bodies := map[string]parser.Body{}
+	for _, stmt := range tree.Body.Statements {
+		block, ok := stmt.(*parser.Block)
+		if !ok || len(block.Type.Idents) != 1 || block.Type.Idents[0].Value != namespaceKeyword {
+			err := errpos.WithCode(fmt.Errorf("statements must be in a namespace block"), errpos.CodeNamespace)
+			return nil, nil, errpos.AddPosition(err, stmt.Source().Position())
+		}
+
+		hdr := block.BlockHeader
+		if len(hdr.Tags) != 1 || len(hdr.Qualifiers) > 0 || hdr.Description != nil {
+			err := errpos.WithCode(fmt.Errorf("namespace blocks need a name, and nothing else"), errpos.CodeNamespace)
+			return nil, nil, errpos.AddPosition(err, hdr.Position())
+		}
+		name, err := hdr.Tags[0].AsString()
+		if err != nil {
+			err = errpos.WithCode(err, errpos.CodeNamespace)
+			return nil, nil, errpos.AddPosition(err, hdr.Tags[0].Position())
+		}
+		if _, ok := p.routes[name]; !ok {
+			err := errpos.WithCode(fmt.Errorf("unknown schema %q", name), errpos.CodeUnknownSchema)
+			return nil, nil, errpos.AddPosition(err, hdr.Tags[0].Position())
+		}
+
+		body, ok := bodies[name]
+		if !ok {
+			names = append(names, name)
+		}
+		body.IsRoot = true
+		body.Statements = append(body.Statements, block.Body.Statements...)
+		bodies[name] = body
+	}
+	return names, bodies, nil
+}
@@ -0,0 +1,212 @@
+package bcl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// CheckNaming reports the block names and map keys of a parsed file which
+// don't follow the case set for them in naming, keyed as for
+// project.Config.Naming. Block names are the values of the name tags of the
+// block specs of the parser.
+//
+// The findings are warnings, errpos.CodeNaming, which are not filtered by
+// bcl:ignore comments, so that the caller can mark the ones they match. When
+// the file has its Source set, each finding whose words are unambiguous has a
+// fix which renames it. Renaming changes what the file means, so the fixes
+// are not Safe.
+func (p *Parser) CheckNaming(file SourceFile, naming map[string]project.Case) (errpos.Errors, error) {
+	nc := &namingChecker{
+		names:    p.names,
+		naming:   naming,
+		filename: file.Filename,
+		keys:     map[errpos.Point]parser.Ident{},
+	}
+
+	if file.Source != "" {
+		tree, err := file.tree()
+		if err != nil {
+			return nil, err
+		}
+		nc.lines = errpos.SplitLines(file.Source)
+		assignedKeys(tree.Body, nc.keys)
+	}
+
+	err := visitMessages(file.Message, file.SourceLocation, func(node messageNode) error {
+		return nc.checkMessage(node.msg, node.loc)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Filename, err)
+	}
+	// In file order, the maps of messages are visited by key
+	sort.SliceStable(nc.errs, func(i, j int) bool {
+		a, b := nc.errs[i].Pos.Start, nc.errs[j].Pos.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return nc.errs, nil
+}
+
+type namingChecker struct {
+	names    map[string]*schema.Tag // schema name to name tag
+	naming   map[string]project.Case
+	filename string
+	lines    []string
+
+	// keys are the last idents of the keys of assignments, by the start of
+	// their values, which is where the source location of a map entry is.
+	keys map[errpos.Point]parser.Ident
+
+	errs errpos.Errors
+}
+
+func assignedKeys(body parser.Body, keys map[errpos.Point]parser.Ident) {
+	for _, stmt := range body.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			keys[stmt.Value.Start] = stmt.Key.Idents[len(stmt.Key.Idents)-1]
+		case *parser.Block:
+			assignedKeys(stmt.Body, keys)
+		}
+	}
+}
+
+// checkMessage checks the block name of the message and the keys of its maps.
+func (nc *namingChecker) checkMessage(msg protoreflect.Message, loc *bcl_j5pb.SourceLocation) error {
+	desc := msg.Descriptor()
+	schemaName := j5SchemaName(desc)
+
+	if style, ok := nc.naming[schemaName]; ok && nc.names[schemaName] != nil {
+		tag := nc.names[schemaName]
+		fd := desc.Fields().ByJSONName(tag.FieldName)
+		if fd == nil {
+			fd = desc.Fields().ByName(protoreflect.Name(tag.FieldName))
+		}
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+			return fmt.Errorf("name field %q of %s is not a string", tag.FieldName, schemaName)
+		}
+		name := msg.Get(fd).String()
+		if tag.ScopeSeparator != nil {
+			// Only the last part is written in the tag
+			if idx := strings.LastIndex(name, *tag.ScopeSeparator); idx >= 0 {
+				name = name[idx+len(*tag.ScopeSeparator):]
+			}
+		}
+		if nameLoc, ok := loc.GetChildren()[fd.JSONName()]; ok && name != "" {
+			pos := locPosition(nc.filename, nameLoc)
+			nc.check(style, "block name", name, pos, true, nil)
+		}
+	}
+
+	fields := desc.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		if fd.IsMap() && msg.Has(fd) {
+			nc.checkMap(schemaName+"."+fd.JSONName(), fd, msg.Get(fd).Map(), loc.GetChildren()[fd.JSONName()])
+		}
+	}
+	return nil
+}
+
+// checkMap checks the keys of the map, in file order, when the naming sets a
+// case for them.
+func (nc *namingChecker) checkMap(name string, fd protoreflect.FieldDescriptor, value protoreflect.Map, loc *bcl_j5pb.SourceLocation) {
+	style, checked := nc.naming[name]
+	if !checked {
+		return
+	}
+	keys := []string{}
+	taken := map[string]bool{}
+	value.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		taken[key.String()] = true
+		if _, ok := loc.GetChildren()[key.String()]; ok {
+			keys = append(keys, key.String())
+		}
+		return true
+	})
+	// In file order
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := loc.Children[keys[i]], loc.Children[keys[j]]
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		return a.StartColumn < b.StartColumn
+	})
+
+	isMessage := fd.MapValue().Message() != nil
+	for _, key := range keys {
+		pos := locPosition(nc.filename, loc.Children[key])
+		ident, isKey := nc.keys[pos.Start]
+		if isKey && !isMessage {
+			pos = ident.Position()
+			pos.Filename = &nc.filename
+		}
+		nc.check(style, "map key", key, pos, isKey && !isMessage, taken)
+	}
+}
+
+// check adds a warning when the name is not in the style, with a fix when pos
+// is exactly the name in the source, and its words are unambiguous.
+func (nc *namingChecker) check(style project.Case, what, name string, pos errpos.Position, exact bool, taken map[string]bool) {
+	if style.Matches(name) {
+		return
+	}
+	err := &errpos.Err{
+		Pos:      &pos,
+		Code:     errpos.CodeNaming,
+		Severity: errpos.SeverityWarning,
+		Err:      fmt.Errorf("%s %q is not %s", what, name, style),
+	}
+	nc.errs = append(nc.errs, err)
+
+	fixed, ok := style.Convert(name)
+	if !ok || !exact || taken[fixed] {
+		return
+	}
+	text, ok := nc.text(pos)
+	switch {
+	case !ok:
+		return
+	case text == name:
+		text = fixed
+	case strings.HasPrefix(text, `"`):
+		if unquoted, err := strconv.Unquote(text); err != nil || unquoted != name {
+			// e.g. an interpolated name
+			return
+		}
+		text = strconv.Quote(fixed)
+	default:
+		return
+	}
+	err.Fixes = []errpos.Fix{{
+		Title: fmt.Sprintf("Rename %s to %s", name, fixed),
+		Edits: []errpos.Edit{{
+			Start:   pos.Start,
+			End:     errpos.Point{Line: pos.End.Line, Column: pos.End.Column + 1},
+			NewText: text,
+		}},
+	}}
+}
+
+// text returns the source of a position on one line, the end inclusive.
+func (nc *namingChecker) text(pos errpos.Position) (string, bool) {
+	if pos.Start.Line != pos.End.Line || pos.Start.Line >= len(nc.lines) {
+		return "", false
+	}
+	line := []rune(strings.TrimRight(nc.lines[pos.Start.Line], "\r\n"))
+	if pos.Start.Column < 0 || pos.End.Column >= len(line) || pos.End.Column < pos.Start.Column {
+		return "", false
+	}
+	return string(line[pos.Start.Column : pos.End.Column+1]), true
+}
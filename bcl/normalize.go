@@ -0,0 +1,40 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// NormalizeFunc rewrites the string value of a field before it is set, or
+// rejects it, failing the parse with errpos.CodeInvalidValue at the value.
+type NormalizeFunc = schema.NormalizeFunc
+
+// Normalized is a value which a normalizer changed, with the text as the file
+// wrote it, for diagnostics. Pos is the position of the value.
+type Normalized = schema.Normalized
+
+// ParserOption configures a Parser as NewParser or NewCompiledParser
+// constructs it.
+type ParserOption func(*Parser)
+
+// WithNormalizer adds a normalizer which the Normalizers of a block spec can
+// name as a step, alongside the built-in trim, lowercase, clean_path and url.
+// It replaces a built-in of the same name. The steps of the schema are
+// checked when the parser is constructed, so a step which is neither a
+// built-in nor added by an option fails the construction.
+func WithNormalizer(name string, fn NormalizeFunc) ParserOption {
+	return func(p *Parser) {
+		p.RegisterNormalizer(name, fn)
+	}
+}
+
+// RegisterNormalizer adds a normalizer after the parser is constructed,
+// which can only replace a step the schema names, as unknown steps fail
+// the construction.
+//
+// Deprecated: use WithNormalizer.
+func (p *Parser) RegisterNormalizer(name string, fn NormalizeFunc) {
+	if p.normalizers == nil {
+		p.normalizers = map[string]schema.NormalizeFunc{}
+	}
+	p.normalizers[name] = fn
+}
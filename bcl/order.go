@@ -0,0 +1,101 @@
+package bcl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// Order returns the symbols in dependency order, each block after the blocks
+// it references. Blocks which don't depend on each other keep the order of the
+// files. Cycles are returned as errpos.Errors at the reference which closes
+// the cycle, naming the position of each reference around it, with every
+// symbol still in the order.
+func (st *SymbolTable) Order() ([]*Symbol, error) {
+	deps := map[*Symbol][]*Reference{}
+	for _, ref := range st.Dependencies() {
+		deps[ref.From] = append(deps[ref.From], ref)
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[*Symbol]int{}
+	order := make([]*Symbol, 0, len(st.Symbols))
+	stack := []*Symbol{}
+	followed := []*Reference{} // followed[i] leads from stack[i] to stack[i+1]
+	var errs errpos.Errors
+
+	var visit func(symbol *Symbol)
+	visit = func(symbol *Symbol) {
+		state[symbol] = visiting
+		stack = append(stack, symbol)
+		for _, ref := range deps[symbol] {
+			switch state[ref.To] {
+			case unvisited:
+				followed = append(followed, ref)
+				visit(ref.To)
+				followed = followed[:len(followed)-1]
+			case visiting:
+				errs = append(errs, cycleError(stack, followed, ref))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[symbol] = done
+		order = append(order, symbol)
+	}
+
+	for _, symbol := range st.Symbols {
+		if state[symbol] == unvisited {
+			visit(symbol)
+		}
+	}
+
+	if len(errs) > 0 {
+		return order, errs
+	}
+	return order, nil
+}
+
+func cycleError(stack []*Symbol, followed []*Reference, ref *Reference) *errpos.Err {
+	start := 0
+	for idx, symbol := range stack {
+		if symbol == ref.To {
+			start = idx
+			break
+		}
+	}
+	names := make([]string, 0, len(stack)-start+1)
+	for _, symbol := range stack[start:] {
+		names = append(names, symbol.Name)
+	}
+	names = append(names, ref.To.Name)
+
+	hops := append(append([]*Reference{}, followed[start:]...), ref)
+	hopNames := make([]string, len(hops))
+	for idx, hop := range hops {
+		hopNames[idx] = fmt.Sprintf("%s names %s at %s", hop.From.Name, hop.To.Name, hop.Position.String())
+	}
+
+	pos := ref.Position
+	return &errpos.Err{
+		Pos:  &pos,
+		Code: errpos.CodeReferenceCycle,
+		Err:  fmt.Errorf("reference cycle: %s, %s", strings.Join(names, " -> "), strings.Join(hopNames, ", ")),
+	}
+}
+
+// Dependencies returns the resolved references from one named block to
+// another, the edges of the graph which Order sorts, in file order.
+func (st *SymbolTable) Dependencies() []*Reference {
+	deps := make([]*Reference, 0, len(st.References))
+	for _, ref := range st.References {
+		if ref.From != nil && ref.To != nil {
+			deps = append(deps, ref)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,224 @@
+package bcl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// ApplyOverride applies an override file to its base file, returning the
+// base with the values of the override set in it. The rest of the base is
+// kept as written.
+//
+// Overrides only modify what the base has: an assignment replaces the value
+// in the base or adds the attribute, and a block must match a block of the
+// base by its type and tags. Blocks which are not in the base, including
+// those a dotted assignment would create, are CodeOverrideBlock errors,
+// positioned in the override file.
+func ApplyOverride(baseName, base, overrideName, override string) (string, error) {
+	applied, err := applyOverride(baseName, base, overrideName, override)
+	if err != nil {
+		return "", err
+	}
+	return applied.merged, nil
+}
+
+// appliedOverride is a base file with an override file applied, which places
+// positions in the merged text back in the file they came from.
+type appliedOverride struct {
+	baseName     string
+	base         string
+	overrideName string
+	override     string
+	merged       string
+
+	baseLines     lineOffsets
+	overrideLines lineOffsets
+	mergedLines   lineOffsets
+	spans         []overrideSpan
+}
+
+// overrideSpan is the text of an edit in the merged file, as rune offsets.
+type overrideSpan struct {
+	start, end int
+	delta      int // of the offsets in the merged file after the span
+
+	valueStart int // of the value in the merged file
+	valueEnd   int
+	value      int // offset of the value in the override file
+	statement  errpos.Position
+}
+
+func applyOverride(baseName, base, overrideName, override string) (*appliedOverride, error) {
+	baseTree, err := parser.ParseFile(base, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, baseName, base)
+	}
+	overrideTree, err := parser.ParseFile(override, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, overrideName, override)
+	}
+
+	edits, errs := parser.OverrideEdits(base, baseTree, override, overrideTree)
+	if len(errs) > 0 {
+		return nil, errpos.AddSourceFile(errs, overrideName, override)
+	}
+	plain := make([]errpos.Edit, len(edits))
+	for idx, edit := range edits {
+		plain[idx] = edit.Edit
+	}
+	merged, err := errpos.ApplyEdits(base, plain)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", overrideName, err)
+	}
+
+	applied := &appliedOverride{
+		baseName:      baseName,
+		base:          base,
+		overrideName:  overrideName,
+		override:      override,
+		merged:        merged,
+		baseLines:     newLineOffsets(base),
+		overrideLines: newLineOffsets(override),
+		mergedLines:   newLineOffsets(merged),
+	}
+
+	// ApplyEdits keeps the order of edits which start at the same point.
+	sort.SliceStable(edits, func(i, j int) bool {
+		return applied.baseLines.offset(edits[i].Start) < applied.baseLines.offset(edits[j].Start)
+	})
+	delta := 0
+	for _, edit := range edits {
+		start := applied.baseLines.offset(edit.Start) + delta
+		length := utf8.RuneCountInString(edit.NewText)
+		delta += length - (applied.baseLines.offset(edit.End) - applied.baseLines.offset(edit.Start))
+
+		value := errpos.SourceText(override, edit.Value.Start, edit.Value.End)
+		valueStart := start + utf8.RuneCountInString(edit.NewText[:strings.LastIndex(edit.NewText, value)])
+		applied.spans = append(applied.spans, overrideSpan{
+			start:      start,
+			end:        start + length,
+			delta:      delta,
+			valueStart: valueStart,
+			valueEnd:   valueStart + utf8.RuneCountInString(value),
+			value:      applied.overrideLines.offset(edit.Value.Start),
+			statement:  edit.Statement.Position(),
+		})
+	}
+	return applied, nil
+}
+
+// mapPoint returns the point in the base or override file of a point in the
+// merged text, true when it is in the override file. Points in the text an
+// edit added around a value are at the start of the override assignment.
+func (ao *appliedOverride) mapPoint(point errpos.Point) (errpos.Point, bool) {
+	offset := ao.mergedLines.offset(point)
+	delta := 0
+	for _, span := range ao.spans {
+		if offset < span.start {
+			break
+		}
+		if offset >= span.end {
+			delta = span.delta
+			continue
+		}
+		if offset >= span.valueStart && offset < span.valueEnd {
+			return ao.overrideLines.point(span.value + offset - span.valueStart), true
+		}
+		return span.statement.Start, true
+	}
+	return ao.baseLines.point(offset - delta), false
+}
+
+// mapPosition returns the position in the base or override file of a
+// position in the merged text.
+func (ao *appliedOverride) mapPosition(pos errpos.Position) errpos.Position {
+	start, inOverride := ao.mapPoint(pos.Start)
+	end, endInOverride := ao.mapPoint(pos.End)
+	if endInOverride != inOverride || (end.Line == start.Line && end.Column < start.Column) || end.Line < start.Line {
+		end = start
+	}
+	filename := ao.baseName
+	if inOverride {
+		filename = ao.overrideName
+	}
+	return errpos.Position{
+		Filename: &filename,
+		Start:    start,
+		End:      end,
+	}
+}
+
+// splitErrors places the errors of parsing the merged text in the file each
+// came from, returning those in the base and those in the override file, each
+// with the source of its file.
+func (ao *appliedOverride) splitErrors(err error) (error, error) {
+	var errs errpos.Errors
+	if withSource, ok := errpos.AsErrorsWithSource(err); ok {
+		errs = withSource.Errors
+	} else if asErrs, ok := errpos.AsErrors(err); ok {
+		errs = asErrs
+	} else {
+		return err, nil
+	}
+
+	var baseErrs, overrideErrs errpos.Errors
+	for _, single := range errs {
+		if single.Pos == nil || single.Generated != nil {
+			baseErrs = append(baseErrs, single)
+			continue
+		}
+		for idx, related := range single.Related {
+			single.Related[idx].Pos = ao.mapPosition(related.Pos)
+		}
+		pos := ao.mapPosition(*single.Pos)
+		single.Pos = &pos
+		if *pos.Filename == ao.overrideName {
+			overrideErrs = append(overrideErrs, single)
+		} else {
+			baseErrs = append(baseErrs, single)
+		}
+	}
+
+	var baseErr, overrideErr error
+	if len(baseErrs) > 0 {
+		baseErr = errpos.AddSourceFile(baseErrs, ao.baseName, ao.base)
+	}
+	if len(overrideErrs) > 0 {
+		overrideErr = errpos.AddSourceFile(overrideErrs, ao.overrideName, ao.override)
+	}
+	return baseErr, overrideErr
+}
+
+// lineOffsets are the rune offsets of the start of each line of a source, as
+// SplitLines counts lines.
+type lineOffsets []int
+
+func newLineOffsets(source string) lineOffsets {
+	lines := errpos.SplitLines(source)
+	offsets := make(lineOffsets, len(lines))
+	total := 0
+	for idx, line := range lines {
+		offsets[idx] = total
+		total += utf8.RuneCountInString(line)
+	}
+	return offsets
+}
+
+func (lo lineOffsets) offset(point errpos.Point) int {
+	if point.Line >= len(lo) {
+		return lo[len(lo)-1]
+	}
+	return lo[point.Line] + point.Column
+}
+
+func (lo lineOffsets) point(offset int) errpos.Point {
+	line := sort.Search(len(lo), func(idx int) bool {
+		return lo[idx] > offset
+	}) - 1
+	return errpos.Point{Line: line, Column: offset - lo[line]}
+}
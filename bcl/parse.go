@@ -1,48 +1,209 @@
 package bcl
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	"github.com/bufbuild/protovalidate-go"
 	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
 	"github.com/pentops/bcl.go/internal/parser"
 	"github.com/pentops/bcl.go/internal/walker"
 	"github.com/pentops/bcl.go/internal/walker/schema"
 	"github.com/pentops/j5/lib/j5reflect"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type Parser struct {
-	refl     *j5reflect.Reflector
-	Verbose  bool
+	refl  *j5reflect.Reflector
+	views *scalarViews
+
+	// Verbose logs debug events to stderr when Logger is not set.
+	//
+	// Deprecated: set Logger, e.g. to SlogLogger.
+	Verbose bool
+
+	// Logger, when set, receives structured debug events as files are
+	// walked.
+	Logger Logger
+
+	// FailFast stops at the first syntax error of a file. Otherwise each is
+	// reported, the lexer skipping from a bad token to the next line break or
+	// closing brace, and the parser to the end of the statement.
 	FailFast bool
 	validate *protovalidate.Validator
 	schema   *schema.SchemaSet
+	policies *policySet
+	derived  *derivedSet
+	identity map[string][]string
+	names    map[string]*schema.Tag
+	prose    map[string][]string
+	routes   map[string]schemaRoute
+
+	functions        map[string]Function
+	allowedFunctions map[string]bool // nil allows every function
+	computed         map[string][]walker.ComputedChild
+	normalizers      map[string]schema.NormalizeFunc
+
+	// SourceLocations sets how the source location of each field is tracked,
+	// defaulting to SourceLocationsFull.
+	SourceLocations SourceLocations
+
+	// OneofConflicts sets how a second member of a oneof in one block is
+	// treated, an error by default.
+	OneofConflicts OneofConflicts
+
+	// Limits caps the size of parsed files, for parsing untrusted input. The
+	// zero value is not limited.
+	Limits Limits
+
+	// AnyTypes resolves the type tags of blocks which set google.protobuf.Any
+	// fields, defaulting to protoregistry.GlobalTypes. Dynamic messages need
+	// a resolver over their own files, e.g. dynamicpb.NewTypes.
+	AnyTypes protoregistry.MessageTypeResolver
+
+	// Extensions, when set, are the protobuf extensions which files can set
+	// in the blocks of the messages they extend, by the camel case of their
+	// name as for fields, e.g. maxReplicas for max_replicas.
+	Extensions *protoregistry.Types
+
+	// Resolver, when set, serves the file() and filebase64() functions, which
+	// inline the content of a file named relative to the file being parsed.
+	// Paths are resolved and read through it, so they are confined to its
+	// root.
+	Resolver project.Resolver
+
+	// Secrets, when set, serves the secret() function, which resolves a
+	// reference to a credential when the file is parsed.
+	Secrets SecretResolver
+
+	// Decrypter, when set, decrypts enc"..." values, for secrets kept in the
+	// repository encrypted.
+	Decrypter Decrypter
+
+	// FieldMask returns the paths of every field the file set in
+	// ParseResult.FieldMask, to tell a field set to its default from one
+	// which isn't mentioned, and the derived fields. The mask is built from
+	// the source location tree, which is built for it whatever
+	// SourceLocations is set to.
+	FieldMask bool
+
+	// Fingerprints returns a Fingerprint of each block in
+	// ParseResult.Fingerprints, built from the source location tree as
+	// FieldMask is.
+	Fingerprints bool
+
+	// Progress, when set, is called as each file is parsed and validated.
+	Progress func(Progress)
+
+	// Tracer, when set, starts a span for each phase of a parse, under the
+	// context given to ParseContext.
+	Tracer Tracer
 }
 
-func NewParser(schemaSpec *bcl_j5pb.Schema) (*Parser, error) {
-	pv, err := protovalidate.New()
+// Limits caps token, string and array sizes and the number of nodes in a file,
+// input over a limit fails with errpos.CodeLimitExceeded. MaxRecursion caps
+// how deep blocks of a schema which contains itself are nested, and fails
+// with errpos.CodeMaxRecursion naming each block in the chain.
+type Limits = parser.Limits
+
+// OneofConflicts sets how the parser treats a member of a oneof set in a
+// block which already set another member, which would otherwise silently
+// clear the first.
+type OneofConflicts int
+
+const (
+	// OneofConflictError reports the second member as an
+	// errpos.CodeOneofConflict error naming the position of the first.
+	OneofConflictError OneofConflicts = OneofConflicts(walker.OneofError)
+
+	// OneofConflictFirst keeps the first member and skips the statement
+	// which sets the second, with a warning in the ParseResult.
+	OneofConflictFirst OneofConflicts = OneofConflicts(walker.OneofFirst)
+
+	// OneofConflictLast replaces the first member with the second, with a
+	// warning in the ParseResult.
+	OneofConflictLast OneofConflicts = OneofConflicts(walker.OneofLast)
+)
+
+// SourceLocations sets how the parser builds the SourceLocation tree.
+type SourceLocations int
+
+const (
+	// SourceLocationsFull builds the full tree on every parse.
+	SourceLocationsFull SourceLocations = iota
+
+	// SourceLocationsLazy skips the tree unless validation fails, then walks
+	// the file a second time to place the validation errors. The tree is
+	// returned only when it was built.
+	SourceLocationsLazy
+
+	// SourceLocationsNone never builds the tree, validation errors have no
+	// position.
+	SourceLocationsNone
+)
+
+func NewParser(schemaSpec *bcl_j5pb.Schema, opts ...ParserOption) (*Parser, error) {
+	ss, err := schema.NewSchemaSet(schemaSpec)
 	if err != nil {
 		return nil, err
 	}
 
-	ss, err := schema.NewSchemaSet(schemaSpec)
+	return newParser(ss, opts)
+}
+
+// NewCompiledParser creates a parser from a precompiled schema, skipping the
+// spec derivation done by NewParser.
+func NewCompiledParser(compiled *CompiledSchema, opts ...ParserOption) (*Parser, error) {
+	if compiled == nil || compiled.set == nil {
+		return nil, fmt.Errorf("compiled schema is empty")
+	}
+	return newParser(compiled.set, opts)
+}
+
+func newParser(ss *schema.SchemaSet, opts []ParserOption) (*Parser, error) {
+	pv, err := protovalidate.New()
+	if err != nil {
+		return nil, err
+	}
+	policies, err := newPolicySet(ss)
+	if err != nil {
+		return nil, err
+	}
+	derived, err := newDerivedSet(ss)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Parser{
+	p := &Parser{
 		refl:     j5reflect.New(),
+		views:    newScalarViews(),
 		FailFast: true,
 		validate: pv,
 		schema:   ss,
+		policies: policies,
+		derived:  derived,
+		identity: ss.Identities(),
+		names:    ss.Names(),
+		prose:    ss.Prose(),
 		Verbose:  isTruthy(os.Getenv("BCL_DEBUG")),
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	normalizers := &schema.Normalizers{Funcs: p.normalizers}
+	if err := ss.CheckNormalizers(normalizers); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 func isTruthy(s string) bool {
@@ -50,47 +211,342 @@ func isTruthy(s string) bool {
 	return lower == "true" || lower == "1" || lower == "yes" || lower == "y" || lower == "t"
 }
 
+// ParseResult is the output of Parse, beyond the message itself.
+type ParseResult struct {
+	SourceLocation *bcl_j5pb.SourceLocation
+	Stats          Stats
+
+	// FieldMask is the paths of the fields set by the file, when
+	// Parser.FieldMask is set. Repeated and map fields are set as a whole.
+	FieldMask *fieldmaskpb.FieldMask
+
+	// Fingerprints identify the blocks of the file, when
+	// Parser.Fingerprints is set.
+	Fingerprints []Fingerprint
+
+	// Warnings are the oneof conflicts which Parser.OneofConflicts resolved,
+	// at the statement which set the second member, and the deprecated
+	// aliases the file uses.
+	Warnings errpos.Errors
+
+	// Normalized are the values which the normalizers of the block specs
+	// changed, in the order they were set.
+	Normalized []Normalized
+
+	// Metadata is read from the header of the file
+	Metadata Metadata
+
+	filename string
+	lineMap  parser.LineMap
+}
+
+// Metadata is the '!key "value"' header of a file, kept outside the parsed
+// message so schemas don't need to model it. Keys which aren't in the header
+// are empty.
+type Metadata struct {
+	// Edition is the language edition of the file, from its '!bcl' header,
+	// 1 when it has none
+	Edition int
+
+	Schema      string
+	Version     string
+	Owner       string
+	Description string
+}
+
+func fileMetadata(tree *parser.File) Metadata {
+	md := Metadata{
+		Edition: int(tree.Edition),
+	}
+	for _, directive := range tree.Header {
+		switch directive.Key {
+		case "schema":
+			md.Schema = directive.Value
+		case "version":
+			md.Version = directive.Value
+		case "owner":
+			md.Owner = directive.Value
+		case "description":
+			md.Description = directive.Value
+		}
+	}
+	return md
+}
+
+// Stats are counters and timings for each phase of a parse, for tracking
+// performance.
+type Stats struct {
+	Tokens        int // Tokens lexed
+	Statements    int // Statements walked, including those in blocks
+	Blocks        int // Blocks walked
+	ReflectValues int // Reflection fields fetched or created by the walker
+
+	Lex      time.Duration
+	Parse    time.Duration
+	Walk     time.Duration
+	Validate time.Duration
+}
+
+// Total is the time spent in all phases.
+func (s Stats) Total() time.Duration {
+	return s.Lex + s.Parse + s.Walk + s.Validate
+}
+
 func (p *Parser) ParseFile(filename string, data string, msg protoreflect.Message) (*bcl_j5pb.SourceLocation, error) {
+	result, err := p.Parse(filename, data, msg)
+	if result == nil {
+		return nil, err
+	}
+	return result.SourceLocation, err
+}
+
+// Parse parses the file data into msg, as ParseFile, also returning the stats.
+// The result is returned with any error from walking or validation.
+func (p *Parser) Parse(filename string, data string, msg protoreflect.Message) (*ParseResult, error) {
+	return p.ParseContext(context.Background(), filename, data, msg)
+}
 
-	tree, err := parser.ParseFile(data, p.FailFast)
+// ParseContext parses the file as Parse, with spans for the Tracer under the
+// span in ctx.
+func (p *Parser) ParseContext(ctx context.Context, filename string, data string, msg protoreflect.Message) (*ParseResult, error) {
+	ctx, span := p.startSpan(ctx, SpanParse, time.Now())
+	span.SetAttribute("bcl.file", filename)
+
+	tree, err := p.parseTree(ctx, filename, data)
+	if err != nil {
+		p.reportProgress(Progress{Stage: ProgressParsed, Filename: filename, Failed: true})
+		span.End(time.Now(), err)
+		return nil, err
+	}
+
+	result, err := p.parseAST(ctx, filename, tree, msg)
+	if err != nil {
+		err = fileErr(err, filename, data, tree)
+	}
+	span.SetAttribute("bcl.tokens", result.Stats.Tokens)
+	span.SetAttribute("bcl.statements", result.Stats.Statements)
+	span.End(time.Now(), err)
+	return result, err
+}
+
+// fileErr adds the file to the errors from walking its tree, placing errors
+// after a '#line' directive at the source the file was generated from.
+func fileErr(err error, filename string, data string, tree *parser.File) error {
+	err = errpos.AddSourceFile(err, filename, data)
+	return errpos.MapPositions(err, tree.LineMap.Map)
+}
+
+func (p *Parser) parseTree(ctx context.Context, filename string, data string) (*parser.File, error) {
+	start := time.Now()
+	tree, err := parser.ParseFileWithLimits(data, p.FailFast, p.Limits)
+	if tree != nil {
+		p.traceSpan(ctx, SpanLex, start, tree.Stats.Lex, nil)
+		p.traceSpan(ctx, SpanSyntax, start.Add(tree.Stats.Lex), tree.Stats.Parse, err)
+	} else {
+		// The lexer failed, so there was no syntax phase.
+		p.traceSpan(ctx, SpanLex, start, time.Since(start), err)
+	}
 	if err != nil {
 		if err == parser.HadErrors {
 			return nil, errpos.AddSourceFile(tree.Errors, filename, data)
 		}
+		if _, ok := errpos.AsErrorsWithSource(err); ok {
+			return nil, errpos.AddSourceFile(err, filename, data)
+		}
 		return nil, fmt.Errorf("parse file not HadErrors - : %w", err)
 	}
+	return tree, nil
+}
+
+func (p *Parser) ParseAST(tree *parser.File, msg protoreflect.Message) (*bcl_j5pb.SourceLocation, error) {
+	result, err := p.ParseASTResult(tree, msg)
+	if result == nil {
+		return nil, err
+	}
+	return result.SourceLocation, err
+}
+
+// ParseASTResult is ParseAST returning the whole result, including the
+// warnings of the walk.
+func (p *Parser) ParseASTResult(tree *parser.File, msg protoreflect.Message) (*ParseResult, error) {
+	return p.parseAST(context.Background(), "", tree, msg)
+}
+
+// parseAST walks and validates the tree of the file at filename, which may be
+// empty when the tree wasn't read from a file.
+func (p *Parser) parseAST(ctx context.Context, filename string, tree *parser.File, msg protoreflect.Message) (*ParseResult, error) {
+	var source *bcl_j5pb.SourceLocation
+	if p.SourceLocations == SourceLocationsFull {
+		source = &bcl_j5pb.SourceLocation{}
+	}
+
+	result := &ParseResult{
+		SourceLocation: source,
+		Stats: Stats{
+			Tokens: tree.Stats.Tokens,
+			Lex:    tree.Stats.Lex,
+			Parse:  tree.Stats.Parse,
+		},
+		Metadata: fileMetadata(tree),
+		filename: filename,
+		lineMap:  tree.LineMap,
+	}
+
+	if len(tree.Separators) > 0 {
+		return result, separatorError(tree.Separators[0], "the file has several documents, which ParseDocuments parses")
+	}
+
+	maskSource := source
+	if (p.FieldMask || p.Fingerprints) && maskSource == nil {
+		maskSource = &bcl_j5pb.SourceLocation{}
+	}
 
-	loc, err := p.ParseAST(tree, msg)
+	walkStart := time.Now()
+	warnings := errpos.Errors{}
+	err := p.walkAST(filename, tree, msg, maskSource, &result.Stats, &warnings, &result.Normalized)
+	if len(warnings) > 0 {
+		result.Warnings = warnings
+		errpos.MapPositions(warnings, tree.LineMap.Map)
+	}
+	result.Stats.Walk = time.Since(walkStart)
+	p.traceSpan(ctx, SpanWalk, walkStart, result.Stats.Walk, err)
+	p.reportProgress(Progress{Stage: ProgressParsed, Filename: filename, Stats: result.Stats, Failed: err != nil})
 	if err != nil {
-		err = errpos.AddSourceFile(err, filename, data)
-		return loc, err
+		return result, err
+	}
+	if err := p.deriveFields(filename, msg, maskSource, tree.Suppressions); err != nil {
+		return result, err
+	}
+	if p.FieldMask {
+		result.FieldMask = fieldMask(msg.Descriptor(), maskSource)
+	}
+
+	validateStart := time.Now()
+	err = p.validateAST(filename, tree, msg, result)
+	result.Stats.Validate = time.Since(validateStart)
+	p.traceSpan(ctx, SpanValidate, validateStart, result.Stats.Validate, err)
+	p.reportProgress(Progress{Stage: ProgressValidated, Filename: filename, Stats: result.Stats, Failed: err != nil})
+	if err != nil {
+		return result, err
+	}
+
+	if p.Fingerprints {
+		result.Fingerprints, err = p.fingerprints(filename, msg, maskSource)
+		if err != nil {
+			return result, err
+		}
 	}
-	return loc, nil
+
+	return result, nil
 }
 
-func (p *Parser) ParseAST(tree *parser.File, msg protoreflect.Message) (*bcl_j5pb.SourceLocation, error) {
-	obj, err := p.refl.NewObject(msg)
+func (p *Parser) walkAST(filename string, tree *parser.File, msg protoreflect.Message, source *bcl_j5pb.SourceLocation, stats *Stats, warnings *errpos.Errors, normalized *[]Normalized) error {
+	walked, obj, err := p.objectFor(msg)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer fromView(msg, walked)
 
-	source := &bcl_j5pb.SourceLocation{}
 	scope, err := schema.NewRootSchemaWalker(p.schema, obj, source)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	normalizers := &schema.Normalizers{Funcs: p.normalizers}
+	scope.SetNormalizers(normalizers)
 
-	err = walker.WalkSchema(scope, tree.Body, p.Verbose)
+	walkStats := &walker.Stats{}
+	err = walker.WalkSchema(scope, tree.Body, walker.WalkOptions{
+		Logger:     p.logger(),
+		Filename:   filename,
+		Suppress:   tree.Suppressions,
+		Stats:      walkStats,
+		NewAny:     p.newAny,
+		Extensions: p.extensions(),
+		Functions:  p.functionsFor(filename),
+		Edition:    tree.Edition,
+
+		OneofConflicts: walker.OneofPolicy(p.OneofConflicts),
+		Warnings:       warnings,
+		MaxRecursion:   p.Limits.MaxRecursion,
+		Computed:       p.computed,
+		FreeForm:       p.views.freeFormType,
+	})
+	stats.Statements += walkStats.Statements
+	stats.Blocks += walkStats.Blocks
+	stats.ReflectValues += walkStats.ReflectValues
+	if normalized != nil {
+		for _, applied := range normalizers.Applied {
+			if mapped, ok := tree.LineMap.Map(applied.Pos); ok {
+				applied.Pos = mapped
+			}
+			if applied.Pos.Filename == nil && filename != "" {
+				applied.Pos.Filename = &filename
+			}
+			*normalized = append(*normalized, applied)
+		}
+	}
 	if err != nil {
-		return source, fmt.Errorf("walkSchema: %w", err)
+		return fmt.Errorf("walkSchema: %w", err)
 	}
+	return nil
+}
 
-	err = validateFile(p.validate, msg.Interface(), source)
+// newAny builds a new message of the named type to pack into an Any field.
+func (p *Parser) newAny(typeName string) (j5reflect.Object, func() protoreflect.Message, error) {
+	resolver := p.AnyTypes
+	if resolver == nil {
+		resolver = protoregistry.GlobalTypes
+	}
+	mt, err := resolver.FindMessageByName(protoreflect.FullName(typeName))
+	if errors.Is(err, protoregistry.NotFound) {
+		return nil, nil, fmt.Errorf("unknown message type %s", typeName)
+	} else if err != nil {
+		return nil, nil, err
+	}
+	msg := mt.New()
+	walked, obj, err := p.objectFor(msg)
 	if err != nil {
-		return source, err
+		return nil, nil, err
+	}
+	return obj, func() protoreflect.Message {
+		fromView(msg, walked)
+		return msg
+	}, nil
+}
+
+func (p *Parser) validateAST(filename string, tree *parser.File, msg protoreflect.Message, result *ParseResult) error {
+	source := result.SourceLocation
+	if source == nil {
+		source = &bcl_j5pb.SourceLocation{}
 	}
+	err := p.validateFile(filename, msg, source, tree.Suppressions)
+	if err == nil || p.SourceLocations != SourceLocationsLazy {
+		return err
+	}
+
+	// Walk the file again, this time with tracking, into a throwaway message
+	// to find the positions of the validation errors. The computed children
+	// are already in msg, so they aren't computed a second time.
+	rewalk := *p
+	rewalk.computed = nil
+	source = &bcl_j5pb.SourceLocation{}
+	if err := rewalk.walkAST(filename, tree, msg.New(), source, &Stats{}, nil, nil); err != nil {
+		return err
+	}
+	result.SourceLocation = source
+	return p.validateFile(filename, msg, source, tree.Suppressions)
+}
 
-	return source, nil
+// validateFile runs the protovalidate rules, then the policies of the schema
+// and the duplicate block check once the rules pass.
+func (p *Parser) validateFile(filename string, msg protoreflect.Message, source *bcl_j5pb.SourceLocation, suppressions parser.Suppressions) error {
+	if err := validateFile(p.validate, msg.Interface(), source, suppressions); err != nil {
+		return err
+	}
+	if err := p.checkPolicies(filename, msg, source, suppressions); err != nil {
+		return err
+	}
+	return p.checkDuplicates(filename, msg, source, suppressions)
 }
 
 type baseSet struct {
@@ -165,6 +621,10 @@ func (ss sourceSet) err(err error) {
 		}
 	}
 
+	if base.Code == "" {
+		base.Code = errpos.CodeValidation
+	}
+
 	if ss.loc != nil && base.Pos == nil {
 		base.Pos = &errpos.Position{
 			Start: errpos.Point{Line: int(ss.loc.StartLine), Column: int(ss.loc.StartColumn)},
@@ -186,7 +646,7 @@ func printSource(loc *sourcedef_j5pb.SourceLocation, prefix string) {
 }
 */
 
-func validateFile(pv *protovalidate.Validator, msg protoreflect.ProtoMessage, source *bcl_j5pb.SourceLocation) error {
+func validateFile(pv *protovalidate.Validator, msg protoreflect.ProtoMessage, source *bcl_j5pb.SourceLocation, suppressions parser.Suppressions) error {
 
 	sources := newSourceSet(source)
 
@@ -204,9 +664,10 @@ func validateFile(pv *protovalidate.Validator, msg protoreflect.ProtoMessage, so
 		}
 	}
 
-	if len(sources.base.errors) == 0 {
+	errs := suppressions.Filter(sources.base.errors)
+	if len(errs) == 0 {
 		return nil
 	}
 
-	return errpos.Errors(sources.base.errors)
+	return errs
 }
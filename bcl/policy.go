@@ -0,0 +1,139 @@
+package bcl
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// policySet compiles the policies of the schema against the message types as
+// they are first seen.
+type policySet struct {
+	policies map[string][]schema.Policy
+	compiled programCache[protoreflect.FullName, []policyProgram]
+}
+
+type policyProgram struct {
+	policy  schema.Policy
+	program cel.Program
+}
+
+func newPolicySet(ss *schema.SchemaSet) (*policySet, error) {
+	policies := ss.Policies()
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	// Catch syntax errors when the parser is built, types are checked when
+	// the policy is first used.
+	env, err := cel.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+	for name, list := range policies {
+		for _, policy := range list {
+			if _, iss := env.Parse(policy.Expression); iss.Err() != nil {
+				return nil, fmt.Errorf("policy on %s: %w", name, iss.Err())
+			}
+		}
+	}
+	return &policySet{policies: policies}, nil
+}
+
+func (ps *policySet) programs(desc protoreflect.MessageDescriptor) ([]policyProgram, error) {
+	return ps.compiled.get(desc.FullName(), func() ([]policyProgram, error) {
+		return compilePolicies(desc, ps.policies[j5SchemaName(desc)])
+	})
+}
+
+func compilePolicies(desc protoreflect.MessageDescriptor, policies []schema.Policy) ([]policyProgram, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	env, err := cel.NewEnv(
+		cel.TypeDescs(desc.ParentFile()),
+		cel.Variable("this", cel.ObjectType(string(desc.FullName()))),
+		cel.Variable("file", cel.StringType),
+		cel.Variable("line", cel.IntType),
+		cel.Variable("column", cel.IntType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make([]policyProgram, 0, len(policies))
+	for _, policy := range policies {
+		ast, iss := env.Compile(policy.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("policy on %s: %w", desc.FullName(), iss.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("policy on %s: %q is %s, not bool", desc.FullName(), policy.Expression, ast.OutputType())
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("policy on %s: %w", desc.FullName(), err)
+		}
+		programs = append(programs, policyProgram{policy: policy, program: program})
+	}
+	return programs, nil
+}
+
+// checkPolicies evaluates the policies of every message in the file, reporting
+// the false ones at the block of the message.
+func (p *Parser) checkPolicies(filename string, msg protoreflect.Message, source *bcl_j5pb.SourceLocation, suppressions parser.Suppressions) error {
+	if p.policies == nil {
+		return nil
+	}
+	base := &baseSet{}
+	err := visitMessages(msg, source, func(node messageNode) error {
+		return p.policies.check(filename, node.msg, node.sources(base))
+	})
+	if err != nil {
+		return err
+	}
+	errs := suppressions.Filter(base.errors)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func (ps *policySet) check(filename string, msg protoreflect.Message, sources sourceSet) error {
+	programs, err := ps.programs(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	for _, pp := range programs {
+		out, _, err := pp.program.Eval(map[string]any{
+			"this":   msg.Interface(),
+			"file":   filename,
+			"line":   int64(sources.loc.StartLine) + 1,
+			"column": int64(sources.loc.StartColumn) + 1,
+		})
+		if err != nil {
+			sources.err(&errpos.Err{
+				Code: errpos.CodePolicy,
+				Err:  fmt.Errorf("policy %q: %w", pp.policy.Expression, err),
+			})
+			continue
+		}
+		if ok, isBool := out.Value().(bool); !isBool || !ok {
+			message := pp.policy.Message
+			if message == "" {
+				message = fmt.Sprintf("policy %q is false", pp.policy.Expression)
+			}
+			sources.err(&errpos.Err{
+				Code: errpos.CodePolicy,
+				Err:  fmt.Errorf("%s", message),
+			})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package bcl
+
+// ProgressStage is the step of loading a project which a Progress reports.
+type ProgressStage int
+
+const (
+	// ProgressDiscovered reports the number of files to load, before any are
+	// parsed.
+	ProgressDiscovered ProgressStage = iota + 1
+
+	// ProgressParsed reports a file which was lexed, parsed and walked into
+	// its message.
+	ProgressParsed
+
+	// ProgressValidated reports a file which was validated, the last step of
+	// a parse.
+	ProgressValidated
+)
+
+func (ps ProgressStage) String() string {
+	switch ps {
+	case ProgressDiscovered:
+		return "discovered"
+	case ProgressParsed:
+		return "parsed"
+	case ProgressValidated:
+		return "validated"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress is reported to Parser.Progress as each file is parsed, and by
+// LoadFSWithProgress as a project is loaded, for progress bars and slow file
+// telemetry.
+type Progress struct {
+	Stage ProgressStage
+
+	// Files is the number of files discovered, for ProgressDiscovered.
+	Files int
+
+	// Filename is the file parsed or validated.
+	Filename string
+
+	// Stats are the counters and timings of the file so far, Validate is
+	// zero until it is validated.
+	Stats Stats
+
+	// Failed is set when the stage failed, and no later stage is reported
+	// for the file.
+	Failed bool
+}
+
+func (p *Parser) reportProgress(progress Progress) {
+	if p.Progress != nil {
+		p.Progress(progress)
+	}
+}
@@ -0,0 +1,237 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFilename is the name of the config file at the root of a project.
+const ConfigFilename = "bcl.yaml"
+
+// Config is the project config, read from bcl.yaml. All fields are optional.
+type Config struct {
+	// Extensions of the BCL files in the project, default .bcl and .j5s
+	Extensions []string `yaml:"extensions,omitempty"`
+
+	// Exclude is a list of path.Match patterns, relative to the project root,
+	// for files and directories which are not part of the project.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	Fix FixConfig `yaml:"fix,omitempty"`
+
+	Format FormatConfig `yaml:"format,omitempty"`
+
+	// Naming sets the case the linter expects of names, by the schema name
+	// of a block for the values of its name tag, e.g. "test.v1.Element_Foo",
+	// or by the schema name and JSON name of a map field for its keys, e.g.
+	// "test.v1.File.tags".
+	Naming map[string]Case `yaml:"naming,omitempty"`
+
+	// Schema is the path of a BCL schema file (j5.bcl.v1.SchemaFile) with the
+	// block specs for the project files. It is not itself a project file.
+	Schema string `yaml:"schema,omitempty"`
+
+	// OverrideSuffix marks override files, which are applied to the file of
+	// the same name without the suffix, e.g. .override so that
+	// a.override.bcl overrides a.bcl. Empty, the default, has no override
+	// files.
+	OverrideSuffix string `yaml:"overrideSuffix,omitempty"`
+}
+
+// FixConfig controls which changes `bcl lint --fix` makes.
+type FixConfig struct {
+	// SkipFormat disables formatting files after the fixes are applied.
+	SkipFormat bool `yaml:"skipFormat,omitempty"`
+
+	// Unsafe also applies fixes which need review, such as replacing an
+	// unknown name with the closest match.
+	Unsafe bool `yaml:"unsafe,omitempty"`
+
+	// Disable lists codes whose fixes are never applied.
+	Disable []errpos.Code `yaml:"disable,omitempty"`
+
+	// KeepOrder leaves the attributes of blocks which the schema marks
+	// unordered as they are written. Otherwise fixing sorts them, as the
+	// sortAttributes format option does, unless SkipFormat is set.
+	KeepOrder bool `yaml:"keepOrder,omitempty"`
+}
+
+// Allow returns true when the fix for an error with the given code should be
+// applied.
+func (fc FixConfig) Allow(code errpos.Code, fix errpos.Fix) bool {
+	if !fix.Safe && !fc.Unsafe {
+		return false
+	}
+	for _, disabled := range fc.Disable {
+		if disabled == code {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatConfig controls how `bcl fmt` and `bcl lint --fix` format files,
+// including the schema-aware ordering they apply before formatting. All
+// options are off by default.
+type FormatConfig struct {
+	// MaxBlankLines is the longest run of blank lines kept between
+	// statements. Zero uses the default of one.
+	MaxBlankLines int `yaml:"maxBlankLines,omitempty"`
+
+	// Width is the column long arrays and joined strings are wrapped at, one
+	// element per line, with short ones joined onto a line. Zero leaves them
+	// on the lines they are written on.
+	Width int `yaml:"width,omitempty"`
+
+	// LineEndings is "lf" or "crlf", the line breaks files are written with.
+	// Empty keeps the line breaks of each file, by its first line.
+	LineEndings string `yaml:"lineEndings,omitempty"`
+
+	// IndentSpaces indents with that many spaces per level instead of a tab.
+	IndentSpaces int `yaml:"indentSpaces,omitempty"`
+
+	// ByteOrderMark is "add" or "remove", to start files with a byte order
+	// mark or not. Empty keeps each file's.
+	ByteOrderMark string `yaml:"byteOrderMark,omitempty"`
+
+	// TagsFirst moves assignments to the name and type tag fields of a block
+	// to the top of it.
+	TagsFirst bool `yaml:"tagsFirst,omitempty"`
+
+	// AttributesFirst moves the attributes of a block above its nested
+	// blocks.
+	AttributesFirst bool `yaml:"attributesFirst,omitempty"`
+
+	// SortAttributes sorts the attributes of blocks which the schema marks
+	// unordered by name.
+	SortAttributes bool `yaml:"sortAttributes,omitempty"`
+}
+
+func (fc FormatConfig) validate() error {
+	switch fc.LineEndings {
+	case "", "lf", "crlf":
+	default:
+		return fmt.Errorf("lineEndings %q is not lf or crlf", fc.LineEndings)
+	}
+	switch fc.ByteOrderMark {
+	case "", "add", "remove":
+	default:
+		return fmt.Errorf("byteOrderMark %q is not add or remove", fc.ByteOrderMark)
+	}
+	if fc.IndentSpaces < 0 {
+		return fmt.Errorf("indentSpaces %d is negative", fc.IndentSpaces)
+	}
+	return nil
+}
+
+// Reorders returns true when any option changes the order of statements.
+func (fc FormatConfig) Reorders() bool {
+	return fc.TagsFirst || fc.AttributesFirst || fc.SortAttributes
+}
+
+// DefaultConfig is used when the project has no config file.
+func DefaultConfig() *Config {
+	return &Config{
+		Extensions: []string{".bcl", ".j5s"},
+	}
+}
+
+// LoadConfig reads the config file from the root of the project, returning
+// the default config if there isn't one.
+func LoadConfig(root fs.FS) (*Config, error) {
+	data, err := fs.ReadFile(root, ConfigFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultConfig(), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	config := DefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", ConfigFilename, err)
+	}
+
+	for _, pattern := range config.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: exclude %q: %w", ConfigFilename, pattern, err)
+		}
+	}
+	if err := config.Format.validate(); err != nil {
+		return nil, fmt.Errorf("%s: format: %w", ConfigFilename, err)
+	}
+	for name, style := range config.Naming {
+		if err := style.validate(); err != nil {
+			return nil, fmt.Errorf("%s: naming %s: %w", ConfigFilename, name, err)
+		}
+	}
+
+	return config, nil
+}
+
+func (c *Config) excluded(pathname string) bool {
+	for _, pattern := range c.Exclude {
+		if ok, _ := path.Match(pattern, pathname); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsProjectFile returns true when the path, relative to the project root, is a
+// BCL file in the project.
+func (c *Config) IsProjectFile(pathname string) bool {
+	if c.excluded(pathname) || pathname == c.Schema {
+		return false
+	}
+	ext := path.Ext(pathname)
+	for _, want := range c.Extensions {
+		if ext == want {
+			return true
+		}
+	}
+	return false
+}
+
+// BaseFile returns the file an override file applies to, and false when the
+// path is not an override file.
+func (c *Config) BaseFile(pathname string) (string, bool) {
+	if c.OverrideSuffix == "" {
+		return "", false
+	}
+	ext := path.Ext(pathname)
+	stem, ok := strings.CutSuffix(strings.TrimSuffix(pathname, ext), c.OverrideSuffix)
+	if !ok || stem == "" || strings.HasSuffix(stem, "/") {
+		return "", false
+	}
+	return stem + ext, true
+}
+
+// Files lists the BCL files in the project, in lexical order.
+func (c *Config) Files(root fs.FS) ([]string, error) {
+	files := []string{}
+	err := fs.WalkDir(root, ".", func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if pathname != "." && c.excluded(pathname) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if c.IsProjectFile(pathname) {
+			files = append(files, pathname)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
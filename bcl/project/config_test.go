@@ -0,0 +1,72 @@
+package project
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig(t *testing.T) {
+	root := fstest.MapFS{
+		"bcl.yaml": {Data: []byte(`
+extensions: [".bcl"]
+exclude: ["vendor", "*.gen.bcl"]
+fix:
+  disable: [BCL2002]
+`)},
+		"a.bcl":         {},
+		"a.gen.bcl":     {},
+		"b.j5s":         {},
+		"sub/c.bcl":     {},
+		"vendor/d.bcl":  {},
+		"sub/README.md": {},
+	}
+
+	config, err := LoadConfig(root)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	files, err := config.Files(root)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"a.bcl", "sub/c.bcl"}, files)
+
+	safe := errpos.Fix{Safe: true}
+	assert.True(t, config.Fix.Allow(errpos.CodeTypeMismatch, safe))
+	assert.False(t, config.Fix.Allow(errpos.CodeInvalidValue, safe))
+	assert.False(t, config.Fix.Allow(errpos.CodeUnknownBlock, errpos.Fix{}))
+}
+
+func TestDefaultConfig(t *testing.T) {
+	config, err := LoadConfig(fstest.MapFS{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, config.IsProjectFile("x/y.bcl"))
+	assert.True(t, config.IsProjectFile("y.j5s"))
+	assert.False(t, config.IsProjectFile("y.yaml"))
+}
+
+func TestBaseFile(t *testing.T) {
+	config := DefaultConfig()
+	_, ok := config.BaseFile("sub/a.override.bcl")
+	assert.False(t, ok, "override files are opt-in")
+
+	config.OverrideSuffix = ".override"
+	base, ok := config.BaseFile("sub/a.override.bcl")
+	assert.True(t, ok)
+	assert.Equal(t, "sub/a.bcl", base)
+
+	_, ok = config.BaseFile("sub/a.bcl")
+	assert.False(t, ok)
+	_, ok = config.BaseFile("sub/.override.bcl")
+	assert.False(t, ok)
+
+	config.OverrideSuffix = ""
+	_, ok = config.BaseFile("sub/a.override.bcl")
+	assert.False(t, ok)
+}
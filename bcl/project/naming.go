@@ -0,0 +1,132 @@
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Case is a naming convention for block names and map keys, see
+// Config.Naming.
+type Case string
+
+const (
+	CamelCase          Case = "camelCase"
+	PascalCase         Case = "PascalCase"
+	SnakeCase          Case = "snake_case"
+	KebabCase          Case = "kebab-case"
+	ScreamingSnakeCase Case = "SCREAMING_SNAKE_CASE"
+)
+
+var casePatterns = map[Case]*regexp.Regexp{
+	CamelCase:          regexp.MustCompile(`^\p{Ll}[\p{L}\p{N}]*$`),
+	PascalCase:         regexp.MustCompile(`^\p{Lu}[\p{L}\p{N}]*$`),
+	SnakeCase:          regexp.MustCompile(`^\p{Ll}[\p{Ll}\p{N}]*(_[\p{Ll}\p{N}]+)*$`),
+	KebabCase:          regexp.MustCompile(`^\p{Ll}[\p{Ll}\p{N}]*(-[\p{Ll}\p{N}]+)*$`),
+	ScreamingSnakeCase: regexp.MustCompile(`^\p{Lu}[\p{Lu}\p{N}]*(_[\p{Lu}\p{N}]+)*$`),
+}
+
+func (c Case) validate() error {
+	if _, ok := casePatterns[c]; !ok {
+		return fmt.Errorf("case %q is not one of camelCase, PascalCase, snake_case, kebab-case or SCREAMING_SNAKE_CASE", c)
+	}
+	return nil
+}
+
+// Matches returns true when the name follows the convention.
+func (c Case) Matches(name string) bool {
+	pattern, ok := casePatterns[c]
+	return ok && pattern.MatchString(name)
+}
+
+// Convert returns the name in the convention. It is false when the words of
+// the name are ambiguous, e.g. the acronym in "HTTPServer", or when the name
+// can't be written in it, e.g. it starts with a digit.
+func (c Case) Convert(name string) (string, bool) {
+	parts, ok := words(name)
+	if !ok {
+		return "", false
+	}
+	for idx, word := range parts {
+		switch {
+		case c == ScreamingSnakeCase:
+			parts[idx] = strings.ToUpper(word)
+		case c == PascalCase, c == CamelCase && idx > 0:
+			parts[idx] = title(word)
+		default:
+			parts[idx] = strings.ToLower(word)
+		}
+	}
+	var out string
+	switch c {
+	case SnakeCase, ScreamingSnakeCase:
+		out = strings.Join(parts, "_")
+	case KebabCase:
+		out = strings.Join(parts, "-")
+	default:
+		out = strings.Join(parts, "")
+	}
+	if !c.Matches(out) {
+		return "", false
+	}
+	return out, true
+}
+
+// words splits a name at '-', '_' and spaces, and before an upper case letter
+// which follows a lower case letter or a digit. It is false for names with
+// other runes, empty words, or a word with a run of upper case letters before
+// lower case ones, which could be split more than one way.
+func words(name string) ([]string, bool) {
+	out := []string{}
+	word := []rune{}
+	for _, r := range name {
+		switch {
+		case r == '-' || r == '_' || r == ' ':
+			if len(word) == 0 {
+				return nil, false
+			}
+			out = append(out, string(word))
+			word = nil
+			continue
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			return nil, false
+		case unicode.IsUpper(r) && len(word) > 0 && !unicode.IsUpper(word[len(word)-1]):
+			out = append(out, string(word))
+			word = nil
+		}
+		word = append(word, r)
+	}
+	if len(word) == 0 {
+		return nil, false
+	}
+	out = append(out, string(word))
+
+	for _, word := range out {
+		if isAcronym(word) {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// isAcronym is true for a word which starts with more than one upper case
+// letter and goes on in lower case, e.g. "HTTPServer".
+func isAcronym(word string) bool {
+	upper := 0
+	for _, r := range word {
+		if unicode.IsLower(r) {
+			return upper > 1
+		}
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return false
+}
+
+func title(word string) string {
+	lower := []rune(strings.ToLower(word))
+	lower[0] = unicode.ToUpper(lower[0])
+	return string(lower)
+}
@@ -0,0 +1,45 @@
+package project
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestCaseConvert(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		style Case
+		want  string // empty when ambiguous
+	}{
+		{name: "my_key", style: CamelCase, want: "myKey"},
+		{name: "my-key", style: PascalCase, want: "MyKey"},
+		{name: "myKey", style: SnakeCase, want: "my_key"},
+		{name: "MyKey2", style: KebabCase, want: "my-key2"},
+		{name: "my key", style: ScreamingSnakeCase, want: "MY_KEY"},
+		{name: "MY_KEY", style: CamelCase, want: "myKey"},
+		{name: "userID", style: SnakeCase, want: "user_id"},
+		{name: "HTTPServer", style: SnakeCase},
+		{name: "my__key", style: CamelCase},
+		{name: "my.key", style: CamelCase},
+		{name: "2fa", style: CamelCase},
+	} {
+		got, ok := tc.style.Convert(tc.name)
+		if ok != (tc.want != "") || got != tc.want {
+			t.Errorf("%s.Convert(%q) = %q, %v, want %q", tc.style, tc.name, got, ok, tc.want)
+		}
+		if ok && !tc.style.Matches(got) {
+			t.Errorf("%s does not match its own conversion %q", tc.style, got)
+		}
+	}
+
+	if !CamelCase.Matches("myHTTPServer") || CamelCase.Matches("MyKey") || KebabCase.Matches("my_key") {
+		t.Error("unexpected match")
+	}
+
+	_, err := LoadConfig(fstest.MapFS{
+		"bcl.yaml": {Data: []byte("naming:\n  test.v1.Foo: Title Case\n")},
+	})
+	if err == nil {
+		t.Error("expected an error for an unknown case")
+	}
+}
@@ -0,0 +1,74 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ErrOutsideRoot is returned when a reference resolves to a path outside of the
+// resolver's root, e.g. with leading ../ elements.
+var ErrOutsideRoot = errors.New("path is outside of the project root")
+
+// Resolver loads the files which one file references from another, such as
+// includes. Embedders implement it to serve files from memory, a bundle or a
+// database.
+type Resolver interface {
+	// Resolve returns the canonical path of name, referenced from the file at
+	// the canonical path from. Relative names are relative to the directory of
+	// from. The canonical path identifies the file in errors and for detecting
+	// cycles.
+	Resolve(from, name string) (string, error)
+
+	// ReadFile returns the content of a file by its canonical path.
+	ReadFile(pathname string) ([]byte, error)
+}
+
+// FSResolver resolves files in an fs.FS, with paths confined to its root.
+type FSResolver struct {
+	FS fs.FS
+}
+
+var _ Resolver = FSResolver{}
+
+func NewFSResolver(root fs.FS) FSResolver {
+	return FSResolver{FS: root}
+}
+
+// Resolve returns the slash separated path of name relative to the root. Names
+// starting with / are relative to the root rather than to from.
+func (r FSResolver) Resolve(from, name string) (string, error) {
+	return confine(from, name)
+}
+
+func (r FSResolver) ReadFile(pathname string) ([]byte, error) {
+	if !fs.ValidPath(pathname) {
+		return nil, &fs.PathError{Op: "read", Path: pathname, Err: ErrOutsideRoot}
+	}
+	return fs.ReadFile(r.FS, pathname)
+}
+
+// confine joins name to the directory of from, returning an error for results
+// which are outside of the root.
+func confine(from, name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("empty path")
+	}
+	if strings.Contains(name, "\\") {
+		return "", fmt.Errorf("%q: paths are slash separated", name)
+	}
+
+	var joined string
+	if strings.HasPrefix(name, "/") {
+		joined = path.Clean(strings.TrimPrefix(name, "/"))
+	} else {
+		joined = path.Join(path.Dir(from), name)
+	}
+
+	if !fs.ValidPath(joined) || joined == "." {
+		return "", &fs.PathError{Op: "resolve", Path: name, Err: ErrOutsideRoot}
+	}
+	return joined, nil
+}
@@ -0,0 +1,53 @@
+package project
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFSResolver(t *testing.T) {
+	resolver := NewFSResolver(fstest.MapFS{
+		"a.bcl":        {Data: []byte("a")},
+		"sub/b.bcl":    {Data: []byte("b")},
+		"sub/in/c.bcl": {Data: []byte("c")},
+	})
+
+	for _, tc := range []struct {
+		from string
+		name string
+		want string
+	}{
+		{from: "a.bcl", name: "sub/b.bcl", want: "sub/b.bcl"},
+		{from: "sub/b.bcl", name: "in/c.bcl", want: "sub/in/c.bcl"},
+		{from: "sub/in/c.bcl", name: "../../a.bcl", want: "a.bcl"},
+		{from: "sub/in/c.bcl", name: "/sub/b.bcl", want: "sub/b.bcl"},
+	} {
+		got, err := resolver.Resolve(tc.from, tc.name)
+		if err != nil {
+			t.Fatalf("%s from %s: %s", tc.name, tc.from, err)
+		}
+		assert.Equal(t, tc.want, got)
+
+		_, err = resolver.ReadFile(got)
+		assert.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		from string
+		name string
+	}{
+		{from: "a.bcl", name: "../a.bcl"},
+		{from: "sub/b.bcl", name: "../../a.bcl"},
+		{from: "sub/b.bcl", name: "/../a.bcl"},
+		{from: "a.bcl", name: "."},
+	} {
+		_, err := resolver.Resolve(tc.from, tc.name)
+		assert.True(t, errors.Is(err, ErrOutsideRoot), "%s from %s: %v", tc.name, tc.from, err)
+	}
+
+	_, err := resolver.ReadFile("../a.bcl")
+	assert.True(t, errors.Is(err, ErrOutsideRoot))
+}
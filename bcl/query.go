@@ -0,0 +1,354 @@
+package bcl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// QueryResult is a value selected by Query.
+type QueryResult struct {
+	// Path is the dotted path of the value, as for Explain.
+	Path string
+
+	// Field holds the value, nil for the root message. Value is an element
+	// of it when Element is set, otherwise the whole list or map.
+	Field   protoreflect.FieldDescriptor
+	Value   protoreflect.Value
+	Element bool
+
+	sensitive bool
+}
+
+// Query selects values from a parsed message by a path expression. Fields are
+// named by their JSON or proto name and separated by dots, with list elements
+// and map entries selected in brackets:
+//
+//	elements[0].foo.name
+//	elements[-1]    the last element
+//	elements[*]     every element
+//	tags["key"]     also tags.key or tags[key]
+//
+// Quoted keys are Go string literals in double quotes. Values under messages
+// which are not set are not selected. Unset scalars select their default.
+func Query(msg proto.Message, expr string) ([]QueryResult, error) {
+	steps, err := parseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []QueryResult{{
+		Value: protoreflect.ValueOfMessage(msg.ProtoReflect()),
+	}}
+	for _, step := range steps {
+		next := []QueryResult{}
+		for _, result := range results {
+			selected, err := step.apply(result)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", expr, err)
+			}
+			next = append(next, selected...)
+		}
+		results = next
+	}
+	return results, nil
+}
+
+type queryStep struct {
+	field string // a field name, or map key
+
+	index    *int    // [n]
+	key      *string // ["key"]
+	wildcard bool    // [*]
+}
+
+func parseQuery(expr string) ([]queryStep, error) {
+	steps := []queryStep{}
+	rest := strings.TrimPrefix(expr, ".")
+	for rest != "" {
+		switch {
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unclosed '['", expr)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			step := queryStep{}
+			if inner == "*" {
+				step.wildcard = true
+			} else if idx, err := strconv.Atoi(inner); err == nil {
+				step.index = &idx
+			} else if strings.HasPrefix(inner, `"`) {
+				unquoted, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("%s: bad quoted key %s", expr, inner)
+				}
+				step.key = &unquoted
+			} else if strings.ContainsAny(inner, "'`") {
+				return nil, fmt.Errorf("%s: keys are quoted with double quotes, not %s", expr, inner)
+			} else {
+				// map keys out of the range of an int, e.g. uint64, are
+				// keys rather than indexes
+				step.key = &inner
+			}
+			steps = append(steps, step)
+
+		case rest[0] == '.':
+			rest = rest[1:]
+			if rest == "" || rest[0] == '.' || rest[0] == '[' {
+				return nil, fmt.Errorf("%s: empty field name", expr)
+			}
+
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			steps = append(steps, queryStep{field: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+	return steps, nil
+}
+
+func (step queryStep) apply(result QueryResult) ([]QueryResult, error) {
+	field := result.Field
+	switch {
+	case field != nil && field.IsList() && !result.Element:
+		if step.key != nil {
+			if _, err := strconv.Atoi(*step.key); errors.Is(err, strconv.ErrRange) {
+				return nil, fmt.Errorf("%s is a list, index %s is out of range", result.Path, *step.key)
+			}
+		}
+		if step.key != nil || step.field != "" {
+			return nil, fmt.Errorf("%s is a list, select elements by [index]", result.Path)
+		}
+		list := result.Value.List()
+		indexes := []int{}
+		if step.wildcard {
+			for idx := 0; idx < list.Len(); idx++ {
+				indexes = append(indexes, idx)
+			}
+		} else {
+			idx := *step.index
+			if idx < 0 {
+				idx += list.Len()
+			}
+			if idx >= 0 && idx < list.Len() {
+				indexes = append(indexes, idx)
+			}
+		}
+		selected := make([]QueryResult, len(indexes))
+		for idx, listIdx := range indexes {
+			selected[idx] = result.element(strconv.Itoa(listIdx), list.Get(listIdx))
+		}
+		return selected, nil
+
+	case field != nil && field.IsMap() && !result.Element:
+		m := result.Value.Map()
+		keys := []string{}
+		switch {
+		case step.wildcard:
+			m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, key.String())
+				return true
+			})
+			sort.Strings(keys)
+		case step.key != nil:
+			keys = append(keys, *step.key)
+		case step.field != "":
+			keys = append(keys, step.field)
+		default:
+			keys = append(keys, strconv.Itoa(*step.index))
+		}
+		selected := []QueryResult{}
+		for _, key := range keys {
+			mapKey, err := parseMapKey(field, key)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", result.Path, err)
+			}
+			if m.Has(mapKey) {
+				selected = append(selected, result.element(key, m.Get(mapKey)))
+			}
+		}
+		return selected, nil
+
+	case step.field == "":
+		return nil, fmt.Errorf("%s is not a list or map", result.Path)
+	}
+
+	if field != nil && field.IsMap() {
+		field = field.MapValue()
+	}
+	var desc protoreflect.MessageDescriptor
+	if field == nil {
+		desc = result.Value.Message().Descriptor()
+	} else if field.Message() != nil {
+		desc = field.Message()
+	} else {
+		return nil, fmt.Errorf("%s is a scalar, it has no field %q", result.Path, step.field)
+	}
+
+	child := desc.Fields().ByJSONName(step.field)
+	if child == nil {
+		child = desc.Fields().ByName(protoreflect.Name(step.field))
+	}
+	if child == nil {
+		return nil, fmt.Errorf("%s has no field %q", desc.FullName(), step.field)
+	}
+
+	parent := result.Value.Message()
+	if child.Message() != nil && !child.IsList() && !child.IsMap() && !parent.Has(child) {
+		return []QueryResult{}, nil
+	}
+
+	path := child.JSONName()
+	if result.Path != "" {
+		path = result.Path + "." + path
+	}
+	return []QueryResult{{
+		Path:      path,
+		Field:     child,
+		Value:     parent.Get(child),
+		sensitive: result.sensitive || isSensitive(child),
+	}}, nil
+}
+
+func (result QueryResult) element(key string, value protoreflect.Value) QueryResult {
+	return QueryResult{
+		Path:      result.Path + "." + key,
+		Field:     result.Field,
+		Value:     value,
+		Element:   true,
+		sensitive: result.sensitive,
+	}
+}
+
+// String prints scalars as plain text, for shell scripts, and messages, lists
+// and maps as JSON. Sensitive values are Redacted.
+func (result QueryResult) String() string {
+	if result.sensitive {
+		return Redacted
+	}
+	field := result.Field
+	if field != nil && field.IsMap() && result.Element {
+		field = field.MapValue()
+	}
+	if field == nil || field.Message() != nil || (!result.Element && field.IsList()) {
+		data, err := result.MarshalJSON()
+		if err != nil {
+			return fmt.Sprintf("<%s>", err)
+		}
+		return string(data)
+	}
+
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return result.Value.String()
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(result.Value.Bytes())
+	default:
+		return formatExplainValue(field, result.Value, true)
+	}
+}
+
+// MarshalJSON writes the value as protojson would. Sensitive values are
+// Redacted.
+func (result QueryResult) MarshalJSON() ([]byte, error) {
+	if result.sensitive {
+		return json.Marshal(Redacted)
+	}
+	if result.Field == nil {
+		return messageJSON(result.Value.Message())
+	}
+	return queryJSON(result.Field, result.Value, result.Element)
+}
+
+func queryJSON(field protoreflect.FieldDescriptor, value protoreflect.Value, element bool) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	switch {
+	case field.IsList() && !element:
+		list := value.List()
+		buf.WriteString("[")
+		for idx := 0; idx < list.Len(); idx++ {
+			if idx > 0 {
+				buf.WriteString(",")
+			}
+			data, err := queryJSON(field, list.Get(idx), true)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(data)
+		}
+		buf.WriteString("]")
+		return buf.Bytes(), nil
+
+	case field.IsMap() && !element:
+		m := value.Map()
+		keys := []string{}
+		m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			keys = append(keys, key.String())
+			return true
+		})
+		sort.Strings(keys)
+		buf.WriteString("{")
+		for idx, key := range keys {
+			if idx > 0 {
+				buf.WriteString(",")
+			}
+			keyData, err := json.Marshal(key)
+			if err != nil {
+				return nil, err
+			}
+			mapKey, err := parseMapKey(field, key)
+			if err != nil {
+				return nil, err
+			}
+			data, err := queryJSON(field, m.Get(mapKey), true)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(keyData)
+			buf.WriteString(":")
+			buf.Write(data)
+		}
+		buf.WriteString("}")
+		return buf.Bytes(), nil
+	}
+
+	if field.IsMap() {
+		field = field.MapValue()
+	}
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageJSON(value.Message())
+	case protoreflect.EnumKind:
+		return json.Marshal(formatExplainValue(field, value, true))
+	default:
+		return json.Marshal(value.Interface())
+	}
+}
+
+// messageJSON writes a message as compact protojson, which otherwise varies
+// its spacing between builds.
+func messageJSON(msg protoreflect.Message) ([]byte, error) {
+	data, err := protojson.Marshal(redact(msg.Interface()))
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,114 @@
+package bcl
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Redacted replaces the values of sensitive fields in the output of Marshal,
+// Diff and Explain. Fields are sensitive when they are marked with the
+// debug_redact field option.
+const Redacted = "«redacted»"
+
+func isSensitive(field protoreflect.FieldDescriptor) bool {
+	opts, ok := field.Options().(*descriptorpb.FieldOptions)
+	return ok && opts.GetDebugRedact()
+}
+
+// redact returns a copy of msg with the values of sensitive string and bytes
+// fields replaced by Redacted. Sensitive fields of other kinds can't hold the
+// marker, so are cleared.
+func redact(msg proto.Message) proto.Message {
+	if !hasSensitiveFields(msg.ProtoReflect().Descriptor(), map[protoreflect.FullName]bool{}) {
+		return msg
+	}
+	redacted := proto.Clone(msg)
+	redactMessage(redacted.ProtoReflect())
+	return redacted
+}
+
+func hasSensitiveFields(desc protoreflect.MessageDescriptor, visited map[protoreflect.FullName]bool) bool {
+	if visited[desc.FullName()] {
+		return false
+	}
+	visited[desc.FullName()] = true
+
+	fields := desc.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		field := fields.Get(idx)
+		if isSensitive(field) {
+			return true
+		}
+		if field.IsMap() {
+			field = field.MapValue()
+		}
+		if field.Message() != nil && hasSensitiveFields(field.Message(), visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactMessage(msg protoreflect.Message) {
+	fields := msg.Descriptor().Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		field := fields.Get(idx)
+		if !msg.Has(field) {
+			continue
+		}
+		value := msg.Get(field)
+		if isSensitive(field) {
+			redactField(msg, field, value)
+			continue
+		}
+
+		switch {
+		case field.IsList() && field.Message() != nil:
+			list := value.List()
+			for el := 0; el < list.Len(); el++ {
+				redactMessage(list.Get(el).Message())
+			}
+		case field.IsMap() && field.MapValue().Message() != nil:
+			value.Map().Range(func(_ protoreflect.MapKey, entry protoreflect.Value) bool {
+				redactMessage(entry.Message())
+				return true
+			})
+		case !field.IsList() && !field.IsMap() && field.Message() != nil:
+			redactMessage(value.Message())
+		}
+	}
+}
+
+func redactField(msg protoreflect.Message, field protoreflect.FieldDescriptor, value protoreflect.Value) {
+	valueField := field
+	if field.IsMap() {
+		valueField = field.MapValue()
+	}
+	var marker protoreflect.Value
+	switch valueField.Kind() {
+	case protoreflect.StringKind:
+		marker = protoreflect.ValueOfString(Redacted)
+	case protoreflect.BytesKind:
+		marker = protoreflect.ValueOfBytes([]byte(Redacted))
+	default:
+		msg.Clear(field)
+		return
+	}
+
+	switch {
+	case field.IsList():
+		list := value.List()
+		for idx := 0; idx < list.Len(); idx++ {
+			list.Set(idx, marker)
+		}
+	case field.IsMap():
+		entries := value.Map()
+		entries.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+			entries.Set(key, marker)
+			return true
+		})
+	default:
+		msg.Set(field, marker)
+	}
+}
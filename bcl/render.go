@@ -0,0 +1,322 @@
+package bcl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// VarFunction is the name of the function templates call to read a variable,
+// var("name").
+const VarFunction = "var"
+
+// Vars are the values of the variables of a template by name, each the BCL
+// source of a value, e.g. `"eu-west-1"`, `3` or `["a", "b"]`.
+type Vars map[string]string
+
+// ParseVars reads a variables file, a BCL file of assignments. Names in
+// blocks and dotted keys are joined with dots, so `db { host = "x" }` and
+// `db.host = "x"` both set db.host. Values must be literals.
+func ParseVars(filename string, data string) (Vars, error) {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+
+	vars := Vars{}
+	var errs errpos.Errors
+	varErr := func(node parser.SourceNode, format string, args ...interface{}) {
+		pos := node.Position()
+		errs = append(errs, &errpos.Err{
+			Pos:  &pos,
+			Code: errpos.CodeInvalidValue,
+			Err:  fmt.Errorf(format, args...),
+		})
+	}
+
+	ast.Apply(tree, func(c *ast.Cursor) bool {
+		switch st := c.Node().(type) {
+		case *parser.File, *parser.Block, *parser.Comment, *parser.Description:
+		case *parser.Assignment:
+			name := strings.Join(append(blockPath(c.Blocks()), referenceNames(st.Key)...), ".")
+			if _, ok := st.Value.Call(); ok {
+				varErr(st.Value.SourceNode, "variable %s is a function call, variables must be literals", name)
+			} else if _, ok := vars[name]; ok || st.Append {
+				varErr(st.SourceNode, "variable %s is already set", name)
+			} else {
+				vars[name] = errpos.SourceText(data, st.Value.Start, st.Value.End)
+			}
+		default:
+			varErr(st.(parser.Statement).Source(), "variables files only hold assignments and blocks")
+		}
+		return true
+	}, nil)
+
+	if len(errs) > 0 {
+		return nil, errpos.AddSourceFile(errs, filename, data)
+	}
+	return vars, nil
+}
+
+// RenderTemplate replaces each var("name") call in a template with the value
+// of the variable, returning BCL which parses without the variables. Calls
+// are values, so they set attributes and array elements, and need edition 2,
+// '!bcl 2', in the template. Block tags and other strings read a variable
+// with ${var.name}, which is replaced in the string by the variable's value,
+// and must be a scalar. The rest of the file is unchanged, so other function
+// calls and ${} names are resolved when it is parsed.
+//
+// A template which declares its variables in variable blocks, see
+// ParseVariables, may only use those, each value must have the declared type,
+// and variables which are not set take their default. The blocks are removed
+// from the output.
+func RenderTemplate(filename string, data string, vars Vars) (string, error) {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return "", errpos.AddSourceFile(err, filename, data)
+	}
+
+	declared, blocks, errs := declaredVariables(tree, data)
+	var edits []errpos.Edit
+	if len(blocks) > 0 {
+		vars, errs = resolveVars(declared, vars, errs)
+		lines := errpos.SplitLines(data)
+		for _, block := range blocks {
+			end := block.End.Line + 1
+			if block.Close != nil {
+				end = block.Close.Token.Start.Line + 1
+			}
+			// Take the blank line after the block with it
+			if end < len(lines)-1 && strings.TrimSpace(lines[end]) == "" {
+				end++
+			}
+			edits = append(edits, errpos.Edit{
+				Start: errpos.Point{Line: block.Start.Line},
+				End:   errpos.Point{Line: end},
+			})
+		}
+	}
+
+	lookup := func(name string, pos errpos.Position) (string, bool) {
+		value, ok := vars[name]
+		if len(blocks) > 0 && declared.ByName(name) == nil {
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeFunction,
+				Err:  fmt.Errorf("variable %s is not declared", name),
+			})
+			return "", false
+		} else if !ok {
+			if len(blocks) > 0 {
+				// reported at the declaration
+				return "", false
+			}
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeFunction,
+				Err:  fmt.Errorf("variable %s is not set", name),
+			})
+			return "", false
+		}
+		return value, true
+	}
+
+	for _, call := range parser.Calls(tree) {
+		if call.Name.String() != VarFunction {
+			continue
+		}
+		pos := call.Position()
+		if len(call.Args) != 1 {
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeFunction,
+				Err:  fmt.Errorf("%s() takes 1 argument, got %d", VarFunction, len(call.Args)),
+			})
+			continue
+		}
+		name, err := call.Args[0].AsString()
+		if err != nil {
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeFunction,
+				Err:  fmt.Errorf("%s() takes the name of a variable: %w", VarFunction, err),
+			})
+			continue
+		}
+		value, ok := lookup(name, pos)
+		if !ok {
+			continue
+		}
+		edits = append(edits, errpos.Edit{
+			Start:   call.Start,
+			End:     errpos.Point{Line: call.End.Line, Column: call.End.Column + 1},
+			NewText: value,
+		})
+	}
+
+	interpolated, interpolationErrs := interpolateVars(data, lookup)
+	edits = append(edits, interpolated...)
+	errs = append(errs, interpolationErrs...)
+	if len(errs) > 0 {
+		return "", errpos.AddSourceFile(errs, filename, data)
+	}
+
+	return errpos.ApplyEdits(data, edits)
+}
+
+// varInterpolation is ${var.name} in a string, or $${ which is a literal ${
+// as in block names.
+var varInterpolation = regexp.MustCompile(`\$\$\{|\$\{var\.([^}]*)\}`)
+
+// interpolateVars returns the edits which replace each ${var.name} in the
+// strings of the source, block tags included, with the value of the
+// variable.
+func interpolateVars(data string, lookup func(name string, pos errpos.Position) (string, bool)) ([]errpos.Edit, errpos.Errors) {
+	// The source already parsed, so it lexes
+	tokens, _, err := parser.NewLexer(data).AllTokens(false)
+	if err != nil {
+		return nil, errpos.Errors{{Err: err}}
+	}
+	literals := []parser.Token{}
+	for _, tok := range tokens {
+		if tok.Type == parser.STRING {
+			literals = append(literals, tok)
+		}
+	}
+	inString := func(point errpos.Point) (parser.Token, bool) {
+		for _, tok := range literals {
+			if point.Line < tok.Start.Line || point.Line > tok.End.Line {
+				continue
+			}
+			if point.Line == tok.Start.Line && point.Column <= tok.Start.Column {
+				continue
+			}
+			if point.Line == tok.End.Line && point.Column >= tok.End.Column {
+				continue
+			}
+			return tok, true
+		}
+		return parser.Token{}, false
+	}
+
+	var edits []errpos.Edit
+	var errs errpos.Errors
+	for lineIdx, line := range errpos.SplitLines(data) {
+		for _, match := range varInterpolation.FindAllStringSubmatchIndex(line, -1) {
+			if match[2] < 0 {
+				// $${
+				continue
+			}
+			start := errpos.Point{Line: lineIdx, Column: utf8.RuneCountInString(line[:match[0]])}
+			tok, ok := inString(start)
+			if !ok {
+				continue
+			}
+			pos := errpos.Position{Start: start, End: errpos.Point{Line: lineIdx, Column: utf8.RuneCountInString(line[:match[1]])}}
+			value, ok := lookup(line[match[2]:match[3]], pos)
+			if !ok {
+				continue
+			}
+			text, err := interpolatedText(value, tok.Raw)
+			if err != nil {
+				errs = append(errs, &errpos.Err{
+					Pos:  &pos,
+					Code: errpos.CodeFunction,
+					Err:  fmt.Errorf("${var.%s}: %w", line[match[2]:match[3]], err),
+				})
+				continue
+			}
+			edits = append(edits, errpos.Edit{
+				Start:   pos.Start,
+				End:     pos.End,
+				NewText: text,
+			})
+		}
+	}
+	return edits, errs
+}
+
+// interpolatedText is the value of a variable as it is written inside a
+// string, escaped for a quoted string and as it is for a raw one.
+func interpolatedText(value string, raw bool) (string, error) {
+	parsed, err := parseValue(value)
+	if err != nil {
+		return "", err
+	}
+	if parsed.IsArray() {
+		return "", fmt.Errorf("the variable is an array, only scalars are interpolated")
+	}
+	str, err := parsed.AsString()
+	if err != nil {
+		// numbers and bools are written as they are
+		str = strings.TrimSpace(value)
+	}
+	if raw {
+		if strings.Contains(str, "`") {
+			return "", fmt.Errorf("the value has a backtick, which a raw string can't hold")
+		}
+		return str, nil
+	}
+	quoted := strconv.Quote(str)
+	return quoted[1 : len(quoted)-1], nil
+}
+
+// resolveVars fills the defaults of the declared variables and checks the
+// type of each value, with errors at the declarations.
+func resolveVars(declared Variables, vars Vars, errs errpos.Errors) (Vars, errpos.Errors) {
+	resolved := Vars{}
+	for _, v := range declared {
+		pos := v.Pos
+		value, ok := vars[v.Name]
+		if !ok {
+			if v.Default == "" {
+				errs = append(errs, &errpos.Err{
+					Pos:  &pos,
+					Code: errpos.CodeInvalidValue,
+					Err:  fmt.Errorf("variable %s is not set and has no default", v.Name),
+				})
+				continue
+			}
+			value = v.Default
+		}
+		parsed, err := parseValue(value)
+		if err == nil {
+			err = v.check(*parsed)
+		} else if v.Sensitive {
+			err = fmt.Errorf("variable %s has type %s, the value does not parse", v.Name, v.Type)
+		}
+		if err != nil {
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeTypeMismatch,
+				Err:  err,
+			})
+			continue
+		}
+		resolved[v.Name] = value
+	}
+	return resolved, errs
+}
+
+// blockPath is the types of the blocks, joined into one path.
+func blockPath(blocks []*parser.Block) []string {
+	path := []string{}
+	for _, block := range blocks {
+		path = append(path, referenceNames(block.Type)...)
+	}
+	return path
+}
+
+func referenceNames(ref parser.Reference) []string {
+	names := make([]string, len(ref.Idents))
+	for idx, ident := range ref.Idents {
+		names[idx] = ident.Value
+	}
+	return names
+}
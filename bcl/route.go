@@ -0,0 +1,114 @@
+package bcl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaRoute is a schema registered with RegisterSchema, with the policies,
+// derived fields, identities, names and prose fields of its block specs.
+type schemaRoute struct {
+	set     *schema.SchemaSet
+	msgType protoreflect.MessageType
+
+	policies *policySet
+	derived  *derivedSet
+	identity map[string][]string
+	names    map[string]*schema.Tag
+	prose    map[string][]string
+}
+
+// RegisterSchema adds a schema for files which name it in a header, e.g.
+// '!schema "pentops.registry/foo.v1.File"', parsed by ParseRouted into a new
+// message of msgType. Dynamic messages use dynamicpb.NewMessageType.
+func (p *Parser) RegisterSchema(name string, schemaSpec *bcl_j5pb.Schema, msgType protoreflect.MessageType) error {
+	ss, err := schema.NewSchemaSet(schemaSpec)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	if err := ss.CheckNormalizers(&schema.Normalizers{Funcs: p.normalizers}); err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	policies, err := newPolicySet(ss)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	derived, err := newDerivedSet(ss)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	for schemaName, hooks := range p.derived.hooks {
+		for _, hook := range hooks {
+			derived.register(schemaName, hook.field, hook.fn)
+		}
+	}
+
+	if p.routes == nil {
+		p.routes = map[string]schemaRoute{}
+	}
+	p.routes[name] = schemaRoute{
+		set:      ss,
+		msgType:  msgType,
+		policies: policies,
+		derived:  derived,
+		identity: ss.Identities(),
+		names:    ss.Names(),
+		prose:    ss.Prose(),
+	}
+	return nil
+}
+
+// routed returns a copy of the parser which parses files of the route's
+// schema.
+func (p *Parser) routed(route schemaRoute) *Parser {
+	routed := *p
+	routed.schema = route.set
+	routed.policies = route.policies
+	routed.derived = route.derived
+	routed.identity = route.identity
+	routed.names = route.names
+	routed.prose = route.prose
+	return &routed
+}
+
+// ParseRouted parses a file into a new message of the schema named by its
+// '!schema' header, which must have been registered with RegisterSchema. The
+// header is ignored by Parse, which always uses the parser's own schema.
+func (p *Parser) ParseRouted(filename string, data string) (protoreflect.Message, *ParseResult, error) {
+	tree, err := p.parseTree(context.Background(), filename, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	directive, _ := tree.HeaderValue("schema")
+	route, err := p.route(directive)
+	if err != nil {
+		return nil, nil, fileErr(err, filename, data, tree)
+	}
+
+	msg := route.msgType.New()
+	result, err := p.routed(route).parseAST(context.Background(), filename, tree, msg)
+	if err != nil {
+		err = fileErr(err, filename, data, tree)
+	}
+	return msg, result, err
+}
+
+func (p *Parser) route(directive *parser.HeaderDirective) (schemaRoute, error) {
+	if directive == nil {
+		err := errpos.WithCode(fmt.Errorf("file has no !schema header"), errpos.CodeUnknownSchema)
+		return schemaRoute{}, errpos.AddPosition(err, errpos.Position{})
+	}
+	route, ok := p.routes[directive.Value]
+	if !ok {
+		err := errpos.WithCode(fmt.Errorf("unknown schema %q", directive.Value), errpos.CodeUnknownSchema)
+		return schemaRoute{}, errpos.AddPosition(err, directive.Position())
+	}
+	return route, nil
+}
@@ -0,0 +1,67 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ScopeChild describes a name which statements in a Scope can use, with its
+// type and doc from the schema.
+type ScopeChild = schema.ChildInfo
+
+// Scope is a read-only view of what the schema allows at a path in a file,
+// for tools which complete or document files.
+type Scope struct {
+	scope *schema.Scope
+}
+
+// ScopeAt returns the scope inside the blocks named by path, in a file parsed
+// into a message of type root. An empty path is the root of the file. A name
+// which isn't a block of its parent is an errpos.ErrUnknownBlock.
+func (p *Parser) ScopeAt(root protoreflect.MessageDescriptor, path ...string) (*Scope, error) {
+	_, obj, err := p.objectFor(dynamicpb.NewMessage(root))
+	if err != nil {
+		return nil, err
+	}
+	scope, err := schema.NewRootSchemaWalker(p.schema, obj, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range path {
+		child, walkErr := scope.ChildBlock(name, errpos.Position{})
+		if walkErr != nil {
+			return nil, &errpos.Err{Err: walkErr}
+		}
+		scope = child
+	}
+	return &Scope{scope: scope}, nil
+}
+
+// SchemaName is the schema of the innermost block of the scope.
+func (s *Scope) SchemaName() string {
+	return s.scope.SchemaName()
+}
+
+// SchemaNames are the schemas of every block in the scope, outermost first.
+func (s *Scope) SchemaNames() []string {
+	return s.scope.SchemaNames()
+}
+
+// ListBlocks returns the names which open a block in the scope, sorted.
+func (s *Scope) ListBlocks() []string {
+	return s.scope.ListBlocks()
+}
+
+// ListAttributes returns the names which can be assigned a value in the scope,
+// sorted.
+func (s *Scope) ListAttributes() []string {
+	return s.scope.ListAttributes()
+}
+
+// Children describes every name in the scope, sorted by name.
+func (s *Scope) Children() []ScopeChild {
+	return s.scope.Children()
+}
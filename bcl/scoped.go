@@ -0,0 +1,61 @@
+package bcl
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+)
+
+// CheckScopedNames checks that the qualified names of blocks with a scoped
+// name tag are unique across all files. Scoped names share one namespace, so
+// a package and a message with the same qualified name also collide. Root
+// blocks of the same schema may repeat a name, reopening it as a namespace.
+// Each repeated definition is returned as an errpos.Errors entry.
+func CheckScopedNames(spec *bcl_j5pb.Schema, files []SourceFile) error {
+	sb := &symbolBuilder{
+		table: &SymbolTable{
+			byName: map[symbolKey][]*Symbol{},
+		},
+		targets: map[string]string{},
+	}
+	for _, block := range spec.Blocks {
+		if block.Name == nil || block.Name.ScopeSeparator == nil {
+			continue
+		}
+		sb.targets[block.SchemaName] = block.Name.FieldName
+	}
+	if len(sb.targets) == 0 {
+		return nil
+	}
+
+	for _, file := range files {
+		if err := sb.walk(file.Filename, file.Message, file.SourceLocation, nil); err != nil {
+			return fmt.Errorf("%s: %w", file.Filename, err)
+		}
+	}
+
+	var errs errpos.Errors
+	first := map[string]*Symbol{}
+	for _, symbol := range sb.table.Symbols {
+		existing, ok := first[symbol.Name]
+		if !ok {
+			first[symbol.Name] = symbol
+			continue
+		}
+		if existing.Parent == nil && symbol.Parent == nil && existing.Schema == symbol.Schema {
+			// Root blocks reopen the namespace, as a package does across files.
+			continue
+		}
+		pos := symbol.Position
+		errs = append(errs, &errpos.Err{
+			Pos:  &pos,
+			Code: errpos.CodeDuplicateName,
+			Err:  fmt.Errorf("%q is already defined at %s", symbol.Name, existing.Position.String()),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
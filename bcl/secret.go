@@ -0,0 +1,54 @@
+package bcl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretResolver resolves the references of secret() calls, such as
+// "vault://path#key", to their values at parse time, so credentials are never
+// written in files. Errors must not include the value.
+//
+// Secret values are kept out of parse errors and logs, including those of
+// calls which take a secret as an argument.
+type SecretResolver interface {
+	ResolveSecret(ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver.
+type SecretResolverFunc func(ref string) (string, error)
+
+func (fn SecretResolverFunc) ResolveSecret(ref string) (string, error) {
+	return fn(ref)
+}
+
+// SecretSchemes resolves each reference with the resolver for its scheme,
+// e.g. "vault" for "vault://path#key".
+type SecretSchemes map[string]SecretResolver
+
+func (ss SecretSchemes) ResolveSecret(ref string) (string, error) {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok {
+		return "", fmt.Errorf("secret reference %q has no scheme", ref)
+	}
+	resolver, ok := ss[scheme]
+	if !ok {
+		return "", fmt.Errorf("no secret resolver for scheme %q", scheme)
+	}
+	return resolver.ResolveSecret(ref)
+}
+
+// secretFunction returns secret(ref), when the parser has a SecretResolver.
+func (p *Parser) secretFunction(name string) (Function, bool) {
+	if name != "secret" || p.Secrets == nil {
+		return Function{}, false
+	}
+	return Function{
+		Params:    []ValueType{StringType},
+		Result:    StringType,
+		Sensitive: true,
+		Call: func(args []interface{}) (interface{}, error) {
+			return p.Secrets.ResolveSecret(args[0].(string))
+		},
+	}, true
+}
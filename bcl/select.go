@@ -0,0 +1,76 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// NodeMatch is a statement of a file selected by SelectNodes.
+type NodeMatch struct {
+	// Kind is block, attr or description.
+	Kind string
+
+	// Name is the dotted type of a block or key of an attribute, empty for
+	// descriptions.
+	Name string
+
+	// Blocks are the types of the blocks around the statement, outermost
+	// first.
+	Blocks []string
+
+	Pos errpos.Position
+}
+
+// SelectNodes returns the statements of a file which a selector matches, by
+// their syntax rather than the schema:
+//
+//	block[type=foo] > attr[name=tag.*]
+//
+// Steps are a kind, block, attr, description or *, with [property=glob]
+// filters, and are joined by > for a statement directly in a block or a
+// space for one at any depth. Blocks have the properties type, tag and
+// description, attributes name and value.
+func SelectNodes(filename string, data string, selector string) ([]NodeMatch, error) {
+	sel, err := ast.ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+
+	matches := []NodeMatch{}
+	ast.Apply(tree, func(c *ast.Cursor) bool {
+		if !sel.Match(c.Node(), c.Blocks()) {
+			return true
+		}
+		match := NodeMatch{
+			Blocks: blockTypes(c.Blocks()),
+			Pos:    c.Node().(parser.Statement).Source().Position(),
+		}
+		match.Pos.Filename = &filename
+		switch st := c.Node().(type) {
+		case *parser.Block:
+			match.Kind = "block"
+			match.Name = st.Type.String()
+		case *parser.Assignment:
+			match.Kind = "attr"
+			match.Name = st.Key.String()
+		case *parser.Description:
+			match.Kind = "description"
+		}
+		matches = append(matches, match)
+		return true
+	}, nil)
+	return matches, nil
+}
+
+func blockTypes(blocks []*parser.Block) []string {
+	types := make([]string, len(blocks))
+	for idx, block := range blocks {
+		types[idx] = block.Type.String()
+	}
+	return types
+}
@@ -0,0 +1,170 @@
+package bcl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// SourceMapVersion is the version of the source map format written by
+// SourceMap.
+const SourceMapVersion = 1
+
+// SourceMap links the field paths of a parsed message to the positions in the
+// BCL files which set them, for generators which take the message as input to
+// report their own errors against the BCL source. It is written as JSON:
+//
+//	{
+//	  "version": 1,
+//	  "file": "service.bcl",
+//	  "mappings": [
+//	    {"path": "elements.0.name", "file": "service.bcl", "startLine": 3, "startColumn": 3, "endLine": 3, "endColumn": 14, "origin": "explicit"}
+//	  ]
+//	}
+//
+// Paths are dotted as for Explain, naming fields by their JSON name, list
+// elements by index and map entries by key. Lines and columns are 1-based, as
+// in SARIF, with the end column inclusive. Mappings are sorted by path.
+type SourceMap struct {
+	Version  int             `json:"version"`
+	File     string          `json:"file,omitempty"`
+	Mappings []SourceMapping `json:"mappings"`
+}
+
+// SourceMapping is the source of the value at one path.
+type SourceMapping struct {
+	Path        string `json:"path"`
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"startLine"`
+	StartColumn int    `json:"startColumn"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+
+	// Origin is explicit, schema, synthesized, derived or inherited, as the
+	// Origin of the SourceLocation.
+	Origin string `json:"origin,omitempty"`
+}
+
+// NewSourceMap builds the source map of a message parsed from filename, from
+// the source location tree returned with it.
+func NewSourceMap(filename string, locs *bcl_j5pb.SourceLocation) *SourceMap {
+	return newSourceMap(filename, locs, nil)
+}
+
+// SourceMap builds the source map of the parsed message. Positions after a
+// '#line' directive are mapped to the file and line it names, so a file
+// generated from a template maps to the template.
+func (r *ParseResult) SourceMap() *SourceMap {
+	return newSourceMap(r.filename, r.SourceLocation, r.lineMap)
+}
+
+func newSourceMap(filename string, locs *bcl_j5pb.SourceLocation, lineMap parser.LineMap) *SourceMap {
+	sm := &SourceMap{
+		Version:  SourceMapVersion,
+		File:     filename,
+		Mappings: []SourceMapping{},
+	}
+	if locs == nil {
+		return sm
+	}
+
+	var walk func(path string, loc *bcl_j5pb.SourceLocation)
+	walk = func(path string, loc *bcl_j5pb.SourceLocation) {
+		if path != "" {
+			sm.Mappings = append(sm.Mappings, newSourceMapping(path, filename, loc, lineMap))
+		}
+		for key, child := range loc.Children {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			walk(childPath, child)
+		}
+	}
+	walk("", locs)
+
+	sort.Slice(sm.Mappings, func(i, j int) bool {
+		return sm.Mappings[i].Path < sm.Mappings[j].Path
+	})
+	return sm
+}
+
+func newSourceMapping(path string, filename string, loc *bcl_j5pb.SourceLocation, lineMap parser.LineMap) SourceMapping {
+	pos := errpos.Position{
+		Filename: &filename,
+		Start:    errpos.Point{Line: int(loc.StartLine), Column: int(loc.StartColumn)},
+		End:      errpos.Point{Line: int(loc.EndLine), Column: int(loc.EndColumn)},
+	}
+	if mapped, ok := lineMap.Map(pos); ok {
+		pos = mapped
+	}
+
+	mapping := SourceMapping{
+		Path:        path,
+		File:        *pos.Filename,
+		StartLine:   pos.Start.Line + 1,
+		StartColumn: pos.Start.Column + 1,
+		EndLine:     pos.End.Line + 1,
+		EndColumn:   pos.End.Column + 1,
+	}
+	switch loc.Origin {
+	case bcl_j5pb.Origin_ORIGIN_EXPLICIT:
+		mapping.Origin = "explicit"
+	case bcl_j5pb.Origin_ORIGIN_SCHEMA:
+		mapping.Origin = "schema"
+	case bcl_j5pb.Origin_ORIGIN_SYNTHESIZED:
+		mapping.Origin = "synthesized"
+	case bcl_j5pb.Origin_ORIGIN_DERIVED:
+		mapping.Origin = "derived"
+	case bcl_j5pb.Origin_ORIGIN_INHERITED:
+		mapping.Origin = "inherited"
+	}
+	return mapping
+}
+
+// Lookup returns the position of the value at path, or of the nearest parent
+// in the map when the value itself isn't, e.g. a field set from a schema
+// default.
+func (sm *SourceMap) Lookup(path string) (errpos.Position, bool) {
+	for {
+		idx := sort.Search(len(sm.Mappings), func(i int) bool {
+			return sm.Mappings[i].Path >= path
+		})
+		if idx < len(sm.Mappings) && sm.Mappings[idx].Path == path {
+			mapping := sm.Mappings[idx]
+			file := mapping.File
+			return errpos.Position{
+				Filename: &file,
+				Start:    errpos.Point{Line: mapping.StartLine - 1, Column: mapping.StartColumn - 1},
+				End:      errpos.Point{Line: mapping.EndLine - 1, Column: mapping.EndColumn - 1},
+			}, true
+		}
+		dot := strings.LastIndex(path, ".")
+		if dot < 0 {
+			return errpos.Position{}, false
+		}
+		path = path[:dot]
+	}
+}
+
+// MarshalSourceMap writes the source map as indented JSON.
+func MarshalSourceMap(sm *SourceMap) ([]byte, error) {
+	return json.MarshalIndent(sm, "", "  ")
+}
+
+// UnmarshalSourceMap reads a source map written by MarshalSourceMap.
+func UnmarshalSourceMap(data []byte) (*SourceMap, error) {
+	sm := &SourceMap{}
+	if err := json.Unmarshal(data, sm); err != nil {
+		return nil, err
+	}
+	if sm.Version != SourceMapVersion {
+		return nil, fmt.Errorf("unsupported source map version %d", sm.Version)
+	}
+	return sm, nil
+}
@@ -0,0 +1,431 @@
+package bcl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Dictionary is the words CheckSpelling accepts. Words are passed as written,
+// so the dictionary decides how case and apostrophes match.
+type Dictionary interface {
+	Known(word string) bool
+}
+
+// Suggester is implemented by a Dictionary which can offer corrections for
+// an unknown word, the most likely first.
+type Suggester interface {
+	Suggest(word string) []string
+}
+
+// WordList is a Dictionary of a fixed list of words, matched ignoring case
+// and a possessive 's, which suggests the known words a small edit distance
+// from an unknown one.
+type WordList struct {
+	words map[string]string // lower case to as given
+}
+
+var _ Suggester = (*WordList)(nil)
+
+// NewWordList returns a dictionary of the words.
+func NewWordList(words ...string) *WordList {
+	wl := &WordList{words: map[string]string{}}
+	wl.Add(words...)
+	return wl
+}
+
+// Add adds words to the list.
+func (wl *WordList) Add(words ...string) {
+	for _, word := range words {
+		wl.words[strings.ToLower(word)] = word
+	}
+}
+
+func (wl *WordList) Known(word string) bool {
+	lower := strings.ToLower(word)
+	if _, ok := wl.words[lower]; ok {
+		return true
+	}
+	for _, suffix := range []string{"'s", "’s"} {
+		if stem, ok := strings.CutSuffix(lower, suffix); ok {
+			_, known := wl.words[stem]
+			return known
+		}
+	}
+	return false
+}
+
+// maxSuggestions is the most corrections offered for one word.
+const maxSuggestions = 3
+
+// Suggest returns up to three known words within a third of the length of
+// word, at least one, by distance then alphabetically.
+func (wl *WordList) Suggest(word string) []string {
+	lower := strings.ToLower(word)
+	maxDistance := max(len([]rune(lower))/3, 1)
+
+	type suggestion struct {
+		word     string
+		distance int
+	}
+	found := []suggestion{}
+	for known, given := range wl.words {
+		if distance := walker.EditDistance(lower, known); distance <= maxDistance {
+			found = append(found, suggestion{word: given, distance: distance})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].word < found[j].word
+	})
+
+	out := []string{}
+	for idx := 0; idx < len(found) && idx < maxSuggestions; idx++ {
+		out = append(out, found[idx].word)
+	}
+	return out
+}
+
+// CheckSpelling reports the words of the `|` descriptions of a parsed file,
+// and of the string fields its block specs mark as prose, which are not in
+// the dictionary. The file must have its Source set, as each finding is the
+// position of the word.
+//
+// Words with digits, underscores or capitals after the first letter, code in
+// backticks, URLs and paths are skipped, as they are rarely prose. Strings
+// written over more than one line are not checked.
+//
+// The findings are warnings, errpos.CodeSpelling, which are not filtered by
+// bcl:ignore comments, so that the caller can mark the ones they match. When
+// the dictionary is a Suggester, each finding has a fix per suggestion, which
+// is not Safe as the word may be right.
+func (p *Parser) CheckSpelling(file SourceFile, dict Dictionary) (errpos.Errors, error) {
+	if file.Source == "" {
+		return nil, fmt.Errorf("%s: checking spelling needs the source of the file", file.Filename)
+	}
+	tree, err := file.tree()
+	if err != nil {
+		return nil, err
+	}
+
+	sc := &spellChecker{
+		prose:    p.prose,
+		dict:     dict,
+		filename: file.Filename,
+		lines:    errpos.SplitLines(file.Source),
+		strings:  map[errpos.Point][]parser.Token{},
+	}
+	sc.body(tree.Body)
+
+	if len(sc.prose) > 0 {
+		err := visitMessages(file.Message, file.SourceLocation, func(node messageNode) error {
+			return sc.checkMessage(node.msg, node.loc)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Filename, err)
+		}
+	}
+
+	// Descriptions are found first, prose fields second
+	sort.SliceStable(sc.errs, func(i, j int) bool {
+		a, b := sc.errs[i].Pos.Start, sc.errs[j].Pos.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return sc.errs, nil
+}
+
+type spellChecker struct {
+	prose    map[string][]string // schema name to prose fields
+	dict     Dictionary
+	filename string
+	lines    []string
+
+	// strings are the literals of the string values of the file, by the
+	// start of the value, which is where the source location of a field is.
+	strings map[errpos.Point][]parser.Token
+
+	errs errpos.Errors
+}
+
+// body checks the descriptions and collects the string values of the
+// statements.
+func (sc *spellChecker) body(body parser.Body) {
+	for _, stmt := range body.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Description:
+			sc.description(stmt)
+		case *parser.Assignment:
+			sc.value(stmt.Value)
+		case *parser.Block:
+			for _, tag := range stmt.Tags {
+				if tag.Value != nil {
+					sc.value(*tag.Value)
+				}
+			}
+			if stmt.Description != nil {
+				sc.description(stmt.Description)
+			}
+			sc.body(stmt.Body)
+		}
+	}
+}
+
+func (sc *spellChecker) value(value parser.Value) {
+	if elements, ok := value.AsArray(); ok {
+		for _, element := range elements {
+			sc.value(element.(parser.Value))
+		}
+		return
+	}
+	if tokens := value.StringTokens(); len(tokens) > 0 {
+		sc.strings[value.Start] = tokens
+	}
+}
+
+// description checks each line, which runs from the first non-space after
+// the '|' to the end of the line.
+func (sc *spellChecker) description(desc *parser.Description) {
+	for _, tok := range desc.Tokens {
+		line, ok := sc.line(tok.Start.Line)
+		if !ok || !strings.HasSuffix(line, tok.Lit) {
+			continue
+		}
+		column := len([]rune(line)) - len([]rune(tok.Lit))
+		sc.text([]rune(tok.Lit), errpos.Point{Line: tok.Start.Line, Column: column})
+	}
+}
+
+// literal checks a string literal written on one line, reading it from the
+// source so that the columns of words after escapes are right.
+func (sc *spellChecker) literal(tok parser.Token) {
+	if tok.Start.Line != tok.End.Line {
+		return
+	}
+	line, ok := sc.line(tok.Start.Line)
+	if !ok {
+		return
+	}
+	runes := []rune(line)
+	if tok.Start.Column < 0 || tok.End.Column >= len(runes) || tok.End.Column-tok.Start.Column < 1 {
+		return
+	}
+	quote := runes[tok.Start.Column]
+	if quote != '"' && quote != '`' || runes[tok.End.Column] != quote {
+		return
+	}
+	inner := append([]rune{}, runes[tok.Start.Column+1:tok.End.Column]...)
+	if quote == '"' {
+		// Blank out escapes, keeping the columns
+		for idx := 0; idx < len(inner); idx++ {
+			if inner[idx] == '\\' {
+				inner[idx] = ' '
+				if idx+1 < len(inner) {
+					inner[idx+1] = ' '
+					idx++
+				}
+			}
+		}
+	}
+	sc.text(inner, errpos.Point{Line: tok.Start.Line, Column: tok.Start.Column + 1})
+}
+
+func (sc *spellChecker) line(idx int) (string, bool) {
+	if idx < 0 || idx >= len(sc.lines) {
+		return "", false
+	}
+	return strings.TrimRight(sc.lines[idx], "\r\n"), true
+}
+
+// text checks the words of text, which starts at the point in the source.
+func (sc *spellChecker) text(text []rune, start errpos.Point) {
+	maskCode(text)
+
+	// Each run of non-space is checked as a whole or skipped, then split into
+	// words at punctuation.
+	for idx := 0; idx < len(text); {
+		if unicode.IsSpace(text[idx]) {
+			idx++
+			continue
+		}
+		end := idx
+		for end < len(text) && !unicode.IsSpace(text[end]) {
+			end++
+		}
+		if !isTechnical(string(text[idx:end])) {
+			for _, word := range splitWords(text[idx:end]) {
+				sc.word(string(text[idx+word[0]:idx+word[1]]), errpos.Point{
+					Line:   start.Line,
+					Column: start.Column + idx + word[0],
+				})
+			}
+		}
+		idx = end
+	}
+}
+
+func (sc *spellChecker) word(word string, start errpos.Point) {
+	if !isProse(word) || sc.dict.Known(word) {
+		return
+	}
+	length := len([]rune(word))
+	pos := errpos.Position{
+		Filename: &sc.filename,
+		Start:    start,
+		End:      errpos.Point{Line: start.Line, Column: start.Column + length - 1},
+	}
+	err := &errpos.Err{
+		Pos:      &pos,
+		Code:     errpos.CodeSpelling,
+		Severity: errpos.SeverityWarning,
+		Err:      fmt.Errorf("unknown word %q", word),
+	}
+	if suggester, ok := sc.dict.(Suggester); ok {
+		for _, suggestion := range suggester.Suggest(word) {
+			suggestion = matchCase(word, suggestion)
+			err.Fixes = append(err.Fixes, errpos.Fix{
+				Title: fmt.Sprintf("Replace %s with %s", word, suggestion),
+				Edits: []errpos.Edit{{
+					Start:   start,
+					End:     errpos.Point{Line: start.Line, Column: start.Column + length},
+					NewText: suggestion,
+				}},
+			})
+		}
+	}
+	sc.errs = append(sc.errs, err)
+}
+
+// maskCode blanks out `code` spans, and the rest of the text after an
+// unclosed backtick.
+func maskCode(text []rune) {
+	inCode := false
+	for idx, r := range text {
+		if r == '`' {
+			inCode = !inCode
+			text[idx] = ' '
+		} else if inCode {
+			text[idx] = ' '
+		}
+	}
+}
+
+// isTechnical is true for runs of text which are URLs, paths, emails,
+// qualified names, interpolations or code rather than words.
+func isTechnical(run string) bool {
+	if strings.ContainsAny(run, "/\\@_${}<>=#") {
+		return true
+	}
+	// A dot between letters, e.g. example.com or foo.bar
+	runes := []rune(run)
+	for idx := 1; idx < len(runes)-1; idx++ {
+		if runes[idx] == '.' && unicode.IsLetter(runes[idx-1]) && unicode.IsLetter(runes[idx+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitWords returns the start and end of each run of letters, digits and
+// apostrophes between letters, in a run of non-space.
+func splitWords(run []rune) [][2]int {
+	words := [][2]int{}
+	start := -1
+	for idx, r := range run {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		if !inWord && (r == '\'' || r == '’') && start >= 0 && idx+1 < len(run) && unicode.IsLetter(run[idx+1]) {
+			inWord = true
+		}
+		switch {
+		case inWord && start < 0:
+			start = idx
+		case !inWord && start >= 0:
+			words = append(words, [2]int{start, idx})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, [2]int{start, len(run)})
+	}
+	return words
+}
+
+// isProse is false for single letters, and words with digits or capitals
+// after the first letter, which are acronyms or identifiers.
+func isProse(word string) bool {
+	runes := []rune(word)
+	if len(runes) < 2 {
+		return false
+	}
+	for idx, r := range runes {
+		if unicode.IsDigit(r) || idx > 0 && unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCase capitalises a lower case suggestion for a capitalised word.
+func matchCase(word, suggestion string) string {
+	first := []rune(word)[0]
+	runes := []rune(suggestion)
+	if unicode.IsUpper(first) && unicode.IsLower(runes[0]) {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+// checkMessage checks the prose fields of the message.
+func (sc *spellChecker) checkMessage(msg protoreflect.Message, loc *bcl_j5pb.SourceLocation) error {
+	desc := msg.Descriptor()
+	schemaName := j5SchemaName(desc)
+	for _, field := range sc.prose[schemaName] {
+		fd := desc.Fields().ByJSONName(field)
+		if fd == nil {
+			fd = desc.Fields().ByName(protoreflect.Name(field))
+		}
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+			return fmt.Errorf("prose field %q of %s is not a string", field, schemaName)
+		}
+		fieldLoc := loc.GetChildren()[fd.JSONName()]
+		if !fd.IsList() {
+			sc.located(fieldLoc)
+			continue
+		}
+		for idx := 0; idx < msg.Get(fd).List().Len(); idx++ {
+			sc.located(fieldLoc.GetChildren()[strconv.Itoa(idx)])
+		}
+	}
+
+	return nil
+}
+
+// located checks the string literals of the value set at the location, if
+// it was set by one.
+func (sc *spellChecker) located(loc *bcl_j5pb.SourceLocation) {
+	if loc == nil {
+		return
+	}
+	start := errpos.Point{Line: int(loc.StartLine), Column: int(loc.StartColumn)}
+	tokens, ok := sc.strings[start]
+	if !ok {
+		return
+	}
+	// Each literal is checked once, however many fields it set
+	delete(sc.strings, start)
+	for _, tok := range tokens {
+		sc.literal(tok)
+	}
+}
@@ -0,0 +1,155 @@
+package bcl
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// ProjectStats summarizes the syntax of the files of a project, for reports
+// and dashboards. It counts statements as written, without a schema.
+type ProjectStats struct {
+	Files      []FileStats    `json:"files"`      // Longest first, see Sort
+	Blocks     map[string]int `json:"blocks"`     // Blocks by dotted type
+	Attributes map[string]int `json:"attributes"` // Assignments by key
+
+	// MaxDepth is the deepest nesting of blocks, 1 for a block at the root of
+	// a file, and MaxDepthAt the first block at that depth.
+	MaxDepth   int    `json:"maxDepth"`
+	MaxDepthAt string `json:"maxDepthAt,omitempty"`
+}
+
+// FileStats is the size of one file.
+type FileStats struct {
+	Filename   string `json:"filename"`
+	Lines      int    `json:"lines"`
+	Statements int    `json:"statements"`
+	Blocks     int    `json:"blocks"`
+	MaxDepth   int    `json:"maxDepth"`
+}
+
+// NewProjectStats returns empty stats, for AddFile.
+func NewProjectStats() *ProjectStats {
+	return &ProjectStats{
+		Files:      []FileStats{},
+		Blocks:     map[string]int{},
+		Attributes: map[string]int{},
+	}
+}
+
+// CollectStats reads the stats of every project file in fsys, with the
+// project config read from bcl.yaml at the root when there is one.
+func CollectStats(fsys fs.FS) (*ProjectStats, error) {
+	config, err := project.LoadConfig(fsys)
+	if err != nil {
+		return nil, err
+	}
+	files, err := config.Files(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := NewProjectStats()
+	diags := &errpos.Diagnostics{}
+	for _, pathname := range files {
+		data, err := fs.ReadFile(fsys, pathname)
+		if err != nil {
+			return nil, err
+		}
+		if err := stats.AddFile(pathname, string(data)); err != nil {
+			diags.Add(pathname, err)
+		}
+	}
+	if err := diags.Err(); err != nil {
+		return nil, err
+	}
+	stats.Sort()
+	return stats, nil
+}
+
+// AddFile parses the file and adds its statements to the stats.
+func (ps *ProjectStats) AddFile(filename string, data string) error {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return errpos.AddSourceFile(err, filename, data)
+	}
+
+	file := FileStats{
+		Filename: filename,
+		Lines:    strings.Count(strings.TrimSuffix(data, "\n"), "\n") + 1,
+	}
+	if data == "" {
+		file.Lines = 0
+	}
+	ast.Apply(tree, func(c *ast.Cursor) bool {
+		switch st := c.Node().(type) {
+		case *parser.File:
+			return true
+		case *parser.Block:
+			file.Blocks++
+			ps.Blocks[st.Type.String()]++
+			depth := len(c.Blocks()) + 1
+			file.MaxDepth = max(file.MaxDepth, depth)
+			if depth > ps.MaxDepth {
+				pos := st.Position()
+				pos.Filename = &filename
+				ps.MaxDepth = depth
+				ps.MaxDepthAt = pos.String()
+			}
+		case *parser.Assignment:
+			ps.Attributes[st.Key.String()]++
+		case *parser.Comment:
+			return true
+		}
+		file.Statements++
+		return true
+	}, nil)
+	ps.Files = append(ps.Files, file)
+	return nil
+}
+
+// Sort orders the files longest first, then by name.
+func (ps *ProjectStats) Sort() {
+	sort.SliceStable(ps.Files, func(i, j int) bool {
+		if ps.Files[i].Lines != ps.Files[j].Lines {
+			return ps.Files[i].Lines > ps.Files[j].Lines
+		}
+		return ps.Files[i].Filename < ps.Files[j].Filename
+	})
+}
+
+// Lines is the total of the lines of the files.
+func (ps *ProjectStats) Lines() int {
+	total := 0
+	for _, file := range ps.Files {
+		total += file.Lines
+	}
+	return total
+}
+
+// StatCount is a name and its count, see Ranked.
+type StatCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Ranked returns the counts most frequent first, then by name, for the Blocks
+// or Attributes of the stats.
+func Ranked(counts map[string]int) []StatCount {
+	ranked := make([]StatCount, 0, len(counts))
+	for name, count := range counts {
+		ranked = append(ranked, StatCount{Name: name, Count: count})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	return ranked
+}
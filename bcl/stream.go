@@ -0,0 +1,248 @@
+package bcl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DefaultMaxRecordSize is the MaxRecordSize of a new Decoder.
+const DefaultMaxRecordSize = 1 << 20
+
+// Decoder reads a stream of top-level statements, usually blocks, and parses
+// each into its own message as soon as it is complete, so the stream never
+// needs to fit in memory. A statement is complete at the end of the line
+// which closes its last brace or bracket. A statement left open, by a brace
+// which is never closed, ends at the next '---' separator line, and fails to
+// parse, so decoding picks up again after it.
+type Decoder struct {
+	parser   *Parser
+	reader   *bufio.Reader
+	filename string
+	msgType  protoreflect.MessageType
+
+	// MaxRecordSize caps the bytes of a record. A longer record is an
+	// error, and the stream is skipped to the next separator line. Zero is
+	// no limit.
+	MaxRecordSize int
+
+	// header is the '!key "value"' lines at the start of the stream, parsed
+	// with each record.
+	header      strings.Builder
+	headerLines int
+
+	// line is the 0-based line of the stream read next.
+	line    int
+	started bool
+	done    bool
+}
+
+// Record is a statement of a stream, parsed into its own message.
+type Record struct {
+	Message protoreflect.Message
+	Result  *ParseResult
+
+	// Offset places the record in the stream. Errors are already placed,
+	// and the lines of the source locations in the result add Offset.Line.
+	Offset FragmentOffset
+}
+
+// NewDecoder returns a decoder which parses each statement read from r into a
+// new message of msgType with the parser's schema, naming the stream filename
+// in errors.
+func (p *Parser) NewDecoder(r io.Reader, filename string, msgType protoreflect.MessageType) *Decoder {
+	return &Decoder{
+		parser:        p,
+		reader:        bufio.NewReader(r),
+		filename:      filename,
+		msgType:       msgType,
+		MaxRecordSize: DefaultMaxRecordSize,
+	}
+}
+
+// Next reads and parses the next record, returning io.EOF at the end of the
+// stream. A record which fails to parse is returned with its error, and
+// decoding can continue with the next.
+func (d *Decoder) Next() (*Record, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	var chunk strings.Builder
+	var scan recordScanner
+	start := d.line
+	for {
+		line, readErr := d.reader.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		if line != "" {
+			d.line++
+			if !d.started && !scan.significant && strings.HasPrefix(strings.TrimSpace(line), "!") {
+				// the header, with the comments above it
+				d.header.WriteString(chunk.String())
+				d.header.WriteString(line)
+				d.headerLines = d.line
+				chunk.Reset()
+				start = d.line
+				continue
+			}
+			if scan.significant && !scan.complete() && isSeparator(line) {
+				// the record was left open, it ends before the separator
+				return d.parse(chunk.String(), start)
+			}
+			if scan.scanLine(line) {
+				scan.significant = true
+			}
+			chunk.WriteString(line)
+			if d.MaxRecordSize > 0 && chunk.Len() > d.MaxRecordSize {
+				return nil, d.skipRecord(start)
+			}
+		}
+		if readErr == io.EOF {
+			d.done = true
+			if !scan.significant {
+				return nil, io.EOF
+			}
+			return d.parse(chunk.String(), start)
+		}
+		if scan.significant && scan.complete() {
+			return d.parse(chunk.String(), start)
+		}
+	}
+}
+
+// skipRecord reads past a record longer than MaxRecordSize, to the next
+// separator line, returning the error for it.
+func (d *Decoder) skipRecord(start int) error {
+	d.started = true
+	for {
+		line, err := d.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line != "" {
+			d.line++
+		}
+		if err == io.EOF {
+			d.done = true
+			break
+		}
+		if isSeparator(line) {
+			break
+		}
+	}
+	filename := d.filename
+	return &errpos.Err{
+		Pos: &errpos.Position{
+			Filename: &filename,
+			Start:    errpos.Point{Line: start},
+			End:      errpos.Point{Line: start},
+		},
+		Code: errpos.CodeLimitExceeded,
+		Err:  fmt.Errorf("record is longer than %d bytes", d.MaxRecordSize),
+	}
+}
+
+// isSeparator is true for a '---' line between the documents of a file.
+func isSeparator(line string) bool {
+	return strings.TrimRight(line, " \t\r\n") == "---"
+}
+
+func (d *Decoder) parse(chunk string, start int) (*Record, error) {
+	d.started = true
+	record := &Record{
+		Message: d.msgType.New(),
+		Offset: FragmentOffset{
+			Filename: d.filename,
+			Line:     start - d.headerLines,
+		},
+	}
+	result, err := d.parser.ParseFragment(record.Offset, d.header.String()+chunk, record.Message)
+	record.Result = result
+	return record, err
+}
+
+// recordScanner tracks the nesting of the lines of a record, skipping strings,
+// comments and descriptions.
+type recordScanner struct {
+	depth          int
+	inBlockComment bool
+	inRawString    bool
+	significant    bool
+}
+
+func (rs *recordScanner) complete() bool {
+	return rs.depth <= 0 && !rs.inBlockComment && !rs.inRawString
+}
+
+// scanLine returns true when the line has anything but space and comments.
+func (rs *recordScanner) scanLine(line string) bool {
+	significant := false
+	for idx := 0; idx < len(line); idx++ {
+		ch := line[idx]
+		if rs.inBlockComment {
+			if ch == '*' && strings.HasPrefix(line[idx:], "*/") {
+				rs.inBlockComment = false
+				idx++
+			}
+			continue
+		}
+		if rs.inRawString {
+			if ch == '`' {
+				rs.inRawString = false
+			}
+			continue
+		}
+		switch ch {
+		case ' ', '\t', '\r', '\n':
+		case '#':
+			return significant
+		case '/':
+			if strings.HasPrefix(line[idx:], "//") {
+				return significant
+			}
+			if strings.HasPrefix(line[idx:], "/*") {
+				rs.inBlockComment = true
+				idx++
+				continue
+			}
+			significant = true
+			idx = skipQuoted(line, idx, '/')
+		case '|':
+			return true
+		case '"':
+			significant = true
+			idx = skipQuoted(line, idx, '"')
+		case '`':
+			significant = true
+			rs.inRawString = true
+		case '{', '[', '(':
+			significant = true
+			rs.depth++
+		case '}', ']', ')':
+			significant = true
+			rs.depth--
+		default:
+			significant = true
+		}
+	}
+	return significant
+}
+
+// skipQuoted returns the index of the quote closing the literal opened at
+// start, or the end of the line.
+func skipQuoted(line string, start int, quote byte) int {
+	for idx := start + 1; idx < len(line); idx++ {
+		switch line[idx] {
+		case '\\':
+			idx++
+		case quote:
+			return idx
+		}
+	}
+	return len(line)
+}
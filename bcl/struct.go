@@ -0,0 +1,45 @@
+package bcl
+
+import (
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/gostruct"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// UnmarshalStruct parses src onto the Go struct pointed to by v, without a
+// schema. Blocks and attributes map to fields by `bcl` struct tags:
+//
+//	type Config struct {
+//		Name    string             `bcl:"name"`
+//		Servers []*Server          `bcl:"server"`
+//		Labels  map[string]string  `bcl:"label"`
+//	}
+//
+//	type Server struct {
+//		Host        string `bcl:"host,tag"`
+//		Port        int    `bcl:"port"`
+//		Description string `bcl:",description"`
+//	}
+//
+// Fields marked tag take the block header tags in order, and may also be
+// marked optional. Blocks for a slice field append an element, blocks for a
+// map field use the first tag as the key. Untagged fields use their name with
+// a lower case first letter, "-" skips the field.
+//
+// Syntax which only a schema gives a meaning to is an error: with and defaults
+// blocks, function calls and splats of references.
+func UnmarshalStruct(src []byte, v any) error {
+	data := string(src)
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		if err == parser.HadErrors {
+			return errpos.AddSource(tree.Errors, data)
+		}
+		return errpos.AddSource(err, data)
+	}
+
+	if err := gostruct.Decode(tree.Body, v); err != nil {
+		return errpos.AddSource(err, data)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package bcl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SourceFile is a parsed file, the input to project level passes.
+type SourceFile struct {
+	Filename       string
+	Message        protoreflect.Message
+	SourceLocation *bcl_j5pb.SourceLocation
+
+	// Source is the text of the file. When it is set, lints over the project
+	// honour the bcl:ignore comments in it.
+	Source string
+
+	// AST is the tree of Source, when the caller has already parsed it, so
+	// that the checks which need the syntax don't parse the file again.
+	AST *ast.File
+}
+
+// tree returns the AST of the file, parsing Source when it is not set.
+func (file SourceFile) tree() (*ast.File, error) {
+	if file.AST != nil {
+		return file.AST, nil
+	}
+	tree, err := ast.Parse(file.Source)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, file.Filename, file.Source)
+	}
+	return tree, nil
+}
+
+// SourceFiles converts the output of LoadFS for project level passes.
+func SourceFiles[T proto.Message](files []LoadedFile[T]) []SourceFile {
+	out := make([]SourceFile, len(files))
+	for idx, file := range files {
+		out[idx] = SourceFile{
+			Filename:       file.Path,
+			Message:        file.Message.ProtoReflect(),
+			SourceLocation: file.SourceLocation,
+			Source:         file.Source,
+		}
+	}
+	return out
+}
+
+// Symbol is a named block which is the target of a reference field.
+type Symbol struct {
+	Schema   string          // The j5 schema name of the block
+	Name     string          // The value of the block's name field
+	Position errpos.Position // The name, including the filename
+	Message  protoreflect.Message
+	Parent   *Symbol // The innermost named block holding this one, nil at the root
+}
+
+// Reference is a value of a reference field.
+type Reference struct {
+	From     *Symbol // The innermost named block holding the reference, nil when there is none
+	To       *Symbol // The referenced block, nil when unresolved or ambiguous
+	Schema   string  // The schema of the referenced block
+	Name     string
+	Position errpos.Position
+}
+
+// SymbolTable holds the named blocks of a project and the references between
+// them.
+type SymbolTable struct {
+	Symbols    []*Symbol
+	References []*Reference
+
+	byName map[symbolKey][]*Symbol
+}
+
+type symbolKey struct {
+	schema string
+	name   string
+}
+
+// Lookup returns the blocks of the schema with the name, more than one when
+// the name is ambiguous.
+func (st *SymbolTable) Lookup(schema, name string) []*Symbol {
+	return st.byName[symbolKey{schema: schema, name: name}]
+}
+
+// BuildSymbols collects the blocks which are targets of the reference fields
+// declared in spec, from all files, then resolves the references. Unresolved
+// and ambiguous references are returned as errpos.Errors, with the table.
+func BuildSymbols(spec *bcl_j5pb.Schema, files []SourceFile) (*SymbolTable, error) {
+	sb := &symbolBuilder{
+		table: &SymbolTable{
+			byName: map[symbolKey][]*Symbol{},
+		},
+		targets:    map[string]string{},
+		references: map[string][]*bcl_j5pb.Reference{},
+	}
+	for _, block := range spec.Blocks {
+		for _, ref := range block.References {
+			targetField := ref.TargetField
+			if targetField == "" {
+				targetField = "name"
+			}
+			if existing, ok := sb.targets[ref.Target]; ok && existing != targetField {
+				return nil, fmt.Errorf("references to %s use both %q and %q as the name field", ref.Target, existing, targetField)
+			}
+			sb.targets[ref.Target] = targetField
+			sb.references[block.SchemaName] = append(sb.references[block.SchemaName], ref)
+		}
+	}
+
+	for _, file := range files {
+		if err := sb.walk(file.Filename, file.Message, file.SourceLocation, nil); err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Filename, err)
+		}
+	}
+
+	errs := sb.resolve()
+	if len(errs) > 0 {
+		return sb.table, errs
+	}
+	return sb.table, nil
+}
+
+type symbolBuilder struct {
+	table      *SymbolTable
+	targets    map[string]string // schema name to name field
+	references map[string][]*bcl_j5pb.Reference
+}
+
+func (sb *symbolBuilder) walk(filename string, msg protoreflect.Message, loc *bcl_j5pb.SourceLocation, enclosing *Symbol) error {
+	desc := msg.Descriptor()
+	schemaName := j5SchemaName(desc)
+
+	if nameField, ok := sb.targets[schemaName]; ok {
+		fd := desc.Fields().ByJSONName(nameField)
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsList() {
+			return fmt.Errorf("name field %q of %s is not a string", nameField, schemaName)
+		}
+		if name := msg.Get(fd).String(); name != "" {
+			symbol := &Symbol{
+				Schema:   schemaName,
+				Name:     name,
+				Position: locPosition(filename, childLoc(loc, fd.JSONName())),
+				Message:  msg,
+				Parent:   enclosing,
+			}
+			key := symbolKey{schema: schemaName, name: name}
+			sb.table.Symbols = append(sb.table.Symbols, symbol)
+			sb.table.byName[key] = append(sb.table.byName[key], symbol)
+			enclosing = symbol
+		}
+	}
+
+	for _, ref := range sb.references[schemaName] {
+		fd := desc.Fields().ByJSONName(ref.FieldName)
+		if fd == nil || fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+			return fmt.Errorf("reference field %q of %s is not a string", ref.FieldName, schemaName)
+		}
+		fieldLoc := childLoc(loc, fd.JSONName())
+		add := func(name string, loc *bcl_j5pb.SourceLocation) {
+			if name == "" {
+				return
+			}
+			sb.table.References = append(sb.table.References, &Reference{
+				From:     enclosing,
+				Schema:   ref.Target,
+				Name:     name,
+				Position: locPosition(filename, loc),
+			})
+		}
+		if fd.IsList() {
+			list := msg.Get(fd).List()
+			for idx := 0; idx < list.Len(); idx++ {
+				add(list.Get(idx).String(), childLoc(fieldLoc, strconv.Itoa(idx)))
+			}
+		} else {
+			add(msg.Get(fd).String(), fieldLoc)
+		}
+	}
+
+	// Walk in field order, rather than Range, so the table is in file order.
+	fields := desc.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		if fd.Message() == nil || !msg.Has(fd) {
+			continue
+		}
+		fieldLoc := childLoc(loc, fd.JSONName())
+		switch {
+		case fd.IsList():
+			list := msg.Get(fd).List()
+			for idx := 0; idx < list.Len(); idx++ {
+				if err := sb.walk(filename, list.Get(idx).Message(), childLoc(fieldLoc, strconv.Itoa(idx)), enclosing); err != nil {
+					return err
+				}
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				continue
+			}
+			var err error
+			msg.Get(fd).Map().Range(func(key protoreflect.MapKey, val protoreflect.Value) bool {
+				err = sb.walk(filename, val.Message(), childLoc(fieldLoc, key.String()), enclosing)
+				return err == nil
+			})
+			if err != nil {
+				return err
+			}
+		default:
+			if err := sb.walk(filename, msg.Get(fd).Message(), fieldLoc, enclosing); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sb *symbolBuilder) resolve() errpos.Errors {
+	var errs errpos.Errors
+	for _, ref := range sb.table.References {
+		pos := ref.Position
+		found := sb.table.Lookup(ref.Schema, ref.Name)
+		switch len(found) {
+		case 1:
+			ref.To = found[0]
+		case 0:
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeUnresolvedReference,
+				Err:  fmt.Errorf("no %s named %q", ref.Schema, ref.Name),
+			})
+		default:
+			defined := make([]string, len(found))
+			for idx, symbol := range found {
+				defined[idx] = symbol.Position.String()
+			}
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeAmbiguousReference,
+				Err:  fmt.Errorf("%s %q is ambiguous, defined at %s", ref.Schema, ref.Name, strings.Join(defined, ", ")),
+			})
+		}
+	}
+	return errs
+}
+
+// j5SchemaName is the name of the message as used in block specs, nested
+// messages are joined with an underscore.
+func j5SchemaName(desc protoreflect.MessageDescriptor) string {
+	pkg := string(desc.ParentFile().Package())
+	name := strings.TrimPrefix(string(desc.FullName()), pkg+".")
+	return pkg + "." + strings.ReplaceAll(name, ".", "_")
+}
+
+// childLoc returns the location of the child, or the parent location when the
+// child was not recorded.
+func childLoc(loc *bcl_j5pb.SourceLocation, name string) *bcl_j5pb.SourceLocation {
+	if loc == nil {
+		return nil
+	}
+	if child, ok := loc.Children[name]; ok {
+		return child
+	}
+	return loc
+}
+
+func locPosition(filename string, loc *bcl_j5pb.SourceLocation) errpos.Position {
+	pos := errpos.Position{
+		Filename: &filename,
+	}
+	if loc != nil {
+		pos.Start = errpos.Point{Line: int(loc.StartLine), Column: int(loc.StartColumn)}
+		pos.End = errpos.Point{Line: int(loc.EndLine), Column: int(loc.EndColumn)}
+	}
+	return pos
+}
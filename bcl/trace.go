@@ -0,0 +1,53 @@
+package bcl
+
+import (
+	"context"
+	"time"
+)
+
+// Span names, one for each phase of a parse, within SpanParse.
+const (
+	SpanParse    = "bcl.parse"
+	SpanLex      = "bcl.lex"
+	SpanSyntax   = "bcl.syntax"
+	SpanWalk     = "bcl.walk"
+	SpanValidate = "bcl.validate"
+)
+
+// Tracer starts spans around the phases of a parse, so services embedding the
+// parser can see its latency in their traces. It is an interface rather than
+// a dependency on a tracing library, an adapter to e.g. OpenTelemetry passes
+// the start and end times through as span timestamps, since the lex and
+// syntax phases are timed together and reported when both are done.
+type Tracer interface {
+	// StartSpan starts a span named for the phase as a child of any span in
+	// ctx, returning a context holding it.
+	StartSpan(ctx context.Context, name string, start time.Time) (context.Context, Span)
+}
+
+// Span is a phase of a parse started by a Tracer.
+type Span interface {
+	// SetAttribute records a value of the phase, a string or an int.
+	SetAttribute(key string, value interface{})
+
+	// End ends the span at end, with the error of the phase when it failed.
+	End(end time.Time, err error)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End(time.Time, error)             {}
+
+func (p *Parser) startSpan(ctx context.Context, name string, start time.Time) (context.Context, Span) {
+	if p.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return p.Tracer.StartSpan(ctx, name, start)
+}
+
+// traceSpan records a phase which has already ended.
+func (p *Parser) traceSpan(ctx context.Context, name string, start time.Time, duration time.Duration, err error) {
+	_, span := p.startSpan(ctx, name, start)
+	span.End(start.Add(duration), err)
+}
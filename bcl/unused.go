@@ -0,0 +1,132 @@
+package bcl
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// CheckUnused reports the blocks which are targets of the reference fields
+// declared in spec, but which no reference in the files names, such as a
+// listener or template which is never used. A reference to a block nested in
+// a scoped name also uses the blocks around it.
+//
+// The findings are warnings, errpos.CodeUnreferenced, at the name of the
+// block. Blocks which are used from outside the project are allowed with a
+// bcl:ignore comment, for the files which have their Source set. Unresolved
+// references are left to BuildSymbols.
+func CheckUnused(spec *bcl_j5pb.Schema, files []SourceFile) (errpos.Errors, error) {
+	table, err := BuildSymbols(spec, files)
+	if table == nil {
+		return nil, err
+	}
+
+	used := map[*Symbol]bool{}
+	for _, ref := range table.References {
+		// Ambiguous references use every block they might name.
+		for _, found := range table.Lookup(ref.Schema, ref.Name) {
+			for symbol := found; symbol != nil && !used[symbol]; symbol = symbol.Parent {
+				used[symbol] = true
+			}
+		}
+	}
+
+	suppressions, err := sourceSuppressions(files)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := errpos.Errors{}
+	for _, symbol := range table.Symbols {
+		if used[symbol] {
+			continue
+		}
+		pos := symbol.Position
+		unused := &errpos.Err{
+			Pos:      &pos,
+			Code:     errpos.CodeUnreferenced,
+			Severity: errpos.SeverityWarning,
+			Err:      fmt.Errorf("%s %q is never referenced", symbol.Schema, symbol.Name),
+		}
+		errs = append(errs, unused)
+	}
+	return filterSuppressed(errs, suppressions), nil
+}
+
+// CheckUnusedVariables reports the variables a template declares in variable
+// blocks which no var("name") call or ${var.name} string reads, the template
+// counterpart of CheckUnused. The findings are warnings,
+// errpos.CodeUnreferenced, at the declaration, and are allowed with a
+// bcl:ignore comment.
+func CheckUnusedVariables(filename string, data string) (errpos.Errors, error) {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+	declared, _, errs := declaredVariables(tree, data)
+	if len(errs) > 0 {
+		return nil, errpos.AddSourceFile(errs, filename, data)
+	}
+
+	read := map[string]bool{}
+	for _, call := range parser.Calls(tree) {
+		if call.Name.String() != VarFunction || len(call.Args) != 1 {
+			continue
+		}
+		if name, err := call.Args[0].AsString(); err == nil {
+			read[name] = true
+		}
+	}
+	interpolateVars(data, func(name string, _ errpos.Position) (string, bool) {
+		read[name] = true
+		return "", false
+	})
+
+	unused := errpos.Errors{}
+	for _, v := range declared {
+		if read[v.Name] {
+			continue
+		}
+		pos := v.Pos
+		pos.Filename = &filename
+		unused = append(unused, &errpos.Err{
+			Pos:      &pos,
+			Code:     errpos.CodeUnreferenced,
+			Severity: errpos.SeverityWarning,
+			Err:      fmt.Errorf("variable %s is never read", v.Name),
+		})
+	}
+	return tree.Suppressions.Filter(unused), nil
+}
+
+// sourceSuppressions reads the bcl:ignore comments of the files which have
+// their Source set, by filename.
+func sourceSuppressions(files []SourceFile) (map[string]parser.Suppressions, error) {
+	suppressions := map[string]parser.Suppressions{}
+	for _, file := range files {
+		if file.Source == "" {
+			continue
+		}
+		tree, err := parser.ParseFile(file.Source, true)
+		if err != nil {
+			return nil, errpos.AddSourceFile(err, file.Filename, file.Source)
+		}
+		suppressions[file.Filename] = tree.Suppressions
+	}
+	return suppressions, nil
+}
+
+// filterSuppressed drops the errors which a bcl:ignore comment in their file
+// allows.
+func filterSuppressed(errs errpos.Errors, suppressions map[string]parser.Suppressions) errpos.Errors {
+	kept := errpos.Errors{}
+	for _, err := range errs {
+		if err.Pos != nil && err.Pos.Filename != nil && suppressions[*err.Pos.Filename].Suppress(err) {
+			continue
+		}
+		kept = append(kept, err)
+	}
+	return kept
+}
@@ -0,0 +1,402 @@
+package bcl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// VariableBlock is the type of the blocks which declare the variables of a
+// template:
+//
+//	variable region {
+//		| The region to deploy to
+//		type = string
+//		default = "eu-west-1"
+//	}
+const VariableBlock = "variable"
+
+// VarEnvPrefix prefixes the environment variables which set template
+// variables, BCL_VAR_region sets region, and BCL_VAR_db_host sets db.host.
+const VarEnvPrefix = "BCL_VAR_"
+
+// VariableType is the type of the values of a variable.
+type VariableType string
+
+const (
+	VariableString VariableType = "string"
+	VariableInt    VariableType = "int"
+	VariableFloat  VariableType = "float"
+	VariableBool   VariableType = "bool"
+
+	VariableStringArray VariableType = "[string]"
+	VariableIntArray    VariableType = "[int]"
+	VariableFloatArray  VariableType = "[float]"
+	VariableBoolArray   VariableType = "[bool]"
+)
+
+var variableTypes = []VariableType{
+	VariableString, VariableInt, VariableFloat, VariableBool,
+	VariableStringArray, VariableIntArray, VariableFloatArray, VariableBoolArray,
+}
+
+// elem is the type of the elements of an array type, and whether it is one.
+func (vt VariableType) elem() (VariableType, bool) {
+	inner, ok := strings.CutPrefix(string(vt), "[")
+	if !ok {
+		return vt, false
+	}
+	return VariableType(strings.TrimSuffix(inner, "]")), true
+}
+
+// Variable is a variable declared by a template.
+type Variable struct {
+	Name        string
+	Type        VariableType
+	Description string
+
+	// Default is the BCL source of the value used when none is given, empty
+	// when the variable must be set.
+	Default string
+
+	// Sensitive variables have their values left out of errors and
+	// listings.
+	Sensitive bool
+
+	Pos errpos.Position
+}
+
+// Variables are the variables declared by a template, in the order of the
+// file.
+type Variables []*Variable
+
+// ByName returns the declaration of the variable, or nil.
+func (vs Variables) ByName(name string) *Variable {
+	for _, v := range vs {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// ParseVariables reads the variable blocks at the root of a template. Types
+// default to string.
+func ParseVariables(filename string, data string) (Variables, error) {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+	vars, _, errs := declaredVariables(tree, data)
+	if len(errs) > 0 {
+		return nil, errpos.AddSourceFile(errs, filename, data)
+	}
+	return vars, nil
+}
+
+// declaredVariables reads the variable blocks of the file, returning them
+// with the blocks themselves so that rendering can remove them.
+func declaredVariables(tree *parser.File, data string) (Variables, []*parser.Block, errpos.Errors) {
+	var vars Variables
+	var blocks []*parser.Block
+	var errs errpos.Errors
+	declErr := func(node parser.SourceNode, code errpos.Code, format string, args ...interface{}) {
+		pos := node.Position()
+		errs = append(errs, &errpos.Err{
+			Pos:  &pos,
+			Code: code,
+			Err:  fmt.Errorf(format, args...),
+		})
+	}
+
+	for _, statement := range tree.Body.Statements {
+		block, ok := statement.(*parser.Block)
+		if !ok || block.Type.String() != VariableBlock {
+			continue
+		}
+		blocks = append(blocks, block)
+		if len(block.Tags) != 1 {
+			declErr(block.SourceNode, errpos.CodeExpectedTag, "%s blocks take one tag, the name of the variable", VariableBlock)
+			continue
+		}
+		name, err := block.Tags[0].AsString()
+		if err != nil {
+			declErr(block.SourceNode, errpos.CodeExpectedTag, "%s name: %w", VariableBlock, err)
+			continue
+		}
+		if vars.ByName(name) != nil {
+			declErr(block.SourceNode, errpos.CodeDuplicateName, "variable %s is declared more than once", name)
+			continue
+		}
+
+		v := &Variable{
+			Name:        name,
+			Type:        VariableString,
+			Description: block.DescriptionString(),
+			Pos:         block.Position(),
+		}
+		var defaultValue *parser.Value
+		for _, statement := range block.Body.Statements {
+			if desc, ok := statement.(*parser.Description); ok {
+				v.Description = desc.Value
+				continue
+			}
+			assign, ok := statement.(*parser.Assignment)
+			if !ok {
+				if _, ok := statement.(*parser.Block); ok {
+					declErr(statement.Source(), errpos.CodeUnknownBlock, "%s blocks only hold type, default, description and sensitive", VariableBlock)
+				}
+				continue
+			}
+			switch key := assign.Key.String(); key {
+			case "type":
+				str, err := assign.Value.AsString()
+				if err != nil {
+					declErr(assign.Value.SourceNode, errpos.CodeTypeMismatch, "type: %w", err)
+					continue
+				}
+				v.Type = VariableType(str)
+				known := false
+				for _, vt := range variableTypes {
+					known = known || vt == v.Type
+				}
+				if !known {
+					declErr(assign.Value.SourceNode, errpos.CodeInvalidValue, "unknown variable type %q, one of %s", str, variableTypeNames())
+				}
+			case "default":
+				if _, ok := assign.Value.Call(); ok {
+					declErr(assign.Value.SourceNode, errpos.CodeInvalidValue, "the default of variable %s is a function call, defaults must be literals", name)
+					continue
+				}
+				defaultValue = &assign.Value
+				v.Default = errpos.SourceText(data, assign.Value.Start, assign.Value.End)
+			case "description":
+				str, err := assign.Value.AsString()
+				if err != nil {
+					declErr(assign.Value.SourceNode, errpos.CodeTypeMismatch, "description: %w", err)
+					continue
+				}
+				v.Description = str
+			case "sensitive":
+				sensitive, err := assign.Value.AsBool()
+				if err != nil {
+					declErr(assign.Value.SourceNode, errpos.CodeTypeMismatch, "sensitive: %w", err)
+					continue
+				}
+				v.Sensitive = sensitive
+			default:
+				declErr(assign.Key.SourceNode, errpos.CodeUnknownBlock, "unknown %s attribute %s, one of type, default, description and sensitive", VariableBlock, key)
+			}
+		}
+		if defaultValue != nil {
+			if err := v.check(*defaultValue); err != nil {
+				declErr(defaultValue.SourceNode, errpos.CodeTypeMismatch, "default: %w", err)
+			}
+		}
+		vars = append(vars, v)
+	}
+	return vars, blocks, errs
+}
+
+func variableTypeNames() string {
+	names := make([]string, len(variableTypes))
+	for idx, vt := range variableTypes {
+		names[idx] = string(vt)
+	}
+	return strings.Join(names, ", ")
+}
+
+// check returns an error when the value is not of the type of the variable.
+// The value itself is left out, it may be sensitive.
+func (v *Variable) check(value parser.Value) error {
+	elemType, isArray := v.Type.elem()
+	if !isArray {
+		if value.IsArray() || isEmptyArray(value) {
+			return fmt.Errorf("variable %s has type %s, not array", v.Name, v.Type)
+		}
+		return v.checkScalar(value, elemType)
+	}
+
+	if isEmptyArray(value) {
+		return nil
+	}
+	elems, ok := value.AsArray()
+	if !ok {
+		return fmt.Errorf("variable %s has type %s, not %s", v.Name, v.Type, valueKind(value))
+	}
+	for idx, elem := range elems {
+		if err := v.checkScalar(elem.(parser.Value), elemType); err != nil {
+			return fmt.Errorf("element %d of %w", idx, err)
+		}
+	}
+	return nil
+}
+
+func (v *Variable) checkScalar(value parser.Value, vt VariableType) error {
+	var err error
+	switch vt {
+	case VariableString:
+		_, err = value.AsString()
+	case VariableInt:
+		_, err = value.AsInt(64)
+	case VariableFloat:
+		_, err = value.AsFloat(64)
+	case VariableBool:
+		_, err = value.AsBool()
+	}
+	if err != nil {
+		return fmt.Errorf("variable %s has type %s, not %s", v.Name, v.Type, valueKind(value))
+	}
+	return nil
+}
+
+// isEmptyArray reports whether the value is [], which has no token.
+func isEmptyArray(value parser.Value) bool {
+	return !value.IsArray() && value.Token().Type == parser.INVALID
+}
+
+func valueKind(value parser.Value) string {
+	if value.IsArray() || isEmptyArray(value) {
+		return "array"
+	}
+	switch value.Token().Type {
+	case parser.STRING, parser.IDENT, parser.DESCRIPTION, parser.REGEX:
+		return "string"
+	case parser.INT:
+		return "int"
+	case parser.DECIMAL:
+		return "float"
+	case parser.BOOL:
+		return "bool"
+	default:
+		return strings.ToLower(value.Token().Type.String())
+	}
+}
+
+// ParseVars reads a variables file, as the package level ParseVars, checking
+// each value against the declared type. Errors are positioned in the
+// variables file.
+func (vs Variables) ParseVars(filename string, data string) (Vars, error) {
+	vars, err := ParseVars(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse again for the positions of the values, ParseVars has checked the
+	// file so only the walk is repeated.
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+	var errs errpos.Errors
+	ast.Apply(tree, func(c *ast.Cursor) bool {
+		st, ok := c.Node().(*parser.Assignment)
+		if !ok {
+			return true
+		}
+		name := strings.Join(append(blockPath(c.Blocks()), referenceNames(st.Key)...), ".")
+		if err := vs.checkValue(name, st.Value); err != nil {
+			pos := st.Value.Position()
+			errs = append(errs, &errpos.Err{
+				Pos:  &pos,
+				Code: errpos.CodeTypeMismatch,
+				Err:  err,
+			})
+		}
+		return true
+	}, nil)
+
+	if len(errs) > 0 {
+		return nil, errpos.AddSourceFile(errs, filename, data)
+	}
+	return vars, nil
+}
+
+func (vs Variables) checkValue(name string, value parser.Value) error {
+	v := vs.ByName(name)
+	if v == nil {
+		return fmt.Errorf("variable %s is not declared", name)
+	}
+	return v.check(value)
+}
+
+// ParseStrings converts values given as plain strings, from command line
+// arguments or the environment, by the declared types. Values of string
+// variables are used as they are, values of other types are parsed as BCL.
+// Source names where the values are from in errors, e.g. "argument".
+func (vs Variables) ParseStrings(source string, values map[string]string) (Vars, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	vars := Vars{}
+	var errs errpos.Errors
+	for _, name := range names {
+		raw := values[name]
+		v := vs.ByName(name)
+		if v == nil {
+			errs = append(errs, &errpos.Err{
+				Code: errpos.CodeInvalidValue,
+				Err:  fmt.Errorf("%s: variable %s is not declared", source, name),
+			})
+			continue
+		}
+		if v.Type == VariableString {
+			vars[name] = strconv.Quote(raw)
+			continue
+		}
+		value, err := parseValue(raw)
+		if err == nil {
+			err = v.check(*value)
+		} else if v.Sensitive {
+			err = fmt.Errorf("variable %s has type %s, the value does not parse", name, v.Type)
+		} else {
+			err = fmt.Errorf("variable %s has type %s, %q does not parse", name, v.Type, raw)
+		}
+		if err != nil {
+			errs = append(errs, &errpos.Err{
+				Code: errpos.CodeTypeMismatch,
+				Err:  fmt.Errorf("%s: %w", source, err),
+			})
+			continue
+		}
+		vars[name] = raw
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return vars, nil
+}
+
+// Environ returns the values of the declared variables which are set in the
+// environment, named by VarEnvPrefix, for ParseStrings.
+func (vs Variables) Environ() map[string]string {
+	values := map[string]string{}
+	for _, v := range vs {
+		envName := VarEnvPrefix + strings.ReplaceAll(v.Name, ".", "_")
+		if value, ok := os.LookupEnv(envName); ok {
+			values[v.Name] = value
+		}
+	}
+	return values
+}
+
+// MergeVars combines sets of variables, values in later sets replacing those
+// in earlier ones.
+func MergeVars(sets ...Vars) Vars {
+	merged := Vars{}
+	for _, set := range sets {
+		for name, value := range set {
+			merged[name] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,139 @@
+package bcl
+
+import (
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messageNode is a message in the tree of a parsed file, with its place in
+// the tree and in the file.
+type messageNode struct {
+	msg    protoreflect.Message
+	parent protoreflect.Message // nil at the root
+	path   []string             // JSON names and keys from the root
+
+	// loc is the source location of the message, nil when there is none for
+	// it. located is loc, or the location of the nearest message above it
+	// which has one, to place errors.
+	loc     *bcl_j5pb.SourceLocation
+	located *bcl_j5pb.SourceLocation
+}
+
+// sources is the source set of the message, which adds errors to base.
+func (node messageNode) sources(base *baseSet) sourceSet {
+	return sourceSet{
+		path: node.path,
+		loc:  node.located,
+		base: base,
+	}
+}
+
+// child is the node of the field or key of the node, without its message.
+func (node messageNode) child(name string) messageNode {
+	child := messageNode{
+		parent:  node.parent,
+		path:    append(slices.Clone(node.path), name),
+		loc:     node.loc.GetChildren()[name],
+		located: node.located,
+	}
+	if child.loc != nil {
+		child.located = child.loc
+	}
+	return child
+}
+
+// with is the node with its message set.
+func (node messageNode) with(msg protoreflect.Message) messageNode {
+	node.msg = msg
+	return node
+}
+
+// visitMessages calls visit for the message and each message in its fields,
+// parents before their children. Fields are visited in the order of the
+// message, so mostly in file order, lists in order and maps by key, stopping
+// at the first error.
+func visitMessages(msg protoreflect.Message, loc *bcl_j5pb.SourceLocation, visit func(messageNode) error) error {
+	return visitNode(messageNode{msg: msg, loc: loc, located: loc}, visit)
+}
+
+func visitNode(node messageNode, visit func(messageNode) error) error {
+	if err := visit(node); err != nil {
+		return err
+	}
+
+	fields := node.msg.Descriptor().Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		if fd.Message() == nil || !node.msg.Has(fd) {
+			continue
+		}
+		if fd.IsMap() && fd.MapValue().Message() == nil {
+			continue
+		}
+		field := node.child(fd.JSONName())
+		field.parent = node.msg
+		switch {
+		case fd.IsList():
+			list := node.msg.Get(fd).List()
+			for idx := 0; idx < list.Len(); idx++ {
+				if err := visitNode(field.child(strconv.Itoa(idx)).with(list.Get(idx).Message()), visit); err != nil {
+					return err
+				}
+			}
+		case fd.IsMap():
+			value := node.msg.Get(fd).Map()
+			keys := []protoreflect.MapKey{}
+			value.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, key)
+				return true
+			})
+			sortMapKeys(keys)
+			for _, key := range keys {
+				if err := visitNode(field.child(key.String()).with(value.Get(key).Message()), visit); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := visitNode(field.with(node.msg.Get(fd).Message()), visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// programCache holds what is compiled for each message type, such as the
+// CEL programs of its policies, as the types are first seen. The schema alone
+// doesn't know the types.
+type programCache[K comparable, V any] struct {
+	compiled sync.Map // K to cachedProgram[V]
+}
+
+type cachedProgram[V any] struct {
+	value V
+	err   error
+}
+
+// get returns the cached value for the key, compiling it the first time.
+func (pc *programCache[K, V]) get(key K, compile func() (V, error)) (V, error) {
+	if cached, ok := pc.compiled.Load(key); ok {
+		cp := cached.(cachedProgram[V])
+		return cp.value, cp.err
+	}
+	value, err := compile()
+	pc.compiled.Store(key, cachedProgram[V]{value: value, err: err})
+	return value, err
+}
+
+// reset drops the compiled values, for when what they are compiled from
+// changes.
+func (pc *programCache[K, V]) reset() {
+	pc.compiled.Range(func(key, _ any) bool {
+		pc.compiled.Delete(key)
+		return true
+	})
+}
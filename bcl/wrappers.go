@@ -0,0 +1,372 @@
+package bcl
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// wrapperKinds are the google.protobuf wrapper messages, by the type of their
+// value field.
+var wrapperKinds = map[protoreflect.FullName]descriptorpb.FieldDescriptorProto_Type{
+	"google.protobuf.DoubleValue": descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"google.protobuf.FloatValue":  descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"google.protobuf.Int64Value":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"google.protobuf.UInt64Value": descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"google.protobuf.Int32Value":  descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"google.protobuf.UInt32Value": descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"google.protobuf.BoolValue":   descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"google.protobuf.StringValue": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"google.protobuf.BytesValue":  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// freeFormTypes are the google.protobuf messages which hold free-form JSON.
+// Views hold them as a string of their JSON, which the walker sets from the
+// blocks and literals of the file.
+var freeFormTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.Struct":    true,
+	"google.protobuf.Value":     true,
+	"google.protobuf.ListValue": true,
+}
+
+func isWrapper(fd protoreflect.FieldDescriptor) bool {
+	if fd.Message() == nil {
+		return false
+	}
+	_, ok := wrapperKinds[fd.Message().FullName()]
+	return ok
+}
+
+func isFreeForm(fd protoreflect.FieldDescriptor) bool {
+	return fd.Message() != nil && freeFormTypes[fd.Message().FullName()]
+}
+
+// isViewed is true for fields of a different type in the view.
+func isViewed(fd protoreflect.FieldDescriptor) bool {
+	return isWrapper(fd) || isFreeForm(fd)
+}
+
+// scalarViews are copies of message descriptors in which each wrapper field,
+// e.g. a google.protobuf.StringValue, is the scalar it wraps, and each
+// free-form field is a string of its JSON.
+//
+// The reflection layer can't build wrapper fields, so files are walked into a
+// message of the view, then copied into the real message with the values
+// wrapped. Files set a wrapper as its scalar, name = "x" rather than
+// name.value = "x", and an unset field stays unset.
+//
+// Every file declaring a message which needs a view is copied whole, so that
+// the copies keep their full names, and the views have a reflector of their
+// own so their schemas never mix with those of the real messages.
+type scalarViews struct {
+	refl     *j5reflect.Reflector
+	cache    sync.Map // protoreflect.MessageDescriptor to its view, nil without wrappers
+	freeForm sync.Map // message full name and field JSON name to the free-form type
+}
+
+func newScalarViews() *scalarViews {
+	return &scalarViews{refl: j5reflect.New()}
+}
+
+// view returns the view of the message, or nil when no field reachable from
+// it is a wrapper.
+func (sv *scalarViews) view(desc protoreflect.MessageDescriptor) (protoreflect.MessageDescriptor, error) {
+	if cached, ok := sv.cache.Load(desc); ok {
+		view, _ := cached.(protoreflect.MessageDescriptor)
+		return view, nil
+	}
+	view, err := buildScalarView(desc)
+	if err != nil {
+		return nil, fmt.Errorf("building scalar view of %s: %w", desc.FullName(), err)
+	}
+	for _, msg := range viewedMessages(desc) {
+		fields := msg.Fields()
+		for idx := 0; idx < fields.Len(); idx++ {
+			field := fields.Get(idx)
+			if isFreeForm(field) {
+				sv.freeForm.Store(freeFormKey(msg.FullName(), field.JSONName()), string(field.Message().FullName()))
+			}
+		}
+	}
+	sv.cache.Store(desc, view)
+	return view, nil
+}
+
+func freeFormKey(schemaName protoreflect.FullName, field string) string {
+	return string(schemaName) + "/" + field
+}
+
+// freeFormType returns the free-form message type of the field of a viewed
+// message, by its full name and the field's JSON name, or an empty string for
+// other fields.
+func (sv *scalarViews) freeFormType(schemaName, field string) string {
+	typeName, ok := sv.freeForm.Load(freeFormKey(protoreflect.FullName(schemaName), field))
+	if !ok {
+		return ""
+	}
+	return typeName.(string)
+}
+
+// objectFor returns the message to walk for msg, which is msg itself unless
+// it needs a view, and its reflection object. Every message the walker builds
+// is reflected through it, the root, blocks packed into Any fields and
+// extensions, then set from the walked message with fromView.
+func (p *Parser) objectFor(msg protoreflect.Message) (protoreflect.Message, j5reflect.Object, error) {
+	view, err := p.views.view(msg.Descriptor())
+	if err != nil {
+		return nil, nil, err
+	}
+	if view == nil {
+		obj, err := p.refl.NewObject(msg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return msg, obj, nil
+	}
+	walked := dynamicpb.NewMessage(view)
+	copyFields(walked, msg)
+	obj, err := p.views.refl.NewObject(walked)
+	if err != nil {
+		return nil, nil, err
+	}
+	return walked, obj, nil
+}
+
+// fromView replaces the fields of msg with those of the walked view.
+func fromView(msg, walked protoreflect.Message) {
+	if msg == walked {
+		return
+	}
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		msg.Clear(fd)
+		return true
+	})
+	msg.SetUnknown(nil)
+	copyFields(msg, walked)
+}
+
+func buildScalarView(desc protoreflect.MessageDescriptor) (protoreflect.MessageDescriptor, error) {
+	needs := viewedMessages(desc)
+	if needs[desc.FullName()] == nil {
+		return nil, nil
+	}
+
+	copied := map[string]bool{}
+	for _, msg := range needs {
+		copied[msg.ParentFile().Path()] = true
+	}
+
+	// Originals serve the files which aren't copied, and the views are
+	// looked up first, so references between copies resolve to copies.
+	originals := &protoregistry.Files{}
+	views := &protoregistry.Files{}
+	var visit func(file protoreflect.FileDescriptor) error
+	visit = func(file protoreflect.FileDescriptor) error {
+		if _, err := originals.FindFileByPath(file.Path()); err == nil {
+			return nil
+		}
+		imports := file.Imports()
+		for idx := 0; idx < imports.Len(); idx++ {
+			if err := visit(imports.Get(idx).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		if err := originals.RegisterFile(file); err != nil {
+			return err
+		}
+		if !copied[file.Path()] {
+			return nil
+		}
+		fdp := protodesc.ToFileDescriptorProto(file)
+		for _, msg := range fdp.MessageType {
+			unwrapFields(fdp, msg)
+		}
+		viewFile, err := protodesc.NewFile(fdp, viewResolver{views: views, originals: originals})
+		if err != nil {
+			return err
+		}
+		return views.RegisterFile(viewFile)
+	}
+	if err := visit(desc.ParentFile()); err != nil {
+		return nil, err
+	}
+
+	found, err := views.FindDescriptorByName(desc.FullName())
+	if err != nil {
+		return nil, err
+	}
+	view, ok := found.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %T, not a message", desc.FullName(), found)
+	}
+	return view, nil
+}
+
+// viewedMessages returns the messages reachable from desc which have a wrapper
+// or free-form field, directly or through the fields of their own.
+func viewedMessages(desc protoreflect.MessageDescriptor) map[protoreflect.FullName]protoreflect.MessageDescriptor {
+	reachable := map[protoreflect.FullName]protoreflect.MessageDescriptor{}
+	var collect func(msg protoreflect.MessageDescriptor)
+	collect = func(msg protoreflect.MessageDescriptor) {
+		if _, ok := reachable[msg.FullName()]; ok {
+			return
+		}
+		reachable[msg.FullName()] = msg
+		fields := msg.Fields()
+		for idx := 0; idx < fields.Len(); idx++ {
+			if field := fields.Get(idx); field.Message() != nil && !isViewed(field) {
+				collect(field.Message())
+			}
+		}
+	}
+	collect(desc)
+
+	needs := map[protoreflect.FullName]protoreflect.MessageDescriptor{}
+	for changed := true; changed; {
+		changed = false
+		for name, msg := range reachable {
+			if needs[name] != nil {
+				continue
+			}
+			fields := msg.Fields()
+			for idx := 0; idx < fields.Len(); idx++ {
+				field := fields.Get(idx)
+				if isViewed(field) || (field.Message() != nil && needs[field.Message().FullName()] != nil) {
+					needs[name] = msg
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return needs
+}
+
+// unwrapFields makes each wrapper field of the message and its nested
+// messages the scalar it wraps, and each free-form field a string. A singular
+// field of a proto3 file becomes optional, so that an unset wrapper is still
+// told from a zero value, other than map values, which are always set.
+func unwrapFields(file *descriptorpb.FileDescriptorProto, msg *descriptorpb.DescriptorProto) {
+	for _, nested := range msg.NestedType {
+		unwrapFields(file, nested)
+	}
+	for _, field := range msg.Field {
+		if field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+			continue
+		}
+		typeName := protoreflect.FullName(strings.TrimPrefix(field.GetTypeName(), "."))
+		kind, ok := wrapperKinds[typeName]
+		if freeFormTypes[typeName] {
+			kind, ok = descriptorpb.FieldDescriptorProto_TYPE_STRING, true
+		}
+		if !ok {
+			continue
+		}
+		field.Type = kind.Enum()
+		field.TypeName = nil
+		if file.GetSyntax() != "proto3" || msg.GetOptions().GetMapEntry() || field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED || field.OneofIndex != nil {
+			continue
+		}
+		field.Proto3Optional = proto.Bool(true)
+		field.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+		msg.OneofDecl = append(msg.OneofDecl, &descriptorpb.OneofDescriptorProto{
+			Name: proto.String("_" + field.GetName()),
+		})
+	}
+}
+
+// viewResolver resolves the imports of a copied file to the views built so
+// far, then to the original files.
+type viewResolver struct {
+	views     *protoregistry.Files
+	originals *protoregistry.Files
+}
+
+func (r viewResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := r.views.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+	return r.originals.FindFileByPath(path)
+}
+
+func (r viewResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if desc, err := r.views.FindDescriptorByName(name); err == nil {
+		return desc, nil
+	}
+	return r.originals.FindDescriptorByName(name)
+}
+
+// copyFields sets the fields of src on dst, where either is the view of the
+// other, wrapping or unwrapping values where their field types differ.
+func copyFields(dst, src protoreflect.Message) {
+	fields := dst.Descriptor().Fields()
+	src.Range(func(sfd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if sfd.IsExtension() {
+			dst.Set(sfd, value)
+			return true
+		}
+		dfd := fields.ByNumber(sfd.Number())
+		switch {
+		case sfd.IsList():
+			list := dst.Mutable(dfd).List()
+			from := value.List()
+			for idx := 0; idx < from.Len(); idx++ {
+				list.Append(convertValue(dfd, sfd, from.Get(idx), list.NewElement))
+			}
+		case sfd.IsMap():
+			entries := dst.Mutable(dfd).Map()
+			value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				entries.Set(key, convertValue(dfd.MapValue(), sfd.MapValue(), value, entries.NewValue))
+				return true
+			})
+		default:
+			dst.Set(dfd, convertValue(dfd, sfd, value, func() protoreflect.Value {
+				return dst.NewField(dfd)
+			}))
+		}
+		return true
+	})
+	if unknown := src.GetUnknown(); len(unknown) > 0 {
+		dst.SetUnknown(unknown)
+	}
+}
+
+func convertValue(dfd, sfd protoreflect.FieldDescriptor, value protoreflect.Value, newValue func() protoreflect.Value) protoreflect.Value {
+	switch {
+	case sfd.Message() == nil && dfd.Message() == nil:
+		return value
+	case dfd.Message() == nil && isFreeForm(sfd):
+		// the JSON of a free-form message, which always marshals
+		encoded, _ := protojson.Marshal(value.Message().Interface())
+		return protoreflect.ValueOfString(string(encoded))
+	case dfd.Message() == nil:
+		// unwrap
+		msg := value.Message()
+		return msg.Get(msg.Descriptor().Fields().ByNumber(1))
+	case sfd.Message() == nil && isFreeForm(dfd):
+		// the walker sets the JSON from the file, so it always unmarshals
+		msg := newValue().Message()
+		_ = protojson.Unmarshal([]byte(value.String()), msg.Interface())
+		return protoreflect.ValueOfMessage(msg)
+	case sfd.Message() == nil:
+		// wrap
+		msg := newValue().Message()
+		msg.Set(msg.Descriptor().Fields().ByNumber(1), value)
+		return protoreflect.ValueOfMessage(msg)
+	case sfd.Message() == dfd.Message():
+		return value
+	default:
+		msg := newValue().Message()
+		copyFields(msg, value.Message())
+		return protoreflect.ValueOfMessage(msg)
+	}
+}
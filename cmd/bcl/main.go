@@ -2,19 +2,32 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/fs"
 	"log"
+	"log/slog"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/pentops/bcl.go/bcl"
 	"github.com/pentops/bcl.go/bcl/bclsp"
+	"github.com/pentops/bcl.go/bcl/bundle"
 	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/internal/linter"
+	"github.com/pentops/bcl.go/internal/lsp"
 	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/repl"
 	"github.com/pentops/runner/commander"
 	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 var Version = "dev"
@@ -24,6 +37,17 @@ func main() {
 	cmdGroup.Add("lint", commander.NewCommand(runLint))
 	cmdGroup.Add("fmt", commander.NewCommand(runFmt))
 	cmdGroup.Add("lsp", commander.NewCommand(runLSP))
+	cmdGroup.Add("bundle", commander.NewCommand(runBundle))
+	cmdGroup.Add("convert", commander.NewCommand(runConvert))
+	cmdGroup.Add("describe", commander.NewCommand(runDescribe))
+	cmdGroup.Add("repl", commander.NewCommand(runREPL))
+	cmdGroup.Add("query", commander.NewCommand(runQuery))
+	cmdGroup.Add("select", commander.NewCommand(runSelect))
+	cmdGroup.Add("graph", commander.NewCommand(runGraph))
+	cmdGroup.Add("stats", commander.NewCommand(runStats))
+	cmdGroup.Add("render", commander.NewCommand(runRender))
+	cmdGroup.Add("set", commander.NewCommand(runSet))
+	cmdGroup.Add("unset", commander.NewCommand(runUnset))
 	cmdGroup.RunMain("bcl", Version)
 }
 
@@ -32,39 +56,33 @@ type RootConfig struct {
 	Verbose     bool   `flag:"verbose" env:"BCL_VERBOSE" default:"false" desc:"Verbose output"`
 }
 
+// logger logs parser debug events to stderr with --verbose.
+func (cfg RootConfig) logger() bcl.Logger {
+	if !cfg.Verbose {
+		return nil
+	}
+	return bcl.SlogLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})))
+}
+
 func runLint(ctx context.Context, cfg struct {
 	RootConfig
-	Filename string `flag:"filename" desc:"Filename to lint"`
+	Filename string `flag:"filename" default:"" desc:"Filename to lint"`
+	Color    string `flag:"color" default:"auto" desc:"Colorize errors: auto, always or never"`
+	Context  int    `flag:"context" default:"2" desc:"Source lines to print before each error"`
+	Format   string `flag:"format" default:"text" desc:"Output format: text, json or sarif"`
+	Fix      bool   `flag:"fix" default:"false" desc:"Apply safe fixes and format, to --filename or every file in the project"`
 }) error {
 
-	schemaSpec := &bcl_j5pb.Schema{
-		Blocks: []*bcl_j5pb.Block{{
-			SchemaName: "j5.bcl.v1.Block",
-			Name: &bcl_j5pb.Tag{
-				FieldName: "schemaName",
-			},
-		}, {
-			SchemaName: "j5.bcl.v1.ScalarSplit",
-			Alias: []*bcl_j5pb.Alias{{
-				Name: "required",
-				Path: &bcl_j5pb.Path{Path: []string{"requiredFields", "path"}},
-			}, {
-				Name: "optional",
-				Path: &bcl_j5pb.Path{Path: []string{"optionalFields", "path"}},
-			}, {
-				Name: "remainder",
-				Path: &bcl_j5pb.Path{Path: []string{"remainderField", "path"}},
-			}},
-		}, {
-			SchemaName: "j5.bcl.v1.Tag",
-			Alias: []*bcl_j5pb.Alias{{
-				Name: "path",
-				Path: &bcl_j5pb.Path{
-					Path: []string{"path", "path"},
-				},
-			}},
-		}},
+	if cfg.Fix {
+		return runLintFix(ctx, cfg.RootConfig, cfg.Filename)
 	}
+	if cfg.Filename == "" {
+		return fmt.Errorf("--filename is required")
+	}
+
+	schemaSpec := lintSchema()
 
 	msg := &bcl_j5pb.SchemaFile{}
 	/*
@@ -78,7 +96,7 @@ func runLint(ctx context.Context, cfg struct {
 	if err != nil {
 		return err
 	}
-	parser.Verbose = cfg.Verbose
+	parser.Logger = cfg.logger()
 
 	content, err := os.ReadFile(cfg.Filename)
 	if err != nil {
@@ -96,29 +114,784 @@ func runLint(ctx context.Context, cfg struct {
 		return mainError
 	}
 
-	log.Println(locErr.HumanString(2))
+	switch cfg.Format {
+	case "json":
+		err = json.NewEncoder(os.Stdout).Encode(locErr.Errors)
+	case "sarif":
+		err = errpos.WriteSARIF(os.Stdout, "bcl", Version, locErr.Errors)
+	case "text":
+		err = locErr.Render(os.Stderr, errpos.RenderOptions{
+			Color:        useColor(cfg.Color, os.Stderr),
+			ContextLines: cfg.Context,
+		})
+	default:
+		return fmt.Errorf("unknown format %q", cfg.Format)
+	}
+	if err != nil {
+		return err
+	}
 
 	os.Exit(100)
 	return nil
 }
 
-func runFmt(ctx context.Context, cfg struct {
-	Dir   string `flag:"dir" default:"." desc:"Root schema directory, or single file"`
-	Write bool   `flag:"write" default:"false" desc:"Write fixes to files"`
+// lintSchema is the schema for j5 schema files, the only schema the CLI
+// currently lints.
+func lintSchema() *bcl_j5pb.Schema {
+	return bcl.SchemaFileSpec()
+}
+
+func runLintFix(ctx context.Context, cfg RootConfig, filename string) error {
+	root := cfg.ProjectRoot
+	if root == "" {
+		root = "."
+	}
+	rootFS := os.DirFS(root)
+
+	config, err := project.LoadConfig(rootFS)
+	if err != nil {
+		return err
+	}
+
+	parser, err := bcl.NewParser(lintSchema())
+	if err != nil {
+		return err
+	}
+	parser.Logger = cfg.logger()
+
+	fixer := linter.New(parser, func(string) protoreflect.Message {
+		return (&bcl_j5pb.SchemaFile{}).ProtoReflect()
+	})
+
+	var files []string
+	if filename != "" {
+		rel, err := filepath.Rel(root, filename)
+		if err != nil {
+			return err
+		}
+		files = []string{filepath.ToSlash(rel)}
+	} else {
+		files, err = config.Files(rootFS)
+		if err != nil {
+			return err
+		}
+	}
+
+	hadErrors := false
+	for _, pathname := range files {
+		data, err := fs.ReadFile(rootFS, pathname)
+		if err != nil {
+			return err
+		}
+
+		result, err := fixer.FixFile(ctx, &lsp.FileRequest{
+			Filename: pathname,
+			Content:  string(data),
+		}, config)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pathname, err)
+		}
+
+		if result.Changed {
+			if err := os.WriteFile(filepath.Join(root, pathname), []byte(result.Content), 0644); err != nil {
+				return err
+			}
+			fmt.Printf("Fixed: %s\n", pathname)
+			for _, title := range result.Applied {
+				fmt.Printf("  - %s\n", title)
+			}
+		}
+
+		if len(result.Remaining) > 0 {
+			hadErrors = true
+			withSource := errpos.AddSourceFile(result.Remaining, pathname, result.Content)
+			fmt.Fprintln(os.Stderr, withSource.Error())
+		}
+	}
+
+	if hadErrors {
+		os.Exit(100)
+	}
+	return nil
+}
+
+func runBundle(ctx context.Context, cfg struct {
+	RootConfig
+	Output string `flag:"output" default:"bundle.zip" desc:"Bundle file to write"`
 }) error {
+	root := cfg.ProjectRoot
+	if root == "" {
+		root = "."
+	}
+	rootFS := os.DirFS(root)
 
-	doFile := func(data []byte) (string, error) {
-		fixed, err := parser.Fmt(string(data))
+	config, err := project.LoadConfig(rootFS)
+	if err != nil {
+		return err
+	}
+	files, err := config.Files(rootFS)
+	if err != nil {
+		return err
+	}
+
+	schema, err := bcl.CompileSchema(lintSchema(), (&bcl_j5pb.SchemaFile{}).ProtoReflect().Descriptor())
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(cfg.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := bundle.Write(out, schema, rootFS, files); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Bundled %d files to %s\n", len(files), cfg.Output)
+	return nil
+}
+
+func runConvert(ctx context.Context, cfg struct {
+	RootConfig
+	Filename    string `flag:"filename" default:"" desc:"Filename to convert"`
+	Format      string `flag:"format" default:"json" desc:"Output format: json, prototext or binary"`
+	Output      string `flag:"output" default:"" desc:"File to write, defaults to stdout"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+	Unredacted  bool   `flag:"unredacted" default:"false" desc:"Write the values of sensitive fields rather than redacting them"`
+	SourceMap   string `flag:"source-map" default:"" desc:"File to write a JSON source map of field paths to BCL positions"`
+}) error {
+	if cfg.Filename == "" {
+		return fmt.Errorf("--filename is required")
+	}
+
+	content, err := os.ReadFile(cfg.Filename)
+	if err != nil {
+		return err
+	}
+
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+
+	schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+	if err != nil {
+		return err
+	}
+
+	parser, err := bcl.NewParser(schemaSpec)
+	if err != nil {
+		return err
+	}
+	parser.Logger = cfg.logger()
+
+	var msg proto.Message
+	if root != nil {
+		msg = dynamicpb.NewMessage(root)
+	} else {
+		msg = &bcl_j5pb.SchemaFile{}
+	}
+	result, err := parser.Parse(cfg.Filename, string(content), msg.ProtoReflect())
+	if err != nil {
+		return err
+	}
+
+	if cfg.SourceMap != "" {
+		sourceMap, err := bcl.MarshalSourceMap(result.SourceMap())
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(cfg.SourceMap, sourceMap, 0644); err != nil {
+			return err
+		}
+	}
+
+	marshal := bcl.Marshal
+	if cfg.Unredacted {
+		marshal = bcl.MarshalUnredacted
+	}
+	out, err := marshal(msg, bcl.Format(cfg.Format))
+	if err != nil {
+		return err
+	}
+
+	if cfg.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(cfg.Output, out, 0644)
+}
+
+// loadMessage finds the root message in a binary FileDescriptorSet, returning
+// nil when no descriptors are given.
+func loadMessage(descriptors string, message string) (protoreflect.MessageDescriptor, error) {
+	if descriptors == "" {
+		return nil, nil
+	}
+	if message == "" {
+		return nil, fmt.Errorf("--message is required with --descriptors")
+	}
+	data, err := os.ReadFile(descriptors)
+	if err != nil {
+		return nil, err
+	}
+	fds := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, fds); err != nil {
+		return nil, fmt.Errorf("descriptors: %w", err)
+	}
+	return bcl.FindMessage(fds, message)
+}
+
+func runDescribe(ctx context.Context, cfg struct {
+	RootConfig
+	Format      string `flag:"format" default:"text" desc:"Output format: text, markdown or json"`
+	Output      string `flag:"output" default:"" desc:"File to write, defaults to stdout"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}) error {
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+
+	schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		root = (&bcl_j5pb.SchemaFile{}).ProtoReflect().Descriptor()
+	}
+
+	doc, err := bcl.DescribeSchema(schemaSpec, root)
+	if err != nil {
+		return err
+	}
+	out, err := doc.Render(bcl.DocFormat(cfg.Format))
+	if err != nil {
+		return err
+	}
+
+	if cfg.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(cfg.Output, out, 0644)
+}
+
+func runREPL(ctx context.Context, cfg struct {
+	RootConfig
+	Format      string `flag:"format" default:"prototext" desc:"Format to print the message in: prototext or json"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}) error {
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+
+	schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		root = (&bcl_j5pb.SchemaFile{}).ProtoReflect().Descriptor()
+	}
+
+	parser, err := bcl.NewParser(schemaSpec)
+	if err != nil {
+		return err
+	}
+	parser.Logger = cfg.logger()
+
+	fmt.Printf("Parsing into %s, :help lists commands\n", root.FullName())
+	return repl.Run(repl.NewSession(parser, root, bcl.Format(cfg.Format)), os.Stdin, os.Stdout)
+}
+
+func runQuery(ctx context.Context, cfg struct {
+	RootConfig
+	Expr        string `flag:",arg0" desc:"Path of the values to print, e.g. elements[0].foo.name"`
+	Filename    string `flag:",arg1" desc:"Filename to query"`
+	Format      string `flag:"format" default:"text" desc:"Output format: text prints scalars as plain text, json prints every value as JSON"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}) error {
+	if cfg.Format != "text" && cfg.Format != "json" {
+		return fmt.Errorf("unknown format %q", cfg.Format)
+	}
+
+	content, err := os.ReadFile(cfg.Filename)
+	if err != nil {
+		return err
+	}
+
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+
+	schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+	if err != nil {
+		return err
+	}
+
+	parser, err := bcl.NewParser(schemaSpec)
+	if err != nil {
+		return err
+	}
+	parser.Logger = cfg.logger()
+
+	var msg proto.Message
+	if root != nil {
+		msg = dynamicpb.NewMessage(root)
+	} else {
+		msg = &bcl_j5pb.SchemaFile{}
+	}
+	if _, err := parser.Parse(cfg.Filename, string(content), msg.ProtoReflect()); err != nil {
+		return err
+	}
+
+	results, err := bcl.Query(msg, cfg.Expr)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if cfg.Format == "text" {
+			fmt.Println(result.String())
+			continue
+		}
+		data, err := result.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func runSelect(ctx context.Context, cfg struct {
+	Selector string `flag:",arg0" desc:"Statements to print, e.g. 'block[type=foo] > attr[name=tag.*]'"`
+	Filename string `flag:",arg1" desc:"Filename to search"`
+}) error {
+	content, err := os.ReadFile(cfg.Filename)
+	if err != nil {
+		return err
+	}
+
+	matches, err := bcl.SelectNodes(cfg.Filename, string(content), cfg.Selector)
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		if match.Name == "" {
+			fmt.Printf("%s %s\n", match.Pos, match.Kind)
+			continue
+		}
+		fmt.Printf("%s %s %s\n", match.Pos, match.Kind, match.Name)
+	}
+	return nil
+}
+
+func runGraph(ctx context.Context, cfg struct {
+	RootConfig
+	Format      string `flag:"format" default:"dot" desc:"Output format: dot or json"`
+	Output      string `flag:"output" default:"" desc:"File to write, defaults to stdout"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}) error {
+	if cfg.Format != string(bcl.GraphDOT) && cfg.Format != string(bcl.GraphJSON) {
+		return fmt.Errorf("unknown format %q", cfg.Format)
+	}
+
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+
+	schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+	if err != nil {
+		return err
+	}
+
+	parser, err := bcl.NewParser(schemaSpec)
+	if err != nil {
+		return err
+	}
+	parser.Logger = cfg.logger()
+
+	projectRoot := cfg.ProjectRoot
+	if projectRoot == "" {
+		projectRoot = "."
+	}
+	loaded, err := bcl.LoadFSWith(parser, os.DirFS(projectRoot), func() proto.Message {
+		if root != nil {
+			return dynamicpb.NewMessage(root)
+		}
+		return &bcl_j5pb.SchemaFile{}
+	})
+	if err != nil {
+		return err
+	}
+
+	files := make([]bcl.SourceFile, 0, len(loaded))
+	for _, file := range loaded {
+		files = append(files, bcl.SourceFile{
+			Filename:       file.Path,
+			Message:        file.Message.ProtoReflect(),
+			SourceLocation: file.SourceLocation,
+			Source:         file.Source,
+		})
+	}
+
+	// Unresolved references are left out of the graph, and reported.
+	table, err := bcl.BuildSymbols(schemaSpec, files)
+	if table == nil {
+		return err
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if cfg.Output == "" {
+		return table.Graph().Write(os.Stdout, bcl.GraphFormat(cfg.Format))
+	}
+	out, err := os.Create(cfg.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := table.Graph().Write(out, bcl.GraphFormat(cfg.Format)); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func runStats(ctx context.Context, cfg struct {
+	RootConfig
+	Format string `flag:"format" default:"text" desc:"Output format: text or json"`
+	Top    int    `flag:"top" default:"10" desc:"Files, block types and attributes to list in text output"`
+}) error {
+	if cfg.Format != "text" && cfg.Format != "json" {
+		return fmt.Errorf("unknown format %q", cfg.Format)
+	}
+	if cfg.Top < 0 {
+		return fmt.Errorf("--top must be 0 or more, got %d", cfg.Top)
+	}
+
+	root := cfg.ProjectRoot
+	if root == "" {
+		root = "."
+	}
+	stats, err := bcl.CollectStats(os.DirFS(root))
+	if err != nil {
+		return err
+	}
+
+	if cfg.Format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	blocks := 0
+	for _, count := range stats.Blocks {
+		blocks += count
+	}
+	fmt.Printf("Files:     %d\n", len(stats.Files))
+	fmt.Printf("Lines:     %d\n", stats.Lines())
+	fmt.Printf("Blocks:    %d\n", blocks)
+	if stats.MaxDepth > 0 {
+		fmt.Printf("Max depth: %d at %s\n", stats.MaxDepth, stats.MaxDepthAt)
+	}
+
+	fmt.Println("\nLongest files:")
+	for _, file := range stats.Files[:min(cfg.Top, len(stats.Files))] {
+		fmt.Printf("  %6d  %s\n", file.Lines, file.Filename)
+	}
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"Block types", stats.Blocks},
+		{"Attributes", stats.Attributes},
+	} {
+		fmt.Printf("\n%s:\n", section.title)
+		ranked := bcl.Ranked(section.counts)
+		for _, count := range ranked[:min(cfg.Top, len(ranked))] {
+			fmt.Printf("  %6d  %s\n", count.Count, count.Name)
+		}
+	}
+	return nil
+}
+
+func runRender(ctx context.Context, cfg struct {
+	RootConfig
+	Filename    string   `flag:",arg0" desc:"Template to render"`
+	Args        []string `flag:",remaining" desc:"Variables as name=value, after the template"`
+	Vars        string   `flag:"vars" default:"" desc:"Variables file, a BCL file of assignments"`
+	Format      string   `flag:"format" default:"bcl" desc:"Output format: bcl, or json, prototext or binary to parse the rendered file"`
+	Output      string   `flag:"output" default:"" desc:"File to write, defaults to stdout"`
+	Descriptors string   `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, defaults to the schema file message"`
+	Message     string   `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string   `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}) error {
+	content, err := os.ReadFile(cfg.Filename)
+	if err != nil {
+		return err
+	}
+
+	declared, err := bcl.ParseVariables(cfg.Filename, string(content))
+	if err != nil {
+		return err
+	}
+
+	fileVars := bcl.Vars{}
+	if cfg.Vars != "" {
+		varsContent, err := os.ReadFile(cfg.Vars)
+		if err != nil {
+			return err
+		}
+		if len(declared) > 0 {
+			fileVars, err = declared.ParseVars(cfg.Vars, string(varsContent))
+		} else {
+			fileVars, err = bcl.ParseVars(cfg.Vars, string(varsContent))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// The remaining args include the template when any follow it.
+	args := map[string]string{}
+	if len(cfg.Args) > 0 {
+		for _, arg := range cfg.Args[1:] {
+			name, value, ok := strings.Cut(arg, "=")
+			if !ok {
+				return fmt.Errorf("variable %q is not name=value", arg)
+			}
+			args[name] = value
+		}
+	}
+	var vars bcl.Vars
+	if len(declared) == 0 {
+		// Without declarations there are no types, so the values are
+		// taken as BCL where they parse.
+		argVars := bcl.Vars{}
+		for name, value := range args {
+			argVars[name] = bcl.LiteralValue(value)
+		}
+		vars = bcl.MergeVars(fileVars, argVars)
+	} else {
+		envVars, err := declared.ParseStrings("environment", declared.Environ())
 		if err != nil {
-			return "", err
+			return err
+		}
+		argVars, err := declared.ParseStrings("argument", args)
+		if err != nil {
+			return err
+		}
+		vars = bcl.MergeVars(envVars, fileVars, argVars)
+	}
+
+	rendered, err := bcl.RenderTemplate(cfg.Filename, string(content), vars)
+	if err != nil {
+		return err
+	}
+
+	out := []byte(rendered)
+	if cfg.Format != "bcl" {
+		root, err := loadMessage(cfg.Descriptors, cfg.Message)
+		if err != nil {
+			return err
+		}
+		schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, root == nil)
+		if err != nil {
+			return err
+		}
+		parser, err := bcl.NewParser(schemaSpec)
+		if err != nil {
+			return err
+		}
+		parser.Logger = cfg.logger()
+
+		var msg proto.Message
+		if root != nil {
+			msg = dynamicpb.NewMessage(root)
+		} else {
+			msg = &bcl_j5pb.SchemaFile{}
+		}
+		if _, err := parser.Parse(cfg.Filename, rendered, msg.ProtoReflect()); err != nil {
+			return err
+		}
+		out, err = bcl.Marshal(msg, bcl.Format(cfg.Format))
+		if err != nil {
+			return err
 		}
-		return fixed, nil
 	}
 
+	if cfg.Output == "" {
+		_, err = os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(cfg.Output, out, 0644)
+}
+
+// EditConfig is the config of the commands which edit a file in place.
+type EditConfig struct {
+	RootConfig
+	Output      string `flag:"output" default:"" desc:"File to write, defaults to editing the file in place"`
+	Descriptors string `flag:"descriptors" default:"" desc:"Binary FileDescriptorSet holding the message, to check the edited file against"`
+	Message     string `flag:"message" default:"" desc:"Full name of the root message in --descriptors"`
+	Schema      string `flag:"schema" default:"" desc:"BCL schema file, defaults to the project config schema"`
+}
+
+func runSet(ctx context.Context, cfg struct {
+	EditConfig
+	Filename string `flag:",arg0" desc:"Filename to edit"`
+	Path     string `flag:",arg1" desc:"Dotted path of the attribute, e.g. server.timeout"`
+	Value    string `flag:",arg2" desc:"Value to set, quoted as a string unless it is a BCL value"`
+}) error {
+	return editFile(cfg.EditConfig, cfg.Filename, func(content string) (string, error) {
+		return bcl.SetValue(content, cfg.Path, bcl.LiteralValue(cfg.Value))
+	})
+}
+
+func runUnset(ctx context.Context, cfg struct {
+	EditConfig
+	Filename string `flag:",arg0" desc:"Filename to edit"`
+	Path     string `flag:",arg1" desc:"Dotted path of the attribute, e.g. server.timeout"`
+}) error {
+	return editFile(cfg.EditConfig, cfg.Filename, func(content string) (string, error) {
+		return bcl.UnsetValue(content, cfg.Path)
+	})
+}
+
+// editFile applies edit to the file, checking the result against the schema
+// of --descriptors when given, and writes it to --output or back to the file.
+func editFile(cfg EditConfig, filename string, edit func(string) (string, error)) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	edited, err := edit(string(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	root, err := loadMessage(cfg.Descriptors, cfg.Message)
+	if err != nil {
+		return err
+	}
+	if root != nil {
+		schemaSpec, err := convertSchema(cfg.RootConfig, cfg.Schema, false)
+		if err != nil {
+			return err
+		}
+		parser, err := bcl.NewParser(schemaSpec)
+		if err != nil {
+			return err
+		}
+		if _, err := parser.Parse(filename, edited, dynamicpb.NewMessage(root)); err != nil {
+			return err
+		}
+	}
+
+	output := cfg.Output
+	if output == "" {
+		output = filename
+	}
+	return os.WriteFile(output, []byte(edited), 0644)
+}
+
+// convertSchema finds the block specs for convert: the --schema file, then the
+// project config schema, falling back to the schema file spec when converting
+// schema files.
+func convertSchema(cfg RootConfig, schemaFile string, isSchemaFile bool) (*bcl_j5pb.Schema, error) {
+	if schemaFile != "" {
+		dir, name := filepath.Split(schemaFile)
+		if dir == "" {
+			dir = "."
+		}
+		return bcl.LoadSchemaFile(os.DirFS(dir), name)
+	}
+
+	root := cfg.ProjectRoot
+	if root == "" {
+		root = "."
+	}
+	rootFS := os.DirFS(root)
+	config, err := project.LoadConfig(rootFS)
+	if err != nil {
+		return nil, err
+	}
+	if config.Schema != "" {
+		return bcl.LoadSchemaFile(rootFS, config.Schema)
+	}
+	if isSchemaFile {
+		return lintSchema(), nil
+	}
+	return &bcl_j5pb.Schema{}, nil
+}
+
+func useColor(mode string, out *os.File) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	stat, err := out.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+func runFmt(ctx context.Context, cfg struct {
+	Dir   string `flag:"dir" default:"." desc:"Root schema directory, or single file"`
+	Write bool   `flag:"write" default:"false" desc:"Write fixes to files"`
+}) error {
 	stat, err := os.Lstat(cfg.Dir)
 	if err != nil {
 		return err
 	}
+
+	configDir := cfg.Dir
+	if !stat.IsDir() {
+		configDir = filepath.Dir(cfg.Dir)
+	}
+	config, err := project.LoadConfig(os.DirFS(configDir))
+	if err != nil {
+		return err
+	}
+
+	// Ordering by the schema uses the lint schema, as lint --fix does.
+	var schemaParser *bcl.Parser
+	if config.Format.Reorders() {
+		schemaParser, err = bcl.NewParser(lintSchema())
+		if err != nil {
+			return err
+		}
+	}
+	schemaRoot := (&bcl_j5pb.SchemaFile{}).ProtoReflect().Descriptor()
+
+	doFile := func(data []byte) (string, error) {
+		if schemaParser != nil {
+			return schemaParser.Fmt(string(data), schemaRoot, config.Format)
+		}
+		return parser.ConfigOptions(config.Format).Fmt(string(data))
+	}
 	if !stat.IsDir() {
 		data, err := os.ReadFile(cfg.Dir)
 		if err != nil {
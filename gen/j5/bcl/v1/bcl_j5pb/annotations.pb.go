@@ -20,6 +20,72 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Origin is where the value at a SourceLocation came from.
+type Origin int32
+
+const (
+	Origin_ORIGIN_UNSPECIFIED Origin = 0
+	// Set by a statement in the file.
+	Origin_ORIGIN_EXPLICIT Origin = 1
+	// Set by the schema to reach an explicit value, e.g. the containers on the
+	// path of an alias.
+	Origin_ORIGIN_SCHEMA Origin = 2
+	// Copied from another value in the file, e.g. the elements of a splat.
+	Origin_ORIGIN_SYNTHESIZED Origin = 3
+	// Set from the other fields of the file by a derived field of the schema.
+	Origin_ORIGIN_DERIVED Origin = 4
+	// Inherited from the defaults a parent block declares for blocks of its
+	// type.
+	Origin_ORIGIN_INHERITED Origin = 5
+)
+
+// Enum value maps for Origin.
+var (
+	Origin_name = map[int32]string{
+		0: "ORIGIN_UNSPECIFIED",
+		1: "ORIGIN_EXPLICIT",
+		2: "ORIGIN_SCHEMA",
+		3: "ORIGIN_SYNTHESIZED",
+		4: "ORIGIN_DERIVED",
+		5: "ORIGIN_INHERITED",
+	}
+	Origin_value = map[string]int32{
+		"ORIGIN_UNSPECIFIED": 0,
+		"ORIGIN_EXPLICIT":    1,
+		"ORIGIN_SCHEMA":      2,
+		"ORIGIN_SYNTHESIZED": 3,
+		"ORIGIN_DERIVED":     4,
+		"ORIGIN_INHERITED":   5,
+	}
+)
+
+func (x Origin) Enum() *Origin {
+	p := new(Origin)
+	*p = x
+	return p
+}
+
+func (x Origin) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Origin) Descriptor() protoreflect.EnumDescriptor {
+	return file_j5_bcl_v1_annotations_proto_enumTypes[0].Descriptor()
+}
+
+func (Origin) Type() protoreflect.EnumType {
+	return &file_j5_bcl_v1_annotations_proto_enumTypes[0]
+}
+
+func (x Origin) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Origin.Descriptor instead.
+func (Origin) EnumDescriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_annotations_proto_rawDescGZIP(), []int{0}
+}
+
 type SourceLocation struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -30,6 +96,7 @@ type SourceLocation struct {
 	StartColumn int32                      `protobuf:"varint,3,opt,name=start_column,json=startColumn,proto3" json:"start_column,omitempty"`
 	EndLine     int32                      `protobuf:"varint,4,opt,name=end_line,json=endLine,proto3" json:"end_line,omitempty"`
 	EndColumn   int32                      `protobuf:"varint,5,opt,name=end_column,json=endColumn,proto3" json:"end_column,omitempty"`
+	Origin      Origin                     `protobuf:"varint,6,opt,name=origin,proto3,enum=j5.bcl.v1.Origin" json:"origin,omitempty"`
 }
 
 func (x *SourceLocation) Reset() {
@@ -99,12 +166,19 @@ func (x *SourceLocation) GetEndColumn() int32 {
 	return 0
 }
 
+func (x *SourceLocation) GetOrigin() Origin {
+	if x != nil {
+		return x.Origin
+	}
+	return Origin_ORIGIN_UNSPECIFIED
+}
+
 var File_j5_bcl_v1_annotations_proto protoreflect.FileDescriptor
 
 var file_j5_bcl_v1_annotations_proto_rawDesc = []byte{
 	0x0a, 0x1b, 0x6a, 0x35, 0x2f, 0x62, 0x63, 0x6c, 0x2f, 0x76, 0x31, 0x2f, 0x61, 0x6e, 0x6e, 0x6f,
 	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x6a,
-	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x22, 0xa9, 0x02, 0x0a, 0x0e, 0x53, 0x6f, 0x75,
+	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x22, 0xd4, 0x02, 0x0a, 0x0e, 0x53, 0x6f, 0x75,
 	0x72, 0x63, 0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x43, 0x0a, 0x08, 0x63,
 	0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e,
 	0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65,
@@ -117,16 +191,28 @@ var file_j5_bcl_v1_annotations_proto_rawDesc = []byte{
 	0x6d, 0x6e, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x04,
 	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x4c, 0x69, 0x6e, 0x65, 0x12, 0x1d, 0x0a,
 	0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x63, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x1a, 0x56, 0x0a, 0x0d,
-	0x43, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
-	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
-	0x2f, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
-	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x3a, 0x02, 0x38, 0x01, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67,
-	0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x62, 0x63, 0x6c, 0x2f, 0x76, 0x31, 0x2f,
-	0x62, 0x63, 0x6c, 0x5f, 0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x05, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6c, 0x75, 0x6d, 0x6e, 0x12, 0x29, 0x0a, 0x06,
+	0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6a,
+	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x52,
+	0x06, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x1a, 0x56, 0x0a, 0x0d, 0x43, 0x68, 0x69, 0x6c, 0x64,
+	0x72, 0x65, 0x6e, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6a, 0x35, 0x2e, 0x62,
+	0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x6f, 0x63, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x2a,
+	0x8a, 0x01, 0x0a, 0x06, 0x4f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x12, 0x16, 0x0a, 0x12, 0x4f, 0x52,
+	0x49, 0x47, 0x49, 0x4e, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x4f, 0x52, 0x49, 0x47, 0x49, 0x4e, 0x5f, 0x45, 0x58, 0x50,
+	0x4c, 0x49, 0x43, 0x49, 0x54, 0x10, 0x01, 0x12, 0x11, 0x0a, 0x0d, 0x4f, 0x52, 0x49, 0x47, 0x49,
+	0x4e, 0x5f, 0x53, 0x43, 0x48, 0x45, 0x4d, 0x41, 0x10, 0x02, 0x12, 0x16, 0x0a, 0x12, 0x4f, 0x52,
+	0x49, 0x47, 0x49, 0x4e, 0x5f, 0x53, 0x59, 0x4e, 0x54, 0x48, 0x45, 0x53, 0x49, 0x5a, 0x45, 0x44,
+	0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e, 0x4f, 0x52, 0x49, 0x47, 0x49, 0x4e, 0x5f, 0x44, 0x45, 0x52,
+	0x49, 0x56, 0x45, 0x44, 0x10, 0x04, 0x12, 0x14, 0x0a, 0x10, 0x4f, 0x52, 0x49, 0x47, 0x49, 0x4e,
+	0x5f, 0x49, 0x4e, 0x48, 0x45, 0x52, 0x49, 0x54, 0x45, 0x44, 0x10, 0x05, 0x42, 0x32, 0x5a, 0x30,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f,
+	0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35,
+	0x2f, 0x62, 0x63, 0x6c, 0x2f, 0x76, 0x31, 0x2f, 0x62, 0x63, 0x6c, 0x5f, 0x6a, 0x35, 0x70, 0x62,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -141,19 +227,22 @@ func file_j5_bcl_v1_annotations_proto_rawDescGZIP() []byte {
 	return file_j5_bcl_v1_annotations_proto_rawDescData
 }
 
+var file_j5_bcl_v1_annotations_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
 var file_j5_bcl_v1_annotations_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_j5_bcl_v1_annotations_proto_goTypes = []any{
-	(*SourceLocation)(nil), // 0: j5.bcl.v1.SourceLocation
-	nil,                    // 1: j5.bcl.v1.SourceLocation.ChildrenEntry
+	(Origin)(0),            // 0: j5.bcl.v1.Origin
+	(*SourceLocation)(nil), // 1: j5.bcl.v1.SourceLocation
+	nil,                    // 2: j5.bcl.v1.SourceLocation.ChildrenEntry
 }
 var file_j5_bcl_v1_annotations_proto_depIdxs = []int32{
-	1, // 0: j5.bcl.v1.SourceLocation.children:type_name -> j5.bcl.v1.SourceLocation.ChildrenEntry
-	0, // 1: j5.bcl.v1.SourceLocation.ChildrenEntry.value:type_name -> j5.bcl.v1.SourceLocation
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 0: j5.bcl.v1.SourceLocation.children:type_name -> j5.bcl.v1.SourceLocation.ChildrenEntry
+	0, // 1: j5.bcl.v1.SourceLocation.origin:type_name -> j5.bcl.v1.Origin
+	1, // 2: j5.bcl.v1.SourceLocation.ChildrenEntry.value:type_name -> j5.bcl.v1.SourceLocation
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_j5_bcl_v1_annotations_proto_init() }
@@ -180,13 +269,14 @@ func file_j5_bcl_v1_annotations_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_j5_bcl_v1_annotations_proto_rawDesc,
-			NumEnums:      0,
+			NumEnums:      1,
 			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_j5_bcl_v1_annotations_proto_goTypes,
 		DependencyIndexes: file_j5_bcl_v1_annotations_proto_depIdxs,
+		EnumInfos:         file_j5_bcl_v1_annotations_proto_enumTypes,
 		MessageInfos:      file_j5_bcl_v1_annotations_proto_msgTypes,
 	}.Build()
 	File_j5_bcl_v1_annotations_proto = out.File
@@ -1,3 +1,63 @@
 // Code generated by protoc-gen-go-sugar. DO NOT EDIT.
 
 package bcl_j5pb
+
+import (
+	driver "database/sql/driver"
+	fmt "fmt"
+)
+
+// Origin
+const (
+	Origin_UNSPECIFIED Origin = 0
+	Origin_EXPLICIT    Origin = 1
+	Origin_SCHEMA      Origin = 2
+	Origin_SYNTHESIZED Origin = 3
+)
+
+var (
+	Origin_name_short = map[int32]string{
+		0: "UNSPECIFIED",
+		1: "EXPLICIT",
+		2: "SCHEMA",
+		3: "SYNTHESIZED",
+	}
+	Origin_value_short = map[string]int32{
+		"UNSPECIFIED": 0,
+		"EXPLICIT":    1,
+		"SCHEMA":      2,
+		"SYNTHESIZED": 3,
+	}
+	Origin_value_either = map[string]int32{
+		"UNSPECIFIED":        0,
+		"ORIGIN_UNSPECIFIED": 0,
+		"EXPLICIT":           1,
+		"ORIGIN_EXPLICIT":    1,
+		"SCHEMA":             2,
+		"ORIGIN_SCHEMA":      2,
+		"SYNTHESIZED":        3,
+		"ORIGIN_SYNTHESIZED": 3,
+	}
+)
+
+// ShortString returns the un-prefixed string representation of the enum value
+func (x Origin) ShortString() string {
+	return Origin_name_short[int32(x)]
+}
+func (x Origin) Value() (driver.Value, error) {
+	return []uint8(x.ShortString()), nil
+}
+func (x *Origin) Scan(value interface{}) error {
+	var strVal string
+	switch vt := value.(type) {
+	case []uint8:
+		strVal = string(vt)
+	case string:
+		strVal = vt
+	default:
+		return fmt.Errorf("invalid type %T", value)
+	}
+	val := Origin_value_either[strVal]
+	*x = Origin(val)
+	return nil
+}
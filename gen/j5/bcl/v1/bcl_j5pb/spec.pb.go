@@ -86,6 +86,9 @@ type Tag struct {
 	BangBool *string `protobuf:"bytes,4,opt,name=bang_bool,json=bangBool,proto3,oneof" json:"bang_bool,omitempty"`
 	// Same as bang_bool, but for a ?. Still 'true', e.g. 'optional=true)
 	QuestionBool *string `protobuf:"bytes,5,opt,name=question_bool,json=questionBool,proto3,oneof" json:"question_bool,omitempty"`
+	// When set, the tag value is prefixed with the name of the nearest
+	// enclosing named block and this separator, e.g. 'package.message'.
+	ScopeSeparator *string `protobuf:"bytes,6,opt,name=scope_separator,json=scopeSeparator,proto3,oneof" json:"scope_separator,omitempty"`
 }
 
 func (x *Tag) Reset() {
@@ -155,6 +158,13 @@ func (x *Tag) GetQuestionBool() string {
 	return ""
 }
 
+func (x *Tag) GetScopeSeparator() string {
+	if x != nil && x.ScopeSeparator != nil {
+		return *x.ScopeSeparator
+	}
+	return ""
+}
+
 type Alias struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -162,6 +172,9 @@ type Alias struct {
 
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Path *Path  `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// Deprecated aliases are kept for files written before a rename. They
+	// still resolve, with a warning and a fix to write the path instead.
+	Deprecated bool `protobuf:"varint,3,opt,name=deprecated,proto3" json:"deprecated,omitempty"`
 }
 
 func (x *Alias) Reset() {
@@ -210,6 +223,13 @@ func (x *Alias) GetPath() *Path {
 	return nil
 }
 
+func (x *Alias) GetDeprecated() bool {
+	if x != nil {
+		return x.Deprecated
+	}
+	return false
+}
+
 type Block struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -227,6 +247,36 @@ type Block struct {
 	// When true, fields in the block which are not mentioned in tags or children
 	// are not settable.
 	OnlyExplicit bool `protobuf:"varint,9,opt,name=only_explicit,json=onlyExplicit,proto3" json:"only_explicit,omitempty"`
+	// Constraints on the scalar fields of the block, checked as each value is
+	// assigned.
+	Constraints []*Constraint `protobuf:"bytes,11,rep,name=constraints,proto3" json:"constraints,omitempty"`
+	// Fields of the block which name other blocks, resolved across the project
+	// by the symbol table.
+	References []*Reference `protobuf:"bytes,12,rep,name=references,proto3" json:"references,omitempty"`
+	// Boolean fields of the block which can be set by naming them as a
+	// statement, see Flag.
+	Flags []*Flag `protobuf:"bytes,13,rep,name=flags,proto3" json:"flags,omitempty"`
+	// When true, the order of the attributes of the block doesn't matter, and
+	// the formatter may sort them.
+	Unordered bool `protobuf:"varint,14,opt,name=unordered,proto3" json:"unordered,omitempty"`
+	// CEL policies checked against each message of the block's schema after
+	// the file is parsed.
+	Policies []*Policy `protobuf:"bytes,15,rep,name=policies,proto3" json:"policies,omitempty"`
+	// The fields, by JSON name, which identify a block of the schema. Two
+	// blocks with the same values in a file or project are duplicates.
+	Identity []string `protobuf:"bytes,16,rep,name=identity,proto3" json:"identity,omitempty"`
+	// Normalizers rewrite the string values of scalar fields of the block
+	// before they are set.
+	Normalizers []*Normalizer `protobuf:"bytes,17,rep,name=normalizers,proto3" json:"normalizers,omitempty"`
+	// Derived fields are set from the other fields of the block, and of its
+	// parent, once the file is walked.
+	Derived []*Derived `protobuf:"bytes,18,rep,name=derived,proto3" json:"derived,omitempty"`
+	// Selects the type of the block from the value of an attribute in its
+	// body, rather than from a tag.
+	TypeAttribute *TypeAttribute `protobuf:"bytes,19,opt,name=type_attribute,json=typeAttribute,proto3,oneof" json:"type_attribute,omitempty"`
+	// String fields of the block, by JSON name, which hold text for people,
+	// checked by the spelling lint as descriptions are.
+	Prose []string `protobuf:"bytes,20,rep,name=prose,proto3" json:"prose,omitempty"`
 }
 
 func (x *Block) Reset() {
@@ -317,6 +367,76 @@ func (x *Block) GetOnlyExplicit() bool {
 	return false
 }
 
+func (x *Block) GetConstraints() []*Constraint {
+	if x != nil {
+		return x.Constraints
+	}
+	return nil
+}
+
+func (x *Block) GetReferences() []*Reference {
+	if x != nil {
+		return x.References
+	}
+	return nil
+}
+
+func (x *Block) GetFlags() []*Flag {
+	if x != nil {
+		return x.Flags
+	}
+	return nil
+}
+
+func (x *Block) GetUnordered() bool {
+	if x != nil {
+		return x.Unordered
+	}
+	return false
+}
+
+func (x *Block) GetPolicies() []*Policy {
+	if x != nil {
+		return x.Policies
+	}
+	return nil
+}
+
+func (x *Block) GetIdentity() []string {
+	if x != nil {
+		return x.Identity
+	}
+	return nil
+}
+
+func (x *Block) GetNormalizers() []*Normalizer {
+	if x != nil {
+		return x.Normalizers
+	}
+	return nil
+}
+
+func (x *Block) GetDerived() []*Derived {
+	if x != nil {
+		return x.Derived
+	}
+	return nil
+}
+
+func (x *Block) GetTypeAttribute() *TypeAttribute {
+	if x != nil {
+		return x.TypeAttribute
+	}
+	return nil
+}
+
+func (x *Block) GetProse() []string {
+	if x != nil {
+		return x.Prose
+	}
+	return nil
+}
+
 type Schema struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -440,6 +560,8 @@ type ScalarSplit struct {
 	// added to this one field. If there are no remaining values, this field is
 	// not touched. If there are remaining values and this field is not set, an
 	// error is raised.
+	// When the field is repeated, the remaining values are appended as elements
+	// instead.
 	RemainderField *Path `protobuf:"bytes,5,opt,name=remainder_field,json=remainderField,proto3,oneof" json:"remainder_field,omitempty"`
 }
 
@@ -510,6 +632,460 @@ func (x *ScalarSplit) GetRemainderField() *Path {
 	return nil
 }
 
+// A Constraint restricts the values of a scalar field, or each element of an
+// array of scalars. Violations are reported at the position of the value.
+type Constraint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldName string `protobuf:"bytes,1,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"` // The field in the block's schema, not an alias.
+	// A regular expression (RE2) which string values must match.
+	Pattern *string `protobuf:"bytes,2,opt,name=pattern,proto3,oneof" json:"pattern,omitempty"`
+	// Inclusive bounds for numeric values.
+	Min *float64 `protobuf:"fixed64,3,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max *float64 `protobuf:"fixed64,4,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	// Inclusive bounds for the length of string values, in characters.
+	MinLength *uint64 `protobuf:"varint,5,opt,name=min_length,json=minLength,proto3,oneof" json:"min_length,omitempty"`
+	MaxLength *uint64 `protobuf:"varint,6,opt,name=max_length,json=maxLength,proto3,oneof" json:"max_length,omitempty"`
+	// When set, the value must be one of these, compared as strings.
+	Allowed []string `protobuf:"bytes,7,rep,name=allowed,proto3" json:"allowed,omitempty"`
+}
+
+func (x *Constraint) Reset() {
+	*x = Constraint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Constraint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Constraint) ProtoMessage() {}
+
+func (x *Constraint) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Constraint.ProtoReflect.Descriptor instead.
+func (*Constraint) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Constraint) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Constraint) GetPattern() string {
+	if x != nil && x.Pattern != nil {
+		return *x.Pattern
+	}
+	return ""
+}
+
+func (x *Constraint) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *Constraint) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *Constraint) GetMinLength() uint64 {
+	if x != nil && x.MinLength != nil {
+		return *x.MinLength
+	}
+	return 0
+}
+
+func (x *Constraint) GetMaxLength() uint64 {
+	if x != nil && x.MaxLength != nil {
+		return *x.MaxLength
+	}
+	return 0
+}
+
+func (x *Constraint) GetAllowed() []string {
+	if x != nil {
+		return x.Allowed
+	}
+	return nil
+}
+
+// A Reference marks a string field, or array of strings, as holding the names
+// of other blocks.
+type Reference struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldName string `protobuf:"bytes,1,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"`
+	// The schema name of the referenced blocks.
+	Target string `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	// The field holding the name of the referenced blocks, defaults to "name".
+	TargetField string `protobuf:"bytes,3,opt,name=target_field,json=targetField,proto3" json:"target_field,omitempty"`
+}
+
+func (x *Reference) Reset() {
+	*x = Reference{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reference) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reference) ProtoMessage() {}
+
+func (x *Reference) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reference.ProtoReflect.Descriptor instead.
+func (*Reference) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Reference) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Reference) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Reference) GetTargetField() string {
+	if x != nil {
+		return x.TargetField
+	}
+	return ""
+}
+
+// A Flag marks a boolean field as settable by naming it alone on a line, e.g.
+// 'cache' sets it to true, '!cache' and 'no-cache' set it to false.
+type Flag struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldName string `protobuf:"bytes,1,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"`
+}
+
+func (x *Flag) Reset() {
+	*x = Flag{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Flag) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Flag) ProtoMessage() {}
+
+func (x *Flag) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Flag.ProtoReflect.Descriptor instead.
+func (*Flag) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Flag) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+// A Policy is a CEL expression which must be true for each message of the
+// block's schema, checked after the file is parsed and validated. The message
+// is `this`, and `file`, `line` and `column` are where its block starts.
+type Policy struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Expression string `protobuf:"bytes,1,opt,name=expression,proto3" json:"expression,omitempty"`
+	// Reported when the expression is false, defaulting to the expression.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *Policy) Reset() {
+	*x = Policy{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Policy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Policy) ProtoMessage() {}
+
+func (x *Policy) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Policy.ProtoReflect.Descriptor instead.
+func (*Policy) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Policy) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+func (x *Policy) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// A Normalizer rewrites each string value of a scalar field, or each element
+// of an array of scalars, before it is set and checked against constraints.
+// Steps are applied in order, each one the name of a built-in normalizer
+// (trim, lowercase, clean_path, url) or one registered with the parser.
+type Normalizer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldName string   `protobuf:"bytes,1,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"` // The field in the block's schema, not an alias.
+	Steps     []string `protobuf:"bytes,2,rep,name=steps,proto3" json:"steps,omitempty"`
+}
+
+func (x *Normalizer) Reset() {
+	*x = Normalizer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Normalizer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Normalizer) ProtoMessage() {}
+
+func (x *Normalizer) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Normalizer.ProtoReflect.Descriptor instead.
+func (*Normalizer) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Normalizer) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Normalizer) GetSteps() []string {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+// A Derived field is set from a CEL expression, evaluated for each message of
+// the block's schema after the file is walked and before it is validated. The
+// message is `this`, the message holding it is `parent`, null at the root, and
+// `file` is the filename. Parents are derived before their children, so an
+// expression can use the derived fields of its parent.
+type Derived struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FieldName  string `protobuf:"bytes,1,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"` // The field in the block's schema, not an alias.
+	Expression string `protobuf:"bytes,2,opt,name=expression,proto3" json:"expression,omitempty"`
+}
+
+func (x *Derived) Reset() {
+	*x = Derived{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Derived) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Derived) ProtoMessage() {}
+
+func (x *Derived) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Derived.ProtoReflect.Descriptor instead.
+func (*Derived) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Derived) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
+func (x *Derived) GetExpression() string {
+	if x != nil {
+		return x.Expression
+	}
+	return ""
+}
+
+// A TypeAttribute selects the type of a block from the string value of an
+// attribute, e.g. `kind = "http"` choosing the http branch of a oneof. The
+// attribute must come before the other statements of the body, and selects
+// the type as a type_select tag would, at field_name.
+type TypeAttribute struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Attribute string `protobuf:"bytes,1,opt,name=attribute,proto3" json:"attribute,omitempty"`                  // The key of the attribute, which is not a field.
+	FieldName string `protobuf:"bytes,2,opt,name=field_name,json=fieldName,proto3" json:"field_name,omitempty"` // can use aliases, empty or '.' for the block itself.
+}
+
+func (x *TypeAttribute) Reset() {
+	*x = TypeAttribute{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_bcl_v1_spec_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TypeAttribute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TypeAttribute) ProtoMessage() {}
+
+func (x *TypeAttribute) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_bcl_v1_spec_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TypeAttribute.ProtoReflect.Descriptor instead.
+func (*TypeAttribute) Descriptor() ([]byte, []int) {
+	return file_j5_bcl_v1_spec_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TypeAttribute) GetAttribute() string {
+	if x != nil {
+		return x.Attribute
+	}
+	return ""
+}
+
+func (x *TypeAttribute) GetFieldName() string {
+	if x != nil {
+		return x.FieldName
+	}
+	return ""
+}
+
 var File_j5_bcl_v1_spec_proto protoreflect.FileDescriptor
 
 var file_j5_bcl_v1_spec_proto_rawDesc = []byte{
@@ -520,7 +1096,7 @@ var file_j5_bcl_v1_spec_proto_rawDesc = []byte{
 	0x6a, 0x35, 0x2f, 0x65, 0x78, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61,
 	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x1a, 0x0a, 0x04, 0x50,
 	0x61, 0x74, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0xc7, 0x01, 0x0a, 0x03, 0x54, 0x61, 0x67, 0x12,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x89, 0x02, 0x0a, 0x03, 0x54, 0x61, 0x67, 0x12,
 	0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
 	0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x19,
 	0x0a, 0x08, 0x69, 0x73, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
@@ -531,75 +1107,162 @@ var file_j5_bcl_v1_spec_proto_rawDesc = []byte{
 	0x42, 0x6f, 0x6f, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d, 0x71, 0x75, 0x65, 0x73, 0x74,
 	0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x6f, 0x6f, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01,
 	0x52, 0x0c, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x6f, 0x6f, 0x6c, 0x88, 0x01,
-	0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x62, 0x61, 0x6e, 0x67, 0x5f, 0x62, 0x6f, 0x6f, 0x6c, 0x42,
-	0x10, 0x0a, 0x0e, 0x5f, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x6f, 0x6f,
-	0x6c, 0x22, 0x40, 0x0a, 0x05, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x23,
-	0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a,
-	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x52, 0x04, 0x70,
-	0x61, 0x74, 0x68, 0x22, 0xb1, 0x03, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1f, 0x0a,
-	0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x27,
-	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6a,
-	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67, 0x48, 0x00, 0x52, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x34, 0x0a, 0x0b, 0x74, 0x79, 0x70, 0x65, 0x5f,
-	0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6a,
-	0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67, 0x48, 0x01, 0x52, 0x0a,
-	0x74, 0x79, 0x70, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a,
-	0x09, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0e, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67,
-	0x48, 0x02, 0x52, 0x09, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65, 0x72, 0x88, 0x01, 0x01,
-	0x12, 0x30, 0x0a, 0x11, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
-	0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03, 0x52, 0x10, 0x64,
-	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x88,
-	0x01, 0x01, 0x12, 0x26, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x10, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c,
-	0x69, 0x61, 0x73, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x39, 0x0a, 0x0c, 0x73, 0x63,
-	0x61, 0x6c, 0x61, 0x72, 0x5f, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x16, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x61,
-	0x6c, 0x61, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x52, 0x0b, 0x73, 0x63, 0x61, 0x6c, 0x61, 0x72,
-	0x53, 0x70, 0x6c, 0x69, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x6e, 0x6c, 0x79, 0x5f, 0x65, 0x78,
-	0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x6f, 0x6e,
-	0x6c, 0x79, 0x45, 0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x6e,
-	0x61, 0x6d, 0x65, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x73, 0x65, 0x6c,
-	0x65, 0x63, 0x74, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65,
-	0x72, 0x42, 0x14, 0x0a, 0x12, 0x5f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
-	0x6e, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x22, 0x43, 0x0a, 0x06, 0x53, 0x63, 0x68, 0x65, 0x6d,
-	0x61, 0x12, 0x39, 0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x10, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c,
-	0x6f, 0x63, 0x6b, 0x42, 0x0f, 0xc2, 0xff, 0x8e, 0x02, 0x0a, 0xaa, 0x01, 0x07, 0x1a, 0x05, 0x62,
-	0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x7b, 0x0a, 0x0a,
-	0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6a, 0x35, 0x2e,
-	0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x06, 0x73,
-	0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x42, 0x0a, 0x0f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f,
-	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
-	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63,
-	0x65, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xa9, 0x02, 0x0a, 0x0b, 0x53, 0x63,
-	0x61, 0x6c, 0x61, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x12, 0x21, 0x0a, 0x09, 0x64, 0x65, 0x6c,
-	0x69, 0x6d, 0x69, 0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09,
-	0x64, 0x65, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a, 0x0d,
-	0x72, 0x69, 0x67, 0x68, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x6c, 0x65, 0x66, 0x74, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x0b, 0x72, 0x69, 0x67, 0x68, 0x74, 0x54, 0x6f, 0x4c, 0x65, 0x66, 0x74,
-	0x12, 0x38, 0x0a, 0x0f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x66, 0x69, 0x65,
-	0x6c, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e, 0x62,
-	0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x52, 0x0e, 0x72, 0x65, 0x71, 0x75,
-	0x69, 0x72, 0x65, 0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x38, 0x0a, 0x0f, 0x6f, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x04, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e,
-	0x50, 0x61, 0x74, 0x68, 0x52, 0x0e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x69,
-	0x65, 0x6c, 0x64, 0x73, 0x12, 0x3d, 0x0a, 0x0f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65,
-	0x72, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e,
-	0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x48, 0x01,
-	0x52, 0x0e, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x46, 0x69, 0x65, 0x6c, 0x64,
-	0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x64, 0x65, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x65,
-	0x72, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x5f,
-	0x66, 0x69, 0x65, 0x6c, 0x64, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
-	0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e,
-	0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x62, 0x63, 0x6c, 0x2f, 0x76, 0x31,
-	0x2f, 0x62, 0x63, 0x6c, 0x5f, 0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x5f, 0x73, 0x65, 0x70, 0x61, 0x72,
+	0x61, 0x74, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x0e, 0x73, 0x63,
+	0x6f, 0x70, 0x65, 0x53, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x6f, 0x72, 0x88, 0x01, 0x01, 0x42,
+	0x0c, 0x0a, 0x0a, 0x5f, 0x62, 0x61, 0x6e, 0x67, 0x5f, 0x62, 0x6f, 0x6f, 0x6c, 0x42, 0x10, 0x0a,
+	0x0e, 0x5f, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x6f, 0x6f, 0x6c, 0x42,
+	0x12, 0x0a, 0x10, 0x5f, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x5f, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61,
+	0x74, 0x6f, 0x72, 0x22, 0x60, 0x0a, 0x05, 0x41, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x23, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f,
+	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x52,
+	0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x65, 0x70, 0x72, 0x65, 0x63, 0x61,
+	0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x65, 0x70, 0x72, 0x65,
+	0x63, 0x61, 0x74, 0x65, 0x64, 0x22, 0xfc, 0x07, 0x0a, 0x05, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12,
+	0x1f, 0x0a, 0x0b, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x27, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67, 0x48, 0x00,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x88, 0x01, 0x01, 0x12, 0x34, 0x0a, 0x0b, 0x74, 0x79, 0x70,
+	0x65, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e,
+	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67, 0x48, 0x01,
+	0x52, 0x0a, 0x74, 0x79, 0x70, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x12,
+	0x31, 0x0a, 0x09, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x61, 0x67, 0x48, 0x02, 0x52, 0x09, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65, 0x72, 0x88,
+	0x01, 0x01, 0x12, 0x30, 0x0a, 0x11, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x48, 0x03, 0x52,
+	0x10, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x18, 0x0a, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x6c, 0x69, 0x61, 0x73, 0x52, 0x05, 0x61, 0x6c, 0x69, 0x61, 0x73, 0x12, 0x39, 0x0a, 0x0c,
+	0x73, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x5f, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x63, 0x61, 0x6c, 0x61, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x52, 0x0b, 0x73, 0x63, 0x61, 0x6c,
+	0x61, 0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x6f, 0x6e, 0x6c, 0x79, 0x5f,
+	0x65, 0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c,
+	0x6f, 0x6e, 0x6c, 0x79, 0x45, 0x78, 0x70, 0x6c, 0x69, 0x63, 0x69, 0x74, 0x12, 0x4d, 0x0a, 0x0b,
+	0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x73, 0x18, 0x0b, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f,
+	0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x42, 0x14, 0xc2, 0xff, 0x8e, 0x02, 0x0f, 0xaa,
+	0x01, 0x0c, 0x1a, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x52, 0x0b,
+	0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x49, 0x0a, 0x0a, 0x72,
+	0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x14, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x42, 0x13, 0xc2, 0xff, 0x8e, 0x02, 0x0e, 0xaa, 0x01, 0x0b, 0x1a,
+	0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x52, 0x0a, 0x72, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x35, 0x0a, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x18,
+	0x0d, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76,
+	0x31, 0x2e, 0x46, 0x6c, 0x61, 0x67, 0x42, 0x0e, 0xc2, 0xff, 0x8e, 0x02, 0x09, 0xaa, 0x01, 0x06,
+	0x1a, 0x04, 0x66, 0x6c, 0x61, 0x67, 0x52, 0x05, 0x66, 0x6c, 0x61, 0x67, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x75, 0x6e, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x09, 0x75, 0x6e, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x65, 0x64, 0x12, 0x3f, 0x0a, 0x08, 0x70,
+	0x6f, 0x6c, 0x69, 0x63, 0x69, 0x65, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x42, 0x10, 0xc2, 0xff, 0x8e, 0x02, 0x0b, 0xaa, 0x01, 0x08, 0x1a, 0x06, 0x70, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x52, 0x08, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x69, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x18, 0x10, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08,
+	0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x4d, 0x0a, 0x0b, 0x6e, 0x6f, 0x72, 0x6d,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x72, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x6f, 0x72, 0x6d, 0x61, 0x6c,
+	0x69, 0x7a, 0x65, 0x72, 0x42, 0x14, 0xc2, 0xff, 0x8e, 0x02, 0x0f, 0xaa, 0x01, 0x0c, 0x1a, 0x0a,
+	0x6e, 0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x72, 0x52, 0x0b, 0x6e, 0x6f, 0x72, 0x6d,
+	0x61, 0x6c, 0x69, 0x7a, 0x65, 0x72, 0x73, 0x12, 0x3f, 0x0a, 0x07, 0x64, 0x65, 0x72, 0x69, 0x76,
+	0x65, 0x64, 0x18, 0x12, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63,
+	0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x42, 0x11, 0xc2, 0xff,
+	0x8e, 0x02, 0x0c, 0xaa, 0x01, 0x09, 0x1a, 0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x52,
+	0x07, 0x64, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x44, 0x0a, 0x0e, 0x74, 0x79, 0x70, 0x65,
+	0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x79, 0x70,
+	0x65, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x48, 0x04, 0x52, 0x0d, 0x74, 0x79,
+	0x70, 0x65, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x14,
+	0x0a, 0x05, 0x70, 0x72, 0x6f, 0x73, 0x65, 0x18, 0x14, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x70,
+	0x72, 0x6f, 0x73, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x0e, 0x0a,
+	0x0c, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x42, 0x0c, 0x0a,
+	0x0a, 0x5f, 0x71, 0x75, 0x61, 0x6c, 0x69, 0x66, 0x69, 0x65, 0x72, 0x42, 0x14, 0x0a, 0x12, 0x5f,
+	0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x66, 0x69, 0x65, 0x6c,
+	0x64, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x22, 0x43, 0x0a, 0x06, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x12, 0x39,
+	0x0a, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b,
+	0x42, 0x0f, 0xc2, 0xff, 0x8e, 0x02, 0x0a, 0xaa, 0x01, 0x07, 0x1a, 0x05, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x52, 0x06, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x73, 0x22, 0x7b, 0x0a, 0x0a, 0x53, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x52, 0x06, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x12, 0x42, 0x0a, 0x0f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6a, 0x35,
+	0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0e, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x4c, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xa9, 0x02, 0x0a, 0x0b, 0x53, 0x63, 0x61, 0x6c, 0x61,
+	0x72, 0x53, 0x70, 0x6c, 0x69, 0x74, 0x12, 0x21, 0x0a, 0x09, 0x64, 0x65, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x09, 0x64, 0x65, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a, 0x0d, 0x72, 0x69, 0x67,
+	0x68, 0x74, 0x5f, 0x74, 0x6f, 0x5f, 0x6c, 0x65, 0x66, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0b, 0x72, 0x69, 0x67, 0x68, 0x74, 0x54, 0x6f, 0x4c, 0x65, 0x66, 0x74, 0x12, 0x38, 0x0a,
+	0x0f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e,
+	0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x52, 0x0e, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65,
+	0x64, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x12, 0x38, 0x0a, 0x0f, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x61, 0x6c, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e, 0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74,
+	0x68, 0x52, 0x0e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x73, 0x12, 0x3d, 0x0a, 0x0f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x66,
+	0x69, 0x65, 0x6c, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x6a, 0x35, 0x2e,
+	0x62, 0x63, 0x6c, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x61, 0x74, 0x68, 0x48, 0x01, 0x52, 0x0e, 0x72,
+	0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x88, 0x01, 0x01,
+	0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x64, 0x65, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x65, 0x72, 0x42, 0x12,
+	0x0a, 0x10, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x69, 0x6e, 0x64, 0x65, 0x72, 0x5f, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x22, 0x94, 0x02, 0x0a, 0x0a, 0x43, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x61, 0x69, 0x6e,
+	0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x1d, 0x0a, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x48, 0x00, 0x52, 0x07, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x88, 0x01, 0x01, 0x12,
+	0x15, 0x0a, 0x03, 0x6d, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x03,
+	0x6d, 0x69, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x6d, 0x61, 0x78, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x03, 0x6d, 0x61, 0x78, 0x88, 0x01, 0x01, 0x12, 0x22, 0x0a,
+	0x0a, 0x6d, 0x69, 0x6e, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x04, 0x48, 0x03, 0x52, 0x09, 0x6d, 0x69, 0x6e, 0x4c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x88, 0x01,
+	0x01, 0x12, 0x22, 0x0a, 0x0a, 0x6d, 0x61, 0x78, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x04, 0x48, 0x04, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x4c, 0x65, 0x6e, 0x67,
+	0x74, 0x68, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x42,
+	0x0a, 0x0a, 0x08, 0x5f, 0x70, 0x61, 0x74, 0x74, 0x65, 0x72, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x5f,
+	0x6d, 0x69, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x6d, 0x61, 0x78, 0x42, 0x0d, 0x0a, 0x0b, 0x5f,
+	0x6d, 0x69, 0x6e, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x6d,
+	0x61, 0x78, 0x5f, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x22, 0x65, 0x0a, 0x09, 0x52, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69, 0x65, 0x6c,
+	0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x46, 0x69, 0x65, 0x6c, 0x64,
+	0x22, 0x25, 0x0a, 0x04, 0x46, 0x6c, 0x61, 0x67, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65, 0x6c,
+	0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69,
+	0x65, 0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0x42, 0x0a, 0x06, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x41, 0x0a, 0x0a, 0x4e,
+	0x6f, 0x72, 0x6d, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66,
+	0x69, 0x65, 0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x65, 0x70,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x65, 0x70, 0x73, 0x22, 0x48,
+	0x0a, 0x07, 0x44, 0x65, 0x72, 0x69, 0x76, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66,
+	0x69, 0x65, 0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x70, 0x72,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x65, 0x78,
+	0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4c, 0x0a, 0x0d, 0x54, 0x79, 0x70, 0x65,
+	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x74, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x61, 0x74,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x69, 0x65, 0x6c, 0x64,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x4e, 0x61, 0x6d, 0x65, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c,
+	0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x62, 0x63, 0x6c, 0x2f, 0x76,
+	0x31, 0x2f, 0x62, 0x63, 0x6c, 0x5f, 0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
 }
 
 var (
@@ -614,7 +1277,7 @@ func file_j5_bcl_v1_spec_proto_rawDescGZIP() []byte {
 	return file_j5_bcl_v1_spec_proto_rawDescData
 }
 
-var file_j5_bcl_v1_spec_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_j5_bcl_v1_spec_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
 var file_j5_bcl_v1_spec_proto_goTypes = []any{
 	(*Path)(nil),           // 0: j5.bcl.v1.Path
 	(*Tag)(nil),            // 1: j5.bcl.v1.Tag
@@ -623,7 +1286,14 @@ var file_j5_bcl_v1_spec_proto_goTypes = []any{
 	(*Schema)(nil),         // 4: j5.bcl.v1.Schema
 	(*SchemaFile)(nil),     // 5: j5.bcl.v1.SchemaFile
 	(*ScalarSplit)(nil),    // 6: j5.bcl.v1.ScalarSplit
-	(*SourceLocation)(nil), // 7: j5.bcl.v1.SourceLocation
+	(*Constraint)(nil),     // 7: j5.bcl.v1.Constraint
+	(*Reference)(nil),      // 8: j5.bcl.v1.Reference
+	(*Flag)(nil),           // 9: j5.bcl.v1.Flag
+	(*Policy)(nil),         // 10: j5.bcl.v1.Policy
+	(*Normalizer)(nil),     // 11: j5.bcl.v1.Normalizer
+	(*Derived)(nil),        // 12: j5.bcl.v1.Derived
+	(*TypeAttribute)(nil),  // 13: j5.bcl.v1.TypeAttribute
+	(*SourceLocation)(nil), // 14: j5.bcl.v1.SourceLocation
 }
 var file_j5_bcl_v1_spec_proto_depIdxs = []int32{
 	0,  // 0: j5.bcl.v1.Alias.path:type_name -> j5.bcl.v1.Path
@@ -632,17 +1302,24 @@ var file_j5_bcl_v1_spec_proto_depIdxs = []int32{
 	1,  // 3: j5.bcl.v1.Block.qualifier:type_name -> j5.bcl.v1.Tag
 	2,  // 4: j5.bcl.v1.Block.alias:type_name -> j5.bcl.v1.Alias
 	6,  // 5: j5.bcl.v1.Block.scalar_split:type_name -> j5.bcl.v1.ScalarSplit
-	3,  // 6: j5.bcl.v1.Schema.blocks:type_name -> j5.bcl.v1.Block
-	4,  // 7: j5.bcl.v1.SchemaFile.schema:type_name -> j5.bcl.v1.Schema
-	7,  // 8: j5.bcl.v1.SchemaFile.source_location:type_name -> j5.bcl.v1.SourceLocation
-	0,  // 9: j5.bcl.v1.ScalarSplit.required_fields:type_name -> j5.bcl.v1.Path
-	0,  // 10: j5.bcl.v1.ScalarSplit.optional_fields:type_name -> j5.bcl.v1.Path
-	0,  // 11: j5.bcl.v1.ScalarSplit.remainder_field:type_name -> j5.bcl.v1.Path
-	12, // [12:12] is the sub-list for method output_type
-	12, // [12:12] is the sub-list for method input_type
-	12, // [12:12] is the sub-list for extension type_name
-	12, // [12:12] is the sub-list for extension extendee
-	0,  // [0:12] is the sub-list for field type_name
+	7,  // 6: j5.bcl.v1.Block.constraints:type_name -> j5.bcl.v1.Constraint
+	8,  // 7: j5.bcl.v1.Block.references:type_name -> j5.bcl.v1.Reference
+	9,  // 8: j5.bcl.v1.Block.flags:type_name -> j5.bcl.v1.Flag
+	10, // 9: j5.bcl.v1.Block.policies:type_name -> j5.bcl.v1.Policy
+	11, // 10: j5.bcl.v1.Block.normalizers:type_name -> j5.bcl.v1.Normalizer
+	12, // 11: j5.bcl.v1.Block.derived:type_name -> j5.bcl.v1.Derived
+	13, // 12: j5.bcl.v1.Block.type_attribute:type_name -> j5.bcl.v1.TypeAttribute
+	3,  // 13: j5.bcl.v1.Schema.blocks:type_name -> j5.bcl.v1.Block
+	4,  // 14: j5.bcl.v1.SchemaFile.schema:type_name -> j5.bcl.v1.Schema
+	14, // 15: j5.bcl.v1.SchemaFile.source_location:type_name -> j5.bcl.v1.SourceLocation
+	0,  // 16: j5.bcl.v1.ScalarSplit.required_fields:type_name -> j5.bcl.v1.Path
+	0,  // 17: j5.bcl.v1.ScalarSplit.optional_fields:type_name -> j5.bcl.v1.Path
+	0,  // 18: j5.bcl.v1.ScalarSplit.remainder_field:type_name -> j5.bcl.v1.Path
+	19, // [19:19] is the sub-list for method output_type
+	19, // [19:19] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
 }
 
 func init() { file_j5_bcl_v1_spec_proto_init() }
@@ -736,17 +1413,102 @@ func file_j5_bcl_v1_spec_proto_init() {
 				return nil
 			}
 		}
+		file_j5_bcl_v1_spec_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*Constraint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*Reference); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*Flag); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*Policy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*Normalizer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*Derived); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_bcl_v1_spec_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*TypeAttribute); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_j5_bcl_v1_spec_proto_msgTypes[1].OneofWrappers = []any{}
 	file_j5_bcl_v1_spec_proto_msgTypes[3].OneofWrappers = []any{}
 	file_j5_bcl_v1_spec_proto_msgTypes[6].OneofWrappers = []any{}
+	file_j5_bcl_v1_spec_proto_msgTypes[7].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_j5_bcl_v1_spec_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   14,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,356 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: test/v1/config.proto
+
+package test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Tier int32
+
+const (
+	Tier_TIER_UNSPECIFIED Tier = 0
+	Tier_TIER_GOLD        Tier = 1
+	Tier_TIER_SILVER      Tier = 2
+)
+
+// Enum value maps for Tier.
+var (
+	Tier_name = map[int32]string{
+		0: "TIER_UNSPECIFIED",
+		1: "TIER_GOLD",
+		2: "TIER_SILVER",
+	}
+	Tier_value = map[string]int32{
+		"TIER_UNSPECIFIED": 0,
+		"TIER_GOLD":        1,
+		"TIER_SILVER":      2,
+	}
+)
+
+func (x Tier) Enum() *Tier {
+	p := new(Tier)
+	*p = x
+	return p
+}
+
+func (x Tier) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Tier) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_v1_config_proto_enumTypes[0].Descriptor()
+}
+
+func (Tier) Type() protoreflect.EnumType {
+	return &file_test_v1_config_proto_enumTypes[0]
+}
+
+func (x Tier) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Tier) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Tier(num)
+	return nil
+}
+
+// Deprecated: Use Tier.Descriptor instead.
+func (Tier) EnumDescriptor() ([]byte, []int) {
+	return file_test_v1_config_proto_rawDescGZIP(), []int{0}
+}
+
+// Config is extended in the same file, as a vocabulary layered on by another
+// file would be.
+type Config struct {
+	state           protoimpl.MessageState
+	sizeCache       protoimpl.SizeCache
+	unknownFields   protoimpl.UnknownFields
+	extensionFields protoimpl.ExtensionFields
+
+	Name *string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_config_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_config_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_test_v1_config_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Config) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+type Meta struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Team *string `protobuf:"bytes,1,opt,name=team" json:"team,omitempty"`
+}
+
+func (x *Meta) Reset() {
+	*x = Meta{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_config_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Meta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Meta) ProtoMessage() {}
+
+func (x *Meta) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_config_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Meta.ProtoReflect.Descriptor instead.
+func (*Meta) Descriptor() ([]byte, []int) {
+	return file_test_v1_config_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Meta) GetTeam() string {
+	if x != nil && x.Team != nil {
+		return *x.Team
+	}
+	return ""
+}
+
+var file_test_v1_config_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*Config)(nil),
+		ExtensionType: (*string)(nil),
+		Field:         100,
+		Name:          "test.v1.owner",
+		Tag:           "bytes,100,opt,name=owner",
+		Filename:      "test/v1/config.proto",
+	},
+	{
+		ExtendedType:  (*Config)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         101,
+		Name:          "test.v1.labels",
+		Tag:           "bytes,101,rep,name=labels",
+		Filename:      "test/v1/config.proto",
+	},
+	{
+		ExtendedType:  (*Config)(nil),
+		ExtensionType: (*Meta)(nil),
+		Field:         102,
+		Name:          "test.v1.meta",
+		Tag:           "bytes,102,opt,name=meta",
+		Filename:      "test/v1/config.proto",
+	},
+	{
+		ExtendedType:  (*Config)(nil),
+		ExtensionType: (*int32)(nil),
+		Field:         103,
+		Name:          "test.v1.max_replicas",
+		Tag:           "varint,103,opt,name=max_replicas",
+		Filename:      "test/v1/config.proto",
+	},
+	{
+		ExtendedType:  (*Config)(nil),
+		ExtensionType: (*Tier)(nil),
+		Field:         104,
+		Name:          "test.v1.tier",
+		Tag:           "varint,104,opt,name=tier,enum=test.v1.Tier",
+		Filename:      "test/v1/config.proto",
+	},
+}
+
+// Extension fields to Config.
+var (
+	// optional string owner = 100;
+	E_Owner = &file_test_v1_config_proto_extTypes[0]
+	// repeated string labels = 101;
+	E_Labels = &file_test_v1_config_proto_extTypes[1]
+	// optional test.v1.Meta meta = 102;
+	E_Meta = &file_test_v1_config_proto_extTypes[2]
+	// optional int32 max_replicas = 103;
+	E_MaxReplicas = &file_test_v1_config_proto_extTypes[3]
+	// optional test.v1.Tier tier = 104;
+	E_Tier = &file_test_v1_config_proto_extTypes[4]
+)
+
+var File_test_v1_config_proto protoreflect.FileDescriptor
+
+var file_test_v1_config_proto_rawDesc = []byte{
+	0x0a, 0x14, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x22,
+	0x23, 0x0a, 0x06, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x2a, 0x05, 0x08,
+	0x64, 0x10, 0xc8, 0x01, 0x22, 0x1a, 0x0a, 0x04, 0x4d, 0x65, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04,
+	0x74, 0x65, 0x61, 0x6d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x61, 0x6d,
+	0x2a, 0x3c, 0x0a, 0x04, 0x54, 0x69, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x10, 0x54, 0x49, 0x45, 0x52,
+	0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d,
+	0x0a, 0x09, 0x54, 0x49, 0x45, 0x52, 0x5f, 0x47, 0x4f, 0x4c, 0x44, 0x10, 0x01, 0x12, 0x0f, 0x0a,
+	0x0b, 0x54, 0x49, 0x45, 0x52, 0x5f, 0x53, 0x49, 0x4c, 0x56, 0x45, 0x52, 0x10, 0x02, 0x3a, 0x25,
+	0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x0f, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76,
+	0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x64, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x6f, 0x77, 0x6e, 0x65, 0x72, 0x3a, 0x27, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12,
+	0x0f, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x18, 0x65, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x3a, 0x32,
+	0x0a, 0x04, 0x6d, 0x65, 0x74, 0x61, 0x12, 0x0f, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x66, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
+	0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x52, 0x04, 0x6d, 0x65,
+	0x74, 0x61, 0x3a, 0x32, 0x0a, 0x0c, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x73, 0x12, 0x0f, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x18, 0x67, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x6d, 0x61, 0x78, 0x52, 0x65,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x3a, 0x32, 0x0a, 0x04, 0x74, 0x69, 0x65, 0x72, 0x12, 0x0f,
+	0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18,
+	0x68, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e,
+	0x54, 0x69, 0x65, 0x72, 0x52, 0x04, 0x74, 0x69, 0x65, 0x72, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73,
+	0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x74, 0x65, 0x73, 0x74,
+	0x2f, 0x76, 0x31, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62,
+}
+
+var (
+	file_test_v1_config_proto_rawDescOnce sync.Once
+	file_test_v1_config_proto_rawDescData = file_test_v1_config_proto_rawDesc
+)
+
+func file_test_v1_config_proto_rawDescGZIP() []byte {
+	file_test_v1_config_proto_rawDescOnce.Do(func() {
+		file_test_v1_config_proto_rawDescData = protoimpl.X.CompressGZIP(file_test_v1_config_proto_rawDescData)
+	})
+	return file_test_v1_config_proto_rawDescData
+}
+
+var file_test_v1_config_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test_v1_config_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_test_v1_config_proto_goTypes = []any{
+	(Tier)(0),      // 0: test.v1.Tier
+	(*Config)(nil), // 1: test.v1.Config
+	(*Meta)(nil),   // 2: test.v1.Meta
+}
+var file_test_v1_config_proto_depIdxs = []int32{
+	1, // 0: test.v1.owner:extendee -> test.v1.Config
+	1, // 1: test.v1.labels:extendee -> test.v1.Config
+	1, // 2: test.v1.meta:extendee -> test.v1.Config
+	1, // 3: test.v1.max_replicas:extendee -> test.v1.Config
+	1, // 4: test.v1.tier:extendee -> test.v1.Config
+	2, // 5: test.v1.meta:type_name -> test.v1.Meta
+	0, // 6: test.v1.tier:type_name -> test.v1.Tier
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	5, // [5:7] is the sub-list for extension type_name
+	0, // [0:5] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_test_v1_config_proto_init() }
+func file_test_v1_config_proto_init() {
+	if File_test_v1_config_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_test_v1_config_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Config); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			case 3:
+				return &v.extensionFields
+			default:
+				return nil
+			}
+		}
+		file_test_v1_config_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Meta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_test_v1_config_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 5,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_v1_config_proto_goTypes,
+		DependencyIndexes: file_test_v1_config_proto_depIdxs,
+		EnumInfos:         file_test_v1_config_proto_enumTypes,
+		MessageInfos:      file_test_v1_config_proto_msgTypes,
+		ExtensionInfos:    file_test_v1_config_proto_extTypes,
+	}.Build()
+	File_test_v1_config_proto = out.File
+	file_test_v1_config_proto_rawDesc = nil
+	file_test_v1_config_proto_goTypes = nil
+	file_test_v1_config_proto_depIdxs = nil
+}
@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: test/v1/credentials.proto
+
+package test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Credentials is a message with fields marked debug_redact.
+type Credentials struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User     string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Password string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Tokens   []string `protobuf:"bytes,3,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	Pin      int32    `protobuf:"varint,4,opt,name=pin,proto3" json:"pin,omitempty"`
+}
+
+func (x *Credentials) Reset() {
+	*x = Credentials{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_credentials_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Credentials) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Credentials) ProtoMessage() {}
+
+func (x *Credentials) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_credentials_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Credentials.ProtoReflect.Descriptor instead.
+func (*Credentials) Descriptor() ([]byte, []int) {
+	return file_test_v1_credentials_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Credentials) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Credentials) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *Credentials) GetTokens() []string {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *Credentials) GetPin() int32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+var File_test_v1_credentials_proto protoreflect.FileDescriptor
+
+var file_test_v1_credentials_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e,
+	0x74, 0x69, 0x61, 0x6c, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x74, 0x65, 0x73,
+	0x74, 0x2e, 0x76, 0x31, 0x22, 0x76, 0x0a, 0x0b, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77,
+	0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x03, 0x80, 0x01, 0x01, 0x52, 0x08,
+	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x12, 0x1b, 0x0a, 0x06, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x42, 0x03, 0x80, 0x01, 0x01, 0x52, 0x06, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12, 0x15, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x05, 0x42, 0x03, 0x80, 0x01, 0x01, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x42, 0x2f, 0x5a, 0x2d,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f,
+	0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x74, 0x65,
+	0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_test_v1_credentials_proto_rawDescOnce sync.Once
+	file_test_v1_credentials_proto_rawDescData = file_test_v1_credentials_proto_rawDesc
+)
+
+func file_test_v1_credentials_proto_rawDescGZIP() []byte {
+	file_test_v1_credentials_proto_rawDescOnce.Do(func() {
+		file_test_v1_credentials_proto_rawDescData = protoimpl.X.CompressGZIP(file_test_v1_credentials_proto_rawDescData)
+	})
+	return file_test_v1_credentials_proto_rawDescData
+}
+
+var file_test_v1_credentials_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_test_v1_credentials_proto_goTypes = []any{
+	(*Credentials)(nil), // 0: test.v1.Credentials
+}
+var file_test_v1_credentials_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_test_v1_credentials_proto_init() }
+func file_test_v1_credentials_proto_init() {
+	if File_test_v1_credentials_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_test_v1_credentials_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Credentials); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_test_v1_credentials_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_v1_credentials_proto_goTypes,
+		DependencyIndexes: file_test_v1_credentials_proto_depIdxs,
+		MessageInfos:      file_test_v1_credentials_proto_msgTypes,
+	}.Build()
+	File_test_v1_credentials_proto = out.File
+	file_test_v1_credentials_proto_rawDesc = nil
+	file_test_v1_credentials_proto_goTypes = nil
+	file_test_v1_credentials_proto_depIdxs = nil
+}
@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: test/v1/defaults.proto
+
+package test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Level int32
+
+const (
+	Level_LEVEL_UNSPECIFIED Level = 0
+	Level_LEVEL_HIGH        Level = 1
+)
+
+// Enum value maps for Level.
+var (
+	Level_name = map[int32]string{
+		0: "LEVEL_UNSPECIFIED",
+		1: "LEVEL_HIGH",
+	}
+	Level_value = map[string]int32{
+		"LEVEL_UNSPECIFIED": 0,
+		"LEVEL_HIGH":        1,
+	}
+)
+
+func (x Level) Enum() *Level {
+	p := new(Level)
+	*p = x
+	return p
+}
+
+func (x Level) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Level) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_v1_defaults_proto_enumTypes[0].Descriptor()
+}
+
+func (Level) Type() protoreflect.EnumType {
+	return &file_test_v1_defaults_proto_enumTypes[0]
+}
+
+func (x Level) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Level) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Level(num)
+	return nil
+}
+
+// Deprecated: Use Level.Descriptor instead.
+func (Level) EnumDescriptor() ([]byte, []int) {
+	return file_test_v1_defaults_proto_rawDescGZIP(), []int{0}
+}
+
+// Defaults is a message of proto2 fields with default values.
+type Defaults struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Region   *string `protobuf:"bytes,1,opt,name=region,def=eu-west-1" json:"region,omitempty"`
+	Replicas *int32  `protobuf:"varint,2,opt,name=replicas,def=3" json:"replicas,omitempty"`
+	Enabled  *bool   `protobuf:"varint,3,opt,name=enabled,def=1" json:"enabled,omitempty"`
+	Level    *Level  `protobuf:"varint,4,opt,name=level,enum=test.v1.Level,def=1" json:"level,omitempty"`
+	Owner    *string `protobuf:"bytes,5,opt,name=owner" json:"owner,omitempty"`
+}
+
+// Default values for Defaults fields.
+const (
+	Default_Defaults_Region   = string("eu-west-1")
+	Default_Defaults_Replicas = int32(3)
+	Default_Defaults_Enabled  = bool(true)
+	Default_Defaults_Level    = Level_LEVEL_HIGH
+)
+
+func (x *Defaults) Reset() {
+	*x = Defaults{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_defaults_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Defaults) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Defaults) ProtoMessage() {}
+
+func (x *Defaults) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_defaults_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Defaults.ProtoReflect.Descriptor instead.
+func (*Defaults) Descriptor() ([]byte, []int) {
+	return file_test_v1_defaults_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Defaults) GetRegion() string {
+	if x != nil && x.Region != nil {
+		return *x.Region
+	}
+	return Default_Defaults_Region
+}
+
+func (x *Defaults) GetReplicas() int32 {
+	if x != nil && x.Replicas != nil {
+		return *x.Replicas
+	}
+	return Default_Defaults_Replicas
+}
+
+func (x *Defaults) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return Default_Defaults_Enabled
+}
+
+func (x *Defaults) GetLevel() Level {
+	if x != nil && x.Level != nil {
+		return *x.Level
+	}
+	return Default_Defaults_Level
+}
+
+func (x *Defaults) GetOwner() string {
+	if x != nil && x.Owner != nil {
+		return *x.Owner
+	}
+	return ""
+}
+
+var File_test_v1_defaults_proto protoreflect.FileDescriptor
+
+var file_test_v1_defaults_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c,
+	0x74, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76,
+	0x31, 0x22, 0xb4, 0x01, 0x0a, 0x08, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x21,
+	0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x3a, 0x09,
+	0x65, 0x75, 0x2d, 0x77, 0x65, 0x73, 0x74, 0x2d, 0x31, 0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f,
+	0x6e, 0x12, 0x1d, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x3a, 0x01, 0x33, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73,
+	0x12, 0x1e, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x3a, 0x04, 0x74, 0x72, 0x75, 0x65, 0x52, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64,
+	0x12, 0x30, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x0e, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x3a,
+	0x0a, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x48, 0x49, 0x47, 0x48, 0x52, 0x05, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x2a, 0x2e, 0x0a, 0x05, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x15, 0x0a, 0x11, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45,
+	0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0e, 0x0a, 0x0a, 0x4c, 0x45, 0x56, 0x45,
+	0x4c, 0x5f, 0x48, 0x49, 0x47, 0x48, 0x10, 0x01, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62,
+	0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76,
+	0x31, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62,
+}
+
+var (
+	file_test_v1_defaults_proto_rawDescOnce sync.Once
+	file_test_v1_defaults_proto_rawDescData = file_test_v1_defaults_proto_rawDesc
+)
+
+func file_test_v1_defaults_proto_rawDescGZIP() []byte {
+	file_test_v1_defaults_proto_rawDescOnce.Do(func() {
+		file_test_v1_defaults_proto_rawDescData = protoimpl.X.CompressGZIP(file_test_v1_defaults_proto_rawDescData)
+	})
+	return file_test_v1_defaults_proto_rawDescData
+}
+
+var file_test_v1_defaults_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test_v1_defaults_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_test_v1_defaults_proto_goTypes = []any{
+	(Level)(0),       // 0: test.v1.Level
+	(*Defaults)(nil), // 1: test.v1.Defaults
+}
+var file_test_v1_defaults_proto_depIdxs = []int32{
+	0, // 0: test.v1.Defaults.level:type_name -> test.v1.Level
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_test_v1_defaults_proto_init() }
+func file_test_v1_defaults_proto_init() {
+	if File_test_v1_defaults_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_test_v1_defaults_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Defaults); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_test_v1_defaults_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_v1_defaults_proto_goTypes,
+		DependencyIndexes: file_test_v1_defaults_proto_depIdxs,
+		EnumInfos:         file_test_v1_defaults_proto_enumTypes,
+		MessageInfos:      file_test_v1_defaults_proto_msgTypes,
+	}.Build()
+	File_test_v1_defaults_proto = out.File
+	file_test_v1_defaults_proto_rawDesc = nil
+	file_test_v1_defaults_proto_goTypes = nil
+	file_test_v1_defaults_proto_depIdxs = nil
+}
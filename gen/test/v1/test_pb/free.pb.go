@@ -0,0 +1,202 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: test/v1/free.proto
+
+package test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FreeForm has Struct, Value and ListValue fields, which take values of any
+// shape.
+type FreeForm struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Extra  *structpb.Value     `protobuf:"bytes,1,opt,name=extra,proto3" json:"extra,omitempty"`
+	Meta   *structpb.Struct    `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	Items  *structpb.ListValue `protobuf:"bytes,3,opt,name=items,proto3" json:"items,omitempty"`
+	Shape  *structpb.Value     `protobuf:"bytes,4,opt,name=shape,proto3" json:"shape,omitempty"`
+	Nested *FreeForm           `protobuf:"bytes,5,opt,name=nested,proto3" json:"nested,omitempty"`
+}
+
+func (x *FreeForm) Reset() {
+	*x = FreeForm{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_free_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FreeForm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FreeForm) ProtoMessage() {}
+
+func (x *FreeForm) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_free_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FreeForm.ProtoReflect.Descriptor instead.
+func (*FreeForm) Descriptor() ([]byte, []int) {
+	return file_test_v1_free_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FreeForm) GetExtra() *structpb.Value {
+	if x != nil {
+		return x.Extra
+	}
+	return nil
+}
+
+func (x *FreeForm) GetMeta() *structpb.Struct {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *FreeForm) GetItems() *structpb.ListValue {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *FreeForm) GetShape() *structpb.Value {
+	if x != nil {
+		return x.Shape
+	}
+	return nil
+}
+
+func (x *FreeForm) GetNested() *FreeForm {
+	if x != nil {
+		return x.Nested
+	}
+	return nil
+}
+
+var File_test_v1_free_proto protoreflect.FileDescriptor
+
+var file_test_v1_free_proto_rawDesc = []byte{
+	0x0a, 0x12, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x66, 0x72, 0x65, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x1a, 0x1c, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x73,
+	0x74, 0x72, 0x75, 0x63, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf0, 0x01, 0x0a, 0x08,
+	0x46, 0x72, 0x65, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x12, 0x2c, 0x0a, 0x05, 0x65, 0x78, 0x74, 0x72,
+	0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52,
+	0x05, 0x65, 0x78, 0x74, 0x72, 0x61, 0x12, 0x2b, 0x0a, 0x04, 0x6d, 0x65, 0x74, 0x61, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x75, 0x63, 0x74, 0x52, 0x04, 0x6d,
+	0x65, 0x74, 0x61, 0x12, 0x30, 0x0a, 0x05, 0x69, 0x74, 0x65, 0x6d, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05,
+	0x69, 0x74, 0x65, 0x6d, 0x73, 0x12, 0x2c, 0x0a, 0x05, 0x73, 0x68, 0x61, 0x70, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x73, 0x68,
+	0x61, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x06, 0x6e, 0x65, 0x73, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72,
+	0x65, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x52, 0x06, 0x6e, 0x65, 0x73, 0x74, 0x65, 0x64, 0x42, 0x2f,
+	0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e,
+	0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f,
+	0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_test_v1_free_proto_rawDescOnce sync.Once
+	file_test_v1_free_proto_rawDescData = file_test_v1_free_proto_rawDesc
+)
+
+func file_test_v1_free_proto_rawDescGZIP() []byte {
+	file_test_v1_free_proto_rawDescOnce.Do(func() {
+		file_test_v1_free_proto_rawDescData = protoimpl.X.CompressGZIP(file_test_v1_free_proto_rawDescData)
+	})
+	return file_test_v1_free_proto_rawDescData
+}
+
+var file_test_v1_free_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_test_v1_free_proto_goTypes = []any{
+	(*FreeForm)(nil),           // 0: test.v1.FreeForm
+	(*structpb.Value)(nil),     // 1: google.protobuf.Value
+	(*structpb.Struct)(nil),    // 2: google.protobuf.Struct
+	(*structpb.ListValue)(nil), // 3: google.protobuf.ListValue
+}
+var file_test_v1_free_proto_depIdxs = []int32{
+	1, // 0: test.v1.FreeForm.extra:type_name -> google.protobuf.Value
+	2, // 1: test.v1.FreeForm.meta:type_name -> google.protobuf.Struct
+	3, // 2: test.v1.FreeForm.items:type_name -> google.protobuf.ListValue
+	1, // 3: test.v1.FreeForm.shape:type_name -> google.protobuf.Value
+	0, // 4: test.v1.FreeForm.nested:type_name -> test.v1.FreeForm
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_test_v1_free_proto_init() }
+func file_test_v1_free_proto_init() {
+	if File_test_v1_free_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_test_v1_free_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*FreeForm); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_test_v1_free_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_v1_free_proto_goTypes,
+		DependencyIndexes: file_test_v1_free_proto_depIdxs,
+		MessageInfos:      file_test_v1_free_proto_msgTypes,
+	}.Build()
+	File_test_v1_free_proto = out.File
+	file_test_v1_free_proto_rawDesc = nil
+	file_test_v1_free_proto_goTypes = nil
+	file_test_v1_free_proto_depIdxs = nil
+}
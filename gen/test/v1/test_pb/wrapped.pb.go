@@ -0,0 +1,302 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: test/v1/wrapped.proto
+
+package test_pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Wrapped has google.protobuf wrapper fields at the root, in a nested block,
+// repeated and as map values.
+type Wrapped struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    *wrapperspb.StringValue           `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Count   *wrapperspb.Int64Value            `protobuf:"bytes,2,opt,name=count,proto3" json:"count,omitempty"`
+	Enabled *wrapperspb.BoolValue             `protobuf:"bytes,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Ratio   *wrapperspb.DoubleValue           `protobuf:"bytes,4,opt,name=ratio,proto3" json:"ratio,omitempty"`
+	Tags    []*wrapperspb.StringValue         `protobuf:"bytes,5,rep,name=tags,proto3" json:"tags,omitempty"`
+	Limits  map[string]*wrapperspb.Int32Value `protobuf:"bytes,6,rep,name=limits,proto3" json:"limits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Inner   *Inner                            `protobuf:"bytes,7,opt,name=inner,proto3" json:"inner,omitempty"`
+}
+
+func (x *Wrapped) Reset() {
+	*x = Wrapped{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_wrapped_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Wrapped) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wrapped) ProtoMessage() {}
+
+func (x *Wrapped) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_wrapped_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wrapped.ProtoReflect.Descriptor instead.
+func (*Wrapped) Descriptor() ([]byte, []int) {
+	return file_test_v1_wrapped_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Wrapped) GetName() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *Wrapped) GetCount() *wrapperspb.Int64Value {
+	if x != nil {
+		return x.Count
+	}
+	return nil
+}
+
+func (x *Wrapped) GetEnabled() *wrapperspb.BoolValue {
+	if x != nil {
+		return x.Enabled
+	}
+	return nil
+}
+
+func (x *Wrapped) GetRatio() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.Ratio
+	}
+	return nil
+}
+
+func (x *Wrapped) GetTags() []*wrapperspb.StringValue {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Wrapped) GetLimits() map[string]*wrapperspb.Int32Value {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *Wrapped) GetInner() *Inner {
+	if x != nil {
+		return x.Inner
+	}
+	return nil
+}
+
+type Inner struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Label *wrapperspb.StringValue `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+}
+
+func (x *Inner) Reset() {
+	*x = Inner{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_v1_wrapped_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Inner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Inner) ProtoMessage() {}
+
+func (x *Inner) ProtoReflect() protoreflect.Message {
+	mi := &file_test_v1_wrapped_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Inner.ProtoReflect.Descriptor instead.
+func (*Inner) Descriptor() ([]byte, []int) {
+	return file_test_v1_wrapped_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Inner) GetLabel() *wrapperspb.StringValue {
+	if x != nil {
+		return x.Label
+	}
+	return nil
+}
+
+var File_test_v1_wrapped_proto protoreflect.FileDescriptor
+
+var file_test_v1_wrapped_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65,
+	0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31,
+	0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2f, 0x77, 0x72, 0x61, 0x70, 0x70, 0x65, 0x72, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0xbe, 0x03, 0x0a, 0x07, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x12, 0x30, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72,
+	0x69, 0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x31,
+	0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x49, 0x6e, 0x74, 0x36, 0x34, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e,
+	0x74, 0x12, 0x34, 0x0a, 0x07, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x42, 0x6f, 0x6f, 0x6c, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x07,
+	0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12, 0x32, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x12, 0x30, 0x0a, 0x04, 0x74,
+	0x61, 0x67, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x34, 0x0a,
+	0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x72, 0x61, 0x70, 0x70, 0x65, 0x64, 0x2e,
+	0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x69, 0x6d,
+	0x69, 0x74, 0x73, 0x12, 0x24, 0x0a, 0x05, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x74, 0x65, 0x73, 0x74, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x6e,
+	0x65, 0x72, 0x52, 0x05, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x1a, 0x56, 0x0a, 0x0b, 0x4c, 0x69, 0x6d,
+	0x69, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x31, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x49, 0x6e, 0x74, 0x33,
+	0x32, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x3b, 0x0a, 0x05, 0x49, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x32, 0x0a, 0x05, 0x6c, 0x61,
+	0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x53, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x42, 0x2f,
+	0x5a, 0x2d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e,
+	0x74, 0x6f, 0x70, 0x73, 0x2f, 0x62, 0x63, 0x6c, 0x2e, 0x67, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f,
+	0x74, 0x65, 0x73, 0x74, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x70, 0x62, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_test_v1_wrapped_proto_rawDescOnce sync.Once
+	file_test_v1_wrapped_proto_rawDescData = file_test_v1_wrapped_proto_rawDesc
+)
+
+func file_test_v1_wrapped_proto_rawDescGZIP() []byte {
+	file_test_v1_wrapped_proto_rawDescOnce.Do(func() {
+		file_test_v1_wrapped_proto_rawDescData = protoimpl.X.CompressGZIP(file_test_v1_wrapped_proto_rawDescData)
+	})
+	return file_test_v1_wrapped_proto_rawDescData
+}
+
+var file_test_v1_wrapped_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_test_v1_wrapped_proto_goTypes = []any{
+	(*Wrapped)(nil),                // 0: test.v1.Wrapped
+	(*Inner)(nil),                  // 1: test.v1.Inner
+	nil,                            // 2: test.v1.Wrapped.LimitsEntry
+	(*wrapperspb.StringValue)(nil), // 3: google.protobuf.StringValue
+	(*wrapperspb.Int64Value)(nil),  // 4: google.protobuf.Int64Value
+	(*wrapperspb.BoolValue)(nil),   // 5: google.protobuf.BoolValue
+	(*wrapperspb.DoubleValue)(nil), // 6: google.protobuf.DoubleValue
+	(*wrapperspb.Int32Value)(nil),  // 7: google.protobuf.Int32Value
+}
+var file_test_v1_wrapped_proto_depIdxs = []int32{
+	3, // 0: test.v1.Wrapped.name:type_name -> google.protobuf.StringValue
+	4, // 1: test.v1.Wrapped.count:type_name -> google.protobuf.Int64Value
+	5, // 2: test.v1.Wrapped.enabled:type_name -> google.protobuf.BoolValue
+	6, // 3: test.v1.Wrapped.ratio:type_name -> google.protobuf.DoubleValue
+	3, // 4: test.v1.Wrapped.tags:type_name -> google.protobuf.StringValue
+	2, // 5: test.v1.Wrapped.limits:type_name -> test.v1.Wrapped.LimitsEntry
+	1, // 6: test.v1.Wrapped.inner:type_name -> test.v1.Inner
+	3, // 7: test.v1.Inner.label:type_name -> google.protobuf.StringValue
+	7, // 8: test.v1.Wrapped.LimitsEntry.value:type_name -> google.protobuf.Int32Value
+	9, // [9:9] is the sub-list for method output_type
+	9, // [9:9] is the sub-list for method input_type
+	9, // [9:9] is the sub-list for extension type_name
+	9, // [9:9] is the sub-list for extension extendee
+	0, // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_test_v1_wrapped_proto_init() }
+func file_test_v1_wrapped_proto_init() {
+	if File_test_v1_wrapped_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_test_v1_wrapped_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Wrapped); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_test_v1_wrapped_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Inner); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_test_v1_wrapped_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_test_v1_wrapped_proto_goTypes,
+		DependencyIndexes: file_test_v1_wrapped_proto_depIdxs,
+		MessageInfos:      file_test_v1_wrapped_proto_msgTypes,
+	}.Build()
+	File_test_v1_wrapped_proto = out.File
+	file_test_v1_wrapped_proto_rawDesc = nil
+	file_test_v1_wrapped_proto_goTypes = nil
+	file_test_v1_wrapped_proto_depIdxs = nil
+}
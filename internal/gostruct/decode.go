@@ -0,0 +1,500 @@
+// Package gostruct decodes a parsed BCL file onto plain Go structs, using
+// `bcl` struct tags in place of a schema.
+package gostruct
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// Decode sets the fields of the struct pointed to by v from the body. Errors
+// are collected for the whole body rather than stopping at the first.
+func Decode(body parser.Body, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode target must be a non-nil pointer to a struct, got %T", v)
+	}
+
+	dd := &decoder{}
+	dd.body(rv.Elem(), body)
+	if len(dd.errors) > 0 {
+		return dd.errors
+	}
+	return nil
+}
+
+type decoder struct {
+	errors errpos.Errors
+}
+
+func (dd *decoder) errorf(source parser.SourceNode, code errpos.Code, format string, args ...interface{}) {
+	pos := source.Position()
+	dd.errors = append(dd.errors, &errpos.Err{
+		Pos:  &pos,
+		Code: code,
+		Err:  fmt.Errorf(format, args...),
+	})
+}
+
+func (dd *decoder) addError(source parser.SourceNode, code errpos.Code, err error) {
+	pos := source.Position()
+	if code == "" {
+		code = errpos.GetErrorCode(err)
+	}
+	dd.errors = append(dd.errors, &errpos.Err{
+		Pos:  &pos,
+		Code: code,
+		Err:  err,
+	})
+}
+
+func (dd *decoder) body(target reflect.Value, body parser.Body) {
+	info := structInfoOf(target.Type())
+	for _, stmt := range body.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			dd.assign(target, stmt)
+		case *parser.Block:
+			if dd.keywordBlock(info, &stmt.BlockHeader) {
+				continue
+			}
+			dd.block(target, &stmt.BlockHeader, &stmt.Body)
+		case *parser.Declaration:
+			if dd.keywordBlock(info, &stmt.BlockHeader) {
+				continue
+			}
+			dd.block(target, &stmt.BlockHeader, nil)
+		case *parser.Description:
+			dd.description(target, info, stmt.SourceNode, stmt.Value)
+		}
+	}
+}
+
+// unsupportedKeywords start blocks which only a schema gives a meaning to.
+var unsupportedKeywords = []string{"with", "defaults"}
+
+// keywordBlock reports a with or defaults block as unsupported, rather than
+// as an unknown field, unless the struct has a field of that name.
+func (dd *decoder) keywordBlock(info *structInfo, header *parser.BlockHeader) bool {
+	idents := header.Type.Idents
+	if len(idents) != 1 || idents[0].Quoted() || !slices.Contains(unsupportedKeywords, idents[0].Value) {
+		return false
+	}
+	if _, ok := info.byName[idents[0].Value]; ok {
+		return false
+	}
+	dd.errorf(idents[0].SourceNode, errpos.CodeUnknownBlock, "%s blocks are not supported without a schema", idents[0].Value)
+	return true
+}
+
+func (dd *decoder) description(target reflect.Value, info *structInfo, source parser.SourceNode, value string) {
+	if info.description == nil {
+		dd.errorf(source, errpos.CodeNoDescription, "%s has no description field", target.Type())
+		return
+	}
+	field := target.FieldByIndex(info.description.index)
+	if field.Kind() != reflect.String {
+		dd.errorf(source, errpos.CodeSchemaError, "description field %s is not a string", info.description.goName)
+		return
+	}
+	field.SetString(value)
+}
+
+// walkPath follows all but the last ident of the reference, returning the
+// container holding the last.
+func (dd *decoder) walkPath(target reflect.Value, ref parser.Reference) (reflect.Value, bool) {
+	for _, ident := range ref.Idents[:len(ref.Idents)-1] {
+		child, ok := dd.child(target, ident)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		child = deref(child)
+		if child.Kind() != reflect.Struct && child.Kind() != reflect.Map {
+			dd.errorf(ident.SourceNode, errpos.CodeNotContainer, "%s is not a block", ident.Value)
+			return reflect.Value{}, false
+		}
+		target = child
+	}
+	return target, true
+}
+
+// child returns the settable field or map entry for the ident. Map entries are
+// returned as a new value which is stored back by setMapEntry.
+func (dd *decoder) child(target reflect.Value, ident parser.Ident) (reflect.Value, bool) {
+	target = deref(target)
+	switch target.Kind() {
+	case reflect.Struct:
+		info := structInfoOf(target.Type())
+		field, ok := info.byName[ident.Value]
+		if !ok {
+			dd.errorf(ident.SourceNode, errpos.CodeUnknownBlock, "no field %q in %s, available: %s", ident.Value, target.Type(), strings.Join(info.names, ", "))
+			return reflect.Value{}, false
+		}
+		return target.FieldByIndex(field.index), true
+
+	case reflect.Map:
+		key, ok := dd.mapKey(target.Type(), ident.Value, ident.SourceNode)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		elemType := target.Type().Elem()
+		if elemType.Kind() != reflect.Pointer && elemType.Kind() != reflect.Map {
+			// map values are not addressable, so nested fields can only be
+			// set through a reference.
+			dd.errorf(ident.SourceNode, errpos.CodeSchemaError, "%s needs pointer or map values to set nested fields", target.Type())
+			return reflect.Value{}, false
+		}
+		elem := target.MapIndex(key)
+		if !elem.IsValid() {
+			elem = reflect.New(elemType).Elem()
+			if elemType.Kind() == reflect.Pointer {
+				elem.Set(reflect.New(elemType.Elem()))
+			} else {
+				elem.Set(reflect.MakeMap(elemType))
+			}
+			target.SetMapIndex(key, elem)
+		}
+		return elem, true
+	}
+
+	dd.errorf(ident.SourceNode, errpos.CodeNotContainer, "%s is not a block", ident.Value)
+	return reflect.Value{}, false
+}
+
+// mapKey converts a key written in the file to the key type of the map,
+// which must be a string type.
+func (dd *decoder) mapKey(mapType reflect.Type, key string, src parser.SourceNode) (reflect.Value, bool) {
+	if mapType.Key().Kind() != reflect.String {
+		dd.errorf(src, errpos.CodeSchemaError, "map keys must be strings, got %s", mapType.Key())
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(key).Convert(mapType.Key()), true
+}
+
+func (dd *decoder) assign(target reflect.Value, stmt *parser.Assignment) {
+	container, ok := dd.walkPath(target, stmt.Key)
+	if !ok {
+		return
+	}
+	last := stmt.Key.Idents[len(stmt.Key.Idents)-1]
+
+	container = deref(container)
+	if container.Kind() == reflect.Map {
+		key, ok := dd.mapKey(container.Type(), last.Value, last.SourceNode)
+		if !ok {
+			return
+		}
+		if container.IsNil() {
+			container.Set(reflect.MakeMap(container.Type()))
+		}
+		entry := reflect.New(container.Type().Elem()).Elem()
+		if existing := container.MapIndex(key); existing.IsValid() {
+			if !stmt.Append {
+				dd.errorf(last.SourceNode, errpos.CodeAlreadySet, "%s is already set", last.Value)
+				return
+			}
+			entry.Set(existing)
+		}
+		if !dd.setValue(entry, stmt.Value, stmt.Append) {
+			return
+		}
+		container.SetMapIndex(key, entry)
+		return
+	}
+
+	field, ok := dd.child(container, last)
+	if !ok {
+		return
+	}
+	if !stmt.Append && !field.IsZero() && field.Kind() != reflect.Slice {
+		dd.errorf(last.SourceNode, errpos.CodeAlreadySet, "%s is already set", last.Value)
+		return
+	}
+	dd.setValue(field, stmt.Value, stmt.Append)
+}
+
+func (dd *decoder) setValue(field reflect.Value, value parser.Value, appendValue bool) bool {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		var values []parser.ASTValue
+		if value.IsArray() {
+			values, _ = value.AsArray()
+		} else if value.Token().Type != parser.INVALID {
+			// a scalar sets or appends a single element, [] has no token.
+			values = []parser.ASTValue{value}
+		}
+		if !appendValue {
+			field.Set(reflect.MakeSlice(field.Type(), 0, len(values)))
+		}
+		for _, val := range values {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if !dd.setScalar(elem, val) {
+				return false
+			}
+			field.Set(reflect.Append(field, elem))
+		}
+		return true
+	}
+
+	if appendValue {
+		dd.errorf(value.SourceNode, errpos.CodeTypeMismatch, "+= requires a list field, got %s", field.Type())
+		return false
+	}
+	if value.IsArray() {
+		dd.errorf(value.SourceNode, errpos.CodeTypeMismatch, "expected a single value for %s, got an array", field.Type())
+		return false
+	}
+	return dd.setScalar(field, value)
+}
+
+func (dd *decoder) setScalar(field reflect.Value, value parser.ASTValue) bool {
+	pos := value.Position()
+	source := parser.SourceNode{Start: pos.Start, End: pos.End}
+
+	if val, ok := value.(parser.Value); ok {
+		if _, ok := val.Call(); ok {
+			dd.errorf(source, errpos.CodeTypeMismatch, "function calls are not supported without a schema")
+			return false
+		}
+		if _, ok := val.Splat(); ok {
+			dd.errorf(source, errpos.CodeTypeMismatch, "expanding references is not supported without a schema")
+			return false
+		}
+	}
+
+	var err error
+	switch field.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if !dd.setScalar(elem.Elem(), value) {
+			return false
+		}
+		field.Set(elem)
+		return true
+
+	case reflect.String:
+		var val string
+		if val, err = value.AsString(); err == nil {
+			field.SetString(val)
+		}
+
+	case reflect.Bool:
+		var val bool
+		if val, err = value.AsBool(); err == nil {
+			field.SetBool(val)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var val int64
+		if val, err = value.AsInt(field.Type().Bits()); err == nil {
+			field.SetInt(val)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var val uint64
+		if val, err = value.AsUint(field.Type().Bits()); err == nil {
+			field.SetUint(val)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var val float64
+		if val, err = value.AsFloat(field.Type().Bits()); err == nil {
+			field.SetFloat(val)
+		}
+
+	default:
+		dd.errorf(source, errpos.CodeSchemaError, "unsupported field type %s", field.Type())
+		return false
+	}
+
+	if err != nil {
+		numErr := &strconv.NumError{}
+		if errors.As(err, &numErr) {
+			err = fmt.Errorf("%s is not a valid %s: %w", numErr.Num, field.Type(), numErr.Err)
+		}
+		code := errpos.GetErrorCode(err)
+		if code == "" {
+			code = errpos.CodeInvalidValue
+		}
+		dd.addError(source, code, err)
+		return false
+	}
+	return true
+}
+
+func (dd *decoder) block(target reflect.Value, header *parser.BlockHeader, body *parser.Body) {
+	container, ok := dd.walkPath(target, header.Type)
+	if !ok {
+		return
+	}
+	last := header.Type.Idents[len(header.Type.Idents)-1]
+	tags := header.Tags
+
+	field, ok := dd.child(container, last)
+	if !ok {
+		return
+	}
+
+	var elem reflect.Value
+	switch field.Kind() {
+	case reflect.Slice:
+		elem = reflect.New(field.Type().Elem()).Elem()
+		defer func() {
+			field.Set(reflect.Append(field, elem))
+		}()
+
+	case reflect.Map:
+		if len(tags) == 0 {
+			dd.errorf(header.SourceNode, errpos.CodeExpectedTag, "%s requires a key", last.Value)
+			return
+		}
+		key, err := tags[0].AsString()
+		if err != nil {
+			dd.addError(tags[0].SourceNode, errpos.CodeTypeMismatch, err)
+			return
+		}
+		keyValue, ok := dd.mapKey(field.Type(), key, tags[0].SourceNode)
+		if !ok {
+			return
+		}
+		tags = tags[1:]
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		if field.MapIndex(keyValue).IsValid() {
+			dd.errorf(header.SourceNode, errpos.CodeAlreadySet, "%s %q is already set", last.Value, key)
+			return
+		}
+		elem = reflect.New(field.Type().Elem()).Elem()
+		defer func() {
+			field.SetMapIndex(keyValue, elem)
+		}()
+
+	default:
+		elem = field
+	}
+
+	if elem.Kind() == reflect.Pointer {
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+	}
+	blockValue := deref(elem)
+	if blockValue.Kind() != reflect.Struct {
+		dd.errorf(last.SourceNode, errpos.CodeNotContainer, "%s is not a block", last.Value)
+		return
+	}
+
+	info := structInfoOf(blockValue.Type())
+	for idx, tag := range tags {
+		if idx >= len(info.tags) {
+			dd.errorf(tag.SourceNode, errpos.CodeUnexpectedTag, "unexpected tag for %s", last.Value)
+			break
+		}
+		tagField := blockValue.FieldByIndex(info.tags[idx].index)
+		if !dd.setScalar(tagField, tag) {
+			continue
+		}
+	}
+	if len(tags) < len(info.tags) {
+		for _, missing := range info.tags[len(tags):] {
+			if !missing.optional {
+				dd.errorf(header.SourceNode, errpos.CodeExpectedTag, "%s requires a %s tag", last.Value, missing.name)
+			}
+		}
+	}
+
+	if header.Description != nil {
+		dd.description(blockValue, info, header.Description.SourceNode, header.Description.Value)
+	}
+	if body != nil {
+		dd.body(blockValue, *body)
+	}
+}
+
+func deref(val reflect.Value) reflect.Value {
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+		val = val.Elem()
+	}
+	return val
+}
+
+type fieldInfo struct {
+	name     string
+	goName   string
+	index    []int
+	optional bool
+}
+
+type structInfo struct {
+	byName      map[string]*fieldInfo
+	names       []string
+	tags        []*fieldInfo
+	description *fieldInfo
+}
+
+// structInfoOf reads the `bcl` struct tags. The tag is the name, then options:
+// tag for a block header tag, in field order, optional to allow the tag to be
+// omitted, and description for the field which takes the block description.
+// Fields without a tag use their name with a lower case first letter, "-"
+// skips the field.
+func structInfoOf(tt reflect.Type) *structInfo {
+	info := &structInfo{
+		byName: map[string]*fieldInfo{},
+	}
+	for idx := 0; idx < tt.NumField(); idx++ {
+		field := tt.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("bcl")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = lowerFirst(field.Name)
+		}
+		fi := &fieldInfo{
+			name:   name,
+			goName: field.Name,
+			index:  field.Index,
+		}
+		isTag := false
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "tag":
+				isTag = true
+			case "optional":
+				fi.optional = true
+			case "description":
+				info.description = fi
+			}
+		}
+		if isTag {
+			info.tags = append(info.tags, fi)
+		}
+		info.byName[name] = fi
+		info.names = append(info.names, name)
+	}
+	return info
+}
+
+func lowerFirst(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
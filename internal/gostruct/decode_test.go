@@ -0,0 +1,129 @@
+package gostruct
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name    string             `bcl:"name"`
+	Enabled *bool              `bcl:"enabled"`
+	Ports   []int              `bcl:"port"`
+	Labels  map[string]string  `bcl:"label"`
+	Servers []*testServer      `bcl:"server"`
+	Regions map[string]*region `bcl:"region"`
+	Shards  map[int]*region    `bcl:"shard"`
+	Counts  map[int]int        `bcl:"count"`
+	Skipped string             `bcl:"-"`
+}
+
+type testServer struct {
+	Host        string  `bcl:"host,tag"`
+	Alias       string  `bcl:"alias,tag,optional"`
+	Weight      float64 `bcl:"weight"`
+	Description string  `bcl:",description"`
+}
+
+type region struct {
+	Zones []string
+}
+
+func decodeString(t *testing.T, input string, v any) error {
+	t.Helper()
+	tree, err := parser.ParseFile(input, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Decode(tree.Body, v)
+}
+
+func TestDecode(t *testing.T) {
+	cfg := &testConfig{}
+	err := decodeString(t, strings.Join([]string{
+		`name = "main"`,
+		`enabled = true`,
+		`port = [80, 443]`,
+		`port += 8080`,
+		`label.env = "prod"`,
+		`server a.example.com {`,
+		`  | The first server`,
+		`  weight = 1.5`,
+		`}`,
+		`server b.example.com backup`,
+		`region eu {`,
+		`  zones = ["a", "b"]`,
+		`}`,
+		`region.us.zones += "c"`,
+	}, "\n"), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "main", cfg.Name)
+	if assert.NotNil(t, cfg.Enabled) {
+		assert.True(t, *cfg.Enabled)
+	}
+	assert.Equal(t, []int{80, 443, 8080}, cfg.Ports)
+	assert.Equal(t, map[string]string{"env": "prod"}, cfg.Labels)
+	assert.Equal(t, []*testServer{{
+		Host:        "a.example.com",
+		Weight:      1.5,
+		Description: "The first server",
+	}, {
+		Host:  "b.example.com",
+		Alias: "backup",
+	}}, cfg.Servers)
+	assert.Equal(t, []string{"a", "b"}, cfg.Regions["eu"].Zones)
+	assert.Equal(t, []string{"c"}, cfg.Regions["us"].Zones)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		code  errpos.Code
+	}{
+		{input: `unknown = 1`, code: errpos.CodeUnknownBlock},
+		{input: `skipped = "a"`, code: errpos.CodeUnknownBlock},
+		{input: `name = 1`, code: errpos.CodeTypeMismatch},
+		{input: "name = \"a\"\nname = \"b\"", code: errpos.CodeAlreadySet},
+		{input: `server`, code: errpos.CodeExpectedTag},
+		{input: `server a b c`, code: errpos.CodeUnexpectedTag},
+		{input: `name foo`, code: errpos.CodeNotContainer},
+		{input: `| description`, code: errpos.CodeNoDescription},
+		{input: `count.a = 1`, code: errpos.CodeSchemaError},
+		{input: `shard a {` + "\n}", code: errpos.CodeSchemaError},
+		{input: `shard.a.zones = ["z"]`, code: errpos.CodeSchemaError},
+		{input: `port = 99999999999999999999`, code: errpos.CodeInvalidValue},
+		{input: "with label {\n  env = \"prod\"\n}", code: errpos.CodeUnknownBlock},
+		{input: `defaults server`, code: errpos.CodeUnknownBlock},
+		{input: "!bcl 2\nname = env(\"NAME\")", code: errpos.CodeTypeMismatch},
+		{input: "!bcl 2\nport = [ports...]", code: errpos.CodeTypeMismatch},
+	} {
+		err := decodeString(t, tc.input, &testConfig{})
+		errs, ok := errpos.AsErrors(err)
+		if !ok || len(errs) != 1 {
+			t.Errorf("%q: expected one error, got %v", tc.input, err)
+			continue
+		}
+		assert.Equal(t, tc.code, errs[0].Code, tc.input)
+		assert.NotNil(t, errs[0].Pos, tc.input)
+	}
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{input: `port = 99999999999999999999`, want: "99999999999999999999 is not a valid int: value out of range"},
+		{input: "with label {\n}", want: "with blocks are not supported without a schema"},
+		{input: "!bcl 2\nname = env(\"NAME\")", want: "function calls are not supported without a schema"},
+	} {
+		err := decodeString(t, tc.input, &testConfig{})
+		assert.ErrorContains(t, err, tc.want, tc.input)
+	}
+}
@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnyField(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Wrapper"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &test_pb.Wrapper{}
+	_, err = pp.ParseFile("in.bcl", fb(
+		`payload test.v1.Thing {`,
+		`  label = "x"`,
+		`}`,
+	), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "type.googleapis.com/test.v1.Thing", msg.Payload.TypeUrl)
+
+	thing := &test_pb.Thing{}
+	if err := msg.Payload.UnmarshalTo(thing); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "x", thing.Label)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown type",
+		input: fb(`payload test.v1.Missing {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownType, Line: 1, Column: 9},
+	}, {
+		name:  "missing type",
+		input: fb(`payload {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeExpectedTag, Line: 1, Column: 7},
+	}, {
+		name:  "field of packed type",
+		input: fb(`payload test.v1.Thing {`, `  missing = "x"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2, Column: 3},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Wrapper{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
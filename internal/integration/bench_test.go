@@ -0,0 +1,153 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// syntheticFile builds a deterministic file with the given number of
+// elements, each a block with a description, plus tags and array values.
+func syntheticFile(elements int) string {
+	out := &strings.Builder{}
+	out.WriteString("sString = \"root\"\n")
+	for idx := 0; idx < elements; idx++ {
+		fmt.Fprintf(out, "foo Foo%d {\n\t| Description of element %d\n}\n", idx, idx)
+		fmt.Fprintf(out, "bar Bar%d\n", idx)
+		fmt.Fprintf(out, "tag.key%d = \"value %d\"\n", idx, idx)
+		fmt.Fprintf(out, "rString += \"item %d\"\n", idx)
+	}
+	return out.String()
+}
+
+func benchParser(t testing.TB) *bcl.Parser {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}, {
+				Name: "bar",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "bar"}},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.Verbose = false
+	return pp
+}
+
+func TestStats(t *testing.T) {
+	pp := benchParser(t)
+	msg := &test_pb.File{}
+	result, err := pp.Parse("in.bcl", syntheticFile(10), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, msg.Elements, 20)
+	stats := result.Stats
+	assert.Equal(t, 20, stats.Blocks)
+	// 4 per element, the root string and the descriptions
+	assert.Equal(t, 51, stats.Statements)
+	assert.Greater(t, stats.Tokens, 0)
+	assert.Greater(t, stats.ReflectValues, 0)
+}
+
+func TestSourceLocationModes(t *testing.T) {
+	input := syntheticFile(10)
+
+	pp := benchParser(t)
+	want := &test_pb.File{}
+	full, err := pp.ParseFile("in.bcl", input, want.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, full)
+
+	for _, mode := range []bcl.SourceLocations{bcl.SourceLocationsLazy, bcl.SourceLocationsNone} {
+		pp.SourceLocations = mode
+		msg := &test_pb.File{}
+		locs, err := pp.ParseFile("in.bcl", input, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, locs, "mode %d", mode)
+		assert.True(t, proto.Equal(want, msg), "mode %d", mode)
+	}
+}
+
+func BenchmarkLex(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		input := syntheticFile(size)
+		b.Run(fmt.Sprintf("elements=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := parser.NewLexer(input).AllTokens(true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		input := syntheticFile(size)
+		b.Run(fmt.Sprintf("elements=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.ParseFile(input, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWalk(b *testing.B) {
+	pp := benchParser(b)
+	for _, size := range []int{100, 1000} {
+		input := syntheticFile(size)
+		b.Run(fmt.Sprintf("elements=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				msg := &test_pb.File{}
+				if _, err := pp.ParseFile("in.bcl", input, msg.ProtoReflect()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWalkNoLocations(b *testing.B) {
+	pp := benchParser(b)
+	pp.SourceLocations = bcl.SourceLocationsNone
+	for _, size := range []int{100, 1000} {
+		input := syntheticFile(size)
+		b.Run(fmt.Sprintf("elements=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				msg := &test_pb.File{}
+				if _, err := pp.ParseFile("in.bcl", input, msg.ProtoReflect()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
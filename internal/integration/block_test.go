@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseBlock(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Policies: []*bcl_j5pb.Policy{{
+				Expression: `this.name != ""`,
+				Message:    "foo needs a name",
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		msg, err := pp.ParseBlock(fb(`name = "A"`, `description = "First"`), "test.v1.Element_Foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &test_pb.Element_Foo{Name: "A", Description: "First"}
+		assert.True(t, proto.Equal(want, msg), "got %v", msg)
+	})
+
+	t.Run("root", func(t *testing.T) {
+		msg, err := pp.ParseBlock(fb(`sString = "abc"`, `foo A`), "test.v1.File")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, ok := msg.(*test_pb.File)
+		if !assert.True(t, ok, "got %T", msg) {
+			return
+		}
+		assert.Equal(t, "abc", file.SString)
+		assert.Len(t, file.Elements, 1)
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		_, err := pp.ParseBlock(fb(`description = "First"`), "test.v1.Element_Foo")
+		errs, ok := errpos.AsErrorsWithSource(err)
+		if !ok {
+			t.Fatalf("expected positioned errors, got %v", err)
+		}
+		assert.Equal(t, errpos.CodePolicy, errs.Errors[0].Code)
+		assert.Equal(t, "<block>", *errs.Errors[0].Pos.Filename)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := pp.ParseBlock(fb(`sString = "abc"`), "test.v1.Element_Foo")
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown schema", func(t *testing.T) {
+		_, err := pp.ParseBlock(fb(`name = "A"`), "test.v1.Missing")
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeUnknownSchema})
+	})
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonical(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Unordered:  true,
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	}
+	msgType := (&test_pb.File{}).ProtoReflect().Type()
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := pp.Canonical([]byte(fb(
+		`// written one way`,
+		`tags.b = "2"`,
+		`tags.a = "1"`,
+		`sString = "s"`,
+		`rString = ["b", "a"]`,
+		`foo a {`,
+		`  description = ""`,
+		`}`,
+	)), msgType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := pp.Canonical([]byte(fb(
+		`!bcl 2`,
+		`with tags {`,
+		`  a = "1"`,
+		`  b = "2"`,
+		`}`,
+		`elements {`,
+		"  foo.name = `a`",
+		`}`,
+		`rString = [a, b]`,
+		`sString = "s"`,
+	)), msgType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, string(first), string(second))
+	assert.Equal(t, fb(
+		`foo a {`,
+		`}`,
+		`rString = ["a", "b"]`,
+		`sString = "s"`,
+		`tags.a = "1"`,
+		`tags.b = "2"`,
+		``,
+	), string(first))
+
+	_, err = pp.Canonical([]byte(`missing = 1`), msgType)
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 1, Column: 1})
+}
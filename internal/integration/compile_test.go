@@ -0,0 +1,58 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCompiledSchema(t *testing.T) {
+	input := syntheticFile(5)
+
+	want := &test_pb.File{}
+	if _, err := benchParser(t).ParseFile("in.bcl", input, want.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := bcl.CompileSchema(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}, {
+				Name: "bar",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "bar"}},
+			}},
+		}},
+	}, (&test_pb.File{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := compiled.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := &bcl.CompiledSchema{}
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	pp, err := bcl.NewCompiledParser(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &test_pb.File{}
+	if _, err := pp.ParseFile("in.bcl", input, got.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, proto.Equal(want, got))
+
+	assert.Error(t, loaded.UnmarshalBinary([]byte("not a schema")))
+}
@@ -0,0 +1,188 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputedChildren(t *testing.T) {
+	newParser := func(t *testing.T) *bcl.Parser {
+		t.Helper()
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Alias: []*bcl_j5pb.Alias{{
+					Name: "foo",
+					Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pp
+	}
+
+	input := fb(
+		`foo A {`,
+		`}`,
+		`foo B {`,
+		`  description = "given"`,
+		`}`,
+		`foo C {`,
+		`}`,
+	)
+
+	t.Run("sequence", func(t *testing.T) {
+		pp := newParser(t)
+		var calls []bcl.ComputeContext
+		pp.RegisterComputed("test.v1.Element_Foo", "description", func(ctx bcl.ComputeContext) (interface{}, error) {
+			calls = append(calls, ctx)
+			return fmt.Sprintf("seq-%d", len(calls)), nil
+		})
+		pp.RegisterComputed("test.v1.File", "sString", func(ctx bcl.ComputeContext) (interface{}, error) {
+			return "generated", nil
+		})
+
+		msg := &test_pb.File{}
+		locs, err := pp.ParseFile("in.bcl", input, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "seq-1", msg.Elements[0].GetFoo().Description)
+		assert.Equal(t, "given", msg.Elements[1].GetFoo().Description)
+		assert.Equal(t, "seq-2", msg.Elements[2].GetFoo().Description)
+		assert.Equal(t, "generated", msg.SString)
+
+		if assert.Len(t, calls, 2) {
+			assert.Equal(t, "in.bcl", calls[0].Filename)
+			assert.Equal(t, "test.v1.Element_Foo", calls[0].Schema)
+			assert.Equal(t, 0, calls[0].Pos.Start.Line)
+			assert.Equal(t, 5, calls[1].Pos.Start.Line)
+		}
+
+		computed := locs.Children["elements"].Children["0"].Children["foo"].Children["description"]
+		if assert.NotNil(t, computed) {
+			assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED, computed.Origin)
+			assert.EqualValues(t, 0, computed.StartLine)
+		}
+		given := locs.Children["elements"].Children["1"].Children["foo"].Children["description"]
+		assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, given.Origin)
+		assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED, locs.Children["sString"].Origin)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		pp := newParser(t)
+		pp.RegisterComputed("test.v1.Element_Foo", "description", func(ctx bcl.ComputeContext) (interface{}, error) {
+			return nil, fmt.Errorf("clock unavailable")
+		})
+		_, err := pp.ParseFile("in.bcl", input, (&test_pb.File{}).ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 1})
+		assert.ErrorContains(t, err, "clock unavailable")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		pp := newParser(t)
+		pp.RegisterComputed("test.v1.Element_Foo", "generatedAt", func(ctx bcl.ComputeContext) (interface{}, error) {
+			return "x", nil
+		})
+		_, err := pp.ParseFile("in.bcl", input, (&test_pb.File{}).ProtoReflect())
+		assert.ErrorContains(t, err, "test.v1.Element_Foo has no field generatedAt")
+	})
+
+	t.Run("explicit zero", func(t *testing.T) {
+		pp := newParser(t)
+		pp.RegisterComputed("test.v1.Element_Foo", "description", func(ctx bcl.ComputeContext) (interface{}, error) {
+			return "computed", nil
+		})
+		pp.RegisterComputed("test.v1.File", "sString", func(ctx bcl.ComputeContext) (interface{}, error) {
+			return "computed", nil
+		})
+
+		msg := &test_pb.File{}
+		_, err := pp.ParseFile("in.bcl", fb(
+			`sString = ""`,
+			`foo A {`,
+			`  description = ""`,
+			`}`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "", msg.SString)
+		assert.Equal(t, "", msg.Elements[0].GetFoo().Description)
+	})
+
+	t.Run("dotted", func(t *testing.T) {
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Alias: []*bcl_j5pb.Alias{{
+					Name: "element",
+					Path: &bcl_j5pb.Path{Path: []string{"elements"}},
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var calls []bcl.ComputeContext
+		pp.RegisterComputed("test.v1.Element_Foo", "description", func(ctx bcl.ComputeContext) (interface{}, error) {
+			calls = append(calls, ctx)
+			return "computed", nil
+		})
+
+		msg := &test_pb.File{}
+		_, err = pp.ParseFile("in.bcl", fb(
+			`element {`,
+			`  foo.name = "a"`,
+			`}`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "a", msg.Elements[0].GetFoo().Name)
+		assert.Equal(t, "computed", msg.Elements[0].GetFoo().Description)
+		if assert.Len(t, calls, 1) {
+			assert.Equal(t, 1, calls[0].Pos.Start.Line)
+		}
+	})
+
+	t.Run("once when lazy", func(t *testing.T) {
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Alias: []*bcl_j5pb.Alias{{
+					Name: "foo",
+					Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				}},
+				Policies: []*bcl_j5pb.Policy{{
+					Expression: `this.s_string == "valid"`,
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pp.SourceLocations = bcl.SourceLocationsLazy
+		calls := 0
+		pp.RegisterComputed("test.v1.Element_Foo", "description", func(ctx bcl.ComputeContext) (interface{}, error) {
+			calls++
+			return "computed", nil
+		})
+
+		msg := &test_pb.File{}
+		_, err = pp.ParseFile("in.bcl", input, msg.ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodePolicy, Line: 1})
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, "computed", msg.Elements[0].GetFoo().Description)
+	})
+}
@@ -0,0 +1,163 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConstraints(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "sString",
+				Pattern:   proto.String("^[a-z]+$"),
+				MaxLength: proto.Uint64(5),
+			}, {
+				FieldName: "rString",
+				Allowed:   []string{"a", "b"},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "name",
+				MinLength: proto.Uint64(2),
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.FailFast = false
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []bcltest.Diagnostic
+	}{{
+		name:  "valid",
+		input: fb(`sString = "abc"`, `rString = ["a", "b"]`, `foo Name`),
+	}, {
+		name:  "pattern",
+		input: fb(`sString = "ABC"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 1, Column: 11}},
+	}, {
+		name:  "length",
+		input: fb(`sString = "abcdef"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 1, Column: 11}},
+	}, {
+		name:  "array element",
+		input: fb(`rString = ["a", "c"]`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 1, Column: 17}},
+	}, {
+		name:  "append",
+		input: fb(`rString += "c"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 1, Column: 12}},
+	}, {
+		name:  "nested tag",
+		input: fb(`foo N`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 1}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want...)
+		})
+	}
+
+	compiled, err := bcl.CompileSchema(spec, (&test_pb.File{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := compiled.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := &bcl.CompiledSchema{}
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	compiledParser, err := bcl.NewCompiledParser(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = compiledParser.ParseFile("in.bcl", fb(`sString = "ABC"`), (&test_pb.File{}).ProtoReflect())
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeConstraint, Line: 1, Column: 11})
+
+	_, err = bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "sString",
+				Pattern:   proto.String("("),
+			}},
+		}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
+
+func TestConstraintFieldSchema(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		field string
+		err   string
+	}{{
+		name:  "not a scalar",
+		field: "elements",
+		err:   "constraint on test.v1.File.elements is not a scalar field",
+	}, {
+		name:  "not a field",
+		field: "missing",
+		err:   "constraint on test.v1.File.missing is not a field",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+				Blocks: []*bcl_j5pb.Block{{
+					SchemaName: "test.v1.File",
+					Constraints: []*bcl_j5pb.Constraint{{
+						FieldName: tc.field,
+						MaxLength: proto.Uint64(5),
+					}},
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = pp.ParseFile("in.bcl", `sString = "a"`, (&test_pb.File{}).ProtoReflect())
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+
+	_, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "sString",
+				MaxLength: proto.Uint64(5),
+			}, {
+				FieldName: "sString",
+				MinLength: proto.Uint64(1),
+			}},
+		}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMarshalFormats(t *testing.T) {
+	want := &test_pb.File{}
+	if _, err := benchParser(t).ParseFile("in.bcl", syntheticFile(2), want.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		format    bcl.Format
+		unmarshal func([]byte, proto.Message) error
+	}{
+		{bcl.FormatJSON, protojson.Unmarshal},
+		{bcl.FormatPrototext, prototext.Unmarshal},
+		{bcl.FormatBinary, proto.Unmarshal},
+	} {
+		t.Run(string(tc.format), func(t *testing.T) {
+			data, err := bcl.Marshal(want, tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := &test_pb.File{}
+			if err := tc.unmarshal(data, got); err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, proto.Equal(want, got))
+		})
+	}
+
+	_, err := bcl.Marshal(want, "yaml")
+	assert.Error(t, err)
+}
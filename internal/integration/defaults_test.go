@@ -0,0 +1,173 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaults(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("inherited", func(t *testing.T) {
+		msg, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+			`foo before`,
+			`defaults foo {`,
+			`  description = "shared"`,
+			`}`,
+			`foo a`,
+			`foo b {`,
+			`  description = "own"`,
+			`}`,
+			`defaults foo {`,
+			`  description = "later"`,
+			`}`,
+			`foo c`,
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		descriptions := make([]string, len(msg.Elements))
+		for idx, element := range msg.Elements {
+			descriptions[idx] = element.GetFoo().Description
+		}
+		assert.Equal(t, []string{"", "shared", "own", "later"}, descriptions)
+
+		got, err := bcl.Explain(msg, locs, "elements.1.foo.description")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "elements.1.foo.description = \"shared\"\n  inherited from the defaults at 3:17\n", got)
+
+		own := locs.Children["elements"].Children["2"].Children["foo"].Children["description"]
+		if assert.NotNil(t, own) {
+			assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, own.Origin)
+		}
+	})
+
+	t.Run("by schema", func(t *testing.T) {
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Alias: []*bcl_j5pb.Alias{{
+					Name: "foo",
+					Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				}, {
+					Name: "other",
+					Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, _, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+			`defaults foo {`,
+			`  description = "shared"`,
+			`}`,
+			`other a {`,
+			`}`,
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, msg.Elements, 1) {
+			assert.Equal(t, "shared", msg.Elements[0].GetFoo().Description)
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "no type",
+		input: fb(`defaults {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeExpectedTag, Line: 1},
+	}, {
+		name:  "append",
+		input: fb(`defaults foo {`, `  description += "a"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeDefaults, Line: 2, Column: 3},
+	}, {
+		name:  "block",
+		input: fb(`defaults foo {`, `  foo a`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeDefaults, Line: 2, Column: 3},
+	}, {
+		name:  "unknown field",
+		input: fb(`defaults foo {`, `  region = "eu"`, `}`, `foo a`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2, Column: 3},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
+
+func TestKeywordNames(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "defaults",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}, {
+				Name: "with",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input string
+	}{{
+		name:  "children",
+		input: fb(`defaults x {`, `  description = "a"`, `}`, `with y {`, `  description = "b"`, `}`),
+	}, {
+		name:  "quoted",
+		input: fb(`!bcl 2`, `"defaults" x {`, `  description = "a"`, `}`, `"with" y {`, `  description = "b"`, `}`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, _, err := bcl.Decode[*test_pb.File](pp, "in.bcl", tc.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, element := range msg.Elements {
+				names = append(names, element.GetFoo().Name+"="+element.GetFoo().Description)
+			}
+			assert.Equal(t, []string{"x=a", "y=b"}, names)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeprecatedAlias(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name:       "str",
+				Path:       &bcl_j5pb.Path{Path: []string{"sString"}},
+				Deprecated: true,
+			}, {
+				Name:       "item",
+				Path:       &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				Deprecated: true,
+			}, {
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := fb(
+		`str = "a"`,
+		`item A`,
+		`foo B`,
+	)
+
+	msg := &test_pb.File{}
+	result, err := pp.Parse("in.bcl", input, msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", msg.SString)
+	assert.Len(t, msg.Elements, 2)
+
+	if !assert.Len(t, result.Warnings, 2) {
+		return
+	}
+	edits := []errpos.Edit{}
+	for _, warning := range result.Warnings {
+		assert.Equal(t, errpos.CodeDeprecated, warning.ErrorCode())
+		assert.Equal(t, errpos.SeverityWarning, warning.Severity)
+		if assert.Len(t, warning.Fixes, 1) {
+			assert.True(t, warning.Fixes[0].Safe)
+			edits = append(edits, warning.Fixes[0].Edits...)
+		}
+	}
+	assert.Equal(t, "in.bcl:1:1", result.Warnings[0].Pos.String())
+	assert.Equal(t, "in.bcl:2:1", result.Warnings[1].Pos.String())
+
+	fixed, err := errpos.ApplyEdits(input, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`sString = "a"`,
+		`elements.foo A`,
+		`foo B`,
+	), fixed)
+
+	fixedMsg := &test_pb.File{}
+	fixedResult, err := pp.Parse("in.bcl", fixed, fixedMsg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, fixedResult.Warnings)
+	fixedMsg.SourceLocation, msg.SourceLocation = nil, nil
+	assert.True(t, proto.Equal(msg, fixedMsg), "got %v, want %v", fixedMsg, msg)
+}
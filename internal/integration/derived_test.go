@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivedFields(t *testing.T) {
+	newParser := func(t *testing.T, derived ...*bcl_j5pb.Derived) *bcl.Parser {
+		t.Helper()
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Alias: []*bcl_j5pb.Alias{{
+					Name: "foo",
+					Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+				}},
+				Derived: derived,
+			}, {
+				SchemaName: "test.v1.Element_Foo",
+				Name: &bcl_j5pb.Tag{
+					FieldName: "name",
+				},
+				Derived: []*bcl_j5pb.Derived{{
+					FieldName:  "description",
+					Expression: `file + ":" + this.name + (has(parent.foo) ? "" : "?")`,
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pp.FailFast = false
+		return pp
+	}
+
+	t.Run("expression and hook", func(t *testing.T) {
+		pp := newParser(t)
+		var parents []string
+		pp.RegisterDerived("test.v1.File", "sString", func(ctx bcl.DeriveContext) (interface{}, error) {
+			if ctx.Parent != nil {
+				parents = append(parents, string(ctx.Parent.Descriptor().FullName()))
+			}
+			msg := ctx.This.Interface().(*test_pb.File)
+			return fmt.Sprintf("%d elements", len(msg.Elements)), nil
+		})
+
+		msg := &test_pb.File{}
+		locs, err := pp.ParseFile("in.bcl", fb(
+			`foo a`,
+			`foo b`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "2 elements", msg.SString)
+		assert.Equal(t, "in.bcl:a", msg.Elements[0].GetFoo().Description)
+		assert.Equal(t, "in.bcl:b", msg.Elements[1].GetFoo().Description)
+		assert.Empty(t, parents)
+
+		derived := locs.Children["elements"].Children["1"].Children["foo"].Children["description"]
+		if assert.NotNil(t, derived) {
+			assert.Equal(t, bcl_j5pb.Origin_ORIGIN_DERIVED, derived.Origin)
+			assert.EqualValues(t, 1, derived.StartLine)
+		}
+		assert.Equal(t, bcl_j5pb.Origin_ORIGIN_DERIVED, locs.Children["sString"].Origin)
+	})
+
+	t.Run("field mask", func(t *testing.T) {
+		pp := newParser(t, &bcl_j5pb.Derived{FieldName: "sString", Expression: `"x"`})
+		pp.FieldMask = true
+		msg := &test_pb.File{}
+		result, err := pp.Parse("in.bcl", fb(`foo a`), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Contains(t, result.FieldMask.Paths, "s_string")
+		assert.Contains(t, result.FieldMask.Paths, "elements")
+	})
+
+	t.Run("set in the file", func(t *testing.T) {
+		pp := newParser(t)
+		_, err := pp.ParseFile("in.bcl", fb(
+			`foo a {`,
+			`  description = "given"`,
+			`}`,
+		), (&test_pb.File{}).ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeDerived, Line: 2, Column: 17})
+	})
+
+	t.Run("failed expression", func(t *testing.T) {
+		pp := newParser(t, &bcl_j5pb.Derived{
+			FieldName:  "sString",
+			Expression: `string(10 / size(this.elements))`,
+		})
+		msg := &test_pb.File{}
+		_, err := pp.ParseFile("in.bcl", fb(`foo a`), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "10", msg.SString)
+
+		_, err = pp.ParseFile("in.bcl", fb(`rString = ["a"]`), (&test_pb.File{}).ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeDerived, Line: 1})
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		pp := newParser(t, &bcl_j5pb.Derived{
+			FieldName:  "sString",
+			Expression: `size(this.elements)`,
+		})
+		_, err := pp.ParseFile("in.bcl", fb(`foo a`), (&test_pb.File{}).ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeDerived, Line: 1})
+	})
+}
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDescribeSchema(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "sString",
+				Pattern:   proto.String("^[a-z]+$"),
+				MaxLength: proto.Uint64(5),
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			DescriptionField: proto.String("description"),
+		}},
+	}
+
+	doc, err := bcl.DescribeSchema(spec, (&test_pb.File{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "test.v1.File", doc.Root)
+	assert.Equal(t, "test.v1.File", doc.Blocks[0].Schema)
+	blocks := map[string]*bcl.BlockDoc{}
+	for _, block := range doc.Blocks {
+		blocks[block.Schema] = block
+	}
+
+	root := blocks["test.v1.File"]
+	assert.Contains(t, root.Children, bcl.ChildDoc{
+		Name:       "sString",
+		Type:       "string",
+		Attribute:  true,
+		Constraint: "pattern ^[a-z]+$, length <= 5",
+	})
+	assert.Contains(t, root.Children, bcl.ChildDoc{
+		Name:   "elements",
+		Type:   "array(oneof(test.v1.Element))",
+		Block:  true,
+		Schema: "test.v1.Element",
+	})
+
+	foo := blocks["test.v1.Element_Foo"]
+	if foo == nil {
+		t.Fatal("missing test.v1.Element_Foo")
+	}
+	assert.Equal(t, "name", foo.NameTag)
+	assert.Equal(t, "description", foo.DescriptionField)
+
+	text, err := doc.Render(bcl.DocFormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(text), "  sString attribute string (pattern ^[a-z]+$, length <= 5)\n")
+
+	markdown, err := doc.Render(bcl.DocFormatMarkdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(markdown), "## test.v1.Element_Foo\n\n- name tag: name\n")
+
+	data, err := doc.Render(bcl.DocFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &bcl.SchemaDoc{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, doc, decoded)
+
+	_, err = doc.Render("html")
+	assert.Error(t, err)
+}
+
+func TestDescribeDefaults(t *testing.T) {
+	doc, err := bcl.DescribeSchema(&bcl_j5pb.Schema{}, (&test_pb.Defaults{}).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := map[string]string{}
+	for _, child := range doc.Blocks[0].Children {
+		defaults[child.Name] = child.Default
+	}
+	assert.Equal(t, map[string]string{
+		"region":   `"eu-west-1"`,
+		"replicas": "3",
+		"enabled":  "true",
+		"level":    `"HIGH"`,
+		"owner":    "",
+	}, defaults)
+
+	text, err := doc.Render(bcl.DocFormatText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(text), "  replicas attribute integer (default 3)\n")
+}
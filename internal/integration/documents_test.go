@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocuments(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, locs, err := bcl.DecodeDocuments[*test_pb.File](pp, "in.bcl", fb(
+		`!bcl 2`,
+		`---`,
+		`sString = "a"`,
+		`foo x`,
+		`---`,
+		`// the second`,
+		`sString = "b"`,
+		`---   `,
+		`---`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, "a", msgs[0].SString)
+		assert.Len(t, msgs[0].Elements, 1)
+		assert.Equal(t, "b", msgs[1].SString)
+		assert.Empty(t, msgs[1].Elements)
+	}
+	if assert.Len(t, locs, 2) {
+		assert.EqualValues(t, 2, locs[0].Children["sString"].StartLine)
+		assert.EqualValues(t, 6, locs[1].Children["sString"].StartLine)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "inside a block",
+		input: fb(`foo x {`, `---`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeDocuments, Line: 2, Column: 1},
+	}, {
+		name:  "not alone",
+		input: fb(`--- x`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedChar, Line: 1, Column: 1},
+	}, {
+		name:  "indented",
+		input: fb(`sString = "a"`, `  ---`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedChar, Line: 2, Column: 3},
+	}, {
+		name:  "error in a document",
+		input: fb(`sString = "a"`, `---`, `missing = 1`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 3, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := bcl.DecodeDocuments[*test_pb.File](pp, "in.bcl", tc.input)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	_, err = pp.ParseFile("in.bcl", fb(`sString = "a"`, `---`, `sString = "b"`), (&test_pb.File{}).ProtoReflect())
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeDocuments, Line: 2, Column: 1})
+
+	formatted, err := bcl.Fmt(fb(`sString = "a"`, `---`, `sString = "b"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, formatted, "\n---\n")
+}
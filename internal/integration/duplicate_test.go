@@ -0,0 +1,165 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateBlocks(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Identity: []string{"name"},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.FailFast = false
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []bcltest.Diagnostic
+	}{{
+		name:  "distinct",
+		input: fb(`foo A`, `foo B`),
+	}, {
+		name:  "duplicate",
+		input: fb(`foo A`, `foo B`, `foo A`),
+		want: []bcltest.Diagnostic{
+			{Code: errpos.CodeDuplicateBlock, Line: 1},
+			{Code: errpos.CodeDuplicateBlock, Line: 3},
+		},
+	}, {
+		name: "suppressed",
+		input: fb(
+			`// bcl:ignore-file BCL1015`,
+			`foo A`,
+			`foo A`,
+		),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want...)
+		})
+	}
+
+	t.Run("message", func(t *testing.T) {
+		_, err := pp.ParseFile("in.bcl", fb(`foo A`, `foo A`), (&test_pb.File{}).ProtoReflect())
+		withSource, ok := errpos.AsErrorsWithSource(err)
+		if !ok {
+			t.Fatalf("expected positioned errors, got %v", err)
+		}
+		errs := withSource.Errors
+		if !assert.Len(t, errs, 2) {
+			return
+		}
+		assert.Contains(t, errs[0].Error(), `test.v1.Element_Foo name="A" is also defined at in.bcl:2:1`)
+		assert.Contains(t, errs[1].Error(), `is also defined at in.bcl:1:1`)
+	})
+
+	t.Run("project", func(t *testing.T) {
+		parse := func(filename string, lines ...string) bcl.SourceFile {
+			msg := &test_pb.File{}
+			source := fb(lines...)
+			locs, err := pp.ParseFile(filename, source, msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return bcl.SourceFile{
+				Filename:       filename,
+				Message:        msg.ProtoReflect(),
+				SourceLocation: locs,
+				Source:         source,
+			}
+		}
+		a := parse("a.bcl", `foo A`)
+		b := parse("b.bcl", `foo B`)
+		errs, err := bcl.CheckDuplicateBlocks(spec, []bcl.SourceFile{a, b})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, errs)
+
+		c := parse("c.bcl", `foo B`, `foo C`)
+		errs, err = bcl.CheckDuplicateBlocks(spec, []bcl.SourceFile{a, b, c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		bcltest.AssertDiagnostics(t, errs,
+			bcltest.Diagnostic{Code: errpos.CodeDuplicateBlock, Line: 1},
+			bcltest.Diagnostic{Code: errpos.CodeDuplicateBlock, Line: 1},
+		)
+		if assert.Len(t, errs, 2) {
+			assert.Contains(t, errs[0].Error(), "also defined at c.bcl:1:1")
+			assert.Contains(t, errs[1].Error(), "also defined at b.bcl:1:1")
+		}
+
+		c = parse("c.bcl", `// bcl:ignore `+string(errpos.CodeDuplicateBlock), `foo B`, `foo C`)
+		errs, err = bcl.CheckDuplicateBlocks(spec, []bcl.SourceFile{a, b, c})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, errs, 1) {
+			assert.Contains(t, errs[0].Error(), "b.bcl:1:1")
+		}
+	})
+}
+
+func TestIdentityFieldSchema(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		identity []string
+		err      string
+	}{{
+		name:     "not a scalar",
+		identity: []string{"elements"},
+		err:      "identity field test.v1.File.elements is not a scalar field",
+	}, {
+		name:     "not a field",
+		identity: []string{"missing"},
+		err:      "identity field test.v1.File.missing is not a field",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+				Blocks: []*bcl_j5pb.Block{{
+					SchemaName: "test.v1.File",
+					Identity:   tc.identity,
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = pp.ParseFile("in.bcl", `sString = "a"`, (&test_pb.File{}).ProtoReflect())
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+
+	_, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Identity:   []string{"sString", "sString"},
+		}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorSet returns the file of the descriptor and all of its imports.
+func descriptorSet(desc protoreflect.MessageDescriptor) *descriptorpb.FileDescriptorSet {
+	fds := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	var add func(protoreflect.FileDescriptor)
+	add = func(file protoreflect.FileDescriptor) {
+		if seen[file.Path()] {
+			return
+		}
+		seen[file.Path()] = true
+		imports := file.Imports()
+		for idx := 0; idx < imports.Len(); idx++ {
+			add(imports.Get(idx).FileDescriptor)
+		}
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(file))
+	}
+	add(desc.ParentFile())
+	return fds
+}
+
+func TestParseDynamic(t *testing.T) {
+	input := syntheticFile(3)
+
+	root, err := bcl.FindMessage(descriptorSet((&test_pb.File{}).ProtoReflect().Descriptor()), "test.v1.File")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pp := benchParser(t)
+	dynamic, _, err := pp.ParseDynamic("in.bcl", input, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &test_pb.File{}
+	if _, err := pp.ParseFile("in.bcl", input, want.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := proto.Marshal(dynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &test_pb.File{}
+	if err := proto.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, proto.Equal(want, got))
+
+	_, err = bcl.FindMessage(descriptorSet(root), "test.v1.Missing")
+	assert.Error(t, err)
+}
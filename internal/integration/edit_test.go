@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetValue(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		path  string
+		value string
+		want  string
+	}{{
+		name:  "replace",
+		input: fb(`a = 1 // keep`, `b = 2`),
+		path:  "a",
+		value: "3",
+		want:  fb(`a = 3 // keep`, `b = 2`),
+	}, {
+		name:  "replace in block",
+		input: fb(`server {`, `	timeout = 10`, `}`),
+		path:  "server.timeout",
+		value: `"30s"`,
+		want:  fb(`server {`, `	timeout = "30s"`, `}`),
+	}, {
+		name:  "replace dotted key",
+		input: fb(`server.timeout = 10`),
+		path:  "server.timeout",
+		value: "20",
+		want:  fb(`server.timeout = 20`),
+	}, {
+		name:  "replace in tagged block",
+		input: fb(`service api {`, `	port = 80`, `}`, `service web {`, `	port = 80`, `}`),
+		path:  "service.web.port",
+		value: "8080",
+		want:  fb(`service api {`, `	port = 80`, `}`, `service web {`, `	port = 8080`, `}`),
+	}, {
+		name:  "add to block",
+		input: fb(`server {`, `  host = "a"`, `}`),
+		path:  "server.timeout",
+		value: "30",
+		want:  fb(`server {`, `  host = "a"`, `  timeout = 30`, `}`),
+	}, {
+		name:  "add to empty block",
+		input: fb(`server {`, `}`),
+		path:  "server.timeout",
+		value: "30",
+		want:  fb(`server {`, `	timeout = 30`, `}`),
+	}, {
+		name:  "add to root",
+		input: fb(`a = 1`),
+		path:  "b.c",
+		value: "true",
+		want:  fb(`a = 1`, `b.c = true`),
+	}, {
+		name:  "syntax of the file's edition",
+		input: fb(`!bcl 2`, `a = 1`),
+		path:  "a",
+		value: "`raw`",
+		want:  fb(`!bcl 2`, "a = `raw`"),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := bcl.SetValue(tc.input, tc.path, tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := bcl.SetValue(fb(`a = 1`), "a", "30s")
+	assert.Error(t, err, "not a BCL value")
+
+	_, err = bcl.SetValue(fb(`a = 1`), "a", "`raw`")
+	assert.ErrorContains(t, err, "a raw string needs edition 2")
+
+	_, err = bcl.SetValue(fb(`s x {`, `}`, `s x {`, `}`), "s.x.a", "1")
+	assert.Error(t, err, "ambiguous block")
+}
+
+func TestUnsetValue(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+		path  string
+		want  string
+	}{{
+		name:  "line",
+		input: fb(`a = 1 // gone`, `b = 2`),
+		path:  "a",
+		want:  fb(`b = 2`),
+	}, {
+		name:  "in block",
+		input: fb(`server {`, `	host = "a"`, `	timeout = [`, `		1,`, `	]`, `}`),
+		path:  "server.timeout",
+		want:  fb(`server {`, `	host = "a"`, `}`),
+	}, {
+		name:  "missing",
+		input: fb(`a = 1`),
+		path:  "b",
+		want:  fb(`a = 1`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := bcl.UnsetValue(tc.input, tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLiteralValue(t *testing.T) {
+	for input, want := range map[string]string{
+		"30":         "30",
+		"1.5":        "1.5",
+		"true":       "true",
+		`"quoted"`:   `"quoted"`,
+		`["a", "b"]`: `["a", "b"]`,
+		"30s":        `"30s"`,
+		"plain text": `"plain text"`,
+		"ref":        "ref",
+		"[]":         "[]",
+	} {
+		assert.Equal(t, want, bcl.LiteralValue(input), input)
+	}
+}
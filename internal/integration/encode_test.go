@@ -0,0 +1,203 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncoder(t *testing.T) {
+	elements := []*test_pb.Element{{
+		Type: &test_pb.Element_Foo_{Foo: &test_pb.Element_Foo{
+			Name:        "a",
+			Description: "say \"hi\"\nand { leave",
+		}},
+	}, {
+		Type: &test_pb.Element_Bar_{Bar: &test_pb.Element_Bar{
+			Name: "b",
+		}},
+	}}
+
+	buffer := &bytes.Buffer{}
+	writer := bufio.NewWriter(buffer)
+	enc := bcl.NewEncoder(writer, "elements")
+	for idx, element := range elements {
+		if err := enc.Encode(element); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, idx+1, bytes.Count(buffer.Bytes(), []byte("elements {")), "flushed")
+	}
+
+	out := buffer.String()
+	assert.Equal(t, fb(
+		`elements {`,
+		`	foo a {`,
+		`		description = "say \"hi\"\nand { leave"`,
+		`	}`,
+		`}`,
+		`elements {`,
+		`	bar b {`,
+		`	}`,
+		`}`,
+		``,
+	), out)
+	formatted, err := bcl.Fmt(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, out, formatted)
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := pp.NewDecoder(buffer, "stream.bcl", (&test_pb.File{}).ProtoReflect().Type())
+	for _, want := range elements {
+		record, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := record.Message.Interface().(*test_pb.File)
+		if assert.Len(t, got.Elements, 1) {
+			assert.True(t, proto.Equal(want, got.Elements[0]), "round trip %v", got.Elements[0])
+		}
+	}
+	_, err = dec.Next()
+	assert.Equal(t, io.EOF, err)
+
+	file := &test_pb.File{
+		SString: "s",
+		RString: []string{"x", "y"},
+		Tags:    map[string]string{"b": "2", "a": "1"},
+	}
+	buffer.Reset()
+	if err := bcl.NewEncoder(buffer, "file").Encode(file); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, buffer.String(), "\tsString = \"s\"\n\trString = [\"x\", \"y\"]\n")
+	assert.Contains(t, buffer.String(), "\ttags.a = \"1\"\n\ttags.b = \"2\"\n")
+
+	buffer.Reset()
+	if err := bcl.NewEncoder(buffer, "").Encode(&test_pb.File{SString: "s"}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "sString = \"s\"\n", buffer.String())
+
+	file.Tags = map[string]string{"a b": "1"}
+	err = bcl.NewEncoder(io.Discard, "file").Encode(file)
+	assert.Error(t, err)
+
+	file.Tags = nil
+	file.SString = "a\xffb"
+	err = bcl.NewEncoder(io.Discard, "file").Encode(file)
+	encodeErr := &bcl.EncodeError{}
+	if assert.ErrorAs(t, err, &encodeErr) {
+		assert.Equal(t, "test.v1.File.s_string", string(encodeErr.Field))
+	}
+}
+
+func TestEncoderRoundTrip(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Services",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "service",
+				Path: &bcl_j5pb.Path{Path: []string{"services"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Service",
+			Name:       &bcl_j5pb.Tag{FieldName: "name"},
+			TypeSelect: &bcl_j5pb.Tag{FieldName: "kind"},
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "method",
+				Path: &bcl_j5pb.Path{Path: []string{"methods"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Method",
+			Name:       &bcl_j5pb.Tag{FieldName: "name", ScopeSeparator: proto.String(".")},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := fb(
+		`service api http {`,
+		`  port = 8080`,
+		`  offset = -3`,
+		`  ratio = -0.5`,
+		`  data = "aGkgdGhlcmU/"`,
+		`  method get {`,
+		`    path = "/x"`,
+		`  }`,
+		`  routes.main {`,
+		`    target = "b"`,
+		`  }`,
+		`}`,
+		`service "the other" grpc {`,
+		`  reflection = true`,
+		`}`,
+	)
+	want := &test_pb.Services{}
+	_, err = pp.ParseFile("in.bcl", src, want.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assert.Len(t, want.Services, 2) {
+		return
+	}
+	first := want.Services[0]
+	assert.Equal(t, "api.get", first.Methods[0].Name)
+	assert.Equal(t, []byte("hi there?"), first.Data)
+
+	out, err := pp.Canonical([]byte(src), want.ProtoReflect().Type())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`service api http {`,
+		`	offset = -3`,
+		`	ratio = -0.5`,
+		`	data = "aGkgdGhlcmU/"`,
+		`	method get {`,
+		`		path = "/x"`,
+		`	}`,
+		`	routes.main {`,
+		`		target = "b"`,
+		`	}`,
+		`	port = 8080`,
+		`}`,
+		`service "the other" grpc {`,
+		`	reflection = true`,
+		`}`,
+		``,
+	), string(out))
+
+	got := &test_pb.Services{}
+	_, err = pp.ParseFile("out.bcl", string(out), got.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, proto.Equal(want, got), "round trip %v", got)
+
+	buffer := &bytes.Buffer{}
+	enc := pp.NewEncoder(buffer, "service")
+	for _, service := range want.Services {
+		if err := enc.Encode(service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got = &test_pb.Services{}
+	_, err = pp.ParseFile("stream.bcl", buffer.String(), got.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, proto.Equal(want, got), "encoded round trip %v", got)
+}
@@ -0,0 +1,88 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+		`!bcl 2`,
+		`sString = "a"`,
+		`rString = ["a"]`,
+		`rString += [rString...]`,
+		`tag.k = "v"`,
+		`foo Name {`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path string
+		want string
+	}{{
+		path: "sString",
+		want: "sString = \"a\"\n  set at 2:11\n",
+	}, {
+		path: "r_string",
+		want: "r_string = [2 elements]\n  set at 3:11\n",
+	}, {
+		path: "rString.1",
+		want: "rString.1 = \"a\"\n  copied from another value by the statement at 4:13\n",
+	}, {
+		path: "tags.k",
+		want: "tags.k = \"v\"\n  set at 5:9\n",
+	}, {
+		path: "elements.0",
+		want: "elements.0 = {test.v1.Element}\n  set by the schema at 6:1, on the path to a value set there\n",
+	}, {
+		path: "elements.0.foo.description",
+		want: "elements.0.foo.description is not set\n  not mentioned in the file, the schema default applies\n",
+	}} {
+		t.Run(tc.path, func(t *testing.T) {
+			got, err := bcl.Explain(msg, locs, tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("unset collections", func(t *testing.T) {
+		msg, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", `sString = "a"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"rString.0", "tags.k", "elements.0.foo.name"} {
+			got, err := bcl.Explain(msg, locs, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, path+" is not set\n  not mentioned in the file, the schema default applies\n", got)
+		}
+	})
+
+	_, err = bcl.Explain(msg, locs, "sString.missing")
+	assert.Error(t, err)
+	_, err = bcl.Explain(msg, locs, "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func TestExtensions(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Config"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.Extensions = protoregistry.GlobalTypes
+
+	t.Run("set", func(t *testing.T) {
+		msg := &test_pb.Config{}
+		locs, err := pp.ParseFile("in.bcl", fb(
+			`name = "api"`,
+			`owner = "payments"`,
+			`maxReplicas = 3`,
+			`labels = ["a"]`,
+			`labels += "b"`,
+			`meta {`,
+			`  team = "core"`,
+			`}`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "payments", proto.GetExtension(msg, test_pb.E_Owner))
+		assert.Equal(t, int32(3), proto.GetExtension(msg, test_pb.E_MaxReplicas))
+		assert.Equal(t, []string{"a", "b"}, proto.GetExtension(msg, test_pb.E_Labels))
+		assert.Equal(t, "core", proto.GetExtension(msg, test_pb.E_Meta).(*test_pb.Meta).GetTeam())
+
+		owner := locs.Children["[test.v1.owner]"]
+		if assert.NotNil(t, owner) {
+			assert.Equal(t, int32(1), owner.StartLine)
+		}
+		metaLoc := locs.Children["[test.v1.meta]"]
+		if assert.NotNil(t, metaLoc) {
+			assert.NotNil(t, metaLoc.Children["team"])
+		}
+	})
+
+	t.Run("enum", func(t *testing.T) {
+		for _, name := range []string{"GOLD", "TIER_GOLD"} {
+			msg := &test_pb.Config{}
+			_, err := pp.ParseFile("in.bcl", fb(`tier = "`+name+`"`), msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test_pb.Tier_TIER_GOLD, proto.GetExtension(msg, test_pb.E_Tier), name)
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown enum value",
+		input: fb(`tier = "BRONZE"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeInvalidValue, Line: 1, Column: 8},
+	}, {
+		name:  "already set",
+		input: fb(`owner = "a"`, `owner = "b"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeAlreadySet, Line: 2},
+	}, {
+		name:  "wrong type",
+		input: fb(`maxReplicas = "x"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 15},
+	}, {
+		name:  "scalar as a block",
+		input: fb(`owner {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNotContainer, Line: 1},
+	}, {
+		name:  "unknown",
+		input: fb(`other = "a"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Config{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
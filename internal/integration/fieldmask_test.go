@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldMask(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.FieldMask = true
+	pp.SourceLocations = bcl.SourceLocationsNone
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []string
+	}{{
+		name:  "default value",
+		input: fb(`sString = ""`),
+		want:  []string{"s_string"},
+	}, {
+		name:  "nothing set",
+		input: fb(``),
+		want:  []string{},
+	}, {
+		name: "repeated and map fields",
+		input: fb(
+			`rString = ["a"]`,
+			`foo Name {`,
+			`}`,
+			`tag.k = "v"`,
+		),
+		want: []string{"elements", "r_string", "tags"},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &test_pb.File{}
+			result, err := pp.Parse("in.bcl", tc.input, msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, result.SourceLocation)
+			assert.Equal(t, tc.want, result.FieldMask.Paths)
+		})
+	}
+}
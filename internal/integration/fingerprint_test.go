@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFingerprints(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Identity: []string{"name"},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.Fingerprints = true
+	pp.SourceLocations = bcl.SourceLocationsNone
+
+	parse := func(t *testing.T, lines ...string) map[string]bcl.Fingerprint {
+		result, err := pp.Parse("in.bcl", fb(lines...), (&test_pb.File{}).ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := map[string]bcl.Fingerprint{}
+		for _, fp := range result.Fingerprints {
+			if fp.Schema == "test.v1.Element_Foo" {
+				byName[fp.Path] = fp
+			}
+		}
+		return byName
+	}
+
+	before := parse(t,
+		`foo A {`, `  | First`, `}`,
+		`foo B`,
+	)
+	if !assert.Len(t, before, 2) {
+		return
+	}
+	a := before["elements.0.foo"]
+	assert.Equal(t, "in.bcl", *a.Pos.Filename)
+	assert.Equal(t, 0, a.Pos.Start.Line)
+	assert.Len(t, a.ID, 32)
+
+	again := parse(t,
+		`foo A {`, `  | First`, `}`,
+		`foo B`,
+	)
+	assert.Equal(t, before, again, "fingerprints are deterministic")
+
+	// Moved and edited, the ID follows the identity field.
+	edited := parse(t,
+		`foo B`,
+		`foo A {`, `  | Changed`, `}`,
+	)
+	assert.Equal(t, a.ID, edited["elements.1.foo"].ID)
+	assert.NotEqual(t, a.Content, edited["elements.1.foo"].Content)
+	assert.Equal(t, before["elements.1.foo"].Content, edited["elements.0.foo"].Content)
+
+	// Renamed, the content follows the block.
+	renamed := parse(t,
+		`foo C {`, `  | First`, `}`,
+		`foo B`,
+	)
+	assert.NotEqual(t, a.ID, renamed["elements.0.foo"].ID)
+	assert.Equal(t, a.Content, renamed["elements.0.foo"].Content)
+	assert.Equal(t, before["elements.1.foo"], renamed["elements.1.foo"])
+}
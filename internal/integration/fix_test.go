@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/pentops/bcl.go/internal/linter"
+	"github.com/pentops/bcl.go/internal/lsp"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestFixFile(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Job",
+			Unordered:  true,
+			Alias: []*bcl_j5pb.Alias{{
+				Name:       "who",
+				Path:       &bcl_j5pb.Path{Path: []string{"owner"}},
+				Deprecated: true,
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixer := linter.New(pp, func(string) protoreflect.Message {
+		return (&test_pb.Job{}).ProtoReflect()
+	})
+
+	input := fb(
+		`status = "STATUS_ACTIVE"`,
+		`team = "b"`,
+		`history = ["PAUSED", "STATUS_PAUSED"]`,
+		`history += "paused"`,
+		`who = "a"`,
+		`name = 1`,
+		`nope = 1`,
+		``,
+	)
+
+	t.Run("fix", func(t *testing.T) {
+		result, err := fixer.FixFile(context.Background(), &lsp.FileRequest{
+			Filename: "job.bcl",
+			Content:  input,
+		}, project.DefaultConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fb(
+			`history = ["PAUSED", "PAUSED"]`,
+			`history += "PAUSED"`,
+			`name = "1"`,
+			`nope = 1`,
+			`owner = "a"`,
+			`status = "ACTIVE"`,
+			`team = "b"`,
+			``,
+		), result.Content)
+		assert.True(t, result.Changed)
+
+		// Remaining are found again for the sorted file.
+		if assert.Len(t, result.Remaining, 1) {
+			remaining := result.Remaining[0]
+			assert.Equal(t, errpos.CodeUnknownBlock, remaining.ErrorCode())
+			assert.Equal(t, 3, remaining.Pos.Start.Line)
+		}
+	})
+
+	t.Run("keep order", func(t *testing.T) {
+		config := project.DefaultConfig()
+		config.Fix.KeepOrder = true
+		result, err := fixer.FixFile(context.Background(), &lsp.FileRequest{
+			Filename: "job.bcl",
+			Content:  input,
+		}, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fb(
+			`status = "ACTIVE"`,
+			`team = "b"`,
+			`history = ["PAUSED", "PAUSED"]`,
+			`history += "PAUSED"`,
+			`owner = "a"`,
+			`name = "1"`,
+			`nope = 1`,
+			``,
+		), result.Content)
+		if assert.Len(t, result.Remaining, 1) {
+			assert.Equal(t, 6, result.Remaining[0].Pos.Start.Line)
+		}
+	})
+}
+
+func TestEncodeEnumName(t *testing.T) {
+	job := &test_pb.Job{Status: test_pb.Status_STATUS_ACTIVE}
+
+	// The encoder writes enums as fixing them does, without the prefix.
+	buffer := &strings.Builder{}
+	if err := bcl.NewEncoder(buffer, "job").Encode(job); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, buffer.String(), "\tstatus = \"ACTIVE\"\n")
+}
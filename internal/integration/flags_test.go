@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlags(t *testing.T) {
+	newParser := func(flags ...string) *bcl.Parser {
+		block := &bcl_j5pb.Block{SchemaName: "test.v1.Features"}
+		for _, flag := range flags {
+			block.Flags = append(block.Flags, &bcl_j5pb.Flag{FieldName: flag})
+		}
+		pp, err := bcl.NewParser(&bcl_j5pb.Schema{Blocks: []*bcl_j5pb.Block{block}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pp
+	}
+
+	pp := newParser("cache", "debug")
+
+	msg := &test_pb.Features{Debug: true}
+	if _, err := pp.ParseFile("in.bcl", fb(`cache`, `!debug`), msg.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, msg.Cache)
+	assert.False(t, msg.Debug)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "no- prefix sets the field",
+		input: fb(`cache`, `no-cache`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeAlreadySet, Line: 2, Column: 1},
+	}, {
+		name:  "not a flag",
+		input: fb(`verbose`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNotContainer, Line: 1, Column: 1},
+	}, {
+		name:  "negated non flag",
+		input: fb(`!verbose`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNotFlag, Line: 1, Column: 1},
+	}, {
+		name:  "double negative",
+		input: fb(`!no-cache`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNotFlag, Line: 1, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Features{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	_, err := newParser("name").ParseFile("in.bcl", fb(`name`), (&test_pb.Features{}).ProtoReflect())
+	assert.ErrorContains(t, err, "not a boolean field")
+}
@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFmtSchemaOrder(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Unordered:  true,
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name:       &bcl_j5pb.Tag{FieldName: "name"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := (&test_pb.File{}).ProtoReflect().Descriptor()
+
+	input := fb(
+		`sString = "a"`,
+		`foo {`,
+		`  description = "first"`,
+		`  // the name`,
+		`  name = "First"`,
+		`}`,
+		``,
+		`// tags`,
+		`tags.b = "2"`,
+		`rString = ["x"]`,
+		``,
+		`// stays`,
+		``,
+		`tags.a = "1"`,
+	)
+
+	for _, tc := range []struct {
+		name   string
+		config project.FormatConfig
+		want   string
+	}{{
+		name:   "off",
+		config: project.FormatConfig{},
+		want: fb(
+			`sString = "a"`,
+			`foo {`,
+			`	description = "first"`,
+			`	// the name`,
+			`	name = "First"`,
+			`}`,
+			``,
+			`// tags`,
+			`tags.b = "2"`,
+			`rString = ["x"]`,
+			``,
+			`// stays`,
+			``,
+			`tags.a = "1"`,
+		),
+	}, {
+		name:   "tags first",
+		config: project.FormatConfig{TagsFirst: true},
+		want: fb(
+			`sString = "a"`,
+			`foo {`,
+			`	// the name`,
+			`	name = "First"`,
+			`	description = "first"`,
+			`}`,
+			``,
+			`// tags`,
+			`tags.b = "2"`,
+			`rString = ["x"]`,
+			``,
+			`// stays`,
+			``,
+			`tags.a = "1"`,
+		),
+	}, {
+		name:   "attributes first and sorted",
+		config: project.FormatConfig{AttributesFirst: true, SortAttributes: true},
+		want: fb(
+			`rString = ["x"]`,
+			`sString = "a"`,
+			``,
+			`// tags`,
+			`tags.b = "2"`,
+			`foo {`,
+			`	description = "first"`,
+			`	// the name`,
+			`	name = "First"`,
+			`}`,
+			``,
+			`// stays`,
+			``,
+			`tags.a = "1"`,
+		),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := pp.Fmt(input, root, tc.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.want+"\n", got)
+			assert.NoError(t, pp.FormatStable(input, root, tc.config))
+		})
+	}
+}
+
+func TestFormatStable(t *testing.T) {
+	assert.NoError(t, bcl.FormatStable(fb(
+		`// header`,
+		``,
+		``,
+		`a {   // open`,
+		`  b = [ 1, /* two */ 2 ]`,
+		`} // close`,
+	)))
+
+	err := bcl.FormatStable(`a = `)
+	assert.Error(t, err, "syntax errors are returned")
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFragment(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fenced block starting on line 10 of the document, and an inline
+	// fragment at column 5 of line 3.
+	fenced := bcl.FragmentOffset{Filename: "README.md", Line: 9}
+	inline := bcl.FragmentOffset{Filename: "README.md", Line: 2, Column: 4}
+
+	for _, tc := range []struct {
+		name  string
+		at    bcl.FragmentOffset
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown field",
+		at:    fenced,
+		input: fb(`sString = "a"`, `missing = "b"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 11, Column: 1},
+	}, {
+		name:  "syntax error",
+		at:    fenced,
+		input: fb(`foo A`, `sString = = "a"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 11, Column: 11},
+	}, {
+		name:  "first line",
+		at:    inline,
+		input: `missing = "b"`,
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 3, Column: 5},
+	}, {
+		name:  "line directive",
+		at:    fenced,
+		input: fb(`#line service.tmpl:4`, `missing = "b"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 4, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFragment(tc.at, tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	_, err = pp.ParseFragment(fenced, fb(`sString = "a"`, `missing = "b"`), (&test_pb.File{}).ProtoReflect())
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok {
+		t.Fatal("expected errors with source")
+	}
+	posErr := withSource.Errors[0]
+	assert.Equal(t, "README.md:11:1", posErr.Pos.String())
+	assert.Equal(t, "<fragment>:2:1", posErr.Generated.String())
+	assert.Contains(t, withSource.HumanString(0), `missing = "b"`)
+
+	file := &test_pb.File{}
+	if _, err := pp.ParseFragment(fenced, `sString = "a"`, file.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", file.SString)
+}
@@ -0,0 +1,297 @@
+package integration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestFunctions(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.RegisterFunctions(bcl.StandardFunctions())
+	pp.RegisterFunction("len", bcl.Function{
+		Params: []bcl.ValueType{bcl.StringType},
+		Result: bcl.IntType,
+		Call: func(args []interface{}) (interface{}, error) {
+			return int64(len(args[0].(string))), nil
+		},
+	})
+	pp.RegisterFunction("fail", bcl.Function{
+		Result: bcl.StringType,
+		Call: func(args []interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("always fails")
+		},
+	})
+
+	msg, _, err := pp.ParseDynamic("in.bcl", fb(
+		`!bcl 2`,
+		`defaults = [upper("a"), lower(upper("B"))]`,
+		`items = [`,
+		`  uuidv5("6ba7b810-9dad-11d1-80b4-00c04fd430c8", "example.com"),`,
+		`  cidrsubnet("10.0.0.0/16", 8, 2),`,
+		`  cidrsubnet("fd00::/48", 16, 257),`,
+		`]`,
+		`sizes = [len("abc"), 4]`,
+	), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := func(name protoreflect.Name) []string {
+		list := msg.ProtoReflect().Get(root.Fields().ByName(name)).List()
+		out := make([]string, list.Len())
+		for idx := range out {
+			out[idx] = list.Get(idx).String()
+		}
+		return out
+	}
+	assert.Equal(t, []string{"A", "b"}, values("defaults"))
+	assert.Equal(t, []string{
+		"cfbff0d1-9375-5685-968c-48ce8b15ae17",
+		"10.0.2.0/24",
+		"fd00:0:0:101::/64",
+	}, values("items"))
+	assert.Equal(t, []string{"3", "4"}, values("sizes"))
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown function",
+		input: fb(`!bcl 2`, `items = [missing()]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "argument count",
+		input: fb(`!bcl 2`, `items = [upper("a", "b")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "argument type",
+		input: fb(`!bcl 2`, `sizes = [len(1)]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 2, Column: 14},
+	}, {
+		name:  "result type",
+		input: fb(`!bcl 2`, `sizes = [upper("a")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 2, Column: 10},
+	}, {
+		name:  "call fails",
+		input: fb(`!bcl 2`, `items = [fail()]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "edition 1",
+		input: fb(`items = [upper("a")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeEdition, Line: 1, Column: 15},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("in.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	t.Run("allowlist", func(t *testing.T) {
+		pp.AllowFunctions("upper")
+
+		_, _, err := pp.ParseDynamic("in.bcl", fb(`!bcl 2`, `items = [upper("a")]`), root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = pp.ParseDynamic("in.bcl", fb(`!bcl 2`, `items = [lower("a")]`), root)
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10})
+	})
+}
+
+func TestFileFunctions(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := fb(
+		`!bcl 2`,
+		`items = [file("certs/ca.pem"), filebase64("/bin/blob")]`,
+	)
+
+	_, _, err = pp.ParseDynamic("config/in.bcl", input, root)
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10})
+
+	pp.Resolver = project.NewFSResolver(fstest.MapFS{
+		"config/certs/ca.pem": {Data: []byte("-----BEGIN CERTIFICATE-----\n")},
+		"bin/blob":            {Data: []byte{0x00, 0xff}},
+		"secret":              {Data: []byte("x")},
+	})
+
+	msg, _, err := pp.ParseDynamic("config/in.bcl", input, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := msg.ProtoReflect().Get(root.Fields().ByName("items")).List()
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----\n", list.Get(0).String())
+	assert.Equal(t, "AP8=", list.Get(1).String())
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "outside root",
+		input: fb(`!bcl 2`, `items = [file("../../secret")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "missing file",
+		input: fb(`!bcl 2`, `items = [file("missing.pem")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("config/in.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
+
+func TestSecretFunction(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.RegisterFunctions(bcl.StandardFunctions())
+	pp.RegisterFunction("quote", bcl.Function{
+		Params: []bcl.ValueType{bcl.StringType},
+		Result: bcl.StringType,
+		Call: func(args []interface{}) (interface{}, error) {
+			return nil, fmt.Errorf("can't quote %q", args[0])
+		},
+	})
+	pp.Secrets = bcl.SecretSchemes{
+		"vault": bcl.SecretResolverFunc(func(ref string) (string, error) {
+			if ref != "vault://db#password" {
+				return "", fmt.Errorf("no secret at %s", ref)
+			}
+			return "hunter2", nil
+		}),
+	}
+
+	msg, _, err := pp.ParseDynamic("in.bcl", fb(
+		`!bcl 2`,
+		`items = [secret("vault://db#password"), upper(secret("vault://db#password"))]`,
+	), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := msg.ProtoReflect().Get(root.Fields().ByName("items")).List()
+	assert.Equal(t, "hunter2", list.Get(0).String())
+	assert.Equal(t, "HUNTER2", list.Get(1).String())
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown secret",
+		input: fb(`!bcl 2`, `items = [secret("vault://db#missing")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "unknown scheme",
+		input: fb(`!bcl 2`, `items = [secret("env://PASSWORD")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "field type",
+		input: fb(`!bcl 2`, `sizes = [secret("vault://db#password")]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeSensitive, Line: 2, Column: 10},
+	}, {
+		name:  "argument type",
+		input: fb(`!bcl 2`, `items = [cidrsubnet("10.0.0.0/8", secret("vault://db#password"), 1)]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeSensitive, Line: 2, Column: 35},
+	}, {
+		name:  "function error",
+		input: fb(`!bcl 2`, `items = [quote(secret("vault://db#password"))]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("in.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+			assert.NotContains(t, err.Error(), "hunter2")
+		})
+	}
+}
+
+func TestEncryptedValues(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.AllowFunctions()
+
+	input := fb(`!bcl 2`, `items = [enc"AGE-2retnuh", "plain"]`)
+	_, _, err = pp.ParseDynamic("in.bcl", input, root)
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10})
+
+	// Reverses the text after the prefix, standing in for age.
+	pp.Decrypter = bcl.DecrypterPrefixes{
+		"AGE-": bcl.DecrypterFunc(func(ciphertext string) (string, error) {
+			text := []rune(strings.TrimPrefix(ciphertext, "AGE-"))
+			slices.Reverse(text)
+			return string(text), nil
+		}),
+	}
+
+	msg, _, err := pp.ParseDynamic("in.bcl", input, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := msg.ProtoReflect().Get(root.Fields().ByName("items")).List()
+	assert.Equal(t, "hunter2", list.Get(0).String())
+	assert.Equal(t, "plain", list.Get(1).String())
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown prefix",
+		input: fb(`!bcl 2`, `items = [enc"KMS-2retnuh"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeFunction, Line: 2, Column: 10},
+	}, {
+		name:  "field type",
+		input: fb(`!bcl 2`, `sizes = [enc"AGE-2retnuh"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeSensitive, Line: 2, Column: 10},
+	}, {
+		name:  "edition 1",
+		input: fb(`items = [enc"AGE-2retnuh"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeEdition, Line: 1, Column: 13},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("in.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+			assert.NotContains(t, err.Error(), "hunter2")
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
 	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
 	"github.com/stretchr/testify/assert"
@@ -78,6 +79,143 @@ func TestEndToEnd(t *testing.T) {
 		assert.Equal(t, "b-val", msg.Tags["b"])
 	})
 
+	t.Run("multiline array", func(t *testing.T) {
+		msg := run(t, fb(
+			`rString = [`,
+			`  "a",`,
+			`  // about b`,
+			`  "b", // second`,
+			`]`,
+		))
+
+		assert.Equal(t, []string{"a", "b"}, msg.RString)
+		assertLoc(t, msg.SourceLocation, "rString", 0)
+		assertLoc(t, msg.SourceLocation, "rString.0", 1)
+		assertLoc(t, msg.SourceLocation, "rString.1", 3)
+	})
+
+	t.Run("string join", func(t *testing.T) {
+		msg := run(t, fb(
+			`!bcl 2`,
+			`sString = "https://example.com/" +`,
+			`  "path"`,
+		))
+
+		assert.Equal(t, "https://example.com/path", msg.SString)
+		assertLoc(t, msg.SourceLocation, "sString", 1)
+		assert.Equal(t, int32(2), msg.SourceLocation.Children["sString"].EndLine)
+	})
+
+	t.Run("interpolated name", func(t *testing.T) {
+		msg := run(t, fb(
+			`!bcl 2`,
+			`sString = "payments"`,
+			`foo "${sString}-api" {`,
+			`}`,
+			`foo "$${literal}" {`,
+			`}`,
+			"foo `${raw}` {",
+			`}`,
+		))
+
+		if assert.Len(t, msg.Elements, 3) {
+			assert.Equal(t, "payments-api", msg.Elements[0].GetFoo().GetName())
+			assert.Equal(t, "${literal}", msg.Elements[1].GetFoo().GetName())
+			assert.Equal(t, "${raw}", msg.Elements[2].GetFoo().GetName())
+		}
+
+		// Names are not interpolated before edition 2
+		msg = run(t, fb(
+			`sString = "payments"`,
+			`foo "${sString}-api" {`,
+			`}`,
+		))
+		if assert.Len(t, msg.Elements, 1) {
+			assert.Equal(t, "${sString}-api", msg.Elements[0].GetFoo().GetName())
+		}
+	})
+
+	t.Run("with", func(t *testing.T) {
+		msg := run(t, fb(
+			`with tag {`,
+			`  a = "a-val"`,
+			`  b = "b-val"`,
+			`}`,
+			`with elements {`,
+			`  foo Name {`,
+			`  }`,
+			`}`,
+		))
+
+		assert.Equal(t, "a-val", msg.Tags["a"])
+		assert.Equal(t, "b-val", msg.Tags["b"])
+		if assert.Len(t, msg.Elements, 1) {
+			assert.Equal(t, "Name", msg.Elements[0].GetFoo().GetName())
+		}
+	})
+
+	t.Run("error codes", func(t *testing.T) {
+		for _, tc := range []struct {
+			input string
+			code  errpos.Code
+		}{
+			{input: `unknown = "foo"`, code: errpos.CodeUnknownBlock},
+			{input: `sString = 1`, code: errpos.CodeTypeMismatch},
+			{input: fb(`sString = "a"`, `sString = "b"`), code: errpos.CodeAlreadySet},
+			{input: `foo {`, code: errpos.CodeUnbalancedBlock},
+			{input: `sString = "a`, code: errpos.CodeUnexpectedEOF},
+			{input: fb(`with {`, `}`), code: errpos.CodeExpectedTag},
+			{input: fb(`with "tag" {`, `}`), code: errpos.CodeTypeMismatch},
+			{input: fb(`with tag {`, `  | text`, `}`), code: errpos.CodeNoDescription},
+			{input: fb(`!bcl 2`, `foo "${sString}" {`, `}`, `sString = "late"`), code: errpos.CodeInterpolation},
+			{input: fb(`!bcl 2`, `foo "${rString}" {`, `}`), code: errpos.CodeInterpolation},
+			{input: fb(`!bcl 2`, `foo "${sString" {`, `}`), code: errpos.CodeInterpolation},
+			{input: fb(`!bcl 2`, `foo "${missing}" {`, `}`), code: errpos.CodeUnknownBlock},
+			{input: fb(`!bcl 3`), code: errpos.CodeEdition},
+			{input: fb("sString = `raw`"), code: errpos.CodeEdition},
+		} {
+			msg := &test_pb.File{}
+			_, err := pp.ParseFile("in.bcl", tc.input, msg.ProtoReflect())
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.input)
+			}
+			assert.Equal(t, tc.code, errpos.GetErrorCode(err), tc.input)
+		}
+	})
+
+	t.Run("suppressed", func(t *testing.T) {
+		msg := run(t, fb(
+			`unknown = "foo" // bcl:ignore BCL1001`,
+			`sString = "foo"`,
+		))
+		assert.Equal(t, "foo", msg.SString)
+	})
+
+	t.Run("fixes", func(t *testing.T) {
+		for _, tc := range []struct {
+			input string
+			want  string
+		}{
+			{input: `sStrng = "foo"`, want: `sString = "foo"`},
+			{input: `sString = 1`, want: `sString = "1"`},
+		} {
+			msg := &test_pb.File{}
+			_, err := pp.ParseFile("in.bcl", tc.input, msg.ProtoReflect())
+			if err == nil {
+				t.Fatalf("expected error for %q", tc.input)
+			}
+			withSource, ok := errpos.AsErrorsWithSource(err)
+			if !ok || len(withSource.Errors) != 1 || len(withSource.Errors[0].Fixes) != 1 {
+				t.Fatalf("expected one fix for %q, got %v", tc.input, err)
+			}
+			fixed, err := errpos.ApplyEdits(tc.input, withSource.Errors[0].Fixes[0].Edits)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			assert.Equal(t, tc.want, fixed)
+		}
+	})
+
 	t.Run("array flat", func(t *testing.T) {
 		msg := run(t, fb(
 			`rString = ["a", "b"]`,
@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineDirectives(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = pp.ParseDynamic("out.bcl", fb(
+		`defaults = ["x"]`,
+		`#line templates/lists.tmpl:10`,
+		`items = ["a"]`,
+		`sizes = ["x"]`,
+	), root)
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 11, Column: 10})
+
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok {
+		t.Fatal("expected errors with source")
+	}
+	posErr := withSource.Errors[0]
+	assert.Equal(t, "templates/lists.tmpl:11:10", posErr.Pos.String())
+	assert.Equal(t, "out.bcl:4:10", posErr.Generated.String())
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "before the directive",
+		input: fb(`sizes = ["x"]`, `#line lists.tmpl:10`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 10},
+	}, {
+		name:  "syntax error",
+		input: fb(`#line lists.tmpl:3`, `items = ["a" "b"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 3, Column: 14},
+	}, {
+		name:  "second directive",
+		input: fb(`#line a.tmpl:3`, `#line b.tmpl:20`, `items = ["a"]`, `sizes = ["x"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 21, Column: 10},
+	}, {
+		name:  "no line number",
+		input: fb(`#line lists.tmpl`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 1, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("out.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
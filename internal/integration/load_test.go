@@ -0,0 +1,315 @@
+package integration
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestLoadFS(t *testing.T) {
+	root := fstest.MapFS{
+		"bcl.yaml": {Data: []byte("schema: schema.bcl\n")},
+		"schema.bcl": {Data: []byte(fb(
+			`schema {`,
+			`  block test.v1.File {`,
+			`    alias foo {`,
+			`      path.path = ["elements", "foo"]`,
+			`    }`,
+			`  }`,
+			`}`,
+		))},
+		"a.bcl": {Data: []byte(fb(
+			`!owner "payments"`,
+			`sString = "a"`,
+			`foo Name`,
+		))},
+		"sub/b.bcl": {Data: []byte(fb(
+			`sString = "b"`,
+			`bar Name`,
+		))},
+	}
+
+	loaded, err := bcl.LoadFS[*test_pb.File](root)
+	if len(loaded) != 1 {
+		t.Fatalf("expected one good file, got %d (%v)", len(loaded), err)
+	}
+	assert.Equal(t, "a.bcl", loaded[0].Path)
+	assert.Equal(t, "a", loaded[0].Message.SString)
+	assert.Equal(t, "payments", loaded[0].Metadata.Owner)
+	assert.Equal(t, "Name", loaded[0].Message.Elements[0].GetFoo().GetName())
+
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if !ok || len(withSource.Errors) != 1 {
+		t.Fatalf("expected one error, got %v", err)
+	}
+	got := withSource.Errors[0]
+	assert.Equal(t, errpos.CodeUnknownBlock, got.Code)
+	if assert.NotNil(t, got.Pos) && assert.NotNil(t, got.Pos.Filename) {
+		assert.Equal(t, "sub/b.bcl", *got.Pos.Filename)
+		assert.Equal(t, 1, got.Pos.Start.Line)
+	}
+}
+
+func TestLoadFSDiagnostics(t *testing.T) {
+	root := fstest.MapFS{
+		"z.bcl": {Data: []byte(fb(
+			`sString = "z"`,
+			`bar Name`,
+		))},
+		"a.bcl":  {Data: []byte(`bar Name`)},
+		"ok.bcl": {Data: []byte(`sString = "ok"`)},
+	}
+
+	loaded, err := bcl.LoadFS[*test_pb.File](root)
+	assert.Len(t, loaded, 1)
+
+	diags, ok := errpos.AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %v", err)
+	}
+	if assert.Len(t, diags.Files, 2) {
+		assert.Equal(t, "a.bcl", diags.Files[0].Filename)
+		assert.Equal(t, "z.bcl", diags.Files[1].Filename)
+		assert.Equal(t, 1, diags.Files[1].Errors[0].Pos.Start.Line)
+	}
+
+	summary := diags.Summary()
+	assert.Equal(t, 2, summary.Files)
+	assert.Equal(t, 2, summary.Total)
+	assert.Equal(t, 2, summary.ByCode[errpos.CodeUnknownBlock])
+	assert.Equal(t, "2 errors in 2 files", summary.String())
+
+	// The errors of the first file are found as for a single file.
+	withSource, ok := errpos.AsErrorsWithSource(err)
+	if assert.True(t, ok) && assert.Len(t, withSource.Errors, 1) {
+		assert.Equal(t, "a.bcl", *withSource.Errors[0].Pos.Filename)
+	}
+}
+
+func TestLoadFSProgress(t *testing.T) {
+	root := fstest.MapFS{
+		"a.bcl": {Data: []byte(`sString = "a"`)},
+		"b.bcl": {Data: []byte(`bar Name`)},
+		"c.bcl": {Data: []byte(`sString = `)},
+	}
+
+	events := []string{}
+	_, err := bcl.LoadFSWithProgress[*test_pb.File](root, func(progress bcl.Progress) {
+		event := progress.Stage.String() + " " + progress.Filename
+		if progress.Stage == bcl.ProgressDiscovered {
+			event = fmt.Sprintf("discovered %d", progress.Files)
+		}
+		if progress.Failed {
+			event += " failed"
+		}
+		if progress.Stage == bcl.ProgressValidated && progress.Stats.Total() <= 0 {
+			t.Errorf("%s has no duration", progress.Filename)
+		}
+		events = append(events, event)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"discovered 3",
+		"parsed a.bcl",
+		"validated a.bcl",
+		"parsed b.bcl failed",
+		"parsed c.bcl failed",
+	}, events)
+}
+
+func TestLoadFSOverride(t *testing.T) {
+	schema := fb(
+		`schema {`,
+		`  block test.v1.File {`,
+		`    alias foo {`,
+		`      path.path = ["elements", "foo"]`,
+		`    }`,
+		`  }`,
+		`  block test.v1.Element.Foo {`,
+		`    name.fieldName = "name"`,
+		`  }`,
+		`}`,
+	)
+	base := fb(
+		`sString = "base"`,
+		`rString = ["a"]`,
+		``,
+		`foo First {`,
+		`  description = "first"`,
+		`}`,
+		``,
+		`foo Second {`,
+		`}`,
+	)
+
+	root := fstest.MapFS{
+		"bcl.yaml":   {Data: []byte("schema: schema.bcl\noverrideSuffix: .override\n")},
+		"schema.bcl": {Data: []byte(schema)},
+		"a.bcl":      {Data: []byte(base)},
+		"a.override.bcl": {Data: []byte(fb(
+			`sString = "override"`,
+			`foo First {`,
+			`  description = "changed"`,
+			`}`,
+			`foo Second {`,
+			`  description = "added"`,
+			`}`,
+		))},
+	}
+
+	loaded, err := bcl.LoadFS[*test_pb.File](root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, loaded, 1) {
+		msg := loaded[0].Message
+		assert.Equal(t, "a.bcl", loaded[0].Path)
+		assert.Equal(t, "override", msg.SString)
+		assert.Equal(t, []string{"a"}, msg.RString)
+		if assert.Len(t, msg.Elements, 2) {
+			assert.Equal(t, "changed", msg.Elements[0].GetFoo().Description)
+			assert.Equal(t, "added", msg.Elements[1].GetFoo().Description)
+		}
+	}
+
+	root["a.override.bcl"] = &fstest.MapFile{Data: []byte(fb(
+		`foo First {`,
+		`  description = "changed"`,
+		`}`,
+		`foo Third {`,
+		`  description = "new"`,
+		`}`,
+	))}
+	root["b.override.bcl"] = &fstest.MapFile{Data: []byte(`sString = "b"`)}
+
+	loaded, err = bcl.LoadFS[*test_pb.File](root)
+	assert.Len(t, loaded, 0)
+	diags, ok := errpos.AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %v", err)
+	}
+	if assert.Len(t, diags.Files, 2) {
+		assert.Equal(t, "a.override.bcl", diags.Files[0].Filename)
+		got := diags.Files[0].Errors[0]
+		assert.Equal(t, errpos.CodeOverrideBlock, got.Code)
+		assert.Equal(t, 3, got.Pos.Start.Line)
+		assert.ErrorContains(t, got, "block foo Third is not in the base file")
+
+		assert.Equal(t, "b.override.bcl", diags.Files[1].Filename)
+		assert.ErrorContains(t, diags.Files[1].Errors[0], "override file has no base file b.bcl")
+	}
+
+	// A dotted assignment can't create a block the base doesn't have.
+	root["a.override.bcl"] = &fstest.MapFile{Data: []byte(fb(
+		`foo.Third.description = "new"`,
+	))}
+	delete(root, "b.override.bcl")
+	_, err = bcl.LoadFS[*test_pb.File](root)
+	diags, ok = errpos.AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %v", err)
+	}
+	if assert.Len(t, diags.Files, 1) {
+		assert.Equal(t, "a.override.bcl", diags.Files[0].Filename)
+		got := diags.Files[0].Errors[0]
+		assert.Equal(t, errpos.CodeOverrideBlock, got.Code)
+		assert.ErrorContains(t, got, "block foo Third is not in the base file")
+	}
+
+	// Errors in the applied values are in the override file.
+	root["a.override.bcl"] = &fstest.MapFile{Data: []byte(fb(
+		`// wrong type`,
+		`sString = 1`,
+	))}
+	_, err = bcl.LoadFS[*test_pb.File](root)
+	diags, ok = errpos.AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %v", err)
+	}
+	if assert.Len(t, diags.Files, 1) && assert.Len(t, diags.Files[0].Errors, 1) {
+		assert.Equal(t, "a.override.bcl", diags.Files[0].Filename)
+		got := diags.Files[0].Errors[0]
+		assert.Equal(t, errpos.Point{Line: 1, Column: 10}, got.Pos.Start)
+		assert.Equal(t, "a.override.bcl", *got.Pos.Filename)
+	}
+
+	// Others are in the base, at their line before the override added to it.
+	root["a.bcl"] = &fstest.MapFile{Data: []byte(base + "\nunknown = 1")}
+	root["a.override.bcl"] = &fstest.MapFile{Data: []byte(fb(
+		`foo Second {`,
+		`  description = "added"`,
+		`}`,
+	))}
+	_, err = bcl.LoadFS[*test_pb.File](root)
+	diags, ok = errpos.AsDiagnostics(err)
+	if !ok {
+		t.Fatalf("expected diagnostics, got %v", err)
+	}
+	if assert.Len(t, diags.Files, 1) && assert.Len(t, diags.Files[0].Errors, 1) {
+		assert.Equal(t, "a.bcl", diags.Files[0].Filename)
+		assert.Equal(t, 9, diags.Files[0].Errors[0].Pos.Start.Line)
+	}
+	root["a.bcl"] = &fstest.MapFile{Data: []byte(base)}
+
+	// Override files are opt-in, without a suffix they are plain files.
+	root["bcl.yaml"] = &fstest.MapFile{Data: []byte("schema: schema.bcl\n")}
+	loaded, err = bcl.LoadFS[*test_pb.File](root)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 2)
+}
+
+func TestLoadFSWith(t *testing.T) {
+	root := fstest.MapFS{
+		"bcl.yaml":       {Data: []byte("overrideSuffix: .override\nexclude: [skip.bcl]\n")},
+		"a.bcl":          {Data: []byte(`sString = "base"`)},
+		"a.override.bcl": {Data: []byte(`sString = "override"`)},
+		"skip.bcl":       {Data: []byte(`unknown = 1`)},
+	}
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := (&test_pb.File{}).ProtoReflect().Descriptor()
+	loaded, err := bcl.LoadFSWith(pp, root, func() *dynamicpb.Message {
+		return dynamicpb.NewMessage(desc)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, loaded, 1) {
+		assert.Equal(t, "a.bcl", loaded[0].Path)
+		assert.Equal(t, "override", loaded[0].Message.Get(desc.Fields().ByName("s_string")).String())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+		`sString = "a"`,
+		`rString = ["x"]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", msg.SString)
+	assert.Equal(t, []string{"x"}, msg.RString)
+	if assert.NotNil(t, locs.Children["sString"]) {
+		assert.Equal(t, int32(0), locs.Children["sString"].StartLine)
+	}
+
+	msg, _, err = bcl.Decode[*test_pb.File](pp, "in.bcl", `unknown = 1`)
+	assert.Error(t, err)
+	assert.NotNil(t, msg, "a new message even on error")
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	pp := benchParser(t)
+	entries := []bcl.LogEntry{}
+	pp.Logger = bcl.LoggerFunc(func(entry bcl.LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	msg := &test_pb.File{}
+	_, err := pp.Parse("in.bcl", fb(
+		`sString = "a"`,
+		`foo Name {`,
+		`  description = "x"`,
+		`}`,
+	), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := map[string]bcl.LogEntry{}
+	for _, entry := range entries {
+		assert.Equal(t, "in.bcl", entry.File)
+		if _, ok := events[entry.Event]; !ok {
+			events[entry.Event] = entry
+		}
+	}
+	for _, event := range []string{"assign", "set_attribute", "block", "enter_block", "exit_block"} {
+		assert.Contains(t, events, event)
+	}
+	if assign, ok := events["assign"]; ok && assert.NotNil(t, assign.Position) {
+		assert.Equal(t, 0, assign.Position.Start.Line)
+		assert.Contains(t, assign.Message, "sString")
+	}
+
+	buf := &bytes.Buffer{}
+	pp.Logger = bcl.SlogLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	_, err = pp.Parse("in.bcl", `sString = "a"`, msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, _, _ := bytes.Cut(buf.Bytes(), []byte("\n"))
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(line, &record); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "DEBUG", record["level"])
+	assert.Equal(t, "assign", record["event"])
+	assert.Equal(t, "in.bcl", record["file"])
+	assert.Equal(t, "1:1", record["position"])
+
+	// Loggers above debug level are not sent the events.
+	buf.Reset()
+	pp.Logger = bcl.SlogLogger(slog.New(slog.NewJSONHandler(buf, nil)))
+	_, err = pp.Parse("in.bcl", `sString = "a"`, msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.File"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &test_pb.File{}
+	result, err := pp.Parse("in.bcl", fb(
+		`// header`,
+		`!version "3"`,
+		`!owner "payments"`,
+		`!description "Payment service config"`,
+		``,
+		`sString = "a"`,
+	), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", msg.SString)
+	assert.Equal(t, bcl.Metadata{
+		Edition:     1,
+		Version:     "3",
+		Owner:       "payments",
+		Description: "Payment service config",
+	}, result.Metadata)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown key",
+		input: fb(`!team "payments"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 1, Column: 1},
+	}, {
+		name:  "duplicate key",
+		input: fb(`!owner "a"`, `!owner "b"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 2, Column: 1},
+	}, {
+		name:  "after a statement",
+		input: fb(`sString = "a"`, `!owner "a"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 2, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.Parse("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestNamespaces(t *testing.T) {
+	features := (&test_pb.Features{}).ProtoReflect().Descriptor()
+	lists := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pp.RegisterSchema("features", &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Features"}},
+	}, dynamicpb.NewMessageType(features))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pp.RegisterSchema("registry/lists.v1", &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	}, dynamicpb.NewMessageType(lists))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, results, err := pp.ParseNamespaces("in.bcl", fb(
+		`namespace features {`,
+		`  name = "x"`,
+		`}`,
+		`namespace "registry/lists.v1" {`,
+		`  items = ["a"]`,
+		`}`,
+		`namespace "registry/lists.v1" {`,
+		`  items += ["b"]`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, msgs, 2) {
+		assert.Equal(t, "x", msgs["features"].Get(features.Fields().ByName("name")).String())
+		assert.Equal(t, 2, msgs["registry/lists.v1"].Get(lists.Fields().ByName("items")).List().Len())
+	}
+	assert.Len(t, results, 2)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "outside a namespace",
+		input: fb(`name = "x"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNamespace, Line: 1, Column: 1},
+	}, {
+		name:  "no name",
+		input: fb(`namespace {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeNamespace, Line: 1, Column: 1},
+	}, {
+		name:  "unknown schema",
+		input: fb(`namespace missing {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownSchema, Line: 1, Column: 11},
+	}, {
+		name:  "error in a namespace",
+		input: fb(`namespace features {`, `  missing = "x"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2, Column: 3},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseNamespaces("in.bcl", tc.input)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
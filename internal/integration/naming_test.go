@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/ast"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckNaming(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name:       &bcl_j5pb.Tag{FieldName: "name"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := fb(
+		`!bcl 2`,
+		`foo "first-block" {`,
+		`}`,
+		`foo "HTTPServer" {`,
+		`}`,
+		`foo secondBlock {`,
+		`}`,
+		`tags.my_key = "a"`,
+		`tags."other key" = "b"`,
+		`tags.myKey2 = "c"`,
+		`tags.my_key2 = "d"`,
+	)
+	file := &test_pb.File{}
+	result, err := pp.ParseFile("in.bcl", input, file.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree, err := ast.Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := pp.CheckNaming(bcl.SourceFile{
+		Filename:       "in.bcl",
+		Message:        file.ProtoReflect(),
+		SourceLocation: result,
+		Source:         input,
+		AST:            tree,
+	}, map[string]project.Case{
+		"test.v1.Element_Foo": project.CamelCase,
+		"test.v1.File.tags":   project.CamelCase,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type finding struct {
+		line, column int
+		fix          string
+	}
+	got := []finding{}
+	fixes := []errpos.Edit{}
+	for _, err := range errs {
+		assert.Equal(t, errpos.CodeNaming, err.Code)
+		assert.Equal(t, errpos.SeverityWarning, err.Severity)
+		found := finding{line: err.Pos.Start.Line + 1, column: err.Pos.Start.Column + 1}
+		if len(err.Fixes) > 0 {
+			found.fix = err.Fixes[0].Title
+			fixes = append(fixes, err.Fixes[0].Edits...)
+		}
+		got = append(got, found)
+	}
+	assert.Equal(t, []finding{
+		{line: 2, column: 5, fix: "Rename first-block to firstBlock"},
+		// the words of an acronym are ambiguous
+		{line: 4, column: 5},
+		{line: 8, column: 6, fix: "Rename my_key to myKey"},
+		{line: 9, column: 6, fix: "Rename other key to otherKey"},
+		// the fix would clash with myKey2
+		{line: 11, column: 6},
+	}, got)
+
+	fixed, err := errpos.ApplyEdits(input, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`!bcl 2`,
+		`foo "firstBlock" {`,
+		`}`,
+		`foo "HTTPServer" {`,
+		`}`,
+		`foo secondBlock {`,
+		`}`,
+		`tags.myKey = "a"`,
+		`tags."otherKey" = "b"`,
+		`tags.myKey2 = "c"`,
+		`tags.my_key2 = "d"`,
+	), fixed)
+}
@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestArrayOfArrays(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Row",
+			ScalarSplit: &bcl_j5pb.ScalarSplit{
+				RequiredFields: []*bcl_j5pb.Path{{Path: []string{"name"}}},
+				RemainderField: &bcl_j5pb.Path{Path: []string{"cells"}},
+			},
+		}, {
+			SchemaName: "test.v1.Sheet",
+			ScalarSplit: &bcl_j5pb.ScalarSplit{
+				RequiredFields: []*bcl_j5pb.Path{{Path: []string{"name"}}},
+				RemainderField: &bcl_j5pb.Path{Path: []string{"rows"}},
+			},
+		}, {
+			SchemaName: "test.v1.Tagged",
+			ScalarSplit: &bcl_j5pb.ScalarSplit{
+				RequiredFields: []*bcl_j5pb.Path{{Path: []string{"name"}}},
+				RemainderField: &bcl_j5pb.Path{Path: []string{"attrs"}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &test_pb.Table{}
+	locs, err := pp.ParseFile("in.bcl", fb(
+		`rows = [`,
+		`  ["a", "1", "2"],`,
+		`  ["b"],`,
+		`]`,
+		`rows += ["c", "3"]`,
+	), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &test_pb.Table{
+		Rows: []*test_pb.Row{
+			{Name: "a", Cells: []string{"1", "2"}},
+			{Name: "b"},
+			{Name: "c", Cells: []string{"3"}},
+		},
+	}
+	assert.True(t, proto.Equal(want, msg), "got %v", msg)
+
+	assertLoc(t, locs, "rows.1", 2)
+	assertLoc(t, locs, "rows.0.cells.1", 1)
+
+	t.Run("array of arrays of blocks", func(t *testing.T) {
+		msg := &test_pb.Table{}
+		_, err := pp.ParseFile("in.bcl", fb(
+			`sheets = [`,
+			`  ["first", ["a", "1"], ["b"]],`,
+			`  ["empty"],`,
+			`]`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &test_pb.Table{
+			Sheets: []*test_pb.Sheet{{
+				Name: "first",
+				Rows: []*test_pb.Row{
+					{Name: "a", Cells: []string{"1"}},
+					{Name: "b"},
+				},
+			}, {
+				Name: "empty",
+			}},
+		}
+		assert.True(t, proto.Equal(want, msg), "got %v", msg)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "scalar for array",
+		input: fb(`rows = "a"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 8},
+	}, {
+		name:  "scalar element",
+		input: fb(`rows = [["a"], "b"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 16},
+	}, {
+		name:  "map remainder",
+		input: fb(`tagged = [["a", "b"]]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 17},
+	}, {
+		name:  "map value",
+		input: fb(`labels = ["a"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 10},
+	}, {
+		name:  "no scalar split",
+		input: fb(`plain = [["a"]]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 10},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Table{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
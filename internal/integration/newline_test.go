@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLineEndings(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName:       "test.v1.Element_Foo",
+			Name:             &bcl_j5pb.Tag{FieldName: "name"},
+			DescriptionField: proto.String("description"),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := (&test_pb.File{}).ProtoReflect().Descriptor()
+
+	crlf := func(s ...string) string {
+		return strings.Join(s, "\r\n") + "\r\n"
+	}
+	input := errpos.ByteOrderMark + crlf(
+		`// c`,
+		`sString = "a"`,
+		`foo a {`,
+		`  | desc`,
+		`  // x`,
+		`}`,
+	)
+
+	t.Run("parse", func(t *testing.T) {
+		file := &test_pb.File{}
+		if _, err := pp.ParseFile("in.bcl", input, file.ProtoReflect()); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "a", file.SString)
+		assert.Equal(t, "desc", file.Elements[0].GetFoo().Description)
+
+		for _, tc := range []struct {
+			name  string
+			input string
+			want  bcltest.Diagnostic
+		}{{
+			name:  "lone CR",
+			input: "sString = \"a\"\rmissing = 1\r",
+			want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2, Column: 1},
+		}, {
+			name:  "mixed",
+			input: "sString = \"a\"\r\n\n\rfoo a {\r\n  missing = 1\n}",
+			want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 5, Column: 3},
+		}, {
+			name:  "byte order mark",
+			input: errpos.ByteOrderMark + "missing = 1",
+			want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 1, Column: 1},
+		}} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+				bcltest.AssertDiagnostics(t, err, tc.want)
+			})
+		}
+	})
+
+	t.Run("fmt", func(t *testing.T) {
+		for _, tc := range []struct {
+			name   string
+			config project.FormatConfig
+			want   string
+		}{{
+			name: "kept",
+			want: errpos.ByteOrderMark + crlf(
+				`// c`,
+				`sString = "a"`,
+				`foo a {`,
+				"\t| desc",
+				"\t// x",
+				`}`,
+			),
+		}, {
+			name: "configured",
+			config: project.FormatConfig{
+				LineEndings:   "lf",
+				IndentSpaces:  2,
+				ByteOrderMark: "remove",
+			},
+			want: fb(
+				`// c`,
+				`sString = "a"`,
+				`foo a {`,
+				`  | desc`,
+				`  // x`,
+				`}`,
+			) + "\n",
+		}} {
+			t.Run(tc.name, func(t *testing.T) {
+				got, err := pp.Fmt(input, root, tc.config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, tc.want, got)
+			})
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		got, err := bcl.SetValue(input, "foo.a.description", `"d"`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, errpos.ByteOrderMark+crlf(
+			`// c`,
+			`sString = "a"`,
+			`foo a {`,
+			`  | desc`,
+			`  // x`,
+			`  description = "d"`,
+			`}`,
+		), got)
+	})
+}
@@ -0,0 +1,162 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNormalizers(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			Normalizers: []*bcl_j5pb.Normalizer{{
+				FieldName: "sString",
+				Steps:     []string{"trim", "url"},
+			}, {
+				FieldName: "rString",
+				Steps:     []string{"clean_path"},
+			}},
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "rString",
+				MaxLength: proto.Uint64(6),
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Normalizers: []*bcl_j5pb.Normalizer{{
+				FieldName: "name",
+				Steps:     []string{"lowercase", "no_dashes"},
+			}},
+		}},
+	}
+
+	noDashes := bcl.WithNormalizer("no_dashes", func(s string) (string, error) {
+		if strings.HasPrefix(s, "-") {
+			return "", fmt.Errorf("leading dash")
+		}
+		return strings.ReplaceAll(s, "-", "_"), nil
+	})
+
+	newParser := func(t *testing.T) *bcl.Parser {
+		t.Helper()
+		pp, err := bcl.NewParser(spec, noDashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pp
+	}
+
+	t.Run("applied", func(t *testing.T) {
+		pp := newParser(t)
+		msg := &test_pb.File{}
+		result, err := pp.Parse("in.bcl", fb(
+			`sString = " HTTPS://Example.COM:443 "`,
+			`rString = ["a/./b/", "/c"]`,
+			`foo "My-Name"`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, "https://example.com/", msg.SString)
+		assert.Equal(t, []string{"a/b", "/c"}, msg.RString)
+		assert.Equal(t, "my_name", msg.Elements[0].GetFoo().Name)
+
+		if assert.Len(t, result.Normalized, 3) {
+			first := result.Normalized[0]
+			assert.Equal(t, "test.v1.File", first.Schema)
+			assert.Equal(t, "sString", first.Field)
+			assert.Equal(t, " HTTPS://Example.COM:443 ", first.Original)
+			assert.Equal(t, "https://example.com/", first.Value)
+			assert.Equal(t, "in.bcl:1:11", first.Pos.String())
+
+			assert.Equal(t, "a/./b/", result.Normalized[1].Original)
+			assert.Equal(t, "My-Name", result.Normalized[2].Original)
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+		msg   string
+	}{{
+		name:  "rejected",
+		input: fb(`foo "-name"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeInvalidValue, Line: 1},
+		msg:   "leading dash",
+	}, {
+		name:  "constraint after",
+		input: fb(`rString = ["abc/../defghij"]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeConstraint, Line: 1, Column: 12},
+		msg:   `"defghij" (normalized from "abc/../defghij")`,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp := newParser(t)
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+			if err != nil {
+				assert.Contains(t, err.Error(), tc.msg)
+			}
+		})
+	}
+
+	t.Run("unknown step", func(t *testing.T) {
+		_, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Normalizers: []*bcl_j5pb.Normalizer{{
+					FieldName: "sString",
+					Steps:     []string{"shout"},
+				}},
+			}},
+		})
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `normalizer of test.v1.File.sString: no normalizer named "shout"`)
+		}
+
+		_, err = bcl.NewParser(spec)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `no normalizer named "no_dashes"`)
+		}
+	})
+
+	t.Run("compiled", func(t *testing.T) {
+		compiled, err := bcl.CompileSchema(spec, (&test_pb.File{}).ProtoReflect().Descriptor())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := compiled.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := &bcl.CompiledSchema{}
+		if err := loaded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		pp, err := bcl.NewCompiledParser(loaded, noDashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := &test_pb.File{}
+		if _, err := pp.ParseFile("in.bcl", fb(`sString = " http://a.b "`), msg.ProtoReflect()); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "http://a.b/", msg.SString)
+	})
+}
@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOneofConflicts(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.File"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conflicting := fb(
+		`elements {`,
+		`  foo.name = "a"`,
+		`  bar.name = "b"`,
+		`}`,
+	)
+
+	t.Run("error", func(t *testing.T) {
+		for _, tc := range []struct {
+			name  string
+			input string
+			want  []bcltest.Diagnostic
+		}{{
+			name:  "attributes",
+			input: conflicting,
+			want:  []bcltest.Diagnostic{{Code: errpos.CodeOneofConflict, Line: 3, Column: 3}},
+		}, {
+			name:  "blocks",
+			input: fb(`elements {`, `  foo {`, `    name = "a"`, `  }`, `  bar {`, `    name = "b"`, `  }`, `}`),
+			want:  []bcltest.Diagnostic{{Code: errpos.CodeOneofConflict, Line: 5, Column: 3}},
+		}, {
+			name:  "same member",
+			input: fb(`elements {`, `  foo.name = "a"`, `  foo.description = "b"`, `}`),
+		}, {
+			name:  "separate elements",
+			input: fb(`elements {`, `  foo.name = "a"`, `}`, `elements {`, `  bar.name = "b"`, `}`),
+		}, {
+			name:  "suppressed",
+			input: fb(`elements {`, `  foo.name = "a"`, `  bar.name = "b" // bcl:ignore BCL2008`, `}`),
+		}} {
+			t.Run(tc.name, func(t *testing.T) {
+				_, err := pp.Parse("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+				bcltest.AssertDiagnostics(t, err, tc.want...)
+			})
+		}
+
+		_, err := pp.Parse("in.bcl", conflicting, (&test_pb.File{}).ProtoReflect())
+		assert.ErrorContains(t, err, "bar conflicts with foo of oneof type, set at in.bcl:2:3")
+
+		unlocated := *pp
+		unlocated.SourceLocations = bcl.SourceLocationsNone
+		_, err = unlocated.Parse("in.bcl", conflicting, (&test_pb.File{}).ProtoReflect())
+		assert.ErrorContains(t, err, "bar conflicts with foo of oneof type, set at in.bcl:2:3")
+	})
+
+	t.Run("first", func(t *testing.T) {
+		first := *pp
+		first.OneofConflicts = bcl.OneofConflictFirst
+		msg := &test_pb.File{}
+		result, err := first.Parse("in.bcl", conflicting, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "a", msg.Elements[0].GetFoo().GetName())
+		assert.Nil(t, msg.Elements[0].GetBar())
+		if assert.Len(t, result.Warnings, 1) {
+			warning := result.Warnings[0]
+			assert.Equal(t, errpos.CodeOneofConflict, warning.ErrorCode())
+			assert.Equal(t, errpos.SeverityWarning, warning.Severity)
+			assert.Equal(t, "in.bcl:3:3", warning.Pos.String())
+		}
+	})
+
+	t.Run("last", func(t *testing.T) {
+		last := *pp
+		last.OneofConflicts = bcl.OneofConflictLast
+		msg := &test_pb.File{}
+		result, err := last.Parse("in.bcl", conflicting, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, msg.Elements[0].GetFoo())
+		assert.Equal(t, "b", msg.Elements[0].GetBar().GetName())
+		assert.Len(t, result.Warnings, 1)
+
+		element := result.SourceLocation.Children["elements"].Children["0"]
+		assert.Nil(t, element.Children["foo"])
+		assert.NotNil(t, element.Children["bar"])
+		assert.Equal(t, 1, result.Stats.Blocks)
+	})
+}
@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolOrder(t *testing.T) {
+	// Foo blocks depend on the Foo named in their description.
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			References: []*bcl_j5pb.Reference{{
+				FieldName: "description",
+				Target:    "test.v1.Element_Foo",
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(t *testing.T, lines ...string) *bcl.SymbolTable {
+		msg, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(lines...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, err := bcl.BuildSymbols(spec, []bcl.SourceFile{{
+			Filename:       "in.bcl",
+			Message:        msg.ProtoReflect(),
+			SourceLocation: locs,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return table
+	}
+
+	order := func(t *testing.T, lines ...string) ([]string, error) {
+		symbols, err := build(t, lines...).Order()
+		names := make([]string, len(symbols))
+		for idx, symbol := range symbols {
+			names[idx] = symbol.Name
+		}
+		return names, err
+	}
+
+	t.Run("dependencies first", func(t *testing.T) {
+		names, err := order(t,
+			`foo A {`, `  description = "B"`, `}`,
+			`foo B`,
+			`foo C {`, `  description = "A"`, `}`,
+			`foo D`,
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"B", "A", "C", "D"}, names)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		names, err := order(t,
+			`foo X {`, `  description = "Y"`, `}`,
+			`foo Y {`, `  description = "Z"`, `}`,
+			`foo Z {`, `  description = "X"`, `}`,
+		)
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{
+			Code: errpos.CodeReferenceCycle,
+			Line: 8,
+		})
+		assert.Contains(t, err.Error(), "X -> Y -> Z -> X")
+		assert.Contains(t, err.Error(), "X names Y at in.bcl:2:17, Y names Z at in.bcl:5:17, Z names X at in.bcl:8:17")
+		assert.Len(t, names, 3)
+	})
+
+	t.Run("graph", func(t *testing.T) {
+		table := build(t,
+			`foo A {`, `  description = "B"`, `}`,
+			`foo B`,
+		)
+		if assert.Len(t, table.Dependencies(), 1) {
+			assert.Equal(t, "A", table.Dependencies()[0].From.Name)
+		}
+
+		out := &strings.Builder{}
+		if err := table.WriteDOT(out); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fb(
+			`digraph bcl {`,
+			`  subgraph cluster_0 {`,
+			`    label="in.bcl";`,
+			`    n0 [label="A\ntest.v1.Element_Foo"];`,
+			`    n1 [label="B\ntest.v1.Element_Foo"];`,
+			`  }`,
+			`  n0 -> n1;`,
+			`}`,
+			``,
+		), out.String())
+
+		out.Reset()
+		if err := table.Graph().Write(out, bcl.GraphJSON); err != nil {
+			t.Fatal(err)
+		}
+		graph := &bcl.Graph{}
+		if err := json.Unmarshal([]byte(out.String()), graph); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, &bcl.Graph{
+			Nodes: []bcl.GraphNode{
+				{ID: "n0", Schema: "test.v1.Element_Foo", Name: "A", File: "in.bcl", Position: "in.bcl:1:5"},
+				{ID: "n1", Schema: "test.v1.Element_Foo", Name: "B", File: "in.bcl", Position: "in.bcl:4:5"},
+			},
+			Edges: []bcl.GraphEdge{{From: "n0", To: "n1", Position: "in.bcl:2:17"}},
+		}, graph)
+	})
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceOrigin(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, locs, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+		`!bcl 2`,
+		`sString = "a"`,
+		`rString = ["a"]`,
+		`rString += [rString...]`,
+		`foo Name {`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origin := func(path ...string) bcl_j5pb.Origin {
+		loc := locs
+		for _, name := range path {
+			loc = loc.Children[name]
+			if loc == nil {
+				t.Fatalf("no location at %v", path)
+			}
+		}
+		return loc.Origin
+	}
+
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, origin("sString"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, origin("rString"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, origin("rString", "0"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED, origin("rString", "1"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SCHEMA, origin("elements"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SCHEMA, origin("elements", "0"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, origin("elements", "0", "foo"))
+	assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, origin("elements", "0", "foo", "name"))
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+)
+
+func TestPolicies(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			Policies: []*bcl_j5pb.Policy{{
+				Expression: `size(this.elements) == 0 || this.s_string != ""`,
+				Message:    "files with elements need sString",
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+			Policies: []*bcl_j5pb.Policy{{
+				Expression: `this.description != "" || this.name.startsWith("x")`,
+			}, {
+				Expression: `file == "in.bcl" && line >= 1 && column == 1`,
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.FailFast = false
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []bcltest.Diagnostic
+	}{{
+		name:  "valid",
+		input: fb(`sString = "abc"`, `foo xName`),
+	}, {
+		name:  "empty",
+		input: fb(),
+	}, {
+		name:  "root",
+		input: fb(`foo xName`, `foo xOther`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodePolicy, Line: 1}},
+	}, {
+		name:  "nested",
+		input: fb(`sString = "abc"`, `foo Name`, `foo Other {`, `  | Described`, `}`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodePolicy, Line: 2}},
+	}, {
+		name: "suppressed",
+		input: fb(
+			`sString = "abc"`,
+			`// bcl:ignore BCL4002`,
+			`foo Name`,
+		),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want...)
+		})
+	}
+
+	t.Run("lazy", func(t *testing.T) {
+		lazy, err := bcl.NewParser(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lazy.SourceLocations = bcl.SourceLocationsLazy
+		_, err = lazy.ParseFile("in.bcl", fb(`sString = "abc"`, `foo Name`), (&test_pb.File{}).ProtoReflect())
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodePolicy, Line: 2})
+	})
+
+	t.Run("not bool", func(t *testing.T) {
+		notBool, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.File",
+				Policies:   []*bcl_j5pb.Policy{{Expression: `this.s_string`}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = notBool.ParseFile("in.bcl", fb(`sString = "abc"`), (&test_pb.File{}).ProtoReflect())
+		if err == nil {
+			t.Fatal("expected an error for a policy which is not bool")
+		}
+	})
+
+	_, err = bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Policies:   []*bcl_j5pb.Policy{{Expression: `this.s_string ==`}},
+		}},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+}
@@ -0,0 +1,198 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, _, err := bcl.Decode[*test_pb.File](pp, "in.bcl", fb(
+		`sString = "a"`,
+		`rString = ["x", "y"]`,
+		`tag.k = "v"`,
+		`foo One {`,
+		`}`,
+		`foo Two {`,
+		`  description = "second"`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		paths []string
+		want  []string
+	}{{
+		expr:  "sString",
+		paths: []string{"sString"},
+		want:  []string{"a"},
+	}, {
+		expr:  "elements[0].foo.name",
+		paths: []string{"elements.0.foo.name"},
+		want:  []string{"One"},
+	}, {
+		expr:  "elements[-1].foo.description",
+		paths: []string{"elements.1.foo.description"},
+		want:  []string{"second"},
+	}, {
+		expr:  "elements[*].foo.name",
+		paths: []string{"elements.0.foo.name", "elements.1.foo.name"},
+		want:  []string{"One", "Two"},
+	}, {
+		expr:  "r_string",
+		paths: []string{"rString"},
+		want:  []string{`["x","y"]`},
+	}, {
+		expr:  `tags["k"]`,
+		paths: []string{"tags.k"},
+		want:  []string{"v"},
+	}, {
+		expr:  "tags.k",
+		paths: []string{"tags.k"},
+		want:  []string{"v"},
+	}, {
+		expr:  "tags",
+		paths: []string{"tags"},
+		want:  []string{`{"k":"v"}`},
+	}, {
+		expr:  "elements[0].foo",
+		paths: []string{"elements.0.foo"},
+		want:  []string{`{"name":"One"}`},
+	}, {
+		expr:  "elements[5]",
+		paths: []string{},
+		want:  []string{},
+	}, {
+		expr:  "elements[0].bar.name",
+		paths: []string{},
+		want:  []string{},
+	}} {
+		t.Run(tc.expr, func(t *testing.T) {
+			results, err := bcl.Query(msg, tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths := []string{}
+			got := []string{}
+			for _, result := range results {
+				paths = append(paths, result.Path)
+				got = append(got, result.String())
+			}
+			assert.Equal(t, tc.paths, paths)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	for _, expr := range []string{
+		"nope",
+		"sString.x",
+		"elements.x",
+		"elements[0",
+		"sString[0]",
+		"elements..foo",
+	} {
+		_, err := bcl.Query(msg, expr)
+		assert.Error(t, err, expr)
+	}
+
+	_, err = bcl.Query(msg, `tags['k']`)
+	assert.ErrorContains(t, err, "keys are quoted with double quotes, not 'k'")
+	_, err = bcl.Query(msg, `tags["k]`)
+	assert.ErrorContains(t, err, `bad quoted key "k`)
+	_, err = bcl.Query(msg, "elements[99999999999999999999]")
+	assert.ErrorContains(t, err, "elements is a list, index 99999999999999999999 is out of range")
+}
+
+func TestSelectNodes(t *testing.T) {
+	matches, err := bcl.SelectNodes("in.bcl", fb(
+		`tag.root = "r"`,
+		`foo One {`,
+		`  tag.k = "v"`,
+		`  bar {`,
+		`    tag.deep = "d"`,
+		`  }`,
+		`}`,
+	), `block[type=foo] > attr[name=tag.*]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, matches, 1) {
+		assert.Equal(t, "attr", matches[0].Kind)
+		assert.Equal(t, "tag.k", matches[0].Name)
+		assert.Equal(t, []string{"foo"}, matches[0].Blocks)
+		assert.Equal(t, "in.bcl:3:3", matches[0].Pos.String())
+	}
+
+	_, err = bcl.SelectNodes("in.bcl", `a = 1`, `attr[nam=a]`)
+	assert.ErrorContains(t, err, `attr has no property "nam"`)
+}
+
+func TestQueryMapKeys(t *testing.T) {
+	msg := &test_pb.Ports{
+		Ports: map[int32]string{80: "http", 443: "https"},
+		Flags: map[bool]string{true: "on"},
+	}
+
+	for _, tc := range []struct {
+		expr  string
+		paths []string
+		want  []string
+	}{{
+		expr:  `ports["80"]`,
+		paths: []string{"ports.80"},
+		want:  []string{"http"},
+	}, {
+		expr:  "ports[443]",
+		paths: []string{"ports.443"},
+		want:  []string{"https"},
+	}, {
+		expr:  "ports[*]",
+		paths: []string{"ports.443", "ports.80"},
+		want:  []string{"https", "http"},
+	}, {
+		expr:  "ports",
+		paths: []string{"ports"},
+		want:  []string{`{"443":"https","80":"http"}`},
+	}, {
+		expr:  "flags.true",
+		paths: []string{"flags.true"},
+		want:  []string{"on"},
+	}} {
+		t.Run(tc.expr, func(t *testing.T) {
+			results, err := bcl.Query(msg, tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			paths := []string{}
+			got := []string{}
+			for _, result := range results {
+				paths = append(paths, result.Path)
+				got = append(got, result.String())
+			}
+			assert.Equal(t, tc.paths, paths)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := bcl.Query(msg, `ports["http"]`)
+	assert.ErrorContains(t, err, `ports has int32 keys, "http" is not one`)
+}
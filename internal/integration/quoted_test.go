@@ -0,0 +1,48 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotedNames(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := fb(
+		`!bcl 2`,
+		``,
+		`tags."my key" = "a"`,
+		`tags."import" = "b"`,
+		`"sString" = "s"`,
+	) + "\n"
+
+	file := &test_pb.File{}
+	if _, err := pp.ParseFile("in.bcl", input, file.ProtoReflect()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"my key": "a", "import": "b"}, file.Tags)
+	assert.Equal(t, "s", file.SString)
+
+	formatted, err := pp.Fmt(input, file.ProtoReflect().Descriptor(), project.FormatConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, input, formatted)
+
+	_, err = pp.ParseFile("in.bcl", `tags."my key" = "a"`, (&test_pb.File{}).ProtoReflect())
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeEdition, Line: 1, Column: 6})
+}
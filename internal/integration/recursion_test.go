@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecursiveSchema(t *testing.T) {
+	root := (&test_pb.Group{}).ProtoReflect().Descriptor()
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Group"}},
+	}
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nested := fb(
+		`group a {`,
+		`  group b {`,
+		`    child {`,
+		`      name = "c"`,
+		`    }`,
+		`  }`,
+		`}`,
+	)
+
+	t.Run("unlimited", func(t *testing.T) {
+		msg := &test_pb.Group{}
+		_, err := pp.ParseFile("in.bcl", nested, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "c", msg.GetGroups()[0].GetGroups()[0].GetChild().GetName())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		limited := *pp
+		limited.Limits.MaxRecursion = 2
+
+		_, _, err := limited.ParseDynamic("in.bcl", fb(`group a {`, `  child {`, `    name = "b"`, `  }`, `}`), root)
+		assert.NoError(t, err)
+
+		_, _, err = limited.ParseDynamic("in.bcl", nested, root)
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeMaxRecursion, Line: 3, Column: 5})
+		assert.ErrorContains(t, err, "test.v1.Group nested 3 deep, over the limit of 2: in.bcl:1:1 > in.bcl:2:3 > in.bcl:3:5")
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		_, err := bcl.CompileSchema(spec, root)
+		assert.NoError(t, err)
+
+		doc, err := bcl.DescribeSchema(spec, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, doc.Blocks, 1) {
+			for _, child := range doc.Blocks[0].Children {
+				if child.Block {
+					assert.Equal(t, "test.v1.Group", child.Schema, child.Name)
+				}
+			}
+		}
+
+		scope, err := pp.ScopeAt(root, "group", "group", "child")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "test.v1.Group", scope.SchemaName())
+	})
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedaction(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Credentials"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(input string) (*test_pb.Credentials, *bcl_j5pb.SourceLocation) {
+		t.Helper()
+		msg := &test_pb.Credentials{}
+		locs, err := pp.ParseFile("in.bcl", input, msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return msg, locs
+	}
+	from, locs := parse(fb(
+		`user = "admin"`,
+		`password = "hunter2"`,
+		`tokens = ["t1", "t2"]`,
+		`pin = 1234`,
+	))
+
+	out, err := bcl.Marshal(from, bcl.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{
+		"user": "admin",
+		"password": "«redacted»",
+		"tokens": ["«redacted»", "«redacted»"]
+	}`, string(out))
+
+	out, err = bcl.MarshalUnredacted(from, bcl.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, string(out), "hunter2")
+
+	explained, err := bcl.Explain(from, locs, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "password = «redacted»\n  set at 2:12\n", explained)
+
+	to, _ := parse(fb(
+		`user = "root"`,
+		`password = "hunter3"`,
+		`tokens = ["t1"]`,
+		`pin = 1234`,
+	))
+	diff, err := bcl.Diff(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`user: "admin" -> "root"`,
+		`password: «redacted» -> «redacted»`,
+		`tokens.1: «redacted» -> not set`,
+		``,
+	), diff)
+
+	diff, err = bcl.Diff(from, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, diff)
+}
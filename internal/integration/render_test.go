@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	vars, err := bcl.ParseVars("prod.vars.bcl", fb(
+		`name = "prod"`,
+		`tags {`,
+		`	all = [`,
+		`		"a",`,
+		`		"b",`,
+		`	]`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bcl.Vars{
+		"name":     `"prod"`,
+		"tags.all": fb(`[`, `		"a",`, `		"b",`, `	]`),
+	}, vars)
+
+	template := fb(
+		`!bcl 2`,
+		`sString = var("name") // the environment`,
+		`rString = var("tags.all")`,
+		`foo Name {`,
+		`	description = upper(var("name"))`,
+		`}`,
+	)
+	rendered, err := bcl.RenderTemplate("template.bcl", template, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`!bcl 2`,
+		`sString = "prod" // the environment`,
+		`rString = [`,
+		`		"a",`,
+		`		"b",`,
+		`	]`,
+		`foo Name {`,
+		`	description = upper("prod")`,
+		`}`,
+	), rendered)
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name:       &bcl_j5pb.Tag{FieldName: "name"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.RegisterFunctions(bcl.StandardFunctions())
+	msg, _, err := bcl.Decode[*test_pb.File](pp, "template.bcl", rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "prod", msg.SString)
+	assert.Equal(t, []string{"a", "b"}, msg.RString)
+	assert.Equal(t, "PROD", msg.Elements[0].GetFoo().Description)
+
+	_, err = bcl.RenderTemplate("template.bcl", fb(`!bcl 2`, `sString = var("missing")`), vars)
+	assert.ErrorContains(t, err, "variable missing is not set")
+	assert.Equal(t, errpos.CodeFunction, errpos.GetErrorCode(err))
+
+	t.Run("interpolated", func(t *testing.T) {
+		rendered, err := bcl.RenderTemplate("template.bcl", fb(
+			`!bcl 2`,
+			`foo "svc-${var.name}" {`,
+			"	description = `at ${var.name}, $${var.name} // ${var.name}`",
+			`}`,
+			`// ${var.name}`,
+		), vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fb(
+			`!bcl 2`,
+			`foo "svc-prod" {`,
+			"	description = `at prod, $${var.name} // prod`",
+			`}`,
+			`// ${var.name}`,
+		), rendered)
+
+		_, err = bcl.RenderTemplate("template.bcl", fb(`sString = "${var.tags.all}"`), vars)
+		assert.ErrorContains(t, err, "only scalars are interpolated")
+
+		_, err = bcl.RenderTemplate("template.bcl", fb(`foo "${var.missing}" {`, `}`), vars)
+		assert.ErrorContains(t, err, "variable missing is not set")
+	})
+
+	_, err = bcl.ParseVars("bad.vars.bcl", fb(`!bcl 2`, `a = upper("x")`))
+	assert.ErrorContains(t, err, "variables must be literals")
+}
+
+func TestRenderDeclaredVariables(t *testing.T) {
+	template := fb(
+		`!bcl 2`,
+		`variable region {`,
+		`	| Where to deploy`,
+		`	default = "eu-west-1"`,
+		`}`,
+		``,
+		`variable replicas {`,
+		`	type = int`,
+		`}`,
+		``,
+		`variable zones {`,
+		`	type = "[string]"`,
+		`	default = []`,
+		`}`,
+		``,
+		`variable password {`,
+		`	type = int`,
+		`	sensitive = true`,
+		`}`,
+		``,
+		`sString = var("region")`,
+		`sInt32 = var("replicas")`,
+		`rString = var("zones")`,
+	)
+
+	declared, err := bcl.ParseVariables("template.bcl", template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, declared, 4) {
+		assert.Equal(t, "Where to deploy", declared[0].Description)
+		assert.Equal(t, bcl.VariableString, declared[0].Type)
+		assert.Equal(t, `"eu-west-1"`, declared[0].Default)
+		assert.Equal(t, bcl.VariableStringArray, declared[2].Type)
+		assert.True(t, declared[3].Sensitive)
+	}
+
+	fileVars, err := declared.ParseVars("prod.vars.bcl", fb(`replicas = 3`, `password = 1234`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	argVars, err := declared.ParseStrings("argument", map[string]string{
+		"region": "us-east-1",
+		"zones":  `["a", "b"]`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `"us-east-1"`, argVars["region"])
+
+	rendered, err := bcl.RenderTemplate("template.bcl", template, bcl.MergeVars(fileVars, argVars))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, fb(
+		`!bcl 2`,
+		`sString = "us-east-1"`,
+		`sInt32 = 3`,
+		`rString = ["a", "b"]`,
+	), rendered)
+
+	rendered, err = bcl.RenderTemplate("template.bcl", template, bcl.Vars{"replicas": "1", "password": "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, rendered, `sString = "eu-west-1"`)
+	assert.Contains(t, rendered, `rString = []`)
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := bcl.RenderTemplate("template.bcl", template, bcl.Vars{"password": "1"})
+		assert.ErrorContains(t, err, "variable replicas is not set and has no default")
+		assert.Equal(t, errpos.CodeInvalidValue, errpos.GetErrorCode(err))
+		withSource, ok := errpos.AsErrorsWithSource(err)
+		if !ok || len(withSource.Errors) != 1 {
+			t.Fatalf("expected one error, got %v", err)
+		}
+		assert.Equal(t, 6, withSource.Errors[0].Pos.Start.Line)
+	})
+
+	t.Run("mistyped file value", func(t *testing.T) {
+		_, err := declared.ParseVars("prod.vars.bcl", fb(`password = 1`, `replicas = "three"`))
+		assert.ErrorContains(t, err, "variable replicas has type int, not string")
+		assert.Equal(t, errpos.CodeTypeMismatch, errpos.GetErrorCode(err))
+		withSource, ok := errpos.AsErrorsWithSource(err)
+		if !ok || len(withSource.Errors) != 1 {
+			t.Fatalf("expected one error, got %v", err)
+		}
+		got := withSource.Errors[0]
+		if assert.NotNil(t, got.Pos.Filename) {
+			assert.Equal(t, "prod.vars.bcl", *got.Pos.Filename)
+		}
+		assert.Equal(t, 1, got.Pos.Start.Line)
+	})
+
+	t.Run("mistyped array element", func(t *testing.T) {
+		_, err := declared.ParseStrings("argument", map[string]string{"zones": `["a", 1]`})
+		assert.ErrorContains(t, err, "argument: element 1 of variable zones has type [string], not int")
+	})
+
+	t.Run("sensitive value", func(t *testing.T) {
+		_, err := declared.ParseStrings("environment", map[string]string{"password": "hunter2!"})
+		assert.ErrorContains(t, err, "variable password has type int, the value does not parse")
+		assert.NotContains(t, err.Error(), "hunter2")
+	})
+
+	t.Run("undeclared", func(t *testing.T) {
+		_, err := declared.ParseVars("prod.vars.bcl", `other = 1`)
+		assert.ErrorContains(t, err, "variable other is not declared")
+
+		_, err = bcl.RenderTemplate("template.bcl", template+"\nsBool = var(\"other\")", bcl.Vars{"replicas": "1", "password": "1"})
+		assert.ErrorContains(t, err, "variable other is not declared")
+	})
+
+	t.Run("bad declaration", func(t *testing.T) {
+		_, err := bcl.ParseVariables("template.bcl", fb(
+			`variable count {`,
+			`	type = int`,
+			`	default = "none"`,
+			`}`,
+		))
+		assert.ErrorContains(t, err, "default: variable count has type int, not string")
+
+		_, err = bcl.ParseVariables("template.bcl", fb(`variable count {`, `	type = number`, `}`))
+		assert.ErrorContains(t, err, `unknown variable type "number"`)
+	})
+}
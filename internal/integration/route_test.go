@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestSchemaRouting(t *testing.T) {
+	features := (&test_pb.Features{}).ProtoReflect().Descriptor()
+	lists := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range []struct {
+		name   string
+		schema string
+		msg    *dynamicpb.Message
+	}{
+		{name: "registry/features.v1", schema: "test.v1.Features", msg: dynamicpb.NewMessage(features)},
+		{name: "registry/lists.v1", schema: "test.v1.Lists", msg: dynamicpb.NewMessage(lists)},
+	} {
+		err := pp.RegisterSchema(root.name, &bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{SchemaName: root.schema}},
+		}, root.msg.Type())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each route checks the policies of its own block specs.
+	err = pp.RegisterSchema("registry/checked.v1", &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Features",
+			Policies:   []*bcl_j5pb.Policy{{Expression: `this.name != "bad"`}},
+		}},
+	}, dynamicpb.NewMessageType(features))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = pp.ParseRouted("in.bcl", fb(
+		`!schema "registry/checked.v1"`,
+		`name = "bad"`,
+	))
+	bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodePolicy, Line: 1})
+	_, _, err = pp.ParseRouted("in.bcl", fb(
+		`!schema "registry/features.v1"`,
+		`name = "bad"`,
+	))
+	assert.NoError(t, err)
+
+	msg, _, err := pp.ParseRouted("in.bcl", fb(
+		`// a features file`,
+		`!schema "registry/features.v1"`,
+		`name = "x"`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, features.FullName(), msg.Descriptor().FullName())
+	assert.Equal(t, "x", msg.Get(features.Fields().ByName("name")).String())
+
+	msg, _, err = pp.ParseRouted("in.bcl", fb(
+		`!schema "registry/lists.v1"`,
+		`items = ["a"]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, lists.FullName(), msg.Descriptor().FullName())
+	assert.Equal(t, 1, msg.Get(lists.Fields().ByName("items")).List().Len())
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown schema",
+		input: fb(`!schema "registry/missing.v1"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownSchema, Line: 1, Column: 9},
+	}, {
+		name:  "no header",
+		input: fb(`name = "x"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownSchema, Line: 1, Column: 1},
+	}, {
+		name:  "header after a statement",
+		input: fb(`name = "x"`, `!schema "registry/features.v1"`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnexpectedToken, Line: 2, Column: 1},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseRouted("in.bcl", tc.input)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
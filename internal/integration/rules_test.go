@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestValidateRulesDuringParse(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pp.FailFast = false
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []bcltest.Diagnostic
+	}{{
+		name:  "valid",
+		input: fb(`slug = "abc"`, `count = 9`),
+	}, {
+		name:  "pattern",
+		input: fb(`count = 1`, `slug = "A1"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeValidation, Line: 2, Column: 8}},
+	}, {
+		name:  "exclusive maximum",
+		input: fb(`count = 10`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeValidation, Line: 1, Column: 9}},
+	}, {
+		name:  "minimum",
+		input: fb(`count = 0`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeValidation, Line: 1, Column: 9}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Rules{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want...)
+		})
+	}
+}
+
+func TestValidateRulesWithConstraint(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Rules",
+			Constraints: []*bcl_j5pb.Constraint{{
+				FieldName: "slug",
+				MaxLength: proto.Uint64(4),
+			}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  []bcltest.Diagnostic
+	}{{
+		name:  "valid",
+		input: fb(`count = 1`, `slug = "abc"`),
+	}, {
+		name:  "spec constraint",
+		input: fb(`count = 1`, `slug = "abcde"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeConstraint, Line: 2, Column: 8}},
+	}, {
+		name:  "field rules",
+		input: fb(`count = 1`, `slug = "A1"`),
+		want:  []bcltest.Diagnostic{{Code: errpos.CodeValidation, Line: 2, Column: 8}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.Rules{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want...)
+		})
+	}
+}
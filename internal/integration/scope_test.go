@@ -0,0 +1,43 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeAt(t *testing.T) {
+	pp := benchParser(t)
+	root := (&test_pb.File{}).ProtoReflect().Descriptor()
+
+	scope, err := pp.ScopeAt(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test.v1.File", scope.SchemaName())
+	assert.Contains(t, scope.ListBlocks(), "elements")
+	assert.Equal(t, []string{"sString"}, scope.ListAttributes())
+
+	scope, err = pp.ScopeAt(root, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test.v1.Element_Foo", scope.SchemaName())
+	assert.Equal(t, []string{"description", "name"}, scope.ListAttributes())
+	assert.Equal(t, []bcl.ScopeChild{{
+		Name:      "description",
+		Attribute: true,
+		Type:      "string",
+	}, {
+		Name:      "name",
+		Attribute: true,
+		Type:      "string",
+	}}, scope.Children())
+
+	_, err = pp.ScopeAt(root, "nope")
+	assert.True(t, errors.Is(err, errpos.ErrUnknownBlock), "got %v", err)
+}
@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestScopedNames(t *testing.T) {
+	scoped := func(schemaName string) *bcl_j5pb.Block {
+		return &bcl_j5pb.Block{
+			SchemaName: schemaName,
+			Name: &bcl_j5pb.Tag{
+				FieldName:      "name",
+				ScopeSeparator: proto.String("."),
+			},
+		}
+	}
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{
+			scoped("test.v1.Package"),
+			scoped("test.v1.Message"),
+		},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(filename string, lines ...string) bcl.SourceFile {
+		msg := &test_pb.Scoped{}
+		locs, err := pp.ParseFile(filename, fb(lines...), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bcl.SourceFile{
+			Filename:       filename,
+			Message:        msg.ProtoReflect(),
+			SourceLocation: locs,
+		}
+	}
+
+	a := parse("a.bcl",
+		`package foo {`,
+		`  message Bar {`,
+		`    message Baz {`,
+		`    }`,
+		`  }`,
+		`}`,
+	)
+
+	names := []string{}
+	var collect func(protoreflect.Message)
+	collect = func(msg protoreflect.Message) {
+		msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+			switch {
+			case fd.Name() == "name":
+				names = append(names, val.String())
+			case fd.IsList():
+				for idx := 0; idx < val.List().Len(); idx++ {
+					collect(val.List().Get(idx).Message())
+				}
+			}
+			return true
+		})
+	}
+	collect(a.Message)
+	sort.Strings(names)
+	assert.Equal(t, []string{"foo", "foo.Bar", "foo.Bar.Baz"}, names)
+
+	b := parse("b.bcl",
+		`package foo {`,
+		`  message Qux {`,
+		`  }`,
+		`}`,
+	)
+	assert.NoError(t, bcl.CheckScopedNames(spec, []bcl.SourceFile{a, b}))
+
+	c := parse("c.bcl",
+		`package foo.Bar {`,
+		`  message Baz {`,
+		`  }`,
+		`}`,
+	)
+	err = bcl.CheckScopedNames(spec, []bcl.SourceFile{a, b, c})
+	bcltest.AssertDiagnostics(t, err,
+		bcltest.Diagnostic{Code: errpos.CodeDuplicateName, Line: 1, Column: 9},
+		bcltest.Diagnostic{Code: errpos.CodeDuplicateName, Line: 2, Column: 11},
+	)
+	errs, _ := errpos.AsErrors(err)
+	if assert.Len(t, errs, 2) {
+		assert.Contains(t, errs[1].Error(), `"foo.Bar.Baz" is already defined at a.bcl:3:13`)
+	}
+}
@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	pp := benchParser(t)
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		is    []error
+		isNot []error
+	}{{
+		name:  "unknown block",
+		input: `baz Name`,
+		is:    []error{errpos.ErrUnknownBlock},
+		isNot: []error{errpos.ErrSyntax, errpos.ErrNotScalar},
+	}, {
+		name:  "unexpected EOF",
+		input: `sString = "abc`,
+		is:    []error{errpos.ErrSyntax, errpos.ErrUnexpectedEOF},
+		isNot: []error{errpos.ErrUnknownBlock},
+	}, {
+		name:  "unexpected character",
+		input: `sString = "a" @`,
+		is:    []error{errpos.ErrSyntax},
+		isNot: []error{errpos.ErrUnexpectedEOF},
+	}, {
+		name:  "not a scalar array",
+		input: `sString = ["a"]`,
+		is:    []error{errpos.ErrNotScalar, errpos.ErrTypeMismatch},
+		isNot: []error{errpos.ErrSyntax},
+	}, {
+		name:  "type mismatch",
+		input: `elements = "x"`,
+		is:    []error{errpos.ErrTypeMismatch},
+		isNot: []error{errpos.ErrNotScalar},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &test_pb.File{}
+			_, err := pp.Parse("in.bcl", tc.input, msg.ProtoReflect())
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, target := range tc.is {
+				assert.True(t, errors.Is(err, target), "%v should be %v", err, target)
+			}
+			for _, target := range tc.isNot {
+				assert.False(t, errors.Is(err, target), "%v should not be %v", err, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestSourceMap(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := pp.Parse("out.bcl", fb(
+		`defaults = ["x"]`,
+		`#line templates/lists.tmpl:10`,
+		`items = ["a", "b"]`,
+	), dynamicpb.NewMessage(root))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := bcl.MarshalSourceMap(result.SourceMap())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := bcl.UnmarshalSourceMap(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bcl.SourceMapVersion, sm.Version)
+	assert.Equal(t, "out.bcl", sm.File)
+
+	paths := map[string]bcl.SourceMapping{}
+	for _, mapping := range sm.Mappings {
+		paths[mapping.Path] = mapping
+	}
+	defaults, ok := paths["defaults"]
+	if assert.True(t, ok, "defaults mapped") {
+		assert.Equal(t, "out.bcl", defaults.File)
+		assert.Equal(t, 1, defaults.StartLine)
+		assert.Equal(t, "explicit", defaults.Origin)
+	}
+	items, ok := paths["items.1"]
+	if assert.True(t, ok, "items.1 mapped") {
+		assert.Equal(t, "templates/lists.tmpl", items.File)
+		assert.Equal(t, 10, items.StartLine)
+	}
+
+	pos, ok := sm.Lookup("items.1")
+	if assert.True(t, ok) {
+		assert.Equal(t, "templates/lists.tmpl:10:", pos.String()[:len("templates/lists.tmpl:10:")])
+	}
+	_, ok = sm.Lookup("sizes")
+	assert.False(t, ok, "sizes is not set")
+
+	_, err = bcl.UnmarshalSourceMap([]byte(`{"version": 2}`))
+	assert.Error(t, err)
+}
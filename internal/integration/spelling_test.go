@@ -0,0 +1,135 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCheckSpelling(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			Prose: []string{"sString", "rString"},
+		}, {
+			SchemaName:       "test.v1.Element_Foo",
+			Name:             &bcl_j5pb.Tag{FieldName: "name"},
+			DescriptionField: proto.String("description"),
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := fb(
+		`foo first {`,
+		"\t| The frist block, see `teh` and https://exampel.com",
+		`}`,
+		`foo second | A secnd one`,
+		`sString = "Helo \"world\" again"`,
+		`rString = ["fine", "wrold"]`,
+		`tags.key = "nott checked"`,
+	)
+	file := &test_pb.File{}
+	result, err := pp.ParseFile("in.bcl", input, file.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dict := bcl.NewWordList("the", "first", "second", "one", "block", "see", "and", "hello", "world", "again", "fine")
+	errs, err := pp.CheckSpelling(bcl.SourceFile{
+		Filename:       "in.bcl",
+		Message:        file.ProtoReflect(),
+		SourceLocation: result,
+		Source:         input,
+	}, dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type finding struct {
+		line, column int
+		message      string
+		fixes        []string
+	}
+	got := []finding{}
+	for _, err := range errs {
+		assert.Equal(t, errpos.CodeSpelling, err.Code)
+		assert.Equal(t, errpos.SeverityWarning, err.Severity)
+		found := finding{
+			line:    err.Pos.Start.Line + 1,
+			column:  err.Pos.Start.Column + 1,
+			message: err.Err.Error(),
+		}
+		for _, fix := range err.Fixes {
+			assert.False(t, fix.Safe)
+			found.fixes = append(found.fixes, fix.Title)
+		}
+		got = append(got, found)
+	}
+	assert.Equal(t, []finding{
+		// a transposition is two edits, too far for a word of five letters
+		{line: 2, column: 8, message: `unknown word "frist"`},
+		{line: 4, column: 16, message: `unknown word "secnd"`, fixes: []string{"Replace secnd with second"}},
+		{line: 5, column: 12, message: `unknown word "Helo"`, fixes: []string{"Replace Helo with Hello"}},
+		{line: 6, column: 21, message: `unknown word "wrold"`},
+	}, got)
+
+	fixed, err := errpos.ApplyEdits(input, errs[2].Fixes[0].Edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, fixed, `sString = "Hello \"world\" again"`)
+
+	assert.True(t, dict.Known("block's"))
+	assert.Equal(t, []string{"one", "the"}, dict.Suggest("tne"))
+}
+
+func TestProseFieldSchema(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		prose []string
+		err   string
+	}{{
+		name:  "not a string",
+		prose: []string{"elements"},
+		err:   "prose field test.v1.File.elements is not a string field",
+	}, {
+		name:  "not a field",
+		prose: []string{"missing"},
+		err:   "prose field test.v1.File.missing is not a field",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+				Blocks: []*bcl_j5pb.Block{{
+					SchemaName: "test.v1.File",
+					Prose:      tc.prose,
+				}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = pp.ParseFile("in.bcl", `sString = "a"`, (&test_pb.File{}).ProtoReflect())
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+
+	_, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Prose:      []string{"sString", "sString"},
+		}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestArraySplat(t *testing.T) {
+	root := (&test_pb.Lists{}).ProtoReflect().Descriptor()
+
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Lists"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := func(msg protoreflect.Message, name protoreflect.Name) []string {
+		list := msg.Get(msg.Descriptor().Fields().ByName(name)).List()
+		out := make([]string, list.Len())
+		for idx := range out {
+			out[idx] = list.Get(idx).String()
+		}
+		return out
+	}
+
+	msg, _, err := pp.ParseDynamic("in.bcl", fb(
+		`!bcl 2`,
+		`defaults = ["a", "b"]`,
+		`items = ["first", defaults..., "extra"]`,
+		`items += [items...]`,
+	), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"a", "b"}, values(msg.ProtoReflect(), "defaults"))
+	assert.Equal(t, []string{
+		"first", "a", "b", "extra",
+		"first", "a", "b", "extra",
+	}, values(msg.ProtoReflect(), "items"))
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "unknown field",
+		input: fb(`!bcl 2`, `items = [missing...]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2, Column: 10},
+	}, {
+		name:  "element type",
+		input: fb(`!bcl 2`, `defaults = ["a"]`, `sizes = [defaults...]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 3, Column: 10},
+	}, {
+		name:  "edition 1",
+		input: fb(`items = [defaults...]`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeEdition, Line: 1, Column: 18},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := pp.ParseDynamic("in.bcl", tc.input, root)
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	t.Run("origin", func(t *testing.T) {
+		_, locs, err := pp.ParseDynamic("in.bcl", fb(
+			`!bcl 2`,
+			`defaults = ["a"]`,
+			`items = ["first", defaults...]`,
+		), root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items := locs.Children["items"].Children
+		assert.Equal(t, bcl_j5pb.Origin_ORIGIN_EXPLICIT, items["0"].Origin)
+		if assert.NotNil(t, items["1"]) {
+			assert.Equal(t, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED, items["1"].Origin)
+			assert.EqualValues(t, 2, items["1"].StartLine)
+		}
+	})
+
+	t.Run("constraint", func(t *testing.T) {
+		constrained, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{
+				SchemaName: "test.v1.Lists",
+				Constraints: []*bcl_j5pb.Constraint{{
+					FieldName: "items",
+					Allowed:   []string{"a", "b"},
+				}},
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = constrained.ParseDynamic("in.bcl", fb(
+			`!bcl 2`,
+			`defaults = ["a", "c"]`,
+			`items = [defaults...]`,
+		), root)
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeConstraint, Line: 3, Column: 10})
+	})
+}
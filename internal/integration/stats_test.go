@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectStats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.bcl": {Data: []byte(fb(
+			`// Comment`,
+			`name = "a"`,
+			`service web {`,
+			`  port = 80`,
+			`  route root {`,
+			`    path = "/"`,
+			`  }`,
+			`}`,
+			``,
+		))},
+		"b.bcl": {Data: []byte(fb(
+			`service api {`,
+			`  port = 81`,
+			`}`,
+		))},
+		"c.txt": {Data: []byte(`not bcl`)},
+	}
+
+	stats, err := bcl.CollectStats(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []bcl.FileStats{{
+		Filename:   "a.bcl",
+		Lines:      8,
+		Statements: 5,
+		Blocks:     2,
+		MaxDepth:   2,
+	}, {
+		Filename:   "b.bcl",
+		Lines:      3,
+		Statements: 2,
+		Blocks:     1,
+		MaxDepth:   1,
+	}}, stats.Files)
+	assert.Equal(t, 11, stats.Lines())
+	assert.Equal(t, map[string]int{"service": 2, "route": 1}, stats.Blocks)
+	assert.Equal(t, []bcl.StatCount{
+		{Name: "port", Count: 2},
+		{Name: "name", Count: 1},
+		{Name: "path", Count: 1},
+	}, bcl.Ranked(stats.Attributes))
+	assert.Equal(t, 2, stats.MaxDepth)
+	assert.Equal(t, "a.bcl:5:3", stats.MaxDepthAt)
+
+	fsys["d.bcl"] = &fstest.MapFile{Data: []byte(`service {`)}
+	_, err = bcl.CollectStats(fsys)
+	assert.Error(t, err)
+}
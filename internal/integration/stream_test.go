@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoder(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name: &bcl_j5pb.Tag{
+				FieldName: "name",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgType := (&test_pb.File{}).ProtoReflect().Type()
+
+	t.Run("records", func(t *testing.T) {
+		reader, writer := io.Pipe()
+		next := make(chan struct{})
+		go func() {
+			io.WriteString(writer, fb(
+				`!bcl 2`,
+				`foo a {`,
+				`  description = "x { /* "`,
+				`  /* } */`,
+				`}`,
+			)+"\n")
+			<-next
+			io.WriteString(writer, fb(
+				`// the second`,
+				`foo b`,
+				`missing = 1`,
+				`foo c`,
+			))
+			writer.Close()
+		}()
+
+		dec := pp.NewDecoder(reader, "stream.bcl", msgType)
+		first, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := first.Message.Interface().(*test_pb.File)
+		if assert.Len(t, msg.Elements, 1) {
+			assert.Equal(t, "a", msg.Elements[0].GetFoo().Name)
+			assert.Equal(t, "x { /* ", msg.Elements[0].GetFoo().Description)
+		}
+		close(next)
+
+		second, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "b", second.Message.Interface().(*test_pb.File).Elements[0].GetFoo().Name)
+		foo := second.Result.SourceLocation.Children["elements"]
+		assert.EqualValues(t, 6, int(foo.StartLine)+second.Offset.Line)
+
+		_, err = dec.Next()
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 8, Column: 1})
+
+		third, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "c", third.Message.Interface().(*test_pb.File).Elements[0].GetFoo().Name)
+
+		_, err = dec.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		dec := pp.NewDecoder(strings.NewReader(fb(`foo a {`, `  name = "a"`)), "stream.bcl", msgType)
+		_, err := dec.Next()
+		assert.Error(t, err)
+		_, err = dec.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("left open", func(t *testing.T) {
+		dec := pp.NewDecoder(strings.NewReader(fb(
+			`foo a {`,
+			`  name = "a"`,
+			`---`,
+			`foo b`,
+		)), "stream.bcl", msgType)
+		_, err := dec.Next()
+		assert.Error(t, err)
+		record, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "b", record.Message.Interface().(*test_pb.File).Elements[0].GetFoo().Name)
+		_, err = dec.Next()
+		assert.Equal(t, io.EOF, err)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		dec := pp.NewDecoder(strings.NewReader(fb(
+			`foo a {`,
+			`  description = "`+strings.Repeat("x", 64)+`"`,
+			`  name = "a"`,
+			`---`,
+			`foo b`,
+		)), "stream.bcl", msgType)
+		dec.MaxRecordSize = 32
+		_, err := dec.Next()
+		bcltest.AssertDiagnostics(t, err, bcltest.Diagnostic{Code: errpos.CodeLimitExceeded, Line: 1, Column: 1})
+		record, err := dec.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "b", record.Message.Interface().(*test_pb.File).Elements[0].GetFoo().Name)
+	})
+}
@@ -0,0 +1,80 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSymbols(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			References: []*bcl_j5pb.Reference{{
+				FieldName: "sString",
+				Target:    "test.v1.Element_Foo",
+			}, {
+				FieldName: "rString",
+				Target:    "test.v1.Element_Foo",
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(filename string, lines ...string) bcl.SourceFile {
+		msg, locs, err := bcl.Decode[*test_pb.File](pp, filename, fb(lines...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bcl.SourceFile{
+			Filename:       filename,
+			Message:        msg.ProtoReflect(),
+			SourceLocation: locs,
+		}
+	}
+
+	files := []bcl.SourceFile{
+		parse("a.bcl", `foo A`, `foo B`),
+		parse("b.bcl", `rString = ["B", "C"]`, `foo A`),
+		parse("c.bcl", `sString = "B"`),
+	}
+
+	table, err := bcl.BuildSymbols(spec, files)
+	bcltest.AssertDiagnostics(t, err,
+		bcltest.Diagnostic{Code: errpos.CodeUnresolvedReference, Line: 1, Column: 17},
+	)
+
+	assert.Len(t, table.Symbols, 3)
+	if assert.Len(t, table.References, 3) {
+		assert.Equal(t, "B", table.References[0].Name)
+		assert.Equal(t, "a.bcl", *table.References[0].To.Position.Filename)
+		assert.Nil(t, table.References[1].To)
+		assert.Equal(t, "b.bcl", *table.References[1].Position.Filename)
+		assert.Equal(t, "a.bcl", *table.References[2].To.Position.Filename)
+	}
+
+	files = append(files, parse("d.bcl", `sString = "A"`))
+	_, err = bcl.BuildSymbols(spec, files)
+	bcltest.AssertDiagnostics(t, err,
+		bcltest.Diagnostic{Code: errpos.CodeUnresolvedReference},
+		bcltest.Diagnostic{Code: errpos.CodeAmbiguousReference, Line: 1, Column: 11},
+	)
+	errs, _ := errpos.AsErrors(err)
+	ambiguous := errs[len(errs)-1].Error()
+	assert.Contains(t, ambiguous, "d.bcl:1:11")
+	assert.Contains(t, ambiguous, "a.bcl:1:5")
+	assert.Contains(t, ambiguous, "b.bcl:2:5")
+}
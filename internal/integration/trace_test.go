@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	name   string
+	parent string
+	start  time.Time
+	end    time.Time
+	err    error
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *testSpan) End(end time.Time, err error) {
+	s.end, s.err, s.ended = end, err, true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (tt *testTracer) StartSpan(ctx context.Context, name string, start time.Time) (context.Context, bcl.Span) {
+	span := &testSpan{name: name, start: start, attrs: map[string]interface{}{}}
+	if parent, ok := ctx.Value(spanKey{}).(*testSpan); ok {
+		span.parent = parent.name
+	}
+	tt.spans = append(tt.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracer(t *testing.T) {
+	pp := benchParser(t)
+	tracer := &testTracer{}
+	pp.Tracer = tracer
+
+	msg := &test_pb.File{}
+	_, err := pp.ParseContext(context.Background(), "in.bcl", syntheticFile(2), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, span := range tracer.spans {
+		names = append(names, span.name)
+		assert.True(t, span.ended, "%s ended", span.name)
+		assert.False(t, span.end.Before(span.start), "%s ends before it starts", span.name)
+		if span.name != bcl.SpanParse {
+			assert.Equal(t, bcl.SpanParse, span.parent, "%s parent", span.name)
+		}
+	}
+	assert.Equal(t, []string{bcl.SpanParse, bcl.SpanLex, bcl.SpanSyntax, bcl.SpanWalk, bcl.SpanValidate}, names)
+	assert.Equal(t, "in.bcl", tracer.spans[0].attrs["bcl.file"])
+	assert.Greater(t, tracer.spans[0].attrs["bcl.tokens"], 0)
+
+	tracer.spans = nil
+	_, err = pp.Parse("bad.bcl", `sString = `, msg.ProtoReflect())
+	assert.Error(t, err)
+	if assert.NotEmpty(t, tracer.spans) {
+		assert.Error(t, tracer.spans[0].err, "the parse span records the error")
+		assert.Error(t, tracer.spans[len(tracer.spans)-1].err, "the failed phase records the error")
+	}
+}
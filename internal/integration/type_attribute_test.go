@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeAttribute(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Element",
+			TypeAttribute: &bcl_j5pb.TypeAttribute{
+				Attribute: "kind",
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("selected", func(t *testing.T) {
+		msg := &test_pb.File{}
+		_, err := pp.ParseFile("in.bcl", fb(
+			`elements {`,
+			`  // the type comes first`,
+			`  kind = "foo"`,
+			`  name = "a"`,
+			`  description = "first"`,
+			`}`,
+			`elements {`,
+			`  kind = "bar"`,
+			`  name = "b"`,
+			`}`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if assert.Len(t, msg.Elements, 2) {
+			assert.Equal(t, "a", msg.Elements[0].GetFoo().GetName())
+			assert.Equal(t, "first", msg.Elements[0].GetFoo().GetDescription())
+			assert.Equal(t, "b", msg.Elements[1].GetBar().GetName())
+		}
+	})
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "missing",
+		input: fb(`elements {`, `  name = "a"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeAttribute, Line: 1, Column: 1},
+	}, {
+		name:  "too late",
+		input: fb(`elements {`, `  name = "a"`, `  kind = "foo"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeAttribute, Line: 3, Column: 3},
+	}, {
+		name:  "twice",
+		input: fb(`elements {`, `  kind = "foo"`, `  kind = "bar"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeAlreadySet, Line: 3, Column: 3},
+	}, {
+		name:  "not a string",
+		input: fb(`elements {`, `  kind = 1`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 2, Column: 10},
+	}, {
+		name:  "unknown type",
+		input: fb(`elements {`, `  kind = "baz"`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeUnknownBlock, Line: 2},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.File{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+
+	_, err = bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName:    "test.v1.Element",
+			TypeSelect:    &bcl_j5pb.Tag{FieldName: "."},
+			TypeAttribute: &bcl_j5pb.TypeAttribute{Attribute: "kind"},
+		}},
+	})
+	assert.Error(t, err, "a type attribute and a type select tag")
+}
@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUnused(t *testing.T) {
+	spec := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+			References: []*bcl_j5pb.Reference{{
+				FieldName: "rString",
+				Target:    "test.v1.Element_Foo",
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(filename string, lines ...string) bcl.SourceFile {
+		source := fb(lines...)
+		msg, locs, err := bcl.Decode[*test_pb.File](pp, filename, source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bcl.SourceFile{
+			Filename:       filename,
+			Message:        msg.ProtoReflect(),
+			SourceLocation: locs,
+			Source:         source,
+		}
+	}
+
+	files := []bcl.SourceFile{
+		parse("a.bcl", `foo A`, `foo B`, `// bcl:ignore BCL5002`, `foo C`),
+		parse("b.bcl", `rString = ["A", "D"]`, `foo D`),
+		parse("c.bcl", `foo E`),
+	}
+
+	unused, err := bcl.CheckUnused(spec, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcltest.AssertDiagnostics(t, unused,
+		bcltest.Diagnostic{Code: errpos.CodeUnreferenced, Line: 2, Column: 5},
+		bcltest.Diagnostic{Code: errpos.CodeUnreferenced, Line: 1, Column: 5},
+	)
+	if assert.Len(t, unused, 2) {
+		assert.Equal(t, errpos.SeverityWarning, unused[0].Severity)
+		assert.Contains(t, unused[0].Error(), `a.bcl:2:5`)
+		assert.Contains(t, unused[0].Error(), `test.v1.Element_Foo "B" is never referenced`)
+		assert.Contains(t, unused[1].Error(), `c.bcl:1:5`)
+	}
+
+	// Without the source, the suppression is not seen.
+	files[0].Source = ""
+	unused, err = bcl.CheckUnused(spec, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, unused, 3)
+}
+
+func TestCheckUnusedVariables(t *testing.T) {
+	unused, err := bcl.CheckUnusedVariables("template.bcl", fb(
+		`!bcl 2`,
+		`variable region {`,
+		`}`,
+		`variable zone {`,
+		`}`,
+		`variable replicas {`,
+		`	type = int`,
+		`}`,
+		`// bcl:ignore BCL5002`,
+		`variable spare {`,
+		`}`,
+		`variable stale {`,
+		`}`,
+		`sString = var("region")`,
+		`foo "${var.zone}" {`,
+		`}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcltest.AssertDiagnostics(t, unused,
+		bcltest.Diagnostic{Code: errpos.CodeUnreferenced, Line: 6, Column: 1},
+		bcltest.Diagnostic{Code: errpos.CodeUnreferenced, Line: 12, Column: 1},
+	)
+	if assert.Len(t, unused, 2) {
+		assert.Contains(t, unused[0].Error(), "variable replicas is never read")
+		assert.Equal(t, errpos.SeverityWarning, unused[0].Severity)
+	}
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/bcltest"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// TestFreeForm checks that Struct, Value and ListValue fields are set from
+// nested blocks and literals of any shape.
+func TestFreeForm(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.FreeForm"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &test_pb.FreeForm{}
+	_, err = pp.ParseFile("in.bcl", fb(
+		`extra = 1`,
+		`meta {`,
+		`  a = "x"`,
+		`  b.c = true`,
+		`  list = [1, "two", three]`,
+		`  b {`,
+		`    d = 2`,
+		`  }`,
+		`}`,
+		`items = []`,
+		`shape {`,
+		`  kind = square`,
+		`}`,
+		`nested {`,
+		`  meta {`,
+		`    a = 1`,
+		`  }`,
+		`}`,
+	), msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, `{
+		"extra": 1,
+		"meta": {"a": "x", "b": {"c": true, "d": 2}, "list": [1, "two", "three"]},
+		"items": [],
+		"shape": {"kind": "square"},
+		"nested": {"meta": {"a": 1}}
+	}`, string(got))
+
+	for _, tc := range []struct {
+		name  string
+		input string
+		want  bcltest.Diagnostic
+	}{{
+		name:  "struct from a literal",
+		input: `meta = 1`,
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 8},
+	}, {
+		name:  "list from a block",
+		input: fb(`items {`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeTypeMismatch, Line: 1, Column: 1},
+	}, {
+		name:  "set twice",
+		input: fb(`meta {`, `  a = 1`, `  a = 2`, `}`),
+		want:  bcltest.Diagnostic{Code: errpos.CodeAlreadySet, Line: 3, Column: 3},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := pp.ParseFile("in.bcl", tc.input, (&test_pb.FreeForm{}).ProtoReflect())
+			bcltest.AssertDiagnostics(t, err, tc.want)
+		})
+	}
+}
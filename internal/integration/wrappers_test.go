@@ -0,0 +1,134 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestWrapperFields(t *testing.T) {
+	pp, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.Wrapped",
+		}, {
+			SchemaName: "test.v1.Inner",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(t *testing.T, lines ...string) string {
+		t.Helper()
+		msg := &test_pb.Wrapped{}
+		_, err := pp.ParseFile("in.bcl", fb(lines...), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := protojson.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		got := parse(t,
+			`name = "x"`,
+			`count = 3`,
+			`enabled = true`,
+			`ratio = 0.5`,
+		)
+		assert.JSONEq(t, `{"name": "x", "count": "3", "enabled": true, "ratio": 0.5}`, got)
+	})
+
+	t.Run("zero values are set", func(t *testing.T) {
+		msg := &test_pb.Wrapped{}
+		_, err := pp.ParseFile("in.bcl", fb(`count = 0`, `name = ""`), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotNil(t, msg.Count)
+		assert.NotNil(t, msg.Name)
+		assert.Nil(t, msg.Enabled)
+	})
+
+	t.Run("repeated and map values", func(t *testing.T) {
+		got := parse(t,
+			`tags = ["a", "b"]`,
+			`limits.cpu = 2`,
+		)
+		assert.JSONEq(t, `{"tags": ["a", "b"], "limits": {"cpu": 2}}`, got)
+	})
+
+	t.Run("nested block", func(t *testing.T) {
+		got := parse(t,
+			`inner {`,
+			`  label = "in"`,
+			`}`,
+		)
+		assert.JSONEq(t, `{"inner": {"label": "in"}}`, got)
+	})
+
+	t.Run("packed in an Any", func(t *testing.T) {
+		anyParser, err := bcl.NewParser(&bcl_j5pb.Schema{
+			Blocks: []*bcl_j5pb.Block{{SchemaName: "test.v1.Wrapper"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msg := &test_pb.Wrapper{}
+		_, err = anyParser.ParseFile("in.bcl", fb(
+			`payload test.v1.Wrapped {`,
+			`  name = "x"`,
+			`  tags = ["a"]`,
+			`}`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := protojson.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.JSONEq(t, `{"payload": {"@type": "type.googleapis.com/test.v1.Wrapped", "name": "x", "tags": ["a"]}}`, string(out))
+	})
+
+	t.Run("encoded as scalars", func(t *testing.T) {
+		msg := &test_pb.Wrapped{}
+		_, err := pp.ParseFile("in.bcl", fb(
+			`name = "x"`,
+			`count = 3`,
+			`tags = ["a", "b"]`,
+			`limits.cpu = 2`,
+		), msg.ProtoReflect())
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := &strings.Builder{}
+		if err := pp.NewEncoder(out, "wrapped").Encode(msg); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, fb(
+			"wrapped x {",
+			"\tcount = 3",
+			"\ttags = [\"a\", \"b\"]",
+			"\tlimits.cpu = 2",
+			"}",
+			"",
+		), out.String())
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		_, err := pp.ParseFile("in.bcl", fb(`count = "three"`), (&test_pb.Wrapped{}).ProtoReflect())
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package linter
+
+import (
+	"context"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/internal/lsp"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/log.go/log"
+)
+
+// maxFixPasses bounds the fix loop. The walker stops at the first error, so
+// each pass usually uncovers one more fixable error.
+const maxFixPasses = 20
+
+// FixResult is the outcome of fixing a single file
+type FixResult struct {
+	Content string
+	Changed bool
+
+	// Applied are the titles of the fixes applied, in order.
+	Applied []string
+
+	// Remaining are the errors which could not be fixed, at their positions
+	// in Content.
+	Remaining errpos.Errors
+}
+
+// FixFile applies the fixes allowed by the fix config until none remain, then
+// formats the file with the ordering of the format config, sorting the
+// attributes of unordered blocks unless the fix config keeps their order.
+// Remaining are the errors of the content returned.
+func (l *Linter) FixFile(ctx context.Context, req *lsp.FileRequest, config *project.Config) (*FixResult, error) {
+	result := &FixResult{
+		Content: req.Content,
+	}
+
+	// checked is the content Remaining was found for
+	checked := ""
+	check := func() error {
+		errs, err := l.fileErrors(&lsp.FileRequest{
+			Filename: req.Filename,
+			Content:  result.Content,
+		}, config.Naming)
+		if err != nil {
+			return err
+		}
+		result.Remaining = errs
+		checked = result.Content
+		return nil
+	}
+
+	for pass := 0; pass < maxFixPasses; pass++ {
+		if err := check(); err != nil {
+			return nil, err
+		}
+		fixes := allowedFixes(result.Remaining, config.Fix)
+		if len(fixes) == 0 {
+			break
+		}
+
+		fixed, err := errpos.ApplyEdits(result.Content, fixEdits(fixes))
+		if err != nil {
+			// Overlapping fixes, take them one at a time.
+			log.WithError(ctx, err).Debug("applying fixes separately")
+			fixes = fixes[:1]
+			fixed, err = errpos.ApplyEdits(result.Content, fixEdits(fixes))
+			if err != nil {
+				return nil, err
+			}
+		}
+		result.Content = fixed
+		for _, fix := range fixes {
+			result.Applied = append(result.Applied, fix.Title)
+		}
+	}
+
+	if !config.Fix.SkipFormat {
+		format := config.Format
+		if !config.Fix.KeepOrder {
+			format.SortAttributes = true
+		}
+		formatted, err := l.format(req.Filename, result.Content, format)
+		if err == nil {
+			result.Content = formatted
+		}
+		// Syntax errors are reported in Remaining, the file is left as is.
+	}
+
+	// The last pass of fixes, or formatting, moves the errors which remain.
+	if result.Content != checked {
+		if err := check(); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Changed = result.Content != req.Content
+	return result, nil
+}
+
+// format formats the file, ordering statements by the schema when the linter
+// has one.
+func (l *Linter) format(filename string, content string, config project.FormatConfig) (string, error) {
+	if l.parser == nil || l.fileFactory == nil {
+		return parser.FmtOptions{
+			MaxBlankLines: config.MaxBlankLines,
+			Width:         config.Width,
+			LineEndings:   config.LineEndings,
+			IndentSpaces:  config.IndentSpaces,
+			ByteOrderMark: config.ByteOrderMark,
+		}.Fmt(content)
+	}
+	root := l.fileFactory(filename).Descriptor()
+	return l.parser.Fmt(content, root, config)
+}
+
+// allowedFixes returns the first allowed fix of each error.
+func allowedFixes(errs errpos.Errors, config project.FixConfig) []errpos.Fix {
+	fixes := []errpos.Fix{}
+	for _, err := range errs {
+		for _, fix := range err.Fixes {
+			if config.Allow(err.ErrorCode(), fix) {
+				fixes = append(fixes, fix)
+				break
+			}
+		}
+	}
+	return fixes
+}
+
+func fixEdits(fixes []errpos.Fix) []errpos.Edit {
+	edits := []errpos.Edit{}
+	for _, fix := range fixes {
+		edits = append(edits, fix.Edits...)
+	}
+	return edits
+}
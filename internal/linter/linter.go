@@ -6,6 +6,7 @@ import (
 
 	"github.com/pentops/bcl.go/bcl"
 	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
 	"github.com/pentops/bcl.go/internal/lsp"
 	"github.com/pentops/bcl.go/internal/parser"
 	"github.com/pentops/log.go/log"
@@ -17,6 +18,15 @@ type FileFactory func(filename string) protoreflect.Message
 type Linter struct {
 	parser      *bcl.Parser
 	fileFactory FileFactory
+
+	// Naming sets the case of block names and map keys, as
+	// project.Config.Naming, for LintFile. FixFile uses the config it is
+	// given.
+	Naming map[string]project.Case
+
+	// Dictionary, when set, checks the spelling of descriptions and prose
+	// fields for LintFile and FixFile.
+	Dictionary bcl.Dictionary
 }
 
 func New(parser *bcl.Parser, fileFactory FileFactory) *Linter {
@@ -31,9 +41,23 @@ func NewGeneric() *Linter {
 }
 
 func (l *Linter) LintFile(ctx context.Context, req *lsp.FileRequest) ([]lsp.Diagnostic, error) {
+	errs, err := l.fileErrors(req, l.Naming)
+	if err != nil {
+		return nil, err
+	}
+	return diagnostics(ctx, errpos.NewLineIndex(req.Content), errs), nil
+}
+
+// fileErrors parses and walks the file, returning the positioned errors, or
+// the reserved word, naming, spelling and unused suppression warnings when the
+// file is otherwise valid.
+func (l *Linter) fileErrors(req *lsp.FileRequest, naming map[string]project.Case) (errpos.Errors, error) {
 
 	var mainError error
 
+	// warnings are kept when the walk fails, to report with its error
+	var warnings errpos.Errors
+
 	tree, err := parser.ParseFile(req.Content, false)
 	if err != nil {
 		if err == parser.HadErrors {
@@ -45,50 +69,141 @@ func (l *Linter) LintFile(ctx context.Context, req *lsp.FileRequest) ([]lsp.Diag
 		}
 	}
 
-	if mainError == nil && l.fileFactory != nil && l.parser != nil {
+	if mainError == nil {
+		// Reserved words are found from the syntax alone, so are reported
+		// with or without a schema.
+		warnings = tree.Suppressions.Filter(tree.ReservedNames())
+		if l.fileFactory == nil || l.parser == nil {
+			return warnings, nil
+		}
 		msg := l.fileFactory(req.Filename)
-		_, err = l.parser.ParseAST(tree, msg)
+		result, err := l.parser.ParseASTResult(tree, msg)
+		if result != nil {
+			warnings = append(warnings, tree.Suppressions.Filter(result.Warnings)...)
+		}
 		if err == nil {
-			return nil, nil
+			source := result.SourceLocation
+			if len(naming) > 0 {
+				names, err := l.parser.CheckNaming(bcl.SourceFile{
+					Filename:       req.Filename,
+					Message:        msg,
+					SourceLocation: source,
+					Source:         req.Content,
+					AST:            tree,
+				}, naming)
+				if err != nil {
+					return nil, err
+				}
+				warnings = append(warnings, tree.Suppressions.Filter(names)...)
+			}
+			if l.Dictionary != nil {
+				words, err := l.parser.CheckSpelling(bcl.SourceFile{
+					Filename:       req.Filename,
+					Message:        msg,
+					SourceLocation: source,
+					Source:         req.Content,
+					AST:            tree,
+				}, l.Dictionary)
+				if err != nil {
+					return nil, err
+				}
+				warnings = append(warnings, tree.Suppressions.Filter(words)...)
+			}
+			// Suppressions are only known to be unused once the whole file
+			// has been walked without error.
+			return append(warnings, tree.Suppressions.Unused()...), nil
 		}
 		mainError = err
 	}
 
-	locErr, ok := errpos.AsErrorsWithSource(mainError)
+	if locErr, ok := errpos.AsErrorsWithSource(mainError); ok {
+		return append(locErr.Errors, warnings...), nil
+	}
+
+	errs, ok := errpos.AsErrors(mainError)
 	if !ok {
 		return nil, mainError
 	}
+	return append(errs, warnings...), nil
+
+}
 
-	for _, err := range locErr.Errors {
+// diagnostics converts the errors for the editor, with columns in UTF-16 code
+// units as LSP counts them.
+func diagnostics(ctx context.Context, index *errpos.LineIndex, errs errpos.Errors) []lsp.Diagnostic {
+	for _, err := range errs {
 		log.WithFields(ctx, map[string]interface{}{
 			"pos":   err.Pos.String(),
 			"error": err.Err.Error(),
 		}).Debug("Lint Diagnostic")
 	}
 
-	diagnostics := make([]lsp.Diagnostic, 0, len(locErr.Errors))
+	diagnostics := make([]lsp.Diagnostic, 0, len(errs))
 
-	for _, err := range locErr.Errors {
+	for _, err := range errs {
+		code := "LINT"
+		if errCode := err.ErrorCode(); errCode != "" {
+			code = errCode.String()
+		}
+		severity := lsp.SeverityError
+		switch err.Severity {
+		case errpos.SeverityWarning:
+			severity = lsp.SeverityWarning
+		case errpos.SeverityInfo:
+			severity = lsp.SeverityInformation
+		}
+		var data any
+		if len(err.Fixes) > 0 {
+			data = fixData(index, err.Fixes)
+		}
+		var related []lsp.DiagnosticRelatedInformation
+		for _, rel := range err.Related {
+			related = append(related, lsp.DiagnosticRelatedInformation{
+				Location: lsp.Location{Range: lspRange(index, rel.Pos.Start, rel.Pos.End)},
+				Message:  rel.Message,
+			})
+		}
 		diagnostics = append(diagnostics, lsp.Diagnostic{
-			Range: lsp.Range{
-				Start: lsp.Position{
-					Line:      err.Pos.Start.Line,
-					Character: err.Pos.Start.Column,
-				},
-				End: lsp.Position{
-					Line:      err.Pos.End.Line,
-					Character: err.Pos.End.Column,
-				},
-			},
-			Code:     ptr("LINT"),
+			Range:    lspRange(index, err.Pos.Start, err.Pos.End),
+			Code:     ptr(code),
 			Message:  err.Err.Error(),
-			Severity: lsp.SeverityError,
+			Severity: severity,
 			Source:   ptr("bcl"),
+			Data:     data,
+
+			RelatedInformation: related,
 		})
 	}
 
-	return diagnostics, nil
+	return diagnostics
+}
 
+func fixData(index *errpos.LineIndex, fixes []errpos.Fix) lsp.DiagnosticData {
+	data := lsp.DiagnosticData{
+		Fixes: make([]lsp.DiagnosticFix, 0, len(fixes)),
+	}
+	for _, fix := range fixes {
+		edits := make([]lsp.TextEdit, 0, len(fix.Edits))
+		for _, edit := range fix.Edits {
+			edits = append(edits, lsp.TextEdit{
+				Range:   lspRange(index, edit.Start, edit.End),
+				NewText: edit.NewText,
+			})
+		}
+		data.Fixes = append(data.Fixes, lsp.DiagnosticFix{
+			Title: fix.Title,
+			Edits: edits,
+		})
+	}
+	return data
+}
+
+func lspRange(index *errpos.LineIndex, start, end errpos.Point) lsp.Range {
+	start, end = index.ToUTF16(start), index.ToUTF16(end)
+	return lsp.Range{
+		Start: lsp.Position{Line: start.Line, Character: start.Column},
+		End:   lsp.Position{Line: end.Line, Character: end.Column},
+	}
 }
 
 func ptr[T any](v T) *T {
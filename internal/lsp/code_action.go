@@ -0,0 +1,60 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// handleTextDocumentCodeAction offers the fixes carried in the Data of the
+// diagnostics in the request, so the file doesn't need to be linted again.
+func (h *langHandler) handleTextDocumentCodeAction(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params CodeActionParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	actions := []CodeAction{}
+	for _, diag := range params.Context.Diagnostics {
+		data, ok := diagnosticData(diag)
+		if !ok {
+			continue
+		}
+		for _, fix := range data.Fixes {
+			actions = append(actions, CodeAction{
+				Title:       fix.Title,
+				Kind:        QuickFix,
+				Diagnostics: []Diagnostic{diag},
+				IsPreferred: len(data.Fixes) == 1,
+				Edit: &WorkspaceEdit{
+					Changes: map[DocumentURI][]TextEdit{
+						params.TextDocument.URI: fix.Edits,
+					},
+				},
+			})
+		}
+	}
+	return actions, nil
+}
+
+// diagnosticData decodes the Data of a diagnostic sent back by the client,
+// which arrives as generic JSON.
+func diagnosticData(diag Diagnostic) (*DiagnosticData, bool) {
+	if diag.Data == nil {
+		return nil, false
+	}
+	raw, err := json.Marshal(diag.Data)
+	if err != nil {
+		return nil, false
+	}
+	data := &DiagnosticData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return nil, false
+	}
+	return data, len(data.Fixes) > 0
+}
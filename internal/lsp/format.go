@@ -32,14 +32,89 @@ func (h *langHandler) handleTextDocumentFormatting(ctx context.Context, _ *jsonr
 
 }
 
-type ASTFormatter struct{}
+func (h *langHandler) handleTextDocumentRangeFormatting(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params DocumentRangeFormattingParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	doc, err := h.buildRequest(params.TextDocument.URI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+
+	diffs, err := h.Handlers.Fmter.FormatRange(ctx, doc, params.Range)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format: %v", err)
+	}
+	return diffs, nil
+}
+
+func (h *langHandler) handleTextDocumentOnTypeFormatting(ctx context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (result any, err error) {
+	if req.Params == nil {
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+	}
+
+	var params DocumentOnTypeFormattingParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		return nil, err
+	}
+
+	doc, err := h.buildRequest(params.TextDocument.URI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %v", err)
+	}
+
+	diffs, err := h.Handlers.Fmter.FormatBlock(ctx, doc, params.Position)
+	if err != nil {
+		// The file is often incomplete while typing, which is not worth an
+		// error in the editor.
+		return []TextEdit{}, nil
+	}
+	return diffs, nil
+}
+
+// ASTFormatter formats files, ranges and blocks with the layout options,
+// usually those of the project's format config.
+type ASTFormatter struct {
+	Options parser.FmtOptions
+}
 
 func (f ASTFormatter) FormatFile(ctx context.Context, doc *FileRequest) ([]TextEdit, error) {
-	diffs, err := parser.FmtDiffs(doc.Content)
+	diffs, err := f.Options.Diffs(doc.Content)
 	if err != nil {
 		return nil, err
 	}
+	return diffEdits(diffs), nil
+}
+
+func (f ASTFormatter) FormatRange(ctx context.Context, doc *FileRequest, rng Range) ([]TextEdit, error) {
+	toLine := rng.End.Line
+	if rng.End.Character == 0 && toLine > rng.Start.Line {
+		// The end is exclusive, a range of whole lines ends at the start of
+		// the next
+		toLine--
+	}
+	diffs, err := f.Options.RangeDiffs(doc.Content, rng.Start.Line, toLine)
+	if err != nil {
+		return nil, err
+	}
+	return diffEdits(diffs), nil
+}
+
+func (f ASTFormatter) FormatBlock(ctx context.Context, doc *FileRequest, pos Position) ([]TextEdit, error) {
+	diffs, err := f.Options.BlockDiffs(doc.Content, pos.Line)
+	if err != nil {
+		return nil, err
+	}
+	return diffEdits(diffs), nil
+}
 
+func diffEdits(diffs []parser.FmtDiff) []TextEdit {
 	edits := make([]TextEdit, 0, len(diffs))
 	for _, diff := range diffs {
 		edits = append(edits, TextEdit{
@@ -50,5 +125,5 @@ func (f ASTFormatter) FormatFile(ctx context.Context, doc *FileRequest) ([]TextE
 			NewText: diff.NewText,
 		})
 	}
-	return edits, nil
+	return edits
 }
@@ -0,0 +1,38 @@
+package lsp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl/project"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRangeOptions(t *testing.T) {
+	fmter := ASTFormatter{
+		Options: parser.ConfigOptions(project.FormatConfig{IndentSpaces: 2}),
+	}
+	doc := &FileRequest{Content: "a {\nb = 1\n}\nc {\nd = 2\n}\n"}
+
+	edits, err := fmter.FormatRange(context.Background(), doc, Range{
+		Start: Position{Line: 0},
+		End:   Position{Line: 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, edits, 1) {
+		assert.Equal(t, 1, edits[0].Range.Start.Line)
+		assert.Equal(t, "  b = 1\n", edits[0].NewText)
+	}
+
+	edits, err = fmter.FormatBlock(context.Background(), doc, Position{Line: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, edits, 1) {
+		assert.Equal(t, 4, edits[0].Range.Start.Line)
+		assert.Equal(t, "  d = 2\n", edits[0].NewText)
+	}
+}
@@ -93,6 +93,14 @@ func (h *langHandler) lint(ctx context.Context, uri DocumentURI) (map[DocumentUR
 	if err != nil {
 		return nil, fmt.Errorf("lint error: %v", err)
 	}
+	for _, result := range results {
+		// Linters only know the file, related information is in it
+		for idx := range result.RelatedInformation {
+			if result.RelatedInformation[idx].Location.URI == "" {
+				result.RelatedInformation[idx].Location.URI = uri
+			}
+		}
+	}
 
 	uriToDiagnostics := make(map[DocumentURI][]Diagnostic)
 	uriToDiagnostics[uri] = results
@@ -22,6 +22,13 @@ type Linter interface {
 
 type Fmter interface {
 	FormatFile(context.Context, *FileRequest) ([]TextEdit, error)
+
+	// FormatRange formats the statements which overlap the range.
+	FormatRange(context.Context, *FileRequest, Range) ([]TextEdit, error)
+
+	// FormatBlock formats the innermost block around the position, for
+	// formatting as the user types.
+	FormatBlock(context.Context, *FileRequest, Position) ([]TextEdit, error)
 }
 
 type LSPHandlers struct {
@@ -84,6 +91,12 @@ func (h *langHandler) handle(ctx context.Context, conn *jsonrpc2.Conn, req *json
 		return h.handleTextDocumentDidClose(ctx, conn, req)
 	case "textDocument/formatting":
 		return h.handleTextDocumentFormatting(ctx, conn, req)
+	case "textDocument/rangeFormatting":
+		return h.handleTextDocumentRangeFormatting(ctx, conn, req)
+	case "textDocument/onTypeFormatting":
+		return h.handleTextDocumentOnTypeFormatting(ctx, conn, req)
+	case "textDocument/codeAction":
+		return h.handleTextDocumentCodeAction(ctx, conn, req)
 	}
 
 	return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: fmt.Sprintf("method not supported: %s", req.Method)}
@@ -93,7 +106,15 @@ func (h *langHandler) handleInitialize(_ context.Context, conn *jsonrpc2.Conn, _
 	h.conn = conn
 	return &InitializeResult{
 		Capabilities: ServerCapabilities{
-			DocumentFormattingProvider: true,
+			// Columns are converted from runes for the client
+			PositionEncoding:                "utf-16",
+			DocumentFormattingProvider:      true,
+			DocumentRangeFormattingProvider: true,
+			DocumentOnTypeFormattingProvider: &DocumentOnTypeFormattingOptions{
+				FirstTriggerCharacter: "}",
+				MoreTriggerCharacter:  []string{"\n"},
+			},
+			CodeActionProvider: true,
 			TextDocumentSync: TextDocumentSyncOptions{
 				OpenClose: true,
 				Change:    TDSKFull,
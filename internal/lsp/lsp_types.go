@@ -89,6 +89,16 @@ type ServerCapabilities struct {
 	HoverProvider              bool                         `json:"hoverProvider,omitempty"`
 	CodeActionProvider         bool                         `json:"codeActionProvider,omitempty"`
 	Workspace                  *ServerCapabilitiesWorkspace `json:"workspace,omitempty"`
+
+	PositionEncoding                 string                           `json:"positionEncoding,omitempty"`
+	DocumentRangeFormattingProvider  bool                             `json:"documentRangeFormattingProvider,omitempty"`
+	DocumentOnTypeFormattingProvider *DocumentOnTypeFormattingOptions `json:"documentOnTypeFormattingProvider,omitempty"`
+}
+
+// DocumentOnTypeFormattingOptions is
+type DocumentOnTypeFormattingOptions struct {
+	FirstTriggerCharacter string   `json:"firstTriggerCharacter"`
+	MoreTriggerCharacter  []string `json:"moreTriggerCharacter,omitempty"`
 }
 
 // TextDocumentItem is
@@ -186,7 +196,11 @@ type DiagnosticRelatedInformation struct {
 	Message  string   `json:"message"`
 }
 
-const SeverityError = 1
+const (
+	SeverityError       = 1
+	SeverityWarning     = 2
+	SeverityInformation = 3
+)
 
 // Diagnostic is
 type Diagnostic struct {
@@ -202,6 +216,21 @@ type Diagnostic struct {
 
 	Message            string                         `json:"message"`
 	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation"`
+
+	// Data is preserved by the client between publishDiagnostics and
+	// codeAction, see DiagnosticData.
+	Data any `json:"data,omitempty"`
+}
+
+// DiagnosticData is the Data of diagnostics published by this server
+type DiagnosticData struct {
+	Fixes []DiagnosticFix `json:"fixes,omitempty"`
+}
+
+// DiagnosticFix is a quick-fix for the diagnostic
+type DiagnosticFix struct {
+	Title string     `json:"title"`
+	Edits []TextEdit `json:"edits"`
 }
 
 // PublishDiagnosticsParams is
@@ -220,6 +249,21 @@ type DocumentFormattingParams struct {
 	Options      FormattingOptions      `json:"options"`
 }
 
+// DocumentRangeFormattingParams is
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+
+// DocumentOnTypeFormattingParams is
+type DocumentOnTypeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+	Ch           string                 `json:"ch"`
+	Options      FormattingOptions      `json:"options"`
+}
+
 // TextEdit is
 type TextEdit struct {
 	Range   Range  `json:"range"`
@@ -294,13 +338,14 @@ type Command struct {
 
 // WorkspaceEdit is
 type WorkspaceEdit struct {
-	Changes         any `json:"changes"`         // { [uri: DocumentUri]: TextEdit[]; };
-	DocumentChanges any `json:"documentChanges"` // (TextDocumentEdit[] | (TextDocumentEdit | CreateFile | RenameFile | DeleteFile)[]);
+	Changes         any `json:"changes,omitempty"`         // { [uri: DocumentUri]: TextEdit[]; };
+	DocumentChanges any `json:"documentChanges,omitempty"` // (TextDocumentEdit[] | (TextDocumentEdit | CreateFile | RenameFile | DeleteFile)[]);
 }
 
 // CodeAction is
 type CodeAction struct {
 	Title       string         `json:"title"`
+	Kind        CodeActionKind `json:"kind,omitempty"`
 	Diagnostics []Diagnostic   `json:"diagnostics"`
 	IsPreferred bool           `json:"isPreferred"` // TODO
 	Edit        *WorkspaceEdit `json:"edit"`
@@ -0,0 +1,32 @@
+package parser
+
+// Calls returns the function calls in the assigned values of the file, in
+// source order, each before the calls in its arguments.
+func Calls(file *File) []*Call {
+	calls := []*Call{}
+	var value func(v Value)
+	value = func(v Value) {
+		if v.call != nil {
+			calls = append(calls, v.call)
+			for _, arg := range v.call.Args {
+				value(arg)
+			}
+		}
+		for _, el := range v.array {
+			value(el)
+		}
+	}
+	var body func(b Body)
+	body = func(b Body) {
+		for _, statement := range b.Statements {
+			switch st := statement.(type) {
+			case *Assignment:
+				value(st.Value)
+			case *Block:
+				body(st.Body)
+			}
+		}
+	}
+	body(file.Body)
+	return calls
+}
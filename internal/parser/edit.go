@@ -0,0 +1,195 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// SetEdits returns the edits which set the attribute at path to value, BCL
+// source for the value. An existing assignment has its value replaced,
+// leaving the rest of the file as it was. Otherwise an assignment is added at
+// the end of the innermost block on the path, or of the file.
+//
+// A block is on the path when its type and tags are, e.g. 'server.timeout'
+// is set in 'server { timeout = 1 }' and in 'server.timeout = 1', and
+// 'service.api.port' in 'service api { port = 80 }'.
+//
+// Added lines end with the line break of the file's first line, as Fmt keeps.
+func SetEdits(source string, file *File, path []string, value string) ([]errpos.Edit, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	if assign := findAssignment(file.Body, path); assign != nil {
+		return []errpos.Edit{{
+			Start:   assign.Value.Start,
+			End:     afterPoint(assign.Value.End),
+			NewText: value,
+		}}, nil
+	}
+
+	lines := errpos.SplitLines(source)
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, "\r\n")
+	}
+	newline := FmtOptions{}.layout(source).newline
+	body, block, rest, err := insertionBlock(file.Body, nil, path)
+	if err != nil {
+		return nil, err
+	}
+	statement := strings.Join(rest, ".") + " = " + value
+
+	if block == nil {
+		end := errpos.Point{Line: len(lines) - 1, Column: len([]rune(lines[len(lines)-1]))}
+		insert := statement + newline
+		if end.Column > 0 {
+			// Keep the file without a trailing newline
+			insert = newline + statement
+		}
+		return []errpos.Edit{{Start: end, End: end, NewText: insert}}, nil
+	}
+
+	if block.Close == nil {
+		return nil, fmt.Errorf("block %s has no body", block.Type)
+	}
+	indent := lineIndent(lines, block.Start.Line) + indentUnit(lines)
+	if len(body.Statements) > 0 {
+		indent = lineIndent(lines, body.Statements[0].Source().Start.Line)
+	}
+	closeLine := errpos.Point{Line: block.Close.Token.Start.Line}
+	return []errpos.Edit{{
+		Start:   closeLine,
+		End:     closeLine,
+		NewText: indent + statement + newline,
+	}}, nil
+}
+
+// UnsetEdits returns the edits which remove the lines of the assignment at
+// path, which statements have to themselves. Returns no edits when the path is
+// not assigned.
+func UnsetEdits(file *File, path []string) ([]errpos.Edit, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	assign := findAssignment(file.Body, path)
+	if assign == nil {
+		return nil, nil
+	}
+
+	return []errpos.Edit{{
+		Start: errpos.Point{Line: assign.Start.Line},
+		End:   errpos.Point{Line: assign.End.Line + 1},
+	}}, nil
+}
+
+// findAssignment returns the last assignment of the whole path in the body,
+// directly or in blocks on the path.
+func findAssignment(body Body, path []string) *Assignment {
+	var found *Assignment
+	for _, statement := range body.Statements {
+		switch st := statement.(type) {
+		case *Assignment:
+			if !st.Append && pathEqual(referencePath(st.Key), path) {
+				found = st
+			}
+		case *Block:
+			blockPath := blockPath(st)
+			if len(path) > len(blockPath) && pathEqual(blockPath, path[:len(blockPath)]) {
+				if assign := findAssignment(st.Body, path[len(blockPath):]); assign != nil {
+					found = assign
+				}
+			}
+		}
+	}
+	return found
+}
+
+// insertionBlock finds the innermost block on the path, returning its body
+// and the rest of the path under it. The block is nil for the root of the
+// file.
+func insertionBlock(body Body, block *Block, path []string) (Body, *Block, []string, error) {
+	var matches []*Block
+	for _, statement := range body.Statements {
+		st, ok := statement.(*Block)
+		if !ok {
+			continue
+		}
+		blockPath := blockPath(st)
+		if len(path) > len(blockPath) && pathEqual(blockPath, path[:len(blockPath)]) {
+			matches = append(matches, st)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return body, block, path, nil
+	case 1:
+		match := matches[0]
+		return insertionBlock(match.Body, match, path[len(blockPath(match)):])
+	default:
+		return Body{}, nil, nil, fmt.Errorf("%s is in %d blocks, lines %d and %d", strings.Join(path, "."), len(matches), matches[0].Start.Line+1, matches[1].Start.Line+1)
+	}
+}
+
+func referencePath(ref Reference) []string {
+	path := make([]string, len(ref.Idents))
+	for idx, ident := range ref.Idents {
+		path[idx] = ident.Value
+	}
+	return path
+}
+
+// blockPath is the type of the block followed by its tags.
+func blockPath(block *Block) []string {
+	path := referencePath(block.Type)
+	for _, tag := range block.Tags {
+		if tag.Reference != nil {
+			path = append(path, referencePath(*tag.Reference)...)
+			continue
+		}
+		str, err := tag.AsString()
+		if err != nil {
+			break
+		}
+		path = append(path, str)
+	}
+	return path
+}
+
+func pathEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func lineIndent(lines []string, line int) string {
+	text := lines[line]
+	return text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+}
+
+// indentUnit is the indent of the first indented line, for files indented
+// with spaces, defaulting to a tab as Fmt writes.
+func indentUnit(lines []string) string {
+	for idx, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if indent := lineIndent(lines, idx); indent != "" {
+			return indent
+		}
+	}
+	return "\t"
+}
+
+// afterPoint converts the inclusive end of a node to the exclusive end of an
+// edit.
+func afterPoint(p errpos.Point) errpos.Point {
+	return errpos.Point{Line: p.Line, Column: p.Column + 1}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// Edition is the version of the language a file is written in, set by a
+// '!bcl 2' header. Syntax added after the first edition is only parsed in
+// files which opt in, so existing files keep their meaning.
+type Edition int
+
+const (
+	// Edition1 is the original syntax, used when a file has no edition
+	Edition1 Edition = 1
+
+	// Edition2 adds array splats, string joins, raw strings, function calls,
+	// quoted names and ${} interpolation in block names.
+	Edition2 Edition = 2
+
+	LatestEdition = Edition2
+)
+
+const editionKey = "bcl"
+
+func parseEdition(value string) (Edition, bool) {
+	number, err := strconv.Atoi(value)
+	if err != nil || number < int(Edition1) || number > int(LatestEdition) {
+		return 0, false
+	}
+	return Edition(number), true
+}
+
+// requireEdition returns an error at tok when the file's edition is older
+// than the edition which added the feature.
+func (ww *Walker) requireEdition(edition Edition, tok Token, feature string) *unexpectedTokenError {
+	if ww.edition >= edition {
+		return nil
+	}
+	return &unexpectedTokenError{
+		tok:     tok,
+		code:    errpos.CodeEdition,
+		message: fmt.Sprintf("%s needs edition %d, add '!bcl %d' to the file header", feature, edition, edition),
+	}
+}
+
+// readEdition sets the edition of the walker from a '!bcl' header. Its place
+// in the header is checked with the rest of the header.
+func (ww *Walker) readEdition(fragment Fragment) *unexpectedTokenError {
+	hdr, ok := fragment.(BlockHeader)
+	if !ok {
+		return nil
+	}
+	directive, ok := headerDirective(hdr)
+	if !ok || directive.Key != editionKey {
+		return nil
+	}
+	edition, ok := parseEdition(directive.Value)
+	if !ok {
+		return &unexpectedTokenError{
+			tok:     hdr.Tags[0].Value.token,
+			code:    errpos.CodeEdition,
+			message: fmt.Sprintf("unknown edition %q, the latest is %d", directive.Value, LatestEdition),
+		}
+	}
+	ww.edition = edition
+	return nil
+}
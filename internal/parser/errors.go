@@ -21,6 +21,24 @@ type unexpectedTokenError struct {
 	tok      Token
 	expected []TokenType
 	context  string
+
+	// code and message replace the default for errors which are not about the
+	// token type, e.g. limits.
+	code    errpos.Code
+	message string
+
+	// related is where the bracket the token should have closed was opened.
+	related []errpos.Related
+}
+
+// openedAt notes that the error may be a missing close of the bracket or
+// parenthesis, what, opened by the token.
+func (e *unexpectedTokenError) openedAt(opener Token, what string) *unexpectedTokenError {
+	e.related = append(e.related, errpos.Related{
+		Pos:     errpos.Position{Start: opener.Start, End: opener.End},
+		Message: fmt.Sprintf("%s opened here", what),
+	})
+	return e
 }
 
 func (e *unexpectedTokenError) Error() string {
@@ -31,6 +49,9 @@ func (e *unexpectedTokenError) Error() string {
 }
 
 func (e *unexpectedTokenError) msg() string {
+	if e.message != "" {
+		return e.message
+	}
 	if len(e.expected) == 1 {
 		return fmt.Sprintf("unexpected %s, want %s", e.tok, e.expected[0])
 	}
@@ -54,6 +75,13 @@ func (e *unexpectedTokenError) ErrorPosition() *errpos.Position {
 	}
 }
 
+func (e *unexpectedTokenError) ErrorCode() errpos.Code {
+	if e.code != "" {
+		return e.code
+	}
+	return errpos.CodeUnexpectedToken
+}
+
 func (e *unexpectedTokenError) WithoutPosition() error {
 	return errors.New(e.msg())
 }
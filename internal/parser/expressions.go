@@ -17,6 +17,11 @@ func (i Ident) String() string {
 	return i.Value
 }
 
+// Quoted is true for an ident written as a string, e.g. "my-key".
+func (i Ident) Quoted() bool {
+	return i.Token.Type == STRING
+}
+
 func (i Ident) GoString() string {
 	return fmt.Sprintf("ident(%s)", i.Value)
 }
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 )
@@ -11,7 +12,34 @@ type File struct {
 
 	Body Body
 
+	// Header is the '!key "value"' directives at the start of the file
+	Header []*HeaderDirective
+
+	// Edition is set by the '!bcl' header, defaulting to Edition1
+	Edition Edition
+
+	// Suppressions are the bcl:ignore directives found in comments
+	Suppressions Suppressions
+
+	// LineMap is the '#line' directives of a generated file
+	LineMap LineMap
+
+	// Separators are the '---' lines between the documents of the file
+	Separators []Position
+
 	Errors errpos.Errors
+
+	Stats Stats
+
+	// reserved are the reserved words used as bare names, see ReservedNames
+	reserved []Ident
+}
+
+// Stats are counters and timings from parsing a file
+type Stats struct {
+	Tokens int
+	Lex    time.Duration
+	Parse  time.Duration
 }
 
 type Error struct {
@@ -28,6 +56,10 @@ func (te *TypeError) Error() string {
 	return fmt.Sprintf("expected a %s, got %s", te.Expected, te.Got)
 }
 
+func (te *TypeError) ErrorCode() errpos.Code {
+	return errpos.CodeTypeMismatch
+}
+
 type SourceNode struct {
 	Start   Position
 	End     Position
@@ -49,3 +81,83 @@ type Body struct {
 	IsRoot     bool
 	Statements []Statement
 }
+
+// HeaderKeys are the keys of the '!key "value"' directives which may start a
+// file: the edition and schema it is written against, and metadata kept
+// outside of the parsed message.
+var HeaderKeys = []string{editionKey, "schema", "version", "owner", "description"}
+
+func isHeaderKey(key string) bool {
+	for _, known := range HeaderKeys {
+		if key == known {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderDirective is a '!key "value"' statement in the header of a file, the
+// run of such statements before any other.
+type HeaderDirective struct {
+	Key   string
+	Value string
+
+	// SourceNode is the position of the value
+	SourceNode
+}
+
+// HeaderValue returns the header directive with the key, if the file has one.
+func (f *File) HeaderValue(key string) (*HeaderDirective, bool) {
+	for _, directive := range f.Header {
+		if directive.Key == key {
+			return directive, true
+		}
+	}
+	return nil, false
+}
+
+// headerDirective reads a block header as a header directive, which is a
+// negated flag with a single string or integer tag.
+func headerDirective(hdr BlockHeader) (*HeaderDirective, bool) {
+	if hdr.Mark != TagMarkBang || len(hdr.Type.Idents) != 1 || len(hdr.Tags) != 1 {
+		return nil, false
+	}
+	tag := hdr.Tags[0]
+	if tag.Value == nil {
+		return nil, false
+	}
+	if tag.Value.token.Type != STRING && tag.Value.token.Type != INT {
+		return nil, false
+	}
+	return &HeaderDirective{
+		Key:        hdr.Type.String(),
+		Value:      tag.Value.token.Lit,
+		SourceNode: tag.SourceNode,
+	}, true
+}
+
+// addHeader adds a header directive read at stmt, which is only valid at the
+// root of the file before any other statement.
+func (f *File) addHeader(directive *HeaderDirective, isRoot bool, stmt SourceNode) *errpos.Err {
+	var err error
+	switch {
+	case !isRoot || len(f.Body.Statements) > 0:
+		err = fmt.Errorf("!%s must be in the header, before any other statement", directive.Key)
+	case !isHeaderKey(directive.Key):
+		err = fmt.Errorf("unknown header !%s, expected one of %v", directive.Key, HeaderKeys)
+	default:
+		if _, ok := f.HeaderValue(directive.Key); ok {
+			err = fmt.Errorf("duplicate header !%s", directive.Key)
+		}
+	}
+	if err != nil {
+		pos := stmt.Position()
+		return &errpos.Err{
+			Pos:  &pos,
+			Code: errpos.CodeUnexpectedToken,
+			Err:  err,
+		}
+	}
+	f.Header = append(f.Header, directive)
+	return nil
+}
@@ -3,8 +3,10 @@ package parser
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/bcl/project"
 )
 
 type FmtDiff struct {
@@ -13,32 +15,158 @@ type FmtDiff struct {
 	NewText  string
 }
 
+// FmtOptions are the choices the formatter leaves to the project.
+type FmtOptions struct {
+	// MaxBlankLines is the longest run of blank lines kept between
+	// statements, longer runs are shortened to it. Zero uses the default of
+	// one.
+	MaxBlankLines int
+
+	// Width is the column arrays and joined strings wrap at, with tabs as
+	// four columns. Longer values are printed one element or part per line,
+	// and values which fit are joined onto one line. Zero keeps values on the
+	// lines they were written on.
+	Width int
+
+	// LineEndings is "lf" or "crlf", the line breaks of the output. Empty
+	// keeps the line break of the first line of the input, "\n" when it has
+	// none, so that files written on Windows stay as they are.
+	LineEndings string
+
+	// IndentSpaces indents each level of blocks and arrays with that many
+	// spaces. Zero indents with tabs.
+	IndentSpaces int
+
+	// ByteOrderMark is "add" or "remove", whether the output starts with a
+	// byte order mark. Empty keeps the one the input has, or hasn't.
+	ByteOrderMark string
+}
+
+// ConfigOptions are the layout options of a project's format config, which
+// apply after any ordering of statements.
+func ConfigOptions(config project.FormatConfig) FmtOptions {
+	return FmtOptions{
+		MaxBlankLines: config.MaxBlankLines,
+		Width:         config.Width,
+		LineEndings:   config.LineEndings,
+		IndentSpaces:  config.IndentSpaces,
+		ByteOrderMark: config.ByteOrderMark,
+	}
+}
+
+func (o FmtOptions) maxBlankLines() int {
+	if o.MaxBlankLines < 1 {
+		return 1
+	}
+	return o.MaxBlankLines
+}
+
+// layout is how the text of the formatter is written into the file.
+type layout struct {
+	newline string
+	bom     bool
+}
+
+func (o FmtOptions) layout(input string) layout {
+	ly := layout{
+		newline: "\n",
+		bom:     strings.HasPrefix(input, errpos.ByteOrderMark),
+	}
+	switch o.LineEndings {
+	case "lf":
+	case "crlf":
+		ly.newline = "\r\n"
+	default:
+		first := errpos.SplitLines(input)[0]
+		if strings.HasSuffix(first, "\r\n") {
+			ly.newline = "\r\n"
+		} else if strings.HasSuffix(first, "\r") {
+			ly.newline = "\r"
+		}
+	}
+	switch o.ByteOrderMark {
+	case "add":
+		ly.bom = true
+	case "remove":
+		ly.bom = false
+	}
+	return ly
+}
+
+// lines writes the text, which the formatter builds with "\n" line breaks,
+// with the line breaks of the layout. Block comments can have any.
+func (ly layout) lines(text string) string {
+	if ly.newline == "\n" && !strings.Contains(text, "\r") {
+		return text
+	}
+	lines := errpos.SplitLines(text)
+	for idx, line := range lines[:len(lines)-1] {
+		lines[idx] = strings.TrimRight(line, "\r\n") + ly.newline
+	}
+	return strings.Join(lines, "")
+}
+
+func (o FmtOptions) indent(depth int) string {
+	if o.IndentSpaces > 0 {
+		return strings.Repeat(" ", o.IndentSpaces*depth)
+	}
+	return strings.Repeat("\t", depth)
+}
+
+// indentWidth is the columns of an indent, with tabs as four columns.
+func (o FmtOptions) indentWidth(depth int) int {
+	if o.IndentSpaces > 0 {
+		return o.IndentSpaces * depth
+	}
+	return 4 * depth
+}
+
 func Fmt(input string) (string, error) {
-	diffs, err := collectFmtFragments(input)
+	return FmtOptions{}.Fmt(input)
+}
+
+func FmtDiffs(input string) ([]FmtDiff, error) {
+	return FmtOptions{}.Diffs(input)
+}
+
+// Fmt formats the input. Comments keep their place relative to the
+// statements around them, and formatting the output again does not change
+// it.
+func (o FmtOptions) Fmt(input string) (string, error) {
+	diffs, err := collectFmtFragments(input, o)
 	if err != nil {
 		return "", err
 	}
 
-	out := make([]string, 0, len(diffs))
+	ly := o.layout(input)
+	out := make([]string, 0, len(diffs)+1)
+	if ly.bom {
+		out = append(out, errpos.ByteOrderMark)
+	}
 	lastEnd := -1
 	for idx, diff := range diffs {
 		if idx > 0 && (diff.FromLine > lastEnd) {
-			out = append(out, "\n")
+			out = append(out, strings.Repeat(ly.newline, min(diff.FromLine-lastEnd, o.maxBlankLines())))
 		}
-		out = append(out, diff.NewText)
+		out = append(out, ly.lines(diff.NewText))
 		lastEnd = diff.ToLine
 	}
 	return strings.Join(out, ""), nil
 }
 
-func FmtDiffs(input string) ([]FmtDiff, error) {
-	all, err := collectFmtFragments(input)
+// Diffs returns the changes Fmt makes to the input, by line, leaving the
+// lines which are already formatted out. Lines are counted as
+// errpos.SplitLines does, and the byte order mark is left as it is.
+func (o FmtOptions) Diffs(input string) ([]FmtDiff, error) {
+	all, err := collectFmtFragments(input, o)
 	if err != nil {
 		return nil, err
 	}
 
+	ly := o.layout(input)
 	lines := &lineSet{
-		lines: strings.Split(input, "\n"),
+		lines:   errpos.SplitLines(input),
+		newline: ly.newline,
 	}
 
 	out := make([]FmtDiff, 0, len(all))
@@ -53,16 +181,17 @@ func FmtDiffs(input string) ([]FmtDiff, error) {
 					NewText:  "",
 				})
 			}
-		} else if diff.FromLine > lastEnd+1 {
+		} else if diff.FromLine > lastEnd+o.maxBlankLines() {
 			// FromLine == LastEnd  means no gap
-			// FromLine == LastEnd + 1  is one line gap, OK
-			// FromLine > LastEnd + 1 should be one line
+			// FromLine == LastEnd + n  is an n line gap, OK up to the max
+			// Longer gaps are shortened to the max
 			out = append(out, FmtDiff{
 				FromLine: lastEnd,
 				ToLine:   diff.FromLine,
-				NewText:  "\n",
+				NewText:  strings.Repeat(ly.newline, o.maxBlankLines()),
 			})
 		}
+		diff.NewText = ly.lines(diff.NewText)
 		existing := lines.rangeLines(diff.FromLine, diff.ToLine)
 		if existing != diff.NewText {
 			out = append(out, diff)
@@ -72,15 +201,39 @@ func FmtDiffs(input string) ([]FmtDiff, error) {
 	return out, nil
 }
 
+// lineSet is the lines of the input, with their line breaks. The last line
+// has the newline of the output, as Fmt ends the file with one.
 type lineSet struct {
-	lines []string
+	lines   []string
+	newline string
 }
 
 func (ls *lineSet) rangeLines(from, to int) string {
-	return strings.Join(ls.lines[from:to], "\n") + "\n"
+	out := &strings.Builder{}
+	for _, line := range ls.lines[from:min(to, len(ls.lines))] {
+		out.WriteString(line)
+		if !strings.HasSuffix(line, "\n") && !strings.HasSuffix(line, "\r") {
+			out.WriteString(ls.newline)
+		}
+	}
+	return out.String()
 }
 
-func collectFmtFragments(input string) ([]FmtDiff, error) {
+func collectFmtFragments(input string, opts FmtOptions) ([]FmtDiff, error) {
+	fragments, err := fmtFragments(input)
+	if err != nil {
+		return nil, err
+	}
+	fmter := &fmter{
+		width: opts.Width,
+		opts:  opts,
+	}
+	fmter.diffFile(fragments)
+
+	return fmter.fragments, nil
+}
+
+func fmtFragments(input string) ([]Fragment, error) {
 	l := NewLexer(input)
 
 	tokens, ok, err := l.AllTokens(true)
@@ -102,15 +255,14 @@ func collectFmtFragments(input string) ([]FmtDiff, error) {
 
 		return nil, err
 	}
-	fmter := &fmter{}
-	fmter.diffFile(fragments)
-
-	return fmter.fragments, nil
+	return fragments, nil
 }
 
 type fmter struct {
 	fragments []FmtDiff
 	indent    int
+	width     int
+	opts      FmtOptions
 }
 
 func (p *fmter) diffFile(ff []Fragment) {
@@ -142,12 +294,21 @@ func (p *fmter) diffFile(ff []Fragment) {
 func tokenSource(tok Token) string {
 	switch tok.Type {
 	case STRING:
+		if tok.Raw {
+			return "`" + tok.Lit + "`"
+		}
 		return fmt.Sprintf("%q", tok.Lit)
 	case REGEX:
 		return fmt.Sprintf("/%s/", tok.Lit)
 	case DESCRIPTION:
 		return fmt.Sprintf("| %s", tok.Lit)
 	case COMMENT:
+		if tok.Hash {
+			return fmt.Sprintf("#%s", tok.Lit)
+		}
+		if tok.Separator {
+			return tok.Lit
+		}
 		return fmt.Sprintf("//%s", tok.Lit)
 	case BLOCK_COMMENT:
 		return fmt.Sprintf("/*%s*/", tok.Lit)
@@ -163,7 +324,7 @@ func (p *fmter) singleLineTokens(src SourceNode, parts ...Token) {
 	if src.Comment != nil {
 		line += inlineComment(src.Comment)
 	}
-	line = strings.Repeat("\t", p.indent) + line + "\n"
+	line = p.opts.indent(p.indent) + line + "\n"
 
 	p.fragments = append(p.fragments, FmtDiff{
 		FromLine: src.Start.Line,
@@ -174,7 +335,7 @@ func (p *fmter) singleLineTokens(src SourceNode, parts ...Token) {
 
 func (p *fmter) multiLineToken(src SourceNode, prefix string, lines []string) {
 
-	fullPrefix := strings.Repeat("\t", p.indent) + prefix
+	fullPrefix := p.opts.indent(p.indent) + prefix
 	for idx, part := range lines {
 		// remove trailing space INCLUDING anything after the prefix,
 		// for descriptions "| " becomes "\t|"
@@ -207,6 +368,9 @@ func newToken(ty TokenType, value string) Token {
 func (p *fmter) doBlockHeader(block BlockHeader) {
 
 	nameParts := referenceTokens(block.Type)
+	if block.Mark == TagMarkBang {
+		nameParts = append([]Token{newToken(BANG, "!")}, nameParts...)
+	}
 	for _, val := range block.Tags {
 		nameParts = append(nameParts, newToken(SPACE, " "))
 		nameParts = append(nameParts, tagString(val)...)
@@ -239,7 +403,7 @@ func (p *fmter) printComment(comment Comment) {
 }
 
 func (p *fmter) doDescription(desc Description) {
-	linesOut := reformatDescription(desc.Value, 80-p.indent*4)
+	linesOut := reformatDescription(desc.Value, 80-p.opts.indentWidth(p.indent))
 	p.multiLineToken(desc.SourceNode, "| ", linesOut)
 }
 
@@ -260,11 +424,166 @@ func (p *fmter) doAssignment(assign Assignment) {
 			newToken(SPACE, " "),
 		)
 	}
-	tokens = append(tokens, valueTokens(assign.Value)...)
-	p.singleLineTokens(assign.SourceNode, tokens...)
+	prefix := ""
+	for _, tok := range tokens {
+		prefix += tokenSource(tok)
+	}
+	suffix := inlineComment(assign.Comment)
+	if !p.formatMultiline(assign.Value, p.indent, prefix, suffix) {
+		tokens = append(tokens, valueTokens(assign.Value)...)
+		p.singleLineTokens(assign.SourceNode, tokens...)
+		return
+	}
+
+	lines := p.valueLines(assign.Value, p.indent, prefix, suffix)
+	p.fragments = append(p.fragments, FmtDiff{
+		FromLine: assign.Start.Line,
+		ToLine:   assign.End.Line + 1, // exclusive
+		NewText:  strings.Join(lines, "\n") + "\n",
+	})
+}
+
+// formatMultiline is true for values printed over several lines, on a line
+// between the prefix and suffix. Arrays with comments would lose them on one
+// line. Without a width, values keep being written over several lines or
+// not, with one, values which don't fit in it are wrapped. Scalars have
+// nowhere to wrap, and always stay on one line.
+func (p *fmter) formatMultiline(v Value, indent int, prefix, suffix string) bool {
+	if v.hasComments() {
+		return true
+	}
+	if len(v.array) == 0 && len(v.parts) == 0 {
+		return false
+	}
+	if p.width <= 0 {
+		return v.writtenMultiline()
+	}
+	line := prefix + suffix
+	for _, tok := range valueTokens(v) {
+		line += tokenSource(tok)
+	}
+	return p.opts.indentWidth(indent)+utf8.RuneCountInString(line) > p.width
+}
+
+// hasComments is true for arrays with comments in them, at any depth.
+func (v Value) hasComments() bool {
+	if len(v.trailing) > 0 {
+		return true
+	}
+	for _, val := range v.array {
+		if len(val.leading) > 0 || val.Comment != nil || val.hasComments() {
+			return true
+		}
+	}
+	return false
+}
+
+// writtenMultiline is true for arrays and joined strings written over several
+// lines.
+func (v Value) writtenMultiline() bool {
+	if len(v.parts) > 0 {
+		return v.parts[0].token.Start.Line != v.parts[len(v.parts)-1].token.Start.Line
+	}
+	if len(v.array) == 0 {
+		return false
+	}
+	if v.multiline {
+		return true
+	}
+	for _, val := range v.array {
+		if val.writtenMultiline() {
+			return true
+		}
+	}
+	return false
+}
+
+// valueLines prints a multiline array with one element per line, each with
+// a trailing comma so that adding an element is a one line diff.
+func (p *fmter) valueLines(v Value, indent int, prefix, suffix string) []string {
+	pad := p.opts.indent(indent)
+	if !p.formatMultiline(v, indent, prefix, suffix) {
+		line := ""
+		for _, tok := range valueTokens(v) {
+			line += tokenSource(tok)
+		}
+		return []string{pad + prefix + line + suffix}
+	}
+
+	innerPad := p.opts.indent(indent + 1)
+	if len(v.parts) > 0 {
+		// Parts keep their lines, or with a width are one per line.
+		// Continuation lines are indented once.
+		lines := []string{}
+		line := pad + prefix + tokenSource(v.parts[0].token)
+		for idx, part := range v.parts[1:] {
+			sameLine := p.width <= 0 && part.token.Start.Line == v.parts[idx].token.Start.Line
+			switch {
+			case sameLine && part.plus:
+				line += " + "
+			case sameLine:
+				line += " "
+			case part.plus:
+				lines = append(lines, line+" +")
+				line = innerPad
+			default:
+				lines = append(lines, line)
+				line = innerPad
+			}
+			line += tokenSource(part.token)
+		}
+		return append(lines, line+suffix)
+	}
+
+	lines := []string{pad + prefix + "["}
+	for _, val := range v.array {
+		for _, comment := range val.leading {
+			lines = append(lines, innerPad+tokenSource(comment.Token))
+		}
+		suffix := ","
+		if val.Comment != nil {
+			suffix += " " + tokenSource(val.Comment.Token)
+		}
+		lines = append(lines, p.valueLines(val, indent+1, "", suffix)...)
+	}
+	for _, comment := range v.trailing {
+		lines = append(lines, innerPad+tokenSource(comment.Token))
+	}
+	return append(lines, pad+"]"+suffix)
 }
 
 func valueTokens(v Value) []Token {
+	if v.splat != nil {
+		return append(referenceTokens(*v.splat), newToken(ELLIPSIS, "..."))
+	}
+	if v.call != nil && v.call.Encrypted {
+		return append(referenceTokens(v.call.Name), valueTokens(v.call.Args[0])...)
+	}
+	if v.call != nil {
+		toks := append(referenceTokens(v.call.Name), newToken(LPAREN, "("))
+		for idx, arg := range v.call.Args {
+			if idx > 0 {
+				toks = append(toks,
+					newToken(COMMA, ","),
+					newToken(SPACE, " "))
+			}
+			toks = append(toks, valueTokens(arg)...)
+		}
+		return append(toks, newToken(RPAREN, ")"))
+	}
+	if len(v.parts) > 0 {
+		toks := []Token{}
+		for idx, part := range v.parts {
+			if idx > 0 && part.plus {
+				toks = append(toks, newToken(SPACE, " "), newToken(PLUS, "+"))
+			}
+			if idx > 0 {
+				toks = append(toks, newToken(SPACE, " "))
+			}
+			toks = append(toks, part.token)
+		}
+		return toks
+	}
 	if v.array == nil {
 		return []Token{v.token}
 	}
@@ -287,6 +606,10 @@ func inlineComment(comment *Comment) string {
 	if comment == nil {
 		return ""
 	}
+	if comment.Token.Type == COMMENT {
+		// Keeps # comments as they were written
+		return " " + tokenSource(comment.Token)
+	}
 	return " //" + comment.Value
 }
 
@@ -317,3 +640,86 @@ func referenceTokens(r Reference) []Token {
 	}
 	return toks
 }
+
+// CheckFmt returns an error when format, run on the input, drops or adds a
+// comment, or when formatting its output again changes it.
+func CheckFmt(input string, format func(string) (string, error)) error {
+	once, err := format(input)
+	if err != nil {
+		return err
+	}
+	twice, err := format(once)
+	if err != nil {
+		return fmt.Errorf("formatted output does not format: %w", err)
+	}
+	if twice != once {
+		onceLines, twiceLines := strings.Split(once, "\n"), strings.Split(twice, "\n")
+		for idx := range onceLines {
+			if idx >= len(twiceLines) || onceLines[idx] != twiceLines[idx] {
+				got := ""
+				if idx < len(twiceLines) {
+					got = twiceLines[idx]
+				}
+				return fmt.Errorf("formatting is not stable, formatting again changes line %d from %q to %q", idx+1, onceLines[idx], got)
+			}
+		}
+		return fmt.Errorf("formatting is not stable, formatting again adds lines")
+	}
+
+	inputComments, err := commentTokens(input)
+	if err != nil {
+		return err
+	}
+	outputComments, err := commentTokens(once)
+	if err != nil {
+		return err
+	}
+	remaining := map[string]int{}
+	for _, tok := range outputComments {
+		remaining[tokenSource(tok)]++
+	}
+	var errs errpos.Errors
+	for _, tok := range inputComments {
+		text := tokenSource(tok)
+		if remaining[text] > 0 {
+			remaining[text]--
+			continue
+		}
+		pos := errpos.Position{Start: tok.Start, End: tok.End}
+		errs = append(errs, &errpos.Err{
+			Pos: &pos,
+			Err: fmt.Errorf("formatting drops the comment %s", text),
+		})
+	}
+	for _, tok := range outputComments {
+		text := tokenSource(tok)
+		if remaining[text] > 0 {
+			remaining[text]--
+			errs = append(errs, &errpos.Err{
+				Err: fmt.Errorf("formatting adds the comment %s", text),
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return errpos.AddSource(errs, input)
+	}
+	return nil
+}
+
+func commentTokens(input string) ([]Token, error) {
+	l := NewLexer(input)
+	tokens, ok, err := l.AllTokens(true)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected lexer error: %w", err)
+	}
+	if !ok {
+		return nil, errpos.AddSource(l.Errors, input)
+	}
+	comments := []Token{}
+	for _, tok := range tokens {
+		if tok.Type == COMMENT || tok.Type == BLOCK_COMMENT {
+			comments = append(comments, tok)
+		}
+	}
+	return comments, nil
+}
@@ -45,6 +45,56 @@ func TestFmtDiff(t *testing.T) {
 		expected: []FmtDiff{},
 	})
 
+	run("flags", testCase{
+		input:    "cache\n!debug\nno-trace\n",
+		expected: []FmtDiff{},
+	})
+
+	run("multiline array", testCase{
+		input:    s("a = [", "\t\"x\", // first", "\t// about y", "\t\"y\",", "]", ""),
+		expected: []FmtDiff{},
+	})
+
+	run("multiline array trailing comma", testCase{
+		input:    s("a = [", "  \"x\"", "]", ""),
+		expected: []FmtDiff{{0, 3, "a = [\n\t\"x\",\n]\n"}},
+	})
+
+	run("array splat", testCase{
+		input:    s("!bcl 2", "a = [b.c...,\"x\"]", ""),
+		expected: []FmtDiff{{1, 2, "a = [b.c..., \"x\"]\n"}},
+	})
+
+	run("function call", testCase{
+		input:    s("!bcl 2", "a = upper(lower(\"x\"),1 )", ""),
+		expected: []FmtDiff{{1, 2, "a = upper(lower(\"x\"), 1)\n"}},
+	})
+
+	run("encrypted string", testCase{
+		input:    s("!bcl 2", "a = [ enc\"AGE-x\" ]", ""),
+		expected: []FmtDiff{{1, 2, "a = [enc\"AGE-x\"]\n"}},
+	})
+
+	run("line directive", testCase{
+		input:    s("#line lists.tmpl:10", "a = 1", ""),
+		expected: []FmtDiff{},
+	})
+
+	run("schema header", testCase{
+		input:    s("!schema   \"test.v1.File\"", "a = 1", ""),
+		expected: []FmtDiff{{0, 1, "!schema \"test.v1.File\"\n"}},
+	})
+
+	run("string escapes", testCase{
+		input:    s("!bcl 2", "a = \"x\\ty\"", "b = `C:\\dir`", ""),
+		expected: []FmtDiff{},
+	})
+
+	run("string join", testCase{
+		input:    s("!bcl   2", "a = \"x\"  \"y\" +", "      \"z\"", "b = \"p\"+\"q\"", ""),
+		expected: []FmtDiff{{0, 1, "!bcl 2\n"}, {1, 3, "a = \"x\" \"y\" +\n\t\"z\"\n"}, {3, 4, "b = \"p\" + \"q\"\n"}},
+	})
+
 	run("leading", testCase{
 		input:    "\na = 1\n",
 		expected: []FmtDiff{{0, 1, ""}},
@@ -79,6 +129,257 @@ func TestFmtDiff(t *testing.T) {
 			{1, 3, "\n"},
 		},
 	})
+
+	run("close comment", testCase{
+		input:    s("a {", "\tb = 1", "} // c", ""),
+		expected: []FmtDiff{},
+	})
+}
+
+func TestFmtMaxBlankLines(t *testing.T) {
+	input := "a = 1\n\n\n\n\nb {\n\n\n\tc = 1\n}\n"
+	for _, tc := range []struct {
+		max      int
+		expected string
+	}{
+		{0, "a = 1\n\nb {\n\n\tc = 1\n}\n"},
+		{1, "a = 1\n\nb {\n\n\tc = 1\n}\n"},
+		{2, "a = 1\n\n\nb {\n\n\n\tc = 1\n}\n"},
+		{5, input},
+	} {
+		t.Run(fmt.Sprintf("max %d", tc.max), func(t *testing.T) {
+			opts := FmtOptions{MaxBlankLines: tc.max}
+			actual, err := opts.Fmt(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("got %q, want %q", actual, tc.expected)
+			}
+			if err := CheckFmt(input, opts.Fmt); err != nil {
+				t.Error(err)
+			}
+
+			// The diffs make the same change
+			diffs, err := opts.Diffs(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(input, "\n")
+			for idx := len(diffs) - 1; idx >= 0; idx-- {
+				diff := diffs[idx]
+				replaced := strings.Split(strings.TrimSuffix(diff.NewText, "\n"), "\n")
+				if diff.NewText == "" {
+					replaced = nil
+				}
+				lines = append(lines[:diff.FromLine], append(replaced, lines[diff.ToLine:]...)...)
+			}
+			if got := strings.Join(lines, "\n"); got != tc.expected {
+				t.Errorf("diffs give %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFmtWidth(t *testing.T) {
+	s := func(s ...string) string { return strings.Join(s, "\n") + "\n" }
+	opts := FmtOptions{Width: 30}
+
+	for _, tc := range []struct {
+		name     string
+		inputs   []string
+		expected string
+	}{{
+		name:     "short array joins",
+		inputs:   []string{s(`a = [`, `	1,`, `	2,`, `]`), s(`a = [1, 2]`)},
+		expected: s(`a = [1, 2]`),
+	}, {
+		name:   "long array wraps",
+		inputs: []string{s(`a = ["alpha", "beta", "gamma", "delta"]`), s(`a = ["alpha",`, `"beta", "gamma", "delta"]`)},
+		expected: s(
+			`a = [`,
+			`	"alpha",`,
+			`	"beta",`,
+			`	"gamma",`,
+			`	"delta",`,
+			`]`,
+		),
+	}, {
+		name:   "nested arrays wrap by their own width",
+		inputs: []string{s(`a = [[1, 2], ["al", "be", "ga"]]`)},
+		expected: s(
+			`a = [`,
+			`	[1, 2],`,
+			`	["al", "be", "ga"],`,
+			`]`,
+		),
+	}, {
+		name:   "indent counts",
+		inputs: []string{s(`b {`, `a = ["alpha", "beta", "gam"]`, `}`)},
+		expected: s(
+			`b {`,
+			`	a = [`,
+			`		"alpha",`,
+			`		"beta",`,
+			`		"gam",`,
+			`	]`,
+			`}`,
+		),
+	}, {
+		name:     "comments keep arrays wrapped",
+		inputs:   []string{s(`a = [`, `	1, // one`, `]`)},
+		expected: s(`a = [`, `	1, // one`, `]`),
+	}, {
+		name:   "long strings wrap at parts",
+		inputs: []string{s(`!bcl 2`, `a = "the first part" + "the second"`)},
+		expected: s(
+			`!bcl 2`,
+			`a = "the first part" +`,
+			`	"the second"`,
+		),
+	}, {
+		name:     "long scalars stay on one line",
+		inputs:   []string{s(`name = "a string much longer than the width"`)},
+		expected: s(`name = "a string much longer than the width"`),
+	}, {
+		name:     "short strings join",
+		inputs:   []string{s(`!bcl 2`, `a = "x" +`, `	"y"`)},
+		expected: s(`!bcl 2`, `a = "x" + "y"`),
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, input := range tc.inputs {
+				actual, err := opts.Fmt(input)
+				if err != nil {
+					printErr(t, err)
+					t.Fatal(err)
+				}
+				if actual != tc.expected {
+					t.Errorf("got\n%s\nwant\n%s", actual, tc.expected)
+				}
+				if err := CheckFmt(input, opts.Fmt); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+}
+
+func TestFmtRange(t *testing.T) {
+	input := strings.Join([]string{
+		"a=1",
+		"b {",
+		"  c=2",
+		"  d {",
+		"    e=3",
+		"  }",
+		"}",
+		"f=4",
+		"",
+	}, "\n")
+
+	for _, tc := range []struct {
+		name       string
+		start, end int
+		expected   string
+	}{{
+		name:     "one statement",
+		start:    strings.Index(input, "c=2") + 1,
+		end:      strings.Index(input, "c=2") + 1,
+		expected: "a=1\nb {\n\tc = 2\n  d {\n    e=3\n  }\n}\nf=4\n",
+	}, {
+		name:     "partial lines",
+		start:    strings.Index(input, "e=3") + 2,
+		end:      strings.Index(input, "f=4") + 1,
+		expected: "a=1\nb {\n  c=2\n  d {\n\t\te = 3\n\t}\n}\nf = 4\n",
+	}, {
+		name:     "whole file",
+		start:    0,
+		end:      len(input),
+		expected: "a = 1\nb {\n\tc = 2\n\td {\n\t\te = 3\n\t}\n}\nf = 4\n",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := FmtOptions{}.FmtRange(input, tc.start, tc.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual != tc.expected {
+				t.Errorf("got %q, want %q", actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestFmtBlockDiffs(t *testing.T) {
+	input := strings.Join([]string{
+		"a=1",
+		"b {",
+		"  c=2",
+		"  d {",
+		"    e=3",
+		"  }",
+		"}",
+		"f=4",
+		"",
+	}, "\n")
+
+	for _, tc := range []struct {
+		name     string
+		line     int
+		expected []FmtDiff
+	}{{
+		name: "inner block",
+		line: 4,
+		expected: []FmtDiff{
+			{3, 4, "\td {\n"},
+			{4, 5, "\t\te = 3\n"},
+			{5, 6, "\t}\n"},
+		},
+	}, {
+		name: "outer block",
+		line: 2,
+		expected: []FmtDiff{
+			{2, 3, "\tc = 2\n"},
+			{3, 4, "\td {\n"},
+			{4, 5, "\t\te = 3\n"},
+			{5, 6, "\t}\n"},
+		},
+	}, {
+		name:     "root",
+		line:     7,
+		expected: []FmtDiff{{7, 8, "f = 4\n"}},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := FmtOptions{}.BlockDiffs(input, tc.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCompare(t, tc.expected, actual, func(a, b FmtDiff) bool {
+				return a == b
+			}, func(a FmtDiff) string {
+				return fmt.Sprintf("%d %d %q", a.FromLine, a.ToLine, a.NewText)
+			})
+		})
+	}
+}
+
+func TestCheckFmt(t *testing.T) {
+	dropsComments := func(input string) (string, error) {
+		return "a = 1\n", nil
+	}
+	if err := CheckFmt("// keep\na = 1\n", dropsComments); err == nil {
+		t.Error("expected an error for a dropped comment")
+	} else if !strings.Contains(err.Error(), "drops the comment // keep") {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	grows := func(input string) (string, error) {
+		return input + "a = 1\n", nil
+	}
+	if err := CheckFmt("a = 1\n", grows); err == nil {
+		t.Error("expected an error for an unstable format")
+	} else if !strings.Contains(err.Error(), "not stable") {
+		t.Errorf("unexpected error: %s", err)
+	}
 }
 
 type slicePop[T any] struct {
@@ -220,6 +521,9 @@ func TestFmt(t *testing.T) {
 							return a
 						})
 
+					if err := CheckFmt(input, Fmt); err != nil {
+						t.Error(err)
+					}
 				})
 			}
 		})
@@ -253,6 +557,22 @@ func TestFmt(t *testing.T) {
 		},
 	})
 
+	run("comments", fmtCase{
+		expected: s(
+			`// head`,
+			``,
+			`a { // open`,
+			`	// inner`,
+			`	b = 1 // value`,
+			`} // close`,
+			`/* after */`,
+		),
+		inputs: []string{
+			s(`// head`, ``, `a { // open`, `// inner`, `b = 1 // value`, `} // close`, `/* after */`),
+			s(`// head`, ``, ``, ``, `a {   // open`, `    // inner`, `  b=1 // value`, `}  // close`, `/* after */`),
+		},
+	})
+
 	run("fmt.bcl", fmtCase{
 		testdata.FmtInput,
 		[]string{
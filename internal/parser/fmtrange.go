@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// RangeDiffs returns the diffs of Diffs which change the lines from fromLine
+// to toLine, inclusive, 0 based. Statements which are partly in the range are
+// formatted whole, the rest of the input is left as it is. The whole input
+// must parse.
+func (o FmtOptions) RangeDiffs(input string, fromLine, toLine int) ([]FmtDiff, error) {
+	all, err := o.Diffs(input)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]FmtDiff, 0, len(all))
+	for _, diff := range all {
+		if diff.FromLine <= toLine && diff.ToLine > fromLine {
+			out = append(out, diff)
+		}
+	}
+	return out, nil
+}
+
+// BlockDiffs returns the diffs of Diffs in the innermost block around the
+// line, from its header to its closing brace, or of the statement on the line
+// when it is not in a block. It is for formatting as the user types.
+func (o FmtOptions) BlockDiffs(input string, line int) ([]FmtDiff, error) {
+	fragments, err := fmtFragments(input)
+	if err != nil {
+		return nil, err
+	}
+	fromLine, toLine := enclosingBlock(fragments, line)
+	return o.RangeDiffs(input, fromLine, toLine)
+}
+
+// enclosingBlock returns the first and last lines of the innermost block
+// around the line, or the line itself when there isn't one.
+func enclosingBlock(fragments []Fragment, line int) (int, int) {
+	fromLine, toLine := line, line
+	found := false
+	open := []int{}
+	for _, fragment := range fragments {
+		switch f := fragment.(type) {
+		case BlockHeader:
+			if f.Open {
+				open = append(open, f.Start.Line)
+			}
+		case CloseBlock:
+			if len(open) == 0 {
+				continue
+			}
+			start := open[len(open)-1]
+			open = open[:len(open)-1]
+			// Inner blocks close first, the first match is the innermost.
+			if !found && start <= line && f.End.Line >= line {
+				fromLine, toLine = start, f.End.Line
+				found = true
+			}
+		}
+	}
+	return fromLine, toLine
+}
+
+// FmtRange formats the statements which overlap the bytes from start to end
+// of the input, leaving the rest of it as it is.
+func (o FmtOptions) FmtRange(input string, start, end int) (string, error) {
+	start = max(0, min(start, len(input)))
+	end = max(start, min(end, len(input)))
+	diffs, err := o.RangeDiffs(input, len(errpos.SplitLines(input[:start]))-1, len(errpos.SplitLines(input[:end]))-1)
+	if err != nil {
+		return "", err
+	}
+	return applyFmtDiffs(input, diffs), nil
+}
+
+// applyFmtDiffs replaces the lines of each diff, the diffs are in order and
+// don't overlap.
+func applyFmtDiffs(input string, diffs []FmtDiff) string {
+	lines := errpos.SplitLines(input)
+	out := &strings.Builder{}
+	if strings.HasPrefix(input, errpos.ByteOrderMark) {
+		out.WriteString(errpos.ByteOrderMark)
+	}
+	line := 0
+	for _, diff := range diffs {
+		for ; line < diff.FromLine && line < len(lines); line++ {
+			out.WriteString(lines[line])
+		}
+		out.WriteString(diff.NewText)
+		line = diff.ToLine
+	}
+	for ; line < len(lines); line++ {
+		out.WriteString(lines[line])
+	}
+	return out.String()
+}
@@ -0,0 +1,50 @@
+package parser
+
+// Symbol is the interned identity of a string, equal strings from the same
+// Interner have the same Symbol.
+type Symbol int
+
+// Interner deduplicates identifier strings, so that repeated identifiers share
+// one string and can be keyed by Symbol.
+type Interner struct {
+	strings []string
+	symbols map[string]Symbol
+}
+
+func NewInterner() *Interner {
+	return &Interner{
+		symbols: map[string]Symbol{},
+	}
+}
+
+// Intern returns the canonical copy of s.
+func (in *Interner) Intern(s string) string {
+	return in.strings[in.Symbol(s)]
+}
+
+// Symbol returns the symbol for s, adding it if required.
+func (in *Interner) Symbol(s string) Symbol {
+	if sym, ok := in.symbols[s]; ok {
+		return sym
+	}
+	sym := Symbol(len(in.strings))
+	in.strings = append(in.strings, s)
+	in.symbols[s] = sym
+	return sym
+}
+
+// Lookup returns the symbol for s without adding it.
+func (in *Interner) Lookup(s string) (Symbol, bool) {
+	sym, ok := in.symbols[s]
+	return sym, ok
+}
+
+// String returns the string of a symbol.
+func (in *Interner) String(sym Symbol) string {
+	return in.strings[sym]
+}
+
+// Len is the number of distinct strings interned.
+func (in *Interner) Len() int {
+	return len(in.strings)
+}
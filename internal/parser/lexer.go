@@ -2,29 +2,91 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 )
 
 type Lexer struct {
 	line   int // 0 based
-	column int // 0 based
+	column int // 0 based, in runes
 
-	ch     rune
-	offset int
-	data   []rune
-	isEOL  bool
+	src     string
+	ch      rune
+	chStart int // byte offset of ch
+	offset  int // byte offset of the next character
+	isEOL   bool
+
+	// tokenStart and tokenPos are the start of the token being lexed, for
+	// recovering from an error in it.
+	tokenStart int
+	tokenPos   Position
+
+	// pendingEOL is the line break an invalid token ended at, returned next.
+	pendingEOL *Token
+
+	// buf is reused for literals which differ from the source, i.e. have
+	// escape sequences.
+	buf []byte
+
+	// tokens is reused by AllTokens after Reset
+	tokens []Token
+
+	interner *Interner
+
+	// Limits are checked for each token as it is read, zero values are not
+	// limited.
+	Limits Limits
+
+	// truncated is set when the lexer stopped at MaxTokens, before the end
+	// of the input.
+	truncated bool
+
+	// LineMap is the '#line' directives lexed so far
+	LineMap LineMap
+
+	// Separators are the '---' lines lexed so far
+	Separators []Position
 
 	Errors errpos.Errors
 }
 
 func NewLexer(data string) *Lexer {
-	return &Lexer{
-		data:   []rune(data),
-		line:   0,
-		column: -1,
+	l := &Lexer{
+		interner: NewInterner(),
 	}
+	l.Reset(data)
+	return l
+}
+
+// Reset prepares the lexer for new input, keeping the buffers and interned
+// identifiers. Tokens returned by AllTokens before the reset must no longer be
+// used.
+func (l *Lexer) Reset(data string) {
+	l.src = data
+	l.line = 0
+	l.column = -1
+	l.ch = 0
+	l.chStart = 0
+	l.offset = 0
+	if strings.HasPrefix(data, errpos.ByteOrderMark) {
+		// not a character of the first line
+		l.offset = len(errpos.ByteOrderMark)
+	}
+	l.isEOL = false
+	l.pendingEOL = nil
+	l.tokens = l.tokens[:0]
+	l.LineMap = nil
+	l.Separators = nil
+	l.Errors = nil
+}
+
+// Interner returns the interner used for identifier literals, so that
+// identifiers can be compared and looked up as symbols.
+func (l *Lexer) Interner() *Interner {
+	return l.interner
 }
 
 const lexerEofChr = -1
@@ -39,12 +101,25 @@ func (l *Lexer) next() {
 		l.column++
 	}
 
-	if l.offset >= len(l.data) {
+	l.chStart = l.offset
+	if l.offset >= len(l.src) {
 		l.ch = lexerEofChr
 		return
 	}
-	r := rune(l.data[l.offset])
-	l.offset++
+
+	r, size := rune(l.src[l.offset]), 1
+	if r >= utf8.RuneSelf {
+		r, size = utf8.DecodeRuneInString(l.src[l.offset:])
+	}
+	l.offset += size
+
+	if r == '\r' {
+		// "\r\n" and a lone "\r" are line breaks, read as one '\n'
+		if l.offset < len(l.src) && l.src[l.offset] == '\n' {
+			l.offset++
+		}
+		r = '\n'
+	}
 
 	if r == '\n' {
 		// the EOL position is the end of this line, the next character will
@@ -63,16 +138,72 @@ func (l *Lexer) getPosition() Position {
 }
 
 func (l *Lexer) peek() rune {
-	if l.offset >= len(l.data) {
+	if l.offset >= len(l.src) {
 		return lexerEofChr
 	}
-	return rune(l.data[l.offset])
+	r := rune(l.src[l.offset])
+	if r == '\r' {
+		return '\n'
+	}
+	if r >= utf8.RuneSelf {
+		r, _ = utf8.DecodeRuneInString(l.src[l.offset:])
+	}
+	return r
+}
+
+// letterAfterDash reports whether the rune after the '-' at the next offset is
+// a letter.
+func (l *Lexer) letterAfterDash() bool {
+	if l.offset+1 >= len(l.src) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.offset+1:])
+	return isLetter(r)
+}
+
+// signedNumber reports whether the '-' at the current position is the sign of
+// a number literal, which is a digit after it and no word run up against it,
+// so a-1 is still an error rather than a followed by -1.
+func (l *Lexer) signedNumber() bool {
+	if !isDigit(l.peek()) {
+		return false
+	}
+	if l.chStart == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(l.src[:l.chStart])
+	return !isLetter(r) && !isDigit(r) && r != '_'
+}
+
+func isSpace(r rune) bool {
+	switch r {
+	case ' ', '\t', '\r', '\n', '\v', '\f':
+		return true
+	}
+	if r < utf8.RuneSelf {
+		return false
+	}
+	return unicode.IsSpace(r)
+}
+
+func isLetter(r rune) bool {
+	if r < utf8.RuneSelf {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+	}
+	return unicode.IsLetter(r)
+}
+
+func isDigit(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= '0' && r <= '9'
+	}
+	return unicode.IsDigit(r)
 }
 
 func (l *Lexer) skipWhitespace() {
 	for {
 		v := l.peek()
-		if !unicode.IsSpace(v) {
+		if !isSpace(v) {
 			break
 		}
 		if v == '\n' {
@@ -82,13 +213,14 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
+// current returns the source of the current character, without allocating.
+func (l *Lexer) current() string {
+	return l.src[l.chStart:l.offset]
+}
+
 func (l *Lexer) tokenOf(ty TokenType) Token {
-	lit := string(l.ch)
-	if l.ch == lexerEofChr {
-		lit = ""
-	}
 	return Token{
-		Lit:   lit,
+		Lit:   l.current(),
 		Type:  ty,
 		Start: l.getPosition(),
 		End:   l.getPosition(),
@@ -96,7 +228,15 @@ func (l *Lexer) tokenOf(ty TokenType) Token {
 }
 
 func (l *Lexer) AllTokens(failFast bool) ([]Token, bool, error) {
-	var tokens []Token
+	tokens := l.tokens[:0]
+	if cap(tokens) == 0 {
+		// roughly one token per 4 bytes of typical source
+		size := len(l.src)/4 + 1
+		if max := l.Limits.MaxTokens; max > 0 && size > max+1 {
+			size = max + 1
+		}
+		tokens = make([]Token, 0, size)
+	}
 	for {
 		tok, err := l.NextToken()
 		if err != nil {
@@ -115,16 +255,27 @@ func (l *Lexer) AllTokens(failFast bool) ([]Token, bool, error) {
 			break
 		}
 		tokens = append(tokens, tok)
+		if max := l.Limits.MaxTokens; max > 0 && len(tokens) > max {
+			l.Errors = append(l.Errors, limitErr(tok, "token count exceeds limit of %d", max).(*errpos.Err))
+			l.truncated = true
+			return nil, false, nil
+		}
 	}
+	l.tokens = tokens
 	if len(l.Errors) > 0 {
-		return nil, false, nil
+		// Without failFast the tokens go on past each error, see NextToken
+		return tokens, false, nil
 	}
 	return tokens, true, nil
 }
 
-func (l *Lexer) errf(format string, args ...interface{}) error {
-	current := l.getPosition()
+func (l *Lexer) errf(code errpos.Code, format string, args ...interface{}) error {
+	return l.errAt(l.getPosition(), code, format, args...)
+}
+
+func (l *Lexer) errAt(current Position, code errpos.Code, format string, args ...interface{}) error {
 	return &errpos.Err{
+		Code: code,
 		Pos: &errpos.Position{
 			Start: errpos.Point{
 				Line:   current.Line,
@@ -139,12 +290,33 @@ func (l *Lexer) errf(format string, args ...interface{}) error {
 	}
 }
 func (l *Lexer) unexpectedEOF() error {
-	return l.errf("unexpected EOF")
+	return l.errf(errpos.CodeUnexpectedEOF, "unexpected EOF")
 }
 
 // NextToken scans the input for the next token. It returns the position of the token,
 // the token's type, and the literal value.
+//
+// Source which is not a token, such as an unterminated string or a bad
+// character, is returned with the error as an INVALID token marked Recovered,
+// which runs to the next line break or closing brace, so that lexing can go
+// on from there.
 func (l *Lexer) NextToken() (Token, error) {
+	if l.pendingEOL != nil {
+		tok := *l.pendingEOL
+		l.pendingEOL = nil
+		return tok, nil
+	}
+	tok, err := l.nextToken()
+	if err != nil {
+		return l.resync(), err
+	}
+	if err := l.Limits.checkToken(tok); err != nil {
+		return tok, err
+	}
+	return tok, nil
+}
+
+func (l *Lexer) nextToken() (Token, error) {
 	// keep looping until we return a token
 	for {
 		l.next()
@@ -152,11 +324,26 @@ func (l *Lexer) NextToken() (Token, error) {
 			return l.tokenOf(EOF), nil
 		}
 
-		if op, ok := operators[l.ch]; ok {
-			return l.tokenOf(op), nil
+		if l.ch == '.' && strings.HasPrefix(l.src[l.offset:], "..") {
+			startPos := l.getPosition()
+			l.next()
+			l.next()
+			return Token{
+				Type:  ELLIPSIS,
+				Start: startPos,
+				End:   l.getPosition(),
+				Lit:   "...",
+			}, nil
+		}
+
+		if l.ch < utf8.RuneSelf {
+			if op := operators[l.ch]; op != INVALID {
+				return l.tokenOf(op), nil
+			}
 		}
 
 		startPos := l.getPosition()
+		l.tokenStart, l.tokenPos = l.chStart, startPos
 		switch l.ch {
 		case '/':
 			opener := l.peek()
@@ -172,7 +359,10 @@ func (l *Lexer) NextToken() (Token, error) {
 				}, nil
 
 			case '*':
-				lit = l.lexBlockComment()
+				lit, err := l.lexBlockComment()
+				if err != nil {
+					return Token{}, err
+				}
 				return Token{
 					Type:  BLOCK_COMMENT,
 					Start: startPos,
@@ -204,6 +394,28 @@ func (l *Lexer) NextToken() (Token, error) {
 				Lit:   lit,
 			}, nil
 
+		case '`':
+			lit, err := l.lexRawString()
+			if err != nil {
+				return Token{}, err
+			}
+			return Token{
+				Type:  STRING,
+				Raw:   true,
+				Start: startPos,
+				End:   l.getPosition(),
+				Lit:   lit,
+			}, nil
+
+		case '#':
+			return l.lexLineDirective()
+
+		case '-':
+			if l.signedNumber() {
+				return l.lexNumber()
+			}
+			return l.lexSeparator()
+
 		case '|':
 			lit := l.lexDescriptionLine()
 			return Token{
@@ -217,11 +429,11 @@ func (l *Lexer) NextToken() (Token, error) {
 			return l.tokenOf(EOL), nil
 
 		default:
-			if unicode.IsSpace(l.ch) {
+			if isSpace(l.ch) {
 				continue
-			} else if unicode.IsDigit(l.ch) {
+			} else if isDigit(l.ch) {
 				return l.lexNumber()
-			} else if unicode.IsLetter(l.ch) {
+			} else if isLetter(l.ch) {
 				lit := l.lexIdent()
 				if keyword, ok := asKeyword(lit); ok {
 					return Token{
@@ -246,65 +458,104 @@ func (l *Lexer) NextToken() (Token, error) {
 					Lit:   lit,
 				}, nil
 			} else {
-				return Token{}, l.errf("unexpected character: %c", l.ch)
+				return Token{}, l.errf(errpos.CodeUnexpectedChar, "unexpected character: %c", l.ch)
 			}
 		}
 	}
 }
 
-// lexInt scans the input until the end of an integer and then returns the
-// literal.
+// resync skips the rest of the token which failed, up to the next line break
+// or closing brace, returning the skipped source as a Recovered token. An
+// error at a line break has consumed it, its EOL token is returned next.
+func (l *Lexer) resync() Token {
+	end := l.offset
+	if l.ch == '\n' || l.ch == lexerEofChr {
+		end = l.chStart
+		if l.ch == '\n' {
+			eol := l.tokenOf(EOL)
+			l.pendingEOL = &eol
+		}
+	} else {
+		for {
+			next := l.peek()
+			if next == lexerEofChr || next == '\n' || next == '}' {
+				break
+			}
+			l.next()
+		}
+		end = l.offset
+	}
+	return Token{
+		Type:      INVALID,
+		Recovered: true,
+		Start:     l.tokenPos,
+		End:       l.getPosition(),
+		Lit:       l.src[l.tokenStart:max(end, l.tokenStart)],
+	}
+}
+
+// lexNumber scans the input until the end of a number and then returns the
+// token.
 func (l *Lexer) lexNumber() (Token, error) {
 	tt := Token{
 		Type:  INT,
 		Start: l.getPosition(),
 		End:   l.getPosition(),
-		Lit:   string(l.ch),
 	}
+	start := l.chStart
 	var seenDot bool
 	for {
 		next := l.peek()
-		if unicode.IsDigit(next) {
+		if isDigit(next) {
 			l.next()
-			tt.Lit = tt.Lit + string(l.ch)
 		} else if next == '.' {
 			if seenDot {
-				return tt, l.errf("unexpected second dot in number literal")
+				tt.Lit = l.src[start:l.offset]
+				return tt, l.errf(errpos.CodeInvalidNumber, "unexpected second dot in number literal")
 			}
 			l.next()
 			seenDot = true
 			tt.Type = DECIMAL
-			tt.Lit = tt.Lit + string('.')
-
 		} else {
 			// scanned something not in the integer
 			tt.End = l.getPosition()
+			tt.Lit = l.src[start:l.offset]
 			return tt, nil
 		}
 	}
 }
 
 // lexIdent scans the input until the end of an identifier and then returns the
-// literal.
+// interned literal. The only '-' an identifier takes is the one of a negated
+// flag, as in no-cache, which a letter must follow, so a-b and no-1 are not
+// identifiers.
 func (l *Lexer) lexIdent() string {
-	var lit = string(l.ch)
+	start := l.chStart
 	for {
 		next := l.peek()
-		if unicode.IsLetter(next) || unicode.IsDigit(next) || next == '_' {
+		negated := next == '-' && l.src[start:l.offset] == "no" && l.letterAfterDash()
+		if isLetter(next) || isDigit(next) || next == '_' || negated {
 			l.next()
-			lit = lit + string(l.ch)
 		} else {
-			return lit
+			return l.interner.Intern(l.src[start:l.offset])
 		}
 	}
 }
 
 // lexString scans the input until the end of a string and then returns the
-// literal.
+// literal. Strings without escapes are slices of the source.
 func (l *Lexer) lexString() (string, error) {
-	var lit string
 	quote := l.ch
+	start := l.offset
+	escaped := false
 	for {
+		length := l.chStart - start
+		if escaped {
+			length = len(l.buf)
+		}
+		if err := l.checkLength(length, true); err != nil {
+			return "", err
+		}
 		l.next()
 
 		if l.ch == lexerEofChr {
@@ -312,20 +563,29 @@ func (l *Lexer) lexString() (string, error) {
 		}
 		if l.ch == quote {
 			// at the end of the string
-			return lit, nil
+			if !escaped {
+				return l.src[start:l.chStart], nil
+			}
+			return string(l.buf), nil
 		}
 		if l.ch == '\n' {
-			return "", l.errf("unexpected EOL in string, did you mean to escape it? ('\\n')")
+			return "", l.errf(errpos.CodeUnexpectedEOL, "unexpected EOL in string, did you mean to escape it? ('\\n')")
 		}
 
 		if l.ch == '\\' {
+			if !escaped {
+				l.buf = append(l.buf[:0], l.src[start:l.chStart]...)
+				escaped = true
+			}
 			if err := l.lexEscape(quote); err != nil {
 				return "", err
 			}
-			// continue, having consumed the escape sequence, the next character
-			// is just 'normal'
+			// lexEscape consumed the sequence and wrote its value
+			continue
+		}
+		if escaped {
+			l.buf = append(l.buf, l.current()...)
 		}
-		lit = lit + string(l.ch)
 	}
 }
 
@@ -335,83 +595,237 @@ func (l *Lexer) lexString() (string, error) {
 // Actual newline characters are invalid, use the \n notation. because it's a
 // regex.
 func (l *Lexer) lexRegex() (string, error) {
-	var lit string
+	start := l.offset
+	escaped := false
 	for {
+		length := l.chStart - start
+		if escaped {
+			length = len(l.buf)
+		}
+		if err := l.checkLength(length, true); err != nil {
+			return "", err
+		}
 		l.next()
 
 		if l.ch == lexerEofChr {
 			return "", l.unexpectedEOF()
 		}
 		if l.ch == '\n' {
-			return "", l.errf("unexpected EOL in regex, did you mean to escape it? ('\\n')")
+			return "", l.errf(errpos.CodeUnexpectedEOL, "unexpected EOL in regex, did you mean to escape it? ('\\n')")
 		}
 
 		// a // becomes /
 		if l.ch == '/' {
 			if l.peek() == '/' {
+				if !escaped {
+					l.buf = append(l.buf[:0], l.src[start:l.chStart]...)
+					escaped = true
+				}
 				l.next()
-				lit = lit + "/"
+				l.buf = append(l.buf, '/')
 				continue
 			}
-			return lit, nil
+			if !escaped {
+				return l.src[start:l.chStart], nil
+			}
+			return string(l.buf), nil
+		}
+		if escaped {
+			l.buf = append(l.buf, l.current()...)
 		}
-		lit = lit + string(l.ch)
 	}
 }
 
-// lexEscape scans the input for an escape sequence and returns an error if the
-// escape sequence is invalid.
+// simpleEscapes are the single character escapes, as in Go, so that strings
+// quoted by strconv.Quote are valid.
+var simpleEscapes = map[rune]rune{
+	'a': '\a',
+	'b': '\b',
+	'f': '\f',
+	'n': '\n',
+	'r': '\r',
+	't': '\t',
+	'v': '\v',
+}
+
+// lexEscape consumes the escape sequence after the backslash at l.ch, and
+// writes its value to l.buf. An escaped line break is kept in the string.
 func (l *Lexer) lexEscape(quote rune) error {
-	switch l.peek() {
+	start := l.getPosition()
+	next := l.peek()
+	switch next {
 	case '\\', '\n', quote:
 		l.next()
+		l.buf = utf8.AppendRune(l.buf, next)
+		return nil
+	case 'x':
+		return l.lexHexEscape(start, 2)
+	case 'u':
+		return l.lexHexEscape(start, 4)
+	case 'U':
+		return l.lexHexEscape(start, 8)
+	}
+	if r, ok := simpleEscapes[next]; ok {
+		l.next()
+		l.buf = utf8.AppendRune(l.buf, r)
 		return nil
 	}
-	err := l.errf("invalid escape, did you mean '\\\\'?")
-	return err
+	return l.errf(errpos.CodeInvalidEscape, "invalid escape, did you mean '\\\\'?")
+}
+
+// lexHexEscape reads the letter and digits of a \x, \u or \U escape. Errors
+// in the digits are reported at the digit, an invalid code point at the
+// backslash.
+func (l *Lexer) lexHexEscape(start Position, digits int) error {
+	l.next() // the x, u or U
+	letter := l.ch
+	var value rune
+	for i := 0; i < digits; i++ {
+		l.next()
+		digit, ok := hexValue(l.ch)
+		if !ok {
+			return l.errf(errpos.CodeInvalidEscape, "invalid escape, \\%c takes %d hex digits", letter, digits)
+		}
+		value = value<<4 | digit
+	}
+	if letter == 'x' && value >= utf8.RuneSelf {
+		return l.errAt(start, errpos.CodeInvalidEscape, "invalid escape, \\x is limited to ASCII, use \\u%04X", value)
+	}
+	if !utf8.ValidRune(value) {
+		return l.errAt(start, errpos.CodeInvalidEscape, "invalid escape, %X is not a unicode code point", value)
+	}
+	l.buf = utf8.AppendRune(l.buf, value)
+	return nil
+}
+
+func hexValue(r rune) (rune, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return r - '0', true
+	case r >= 'a' && r <= 'f':
+		return r - 'a' + 10, true
+	case r >= 'A' && r <= 'F':
+		return r - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// lexRawString scans a `raw string`, which has no escapes, up to the closing
+// backtick.
+func (l *Lexer) lexRawString() (string, error) {
+	start := l.offset
+	for {
+		if err := l.checkLength(l.chStart-start, true); err != nil {
+			return "", err
+		}
+		l.next()
+		switch l.ch {
+		case lexerEofChr:
+			return "", l.unexpectedEOF()
+		case '\n':
+			return "", l.errf(errpos.CodeUnexpectedEOL, "unexpected EOL in raw string")
+		case '`':
+			return l.src[start:l.chStart], nil
+		}
+	}
 }
 
 func (l *Lexer) lexDescriptionLine() string {
-	var lit string
 	l.skipWhitespace()
+	start := l.offset
 	for {
 		next := l.peek()
-		if next == lexerEofChr {
-			return lit
-		}
-		if next == '\n' {
-			return lit
+		if next == lexerEofChr || next == '\n' {
+			return l.src[start:l.offset]
 		}
 		l.next()
-		lit = lit + string(l.ch)
 	}
 }
 
-func (l *Lexer) lexBlockComment() string {
+func (l *Lexer) lexBlockComment() (string, error) {
 	l.next() // consume the first *
-	commentText := ""
+	start := l.offset
 	for {
+		if err := l.checkLength(l.chStart-start, false); err != nil {
+			return "", err
+		}
 		l.next()
 		if l.ch == '*' && l.peek() == '/' {
+			end := l.chStart
 			l.next()
-			return commentText
+			return l.src[start:end], nil
 		}
 		if l.ch == lexerEofChr {
-			return commentText
+			return l.src[start:], nil
 		}
-		commentText = commentText + string(l.ch)
 	}
 }
 
+// lexLineDirective scans a '#line file:n' directive, which must start a line,
+// as a comment.
+func (l *Lexer) lexLineDirective() (Token, error) {
+	startPos := l.getPosition()
+	if startPos.Column != 0 || !strings.HasPrefix(l.src[l.offset:], lineDirectivePrefix) {
+		return Token{}, l.errf(errpos.CodeUnexpectedChar, "unexpected character: %c", l.ch)
+	}
+	start := l.offset
+	for {
+		next := l.peek()
+		if next == lexerEofChr || next == '\n' {
+			break
+		}
+		l.next()
+	}
+	lit := l.src[start:l.offset]
+
+	directive, err := parseLineDirective(startPos.Line, lit)
+	if err != nil {
+		return Token{}, l.errAt(startPos, errpos.CodeUnexpectedToken, "%s", err)
+	}
+	l.LineMap = append(l.LineMap, directive)
+	return Token{
+		Type:  COMMENT,
+		Hash:  true,
+		Start: startPos,
+		End:   l.getPosition(),
+		Lit:   lit,
+	}, nil
+}
+
+// separatorLine separates the documents of a file with several.
+const separatorLine = "---"
+
+// lexSeparator scans a '---' line between the documents of a file, which
+// must be alone on its line, as a comment.
+func (l *Lexer) lexSeparator() (Token, error) {
+	startPos := l.getPosition()
+	rest := l.src[l.chStart:]
+	if end := strings.IndexAny(rest, "\r\n"); end >= 0 {
+		rest = rest[:end]
+	}
+	if startPos.Column != 0 || strings.TrimRight(rest, " \t") != separatorLine {
+		return Token{}, l.errf(errpos.CodeUnexpectedChar, "unexpected character: %c", l.ch)
+	}
+	l.next()
+	l.next()
+	l.Separators = append(l.Separators, startPos)
+	return Token{
+		Type:      COMMENT,
+		Separator: true,
+		Start:     startPos,
+		End:       l.getPosition(),
+		Lit:       separatorLine,
+	}, nil
+}
+
 func (l *Lexer) lexLineComment() string {
 	l.next() // consume the second /
-	var lit string
+	start := l.offset
 	for {
 		next := l.peek()
 		if next == lexerEofChr || next == '\n' {
-			return lit
+			return l.src[start:l.offset]
 		}
 		l.next()
-		lit = lit + string(l.ch)
 	}
 }
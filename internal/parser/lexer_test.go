@@ -28,6 +28,14 @@ func tTokString(lit string) Token {
 	}
 }
 
+func tTokRawString(lit string) Token {
+	return Token{
+		Type: STRING,
+		Lit:  lit,
+		Raw:  true,
+	}
+}
+
 func tTokRegex(lit string) Token {
 	return Token{
 		Type: REGEX,
@@ -107,6 +115,10 @@ var (
 		Type: COMMA,
 		Lit:  ",",
 	}
+	tTokEllipsis = Token{
+		Type: ELLIPSIS,
+		Lit:  "...",
+	}
 )
 
 type positionAsserter struct {
@@ -222,6 +234,31 @@ func TestSimple(t *testing.T) {
 			tTokInt("123"),
 			tTokEOF,
 		},
+	}, {
+		name: "hyphenated identifier",
+		input: []string{
+			`no-cache`,
+		},
+		expected: []Token{
+			tTokIdent("no-cache").tStart(1, 1).tEnd(1, 8),
+			tTokEOF,
+		},
+	}, {
+		name: "hyphen before a digit",
+		input: []string{
+			`a-1 = 1`,
+		},
+		expectError: tPos(1, 2),
+	}, {
+		name: "negative numbers",
+		input: []string{
+			`vv = -12`,
+			`vv = [-1.5, -2]`,
+		},
+		expected: []Token{
+			tTokIdent("vv"), tTokAssign, tTokInt("-12"), tTokEOL,
+			tTokIdent("vv"), tTokAssign, tTokLBracket, tTokDecimal("-1.5"), tTokComma, tTokInt("-2"), tTokRBracket, tTokEOF,
+		},
 	}, {
 		name: "literal types",
 		input: []string{
@@ -252,6 +289,17 @@ func TestSimple(t *testing.T) {
 			tTokIdent("vv"), tTokAssign, tTokLBracket, tTokInt("1"), tTokComma, tTokString("2"), tTokComma, tTokDecimal("3.4"), tTokComma, tTokBool("true"), tTokComma, tTokBool("false"), tTokRBracket, tTokEOL,
 			tTokIdent("vv"), tTokAssign, tTokLBracket, tTokString("a"), tTokComma, tTokLBracket, tTokString("b"), tTokComma, tTokString("c"), tTokRBracket, tTokComma, tTokString("d"), tTokRBracket, tTokEOF,
 		},
+	}, {
+		name: "array splat",
+		input: []string{
+			`vv = [`,
+			`  a.b..., "c"]`,
+		},
+		expected: []Token{
+			tTokIdent("vv"), tTokAssign, tTokLBracket, tTokEOL,
+			tTokIdent("a"), tTokDot, tTokIdent("b"), tTokEllipsis.tStart(2, 6).tEnd(2, 8),
+			tTokComma, tTokString("c"), tTokRBracket, tTokEOF,
+		},
 	}, {
 		name: "type declaration",
 		input: []string{
@@ -303,6 +351,59 @@ func TestSimple(t *testing.T) {
 			`vv = "value \ with invalid escape"`,
 		},
 		expectError: &Position{Line: 0, Column: 12},
+	}, {
+		name: "string escapes",
+		input: []string{
+			`vv = "tab\there\nline \u00e9 \U0001F600 \x41"`,
+		},
+		expected: []Token{
+			tTokIdent("vv"),
+			tTokAssign,
+			tTokString("tab\there\nline é 😀 A"),
+			tTokEOF,
+		},
+	}, {
+		name: "string with bad hex digit",
+		input: []string{
+			`vv = "\u12G4"`,
+		},
+		expectError: tPos(1, 11),
+	}, {
+		name: "string with short unicode escape",
+		input: []string{
+			`vv = "\u12"`,
+		},
+		expectError: tPos(1, 11),
+	}, {
+		name: "string with surrogate escape",
+		input: []string{
+			`vv = "ok \uD800"`,
+		},
+		expectError: tPos(1, 10),
+	}, {
+		name: "string with non ascii byte escape",
+		input: []string{
+			`vv = "\xFF"`,
+		},
+		expectError: tPos(1, 7),
+	}, {
+		name: "raw string",
+		input: []string{
+			"vv = `C:\\dir\\n \\d+`",
+		},
+		expected: []Token{
+			tTokIdent("vv"),
+			tTokAssign,
+			tTokRawString(`C:\dir\n \d+`),
+			tTokEOF,
+		},
+	}, {
+		name: "Newline in raw string is bad",
+		input: []string{
+			"vv = `value",
+			"with newline`",
+		},
+		expectError: tPos(1, 12),
 	}, {
 		name: "Newline in string is bad",
 		input: []string{
@@ -509,7 +610,7 @@ func assertTokensEqual(t *testing.T, tokens, expected []Token) {
 			continue
 		}
 		want := expected[idx]
-		if tok.Type != expected[idx].Type || tok.Lit != want.Lit {
+		if tok.Type != expected[idx].Type || tok.Lit != want.Lit || tok.Raw != want.Raw {
 			t.Errorf("BAD % 3d: %s want %s", idx, tok, want)
 			continue
 		}
@@ -601,3 +702,78 @@ With Lines
 	})
 
 }
+
+func TestLexerReset(t *testing.T) {
+	lex := NewLexer(`foo = "a\"b"`)
+	first, ok, err := lex.AllTokens(true)
+	if err != nil || !ok {
+		t.Fatalf("unexpected error %v", lex.Errors)
+	}
+	if first[2].Lit != `a"b` {
+		t.Errorf("escaped string: got %q", first[2].Lit)
+	}
+
+	lex.Reset("bar = /x//y/\nfoo = 1")
+	tokens, ok, err := lex.AllTokens(true)
+	if err != nil || !ok {
+		t.Fatalf("unexpected error %v", lex.Errors)
+	}
+	want := []string{"bar", "=", "x/y", "\n", "foo", "=", "1"}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for idx, tok := range tokens {
+		if tok.Lit != want[idx] {
+			t.Errorf("token %d: got %q, want %q", idx, tok.Lit, want[idx])
+		}
+	}
+	if tokens[4].Start.Line != 1 {
+		t.Errorf("position not reset, got line %d", tokens[4].Start.Line)
+	}
+
+	in := lex.Interner()
+	if in.Len() != 2 {
+		t.Errorf("expected foo and bar interned, got %d", in.Len())
+	}
+	foo, ok := in.Lookup("foo")
+	if !ok || in.String(foo) != "foo" || in.Symbol("foo") != foo {
+		t.Errorf("foo symbol not stable")
+	}
+}
+
+func TestLexerRecovery(t *testing.T) {
+	lex := NewLexer("a = \"open\nb { c = 1 @ x }\nd = 2")
+	tokens, ok, err := lex.AllTokens(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected errors")
+	}
+	if len(lex.Errors) != 2 {
+		t.Fatalf("got %d errors, want 2", len(lex.Errors))
+	}
+
+	type tok struct {
+		ty        TokenType
+		lit       string
+		recovered bool
+	}
+	want := []tok{
+		{IDENT, "a", false}, {ASSIGN, "=", false}, {INVALID, `"open`, true}, {EOL, "\n", false},
+		{IDENT, "b", false}, {LBRACE, "{", false}, {IDENT, "c", false}, {ASSIGN, "=", false}, {INT, "1", false},
+		{INVALID, "@ x ", true}, {RBRACE, "}", false}, {EOL, "\n", false},
+		{IDENT, "d", false}, {ASSIGN, "=", false}, {INT, "2", false},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %v", len(tokens), len(want), tokens)
+	}
+	for idx, got := range tokens {
+		if got.Type != want[idx].ty || got.Lit != want[idx].lit || got.Recovered != want[idx].recovered {
+			t.Errorf("token %d: got %s %q %v, want %s %q %v", idx, got.Type, got.Lit, got.Recovered, want[idx].ty, want[idx].lit, want[idx].recovered)
+		}
+	}
+	if tokens[12].Start.Line != 2 {
+		t.Errorf("expected d on line 3, got %d", tokens[12].Start.Line+1)
+	}
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// Limits caps the size of what a file can make the parser allocate, for
+// services which parse untrusted input. A zero value is not limited.
+type Limits struct {
+	MaxTokens       int // tokens in the file, the lexer stops reading past the limit
+	MaxTokenLength  int // bytes in any single token
+	MaxStringLength int // bytes in a string or regex literal, after escapes
+	MaxArrayLength  int // values in a single array literal
+	MaxNodes        int // statements, block headers and array values in the file
+	MaxRecursion    int // blocks of one schema nested in each other, checked as the file is walked
+}
+
+func limitExceeded(tok Token, format string, args ...interface{}) *unexpectedTokenError {
+	return &unexpectedTokenError{
+		tok:     tok,
+		code:    errpos.CodeLimitExceeded,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// checkToken returns an error when the token is over the length limits.
+func (lim Limits) checkToken(tok Token) error {
+	if lim.MaxTokenLength > 0 && len(tok.Lit) > lim.MaxTokenLength {
+		return limitErr(tok, "token length %d exceeds limit of %d", len(tok.Lit), lim.MaxTokenLength)
+	}
+	if lim.MaxStringLength > 0 && (tok.Type == STRING || tok.Type == REGEX) && len(tok.Lit) > lim.MaxStringLength {
+		return limitErr(tok, "string length %d exceeds limit of %d", len(tok.Lit), lim.MaxStringLength)
+	}
+	return nil
+}
+
+// checkLength returns an error as soon as the literal of the token being
+// read, length bytes so far, is over the length limits, so that the lexer
+// stops reading a long token rather than checking it at its end.
+func (l *Lexer) checkLength(length int, isString bool) error {
+	lim := l.Limits
+	tok := Token{Start: l.tokenPos, End: l.getPosition()}
+	if lim.MaxTokenLength > 0 && length > lim.MaxTokenLength {
+		return limitErr(tok, "token length exceeds limit of %d", lim.MaxTokenLength)
+	}
+	if lim.MaxStringLength > 0 && isString && length > lim.MaxStringLength {
+		return limitErr(tok, "string length exceeds limit of %d", lim.MaxStringLength)
+	}
+	return nil
+}
+
+func limitErr(tok Token, format string, args ...interface{}) error {
+	return &errpos.Err{
+		Code: errpos.CodeLimitExceeded,
+		Pos: &errpos.Position{
+			Start: tok.Start,
+			End:   tok.End,
+		},
+		Err: fmt.Errorf(format, args...),
+	}
+}
+
+// token is a placeholder token covering the node, for errors about the node
+// as a whole.
+func (sn SourceNode) token() Token {
+	return Token{
+		Type:  AnyLiteral,
+		Start: sn.Start,
+		End:   sn.End,
+	}
+}
@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+func TestLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		input  string
+		limits Limits
+		line   int
+		col    int
+	}{{
+		name:   "token length",
+		input:  "a = 1\nabcdef = 1",
+		limits: Limits{MaxTokenLength: 5},
+		line:   2, col: 1,
+	}, {
+		name:   "string length",
+		input:  `a = "abcdef"`,
+		limits: Limits{MaxStringLength: 5},
+		line:   1, col: 5,
+	}, {
+		name:   "array length",
+		input:  `a = [1, 2, 3]`,
+		limits: Limits{MaxArrayLength: 2},
+		line:   1, col: 12,
+	}, {
+		name:   "tokens",
+		input:  strings.Repeat("a = 1\n", 5),
+		limits: Limits{MaxTokens: 6},
+		line:   2, col: 5,
+	}, {
+		name:   "nodes",
+		input:  strings.Repeat("a = 1\n", 5),
+		limits: Limits{MaxNodes: 4},
+		line:   5, col: 1,
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ParseFile(tc.input, false); err != nil {
+				t.Fatalf("unexpected error without limits: %s", err)
+			}
+
+			_, err := ParseFileWithLimits(tc.input, false, tc.limits)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			errs, ok := errpos.AsErrorsWithSource(err)
+			if !ok {
+				t.Fatalf("expected positioned errors, got %T", err)
+			}
+			if len(errs.Errors) != 1 {
+				t.Fatalf("expected one error, got %s", errs.HumanString(0))
+			}
+			got := errs.Errors[0]
+			if got.Code != errpos.CodeLimitExceeded {
+				t.Errorf("expected limit code, got %s", got.Code)
+			}
+			errPos(tc.line, tc.col)(t, got)
+		})
+	}
+}
+
+func TestLimitsWhileReading(t *testing.T) {
+	// the string is never closed, the limit stops the lexer before it reads
+	// to the end of the input
+	input := `a = "` + strings.Repeat("x", 100)
+	_, err := ParseFileWithLimits(input, false, Limits{MaxStringLength: 5})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	errs, ok := errpos.AsErrorsWithSource(err)
+	if !ok {
+		t.Fatalf("expected positioned errors, got %T", err)
+	}
+	got := errs.Errors[0]
+	if got.Code != errpos.CodeLimitExceeded {
+		t.Errorf("expected limit code, got %s: %s", got.Code, errs.HumanString(0))
+	}
+	errPos(1, 5)(t, got)
+}
@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+const lineDirectivePrefix = "line "
+
+// LineDirective is a '#line file:n' comment in a generated file, which places
+// the lines after it in the source the file was generated from, with the next
+// line at line n of the file.
+type LineDirective struct {
+	Line       int // 0 based, the line of the directive
+	Filename   string
+	SourceLine int // 0 based, the line in Filename of the line after
+}
+
+// parseLineDirective reads the text of a '#line' comment after the #.
+func parseLineDirective(line int, text string) (LineDirective, error) {
+	target := strings.TrimSpace(strings.TrimPrefix(text, lineDirectivePrefix))
+	sep := strings.LastIndex(target, ":")
+	if sep < 1 {
+		return LineDirective{}, fmt.Errorf("line directive %q is not '#line file:n'", text)
+	}
+	number, err := strconv.Atoi(target[sep+1:])
+	if err != nil || number < 1 {
+		return LineDirective{}, fmt.Errorf("line directive %q has no line number", text)
+	}
+	return LineDirective{
+		Line:       line,
+		Filename:   target[:sep],
+		SourceLine: number - 1,
+	}, nil
+}
+
+// LineMap is the line directives of a file, in order.
+type LineMap []LineDirective
+
+// Map returns the position in the source of a position after a line
+// directive, for errpos.MapPositions. Positions before the first directive
+// aren't mapped.
+func (lm LineMap) Map(pos errpos.Position) (errpos.Position, bool) {
+	var directive *LineDirective
+	for idx := range lm {
+		if lm[idx].Line >= pos.Start.Line {
+			break
+		}
+		directive = &lm[idx]
+	}
+	if directive == nil {
+		return pos, false
+	}
+
+	offset := directive.SourceLine - directive.Line - 1
+	filename := directive.Filename
+	mapped := errpos.Position{
+		Filename: &filename,
+		Start: errpos.Point{
+			Line:   pos.Start.Line + offset,
+			Column: pos.Start.Column,
+		},
+		End: errpos.Point{
+			Line:   pos.End.Line + offset,
+			Column: pos.End.Column,
+		},
+	}
+	return mapped, true
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// OverrideEdit is an edit to the base file which sets the value of an
+// assignment in the override file.
+type OverrideEdit struct {
+	errpos.Edit
+
+	// Value is the value in the override file, which the edit's text ends
+	// with.
+	Value SourceNode
+
+	// Statement is the assignment in the override file.
+	Statement SourceNode
+}
+
+// OverrideEdits returns the edits which apply an override file to its base.
+// Assignments in the override set the attribute in the base as SetEdits does,
+// replacing the value or adding it to the block. Blocks in the override must
+// match a block of the base by type and tags, and the statements in them apply
+// to that block.
+//
+// Blocks which are not in the base, dotted assignments into them, appends and
+// other statements are returned as errors, positioned in the override file.
+func OverrideEdits(baseSource string, base *File, overrideSource string, override *File) ([]OverrideEdit, errpos.Errors) {
+	var edits []OverrideEdit
+	var errs errpos.Errors
+	overrideErr := func(node SourceNode, code errpos.Code, err error) {
+		pos := node.Position()
+		errs = append(errs, &errpos.Err{
+			Pos:  &pos,
+			Code: code,
+			Err:  err,
+		})
+	}
+
+	var walk func(prefix []string, body Body)
+	walk = func(prefix []string, body Body) {
+		for _, statement := range body.Statements {
+			switch st := statement.(type) {
+			case *Assignment:
+				if st.Append {
+					overrideErr(st.SourceNode, errpos.CodeUnexpectedToken, fmt.Errorf("override files replace values, they can't append with +="))
+					continue
+				}
+				path := append(slices.Clone(prefix), referencePath(st.Key)...)
+				if parent := path[:len(path)-1]; len(st.Key.Idents) > 1 && findAssignment(base.Body, path) == nil && !hasBlock(base.Body, parent) && !hasAssignmentUnder(base.Body, parent) {
+					overrideErr(st.Key.SourceNode, errpos.CodeOverrideBlock, fmt.Errorf("block %s is not in the base file, override files only modify existing blocks", strings.Join(parent, " ")))
+					continue
+				}
+				value := errpos.SourceText(overrideSource, st.Value.Start, st.Value.End)
+				setEdits, err := SetEdits(baseSource, base, path, value)
+				if err != nil {
+					overrideErr(st.SourceNode, errpos.CodeOverrideBlock, err)
+					continue
+				}
+				for _, edit := range setEdits {
+					edits = append(edits, OverrideEdit{
+						Edit:      edit,
+						Value:     st.Value.SourceNode,
+						Statement: st.SourceNode,
+					})
+				}
+
+			case *Block:
+				path := append(slices.Clone(prefix), blockPath(st)...)
+				if !hasBlock(base.Body, path) {
+					overrideErr(st.BlockHeader.SourceNode, errpos.CodeOverrideBlock, fmt.Errorf("block %s is not in the base file, override files only modify existing blocks", strings.Join(path, " ")))
+					continue
+				}
+				walk(path, st.Body)
+
+			case *Comment:
+
+			default:
+				overrideErr(statement.Source(), errpos.CodeUnexpectedToken, fmt.Errorf("override files only hold assignments and blocks"))
+			}
+		}
+	}
+	walk(nil, override.Body)
+
+	return edits, errs
+}
+
+// hasBlock reports whether the body has blocks, nested or not, which together
+// have exactly the path as their type and tags.
+func hasBlock(body Body, path []string) bool {
+	for _, statement := range body.Statements {
+		st, ok := statement.(*Block)
+		if !ok {
+			continue
+		}
+		blockPath := blockPath(st)
+		if len(blockPath) > len(path) || !pathEqual(blockPath, path[:len(blockPath)]) {
+			continue
+		}
+		if len(blockPath) == len(path) || hasBlock(st.Body, path[len(blockPath):]) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAssignmentUnder reports whether the body assigns an attribute below the
+// path, directly, as 'a.b = 1' is below 'a', or in blocks on the path.
+func hasAssignmentUnder(body Body, path []string) bool {
+	for _, statement := range body.Statements {
+		switch st := statement.(type) {
+		case *Assignment:
+			key := referencePath(st.Key)
+			if len(key) > len(path) && pathEqual(key[:len(path)], path) {
+				return true
+			}
+		case *Block:
+			blockPath := blockPath(st)
+			if len(path) > len(blockPath) && pathEqual(blockPath, path[:len(blockPath)]) && hasAssignmentUnder(st.Body, path[len(blockPath):]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -4,51 +4,121 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 )
 
 func ParseFile(input string, failFast bool) (*File, error) {
+	return ParseFileWithLimits(input, failFast, Limits{})
+}
+
+// ParseFileWithLimits parses as ParseFile, returning CodeLimitExceeded errors
+// for input over the limits.
+func ParseFileWithLimits(input string, failFast bool, limits Limits) (*File, error) {
 	l := NewLexer(input)
+	l.Limits = limits
 
+	lexStart := time.Now()
 	tokens, ok, err := l.AllTokens(failFast)
 	if err != nil {
 		return nil, fmt.Errorf("unexpected lexer error: %w", err)
 	}
-	if !ok {
-		return nil, errpos.AddSource(l.Errors, input)
+	if !ok && (failFast || l.truncated) {
+		return nil, errpos.MapPositions(errpos.AddSource(l.Errors, input), l.LineMap.Map)
 	}
 
-	tree, err := Walk(tokens, failFast)
-	if err != nil {
-		if err == HadErrors {
-			return tree, errpos.AddSource(tree.Errors, input)
+	parseStart := time.Now()
+	tree, err := walk(tokens, failFast, limits)
+	if tree != nil {
+		tree.LineMap = l.LineMap
+		tree.Separators = l.Separators
+		tree.Stats = Stats{
+			Tokens: len(tokens),
+			Lex:    parseStart.Sub(lexStart),
+			Parse:  time.Since(parseStart),
 		}
+	}
+	if err != nil && err != HadErrors {
 		return tree, fmt.Errorf("unexpected walk error: %w", err)
 	}
+	if len(l.Errors) > 0 {
+		// The walk went on past the tokens the lexer recovered from, their
+		// errors are the lexer's.
+		tree.Errors = mergeErrors(l.Errors, tree.Errors)
+		err = HadErrors
+	}
+	if err == HadErrors {
+		return tree, errpos.MapPositions(errpos.AddSource(tree.Errors, input), l.LineMap.Map)
+	}
 
 	return tree, nil
 }
 
+// mergeErrors merges the errors of the lexer and parser, which are each in
+// order, by position.
+func mergeErrors(lexed, walked errpos.Errors) errpos.Errors {
+	merged := make(errpos.Errors, 0, len(lexed)+len(walked))
+	for len(lexed) > 0 && len(walked) > 0 {
+		if errorLine(walked[0]) < errorLine(lexed[0]) {
+			merged = append(merged, walked[0])
+			walked = walked[1:]
+		} else {
+			merged = append(merged, lexed[0])
+			lexed = lexed[1:]
+		}
+	}
+	merged = append(merged, lexed...)
+	return append(merged, walked...)
+}
+
+func errorLine(err *errpos.Err) int {
+	if err.Pos == nil {
+		return 0
+	}
+	return err.Pos.Start.Line
+}
+
 type Walker struct {
 	tokens   []Token
 	offset   int
 	failFast bool
 
+	limits Limits
+	nodes  int
+
+	// edition gates syntax by the file's '!bcl' header
+	edition Edition
+
+	// reserved are the reserved words used as bare names
+	reserved []Ident
+
 	errors errpos.Errors
 }
 
 func (w *Walker) addError(err *unexpectedTokenError) {
+	if err.tok.Recovered {
+		// the lexer has reported the error in the token
+		return
+	}
 	w.errors = append(w.errors, &errpos.Err{
-		Pos: err.ErrorPosition(),
-		Err: errors.New(err.msg()),
+		Pos:     err.ErrorPosition(),
+		Code:    err.ErrorCode(),
+		Err:     errors.New(err.msg()),
+		Related: err.related,
 	})
 }
 
 func Walk(tokens []Token, failFast bool) (*File, error) {
+	return walk(tokens, failFast, Limits{})
+}
+
+func walk(tokens []Token, failFast bool, limits Limits) (*File, error) {
 	ww := &Walker{
 		tokens:   tokens,
 		failFast: failFast,
+		limits:   limits,
+		edition:  Edition1,
 	}
 	fragments, err := ww.walkFragments()
 	if err != nil {
@@ -57,22 +127,39 @@ func Walk(tokens []Token, failFast bool) (*File, error) {
 		}, err
 	}
 	if len(ww.errors) > 0 {
-		return &File{
-			Errors: ww.errors,
-		}, HadErrors
+		file := &File{}
+		if !failFast {
+			// The statements around the errors, when their blocks still
+			// balance, so tools can work with the rest of the file.
+			if partial, err := fragmentsToFile(fragments); err == nil {
+				file = partial
+				file.Edition = ww.edition
+				file.reserved = ww.reserved
+			}
+		}
+		file.Errors = ww.errors
+		return file, HadErrors
 	}
 
-	return fragmentsToFile(fragments)
+	file, err := fragmentsToFile(fragments)
+	if file != nil {
+		file.Edition = ww.edition
+		file.reserved = ww.reserved
+	}
+	return file, err
 }
 
 func fragmentsToFile(fragments []Fragment) (*File, error) {
 
 	type walkingBlock struct {
 		parent *walkingBlock
+		block  *Block
 		body   *Body
 	}
 
-	ff := &File{}
+	ff := &File{
+		Suppressions: buildSuppressions(fragments),
+	}
 
 	currentBlock := &walkingBlock{
 		parent: nil,
@@ -87,6 +174,13 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 	for _, stmt := range fragments {
 		switch s := stmt.(type) {
 		case BlockHeader:
+			if directive, ok := headerDirective(s); ok {
+				if err := ff.addHeader(directive, currentBlock.parent == nil, s.SourceNode); err != nil {
+					ff.Errors = append(ff.Errors, err)
+				}
+				continue
+			}
+
 			block := &Block{
 				BlockHeader: s,
 			}
@@ -98,6 +192,7 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 
 			newBlock := &walkingBlock{
 				parent: currentBlock,
+				block:  block,
 				body:   &block.Body,
 			}
 			currentBlock = newBlock
@@ -115,11 +210,13 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 			if currentBlock.parent == nil {
 				pos := s.SourceNode.Position()
 				ff.Errors = append(ff.Errors, &errpos.Err{
-					Pos: &pos,
-					Err: errors.New("unexpected close block"),
+					Pos:  &pos,
+					Code: errpos.CodeUnbalancedBlock,
+					Err:  errors.New("unexpected close block"),
 				})
 				continue
 			}
+			currentBlock.block.Close = &s
 			currentBlock = currentBlock.parent
 
 		default:
@@ -128,12 +225,7 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 	}
 
 	if currentBlock.parent != nil {
-		lastFragment := fragments[len(fragments)-1]
-		pos := lastFragment.Source().Position()
-		ff.Errors = append(ff.Errors, &errpos.Err{
-			Pos: &pos,
-			Err: errors.New("unclosed block at EOF"),
-		})
+		ff.Errors = append(ff.Errors, unclosedBlock(fragments))
 	}
 
 	if len(ff.Errors) > 0 {
@@ -143,6 +235,78 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 	return ff, nil
 }
 
+// unclosedBlock is the error at EOF for a file with a block left open. It
+// points back at the header of the block which the indentation says is
+// missing its close, and suggests the close after its last statement.
+func unclosedBlock(fragments []Fragment) *errpos.Err {
+	header, last := likelyUnclosed(fragments)
+	insertAfter := fragments[last].Source()
+	if insertAfter.Comment != nil {
+		insertAfter.End = insertAfter.Comment.End
+	}
+	insert := errpos.Point{Line: insertAfter.End.Line, Column: insertAfter.End.Column + 1}
+
+	headerPos := fragments[header].Source().Position()
+	eofPos := fragments[len(fragments)-1].Source().Position()
+	blockType := fragments[header].(BlockHeader).Type.String()
+	return &errpos.Err{
+		Pos:  &eofPos,
+		Code: errpos.CodeUnbalancedBlock,
+		Err:  fmt.Errorf("unclosed block at EOF, %s opened on line %d is missing its '}'", blockType, headerPos.Start.Line+1),
+		Related: []errpos.Related{{
+			Pos:     headerPos,
+			Message: fmt.Sprintf("%s opened here", blockType),
+		}},
+		Fixes: []errpos.Fix{{
+			Title: fmt.Sprintf("Close %s after line %d", blockType, insert.Line+1),
+			Edits: []errpos.Edit{{Start: insert, End: insert, NewText: "\n}"}},
+		}},
+	}
+}
+
+// likelyUnclosed returns the index of the header of the first block which a
+// later statement is indented no further than, without a close between, and
+// the index of the last fragment in the block. When the indentation is no
+// help it is the innermost block open at the end of the file.
+func likelyUnclosed(fragments []Fragment) (int, int) {
+	open := []int{}
+	for idx, fragment := range fragments {
+		start := fragment.Source().Start
+		if len(open) > 0 {
+			top := fragments[open[len(open)-1]].Source()
+			_, isClose := fragment.(CloseBlock)
+			outside := start.Column < top.Start.Column || (start.Column == top.Start.Column && !isClose)
+			if start.Line > top.End.Line && outside {
+				return open[len(open)-1], idx - 1
+			}
+		}
+		switch fragment := fragment.(type) {
+		case BlockHeader:
+			if fragment.Open {
+				open = append(open, idx)
+			}
+		case CloseBlock:
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return open[len(open)-1], len(fragments) - 1
+}
+
+// addNodes counts nodes against the MaxNodes limit.
+func (w *Walker) addNodes(n int, source SourceNode) *unexpectedTokenError {
+	w.nodes += n
+	if w.nodesExceeded() {
+		return limitExceeded(source.token(), "node count exceeds limit of %d", w.limits.MaxNodes)
+	}
+	return nil
+}
+
+func (w *Walker) nodesExceeded() bool {
+	return w.limits.MaxNodes > 0 && w.nodes > w.limits.MaxNodes
+}
+
 func (w *Walker) currentPos() Position {
 	if w.offset == 0 {
 		return Position{}
@@ -186,7 +350,18 @@ func (ww *Walker) walkFragments() ([]Fragment, error) {
 			break
 		}
 		fragment, err := ww.nextFragment()
+		if err == nil && fragment != nil {
+			err = ww.addNodes(1, fragment.Source())
+		}
+		if err == nil && fragment != nil {
+			err = ww.readEdition(fragment)
+		}
 		if err != nil {
+			if err.code == errpos.CodeLimitExceeded && ww.nodesExceeded() {
+				// No point in recovering, every following node is over.
+				ww.addError(err)
+				return fragments, HadErrors
+			}
 			if err := ww.recoverError(err); err != nil {
 				return fragments, err
 			}
@@ -208,12 +383,15 @@ func (ww *Walker) recoverError(err *unexpectedTokenError) error {
 
 	}
 
-	// Skip to the next EOL
+	// Skip to the next EOL, or to the closing brace the lexer stopped at
 	for {
 		if ww.nextType() == EOL || ww.nextType() == EOF {
 			ww.popToken()
 			break
 		}
+		if err.tok.Recovered && ww.nextType() == RBRACE {
+			break
+		}
 		ww.popToken()
 	}
 	return nil
@@ -234,13 +412,26 @@ func (ww *Walker) nextFragment() (Fragment, *unexpectedTokenError) {
 
 	case RBRACE:
 		tok := ww.popToken()
-		return CloseBlock{
+		closeBlock := CloseBlock{
 			Token: tok,
 			SourceNode: SourceNode{
 				Start: tok.Start,
 				End:   tok.End,
 			},
-		}, nil
+		}
+		// A comment after the brace stays on its line.
+		if ww.nextType() == COMMENT {
+			comment := ww.popToken()
+			closeBlock.Comment = &Comment{
+				Token: comment,
+				Value: comment.Lit,
+				SourceNode: SourceNode{
+					Start: comment.Start,
+					End:   comment.End,
+				},
+			}
+		}
+		return closeBlock, nil
 
 	case COMMENT, BLOCK_COMMENT:
 		tok := ww.popToken()
@@ -260,7 +451,14 @@ func (ww *Walker) nextFragment() (Fragment, *unexpectedTokenError) {
 		}
 		return stmt, nil
 
-	case IDENT, BOOL: // bool looks like an ident.
+	case BANG:
+		stmt, err := ww.walkNegatedFlag()
+		if err != nil {
+			return nil, err
+		}
+		return stmt, nil
+
+	case IDENT, BOOL, STRING: // bool looks like an ident, a string is a quoted one.
 		stmt, err := ww.walkStatement()
 		if err != nil {
 			return nil, err
@@ -268,7 +466,7 @@ func (ww *Walker) nextFragment() (Fragment, *unexpectedTokenError) {
 		return stmt, nil
 
 	default:
-		return nil, unexpectedToken(ww.popToken(), IDENT, COMMENT, DESCRIPTION, RBRACE, EOL)
+		return nil, unexpectedToken(ww.popToken(), IDENT, BANG, COMMENT, DESCRIPTION, RBRACE, EOL)
 	}
 
 }
@@ -287,21 +485,21 @@ func (ww *Walker) popValue() (Value, *unexpectedTokenError) {
 		if err != nil {
 			return Value{}, err
 		}
-		return Value{
-			token: Token{
-				Type:  STRING,
-				Lit:   ref.String(),
-				Start: ref.SourceNode.Start,
-				End:   ref.SourceNode.End,
-			},
-			SourceNode: SourceNode{
-				Start: ref.SourceNode.Start,
-				End:   ref.SourceNode.End,
-			},
-		}, nil
+		if ww.nextType() == LPAREN {
+			return ww.popCall(ref)
+		}
+		if ww.isEncrypted(ref) {
+			return ww.popEncrypted(ref)
+		}
+		return referenceValue(ref), nil
 	}
 	if ww.nextType().IsLiteral() {
 		token := ww.popToken()
+		if token.Raw {
+			if err := ww.requireEdition(Edition2, token, "a raw string"); err != nil {
+				return Value{}, err
+			}
+		}
 		return Value{
 			token: token,
 			SourceNode: SourceNode{
@@ -314,27 +512,68 @@ func (ww *Walker) popValue() (Value, *unexpectedTokenError) {
 	if ww.nextType() == LBRACK {
 		opener := ww.popToken()
 
-		if ww.nextType() == RBRACK {
-			ww.popToken()
-			return Value{
-				array: []Value{},
-				SourceNode: SourceNode{
-					Start: opener.Start,
-					End:   ww.currentPos(),
-				},
-			}, nil
+		arr := Value{
+			array: []Value{},
+		}
+
+		// Line breaks and comments are allowed between elements. A comment on
+		// the line of the previous element belongs to it, others lead the next.
+		var comments []Comment
+		skipSpace := func() {
+			for {
+				switch ww.nextType() {
+				case EOL:
+					ww.popToken()
+					arr.multiline = true
+				case COMMENT, BLOCK_COMMENT:
+					tok := ww.popToken()
+					comment := Comment{
+						Token: tok,
+						Value: tok.Lit,
+						SourceNode: SourceNode{
+							Start: tok.Start,
+							End:   tok.End,
+						},
+					}
+					if last := len(arr.array) - 1; last >= 0 && len(comments) == 0 &&
+						arr.array[last].Comment == nil && arr.array[last].End.Line == tok.Start.Line {
+						arr.array[last].Comment = &comment
+						continue
+					}
+					comments = append(comments, comment)
+				default:
+					return
+				}
+			}
 		}
 
-		values := make([]Value, 0)
 		for {
+			skipSpace()
+			if ww.nextType() == RBRACK {
+				// Empty, or after a trailing comma
+				ww.popToken()
+				break
+			}
 
-			value, err := ww.popValue()
+			value, err := ww.popElement()
 			if err != nil {
+				if err.tok.Type == EOF {
+					err.openedAt(opener, "array")
+				}
+				return Value{}, err
+			}
+			if max := ww.limits.MaxArrayLength; max > 0 && len(arr.array) >= max {
+				return Value{}, limitExceeded(value.SourceNode.token(), "array length exceeds limit of %d", max)
+			}
+			if err := ww.addNodes(1, value.SourceNode); err != nil {
 				return Value{}, err
 			}
 
-			values = append(values, value)
+			value.leading = comments
+			comments = nil
+			arr.array = append(arr.array, value)
 
+			skipSpace()
 			if ww.nextType() == COMMA {
 				ww.popToken()
 				continue
@@ -343,21 +582,216 @@ func (ww *Walker) popValue() (Value, *unexpectedTokenError) {
 				ww.popToken()
 				break
 			}
-			return Value{}, unexpectedToken(ww.popToken(), COMMA, RBRACK)
+			return Value{}, unexpectedToken(ww.popToken(), COMMA, RBRACK).openedAt(opener, "array")
 		}
-		return Value{
-			array: values,
+
+		arr.trailing = comments
+		arr.SourceNode = SourceNode{
+			Start: opener.Start,
+			End:   ww.currentPos(),
+		}
+		return arr, nil
+	}
+
+	return Value{}, unexpectedToken(ww.popToken(), AnyLiteral, LBRACK)
+}
+
+// popElement reads an array element, which may be a reference followed by
+// an ellipsis to splat the values of another field into the array.
+func (ww *Walker) popElement() (Value, *unexpectedTokenError) {
+	if ww.nextType() != IDENT {
+		return ww.popValue()
+	}
+	ref, err := ww.popReference()
+	if err != nil {
+		return Value{}, err
+	}
+	if ww.nextType() == LPAREN {
+		return ww.popCall(ref)
+	}
+	if ww.isEncrypted(ref) {
+		return ww.popEncrypted(ref)
+	}
+	if ww.nextType() != ELLIPSIS {
+		return referenceValue(ref), nil
+	}
+	tok := ww.popToken()
+	if err := ww.requireEdition(Edition2, tok, "an array splat"); err != nil {
+		return Value{}, err
+	}
+	return Value{
+		token: tok,
+		splat: &ref,
+		SourceNode: SourceNode{
+			Start: ref.SourceNode.Start,
+			End:   tok.End,
+		},
+	}, nil
+}
+
+// popCall reads the comma separated arguments of a function call, after the
+// name. Arguments can't span lines.
+func (ww *Walker) popCall(name Reference) (Value, *unexpectedTokenError) {
+	opener := ww.popToken()
+	if err := ww.requireEdition(Edition2, opener, "a function call"); err != nil {
+		return Value{}, err
+	}
+	call := &Call{
+		Name: name,
+		Args: []Value{},
+	}
+	for ww.nextType() != RPAREN {
+		if len(call.Args) > 0 {
+			if _, err := ww.popType(COMMA); err != nil {
+				return Value{}, err.openedAt(opener, "call")
+			}
+		}
+		arg, err := ww.popValue()
+		if err != nil {
+			if err.tok.Type == EOL || err.tok.Type == EOF {
+				err.openedAt(opener, "call")
+			}
+			return Value{}, err
+		}
+		if err := ww.addNodes(1, arg.SourceNode); err != nil {
+			return Value{}, err
+		}
+		call.Args = append(call.Args, arg)
+	}
+	closer := ww.popToken()
+	call.SourceNode = SourceNode{
+		Start: name.SourceNode.Start,
+		End:   closer.End,
+	}
+	return Value{
+		token:      opener,
+		call:       call,
+		SourceNode: call.SourceNode,
+	}, nil
+}
+
+// EncryptedPrefix marks a string of ciphertext, written enc"...", which is
+// decrypted as the file is walked.
+const EncryptedPrefix = "enc"
+
+// isEncrypted returns true when the reference is the prefix of an encrypted
+// string, written against the opening quote.
+func (ww *Walker) isEncrypted(ref Reference) bool {
+	if len(ref.Idents) != 1 || ref.Idents[0].Value != EncryptedPrefix || ww.nextType() != STRING {
+		return false
+	}
+	next := ww.tokens[ww.offset]
+	return next.Start.Line == ref.End.Line && next.Start.Column == ref.End.Column+1
+}
+
+// popEncrypted reads the string of an encrypted value, which is a call to the
+// enc function with the ciphertext.
+func (ww *Walker) popEncrypted(prefix Reference) (Value, *unexpectedTokenError) {
+	tok := ww.popToken()
+	if err := ww.requireEdition(Edition2, tok, "an encrypted string"); err != nil {
+		return Value{}, err
+	}
+	call := &Call{
+		Name: prefix,
+		Args: []Value{{
+			token: tok,
 			SourceNode: SourceNode{
-				Start: opener.Start,
-				End:   ww.currentPos(),
+				Start: tok.Start,
+				End:   tok.End,
 			},
-		}, nil
+		}},
+		Encrypted: true,
+		SourceNode: SourceNode{
+			Start: prefix.SourceNode.Start,
+			End:   tok.End,
+		},
 	}
+	return Value{
+		token:      prefix.Idents[0].Token,
+		call:       call,
+		SourceNode: call.SourceNode,
+	}, nil
+}
 
-	return Value{}, unexpectedToken(ww.popToken(), AnyLiteral, LBRACK)
+// referenceValue is a bare reference used as a value, which is read as a
+// string.
+func referenceValue(ref Reference) Value {
+	return Value{
+		token: Token{
+			Type:  STRING,
+			Lit:   ref.String(),
+			Start: ref.SourceNode.Start,
+			End:   ref.SourceNode.End,
+		},
+		SourceNode: SourceNode{
+			Start: ref.SourceNode.Start,
+			End:   ref.SourceNode.End,
+		},
+	}
+}
+
+// popStringJoin continues an assigned string with the string literals which
+// follow it, either adjacent, on the same or the next line, or after a +
+// which may end the line. The joined value spans all of the literals.
+func (ww *Walker) popStringJoin(value Value) (Value, *unexpectedTokenError) {
+	for {
+		plus := false
+		switch {
+		case ww.nextType() == STRING:
+		case ww.nextType() == EOL && ww.peekType(1) == STRING && ww.joinsLine(1):
+			ww.popToken()
+		case ww.nextType() == PLUS:
+			ww.popToken()
+			plus = true
+			if ww.nextType() == EOL {
+				ww.popToken()
+			}
+			if ww.nextType() != STRING {
+				return Value{}, unexpectedToken(ww.popToken(), STRING)
+			}
+		default:
+			return value, nil
+		}
+
+		tok := ww.popToken()
+		if err := ww.requireEdition(Edition2, tok, "a string join"); err != nil {
+			return Value{}, err
+		}
+		if len(value.parts) == 0 {
+			value.parts = []stringPart{{token: value.token}}
+		}
+		value.parts = append(value.parts, stringPart{token: tok, plus: plus})
+		value.token.Lit += tok.Lit
+		value.token.Raw = false
+		value.token.End = tok.End
+		value.End = tok.End
+
+		if max := ww.limits.MaxStringLength; max > 0 && len(value.token.Lit) > max {
+			return Value{}, limitExceeded(value.SourceNode.token(), "string length %d exceeds limit of %d", len(value.token.Lit), max)
+		}
+	}
+}
+
+// joinsLine is true when the line from the token at offset is only strings
+// and '+', continuing a string join, rather than a statement with a quoted
+// name such as '"key" = 1'.
+func (ww *Walker) joinsLine(offset int) bool {
+	for {
+		switch ww.peekType(offset) {
+		case STRING, PLUS:
+			offset++
+		case EOL, EOF, COMMENT, BLOCK_COMMENT, RBRACE:
+			return true
+		default:
+			return false
+		}
+	}
 }
 
 func (ww *Walker) popIdent() (Ident, *unexpectedTokenError) {
+	if ww.nextType() == STRING {
+		return ww.popQuotedIdent()
+	}
 	tok, ok := ww.popToken().AsIdent()
 	if !ok {
 		return Ident{}, unexpectedToken(tok, IDENT)
@@ -379,6 +813,10 @@ func (ww *Walker) popReference() (Reference, *unexpectedTokenError) {
 	for {
 		ident, err := ww.popIdent()
 		if err != nil {
+			if len(ref) == 0 {
+				// a quoted first name which is not allowed
+				return Reference{}, err
+			}
 			rr := NewReference(ref)
 			err.context = fmt.Sprintf("after \"%s.\"", rr.String())
 			return rr, err
@@ -485,6 +923,7 @@ func (ww *Walker) walkStatement() (Fragment, *unexpectedTokenError) {
 		err.context = fmt.Sprintf("after \"%s\"", ref.String())
 		return nil, err
 	}
+	ww.checkReserved(ref, ww.nextType() != ASSIGN && ww.nextType() != PLUS)
 
 	start := ref.SourceNode.Start
 
@@ -593,6 +1032,61 @@ func (ww *Walker) walkStatement() (Fragment, *unexpectedTokenError) {
 	}
 }
 
+// walkNegatedFlag reads a '!flag' statement, which is a block header with no
+// tags or body.
+func (ww *Walker) walkNegatedFlag() (Fragment, *unexpectedTokenError) {
+	markToken := ww.popToken()
+	if ww.nextType() != IDENT {
+		// A bang needs a flag name, report the bang as the odd token out
+		return nil, unexpectedToken(markToken, IDENT)
+	}
+
+	ref, err := ww.popReference()
+	if err != nil {
+		return nil, err
+	}
+
+	hdr := BlockHeader{
+		Type:      ref,
+		Mark:      TagMarkBang,
+		MarkToken: markToken,
+		SourceNode: SourceNode{
+			Start: markToken.Start,
+		},
+	}
+
+	if ww.nextType() == STRING || ww.nextType() == INT {
+		// A '!key "value"' file header, see HeaderDirective
+		value, err := ww.popValue()
+		if err != nil {
+			return nil, err
+		}
+		hdr.Tags = []TagValue{{
+			Value:      &value,
+			SourceNode: value.SourceNode,
+		}}
+	}
+
+	switch ww.nextType() {
+	case COMMENT:
+		comment, err := ww.endStatement()
+		if err != nil {
+			return hdr, err
+		}
+		if comment != nil {
+			hdr.Comment = comment
+		}
+		return hdr, nil
+
+	case EOL, EOF:
+		hdr.End = ww.currentPos()
+		return hdr, nil
+
+	default:
+		return nil, unexpectedToken(ww.popToken(), EOL, COMMENT)
+	}
+}
+
 func (ww *Walker) endStatement() (*Comment, *unexpectedTokenError) {
 	tok := ww.popToken()
 
@@ -600,6 +1094,7 @@ func (ww *Walker) endStatement() (*Comment, *unexpectedTokenError) {
 
 	if tok.Type == COMMENT {
 		returnComment = &Comment{
+			Token: tok,
 			Value: tok.Lit,
 			SourceNode: SourceNode{
 				Start: tok.Start,
@@ -630,10 +1125,17 @@ func (ww *Walker) walkValueAssign(ref Reference) (Assignment, *unexpectedTokenEr
 		return assign, err
 	}
 
+	isString := ww.nextType() == STRING
 	value, err := ww.popValue()
 	if err != nil {
 		return assign, err
 	}
+	if isString {
+		value, err = ww.popStringJoin(value)
+		if err != nil {
+			return assign, err
+		}
+	}
 
 	assign.Value = value
 	assign.End = value.End
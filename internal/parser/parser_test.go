@@ -51,6 +51,93 @@ func TestErrors(t *testing.T) {
 		assertErr(t, `}`, errSet(errPos(1, 1)))
 	})
 
+	t.Run("lexer recovery", func(t *testing.T) {
+		input := strings.Join([]string{
+			`a = "unterminated`,
+			`block Foo {`,
+			`  b = 1 @ 2`,
+			`}`,
+			`bad }`,
+			`c = 1`,
+		}, "\n")
+		assertErr(t, input, errSet(
+			errContains("unexpected EOL in string"),
+			errPos(1, 18),
+		), errSet(
+			errContains("unexpected character"),
+			errPos(3, 9),
+		), errSet(
+			errPos(5, 5),
+		))
+
+		file, err := ParseFile(input, false)
+		errs, _ := errpos.AsErrorsWithSource(err)
+		if len(errs.Errors) != 3 {
+			t.Errorf("ERROR: expected 3 errors, got %d", len(errs.Errors))
+		}
+		// the block and the assignment after the errors are in the tree
+		if len(file.Body.Statements) != 2 {
+			t.Errorf("ERROR: expected 2 statements around the errors, got %d", len(file.Body.Statements))
+		}
+	})
+
+	t.Run("unclosed block", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			input   []string
+			opened  int
+			fixed   []string
+			related string
+		}{{
+			name:    "at EOF",
+			input:   []string{"a {", "  b = 1 // one"},
+			opened:  0,
+			fixed:   []string{"a {", "  b = 1 // one", "}"},
+			related: "a opened here",
+		}, {
+			name:    "inner block",
+			input:   []string{"a {", "  b x {", "    c = 1", "", "  d = 2", "}"},
+			opened:  1,
+			fixed:   []string{"a {", "  b x {", "    c = 1", "}", "", "  d = 2", "}"},
+			related: "b opened here",
+		}} {
+			t.Run(tc.name, func(t *testing.T) {
+				input := strings.Join(tc.input, "\n")
+				_, err := ParseFile(input, true)
+				errs, ok := errpos.AsErrorsWithSource(err)
+				if !ok || len(errs.Errors) != 1 {
+					t.Fatalf("FATAL: expected one error, got %v", err)
+				}
+				got := errs.Errors[0]
+				if got.Code != errpos.CodeUnbalancedBlock {
+					t.Errorf("ERROR: expected unbalanced block, got %s", got.Code)
+				}
+				if len(got.Related) != 1 || got.Related[0].Pos.Start.Line != tc.opened || got.Related[0].Message != tc.related {
+					t.Errorf("ERROR: expected related %q on line %d, got %v", tc.related, tc.opened+1, got.Related)
+				}
+				if len(got.Fixes) != 1 {
+					t.Fatalf("FATAL: expected a fix, got %d", len(got.Fixes))
+				}
+				fixed, err := errpos.ApplyEdits(input, got.Fixes[0].Edits)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := strings.Join(tc.fixed, "\n"); fixed != want {
+					t.Errorf("ERROR: fixed\n%s\nwant\n%s", fixed, want)
+				}
+			})
+		}
+
+		assertErr(t, "a = [1, 2\nb = 3", errSet(
+			errPos(2, 1),
+			func(t *testing.T, err *errpos.Err) {
+				if len(err.Related) != 1 || err.Related[0].Pos.Start != (Position{Line: 0, Column: 4}) {
+					t.Errorf("ERROR: expected the array opened at 1:5, got %v", err.Related)
+				}
+			},
+		))
+	})
+
 	t.Run("multiple errors", func(t *testing.T) {
 		assertErr(t, strings.Join([]string{
 			"block }",
@@ -178,6 +265,162 @@ v6 = []
 	)
 }
 
+func TestMultilineArray(t *testing.T) {
+	input := strings.Join([]string{
+		`v1 = [`,
+		`  "a", // first`,
+		`  // about b`,
+		`  "b",`,
+		`]`,
+		`v2 = [1,`,
+		`  2]`,
+	}, "\n")
+
+	file := tParseFile(t, input)
+
+	assertStatements(t, file.Body.Statements,
+		tAssign("v1", tArray(tString("a"), tString("b"))),
+		tAssign("v2", tArray(tDecimal("1"), tDecimal("2"))),
+	)
+
+	v1 := file.Body.Statements[0].(*Assignment).Value
+	first, second := v1.array[0], v1.array[1]
+	if first.Comment == nil || first.Comment.Value != " first" {
+		t.Errorf("expected trailing comment on first element, got %#v", first.Comment)
+	}
+	if len(second.leading) != 1 || second.leading[0].Value != " about b" {
+		t.Errorf("expected leading comment on second element, got %#v", second.leading)
+	}
+	if second.Start.Line != 3 || second.Start.Column != 2 {
+		t.Errorf("expected second element at 3:2, got %s", second.Start)
+	}
+
+	assertErr(t, "v = [\n  \"a\"\n  \"b\"\n]", errSet(errPos(3, 3)))
+	assertErr(t, "v = [,]", errSet(errPos(1, 6)))
+}
+
+func TestArraySplat(t *testing.T) {
+	file := tParseFile(t, "!bcl 2\n"+`v1 = [base.items..., "extra"]`)
+
+	v1 := file.Body.Statements[0].(*Assignment).Value
+	if len(v1.array) != 2 {
+		t.Fatalf("expected 2 elements, got %#v", v1)
+	}
+	ref, ok := v1.array[0].Splat()
+	if !ok || ref.String() != "base.items" {
+		t.Errorf("expected splat of base.items, got %#v", v1.array[0])
+	}
+	if _, ok := v1.array[1].Splat(); ok {
+		t.Errorf("expected plain second element, got %#v", v1.array[1])
+	}
+	if end := v1.array[0].End; end.Line != 1 || end.Column != 18 {
+		t.Errorf("expected splat to end at 1:18, got %s", end)
+	}
+	if file.Edition != Edition2 {
+		t.Errorf("expected edition 2, got %d", file.Edition)
+	}
+
+	// A splat is only an array element
+	assertErr(t, "!bcl 2\n"+`v = items...`, errSet(errPos(2, 10)))
+	assertErr(t, "!bcl 2\n"+`v = [items..]`, errSet(errPos(2, 12)))
+	// and only from edition 2
+	assertErr(t, `v = [items...]`, errSet(errPos(1, 11)))
+}
+
+func TestQuotedNames(t *testing.T) {
+	file := tParseFile(t, strings.Join([]string{
+		`!bcl 2`,
+		`tags."my key" = "v"`,
+		`"null" = 1`,
+		`"include" a {`,
+		`  include = 2`,
+		`}`,
+	}, "\n"))
+
+	key := file.Body.Statements[0].(*Assignment).Key
+	if got := key.Strings(); len(got) != 2 || got[1] != "my key" {
+		t.Errorf("expected key [tags, my key], got %q", got)
+	}
+	if !key.Idents[1].Quoted() || key.Idents[0].Quoted() {
+		t.Errorf("expected only the second ident quoted, got %#v", key.Idents)
+	}
+	if end := key.End; end.Line != 1 || end.Column != 12 {
+		t.Errorf("expected key to end at 2:13, got %s", end)
+	}
+	block := file.Body.Statements[2].(*Block)
+	if block.Type.String() != "include" {
+		t.Errorf("expected block type include, got %s", block.Type)
+	}
+
+	// Only the bare reserved word is a warning, with a fix quoting it
+	reserved := file.ReservedNames()
+	if len(reserved) != 1 {
+		t.Fatalf("expected one reserved word, got %v", reserved)
+	}
+	if pos := reserved[0].Pos.Start; pos.Line != 4 || pos.Column != 2 {
+		t.Errorf("expected reserved word at 5:3, got %s", pos)
+	}
+	if got := reserved[0].Fixes[0].Edits[0]; got.NewText != `"include"` || got.End.Column != 9 {
+		t.Errorf("unexpected fix %#v", got)
+	}
+
+	// Files which can't quote them are not warned
+	if reserved := tParseFile(t, `include = 1`).ReservedNames(); len(reserved) != 0 {
+		t.Errorf("expected no warnings in edition 1, got %v", reserved)
+	}
+
+	// A quoted name after a string value is a statement, not a join
+	joined := tParseFile(t, "!bcl 2\nv = \"a\"\n\"k\" = 1").Body.Statements
+	if len(joined) != 2 || joined[1].(*Assignment).Key.String() != "k" {
+		t.Errorf("expected v and k, got %#v", joined)
+	}
+
+	assertErr(t, "!bcl 2\n"+`"" = 1`, errSet(errPos(2, 1)))
+	// Only from edition 2
+	assertErr(t, `tags."my key" = "v"`, errSet(errPos(1, 6)))
+}
+
+func TestStringJoin(t *testing.T) {
+	input := strings.Join([]string{
+		`!bcl 2`,
+		`v1 = "https://example.com/"`,
+		`  "path"`,
+		`v2 = "a" + "b" +`,
+		`  "c" // joined`,
+		`v3 = "x" "y"`,
+	}, "\n")
+
+	file := tParseFile(t, input)
+
+	assertStatements(t, file.Body.Statements,
+		tAssign("v1", tString("https://example.com/path")),
+		tAssign("v2", tString("abc")),
+		tAssign("v3", tString("xy")),
+	)
+
+	v1 := file.Body.Statements[0].(*Assignment).Value
+	if v1.Start.Line != 1 || v1.Start.Column != 5 || v1.End.Line != 2 || v1.End.Column != 7 {
+		t.Errorf("expected v1 to span 1:5 to 2:7, got %s to %s", v1.Start, v1.End)
+	}
+	v2 := file.Body.Statements[1].(*Assignment)
+	if v2.Comment == nil || v2.Comment.Value != " joined" {
+		t.Errorf("expected comment on v2, got %#v", v2.Comment)
+	}
+
+	// A blank line ends the value, the next string is a quoted name
+	ended := tParseFile(t, "!bcl 2\nv = \"a\"\n\n\"b\"").Body.Statements
+	if len(ended) != 2 || ended[1].(*Block).Type.String() != "b" {
+		t.Errorf("expected v and a block b, got %#v", ended)
+	}
+	assertErr(t, "!bcl 2\n"+`v = "a" + 1`, errSet(errPos(2, 11)))
+	// Strings are only joined from edition 2
+	assertErr(t, `v = "a" "b"`, errSet(errPos(1, 9)))
+	// Tags are not joined
+	assertStatements(t, tParseFile(t, "!bcl 2\nb \"x\" \"y\" {\n}").Body.Statements,
+		tBlock(tBlockTags("x", "y")),
+	)
+}
+
 func TestArrayAppend(t *testing.T) {
 	input := `
 v1 += 1
@@ -236,6 +479,32 @@ func TestDirectives(t *testing.T) {
 	)
 }
 
+func TestFlags(t *testing.T) {
+	input := strings.Join([]string{
+		`cache`,
+		`!debug // off`,
+		`no-trace`,
+	}, "\n")
+
+	file := tParseFile(t, input)
+
+	assertStatements(t, file.Body.Statements,
+		tBlock(tBlockType("cache")),
+		tBlock(tBlockType("debug")),
+		tBlock(tBlockType("no-trace")),
+	)
+	if mark := file.Body.Statements[1].(*Block).Mark; mark != TagMarkBang {
+		t.Errorf("expected bang mark, got %d", mark)
+	}
+
+	assertErr(t, "!debug {\n}", errSet(errPos(1, 8)))
+	assertErr(t, "!debug = true", errSet(errPos(1, 8)))
+
+	// Only the no- of a negated flag joins words
+	assertErr(t, "first-block", errSet(errPos(1, 6)))
+	assertErr(t, "foo no-cache-x", errSet(errPos(1, 13)))
+}
+
 func TestMultilineDescription(t *testing.T) {
 	input := strings.Join([]string{
 		`block Foo {`,
@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// SectionOrder returns the statements of a section in a new order. It is
+// called with the blocks enclosing the section, outermost first, and must
+// return the same statements.
+type SectionOrder func(blocks []*Block, section []Statement) []Statement
+
+// Reorder returns the source with the statements of each body reordered by
+// order. Sections are the runs of assignments and blocks in a body, broken by
+// any other statement, such as a description, or a comment which is not
+// directly above a statement, so each run is ordered on its own. Comments
+// directly above a statement move with it, and the blank lines between
+// statements stay where they were.
+//
+// Bodies with more than one statement on a line are left as they are. Lines
+// are joined with the line break of the first line.
+func Reorder(source string, file *File, order SectionOrder) string {
+	lines := errpos.SplitLines(source)
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight(line, "\r\n")
+	}
+	ro := &reorderer{
+		lines:    lines,
+		order:    order,
+		comments: commentLines(source),
+	}
+	from, to, body := ro.body(nil, file.Body, 0)
+	if from == to {
+		return source
+	}
+
+	out := make([]string, 0, len(lines))
+	out = append(out, lines[:from]...)
+	out = append(out, body...)
+	out = append(out, lines[to:]...)
+	bom := ""
+	if strings.HasPrefix(source, errpos.ByteOrderMark) {
+		bom = errpos.ByteOrderMark
+	}
+	return bom + strings.Join(out, FmtOptions{}.layout(source).newline)
+}
+
+type reorderer struct {
+	lines    []string
+	order    SectionOrder
+	comments map[int]bool
+}
+
+// commentLines returns the lines which hold only comments.
+func commentLines(source string) map[int]bool {
+	comments := map[int]bool{}
+	other := map[int]bool{}
+	tokens, _, _ := NewLexer(source).AllTokens(true)
+	for _, tok := range tokens {
+		switch tok.Type {
+		case COMMENT, BLOCK_COMMENT:
+			for line := tok.Start.Line; line <= tok.End.Line; line++ {
+				comments[line] = true
+			}
+		case EOL, SPACE, EOF:
+		default:
+			other[tok.Start.Line] = true
+		}
+	}
+	for line := range other {
+		delete(comments, line)
+	}
+	return comments
+}
+
+// chunk is the lines of a statement, with the comments directly above it.
+type chunk struct {
+	statement Statement
+	from, to  int // lines, to is exclusive
+}
+
+// body returns the reordered lines of the statements of the body, and the
+// range of lines they replace. Comments above the first statement are only
+// taken from lower on.
+func (ro *reorderer) body(blocks []*Block, body Body, lower int) (int, int, []string) {
+	chunks := make([]*chunk, 0, len(body.Statements))
+	for _, statement := range body.Statements {
+		c := &chunk{
+			statement: statement,
+			from:      statement.Source().Start.Line,
+			to:        statementEnd(statement) + 1,
+		}
+		if len(chunks) > 0 {
+			lower = chunks[len(chunks)-1].to
+		}
+		if c.from < lower {
+			// More than one statement on a line
+			return 0, 0, nil
+		}
+		for c.from > lower && ro.comments[c.from-1] {
+			c.from--
+		}
+		chunks = append(chunks, c)
+	}
+	if len(chunks) == 0 {
+		return 0, 0, nil
+	}
+	from, to := chunks[0].from, chunks[len(chunks)-1].to
+
+	texts := make(map[Statement][]string, len(chunks))
+	for _, c := range chunks {
+		texts[c.statement] = ro.chunkLines(blocks, c)
+	}
+
+	// Each slot keeps its place and the gap after it, the statements in the
+	// slots of a section are reordered.
+	slots := make([]Statement, len(chunks))
+	for start := 0; start < len(chunks); {
+		end := start
+		section := []Statement{}
+		for end < len(chunks) && movable(chunks[end].statement) {
+			section = append(section, chunks[end].statement)
+			end++
+			if end < len(chunks) && ro.hasComment(chunks[end-1].to, chunks[end].from) {
+				break
+			}
+		}
+		if end == start {
+			slots[start] = chunks[start].statement
+			start++
+			continue
+		}
+		ordered := ro.order(blocks, section)
+		if len(ordered) != len(section) {
+			ordered = section
+		}
+		copy(slots[start:end], ordered)
+		start = end
+	}
+
+	out := []string{}
+	for idx, c := range chunks {
+		out = append(out, texts[slots[idx]]...)
+		if idx+1 < len(chunks) {
+			out = append(out, ro.lines[c.to:chunks[idx+1].from]...)
+		}
+	}
+	return from, to, out
+}
+
+func movable(statement Statement) bool {
+	switch statement.(type) {
+	case *Assignment, *Block:
+		return true
+	default:
+		return false
+	}
+}
+
+// hasComment reports whether any line in the range is a comment.
+func (ro *reorderer) hasComment(from, to int) bool {
+	for line := from; line < to; line++ {
+		if ro.comments[line] {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkLines is the lines of the chunk, with the body of a block reordered.
+func (ro *reorderer) chunkLines(blocks []*Block, c *chunk) []string {
+	block, ok := c.statement.(*Block)
+	if !ok {
+		return ro.lines[c.from:c.to]
+	}
+	inner := append(append([]*Block{}, blocks...), block)
+	from, to, body := ro.body(inner, block.Body, block.Start.Line+1)
+	if from == to {
+		return ro.lines[c.from:c.to]
+	}
+	out := append([]string{}, ro.lines[c.from:from]...)
+	out = append(out, body...)
+	return append(out, ro.lines[to:c.to]...)
+}
+
+// statementEnd is the last line of the statement, the closing brace of a
+// block.
+func statementEnd(statement Statement) int {
+	if block, ok := statement.(*Block); ok && block.Close != nil {
+		return block.Close.Token.Start.Line
+	}
+	return statement.Source().End.Line
+}
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+// ReservedWords are the literals, the keywords, and the words kept for
+// keywords of later editions. They still parse as names, but the linter asks
+// for them to be quoted so that a file keeps its meaning when they become
+// keywords.
+var ReservedWords = []string{
+	"true",
+	"false",
+	"null",
+	"import",
+	"include",
+	"with",
+	"defaults",
+}
+
+// blockKeywords start a block, `with a.b {`, and are only reserved as names
+// elsewhere.
+var blockKeywords = map[string]bool{
+	"with":     true,
+	"defaults": true,
+}
+
+var reservedWords = func() map[string]bool {
+	out := make(map[string]bool, len(ReservedWords))
+	for _, word := range ReservedWords {
+		out[word] = true
+	}
+	return out
+}()
+
+// IsReserved returns true for the ReservedWords.
+func IsReserved(name string) bool {
+	return reservedWords[name]
+}
+
+// IsName returns true when the name can be written without quotes, a letter
+// followed by letters, digits, underscores or hyphens before a letter, as the
+// lexer reads an identifier. Reserved words are names.
+func IsName(name string) bool {
+	dash := false
+	for idx, r := range name {
+		if dash && !isLetter(r) {
+			return false
+		}
+		dash = r == '-'
+		if !isLetter(r) && (idx == 0 || (!isDigit(r) && r != '_' && !dash)) {
+			return false
+		}
+	}
+	return name != "" && !dash
+}
+
+// QuoteName returns the name as it is written in a reference, bare when it is
+// a name, otherwise quoted, which needs edition 2.
+func QuoteName(name string) string {
+	if IsName(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+// popQuotedIdent reads a string as an ident, for a name which is reserved or
+// which isn't an identifier, e.g. a map key with a space.
+func (ww *Walker) popQuotedIdent() (Ident, *unexpectedTokenError) {
+	tok := ww.popToken()
+	if err := ww.requireEdition(Edition2, tok, "a quoted name"); err != nil {
+		return Ident{}, err
+	}
+	if tok.Lit == "" {
+		return Ident{}, &unexpectedTokenError{
+			tok:     tok,
+			message: "a quoted name can't be empty",
+		}
+	}
+	return Ident{
+		Token: tok,
+		Value: tok.Lit,
+		SourceNode: SourceNode{
+			Start: tok.Start,
+			End:   tok.End,
+		},
+	}, nil
+}
+
+// checkReserved records the reserved words used as bare names in the
+// reference, in files of an edition which can quote them. The reference of a
+// block statement may be a keyword.
+func (ww *Walker) checkReserved(ref Reference, block bool) {
+	if ww.edition < Edition2 {
+		return
+	}
+	if block && len(ref.Idents) == 1 && blockKeywords[ref.Idents[0].Value] {
+		return
+	}
+	for _, ident := range ref.Idents {
+		if !ident.Quoted() && IsReserved(ident.Value) {
+			ww.reserved = append(ww.reserved, ident)
+		}
+	}
+}
+
+// ReservedNames returns a warning for each reserved word used as a bare name
+// in the file, with a fix which quotes it.
+func (f *File) ReservedNames() errpos.Errors {
+	out := errpos.Errors{}
+	for _, ident := range f.reserved {
+		pos := ident.Position()
+		quoted := strconv.Quote(ident.Value)
+		out = append(out, &errpos.Err{
+			Pos:      &pos,
+			Code:     errpos.CodeReservedWord,
+			Severity: errpos.SeverityWarning,
+			Err:      fmt.Errorf("%s is a reserved word, quote it as %s", ident.Value, quoted),
+			Fixes: []errpos.Fix{{
+				Title: fmt.Sprintf("Quote %s", ident.Value),
+				Safe:  true,
+				Edits: []errpos.Edit{{Start: ident.Start, End: afterPoint(ident.End), NewText: quoted}},
+			}},
+		})
+	}
+	return out
+}
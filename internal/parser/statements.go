@@ -126,6 +126,10 @@ type BlockHeader struct {
 	Description *Description // A single | description block
 	Open        bool         // 'block' is opened with a {
 
+	// Mark is TagMarkBang for a negated flag statement, '!cache'
+	Mark      TagMark
+	MarkToken Token
+
 	SourceNode
 }
 
@@ -157,7 +161,8 @@ func (bs BlockHeader) RootName() string {
 
 type Block struct {
 	BlockHeader
-	Body Body
+	Body  Body
+	Close *CloseBlock // The closing brace, nil for blocks without a body
 }
 
 var _ Statement = &Block{}
@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+const (
+	suppressLinePrefix = "bcl:ignore"
+	suppressFilePrefix = "bcl:ignore-file"
+)
+
+// Suppression is a comment directive which hides errors with the given codes
+// between the lines.
+//
+//	// bcl:ignore-file BCL1001       the whole file
+//	// bcl:ignore BCL1001 BCL2003    the following statement, or block
+//	key = "value" // bcl:ignore      the line (or block) it trails
+//
+// With no codes, all errors are suppressed.
+type Suppression struct {
+	Codes    []errpos.Code
+	Comment  Comment
+	File     bool // Applies to the whole file, ignoring lines
+	FromLine int  // 0 based, inclusive
+	ToLine   int  // 0 based, inclusive
+
+	used bool
+}
+
+func (s *Suppression) matches(err *errpos.Err) bool {
+	if !s.File {
+		if err.Pos == nil {
+			return false
+		}
+		line := err.Pos.Start.Line
+		if line < s.FromLine || line > s.ToLine {
+			return false
+		}
+	}
+	if len(s.Codes) == 0 {
+		return true
+	}
+	code := err.ErrorCode()
+	for _, c := range s.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+type Suppressions []*Suppression
+
+// Suppress returns true when any of the suppressions match the error, marking
+// the matching suppressions as used.
+func (ss Suppressions) Suppress(err error) bool {
+	posErr, ok := errpos.AsError(err)
+	if !ok {
+		return false
+	}
+	return ss.suppressErr(posErr)
+}
+
+func (ss Suppressions) suppressErr(err *errpos.Err) bool {
+	suppressed := false
+	for _, s := range ss {
+		if s.matches(err) {
+			s.used = true
+			suppressed = true
+		}
+	}
+	return suppressed
+}
+
+// Filter returns the errors which are not suppressed.
+func (ss Suppressions) Filter(errs errpos.Errors) errpos.Errors {
+	out := make(errpos.Errors, 0, len(errs))
+	for _, err := range errs {
+		if !ss.suppressErr(err) {
+			out = append(out, err)
+		}
+	}
+	return out
+}
+
+// Unused returns a warning for each suppression which has not matched an
+// error. It is only meaningful after the whole file has been walked.
+func (ss Suppressions) Unused() errpos.Errors {
+	out := errpos.Errors{}
+	for _, s := range ss {
+		if s.used {
+			continue
+		}
+		pos := s.Comment.Position()
+		msg := "unused bcl:ignore"
+		if len(s.Codes) > 0 {
+			codes := make([]string, len(s.Codes))
+			for idx, code := range s.Codes {
+				codes[idx] = code.String()
+			}
+			msg = fmt.Sprintf("unused bcl:ignore for %s", strings.Join(codes, ", "))
+		}
+		out = append(out, &errpos.Err{
+			Pos:      &pos,
+			Code:     errpos.CodeUnusedSuppression,
+			Severity: errpos.SeverityWarning,
+			Err:      errors.New(msg),
+		})
+	}
+	return out
+}
+
+func parseSuppression(comment Comment) (*Suppression, bool) {
+	text := strings.TrimSpace(comment.Value)
+	isFile := false
+	switch {
+	case strings.HasPrefix(text, suppressFilePrefix):
+		text = strings.TrimPrefix(text, suppressFilePrefix)
+		isFile = true
+	case strings.HasPrefix(text, suppressLinePrefix):
+		text = strings.TrimPrefix(text, suppressLinePrefix)
+	default:
+		return nil, false
+	}
+
+	if text != "" && !strings.HasPrefix(text, " ") {
+		// e.g. bcl:ignored, not a directive
+		return nil, false
+	}
+
+	sup := &Suppression{
+		Comment: comment,
+		File:    isFile,
+	}
+	for _, code := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ' ' || r == ','
+	}) {
+		sup.Codes = append(sup.Codes, errpos.Code(code))
+	}
+	return sup, true
+}
+
+// buildSuppressions finds the directives in standalone and trailing comments
+// and resolves the lines they apply to.
+func buildSuppressions(fragments []Fragment) Suppressions {
+	out := Suppressions{}
+
+	fragmentLines := func(idx int) (int, int) {
+		src := fragments[idx].Source()
+		from, to := src.Start.Line, src.End.Line
+		if hdr, ok := fragments[idx].(BlockHeader); ok && hdr.Open {
+			to = closingLine(fragments, idx)
+		}
+		return from, to
+	}
+
+	for idx, fragment := range fragments {
+		if comment, ok := fragment.(Comment); ok {
+			sup, ok := parseSuppression(comment)
+			if !ok {
+				continue
+			}
+			if sup.File {
+				out = append(out, sup)
+				continue
+			}
+
+			// applies to the next statement
+			sup.FromLine = comment.Start.Line
+			sup.ToLine = comment.End.Line
+			for next := idx + 1; next < len(fragments); next++ {
+				if _, ok := fragments[next].(Comment); ok {
+					continue
+				}
+				if _, ok := fragments[next].(CloseBlock); ok {
+					break
+				}
+				_, sup.ToLine = fragmentLines(next)
+				break
+			}
+			out = append(out, sup)
+			continue
+		}
+
+		trailing := fragment.Source().Comment
+		if trailing == nil {
+			continue
+		}
+		sup, ok := parseSuppression(*trailing)
+		if !ok {
+			continue
+		}
+		if !sup.File {
+			sup.FromLine, sup.ToLine = fragmentLines(idx)
+		}
+		out = append(out, sup)
+	}
+
+	return out
+}
+
+// closingLine returns the line of the CloseBlock matching the open BlockHeader
+// at idx, or the last line of the file when it is not closed.
+func closingLine(fragments []Fragment, idx int) int {
+	depth := 0
+	for ; idx < len(fragments); idx++ {
+		switch f := fragments[idx].(type) {
+		case BlockHeader:
+			if f.Open {
+				depth++
+			}
+		case CloseBlock:
+			depth--
+			if depth == 0 {
+				return f.End.Line
+			}
+		}
+	}
+	return math.MaxInt
+}
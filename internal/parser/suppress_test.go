@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+)
+
+func TestSuppressions(t *testing.T) {
+	file := tParseFile(t, strings.Join([]string{
+		"// bcl:ignore-file BCL4001",
+		"a = 1 // bcl:ignore BCL1001",
+		"// bcl:ignore",
+		"block foo {",
+		"  b = 2",
+		"}",
+		"c = 3 // bcl:ignored is not a directive",
+	}, "\n"))
+
+	type want struct {
+		codes    []errpos.Code
+		file     bool
+		from, to int
+	}
+
+	wantSet := []want{
+		{codes: []errpos.Code{errpos.CodeValidation}, file: true},
+		{codes: []errpos.Code{errpos.CodeUnknownBlock}, from: 1, to: 1},
+		{from: 2, to: 5},
+	}
+
+	if len(file.Suppressions) != len(wantSet) {
+		t.Fatalf("expected %d suppressions, got %d", len(wantSet), len(file.Suppressions))
+	}
+
+	for idx, want := range wantSet {
+		got := file.Suppressions[idx]
+		if got.File != want.file {
+			t.Errorf("%d: expected file %v", idx, want.file)
+		}
+		if !want.file && (got.FromLine != want.from || got.ToLine != want.to) {
+			t.Errorf("%d: expected lines %d-%d, got %d-%d", idx, want.from, want.to, got.FromLine, got.ToLine)
+		}
+		if strings.Join(codeStrings(got.Codes), ",") != strings.Join(codeStrings(want.codes), ",") {
+			t.Errorf("%d: expected codes %v, got %v", idx, want.codes, got.Codes)
+		}
+	}
+
+	errAt := func(line int, code errpos.Code) *errpos.Err {
+		return &errpos.Err{
+			Pos:  &errpos.Position{Start: errpos.Point{Line: line}},
+			Code: code,
+		}
+	}
+
+	remaining := file.Suppressions.Filter(errpos.Errors{
+		errAt(1, errpos.CodeUnknownBlock),           // line directive
+		errAt(1, errpos.CodeTypeMismatch),           // wrong code
+		errAt(4, errpos.CodeTypeMismatch),           // within the block
+		errAt(math.MaxInt32, errpos.CodeValidation), // file
+	})
+	if len(remaining) != 1 || remaining[0].Code != errpos.CodeTypeMismatch || remaining[0].Pos.Start.Line != 1 {
+		t.Fatalf("unexpected remaining errors: %v", remaining)
+	}
+
+	if unused := file.Suppressions.Unused(); len(unused) != 0 {
+		t.Fatalf("expected all suppressions used, got %v", unused)
+	}
+}
+
+func codeStrings(codes []errpos.Code) []string {
+	out := make([]string, len(codes))
+	for idx, code := range codes {
+		out[idx] = code.String()
+	}
+	return out
+}
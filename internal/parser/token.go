@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 )
@@ -40,6 +41,9 @@ const (
 	PLUS     // +
 	BANG     // !
 	QUESTION // ?
+	ELLIPSIS // ...
+	LPAREN   // (
+	RPAREN   // )
 	operator_end
 
 	keyword_beg
@@ -84,6 +88,9 @@ var tokens = [...]string{
 	PLUS:         "+",
 	BANG:         "!",
 	QUESTION:     "?",
+	ELLIPSIS:     "...",
+	LPAREN:       "(",
+	RPAREN:       ")",
 	operator_end: "",
 
 	// Keywords
@@ -103,6 +110,11 @@ type Token struct {
 	Type       TokenType
 	Lit        string
 	Start, End Position
+
+	Raw       bool // a STRING written in backticks, without escapes
+	Hash      bool // a COMMENT written with #, a '#line' directive
+	Separator bool // a COMMENT which is a '---' line between documents
+	Recovered bool // an INVALID token the lexer skipped after an error
 }
 
 func (tok Token) AsIdent() (Token, bool) {
@@ -160,7 +172,8 @@ func (tok TokenType) String() string {
 
 var keywords map[string]TokenType
 
-var operators map[rune]TokenType
+// operators maps ASCII characters to their operator token, or INVALID
+var operators [utf8.RuneSelf]TokenType
 
 func init() {
 	keywords = make(map[string]TokenType, keyword_end-(keyword_beg+1))
@@ -168,9 +181,10 @@ func init() {
 		keywords[tokens[i]] = i
 	}
 
-	operators = map[rune]TokenType{}
 	for i := operator_beg + 1; i < operator_end; i++ {
-		operators[rune(tokens[i][0])] = i
+		if len(tokens[i]) == 1 {
+			operators[rune(tokens[i][0])] = i
+		}
 	}
 }
 
@@ -62,21 +62,106 @@ type Value struct {
 	token Token
 	array []Value
 	SourceNode
+
+	leading   []Comment // comments on the lines before an array element
+	trailing  []Comment // comments after the last element of an array
+	multiline bool      // the array spans lines
+
+	splat *Reference // an array element written as ref...
+	call  *Call      // a function called in value position
+
+	parts []stringPart // the literals of a joined string
+}
+
+// stringPart is one literal of a string joined from several.
+type stringPart struct {
+	token Token
+	plus  bool // joined to the previous part with +
 }
 
 var _ ASTValue = Value{}
 
 func (v Value) GoString() string {
+	if v.splat != nil {
+		return fmt.Sprintf("splat(%s)", v.splat)
+	}
+	if v.call != nil {
+		return fmt.Sprintf("call(%s, %#v)", v.call.Name, v.call.Args)
+	}
 	if v.IsArray() {
 		return fmt.Sprintf("[%#v]", v.array)
 	}
 	return fmt.Sprintf("value(%s:%s)", v.token.Type, v.token.Lit)
 }
 
+// Splat returns the reference of an array element written as ref..., which
+// expands to the values of the referenced field.
+func (v Value) Splat() (*Reference, bool) {
+	return v.splat, v.splat != nil
+}
+
+// Call returns the function call of a value written as name(args...), which
+// the walker evaluates before setting the field.
+func (v Value) Call() (*Call, bool) {
+	return v.call, v.call != nil
+}
+
+// Call is a function called in value position. Arguments are values, which
+// may themselves be calls.
+type Call struct {
+	Name Reference
+	Args []Value
+	SourceNode
+
+	// Encrypted is an enc"ciphertext" string, a call to the enc function
+	// written without brackets.
+	Encrypted bool
+}
+
+// NewLiteralValue is a scalar value with the literal of a token type, for
+// values computed from the file rather than read from it.
+func NewLiteralValue(tokenType TokenType, lit string, src SourceNode) Value {
+	return Value{
+		token: Token{
+			Type:  tokenType,
+			Lit:   lit,
+			Start: src.Start,
+			End:   src.End,
+		},
+		SourceNode: src,
+	}
+}
+
+// Token returns the literal token of a scalar value
+func (v Value) Token() Token {
+	return v.token
+}
+
+// StringTokens returns the literals of a string value, one for each part of
+// a joined string, or nil for other values.
+func (v Value) StringTokens() []Token {
+	if len(v.parts) > 0 {
+		tokens := make([]Token, len(v.parts))
+		for idx, part := range v.parts {
+			tokens[idx] = part.token
+		}
+		return tokens
+	}
+	if v.token.Type != STRING || v.splat != nil || v.call != nil || v.IsArray() {
+		return nil
+	}
+	return []Token{v.token}
+}
+
 func (v Value) IsArray() bool {
 	return len(v.array) > 0
 }
 
+// IsEmptyArray is true for an array written with no elements, [].
+func (v Value) IsEmptyArray() bool {
+	return v.array != nil && len(v.array) == 0
+}
+
 func (v Value) IsScalar() bool {
 	return !v.IsArray()
 }
@@ -249,10 +334,11 @@ type BoolValue struct {
 	SourceNode
 }
 
-func NewBoolValue(val bool, pos Position) ASTValue {
+func NewBoolValue(val bool, src SourceNode) ASTValue {
 	return BoolValue{
 		unknownValue: unknownValue{typeName: "bool"},
 		val:          val,
+		SourceNode:   src,
 	}
 }
 
@@ -0,0 +1,134 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	keyInterrupt = 0x03
+	keyEOF       = 0x04
+	keyBackspace = 0x08
+	keyTab       = '\t'
+	keyEscape    = 0x1b
+	keyDelete    = 0x7f
+)
+
+// editor reads lines a key at a time from a terminal in key mode, echoing
+// them, so that tab completes the word before the cursor as it is pressed.
+// The cursor is always at the end of the line.
+type editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// complete returns the last word of the line and the names which can
+	// replace it.
+	complete func(line string) (string, []string, error)
+}
+
+func newEditor(in io.Reader, out io.Writer, complete func(string) (string, []string, error)) *editor {
+	return &editor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		complete: complete,
+	}
+}
+
+// readLine reads a line after writing the prompt, returning io.EOF when
+// ctrl-d is pressed on an empty line or the input ends.
+func (ed *editor) readLine(prompt string) (string, error) {
+	fmt.Fprint(ed.out, prompt)
+	line := []byte{}
+	for {
+		key, err := ed.in.ReadByte()
+		if err == io.EOF && len(line) > 0 {
+			fmt.Fprintln(ed.out)
+			return string(line), nil
+		} else if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case '\r', '\n':
+			fmt.Fprintln(ed.out)
+			return string(line), nil
+
+		case keyEOF:
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+
+		case keyInterrupt:
+			fmt.Fprintln(ed.out, "^C")
+			return "", nil
+
+		case keyBackspace, keyDelete:
+			if len(line) == 0 {
+				continue
+			}
+			_, size := utf8.DecodeLastRune(line)
+			line = line[:len(line)-size]
+			fmt.Fprint(ed.out, "\b \b")
+
+		case keyTab:
+			completed, err := ed.tab(prompt, string(line))
+			if err != nil {
+				fmt.Fprintf(ed.out, "\n%s\n%s%s", formatError(err), prompt, line)
+				continue
+			}
+			line = []byte(completed)
+
+		case keyEscape:
+			ed.skipEscape()
+
+		default:
+			if key < 0x20 {
+				continue
+			}
+			line = append(line, key)
+			ed.out.Write([]byte{key})
+		}
+	}
+}
+
+// tab completes the last word of the line as far as the names which can
+// replace it agree, listing them when they don't agree on any more.
+func (ed *editor) tab(prompt, line string) (string, error) {
+	word, names, err := ed.complete(line)
+	if err != nil || len(names) == 0 {
+		return line, err
+	}
+	common := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(names) == 1 {
+		common += " "
+	}
+	if added, ok := strings.CutPrefix(common, word); ok && added != "" {
+		fmt.Fprint(ed.out, added)
+		return line + added, nil
+	}
+	fmt.Fprintf(ed.out, "\n%s\n%s%s", strings.Join(names, "  "), prompt, line)
+	return line, nil
+}
+
+// skipEscape reads the rest of an escape sequence, such as an arrow key,
+// which the editor doesn't act on.
+func (ed *editor) skipEscape() {
+	next, err := ed.in.ReadByte()
+	if err != nil || (next != '[' && next != 'O') {
+		return
+	}
+	for {
+		final, err := ed.in.ReadByte()
+		if err != nil || (final >= 0x40 && final <= 0x7e) {
+			return
+		}
+	}
+}
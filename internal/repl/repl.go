@@ -0,0 +1,336 @@
+// Package repl reads BCL statements interactively, parsing the file built so
+// far as each statement is completed.
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const filename = "repl.bcl"
+
+const help = `Enter BCL statements, a statement spanning lines is parsed when its blocks
+and arrays are closed. In a terminal, tab completes the last word of the line,
+listing the names which complete it when there is more than one; otherwise end
+a line with a tab to list them.
+
+  :help           print this help
+  :scope          list the blocks and attributes of the open block
+  :print          print the message
+  :source         print the statements entered
+  :undo           remove the last statement
+  :reset          remove every statement
+`
+
+// Session holds the statements entered so far. Each complete statement is
+// parsed with the ones before it into a new message, and kept only when the
+// file still parses.
+type Session struct {
+	parser *bcl.Parser
+	root   protoreflect.MessageDescriptor
+	format bcl.Format
+
+	statements []string
+	pending    []string
+	message    proto.Message
+}
+
+// NewSession starts an empty file, parsed into messages of type root and
+// printed in format.
+func NewSession(p *bcl.Parser, root protoreflect.MessageDescriptor, format bcl.Format) *Session {
+	return &Session{
+		parser:  p,
+		root:    root,
+		format:  format,
+		message: dynamicpb.NewMessage(root),
+	}
+}
+
+// Message is the message parsed from the statements so far.
+func (s *Session) Message() proto.Message {
+	return s.message
+}
+
+// Source is the statements so far, as a file.
+func (s *Session) Source() string {
+	return strings.Join(s.statements, "\n")
+}
+
+// Pending reports whether the lines since the last statement are an
+// unfinished statement.
+func (s *Session) Pending() bool {
+	return len(s.pending) > 0
+}
+
+// Input adds a line. When it completes a statement the file is parsed, and
+// the statement is dropped if the file fails. Returns whether a statement was
+// added.
+func (s *Session) Input(line string) (bool, error) {
+	s.pending = append(s.pending, line)
+	statement := strings.Join(s.pending, "\n")
+	if strings.TrimSpace(statement) == "" {
+		s.pending = nil
+		return false, nil
+	}
+	if _, open := openBlocks(statement); open {
+		return false, nil
+	}
+	s.pending = nil
+
+	source := strings.Join(append(s.statements, statement), "\n")
+	msg := dynamicpb.NewMessage(s.root)
+	if _, err := s.parser.Parse(filename, source, msg); err != nil {
+		return false, err
+	}
+	s.statements = append(s.statements, statement)
+	s.message = msg
+	return true, nil
+}
+
+// Undo removes the last statement, or the unfinished one.
+func (s *Session) Undo() error {
+	if s.pending != nil {
+		s.pending = nil
+		return nil
+	}
+	if len(s.statements) == 0 {
+		return fmt.Errorf("no statements to undo")
+	}
+	statements := s.statements[:len(s.statements)-1]
+	msg := dynamicpb.NewMessage(s.root)
+	if _, err := s.parser.Parse(filename, strings.Join(statements, "\n"), msg); err != nil {
+		return err
+	}
+	s.statements = statements
+	s.message = msg
+	return nil
+}
+
+// Reset removes every statement.
+func (s *Session) Reset() {
+	s.statements = nil
+	s.pending = nil
+	s.message = dynamicpb.NewMessage(s.root)
+}
+
+// Scope is the scope of the innermost block opened by the unfinished
+// statement, the root of the file when there is none.
+func (s *Session) Scope() (*bcl.Scope, error) {
+	path, _ := openBlocks(strings.Join(s.pending, "\n"))
+	return s.parser.ScopeAt(s.root, path...)
+}
+
+// Complete returns the names in the current scope which start with prefix,
+// sorted.
+func (s *Session) Complete(prefix string) ([]string, error) {
+	scope, err := s.Scope()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, child := range scope.Children() {
+		if strings.HasPrefix(child.Name, prefix) {
+			names = append(names, child.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// openBlocks returns the names of the blocks left open at the end of the
+// source, and whether any block, array or parenthesis is left open. A string
+// left open counts as open, other lexer errors do not, so the statement is
+// parsed and the error reported.
+func openBlocks(source string) ([]string, bool) {
+	lexer := parser.NewLexer(source)
+	tokens, ok, err := lexer.AllTokens(true)
+	if err != nil || !ok {
+		for _, lexErr := range lexer.Errors {
+			if lexErr.Code == errpos.CodeUnexpectedEOF {
+				return nil, true
+			}
+		}
+		return nil, false
+	}
+
+	path := []string{}
+	depth := 0
+	var statement *parser.Token
+	for idx, tok := range tokens {
+		switch tok.Type {
+		case parser.EOL, parser.SPACE, parser.COMMENT, parser.BLOCK_COMMENT:
+			if tok.Type == parser.EOL {
+				statement = nil
+			}
+			continue
+		case parser.LBRACE:
+			name := ""
+			if statement != nil {
+				name = statement.Lit
+			}
+			path = append(path, name)
+			statement = nil
+			continue
+		case parser.RBRACE:
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			statement = nil
+			continue
+		case parser.LBRACK, parser.LPAREN:
+			depth++
+		case parser.RBRACK, parser.RPAREN:
+			depth--
+		}
+		if statement == nil && tok.Type == parser.IDENT {
+			statement = &tokens[idx]
+		}
+	}
+	return path, len(path) > 0 || depth > 0
+}
+
+// Run reads lines from in until it is closed, writing the message after each
+// statement, and errors, completions and command output, to out. When in is a
+// terminal it is read a key at a time, so tab completes as it is pressed.
+func Run(s *Session, in io.Reader, out io.Writer) error {
+	if file, ok := in.(*os.File); ok {
+		if restore, err := keyMode(file); err == nil {
+			defer restore()
+			return runEditor(s, newEditor(file, out, s.completeLine), out)
+		}
+	}
+
+	scanner := bufio.NewScanner(in)
+	prompt := func() {
+		fmt.Fprint(out, s.prompt())
+	}
+
+	prompt()
+	for scanner.Scan() {
+		line := scanner.Text()
+		if err := s.handle(line, out); err != nil {
+			fmt.Fprintln(out, formatError(err))
+		}
+		prompt()
+	}
+	fmt.Fprintln(out)
+	return scanner.Err()
+}
+
+func runEditor(s *Session, ed *editor, out io.Writer) error {
+	for {
+		line, err := ed.readLine(s.prompt())
+		if err == io.EOF {
+			fmt.Fprintln(out)
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := s.handle(line, out); err != nil {
+			fmt.Fprintln(out, formatError(err))
+		}
+	}
+}
+
+func (s *Session) prompt() string {
+	if s.Pending() {
+		return "... "
+	}
+	return "> "
+}
+
+// completeLine returns the last word of the line, empty after a space, and
+// the names in the current scope which start with it.
+func (s *Session) completeLine(line string) (string, []string, error) {
+	words := strings.Fields(line)
+	prefix := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		prefix = words[len(words)-1]
+	}
+	names, err := s.Complete(prefix)
+	return prefix, names, err
+}
+
+func (s *Session) handle(line string, out io.Writer) error {
+	if before, ok := strings.CutSuffix(line, "\t"); ok {
+		_, names, err := s.completeLine(before)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, strings.Join(names, "  "))
+		return nil
+	}
+
+	// No statement starts with a colon, so commands can be given part way
+	// through one.
+	if command := strings.TrimSpace(line); strings.HasPrefix(command, ":") {
+		return s.command(command, out)
+	}
+
+	added, err := s.Input(line)
+	if err != nil || !added {
+		return err
+	}
+	return s.print(out)
+}
+
+func (s *Session) command(command string, out io.Writer) error {
+	switch command {
+	case ":help":
+		fmt.Fprint(out, help)
+	case ":scope":
+		scope, err := s.Scope()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, scope.SchemaName())
+		for _, child := range scope.Children() {
+			kind := "attribute"
+			if child.Block {
+				kind = "block"
+			}
+			fmt.Fprintf(out, "  %s %s %s\n", child.Name, kind, child.Type)
+		}
+	case ":print":
+		return s.print(out)
+	case ":source":
+		fmt.Fprintln(out, s.Source())
+	case ":undo":
+		if err := s.Undo(); err != nil {
+			return err
+		}
+		return s.print(out)
+	case ":reset":
+		s.Reset()
+	default:
+		return fmt.Errorf("unknown command %q, :help lists them", command)
+	}
+	return nil
+}
+
+func (s *Session) print(out io.Writer) error {
+	data, err := bcl.Marshal(s.message, s.format)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, strings.TrimSpace(string(data)))
+	return err
+}
+
+func formatError(err error) string {
+	if withSource, ok := errpos.AsErrorsWithSource(err); ok {
+		return withSource.HumanString(0)
+	}
+	return err.Error()
+}
@@ -0,0 +1,138 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pentops/bcl.go/bcl"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/bcl.go/gen/test/v1/test_pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func testSession(t *testing.T) *Session {
+	t.Helper()
+	p, err := bcl.NewParser(&bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}, {
+			SchemaName: "test.v1.Element_Foo",
+			Name:       &bcl_j5pb.Tag{FieldName: "name"},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSession(p, (&test_pb.File{}).ProtoReflect().Descriptor(), bcl.FormatJSON)
+}
+
+func TestSession(t *testing.T) {
+	s := testSession(t)
+
+	added, err := s.Input(`sString = "a"`)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = s.Input(`foo Name {`)
+	assert.NoError(t, err)
+	assert.False(t, added)
+	assert.True(t, s.Pending())
+
+	scope, err := s.Scope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "test.v1.Element_Foo", scope.SchemaName())
+	names, err := s.Complete("de")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"description"}, names)
+
+	added, err = s.Input(`  description = "d"`)
+	assert.NoError(t, err)
+	assert.False(t, added)
+	added, err = s.Input(`}`)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	got := fileMessage(t, s)
+	assert.Equal(t, "a", got.SString)
+	assert.Equal(t, "d", got.Elements[0].GetFoo().Description)
+
+	// A statement which fails is dropped, keeping the message
+	_, err = s.Input(`sString = "b"`)
+	assert.Error(t, err)
+	assert.Equal(t, "sString = \"a\"\nfoo Name {\n  description = \"d\"\n}", s.Source())
+
+	assert.NoError(t, s.Undo())
+	assert.Equal(t, `sString = "a"`, s.Source())
+}
+
+func fileMessage(t *testing.T, s *Session) *test_pb.File {
+	t.Helper()
+	data, err := proto.Marshal(s.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &test_pb.File{}
+	if err := proto.Unmarshal(data, file); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestOpenBlocks(t *testing.T) {
+	for _, tc := range []struct {
+		source string
+		path   []string
+		open   bool
+	}{
+		{source: `a = 1`, path: []string{}},
+		{source: `a { b x {`, path: []string{"a", "b"}, open: true},
+		{source: "a {\n}", path: []string{}},
+		{source: "a = [1,", path: []string{}, open: true},
+		{source: `a = "abc`, open: true},
+	} {
+		path, open := openBlocks(tc.source)
+		assert.Equal(t, tc.path, path, tc.source)
+		assert.Equal(t, tc.open, open, tc.source)
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := testSession(t)
+	out := &bytes.Buffer{}
+	in := strings.NewReader("sStr\t\nsString = \"a\"\n:nope\n")
+	assert.NoError(t, Run(s, in, out))
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, "> sString", lines[0])
+	assert.Contains(t, out.String(), `"sString"`)
+	assert.Contains(t, out.String(), "> unknown command \":nope\", :help lists them\n")
+}
+
+func TestEditor(t *testing.T) {
+	s := testSession(t)
+	out := &bytes.Buffer{}
+	in := strings.NewReader("sStr\t= \"a\"\n\tx\x7f\x7f\x03\x1b[D\x04")
+	ed := newEditor(in, out, s.completeLine)
+
+	line, err := ed.readLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, `sString = "a"`, line)
+
+	line, err = ed.readLine("> ")
+	assert.NoError(t, err)
+	assert.Equal(t, "", line)
+
+	line, err = ed.readLine("> ")
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "", line)
+
+	assert.Contains(t, out.String(), "> sString = \"a\"\n")
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package repl
+
+import "golang.org/x/sys/unix"
+
+const (
+	ioctlGetTermios = unix.TCGETS
+	ioctlSetTermios = unix.TCSETS
+)
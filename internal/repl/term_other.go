@@ -0,0 +1,14 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package repl
+
+import (
+	"fmt"
+	"os"
+)
+
+// keyMode is not supported, lines are read whole and a trailing tab lists
+// the completions.
+func keyMode(file *os.File) (func(), error) {
+	return nil, fmt.Errorf("key input is not supported on this platform")
+}
@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package repl
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyMode stops the terminal echoing input, buffering it by line and turning
+// ctrl-c into a signal, so the editor reads each key as it is pressed. Output
+// processing is left on, so newlines still return the carriage. The returned
+// function restores the terminal.
+func keyMode(file *os.File) (func(), error) {
+	fd := int(file.Fd())
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	keys := *state
+	keys.Lflag &^= unix.ECHO | unix.ICANON | unix.ISIG
+	keys.Cc[unix.VMIN] = 1
+	keys.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &keys); err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = unix.IoctlSetTermios(fd, ioctlSetTermios, state)
+	}, nil
+}
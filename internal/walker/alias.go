@@ -0,0 +1,33 @@
+package walker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// deprecatedAlias warns when the reference starts with an alias which the
+// block spec deprecates, with a fix writing the path the alias stands for.
+func (wc *walkContext) deprecatedAlias(ref parser.Reference) {
+	if len(ref.Idents) == 0 {
+		return
+	}
+	first := ref.Idents[0]
+	path, ok := wc.scope.DeprecatedAlias(first.Value)
+	if !ok {
+		return
+	}
+
+	replacement := strings.Join(path, ".")
+	pos := first.Position()
+	fix := errpos.ReplaceFix(fmt.Sprintf("Replace with %s", replacement), pos, replacement)
+	fix.Safe = true
+	wc.warn(&errpos.Err{
+		Pos:   &pos,
+		Code:  errpos.CodeDeprecated,
+		Err:   fmt.Errorf("%s is deprecated, write %s", first.Value, replacement),
+		Fixes: []errpos.Fix{fix},
+	})
+}
@@ -6,25 +6,119 @@ import (
 	"github.com/pentops/bcl.go/bcl/errpos"
 	"github.com/pentops/bcl.go/internal/parser"
 	"github.com/pentops/bcl.go/internal/walker/schema"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-func WalkSchema(scope *schema.Scope, body parser.Body, verbose bool) error {
+// Suppressor decides if an error in a statement should be ignored, allowing
+// the walk to continue with the next statement.
+type Suppressor interface {
+	Suppress(err error) bool
+}
+
+// Stats are counters from walking a file
+type Stats struct {
+	Statements int
+	Blocks     int
+
+	// ReflectValues is the number of reflection fields fetched or created
+	ReflectValues int
+}
+
+// NewAny builds the message named by the type tag of a block which sets a
+// google.protobuf.Any field, returning the reflection of the message to walk,
+// and a function which returns the message to pack once it is walked.
+type NewAny func(typeName string) (j5reflect.Object, func() protoreflect.Message, error)
+
+type WalkOptions struct {
+	// Logger, when set, receives debug events
+	Logger Logger
+
+	// Filename is the file being walked, for the Logger
+	Filename string
+
+	// Suppress, when set, can skip errors in statements
+	Suppress Suppressor
+
+	// Stats, when set, is incremented as the file is walked
+	Stats *Stats
+
+	// NewAny, when set, resolves the types of blocks for Any fields
+	NewAny NewAny
+
+	// Extensions, when set, resolves the extensions of blocks
+	Extensions Extensions
+
+	// Functions, when set, resolves the functions called in value position
+	Functions FunctionResolver
+
+	// Edition is the edition of the file, from its '!bcl' header
+	Edition parser.Edition
+
+	// OneofConflicts is how a second member of a oneof set in one block is
+	// walked, an error by default
+	OneofConflicts OneofPolicy
+
+	// Warnings, when set, receives the conflicts which OneofConflicts keeps
+	// walking past
+	Warnings *errpos.Errors
+
+	// MaxRecursion, when set, is the most blocks of one schema which can be
+	// nested in each other, for schemas whose blocks contain themselves
+	MaxRecursion int
+
+	// Computed are the children of blocks set by a callback, by the schema
+	// of the block
+	Computed map[string][]ComputedChild
+
+	// FreeForm, when set, names the free-form fields, which are set from
+	// any nested blocks and values
+	FreeForm FreeForm
+}
+
+func WalkSchema(scope *schema.Scope, body parser.Body, opts WalkOptions) error {
+
+	stats := opts.Stats
+	if stats == nil {
+		stats = &Stats{}
+	}
+	counters := &schema.Counters{}
+	scope.SetCounters(counters)
+	scope.CheckOneofs()
+	defer func() {
+		stats.ReflectValues += counters.ReflectValues
+	}()
 
 	rootContext := &walkContext{
-		scope:   scope,
-		path:    []string{""},
-		verbose: verbose,
+		scope:      scope,
+		path:       []string{""},
+		logger:     opts.Logger,
+		filename:   opts.Filename,
+		suppress:   opts.Suppress,
+		stats:      stats,
+		newAny:     opts.NewAny,
+		extensions: opts.Extensions,
+		functions:  opts.Functions,
+		edition:    opts.Edition,
+		oneofs:     opts.OneofConflicts,
+		warnings:   opts.Warnings,
+
+		maxRecursion: opts.MaxRecursion,
+		computed:     opts.Computed,
+		freeForm:     opts.FreeForm,
+		dotted:       &[]dottedBlock{},
 	}
 
 	rootErr := rootContext.run(func(sc Context) error {
-		return doBody(sc, body)
+		if err := doBody(sc, body); err != nil {
+			return err
+		}
+		return sc.computeChildren(errpos.Position{})
 	})
 	if rootErr == nil {
 		return nil
 	}
-	if rootContext.verbose {
-		logError(rootErr)
-	}
+	rootContext.logError(rootErr)
 	return rootErr
 
 }
@@ -41,6 +135,10 @@ func (e *ErrExpectedTag) Error() string {
 	return fmt.Sprintf("expected %s tag", e.Label)
 }
 
+func (e *ErrExpectedTag) ErrorCode() errpos.Code {
+	return errpos.CodeExpectedTag
+}
+
 func pointPosition(point parser.Position) errpos.Position {
 	return errpos.Position{
 		Start: point,
@@ -55,44 +153,93 @@ func spanPosition(start, end parser.Position) errpos.Position {
 	}
 }
 
-var ErrUnexpectedTag = fmt.Errorf("unexpected tag")
-var ErrUnexpectedQualifier = fmt.Errorf("unexpected qualifier")
+var ErrUnexpectedTag = errpos.WithCode(fmt.Errorf("unexpected tag"), errpos.CodeUnexpectedTag)
+var ErrUnexpectedQualifier = errpos.WithCode(fmt.Errorf("unexpected qualifier"), errpos.CodeUnexpectedQualifier)
 
 func doBody(sc Context, body parser.Body) error {
+	stats := sc.walkStats()
 	for _, decl := range body.Statements {
-		switch decl := decl.(type) {
-
-		case *parser.Description:
-			sc.Logf("Description Statement %#v", decl)
-			err := doDescription(sc, decl)
-			if err != nil {
-				err = errpos.AddPosition(err, decl.Position())
-				return err
+		stats.Statements++
+		blocks := stats.Blocks
+		err := doStatement(sc, decl)
+		if err == nil {
+			continue
+		}
+		err = errpos.AddPosition(err, decl.Source().Position())
+		retry, err := sc.resolveOneof(err)
+		if retry {
+			// The blocks of the first attempt are walked again.
+			stats.Blocks = blocks
+			err = doStatement(sc, decl)
+			if err == nil {
+				continue
 			}
+			err = errpos.AddPosition(err, decl.Source().Position())
+		}
+		if err == nil {
+			continue
+		}
+		if sc.Suppress(err) {
+			sc.Log("suppressed", decl.Source(), "Suppressed %s", err)
+			continue
+		}
+		return err
+	}
+	return nil
+}
 
-		case *parser.Assignment:
-			sc.Logf("Assign Statement %#v <- %#v (%s)", decl.Key, decl.Value, decl.SourceNode.Start)
-			err := doAssign(sc, decl)
-			if err != nil {
-				err = errpos.AddPosition(err, decl.Position())
-				return err
-			}
-			sc.Logf("Assign OK")
+func doStatement(sc Context, decl parser.Statement) error {
+	switch decl := decl.(type) {
 
-		case *parser.Block:
-			sc.Logf("Block Statement %#v", decl.BlockHeader)
-			err := doFullBlock(sc, decl)
+	case *parser.Description:
+		sc.Log("description", decl, "Description Statement")
+		return doDescription(sc, decl)
+
+	case *parser.Assignment:
+		sc.Log("assign", decl, "Assign Statement %s", decl.Key)
+		if typeName, ok := sc.freeFormType(decl.Key); ok {
+			return doFreeFormAssign(sc, typeName, decl)
+		}
+		sc.deprecatedAlias(decl.Key)
+		err := doAssign(sc, decl)
+		if err == nil {
+			sc.Log("assign_ok", decl, "Assign OK")
+		}
+		return err
+
+	case *parser.Block:
+		sc.Log("block", decl.BlockHeader, "Block Statement %s", decl.Type)
+		if field, value, ok := flagStatement(sc.currentSpec(), decl); ok {
+			return sc.SetAttribute(schema.PathSpec{field}, nil, parser.NewBoolValue(value, decl.SourceNode))
+		}
+		if sc.isKeyword(decl, withKeyword) {
+			inner, err := withBody(sc, decl)
 			if err != nil {
-				err = errpos.AddPosition(err, decl.Position())
 				return err
 			}
-			sc.Logf("Block OK")
-
-		default:
-			return fmt.Errorf("unexpected statement type %T", decl)
+			return doBody(sc, inner)
+		}
+		if sc.isKeyword(decl, defaultsKeyword) {
+			return sc.addDefaults(decl)
+		}
+		if typeName, ok := sc.freeFormType(decl.Type); ok {
+			return doFreeFormBlock(sc, typeName, decl)
 		}
+		if decl.Mark == parser.TagMarkBang {
+			err := fmt.Errorf("%s is not a flag", decl.Type)
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeNotFlag), decl.BlockHeader)
+		}
+		sc.walkStats().Blocks++
+		sc.deprecatedAlias(decl.Type)
+		err := doFullBlock(sc, decl)
+		if err == nil {
+			sc.Log("block_ok", decl.BlockHeader, "Block OK")
+		}
+		return err
+
+	default:
+		return fmt.Errorf("unexpected statement type %T", decl)
 	}
-	return nil
 }
 
 func doAssign(sc Context, a *parser.Assignment) error {
@@ -110,17 +257,130 @@ func doDescription(sc Context, decl *parser.Description) error {
 	return nil
 }
 
+// withKeyword starts a grouping block, `with a.b {`, which prefixes the keys of
+// the assignments and the types of the blocks in its body with the path.
+const withKeyword = "with"
+
+// isKeywordBlock is true for a block whose type is the bare keyword. Quoting
+// it, `"with" {`, writes a block of that name.
+func isKeywordBlock(decl *parser.Block, keyword string) bool {
+	idents := decl.Type.Idents
+	return len(idents) == 1 && !idents[0].Quoted() && idents[0].Value == keyword
+}
+
+// withBody returns the body of a with block, with the prefix applied to each
+// statement. Nested with blocks combine the prefixes.
+func withBody(sc Context, decl *parser.Block) (parser.Body, error) {
+	hdr := decl.BlockHeader
+	if len(hdr.Tags) == 0 {
+		err := &ErrExpectedTag{
+			Label:  "path",
+			Schema: withKeyword,
+		}
+		return parser.Body{}, sc.WrapErr(err, pointPosition(hdr.Type.End))
+	}
+	if len(hdr.Tags) > 1 {
+		return parser.Body{}, sc.WrapErr(ErrUnexpectedTag, hdr.Tags[1])
+	}
+	if len(hdr.Qualifiers) > 0 {
+		return parser.Body{}, sc.WrapErr(ErrUnexpectedQualifier, hdr.Qualifiers[0])
+	}
+	if hdr.Description != nil {
+		err := errpos.WithCode(fmt.Errorf("with blocks have no description"), errpos.CodeNoDescription)
+		return parser.Body{}, sc.WrapErr(err, hdr.Description)
+	}
+	tag := hdr.Tags[0]
+	if tag.Reference == nil || tag.Mark != parser.TagMarkNone {
+		err := errpos.WithCode(fmt.Errorf("with needs a path, not a value"), errpos.CodeTypeMismatch)
+		return parser.Body{}, sc.WrapErr(err, tag)
+	}
+
+	prefix := func(ref parser.Reference) parser.Reference {
+		idents := make([]parser.Ident, 0, len(tag.Reference.Idents)+len(ref.Idents))
+		idents = append(idents, tag.Reference.Idents...)
+		idents = append(idents, ref.Idents...)
+		return parser.NewReference(idents)
+	}
+
+	body := parser.Body{
+		Statements: make([]parser.Statement, 0, len(decl.Body.Statements)),
+	}
+	for _, stmt := range decl.Body.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			prefixed := *stmt
+			prefixed.Key = prefix(stmt.Key)
+			body.Statements = append(body.Statements, &prefixed)
+
+		case *parser.Block:
+			prefixed := *stmt
+			if isKeywordBlock(stmt, withKeyword) && len(stmt.Tags) > 0 && stmt.Tags[0].Reference != nil {
+				tags := append([]parser.TagValue{}, stmt.Tags...)
+				ref := prefix(*tags[0].Reference)
+				tags[0].Reference = &ref
+				prefixed.Tags = tags
+			} else {
+				prefixed.Type = prefix(stmt.Type)
+			}
+			body.Statements = append(body.Statements, &prefixed)
+
+		case *parser.Description:
+			err := errpos.WithCode(fmt.Errorf("with blocks have no description"), errpos.CodeNoDescription)
+			return parser.Body{}, sc.WrapErr(err, stmt)
+
+		default:
+			return parser.Body{}, fmt.Errorf("unexpected statement type %T", stmt)
+		}
+	}
+	return body, nil
+}
+
+// flagStatement returns the field and value set by a flag statement, a bare
+// name with no tags or body, when the name is a flag of the spec.
+func flagStatement(spec schema.BlockSpec, decl *parser.Block) (string, bool, bool) {
+	hdr := decl.BlockHeader
+	if len(spec.Flags) == 0 || len(hdr.Type.Idents) != 1 || len(hdr.Tags) > 0 ||
+		len(hdr.Qualifiers) > 0 || hdr.Description != nil || hdr.Open {
+		return "", false, false
+	}
+	field, value, ok := spec.FlagName(hdr.Type.Idents[0].String())
+	if !ok {
+		return "", false, false
+	}
+	if hdr.Mark == parser.TagMarkBang {
+		if !value {
+			// !no-cache reads as a double negative, so is not allowed
+			return "", false, false
+		}
+		value = false
+	}
+	return field, value, true
+}
+
 func doFullBlock(sc Context, decl *parser.Block) error {
 
+	if ok, err := sc.anyBlock(decl); ok {
+		return err
+	}
+
 	typeTag := decl.BlockHeader.Type
 
 	newScope, err := sc.BuildScope(nil, typeTag.Idents, ResetScope)
 	if err != nil {
+		if ok, extErr := sc.extensionBlock(decl); ok {
+			return extErr
+		}
 		return fmt.Errorf("WithContainer, building scope: %w", err)
 	}
 
-	err = sc.WithScope(newScope, func(sc Context, blockSpec schema.BlockSpec) error {
-		return doBlock(sc, blockSpec, decl)
+	err = sc.withBlock(newScope, typeTag.Position(), func(sc Context, blockSpec schema.BlockSpec) error {
+		if err := doBlock(sc, blockSpec, decl); err != nil {
+			return err
+		}
+		if err := sc.inheritDefaults(typeTag.String()); err != nil {
+			return err
+		}
+		return sc.computeChildren(typeTag.Position())
 	})
 	if err != nil {
 		return err
@@ -171,13 +431,18 @@ func doBlock(sc Context, spec schema.BlockSpec, bs *parser.Block) error {
 			if bs.BlockHeader.Description != nil {
 
 				if rootBlockSpec.Description == nil {
-					return sc.WrapErr(fmt.Errorf("block %q has no description field", spec.ErrName()), bs.BlockHeader.Description)
+					err := errpos.WithCode(fmt.Errorf("block %q has no description field", spec.ErrName()), errpos.CodeNoDescription)
+					return sc.WrapErr(err, bs.BlockHeader.Description)
 				}
 				if err := sc.SetAttribute(schema.PathSpec{*rootBlockSpec.Description}, nil, parser.NewStringValue(bs.Description.Value, bs.SourceNode)); err != nil {
 					return err
 				}
 			}
 
+			if spec.TypeAttribute != nil {
+				return walkTypeAttribute(sc, spec, bs)
+			}
+
 			if err := doBody(sc, bs.Body); err != nil {
 				return err
 			}
@@ -204,8 +469,8 @@ func checkBang(sc Context, tagSpec schema.Tag, gotTag parser.TagValue) error {
 		path = schema.PathSpec{*tagSpec.QuestionFieldName}
 	}
 
-	sc.Logf("Applying Tag Mark, %#v %s", tagSpec, gotTag)
-	err := sc.SetAttribute(path, nil, parser.NewBoolValue(true, gotTag.Start))
+	sc.Log("tag_mark", gotTag, "Applying Tag Mark to %s", tagSpec.FieldName)
+	err := sc.SetAttribute(path, nil, parser.NewBoolValue(true, gotTag.SourceNode))
 	if err != nil {
 		return err
 	}
@@ -233,12 +498,27 @@ func walkTags(sc Context, spec schema.BlockSpec, gotTags popSet, outerCallback S
 			return err
 		}
 
-		sc.Logf("Applying Name tag, %#v %#v", tagSpec, gotTag)
-		err := sc.SetAttribute(schema.PathSpec{tagSpec.FieldName}, nil, gotTag)
+		var nameValue parser.ASTValue = gotTag
+		if name, err := gotTag.AsString(); err == nil {
+			qualified := name
+			if gotTag.Value != nil && !gotTag.Value.Token().Raw {
+				qualified, err = sc.interpolate(name, gotTag)
+				if err != nil {
+					return err
+				}
+			}
+			qualified = sc.qualifyName(qualified, tagSpec.ScopeSeparator)
+			if qualified != name {
+				nameValue = parser.NewStringValue(qualified, gotTag.SourceNode)
+			}
+		}
+
+		sc.Log("name_tag", gotTag, "Applying Name tag to %s", tagSpec.FieldName)
+		err := sc.SetAttribute(schema.PathSpec{tagSpec.FieldName}, nil, nameValue)
 		if err != nil {
 			return err
 		}
-		sc.Logf("Applied Name, remaining tags: %#v", gotTags.items)
+		sc.Log("name_tag_ok", gotTag, "Applied Name, %d tags remaining", len(gotTags.items))
 	}
 
 	if spec.TypeSelect != nil {
@@ -253,9 +533,10 @@ func walkTags(sc Context, spec schema.BlockSpec, gotTags popSet, outerCallback S
 
 		tagSpec := *spec.TypeSelect
 
-		sc.Logf("TypeSelect %#v %s", tagSpec, gotTag)
+		sc.Log("type_select", gotTag, "TypeSelect %s", tagSpec.FieldName)
 		if gotTag.Reference == nil {
-			return fmt.Errorf("type-select %s needs to be a reference", tagSpec.FieldName)
+			err := fmt.Errorf("type-select %s needs to be a reference", tagSpec.FieldName)
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), gotTag)
 		}
 
 		pathToType := schema.PathSpec{tagSpec.FieldName}
@@ -278,15 +559,16 @@ func walkTags(sc Context, spec schema.BlockSpec, gotTags popSet, outerCallback S
 	if gotTags.hasMore() {
 		for _, tag := range gotTags.items {
 			if tag.Mark != parser.TagMarkNone {
-				return sc.WrapErr(fmt.Errorf("unexpected tag mark"), tag)
+				return sc.WrapErr(errpos.WithCode(fmt.Errorf("unexpected tag mark"), errpos.CodeUnexpectedTag), tag)
 			}
 		}
 		if spec.ScalarSplit != nil {
 			if len(gotTags.items) != 1 {
-				return fmt.Errorf("expected exactly one tag for type %s", spec.ErrName())
+				err := fmt.Errorf("expected exactly one tag for type %s", spec.ErrName())
+				return errpos.WithCode(err, errpos.CodeUnexpectedTag)
 			}
 
-			sc.Logf("Applying ScalarSplit %#v %#v", spec.ScalarSplit, gotTags.items[0])
+			sc.Log("scalar_split", gotTags.items[0], "Applying ScalarSplit to %s", spec.ErrName())
 
 			ref := gotTags.items[0]
 
@@ -296,7 +578,7 @@ func walkTags(sc Context, spec schema.BlockSpec, gotTags popSet, outerCallback S
 
 		} else {
 
-			err := fmt.Errorf("no more tags expected for type %s", spec.ErrName())
+			err := errpos.WithCode(fmt.Errorf("no more tags expected for type %s", spec.ErrName()), errpos.CodeUnexpectedTag)
 			return errpos.AddPosition(err, spanPosition(gotTags.items[0].Position().Start, gotTags.items[len(gotTags.items)-1].Position().End))
 		}
 	}
@@ -313,11 +595,11 @@ func walkQualifiers(sc Context, spec schema.BlockSpec, gotQualifiers popSet, out
 
 	if spec.Qualifier == nil {
 		err := fmt.Errorf("not expecting a qualifier for type %s", spec.ErrName())
-		return sc.WrapErr(err, qualifier.Position())
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeUnexpectedQualifier), qualifier.Position())
 	}
 
 	tagSpec := spec.Qualifier
-	sc.Logf("Qualifier %#v %s", tagSpec, qualifier)
+	sc.Log("qualifier", qualifier, "Qualifier %s", qualifier)
 
 	if !tagSpec.IsBlock {
 		if err := checkBang(sc, *tagSpec, qualifier); err != nil {
@@ -337,7 +619,8 @@ func walkQualifiers(sc Context, spec schema.BlockSpec, gotQualifiers popSet, out
 	}
 
 	if qualifier.Reference == nil {
-		return fmt.Errorf("qualifier %s needs to be a reference to specify a block", tagSpec.FieldName)
+		err := fmt.Errorf("qualifier %s needs to be a reference to specify a block", tagSpec.FieldName)
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), qualifier)
 	}
 
 	// WithTypeSelect selects a child container from a wrapper container at path.
@@ -0,0 +1,128 @@
+package walker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// ComputeContext is the block a computed child is produced for.
+type ComputeContext struct {
+	Filename string
+	Schema   string
+	Path     []string // field names from the root of the file to the block
+	Pos      errpos.Position
+}
+
+// ComputeFunc produces the value of a computed child, as the Go type its
+// scalar field is set from, e.g. string, int64 or time.Time.
+type ComputeFunc func(ComputeContext) (interface{}, error)
+
+// ComputedChild is a field of a block which is set by a ComputeFunc rather
+// than written in the file.
+type ComputedChild struct {
+	Name    string
+	Compute ComputeFunc
+}
+
+// dottedBlock is a block which a dotted assignment walked into, such as foo
+// in foo.bar = 1, computed when the block holding the assignment is closed,
+// once every assignment to it is set.
+type dottedBlock struct {
+	scope *schema.Scope
+	path  []string
+	pos   errpos.Position
+	depth int
+}
+
+// walkDotted walks the path to the block of an assignment, recording the
+// blocks which the file names in it to compute when the block holding the
+// assignment is closed.
+func (wc *walkContext) walkDotted(path []pathElement) (*schema.Scope, error) {
+	if len(wc.computed) == 0 || wc.dotted == nil {
+		return wc.walkScopePath(path)
+	}
+	scope := wc.scope
+	loc := wc.blockLocation
+	names := slices.Clone(wc.path[1:])
+	for _, elem := range path {
+		if elem.position != nil {
+			loc = *elem.position
+		}
+		next, err := walkScope(scope, []pathElement{elem}, loc)
+		if err != nil {
+			return nil, err
+		}
+		scope = next
+		names = append(names, elem.name)
+		if elem.position == nil {
+			continue
+		}
+		*wc.dotted = append(*wc.dotted, dottedBlock{
+			scope: scope,
+			path:  slices.Clone(names),
+			pos:   loc,
+			depth: wc.depth,
+		})
+	}
+	return scope, nil
+}
+
+// computeChildren sets the computed children of the innermost block which the
+// file left unset, once its body is walked, and then those of the blocks
+// dotted assignments within it walked into.
+func (wc *walkContext) computeChildren(pos errpos.Position) error {
+	if err := wc.computeScope(wc.scope, wc.path[1:], pos); err != nil {
+		return err
+	}
+	if wc.dotted == nil {
+		return nil
+	}
+	pending := *wc.dotted
+	kept := pending[:0]
+	var inside []dottedBlock
+	for _, block := range pending {
+		if block.depth >= wc.depth {
+			inside = append(inside, block)
+		} else {
+			kept = append(kept, block)
+		}
+	}
+	*wc.dotted = kept
+	seen := map[string]bool{}
+	for _, block := range inside {
+		key := strings.Join(block.path, ".")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if err := wc.computeScope(block.scope, block.path, block.pos); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wc *walkContext) computeScope(scope *schema.Scope, path []string, pos errpos.Position) error {
+	schemaName := scope.SchemaName()
+	for _, child := range wc.computed[schemaName] {
+		child := child
+		err := scope.SetSynthesized(child.Name, pos, func() (interface{}, error) {
+			return child.Compute(ComputeContext{
+				Filename: wc.filename,
+				Schema:   schemaName,
+				Path:     path,
+				Pos:      pos,
+			})
+		})
+		if err != nil {
+			err = errpos.WithCode(fmt.Errorf("computing %s.%s: %w", schemaName, child.Name, err), errpos.CodeFunction)
+			return errpos.AddPosition(err, pos)
+		}
+		wc.Log("computed", pos, "Computed %s", child.Name)
+	}
+	return nil
+}
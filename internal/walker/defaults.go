@@ -0,0 +1,114 @@
+package walker
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// defaultsKeyword starts a block of default values, `defaults service {`,
+// which each later block of the type in the same body, or nested in it,
+// inherits unless the block sets the value itself.
+const defaultsKeyword = "defaults"
+
+// blockDefaults are the assignments of a defaults block, for the blocks of
+// schemaName, or when the type didn't resolve to a schema, those whose type is
+// written as blockType.
+type blockDefaults struct {
+	blockType   string
+	schemaName  string
+	assignments []*parser.Assignment
+}
+
+func (bd blockDefaults) matches(schemaName, blockType string) bool {
+	if bd.schemaName != "" {
+		return bd.schemaName == schemaName
+	}
+	return bd.blockType == blockType
+}
+
+// addDefaults records a defaults block for the blocks which follow it in this
+// context and its children.
+func (wc *walkContext) addDefaults(decl *parser.Block) error {
+	hdr := decl.BlockHeader
+	if len(hdr.Tags) == 0 {
+		err := &ErrExpectedTag{
+			Label:  "block type",
+			Schema: defaultsKeyword,
+		}
+		return wc.WrapErr(err, pointPosition(hdr.Type.End))
+	}
+	if len(hdr.Tags) > 1 {
+		return wc.WrapErr(ErrUnexpectedTag, hdr.Tags[1])
+	}
+	if len(hdr.Qualifiers) > 0 {
+		return wc.WrapErr(ErrUnexpectedQualifier, hdr.Qualifiers[0])
+	}
+	if hdr.Description != nil {
+		err := errpos.WithCode(fmt.Errorf("defaults blocks have no description"), errpos.CodeNoDescription)
+		return wc.WrapErr(err, hdr.Description)
+	}
+	tag := hdr.Tags[0]
+	if tag.Reference == nil || tag.Mark != parser.TagMarkNone {
+		err := errpos.WithCode(fmt.Errorf("defaults needs a block type, not a value"), errpos.CodeTypeMismatch)
+		return wc.WrapErr(err, tag)
+	}
+
+	defaults := blockDefaults{
+		blockType: tag.Reference.String(),
+	}
+	path := make([]string, len(tag.Reference.Idents))
+	for idx, ident := range tag.Reference.Idents {
+		path[idx] = ident.Value
+	}
+	if schemaName, ok := wc.scope.BlockSchemaName(path); ok {
+		defaults.schemaName = schemaName
+	}
+	for _, stmt := range decl.Body.Statements {
+		assign, ok := stmt.(*parser.Assignment)
+		if !ok || assign.Append {
+			err := errpos.WithCode(fmt.Errorf("defaults blocks can only set values"), errpos.CodeDefaults)
+			return wc.WrapErr(err, stmt.Source())
+		}
+		defaults.assignments = append(defaults.assignments, assign)
+	}
+	wc.defaults = append(wc.defaults, defaults)
+	return nil
+}
+
+// inheritDefaults sets the defaults declared for the block's schema in the
+// enclosing contexts on the block of this context, skipping the values the
+// block set itself. The nearest defaults for a key win.
+func (wc *walkContext) inheritDefaults(blockType string) error {
+	schemaName := wc.scope.SchemaName()
+	inherited := *wc
+	inherited.scope = wc.scope.Inherited()
+
+	seen := map[string]bool{}
+	for ctx := wc.parent; ctx != nil; ctx = ctx.parent {
+		for idx := len(ctx.defaults) - 1; idx >= 0; idx-- {
+			defaults := ctx.defaults[idx]
+			if !defaults.matches(schemaName, blockType) {
+				continue
+			}
+			for _, assign := range defaults.assignments {
+				key := assign.Key.String()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				err := doAssign(&inherited, assign)
+				if err == nil {
+					continue
+				}
+				if errpos.GetErrorCode(err) == errpos.CodeAlreadySet {
+					wc.Log("default_overridden", assign, "Block sets %s", key)
+					continue
+				}
+				return errpos.AddPosition(err, assign.Source().Position())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package walker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"github.com/pentops/j5/lib/j5schema"
+)
+
+// enumValue returns the enum of the named field and the value as written,
+// for literal values of enum fields.
+func enumValue(scope *schema.Scope, name string, val parser.ASTValue) (*j5schema.EnumSchema, parser.Value, string, bool) {
+	value, ok := val.(parser.Value)
+	if !ok || !value.IsScalar() {
+		return nil, parser.Value{}, "", false
+	}
+	written, err := value.AsString()
+	if err != nil {
+		return nil, parser.Value{}, "", false
+	}
+	enum, ok := scope.EnumSchema(name)
+	if !ok {
+		return nil, parser.Value{}, "", false
+	}
+	return enum, value, written, true
+}
+
+// enumFix replaces the value with the name of the option, keeping a quoted
+// value quoted.
+func enumFix(value parser.Value, option string) errpos.Fix {
+	text := option
+	if value.Token().Type == parser.STRING {
+		text = strconv.Quote(option)
+	}
+	fix := errpos.ReplaceFix(fmt.Sprintf("Replace with %s", option), value.Position(), text)
+	fix.Safe = true
+	return fix
+}
+
+// enumName warns when an enum value is written other than as the name of
+// its option, with the enum's prefix, with a fix writing the name.
+func (wc *walkContext) enumName(scope *schema.Scope, name string, val parser.ASTValue) {
+	enum, value, written, ok := enumValue(scope, name, val)
+	if !ok {
+		return
+	}
+	option := enum.OptionByName(written)
+	if option == nil || option.Name() == written {
+		return
+	}
+	pos := value.Position()
+	wc.warn(&errpos.Err{
+		Pos:   &pos,
+		Code:  errpos.CodeEnumName,
+		Err:   fmt.Errorf("%s is written %s, without the prefix of the enum", written, option.Name()),
+		Fixes: []errpos.Fix{enumFix(value, option.Name())},
+	})
+}
+
+// suggestEnum adds a fix to the error for an enum value which matches an
+// option in another case, with or without the enum's prefix.
+func suggestEnum(err error, scope *schema.Scope, name string, val parser.ASTValue) error {
+	enum, value, written, ok := enumValue(scope, name, val)
+	if !ok {
+		return err
+	}
+	if len(written) >= len(enum.NamePrefix) && strings.EqualFold(written[:len(enum.NamePrefix)], enum.NamePrefix) {
+		written = written[len(enum.NamePrefix):]
+	}
+	for _, option := range enum.Options {
+		if strings.EqualFold(option.Name(), written) {
+			err = fmt.Errorf("%w, did you mean %s?", err, option.Name())
+			return errpos.AddFix(err, enumFix(value, option.Name()))
+		}
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package walker
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Extensions resolves the protobuf extensions which a block can set, by the
+// name the file uses, for names which are not fields of the block.
+type Extensions interface {
+	FindExtension(extendee protoreflect.MessageDescriptor, name string) (protoreflect.ExtensionType, bool)
+
+	// NewObject reflects the message of an extension set with a block, and
+	// returns a function which sets msg from the object once it is walked.
+	NewObject(msg protoreflect.Message) (j5reflect.Object, func(), error)
+}
+
+func (wc *walkContext) extensionField(scope *schema.Scope, name string, pos errpos.Position) (*schema.ExtensionField, bool) {
+	if wc.extensions == nil {
+		return nil, false
+	}
+	msg, ok := scope.BlockMessage()
+	if !ok {
+		return nil, false
+	}
+	xt, ok := wc.extensions.FindExtension(msg.Descriptor(), name)
+	if !ok {
+		return nil, false
+	}
+	return scope.ExtensionField(msg, xt, pos), true
+}
+
+// extensionAttribute sets a scalar extension of the block of the scope. It is
+// false when the block has no extension of the name.
+func (wc *walkContext) extensionAttribute(scope *schema.Scope, name string, val parser.ASTValue, appendValue bool) (bool, error) {
+	field, ok := wc.extensionField(scope, name, val.Position())
+	if !ok {
+		return false, nil
+	}
+	wc.Log("set_extension", val.Position(), "Set extension %s", field.FullTypeName())
+
+	vals, isArray := val.AsArray()
+	if !isArray && !appendValue {
+		if err := field.SetASTValue(val); err != nil {
+			return true, wc.WrapErr(err, val.Position())
+		}
+		return true, nil
+	}
+	if !isArray {
+		vals = []parser.ASTValue{val}
+	}
+	if !appendValue && field.Length() > 0 {
+		return true, wc.WrapErr(errpos.WithCode(fmt.Errorf("value already set"), errpos.CodeAlreadySet), val.Position())
+	}
+	for _, val := range vals {
+		val, err := wc.callValue(val)
+		if err != nil {
+			return true, err
+		}
+		if _, err := field.AppendASTValue(val); err != nil {
+			return true, wc.WrapErr(err, val.Position())
+		}
+	}
+	return true, nil
+}
+
+// extensionBlock walks a block which sets a message extension of the current
+// block. It is false when the block has no extension of the name.
+func (wc *walkContext) extensionBlock(decl *parser.Block) (bool, error) {
+	idents := decl.Type.Idents
+	if len(idents) != 1 {
+		return false, nil
+	}
+	field, ok := wc.extensionField(wc.scope, idents[0].String(), decl.Type.Position())
+	if !ok {
+		return false, nil
+	}
+	msg, err := field.Message()
+	if err != nil {
+		return true, wc.WrapErr(err, decl.Type)
+	}
+	obj, walked, err := wc.extensions.NewObject(msg)
+	if err != nil {
+		return true, wc.WrapErr(err, decl.Type)
+	}
+	extScope, err := field.Root(obj)
+	if err != nil {
+		return true, newSchemaError(err)
+	}
+	err = wc.withSchema(extScope, nil, func(sc Context, spec schema.BlockSpec) error {
+		if err := doBlock(sc, spec, decl); err != nil {
+			return err
+		}
+		return sc.computeChildren(decl.Type.Position())
+	})
+	walked()
+	return true, err
+}
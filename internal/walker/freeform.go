@@ -0,0 +1,226 @@
+package walker
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The free-form well-known types, which take any nested blocks and values
+// rather than following a schema.
+const (
+	freeFormStruct    = "google.protobuf.Struct"
+	freeFormValue     = "google.protobuf.Value"
+	freeFormListValue = "google.protobuf.ListValue"
+)
+
+// FreeForm returns the free-form type of a field, google.protobuf.Struct,
+// Value or ListValue, by the schema of the block and the field's name, or an
+// empty string for other fields. The field itself holds the JSON of the
+// value.
+type FreeForm func(schemaName, field string) string
+
+// freeFormType returns the free-form type of the child of the scope which the
+// reference names, for a reference of one name.
+func (wc *walkContext) freeFormType(ref parser.Reference) (string, bool) {
+	if wc.freeForm == nil || len(ref.Idents) != 1 {
+		return "", false
+	}
+	schemaName, field, ok := wc.scope.FieldOwner(ref.Idents[0].Value)
+	if !ok {
+		return "", false
+	}
+	typeName := wc.freeForm(schemaName, field)
+	return typeName, typeName != ""
+}
+
+// setFreeForm sets a free-form field from its value, as JSON.
+func setFreeForm(sc Context, ref parser.Reference, msg proto.Message, src parser.SourceNode) error {
+	encoded, err := protojson.Marshal(msg)
+	if err != nil {
+		return sc.WrapErr(err, src)
+	}
+	return sc.SetAttribute(nil, ref.Idents, parser.NewStringValue(string(encoded), src))
+}
+
+// doFreeFormAssign sets a Value or ListValue field from a literal or an
+// array.
+func doFreeFormAssign(sc Context, typeName string, a *parser.Assignment) error {
+	if a.Append {
+		err := errpos.WithCode(fmt.Errorf("%s can't be appended to", typeName), errpos.CodeTypeMismatch)
+		return sc.WrapErr(err, a)
+	}
+	value, err := freeFormLiteral(a.Value)
+	if err != nil {
+		return sc.WrapErr(err, a.Value)
+	}
+	switch typeName {
+	case freeFormValue:
+		return setFreeForm(sc, a.Key, value, a.SourceNode)
+	case freeFormListValue:
+		list := value.GetListValue()
+		if list == nil {
+			err := errpos.WithCode(fmt.Errorf("%s needs an array", typeName), errpos.CodeTypeMismatch)
+			return sc.WrapErr(err, a.Value)
+		}
+		return setFreeForm(sc, a.Key, list, a.SourceNode)
+	default:
+		err := errpos.WithCode(fmt.Errorf("%s needs a block", typeName), errpos.CodeTypeMismatch)
+		return sc.WrapErr(err, a.Value)
+	}
+}
+
+// doFreeFormBlock sets a Struct or Value field from the body of a block.
+func doFreeFormBlock(sc Context, typeName string, decl *parser.Block) error {
+	if typeName == freeFormListValue {
+		err := errpos.WithCode(fmt.Errorf("%s needs an array", typeName), errpos.CodeTypeMismatch)
+		return sc.WrapErr(err, decl.BlockHeader)
+	}
+	obj, err := freeFormBlock(sc, decl)
+	if err != nil {
+		return err
+	}
+	if typeName == freeFormStruct {
+		return setFreeForm(sc, decl.Type, obj, decl.SourceNode)
+	}
+	return setFreeForm(sc, decl.Type, structpb.NewStructValue(obj), decl.SourceNode)
+}
+
+// freeFormBlock builds a Struct from a block with no tags, from the
+// assignments and nested blocks of its body.
+func freeFormBlock(sc Context, decl *parser.Block) (*structpb.Struct, error) {
+	hdr := decl.BlockHeader
+	if len(hdr.Tags) > 0 {
+		return nil, sc.WrapErr(ErrUnexpectedTag, hdr.Tags[0])
+	}
+	if len(hdr.Qualifiers) > 0 {
+		return nil, sc.WrapErr(ErrUnexpectedQualifier, hdr.Qualifiers[0])
+	}
+	if hdr.Description != nil {
+		err := errpos.WithCode(fmt.Errorf("free-form blocks have no description"), errpos.CodeNoDescription)
+		return nil, sc.WrapErr(err, hdr.Description)
+	}
+
+	obj := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for _, stmt := range decl.Body.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			if stmt.Append {
+				err := errpos.WithCode(fmt.Errorf("free-form values can't be appended to"), errpos.CodeTypeMismatch)
+				return nil, sc.WrapErr(err, stmt)
+			}
+			value, err := freeFormLiteral(stmt.Value)
+			if err != nil {
+				return nil, sc.WrapErr(err, stmt.Value)
+			}
+			if err := setFreeFormPath(obj, stmt.Key, value); err != nil {
+				return nil, sc.WrapErr(err, stmt.Key)
+			}
+
+		case *parser.Block:
+			nested, err := freeFormBlock(sc, stmt)
+			if err != nil {
+				return nil, err
+			}
+			if err := setFreeFormPath(obj, stmt.Type, structpb.NewStructValue(nested)); err != nil {
+				return nil, sc.WrapErr(err, stmt.Type)
+			}
+
+		case *parser.Description:
+			err := errpos.WithCode(fmt.Errorf("free-form blocks have no description"), errpos.CodeNoDescription)
+			return nil, sc.WrapErr(err, stmt)
+
+		default:
+			return nil, fmt.Errorf("unexpected statement type %T", stmt)
+		}
+	}
+	return obj, nil
+}
+
+// setFreeFormPath sets the value at the dotted path in the struct, creating
+// the structs along it. Blocks of the same name merge, other values can only
+// be set once.
+func setFreeFormPath(obj *structpb.Struct, ref parser.Reference, value *structpb.Value) error {
+	final := len(ref.Idents) - 1
+	for _, ident := range ref.Idents[:final] {
+		existing, ok := obj.Fields[ident.Value]
+		if !ok {
+			existing = structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{}})
+			obj.Fields[ident.Value] = existing
+		}
+		if existing.GetStructValue() == nil {
+			return errpos.WithCode(fmt.Errorf("%s is already set", ident.Value), errpos.CodeAlreadySet)
+		}
+		obj = existing.GetStructValue()
+	}
+
+	key := ref.Idents[final].Value
+	existing, ok := obj.Fields[key]
+	if !ok {
+		obj.Fields[key] = value
+		return nil
+	}
+	if existing.GetStructValue() == nil || value.GetStructValue() == nil {
+		return errpos.WithCode(fmt.Errorf("%s is already set", key), errpos.CodeAlreadySet)
+	}
+	for name, field := range value.GetStructValue().Fields {
+		nested := parser.NewReference([]parser.Ident{{Value: name}})
+		if err := setFreeFormPath(existing.GetStructValue(), nested, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freeFormLiteral builds a Value from a literal or an array of them. Strings
+// and bare names are strings, and numbers are doubles, as in JSON.
+func freeFormLiteral(val parser.Value) (*structpb.Value, error) {
+	if _, ok := val.Call(); ok {
+		return nil, errpos.WithCode(fmt.Errorf("free-form values can't call functions"), errpos.CodeTypeMismatch)
+	}
+	if _, ok := val.Splat(); ok {
+		return nil, errpos.WithCode(fmt.Errorf("free-form values can't expand references"), errpos.CodeTypeMismatch)
+	}
+	if val.IsEmptyArray() {
+		return structpb.NewListValue(&structpb.ListValue{}), nil
+	}
+	if elements, ok := val.AsArray(); ok {
+		list := &structpb.ListValue{Values: make([]*structpb.Value, 0, len(elements))}
+		for _, element := range elements {
+			literal, ok := element.(parser.Value)
+			if !ok {
+				return nil, fmt.Errorf("unexpected array element %T", element)
+			}
+			value, err := freeFormLiteral(literal)
+			if err != nil {
+				return nil, err
+			}
+			list.Values = append(list.Values, value)
+		}
+		return structpb.NewListValue(list), nil
+	}
+
+	switch val.Token().Type {
+	case parser.BOOL:
+		b, err := val.AsBool()
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewBoolValue(b), nil
+	case parser.INT, parser.DECIMAL:
+		f, err := val.AsFloat(64)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewNumberValue(f), nil
+	}
+	str, err := val.AsString()
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewStringValue(str), nil
+}
@@ -0,0 +1,189 @@
+package walker
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// ValueType is the type of a function argument or result.
+type ValueType int
+
+const (
+	StringType ValueType = iota + 1 // Go string
+	IntType                         // Go int64
+	FloatType                       // Go float64
+	BoolType                        // Go bool
+)
+
+func (vt ValueType) String() string {
+	switch vt {
+	case StringType:
+		return "string"
+	case IntType:
+		return "int"
+	case FloatType:
+		return "float"
+	case BoolType:
+		return "bool"
+	default:
+		return fmt.Sprintf("ValueType(%d)", int(vt))
+	}
+}
+
+// goValue converts an argument to the Go type of the value type.
+func (vt ValueType) goValue(val parser.ASTValue) (interface{}, error) {
+	switch vt {
+	case StringType:
+		return val.AsString()
+	case IntType:
+		return val.AsInt(64)
+	case FloatType:
+		return val.AsFloat(64)
+	case BoolType:
+		return val.AsBool()
+	default:
+		return nil, fmt.Errorf("unknown value type %s", vt)
+	}
+}
+
+// literal converts a result to the literal it would be written as, so it is
+// set on the field as if it were written in the file.
+func (vt ValueType) literal(value interface{}, src parser.SourceNode) (parser.Value, bool) {
+	switch vt {
+	case StringType:
+		if str, ok := value.(string); ok {
+			return parser.NewLiteralValue(parser.STRING, str, src), true
+		}
+	case IntType:
+		if num, ok := value.(int64); ok {
+			return parser.NewLiteralValue(parser.INT, strconv.FormatInt(num, 10), src), true
+		}
+	case FloatType:
+		if num, ok := value.(float64); ok {
+			return parser.NewLiteralValue(parser.DECIMAL, strconv.FormatFloat(num, 'g', -1, 64), src), true
+		}
+	case BoolType:
+		if b, ok := value.(bool); ok {
+			return parser.NewLiteralValue(parser.BOOL, strconv.FormatBool(b), src), true
+		}
+	}
+	return parser.Value{}, false
+}
+
+// Function is a pure function which a file can call in value position, as
+// name(args...). Arguments are passed as the Go types of Params, and Call
+// must return the Go type of Result.
+type Function struct {
+	Params []ValueType
+	Result ValueType
+	Call   func(args []interface{}) (interface{}, error)
+
+	// Sensitive results, and the results of calls which take them as
+	// arguments, are kept out of errors and logs.
+	Sensitive bool
+}
+
+// FunctionResolver returns the function a file calls by name, or an error
+// when the name is unknown or the function is not allowed.
+type FunctionResolver func(name string) (Function, error)
+
+// callResult is the value returned by a function call, which remembers the
+// call to explain a result which doesn't suit the field.
+type callResult struct {
+	parser.Value
+	name      string
+	result    ValueType
+	sensitive bool
+}
+
+func (cr callResult) mismatch(err error) error {
+	if cr.sensitive {
+		err = fmt.Errorf("%s() returns a sensitive %s which doesn't suit the field, the reason is redacted", cr.name, cr.result)
+		return errpos.WithCode(err, errpos.CodeSensitive)
+	}
+	err = fmt.Errorf("%s() returns %s: %w", cr.name, cr.result, err)
+	return errpos.WithCode(err, errpos.CodeTypeMismatch)
+}
+
+// callValue evaluates the value when it is a function call, returning other
+// values unchanged.
+func (wc *walkContext) callValue(val parser.ASTValue) (parser.ASTValue, error) {
+	value, ok := val.(parser.Value)
+	if !ok {
+		return val, nil
+	}
+	call, ok := value.Call()
+	if !ok {
+		return val, nil
+	}
+	return wc.evalCall(call)
+}
+
+func (wc *walkContext) evalCall(call *parser.Call) (callResult, error) {
+	name := call.Name.String()
+	callErr := func(pos HasPosition, code errpos.Code, format string, args ...interface{}) error {
+		return wc.WrapErr(errpos.WithCode(fmt.Errorf(format, args...), code), pos)
+	}
+
+	if wc.functions == nil {
+		return callResult{}, callErr(call.Name, errpos.CodeFunction, "unknown function %s", name)
+	}
+	fn, err := wc.functions(name)
+	if err != nil {
+		return callResult{}, callErr(call.Name, errpos.CodeFunction, "%s", err)
+	}
+	if len(call.Args) != len(fn.Params) {
+		return callResult{}, callErr(call, errpos.CodeFunction, "%s() takes %d arguments, got %d", name, len(fn.Params), len(call.Args))
+	}
+
+	sensitiveArgs := false
+	args := make([]interface{}, len(call.Args))
+	for idx, arg := range call.Args {
+		var argValue parser.ASTValue = arg
+		argSensitive := false
+		if inner, ok := arg.Call(); ok {
+			result, err := wc.evalCall(inner)
+			if err != nil {
+				return callResult{}, err
+			}
+			argValue = result
+			argSensitive = result.sensitive
+		} else if arg.IsArray() {
+			return callResult{}, callErr(arg, errpos.CodeTypeMismatch, "argument %d of %s() is an array, want %s", idx+1, name, fn.Params[idx])
+		}
+		args[idx], err = fn.Params[idx].goValue(argValue)
+		if err != nil && argSensitive {
+			return callResult{}, callErr(arg, errpos.CodeSensitive, "argument %d of %s() is sensitive and not a %s, the value is redacted", idx+1, name, fn.Params[idx])
+		} else if err != nil {
+			return callResult{}, callErr(arg, errpos.CodeTypeMismatch, "argument %d of %s(): %s", idx+1, name, err)
+		}
+		sensitiveArgs = sensitiveArgs || argSensitive
+	}
+
+	sensitive := fn.Sensitive || sensitiveArgs
+	if sensitive {
+		wc.Log("call", call, "Call %s(...), sensitive", name)
+	} else {
+		wc.Log("call", call, "Call %s(%v)", name, args)
+	}
+	result, err := fn.Call(args)
+	if err != nil && sensitiveArgs {
+		// The error of a function given a sensitive argument may quote it.
+		return callResult{}, callErr(call, errpos.CodeFunction, "%s() failed on a sensitive argument, the reason is redacted", name)
+	} else if err != nil {
+		return callResult{}, callErr(call, errpos.CodeFunction, "%s(): %s", name, err)
+	}
+	value, ok := fn.Result.literal(result, call.SourceNode)
+	if !ok {
+		return callResult{}, newSchemaError(fmt.Errorf("function %s returned %T, not %s", name, result, fn.Result))
+	}
+	return callResult{
+		Value:     value,
+		name:      name,
+		result:    fn.Result,
+		sensitive: sensitive,
+	}, nil
+}
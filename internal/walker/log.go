@@ -0,0 +1,91 @@
+package walker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// LogEntry is a debug event from walking a file.
+type LogEntry struct {
+	// Event names the kind of event, e.g. "assign" or "enter_block", for
+	// filtering.
+	Event   string
+	Message string
+
+	// File is the file being walked, empty when the tree wasn't read from a
+	// file.
+	File string
+
+	// Path is the dotted path of blocks to the statement.
+	Path string
+
+	// Position is the statement or value the event is about, when there is
+	// one.
+	Position *errpos.Position
+}
+
+// Logger receives the debug events of a walk.
+type Logger interface {
+	Log(entry LogEntry)
+}
+
+// Log sends a debug event to the logger, if there is one. The message is only
+// formatted when it is sent.
+func (wc *walkContext) Log(event string, pos HasPosition, format string, args ...interface{}) {
+	if wc.logger == nil {
+		return
+	}
+	entry := LogEntry{
+		Event:   event,
+		Message: fmt.Sprintf(format, args...),
+		File:    wc.filename,
+		Path:    strings.Join(wc.path, "."),
+	}
+	if pos != nil {
+		position := pos.Position()
+		entry.Position = &position
+	}
+	wc.logger.Log(entry)
+}
+
+// logScope logs the blocks available in the scope, as one multi-line event.
+func (wc *walkContext) logScope(event string, describe func(logf func(string, ...interface{}))) {
+	if wc.logger == nil {
+		return
+	}
+	lines := []string{}
+	describe(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	wc.Log(event, nil, "%s", strings.Join(lines, "\n"))
+}
+
+// logError logs the error which ended the walk, with the scope it was in.
+func (wc *walkContext) logError(err error) {
+	scoped := &scopedError{}
+	if !errors.As(err, &scoped) {
+		wc.Log("walk_error", nil, "%s", err)
+		return
+	}
+	var pos HasPosition
+	if scoped.err.Pos != nil {
+		pos = scoped.err.Pos
+	}
+	wc.Log("walk_error", pos, "%s", scoped.err.Err)
+	wc.logScope("walk_error_scope", scoped.schema.PrintScope)
+}
+
+// attributeName is the dotted name of the attribute a statement sets.
+func attributeName(path schema.PathSpec, ref []parser.Ident) string {
+	elements := combinePath(path, ref)
+	names := make([]string, len(elements))
+	for idx, element := range elements {
+		names[idx] = element.name
+	}
+	return strings.Join(names, ".")
+}
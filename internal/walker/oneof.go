@@ -0,0 +1,65 @@
+package walker
+
+import (
+	"errors"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// OneofPolicy is how a member of a oneof set in a block which already set
+// another member is walked.
+type OneofPolicy int
+
+const (
+	// OneofError reports the conflict as an error.
+	OneofError OneofPolicy = iota
+
+	// OneofFirst keeps the member set first, skipping the statement which
+	// sets the other, with a warning.
+	OneofFirst
+
+	// OneofLast replaces the member set first, with a warning.
+	OneofLast
+)
+
+// resolveOneof applies the policy to an error from a statement, returning
+// true when the statement should be walked again, after the member set first
+// was cleared. Other errors are returned as they are.
+func (wc *walkContext) resolveOneof(err error) (bool, error) {
+	conflict := &schema.OneofConflictError{}
+	if !errors.As(err, &conflict) {
+		return false, err
+	}
+	if conflict.OtherPos != nil && conflict.OtherPos.Filename == nil && wc.filename != "" {
+		filename := wc.filename
+		conflict.OtherPos.Filename = &filename
+	}
+
+	switch wc.oneofs {
+	case OneofFirst:
+		wc.warn(err)
+		return false, nil
+	case OneofLast:
+		wc.warn(err)
+		conflict.ClearOther()
+		return true, nil
+	}
+	return false, err
+}
+
+func (wc *walkContext) warn(err error) {
+	if wc.warnings == nil {
+		return
+	}
+	warning, ok := errpos.AsError(err)
+	if !ok {
+		warning = &errpos.Err{Err: err}
+	}
+	warning.Severity = errpos.SeverityWarning
+	if warning.Pos != nil && warning.Pos.Filename == nil && wc.filename != "" {
+		filename := wc.filename
+		warning.Pos.Filename = &filename
+	}
+	*wc.warnings = append(*wc.warnings, warning)
+}
@@ -0,0 +1,80 @@
+package walker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// blockFrame is a block the walk has entered, for the recursion limit.
+type blockFrame struct {
+	schema string
+	pos    errpos.Position
+}
+
+// RecursionError is a block nested in more blocks of its own schema than
+// WalkOptions.MaxRecursion allows.
+type RecursionError struct {
+	Schema string
+	Limit  int
+
+	// Chain is where each block of the schema was opened, outermost first,
+	// ending with the block over the limit.
+	Chain []errpos.Position
+}
+
+func (e *RecursionError) Error() string {
+	chain := make([]string, len(e.Chain))
+	for idx, pos := range e.Chain {
+		chain[idx] = pos.String()
+	}
+	return fmt.Sprintf("%s nested %d deep, over the limit of %d: %s", e.Schema, len(e.Chain), e.Limit, strings.Join(chain, " > "))
+}
+
+func (e *RecursionError) ErrorCode() errpos.Code {
+	return errpos.CodeMaxRecursion
+}
+
+// withBlock enters the scope of a block opened at pos, as WithScope, once the
+// block is within the recursion limit.
+func (wc *walkContext) withBlock(newScope *schema.Scope, pos errpos.Position, fn SpanCallback) error {
+	block := &blockFrame{
+		schema: newScope.SchemaName(),
+		pos:    pos,
+	}
+	if err := wc.checkRecursion(block); err != nil {
+		return err
+	}
+	return wc.withSchema(newScope, block, fn)
+}
+
+func (wc *walkContext) checkRecursion(block *blockFrame) error {
+	if wc.maxRecursion <= 0 {
+		return nil
+	}
+	chain := []errpos.Position{block.pos}
+	for ctx := wc; ctx != nil; ctx = ctx.parent {
+		if ctx.block != nil && ctx.block.schema == block.schema {
+			chain = append(chain, ctx.block.pos)
+		}
+	}
+	if len(chain) <= wc.maxRecursion {
+		return nil
+	}
+	slices.Reverse(chain)
+	if wc.filename != "" {
+		filename := wc.filename
+		for idx := range chain {
+			chain[idx].Filename = &filename
+		}
+	}
+	err := &RecursionError{
+		Schema: block.schema,
+		Limit:  wc.maxRecursion,
+		Chain:  chain,
+	}
+	return errpos.AddPosition(err, block.pos)
+}
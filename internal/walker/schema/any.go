@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5/lib/j5reflect"
+	"github.com/pentops/j5/lib/j5schema"
+)
+
+// AnyField is a google.protobuf.Any field, set by packing a message which is
+// walked as the root of its own scope.
+type AnyField struct {
+	j5reflect.AnyField
+	scope    *Scope
+	location *bcl_j5pb.SourceLocation
+}
+
+// AnyField returns the Any field with the name, when the name is one. Only
+// fields of the block itself are found, not those at the end of an alias
+// path.
+func (sw *Scope) AnyField(name string, source SourceLocation) (*AnyField, bool, *WalkPathError) {
+	root, spec, ok := sw.findBlock(name)
+	if !ok || len(spec.Path) != 1 {
+		return nil, false, nil
+	}
+	prop, err := root.container.GetProperty(spec.Path[0])
+	if err != nil {
+		return nil, false, nil
+	}
+	if _, ok := prop.Schema().Schema.(*j5schema.AnyField); !ok {
+		return nil, false, nil
+	}
+
+	value, err := root.newValue(spec.Path[0], source)
+	if err != nil {
+		return nil, true, &WalkPathError{
+			Type: UnknownPathError,
+			Err:  errpos.WithCode(err, errpos.CodeAlreadySet),
+		}
+	}
+	sw.counters.addValues(1)
+
+	wrapped, ok := value.(*field)
+	if !ok {
+		return nil, true, unexpectedPathError(name, fmt.Errorf("%s is a %T, not a field", name, value))
+	}
+	anyField, ok := wrapped.Field.(j5reflect.AnyField)
+	if !ok {
+		return nil, true, unexpectedPathError(name, fmt.Errorf("%s is not an Any field", name))
+	}
+	return &AnyField{
+		AnyField: anyField,
+		scope:    sw,
+		location: wrapped.location,
+	}, true, nil
+}
+
+// Root returns a scope for the message packed into the field, with source
+// locations recorded under the field.
+func (af *AnyField) Root(obj j5reflect.Object) (*Scope, error) {
+	root, err := af.scope.schemaSet.wrapContainer(obj, []string{}, af.location)
+	if err != nil {
+		return nil, err
+	}
+	root.isRoot = true
+	root.oneofs = af.scope.leafBlock.oneofs
+	return &Scope{
+		schemaSet:   af.scope.schemaSet,
+		blockSet:    newContainerSet(root),
+		leafBlock:   root,
+		rootBlock:   root,
+		counters:    af.scope.counters,
+		normalizers: af.scope.normalizers,
+		assigned:    af.scope.assigned,
+	}, nil
+}
@@ -38,6 +38,10 @@ type Tag struct {
 	BangFieldName     *string
 	QuestionFieldName *string
 
+	// ScopeSeparator, when set, prefixes the value with the name of the
+	// nearest enclosing named block and the separator.
+	ScopeSeparator *string
+
 	IsOptional bool
 	IsBlock    bool
 }
@@ -56,6 +60,9 @@ func convertTag(tag *bcl_j5pb.Tag) *Tag {
 	if tag.QuestionBool != nil {
 		tt.QuestionFieldName = tag.QuestionBool
 	}
+	if tag.ScopeSeparator != nil {
+		tt.ScopeSeparator = tag.ScopeSeparator
+	}
 	tt.IsOptional = tag.Optional
 	return tt
 }
@@ -74,6 +81,23 @@ func (t *Tag) Validate(tagType TagType) error {
 	return nil
 }
 
+// TypeAttribute selects the type of a block, as a TypeSelect tag does, from
+// the string value of the attribute named Attribute in the body.
+type TypeAttribute struct {
+	Attribute string
+	FieldName string
+}
+
+func convertTypeAttribute(src *bcl_j5pb.TypeAttribute) *TypeAttribute {
+	if src == nil {
+		return nil
+	}
+	return &TypeAttribute{
+		Attribute: src.Attribute,
+		FieldName: src.FieldName,
+	}
+}
+
 type ChildSpec struct {
 	Path PathSpec
 	//IsContainer  bool
@@ -120,9 +144,17 @@ type BlockSpec struct {
 
 	Aliases map[string]PathSpec
 
+	// DeprecatedAliases are the aliases which walk with a warning and a fix
+	// to write their path instead
+	DeprecatedAliases map[string]bool
+
 	Name       *Tag
 	TypeSelect *Tag
 
+	// TypeAttribute selects the type from an attribute of the body rather
+	// than a tag, exclusive with TypeSelect
+	TypeAttribute *TypeAttribute
+
 	Qualifier *Tag // A qualifier maps to a new child block at this field
 
 	// A list of paths to include when searching for blocks
@@ -135,6 +167,63 @@ type BlockSpec struct {
 	RunAfter BlockHook
 
 	ScalarSplit *ScalarSplit
+
+	// Constraints on the scalar fields of the block, by field name
+	Constraints map[string]*Constraint
+
+	// Normalizers rewrite the string values of scalar fields before they are
+	// set, by field name. Each step names a built-in normalizer or one
+	// registered with the parser, and runs in order.
+	Normalizers map[string][]string
+
+	// Boolean fields which can be set as a bare statement, by field name
+	Flags map[string]bool
+
+	// Unordered blocks don't depend on the order of their attributes, so the
+	// formatter may sort them.
+	Unordered bool
+
+	// Policies are checked against each message of the schema once the file
+	// is parsed and validated.
+	Policies []Policy
+
+	// Identity is the fields, by JSON name, which identify a block of the
+	// schema. Blocks with the same values are reported as duplicates.
+	Identity []string
+
+	// Prose is the string fields, by JSON name, which hold text for people,
+	// checked for spelling as descriptions are.
+	Prose []string
+
+	// Derived fields are set from the other fields of each message of the
+	// schema once the file is walked.
+	Derived []Derived
+}
+
+// Derived sets a field from a CEL expression, which the parser compiles
+// against the message type as it does policies.
+type Derived struct {
+	Field      string
+	Expression string
+}
+
+// Policy is a CEL expression which must hold for a message. The schema only
+// carries the source, the parser compiles it against the message type.
+type Policy struct {
+	Expression string
+	Message    string
+}
+
+// FlagName returns the field set by a flag statement, and the value to set,
+// for 'cache' and 'no-cache' style flags.
+func (bs BlockSpec) FlagName(name string) (string, bool, bool) {
+	if bs.Flags[name] {
+		return name, true, true
+	}
+	if field, ok := strings.CutPrefix(name, "no-"); ok && bs.Flags[field] {
+		return field, false, true
+	}
+	return "", false, false
 }
 
 type ScalarSplit struct {
@@ -175,6 +264,15 @@ func (bs *BlockSpec) Validate() error {
 		}
 	}
 
+	if bs.TypeAttribute != nil {
+		if bs.TypeSelect != nil {
+			return fmt.Errorf("typeAttribute: the type is already selected by a tag")
+		}
+		if bs.TypeAttribute.Attribute == "" {
+			return fmt.Errorf("typeAttribute: no attribute")
+		}
+	}
+
 	if bs.Qualifier != nil {
 		err := bs.Qualifier.Validate(TagTypeQualifier)
 		if err != nil {
@@ -0,0 +1,271 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/pentops/j5/lib/j5reflect"
+	"github.com/pentops/j5/lib/j5schema"
+)
+
+// compiledMagic prefixes the binary form of a SchemaSet, the final byte is the
+// format version.
+var compiledMagic = []byte("bclschema\x01")
+
+// specNode is the part of a container needed to build its BlockSpec, which is
+// available from both the reflection tree and the schema alone.
+type specNode interface {
+	SchemaName() string
+	RangePropertySchemas(j5reflect.RangePropertySchemasCallback) error
+}
+
+type propertySchema interface {
+	FullName() string
+	ClientProperties() []*j5schema.ObjectProperty
+}
+
+// schemaNode builds specs from a schema, before there is any value to reflect.
+type schemaNode struct {
+	schema propertySchema
+}
+
+func (sn schemaNode) SchemaName() string {
+	return sn.schema.FullName()
+}
+
+func (sn schemaNode) RangePropertySchemas(callback j5reflect.RangePropertySchemasCallback) error {
+	for _, prop := range sn.schema.ClientProperties() {
+		if err := callback(prop.JSONName, prop.Required, prop.Schema.ToJ5Field()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Precompile builds the BlockSpec of every object and oneof reachable from the
+// roots, so that walking a file does not need to derive any.
+func (ss *SchemaSet) Precompile(roots ...j5schema.RootSchema) error {
+	visited := map[string]bool{}
+	var walk func(propertySchema) error
+	walk = func(node propertySchema) error {
+		if visited[node.FullName()] {
+			return nil
+		}
+		visited[node.FullName()] = true
+
+		if _, err := ss.blockSpec(schemaNode{schema: node}); err != nil {
+			return err
+		}
+
+		for _, prop := range node.ClientProperties() {
+			child := childPropertySchema(prop.Schema)
+			if child == nil {
+				continue
+			}
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, root := range roots {
+		node, ok := root.(propertySchema)
+		if !ok {
+			return fmt.Errorf("root %s is not an object or oneof", root.FullName())
+		}
+		if err := walk(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func childPropertySchema(field j5schema.FieldSchema) propertySchema {
+	switch field := field.(type) {
+	case *j5schema.ObjectField:
+		if field.Ref == nil || field.Ref.To == nil {
+			return nil
+		}
+		return field.Schema()
+	case *j5schema.OneofField:
+		if field.Ref == nil || field.Ref.To == nil {
+			return nil
+		}
+		return field.Schema()
+	case *j5schema.ArrayField:
+		return childPropertySchema(field.Schema)
+	case *j5schema.MapField:
+		return childPropertySchema(field.Schema)
+	}
+	return nil
+}
+
+// compiledSet is the encoded form of a SchemaSet.
+type compiledSet struct {
+	Given  map[string]compiledSpec
+	Cached map[string]compiledSpec
+}
+
+// compiledSpec is the encoded form of a BlockSpec, including the fields which
+// are set by the parser.
+type compiledSpec struct {
+	DebugName     string
+	Source        string
+	Schema        string
+	Description   *string
+	Aliases       map[string][]string
+	Deprecated    map[string]bool
+	Name          *Tag
+	TypeSelect    *Tag
+	TypeAttribute *TypeAttribute
+	Qualifier     *Tag
+	OnlyDefined   bool
+	ScalarSplit   *ScalarSplit
+	Constraints   map[string]*Constraint
+	Normalizers   map[string][]string
+	Flags         map[string]bool
+	Unordered     bool
+	Policies      []Policy
+	Identity      []string
+	Prose         []string
+	Derived       []Derived
+}
+
+func compileSpec(name string, spec *BlockSpec) (compiledSpec, error) {
+	if spec.RunAfter != nil {
+		return compiledSpec{}, fmt.Errorf("block spec for %s has a RunAfter hook, which can't be compiled", name)
+	}
+	aliases := make(map[string][]string, len(spec.Aliases))
+	for alias, path := range spec.Aliases {
+		aliases[alias] = path
+	}
+	return compiledSpec{
+		DebugName:     spec.DebugName,
+		Source:        string(spec.source),
+		Schema:        spec.schema,
+		Description:   spec.Description,
+		Aliases:       aliases,
+		Deprecated:    spec.DeprecatedAliases,
+		Name:          spec.Name,
+		TypeSelect:    spec.TypeSelect,
+		TypeAttribute: spec.TypeAttribute,
+		Qualifier:     spec.Qualifier,
+		OnlyDefined:   spec.OnlyDefined,
+		ScalarSplit:   spec.ScalarSplit,
+		Constraints:   spec.Constraints,
+		Normalizers:   spec.Normalizers,
+		Flags:         spec.Flags,
+		Unordered:     spec.Unordered,
+		Policies:      spec.Policies,
+		Identity:      spec.Identity,
+		Prose:         spec.Prose,
+		Derived:       spec.Derived,
+	}, nil
+}
+
+func (cs compiledSpec) blockSpec() (*BlockSpec, error) {
+	var aliases map[string]PathSpec
+	if cs.Aliases != nil {
+		aliases = make(map[string]PathSpec, len(cs.Aliases))
+		for alias, path := range cs.Aliases {
+			aliases[alias] = path
+		}
+	}
+	spec := &BlockSpec{
+		DebugName:     cs.DebugName,
+		source:        specSource(cs.Source),
+		schema:        cs.Schema,
+		Description:   cs.Description,
+		Aliases:       aliases,
+		Name:          cs.Name,
+		TypeSelect:    cs.TypeSelect,
+		TypeAttribute: cs.TypeAttribute,
+		Qualifier:     cs.Qualifier,
+		OnlyDefined:   cs.OnlyDefined,
+		ScalarSplit:   cs.ScalarSplit,
+		Constraints:   cs.Constraints,
+		Normalizers:   cs.Normalizers,
+		Flags:         cs.Flags,
+		Unordered:     cs.Unordered,
+		Policies:      cs.Policies,
+		Identity:      cs.Identity,
+		Prose:         cs.Prose,
+		Derived:       cs.Derived,
+
+		DeprecatedAliases: cs.Deprecated,
+	}
+	for name, constraint := range cs.Constraints {
+		if err := constraint.compile(); err != nil {
+			return nil, fmt.Errorf("constraint on %s.%s: %w", cs.Schema, name, err)
+		}
+	}
+	return spec, nil
+}
+
+// MarshalBinary encodes the given and derived block specs, see Precompile.
+func (ss *SchemaSet) MarshalBinary() ([]byte, error) {
+	set := compiledSet{
+		Given:  make(map[string]compiledSpec, len(ss.givenSpecs)),
+		Cached: make(map[string]compiledSpec, len(ss.cachedSpecs)),
+	}
+	for name, spec := range ss.givenSpecs {
+		compiled, err := compileSpec(name, spec)
+		if err != nil {
+			return nil, err
+		}
+		set.Given[name] = compiled
+	}
+	for name, spec := range ss.cachedSpecs {
+		compiled, err := compileSpec(name, spec)
+		if err != nil {
+			return nil, err
+		}
+		set.Cached[name] = compiled
+	}
+
+	buf := &bytes.Buffer{}
+	buf.Write(compiledMagic)
+	if err := gob.NewEncoder(buf).Encode(set); err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the specs in the set with those encoded by
+// MarshalBinary.
+func (ss *SchemaSet) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, compiledMagic) {
+		return fmt.Errorf("not a compiled schema, or an unsupported version")
+	}
+
+	set := compiledSet{}
+	if err := gob.NewDecoder(bytes.NewReader(data[len(compiledMagic):])).Decode(&set); err != nil {
+		return fmt.Errorf("decoding schema: %w", err)
+	}
+
+	// Derived specs for given blocks are the same spec, updated in place, so
+	// keep them as one pointer.
+	ss.cachedSpecs = make(map[string]*BlockSpec, len(set.Cached))
+	for name, compiled := range set.Cached {
+		spec, err := compiled.blockSpec()
+		if err != nil {
+			return err
+		}
+		ss.cachedSpecs[name] = spec
+	}
+	ss.givenSpecs = make(map[string]*BlockSpec, len(set.Given))
+	for name, compiled := range set.Given {
+		if cached, ok := ss.cachedSpecs[name]; ok {
+			ss.givenSpecs[name] = cached
+			continue
+		}
+		spec, err := compiled.blockSpec()
+		if err != nil {
+			return err
+		}
+		ss.givenSpecs[name] = spec
+	}
+	return nil
+}
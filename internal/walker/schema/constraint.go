@@ -0,0 +1,322 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/proto"
+)
+
+// Constraint restricts the values assigned to a scalar field, checked as each
+// value is set. Each check applies only to values of its type, values of the
+// wrong type fail when they are set.
+type Constraint struct {
+	Pattern      *string
+	Min          *float64
+	Max          *float64
+	ExclusiveMin bool
+	ExclusiveMax bool
+	MinLength    *uint64
+	MaxLength    *uint64
+	Allowed      []string
+
+	// Code is reported for violations, defaulting to errpos.CodeConstraint.
+	Code errpos.Code
+
+	// Rules are the validation rules of the field, when the block spec also
+	// constrains it, checked after the spec's constraint.
+	Rules *Constraint
+
+	pattern *regexp.Regexp // compiled from Pattern, by compile
+}
+
+func convertConstraint(src *bcl_j5pb.Constraint) *Constraint {
+	return &Constraint{
+		Pattern:   src.Pattern,
+		Min:       src.Min,
+		Max:       src.Max,
+		MinLength: src.MinLength,
+		MaxLength: src.MaxLength,
+		Allowed:   src.Allowed,
+	}
+}
+
+func (c *Constraint) compile() error {
+	if c.Rules != nil {
+		if err := c.Rules.compile(); err != nil {
+			return fmt.Errorf("rules: %w", err)
+		}
+	}
+	if c.Pattern == nil {
+		return nil
+	}
+	re, err := regexp.Compile(*c.Pattern)
+	if err != nil {
+		return fmt.Errorf("pattern: %w", err)
+	}
+	c.pattern = re
+	return nil
+}
+
+// ruleConstraint converts the validation rules of a j5 field, which j5 reads
+// from the buf.validate annotations, so they are checked as values are set
+// rather than after the parse. Returns nil for fields without rules.
+func ruleConstraint(field *schema_j5pb.Field) *Constraint {
+	constraint := &Constraint{
+		Code: errpos.CodeValidation,
+	}
+	switch ft := field.Type.(type) {
+	case *schema_j5pb.Field_String_:
+		rules := ft.String_.GetRules()
+		if rules == nil {
+			return nil
+		}
+		constraint.Pattern = rules.Pattern
+		constraint.MinLength = rules.MinLength
+		constraint.MaxLength = rules.MaxLength
+
+	case *schema_j5pb.Field_Integer:
+		rules := ft.Integer.GetRules()
+		if rules == nil {
+			return nil
+		}
+		if rules.Minimum != nil {
+			constraint.Min = proto.Float64(float64(*rules.Minimum))
+			constraint.ExclusiveMin = rules.GetExclusiveMinimum()
+		}
+		if rules.Maximum != nil {
+			constraint.Max = proto.Float64(float64(*rules.Maximum))
+			constraint.ExclusiveMax = rules.GetExclusiveMaximum()
+		}
+
+	case *schema_j5pb.Field_Float:
+		rules := ft.Float.GetRules()
+		if rules == nil {
+			return nil
+		}
+		constraint.Min = rules.Minimum
+		constraint.ExclusiveMin = rules.GetExclusiveMinimum()
+		constraint.Max = rules.Maximum
+		constraint.ExclusiveMax = rules.GetExclusiveMaximum()
+
+	case *schema_j5pb.Field_Array:
+		if items := ft.Array.GetItems(); items != nil {
+			return ruleConstraint(items)
+		}
+		return nil
+
+	default:
+		return nil
+	}
+
+	if constraint.Pattern == nil && constraint.MinLength == nil && constraint.MaxLength == nil &&
+		constraint.Min == nil && constraint.Max == nil {
+		return nil
+	}
+	return constraint
+}
+
+// ConstraintError is a value which violates a Constraint.
+type ConstraintError struct {
+	Value   string
+	Message string
+	Code    errpos.Code
+}
+
+func (ce *ConstraintError) Error() string {
+	return fmt.Sprintf("value %s %s", ce.Value, ce.Message)
+}
+
+func (ce *ConstraintError) ErrorCode() errpos.Code {
+	if ce.Code != "" {
+		return ce.Code
+	}
+	return errpos.CodeConstraint
+}
+
+// Check returns a ConstraintError if the value violates the constraint.
+func (c *Constraint) Check(val j5reflect.ASTValue) error {
+	err := c.check(val)
+	if err != nil {
+		err.Code = c.Code
+		return err
+	}
+	if c.Rules != nil {
+		return c.Rules.Check(val)
+	}
+	return nil
+}
+
+func (c *Constraint) check(val j5reflect.ASTValue) *ConstraintError {
+	if str, err := val.AsString(); err == nil {
+		quoted := strconv.Quote(str)
+		length := uint64(utf8.RuneCountInString(str))
+		if c.MinLength != nil && length < *c.MinLength {
+			return &ConstraintError{Value: quoted, Message: fmt.Sprintf("is shorter than %d characters", *c.MinLength)}
+		}
+		if c.MaxLength != nil && length > *c.MaxLength {
+			return &ConstraintError{Value: quoted, Message: fmt.Sprintf("is longer than %d characters", *c.MaxLength)}
+		}
+		if c.pattern != nil && !c.pattern.MatchString(str) {
+			return &ConstraintError{Value: quoted, Message: fmt.Sprintf("does not match %q", *c.Pattern)}
+		}
+		return c.checkAllowed(str, quoted)
+	}
+
+	if num, err := val.AsFloat(64); err == nil {
+		str := strconv.FormatFloat(num, 'g', -1, 64)
+		if c.Min != nil {
+			if c.ExclusiveMin && num <= *c.Min {
+				return &ConstraintError{Value: str, Message: fmt.Sprintf("is not greater than %g", *c.Min)}
+			} else if num < *c.Min {
+				return &ConstraintError{Value: str, Message: fmt.Sprintf("is less than %g", *c.Min)}
+			}
+		}
+		if c.Max != nil {
+			if c.ExclusiveMax && num >= *c.Max {
+				return &ConstraintError{Value: str, Message: fmt.Sprintf("is not less than %g", *c.Max)}
+			} else if num > *c.Max {
+				return &ConstraintError{Value: str, Message: fmt.Sprintf("is greater than %g", *c.Max)}
+			}
+		}
+		return c.checkAllowed(str, str)
+	}
+
+	if b, err := val.AsBool(); err == nil {
+		str := strconv.FormatBool(b)
+		return c.checkAllowed(str, str)
+	}
+
+	return nil
+}
+
+func (c *Constraint) checkAllowed(str, display string) *ConstraintError {
+	if len(c.Allowed) == 0 {
+		return nil
+	}
+	for _, allowed := range c.Allowed {
+		if allowed == str {
+			return nil
+		}
+	}
+	return &ConstraintError{Value: display, Message: fmt.Sprintf("is not one of %v", c.Allowed)}
+}
+
+// constrainedField checks the constraint before values are set on the scalar
+// or array of scalars.
+type constrainedField struct {
+	Field
+	constraint *Constraint
+}
+
+func withConstraint(field Field, constraint *Constraint) Field {
+	if constraint == nil {
+		return field
+	}
+	return &constrainedField{
+		Field:      field,
+		constraint: constraint,
+	}
+}
+
+func (cf *constrainedField) AsScalar() (j5reflect.ScalarField, bool) {
+	scalar, ok := cf.Field.AsScalar()
+	if !ok {
+		return nil, false
+	}
+	return &constrainedScalar{ScalarField: scalar, constraint: cf.constraint}, true
+}
+
+func (cf *constrainedField) AsArrayOfScalar() (j5reflect.ArrayOfScalarField, bool) {
+	array, ok := cf.Field.AsArrayOfScalar()
+	if !ok {
+		return nil, false
+	}
+	return &constrainedArray{ArrayOfScalarField: array, constraint: cf.constraint}, true
+}
+
+type constrainedScalar struct {
+	j5reflect.ScalarField
+	constraint *Constraint
+}
+
+func (cs *constrainedScalar) SetASTValue(val j5reflect.ASTValue) error {
+	if err := cs.constraint.Check(val); err != nil {
+		return err
+	}
+	return cs.ScalarField.SetASTValue(val)
+}
+
+type constrainedArray struct {
+	j5reflect.ArrayOfScalarField
+	constraint *Constraint
+}
+
+func (ca *constrainedArray) AppendASTValue(val j5reflect.ASTValue) (int, error) {
+	if err := ca.constraint.Check(val); err != nil {
+		return 0, err
+	}
+	return ca.ArrayOfScalarField.AppendASTValue(val)
+}
+
+func (ca *constrainedArray) appendSynthesized(val interface{}, pos SourceLocation) (int, error) {
+	if err := ca.constraint.Check(goValue{val: val}); err != nil {
+		return 0, err
+	}
+	return AppendSynthesized(ca.ArrayOfScalarField, val, pos)
+}
+
+// goValue is a Go scalar, such as a value read back from the message, to
+// check against a constraint. Kinds the constraints don't cover, such as
+// timestamps, are errors for every conversion, and so pass.
+type goValue struct {
+	val interface{}
+}
+
+func (gv goValue) AsString() (string, error) {
+	if str, ok := gv.val.(string); ok {
+		return str, nil
+	}
+	return "", fmt.Errorf("%T is not a string", gv.val)
+}
+
+func (gv goValue) AsBool() (bool, error) {
+	if b, ok := gv.val.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("%T is not a bool", gv.val)
+}
+
+func (gv goValue) AsFloat(bits int) (float64, error) {
+	switch num := gv.val.(type) {
+	case int32:
+		return float64(num), nil
+	case int64:
+		return float64(num), nil
+	case uint32:
+		return float64(num), nil
+	case uint64:
+		return float64(num), nil
+	case float32:
+		return float64(num), nil
+	case float64:
+		return num, nil
+	}
+	return 0, fmt.Errorf("%T is not a number", gv.val)
+}
+
+func (gv goValue) AsInt(bits int) (int64, error) {
+	num, err := gv.AsFloat(64)
+	return int64(num), err
+}
+
+func (gv goValue) AsUint(bits int) (uint64, error) {
+	num, err := gv.AsFloat(64)
+	return uint64(num), err
+}
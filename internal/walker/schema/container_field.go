@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pentops/bcl.go/bcl/errpos"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
 	"github.com/pentops/j5/lib/j5reflect"
 	"github.com/pentops/j5/lib/j5schema"
@@ -21,6 +22,10 @@ type containerField struct {
 	spec      BlockSpec
 	isRoot    bool
 	location  *bcl_j5pb.SourceLocation
+
+	// oneofs reports members of a oneof set over another, see
+	// Scope.CheckOneofs, nil when they are not checked
+	oneofs *oneofSet
 }
 
 type j5PropSet interface {
@@ -112,6 +117,9 @@ func (sc *containerField) SchemaName() string {
 	return sc.schemaName
 }
 func (sc *containerField) getOrSetValue(name string, hint SourceLocation) (Field, error) {
+	if err := sc.checkOneof(name, hint); err != nil {
+		return nil, err
+	}
 	val, err := sc.container.GetOrCreateValue(name)
 	if err != nil {
 		return nil, err
@@ -120,6 +128,9 @@ func (sc *containerField) getOrSetValue(name string, hint SourceLocation) (Field
 }
 
 func (sc *containerField) newValue(name string, hint SourceLocation) (Field, error) {
+	if err := sc.checkOneof(name, hint); err != nil {
+		return nil, err
+	}
 	val, err := sc.container.NewValue(name)
 	if err != nil {
 		return nil, err
@@ -131,28 +142,41 @@ func (sc *containerField) wrap(val j5reflect.Field, hint SourceLocation) (Field,
 	protoPath := val.ProtoPath()
 	location := sc.location
 	for _, elem := range protoPath {
-		location = childSourceLocation(location, elem, hint)
+		location = childSourceLocation(location, elem, hint, bcl_j5pb.Origin_ORIGIN_EXPLICIT)
 	}
 	ff := &field{
 		Field:    val,
 		location: location,
+		origin:   bcl_j5pb.Origin_ORIGIN_EXPLICIT,
 	}
 	return ff, nil
 }
 
-func childSourceLocation(in *bcl_j5pb.SourceLocation, name string, hint SourceLocation) *bcl_j5pb.SourceLocation {
+// childSourceLocation returns the named child of the location, creating it at
+// hint when it is new. A child first reached by the schema becomes explicit
+// when the file later sets it.
+func childSourceLocation(in *bcl_j5pb.SourceLocation, name string, hint SourceLocation, origin bcl_j5pb.Origin) *bcl_j5pb.SourceLocation {
+	if in == nil {
+		// location tracking is disabled
+		return nil
+	}
 	if in.Children == nil {
 		in.Children = map[string]*bcl_j5pb.SourceLocation{}
 	}
-	if _, ok := in.Children[name]; !ok {
-		in.Children[name] = &bcl_j5pb.SourceLocation{
+	child, ok := in.Children[name]
+	if !ok {
+		child = &bcl_j5pb.SourceLocation{
 			StartLine:   int32(hint.Start.Line),
 			StartColumn: int32(hint.Start.Column),
 			EndLine:     int32(hint.End.Line),
 			EndColumn:   int32(hint.End.Column),
+			Origin:      origin,
 		}
+		in.Children[name] = child
+	} else if origin == bcl_j5pb.Origin_ORIGIN_EXPLICIT {
+		child.Origin = origin
 	}
-	return in.Children[name]
+	return child
 }
 
 type PathErrorType int
@@ -183,6 +207,29 @@ func (wpe *WalkPathError) Error() string {
 	return wpe.LongMessage()
 }
 
+func (wpe *WalkPathError) ErrorCode() errpos.Code {
+	switch wpe.Type {
+	case NodeNotContainer:
+		return errpos.CodeNotContainer
+	case NodeNotScalar, NodeNotScalarArray:
+		return errpos.CodeTypeMismatch
+	case RootNotFound, NodeNotFound:
+		return errpos.CodeUnknownBlock
+	}
+	return errpos.GetErrorCode(wpe.Err)
+}
+
+// Is matches errpos.ErrNotScalar for fields which are not scalars. The
+// sentinels of error codes are matched by the errpos.Err wrapping it.
+func (wpe *WalkPathError) Is(target error) bool {
+	return target == errpos.ErrNotScalar && (wpe.Type == NodeNotScalar || wpe.Type == NodeNotScalarArray)
+}
+
+// Unwrap returns the cause of an UnknownPathError.
+func (wpe *WalkPathError) Unwrap() error {
+	return wpe.Err
+}
+
 func (wpe *WalkPathError) LongMessage() string {
 	switch wpe.Type {
 	case NodeNotContainer:
@@ -229,6 +276,9 @@ func (container *containerField) walkPath(path []string, loc SourceLocation) ([]
 		}
 	}
 
+	if err := container.checkOneof(name, loc); err != nil {
+		return nil, unexpectedPathError(name, err)
+	}
 	val, err := container.container.GetOrCreateValue(name)
 	if err != nil {
 		return nil, unexpectedPathError(name, err)
@@ -258,9 +308,15 @@ func (container *containerField) walkPath(path []string, loc SourceLocation) ([]
 		}
 	}
 
+	// Containers on the way to the end of the path are set by the schema, not
+	// named in the file.
+	origin := bcl_j5pb.Origin_ORIGIN_EXPLICIT
+	if len(resst) > 0 {
+		origin = bcl_j5pb.Origin_ORIGIN_SCHEMA
+	}
 	sourceLocation := container.location
 	for _, elem := range protoPath {
-		sourceLocation = childSourceLocation(sourceLocation, elem, loc)
+		sourceLocation = childSourceLocation(sourceLocation, elem, loc, origin)
 	}
 	childContainer := &containerField{
 		name:       name,
@@ -268,6 +324,7 @@ func (container *containerField) walkPath(path []string, loc SourceLocation) ([]
 		schemaName: fieldWithContainer.SchemaName(),
 		container:  fieldWithContainer,
 		location:   sourceLocation,
+		oneofs:     container.oneofs,
 	}
 
 	if len(resst) == 0 {
@@ -5,10 +5,64 @@ import (
 	"sort"
 
 	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
 	"golang.org/x/exp/maps"
 )
 
-type containerSet []containerField
+// containerSet is the list of containers in a scope, oldest first. It is
+// persistent: adding a container links to the existing set rather than copying
+// it, so child scopes share their parent's set.
+type containerSet struct {
+	last   *containerField
+	parent *containerSet
+	len    int
+}
+
+func newContainerSet(container *containerField) *containerSet {
+	return &containerSet{
+		last: container,
+		len:  1,
+	}
+}
+
+// with returns a new set with the container added at the end.
+func (bs *containerSet) with(container *containerField) *containerSet {
+	if bs == nil {
+		return newContainerSet(container)
+	}
+	return &containerSet{
+		last:   container,
+		parent: bs,
+		len:    bs.len + 1,
+	}
+}
+
+// concat returns a new set with all of the containers of other added at the
+// end.
+func (bs *containerSet) concat(other *containerSet) *containerSet {
+	if other == nil {
+		return bs
+	}
+	return bs.concat(other.parent).with(other.last)
+}
+
+// each calls fn for each container, oldest first, until fn returns false.
+func (bs *containerSet) each(fn func(*containerField) bool) bool {
+	if bs == nil {
+		return true
+	}
+	if !bs.parent.each(fn) {
+		return false
+	}
+	return fn(bs.last)
+}
+
+func (bs *containerSet) Len() int {
+	if bs == nil {
+		return 0
+	}
+	return bs.len
+}
 
 type Container interface {
 	Path() []string
@@ -16,17 +70,18 @@ type Container interface {
 	Name() string
 }
 
-func (bs containerSet) schemaNames() []string {
-	names := make([]string, 0, len(bs))
-	for _, block := range bs {
+func (bs *containerSet) schemaNames() []string {
+	names := make([]string, 0, bs.Len())
+	bs.each(func(block *containerField) bool {
 		names = append(names, block.schemaName)
-	}
+		return true
+	})
 	return names
 }
 
-func (bs containerSet) allChildFields() map[string]*schema_j5pb.Field {
+func (bs *containerSet) allChildFields() map[string]*schema_j5pb.Field {
 	children := map[string]*schema_j5pb.Field{}
-	for _, blockSchema := range bs {
+	bs.each(func(blockSchema *containerField) bool {
 		_ = blockSchema.container.RangePropertySchemas(func(name string, required bool, schema *schema_j5pb.Field) error {
 			if _, ok := children[name]; !ok {
 				children[name] = schema
@@ -42,18 +97,19 @@ func (bs containerSet) allChildFields() map[string]*schema_j5pb.Field {
 				children[name] = schema.ToJ5Field()
 			}
 		}
-	}
+		return true
+	})
 	return children
 }
 
-func (bs containerSet) listChildren() []string {
+func (bs *containerSet) listChildren() []string {
 	fields := bs.allChildFields()
 	fieldNames := maps.Keys(fields)
 	sort.Strings(fieldNames)
 	return fieldNames
 }
 
-func (bs containerSet) listAttributes() []string {
+func (bs *containerSet) listAttributes() []string {
 	fields := bs.allChildFields()
 	fieldNames := []string{}
 
@@ -67,7 +123,7 @@ func (bs containerSet) listAttributes() []string {
 	return fieldNames
 }
 
-func (bs containerSet) listBlocks() []string {
+func (bs *containerSet) listBlocks() []string {
 	fields := bs.allChildFields()
 	fieldNames := []string{}
 
@@ -123,3 +179,81 @@ func schemaCan(st schema_j5pb.IsField_Type) schemaFlags {
 		return schemaFlags{}
 	}
 }
+
+// ChildInfo describes a name which statements in a scope can use.
+type ChildInfo struct {
+	Name string
+
+	// Attribute is set for names assigned a value, name = value, and Block
+	// for names which open a block, name { ... }.
+	Attribute bool
+	Block     bool
+
+	// Type is the j5 type name, e.g. string or object(test.v1.Foo)
+	Type string
+
+	// Description is the doc of the field in the schema.
+	Description string
+
+	Required bool
+
+	// Constraint restricts the values of a scalar, nil when there is none.
+	Constraint *Constraint
+
+	// Flag is set for booleans which can be set by a bare statement.
+	Flag bool
+}
+
+// children describes every name in the set, sorted by name. Names found
+// first, in the innermost block, hide the same name further out.
+func (bs *containerSet) children() []ChildInfo {
+	children := map[string]ChildInfo{}
+	add := func(spec BlockSpec, name string, required bool, field *schema_j5pb.Field, fieldSchema j5schema.FieldSchema, description string) {
+		if _, ok := children[name]; ok {
+			return
+		}
+		can := schemaCan(field.GetType())
+		child := ChildInfo{
+			Name:        name,
+			Attribute:   can.canAttribute,
+			Block:       can.canBlock,
+			Description: description,
+			Required:    required,
+			Constraint:  spec.Constraints[name],
+			Flag:        spec.Flags[name],
+		}
+		if fieldSchema != nil {
+			child.Type = fieldSchema.TypeName()
+		}
+		children[name] = child
+	}
+
+	bs.each(func(blockSchema *containerField) bool {
+		containerSchema := blockSchema.container.ContainerSchema()
+		props, _ := containerSchema.(j5schema.PropertySet)
+		_ = blockSchema.container.RangePropertySchemas(func(name string, required bool, field *schema_j5pb.Field) error {
+			description := ""
+			if prop := props.ByJSONName(name); prop != nil {
+				description = prop.Description
+			}
+			add(blockSchema.spec, name, required, field, containerSchema.PropertyField(name), description)
+			return nil
+		})
+		for name, path := range blockSchema.spec.Aliases {
+			fieldSchema, err := containerSchema.WalkToProperty(path...)
+			if err != nil {
+				continue
+			}
+			add(blockSchema.spec, name, false, fieldSchema.ToJ5Field(), fieldSchema, "")
+		}
+		return true
+	})
+
+	names := maps.Keys(children)
+	sort.Strings(names)
+	infos := make([]ChildInfo, len(names))
+	for idx, name := range names {
+		infos[idx] = children[name]
+	}
+	return infos
+}
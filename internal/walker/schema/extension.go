@@ -0,0 +1,215 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ExtensionField is a protobuf extension of the message of a block. The j5
+// schema of the message doesn't include extensions, so the field is set on
+// the message directly.
+type ExtensionField struct {
+	Type     protoreflect.ExtensionType
+	msg      protoreflect.Message
+	scope    *Scope
+	location *bcl_j5pb.SourceLocation
+}
+
+// BlockMessage returns the message of the innermost block, false when the
+// block is not a message, e.g. a map.
+func (sw *Scope) BlockMessage() (protoreflect.Message, bool) {
+	reflected, ok := sw.leafBlock.container.(interface {
+		ProtoReflect() protoreflect.Message
+	})
+	if !ok {
+		return nil, false
+	}
+	msg := reflected.ProtoReflect()
+	return msg, msg != nil
+}
+
+// ExtensionField returns the extension of msg, the BlockMessage of the scope,
+// with its source location keyed by the bracketed full name of the extension,
+// as in protojson.
+func (sw *Scope) ExtensionField(msg protoreflect.Message, xt protoreflect.ExtensionType, source SourceLocation) *ExtensionField {
+	name := "[" + string(xt.TypeDescriptor().FullName()) + "]"
+	return &ExtensionField{
+		Type:     xt,
+		msg:      msg,
+		scope:    sw,
+		location: childSourceLocation(sw.leafBlock.location, name, source, bcl_j5pb.Origin_ORIGIN_EXPLICIT),
+	}
+}
+
+func (ef *ExtensionField) desc() protoreflect.ExtensionTypeDescriptor {
+	return ef.Type.TypeDescriptor()
+}
+
+// FullTypeName names the extension in errors.
+func (ef *ExtensionField) FullTypeName() string {
+	return string(ef.desc().FullName())
+}
+
+// IsMessage is true for extensions which are set with a block.
+func (ef *ExtensionField) IsMessage() bool {
+	return ef.desc().Message() != nil
+}
+
+// SetASTValue sets a singular scalar extension.
+func (ef *ExtensionField) SetASTValue(val j5reflect.ASTValue) error {
+	xd := ef.desc()
+	if xd.IsList() || ef.IsMessage() {
+		return errpos.WithCode(fmt.Errorf("extension %s is not a scalar", xd.FullName()), errpos.CodeTypeMismatch)
+	}
+	if ef.msg.Has(xd) {
+		return errpos.WithCode(fmt.Errorf("value already set"), errpos.CodeAlreadySet)
+	}
+	value, err := extensionScalar(xd, val)
+	if err != nil {
+		return err
+	}
+	ef.msg.Set(xd, value)
+	return nil
+}
+
+// AppendASTValue appends to a repeated scalar extension, returning the index
+// of the new element.
+func (ef *ExtensionField) AppendASTValue(val j5reflect.ASTValue) (int, error) {
+	xd := ef.desc()
+	if !xd.IsList() || ef.IsMessage() {
+		return 0, errpos.WithCode(fmt.Errorf("extension %s is not an array of scalars", xd.FullName()), errpos.CodeTypeMismatch)
+	}
+	value, err := extensionScalar(xd, val)
+	if err != nil {
+		return 0, err
+	}
+	list := ef.msg.Mutable(xd).List()
+	list.Append(value)
+	if positioned, ok := val.(interface{ Position() errpos.Position }); ok {
+		childSourceLocation(ef.location, strconv.Itoa(list.Len()-1), positioned.Position(), bcl_j5pb.Origin_ORIGIN_EXPLICIT)
+	}
+	return list.Len() - 1, nil
+}
+
+// Length is the number of elements of a repeated extension.
+func (ef *ExtensionField) Length() int {
+	if !ef.desc().IsList() || !ef.msg.Has(ef.desc()) {
+		return 0
+	}
+	return ef.msg.Get(ef.desc()).List().Len()
+}
+
+// Message returns the message of a singular message extension, set on the
+// block's message.
+func (ef *ExtensionField) Message() (protoreflect.Message, error) {
+	xd := ef.desc()
+	if xd.IsList() || !ef.IsMessage() {
+		return nil, errpos.WithCode(fmt.Errorf("extension %s is not a block", xd.FullName()), errpos.CodeNotContainer)
+	}
+	return ef.msg.Mutable(xd).Message(), nil
+}
+
+// Root returns a scope for the message of the extension, with source
+// locations recorded under the extension.
+func (ef *ExtensionField) Root(obj j5reflect.Object) (*Scope, error) {
+	root, err := ef.scope.schemaSet.wrapContainer(obj, []string{}, ef.location)
+	if err != nil {
+		return nil, err
+	}
+	root.isRoot = true
+	root.oneofs = ef.scope.leafBlock.oneofs
+	return &Scope{
+		schemaSet:   ef.scope.schemaSet,
+		blockSet:    newContainerSet(root),
+		leafBlock:   root,
+		rootBlock:   root,
+		counters:    ef.scope.counters,
+		normalizers: ef.scope.normalizers,
+		assigned:    ef.scope.assigned,
+	}, nil
+}
+
+// extensionScalar converts a value to the kind of the extension.
+func extensionScalar(xd protoreflect.FieldDescriptor, val j5reflect.ASTValue) (protoreflect.Value, error) {
+	var value protoreflect.Value
+	var err error
+	switch xd.Kind() {
+	case protoreflect.BoolKind:
+		var b bool
+		b, err = val.AsBool()
+		value = protoreflect.ValueOfBool(b)
+	case protoreflect.StringKind:
+		var s string
+		s, err = val.AsString()
+		value = protoreflect.ValueOfString(s)
+	case protoreflect.BytesKind:
+		var s string
+		s, err = val.AsString()
+		value = protoreflect.ValueOfBytes([]byte(s))
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		var i int64
+		i, err = val.AsInt(32)
+		value = protoreflect.ValueOfInt32(int32(i))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		var i int64
+		i, err = val.AsInt(64)
+		value = protoreflect.ValueOfInt64(i)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		var u uint64
+		u, err = val.AsUint(32)
+		value = protoreflect.ValueOfUint32(uint32(u))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		var u uint64
+		u, err = val.AsUint(64)
+		value = protoreflect.ValueOfUint64(u)
+	case protoreflect.FloatKind:
+		var f float64
+		f, err = val.AsFloat(32)
+		value = protoreflect.ValueOfFloat32(float32(f))
+	case protoreflect.DoubleKind:
+		var f float64
+		f, err = val.AsFloat(64)
+		value = protoreflect.ValueOfFloat64(f)
+	case protoreflect.EnumKind:
+		var s string
+		s, err = val.AsString()
+		if err == nil {
+			enumValue := extensionEnumValue(xd.Enum(), s)
+			if enumValue == nil {
+				err = fmt.Errorf("unknown value %q for enum %s", s, xd.Enum().FullName())
+			} else {
+				value = protoreflect.ValueOfEnum(enumValue.Number())
+			}
+		}
+	default:
+		err = fmt.Errorf("extension %s is a %s, not a scalar", xd.FullName(), xd.Kind())
+	}
+	if err != nil {
+		return value, errpos.WithCode(err, errpos.CodeInvalidValue)
+	}
+	return value, nil
+}
+
+// extensionEnumValue finds the value of the enum by its full name, or by its
+// short name as j5 gives it, without the prefix of the first value, which
+// ends in UNSPECIFIED.
+func extensionEnumValue(enum protoreflect.EnumDescriptor, name string) protoreflect.EnumValueDescriptor {
+	if value := enum.Values().ByName(protoreflect.Name(name)); value != nil {
+		return value
+	}
+	if enum.Values().Len() == 0 {
+		return nil
+	}
+	first := string(enum.Values().Get(0).Name())
+	prefix, ok := strings.CutSuffix(first, "UNSPECIFIED")
+	if !ok || prefix == "" {
+		return nil
+	}
+	return enum.Values().ByName(protoreflect.Name(prefix + name))
+}
@@ -0,0 +1,290 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/j5/lib/j5reflect"
+)
+
+// NormalizeFunc rewrites a string value before it is set on a field, or
+// rejects it.
+type NormalizeFunc func(string) (string, error)
+
+// builtinNormalizers are available to every block spec by name.
+var builtinNormalizers = map[string]NormalizeFunc{
+	"trim": func(s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	},
+	"lowercase": func(s string) (string, error) {
+		return strings.ToLower(s), nil
+	},
+	"clean_path": func(s string) (string, error) {
+		if s == "" {
+			return s, nil
+		}
+		return path.Clean(s), nil
+	},
+	"url": canonicalURL,
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// canonicalURL lowercases the scheme and host, drops the default port of the
+// scheme, and gives an absolute URL without a path the root path.
+func canonicalURL(s string) (string, error) {
+	parsed, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	if port := parsed.Port(); port != "" && port != defaultPorts[parsed.Scheme] {
+		host += ":" + port
+	}
+	parsed.Host = host
+	if parsed.Host != "" && parsed.Path == "" && parsed.Opaque == "" {
+		parsed.Path = "/"
+	}
+	return parsed.String(), nil
+}
+
+// Normalized is a value which a normalizer changed.
+type Normalized struct {
+	Schema   string
+	Field    string
+	Original string
+	Value    string
+	Pos      errpos.Position
+}
+
+// Normalizers holds the normalizers registered in Go, which are looked up
+// before the built-ins, and records the values they change.
+type Normalizers struct {
+	Funcs   map[string]NormalizeFunc
+	Applied []Normalized
+}
+
+// SetNormalizers sets the normalizers for this scope and all child scopes
+// created after the call.
+func (sw *Scope) SetNormalizers(normalizers *Normalizers) {
+	sw.normalizers = normalizers
+}
+
+func (ns *Normalizers) lookup(name string) (NormalizeFunc, bool) {
+	if ns != nil {
+		if fn, ok := ns.Funcs[name]; ok {
+			return fn, true
+		}
+	}
+	fn, ok := builtinNormalizers[name]
+	return fn, ok
+}
+
+// CheckNormalizers checks that each step of the Normalizers of the given
+// block specs is a built-in, or one of the registered normalizers.
+func (ss *SchemaSet) CheckNormalizers(ns *Normalizers) error {
+	names := make([]string, 0, len(ss.givenSpecs))
+	for name := range ss.givenSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := ss.givenSpecs[name]
+		fields := make([]string, 0, len(spec.Normalizers))
+		for field := range spec.Normalizers {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			for _, step := range spec.Normalizers[field] {
+				if _, ok := ns.lookup(step); !ok {
+					return fmt.Errorf("normalizer of %s.%s: %w", name, field, &NormalizeError{Step: step})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// wrap applies the normalizers of the block spec for the field to each
+// string value set on it. It runs outside the constraint, so constraints check
+// the normalized value.
+func (ns *Normalizers) wrap(field Field, parent *containerField, name string) Field {
+	steps := parent.spec.Normalizers[name]
+	if len(steps) == 0 {
+		return field
+	}
+	return &normalizedField{
+		Field: field,
+		run: &normalizeRun{
+			normalizers: ns,
+			schema:      parent.schemaName,
+			field:       name,
+			steps:       steps,
+		},
+	}
+}
+
+type normalizeRun struct {
+	normalizers *Normalizers
+	schema      string
+	field       string
+	steps       []string
+}
+
+// NormalizeError is a value which a normalizer rejected, or a step which names
+// no normalizer.
+type NormalizeError struct {
+	Step  string
+	Value string
+	Err   error
+}
+
+func (ne *NormalizeError) Error() string {
+	if ne.Err == nil {
+		return fmt.Sprintf("no normalizer named %q", ne.Step)
+	}
+	return fmt.Sprintf("normalizer %s rejected %q: %s", ne.Step, ne.Value, ne.Err)
+}
+
+func (ne *NormalizeError) Unwrap() error {
+	return ne.Err
+}
+
+func (ne *NormalizeError) ErrorCode() errpos.Code {
+	if ne.Err == nil {
+		return errpos.CodeSchemaError
+	}
+	return errpos.CodeInvalidValue
+}
+
+// apply returns the value to set in place of val, which is val itself for
+// values which aren't strings or which no step changes.
+func (nr *normalizeRun) apply(val j5reflect.ASTValue) (j5reflect.ASTValue, error) {
+	astValue, ok := val.(parser.ASTValue)
+	if !ok {
+		return val, nil
+	}
+	original, err := astValue.AsString()
+	if err != nil {
+		return val, nil
+	}
+	str := original
+	for _, step := range nr.steps {
+		fn, ok := nr.normalizers.lookup(step)
+		if !ok {
+			return nil, &NormalizeError{Step: step}
+		}
+		str, err = fn(str)
+		if err != nil {
+			return nil, &NormalizeError{Step: step, Value: original, Err: err}
+		}
+	}
+	if str == original {
+		return val, nil
+	}
+	if nr.normalizers != nil {
+		nr.normalizers.Applied = append(nr.normalizers.Applied, Normalized{
+			Schema:   nr.schema,
+			Field:    nr.field,
+			Original: original,
+			Value:    str,
+			Pos:      astValue.Position(),
+		})
+	}
+	return normalizedValue{ASTValue: astValue, value: str, original: original}, nil
+}
+
+// normalizedValue replaces the string of a value, keeping its position.
+type normalizedValue struct {
+	parser.ASTValue
+	value    string
+	original string
+}
+
+func (nv normalizedValue) AsString() (string, error) {
+	return nv.value, nil
+}
+
+type normalizedField struct {
+	Field
+	run *normalizeRun
+}
+
+func (nf *normalizedField) AsScalar() (j5reflect.ScalarField, bool) {
+	scalar, ok := nf.Field.AsScalar()
+	if !ok {
+		return nil, false
+	}
+	return &normalizedScalar{ScalarField: scalar, run: nf.run}, true
+}
+
+func (nf *normalizedField) AsArrayOfScalar() (j5reflect.ArrayOfScalarField, bool) {
+	array, ok := nf.Field.AsArrayOfScalar()
+	if !ok {
+		return nil, false
+	}
+	return &normalizedArray{ArrayOfScalarField: array, run: nf.run}, true
+}
+
+type normalizedScalar struct {
+	j5reflect.ScalarField
+	run *normalizeRun
+}
+
+func (ns *normalizedScalar) SetASTValue(val j5reflect.ASTValue) error {
+	val, err := ns.run.apply(val)
+	if err != nil {
+		return err
+	}
+	return withOriginal(ns.ScalarField.SetASTValue(val), val)
+}
+
+type normalizedArray struct {
+	j5reflect.ArrayOfScalarField
+	run *normalizeRun
+}
+
+func (na *normalizedArray) AppendASTValue(val j5reflect.ASTValue) (int, error) {
+	val, err := na.run.apply(val)
+	if err != nil {
+		return 0, err
+	}
+	idx, err := na.ArrayOfScalarField.AppendASTValue(val)
+	return idx, withOriginal(err, val)
+}
+
+// appendSynthesized appends a value read back from the message, which was
+// normalized when it was set.
+func (na *normalizedArray) appendSynthesized(val interface{}, pos SourceLocation) (int, error) {
+	return AppendSynthesized(na.ArrayOfScalarField, val, pos)
+}
+
+// withOriginal adds the text as written to a constraint error on a normalized
+// value.
+func withOriginal(err error, val j5reflect.ASTValue) error {
+	nv, ok := val.(normalizedValue)
+	if !ok || err == nil {
+		return err
+	}
+	if ce, ok := err.(*ConstraintError); ok {
+		return &ConstraintError{
+			Value:   fmt.Sprintf("%s (normalized from %q)", ce.Value, nv.original),
+			Message: ce.Message,
+			Code:    ce.Code,
+		}
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OneofConflictError is a member of a oneof set in a block which already set
+// another member. Setting it would clear the other.
+type OneofConflictError struct {
+	Oneof  string // proto name of the oneof
+	Member string // being set
+	Other  string // already set
+
+	// OtherPos is where Other was set, whether or not source locations are
+	// tracked. The walker adds the filename.
+	OtherPos *errpos.Position
+
+	clear func()
+}
+
+func (e *OneofConflictError) Error() string {
+	msg := fmt.Sprintf("%s conflicts with %s of oneof %s", e.Member, e.Other, e.Oneof)
+	if e.OtherPos != nil {
+		msg += fmt.Sprintf(", set at %s", e.OtherPos)
+	}
+	return msg
+}
+
+func (e *OneofConflictError) ErrorCode() errpos.Code {
+	return errpos.CodeOneofConflict
+}
+
+// ClearOther clears the member already set, so the statement setting Member
+// can be walked again.
+func (e *OneofConflictError) ClearOther() {
+	e.clear()
+}
+
+// CheckOneofs makes setting a member of a oneof which has another member set
+// an OneofConflictError, in this scope and the blocks below it. Scopes which
+// explore the schema rather than walk a file, e.g. to describe it, open every
+// member of a oneof in turn.
+func (sw *Scope) CheckOneofs() {
+	sw.rootBlock.oneofs = &oneofSet{
+		positions: map[oneofMember]errpos.Position{},
+	}
+}
+
+// oneofSet holds where each member of a oneof was set, by the message, as
+// the source location tree is not always built.
+type oneofSet struct {
+	positions map[oneofMember]errpos.Position
+}
+
+type oneofMember struct {
+	msg    protoreflect.Message
+	number protoreflect.FieldNumber
+}
+
+// checkOneof returns a conflict when the named property is a member of a
+// oneof of the block's message which has another member set, otherwise
+// records that the member was set at pos.
+func (sc *containerField) checkOneof(name string, pos SourceLocation) error {
+	if sc.oneofs == nil {
+		return nil
+	}
+	reflected, ok := sc.container.(interface {
+		ProtoReflect() protoreflect.Message
+	})
+	if !ok || !sc.container.HasProperty(name) {
+		return nil
+	}
+	msg := reflected.ProtoReflect()
+	if msg == nil {
+		return nil
+	}
+	prop, err := sc.container.GetProperty(name)
+	if err != nil {
+		return nil
+	}
+	protoField := prop.Schema().ProtoField
+	if len(protoField) != 1 {
+		return nil
+	}
+	fd := msg.Descriptor().Fields().ByNumber(protoField[0])
+	if fd == nil || fd.ContainingOneof() == nil || fd.ContainingOneof().IsSynthetic() {
+		return nil
+	}
+	set := msg.WhichOneof(fd.ContainingOneof())
+	if set == nil || set.Number() == fd.Number() {
+		member := oneofMember{msg: msg, number: fd.Number()}
+		if _, ok := sc.oneofs.positions[member]; !ok {
+			sc.oneofs.positions[member] = pos
+		}
+		return nil
+	}
+
+	other := set.JSONName()
+	otherMember := oneofMember{msg: msg, number: set.Number()}
+	conflict := &OneofConflictError{
+		Oneof:  string(fd.ContainingOneof().Name()),
+		Member: fd.JSONName(),
+		Other:  other,
+		clear: func() {
+			msg.Clear(set)
+			delete(sc.oneofs.positions, otherMember)
+			if sc.location != nil {
+				delete(sc.location.Children, other)
+			}
+		},
+	}
+	if otherPos, ok := sc.oneofs.positions[otherMember]; ok {
+		conflict.OtherPos = &otherPos
+	}
+	return conflict
+}
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/iancoleman/strcase"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
@@ -33,8 +34,15 @@ func convertBlocks(given []*bcl_j5pb.Block) (map[string]*BlockSpec, error) {
 	givenBlocks := map[string]*BlockSpec{}
 	for _, src := range given {
 		aliases := map[string]PathSpec{}
+		var deprecated map[string]bool
 		for _, alias := range src.Alias {
 			aliases[alias.Name] = PathSpec(alias.Path.Path)
+			if alias.Deprecated {
+				if deprecated == nil {
+					deprecated = map[string]bool{}
+				}
+				deprecated[alias.Name] = true
+			}
 		}
 
 		block := &BlockSpec{
@@ -42,8 +50,14 @@ func convertBlocks(given []*bcl_j5pb.Block) (map[string]*BlockSpec, error) {
 			TypeSelect:  convertTag(src.TypeSelect),
 			Qualifier:   convertTag(src.Qualifier),
 			OnlyDefined: src.OnlyExplicit,
+			Unordered:   src.Unordered,
+			Identity:    src.Identity,
+			Prose:       src.Prose,
 			Aliases:     aliases,
+
+			DeprecatedAliases: deprecated,
 		}
+		block.TypeAttribute = convertTypeAttribute(src.TypeAttribute)
 		if src.DescriptionField != nil {
 			block.Description = src.DescriptionField
 		}
@@ -66,6 +80,64 @@ func convertBlocks(given []*bcl_j5pb.Block) (map[string]*BlockSpec, error) {
 			}
 		}
 
+		for _, policy := range src.Policies {
+			block.Policies = append(block.Policies, Policy{
+				Expression: policy.Expression,
+				Message:    policy.Message,
+			})
+		}
+
+		for _, derived := range src.Derived {
+			block.Derived = append(block.Derived, Derived{
+				Field:      derived.FieldName,
+				Expression: derived.Expression,
+			})
+		}
+
+		for _, cs := range src.Constraints {
+			if block.Constraints == nil {
+				block.Constraints = map[string]*Constraint{}
+			}
+			if _, ok := block.Constraints[cs.FieldName]; ok || cs.FieldName == "" {
+				return nil, fmt.Errorf("invalid block spec for %s: constraint on %q is empty or repeated", src.SchemaName, cs.FieldName)
+			}
+			constraint := convertConstraint(cs)
+			if err := constraint.compile(); err != nil {
+				return nil, fmt.Errorf("invalid constraint on %s.%s: %s", src.SchemaName, cs.FieldName, err)
+			}
+			block.Constraints[cs.FieldName] = constraint
+		}
+
+		for _, normalizer := range src.Normalizers {
+			if block.Normalizers == nil {
+				block.Normalizers = map[string][]string{}
+			}
+			block.Normalizers[normalizer.FieldName] = append(block.Normalizers[normalizer.FieldName], normalizer.Steps...)
+		}
+
+		seen := map[string]bool{}
+		for _, field := range src.Identity {
+			if field == "" || seen[field] {
+				return nil, fmt.Errorf("invalid block spec for %s: identity field %q is empty or repeated", src.SchemaName, field)
+			}
+			seen[field] = true
+		}
+
+		seen = map[string]bool{}
+		for _, field := range src.Prose {
+			if field == "" || seen[field] {
+				return nil, fmt.Errorf("invalid block spec for %s: prose field %q is empty or repeated", src.SchemaName, field)
+			}
+			seen[field] = true
+		}
+
+		for _, flag := range src.Flags {
+			if block.Flags == nil {
+				block.Flags = map[string]bool{}
+			}
+			block.Flags[flag.FieldName] = true
+		}
+
 		if err := block.Validate(); err != nil {
 			return nil, fmt.Errorf("invalid block spec for %s: %s", src.SchemaName, err)
 		}
@@ -87,7 +159,63 @@ func NewSchemaSet(given *bcl_j5pb.Schema) (*SchemaSet, error) {
 	}, nil
 }
 
-func (ss *SchemaSet) _buildSpec(node j5PropSet) (*BlockSpec, error) {
+// Policies returns the policies of the given block specs, by schema name.
+func (ss *SchemaSet) Policies() map[string][]Policy {
+	policies := map[string][]Policy{}
+	for name, spec := range ss.givenSpecs {
+		if len(spec.Policies) > 0 {
+			policies[name] = spec.Policies
+		}
+	}
+	return policies
+}
+
+// Derived returns the derived fields of the given block specs, by schema name.
+func (ss *SchemaSet) Derived() map[string][]Derived {
+	derived := map[string][]Derived{}
+	for name, spec := range ss.givenSpecs {
+		if len(spec.Derived) > 0 {
+			derived[name] = spec.Derived
+		}
+	}
+	return derived
+}
+
+// Identities returns the identity fields of the given block specs, by schema
+// name.
+func (ss *SchemaSet) Identities() map[string][]string {
+	identities := map[string][]string{}
+	for name, spec := range ss.givenSpecs {
+		if len(spec.Identity) > 0 {
+			identities[name] = spec.Identity
+		}
+	}
+	return identities
+}
+
+// Prose returns the prose fields of the given block specs, by schema name.
+func (ss *SchemaSet) Prose() map[string][]string {
+	prose := map[string][]string{}
+	for name, spec := range ss.givenSpecs {
+		if len(spec.Prose) > 0 {
+			prose[name] = spec.Prose
+		}
+	}
+	return prose
+}
+
+// Names returns the name tags of the given block specs, by schema name.
+func (ss *SchemaSet) Names() map[string]*Tag {
+	names := map[string]*Tag{}
+	for name, spec := range ss.givenSpecs {
+		if spec.Name != nil {
+			names[name] = spec.Name
+		}
+	}
+	return names
+}
+
+func (ss *SchemaSet) _buildSpec(node specNode) (*BlockSpec, error) {
 	schemaName := node.SchemaName()
 	blockSpec := ss.givenSpecs[schemaName]
 	if blockSpec == nil {
@@ -98,6 +226,26 @@ func (ss *SchemaSet) _buildSpec(node j5PropSet) (*BlockSpec, error) {
 	}
 	blockSpec.schema = schemaName
 
+	if err := checkConstraints(node, blockSpec); err != nil {
+		return nil, err
+	}
+
+	if err := addRuleConstraints(node, blockSpec); err != nil {
+		return nil, err
+	}
+
+	if err := checkFlags(node, blockSpec); err != nil {
+		return nil, err
+	}
+
+	if err := checkProse(node, blockSpec); err != nil {
+		return nil, err
+	}
+
+	if err := checkIdentity(node, blockSpec); err != nil {
+		return nil, err
+	}
+
 	if blockSpec.OnlyDefined {
 		return blockSpec, nil
 	}
@@ -134,7 +282,8 @@ func (ss *SchemaSet) _buildSpec(node j5PropSet) (*BlockSpec, error) {
 			*schema_j5pb.Field_Bytes,
 			*schema_j5pb.Field_Date,
 			*schema_j5pb.Field_Timestamp,
-			*schema_j5pb.Field_Decimal:
+			*schema_j5pb.Field_Decimal,
+			*schema_j5pb.Field_Any:
 
 		case *schema_j5pb.Field_Array:
 			if field.Array != nil && field.Array.Ext != nil && field.Array.Ext.SingleForm != nil {
@@ -172,6 +321,131 @@ func (ss *SchemaSet) _buildSpec(node j5PropSet) (*BlockSpec, error) {
 	return blockSpec, nil
 }
 
+// addRuleConstraints adds constraints for the validation rules of the
+// block's scalar fields. A field which the spec also constrains checks the
+// spec's constraint first, then the rules.
+func addRuleConstraints(node specNode, blockSpec *BlockSpec) error {
+	return node.RangePropertySchemas(func(name string, required bool, schema *schema_j5pb.Field) error {
+		constraint := ruleConstraint(schema)
+		if constraint == nil {
+			return nil
+		}
+		if err := constraint.compile(); err != nil {
+			return fmt.Errorf("rules for %s.%s: %w", blockSpec.schema, name, err)
+		}
+		if given, ok := blockSpec.Constraints[name]; ok {
+			given.Rules = constraint
+			return nil
+		}
+		if blockSpec.Constraints == nil {
+			blockSpec.Constraints = map[string]*Constraint{}
+		}
+		blockSpec.Constraints[name] = constraint
+		return nil
+	})
+}
+
+// checkFlags checks that the flags of the block are boolean fields.
+func checkFlags(node specNode, blockSpec *BlockSpec) error {
+	names := make([]string, 0, len(blockSpec.Flags))
+	for name := range blockSpec.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return checkFields(node, blockSpec, "flag", "a boolean field", names, func(schema *schema_j5pb.Field) bool {
+		_, ok := schema.Type.(*schema_j5pb.Field_Bool)
+		return ok
+	})
+}
+
+// checkConstraints checks that the constraints of the block are on scalar
+// fields, or arrays of scalars.
+func checkConstraints(node specNode, blockSpec *BlockSpec) error {
+	names := make([]string, 0, len(blockSpec.Constraints))
+	for name := range blockSpec.Constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return checkFields(node, blockSpec, "constraint on", "a scalar field", names, func(schema *schema_j5pb.Field) bool {
+		if array, ok := schema.Type.(*schema_j5pb.Field_Array); ok && array.Array != nil {
+			schema = array.Array.Items
+		}
+		switch schema.GetType().(type) {
+		case *schema_j5pb.Field_Object,
+			*schema_j5pb.Field_Oneof,
+			*schema_j5pb.Field_Array,
+			*schema_j5pb.Field_Map,
+			*schema_j5pb.Field_Any,
+			nil:
+			return false
+		}
+		return true
+	})
+}
+
+// checkProse checks that the prose fields of the block are strings, or
+// arrays of strings.
+func checkProse(node specNode, blockSpec *BlockSpec) error {
+	return checkFields(node, blockSpec, "prose field", "a string field", blockSpec.Prose, func(schema *schema_j5pb.Field) bool {
+		if array, ok := schema.Type.(*schema_j5pb.Field_Array); ok && array.Array != nil {
+			schema = array.Array.Items
+		}
+		_, ok := schema.GetType().(*schema_j5pb.Field_String_)
+		return ok
+	})
+}
+
+// checkIdentity checks that the identity fields of the block are scalars
+// which are not messages in protobuf, as the duplicate check compares them
+// by their string values.
+func checkIdentity(node specNode, blockSpec *BlockSpec) error {
+	return checkFields(node, blockSpec, "identity field", "a scalar field", blockSpec.Identity, func(schema *schema_j5pb.Field) bool {
+		switch schema.Type.(type) {
+		case *schema_j5pb.Field_String_,
+			*schema_j5pb.Field_Integer,
+			*schema_j5pb.Field_Float,
+			*schema_j5pb.Field_Bool,
+			*schema_j5pb.Field_Key,
+			*schema_j5pb.Field_Enum,
+			*schema_j5pb.Field_Bytes:
+			return true
+		}
+		return false
+	})
+}
+
+// checkFields checks that each of the named fields is a field of the block
+// which valid accepts, what and kind naming them in errors.
+func checkFields(node specNode, blockSpec *BlockSpec, what, kind string, names []string, valid func(*schema_j5pb.Field) bool) error {
+	if len(names) == 0 {
+		return nil
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	found := map[string]bool{}
+	err := node.RangePropertySchemas(func(name string, required bool, schema *schema_j5pb.Field) error {
+		if !wanted[name] {
+			return nil
+		}
+		if !valid(schema) {
+			return fmt.Errorf("%s %s.%s is not %s", what, blockSpec.schema, name, kind)
+		}
+		found[name] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !found[name] {
+			return fmt.Errorf("%s %s.%s is not a field", what, blockSpec.schema, name)
+		}
+	}
+	return nil
+}
+
 func (ss *SchemaSet) wrapContainer(node j5reflect.PropertySet, path []string, loc *bcl_j5pb.SourceLocation) (*containerField, error) {
 	spec, err := ss.blockSpec(node)
 	if err != nil {
@@ -188,7 +462,30 @@ func (ss *SchemaSet) wrapContainer(node j5reflect.PropertySet, path []string, lo
 
 }
 
-func (ss *SchemaSet) blockSpec(node j5PropSet) (*BlockSpec, error) {
+// aliases returns the aliases of the schema's block spec, those built so far,
+// or those given when it is yet to be built.
+func (ss *SchemaSet) aliases(schemaName string) map[string]PathSpec {
+	if spec, ok := ss.cachedSpecs[schemaName]; ok {
+		return spec.Aliases
+	}
+	if spec, ok := ss.givenSpecs[schemaName]; ok {
+		return spec.Aliases
+	}
+	return nil
+}
+
+// BlockSpec returns a copy of the spec the walker builds for blocks of the
+// node's schema, objects and oneofs alike.
+func (ss *SchemaSet) BlockSpec(node j5reflect.PropertySet) (*BlockSpec, error) {
+	spec, err := ss.blockSpec(node)
+	if err != nil {
+		return nil, err
+	}
+	copied := *spec
+	return &copied, nil
+}
+
+func (ss *SchemaSet) blockSpec(node specNode) (*BlockSpec, error) {
 	schemaName := node.SchemaName()
 
 	var err error
@@ -2,10 +2,14 @@ package schema
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 	"github.com/pentops/bcl.go/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
 	"github.com/pentops/j5/lib/j5reflect"
+	"github.com/pentops/j5/lib/j5schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 type ScalarField interface {
@@ -25,15 +29,229 @@ type Field interface {
 type field struct {
 	j5reflect.Field
 	location *bcl_j5pb.SourceLocation
+
+	// origin is recorded for the elements appended to an array field.
+	origin bcl_j5pb.Origin
+}
+
+// AsScalar reads bytes from base64 strings, as the Encoder writes them.
+func (f *field) AsScalar() (j5reflect.ScalarField, bool) {
+	scalar, ok := f.Field.AsScalar()
+	if !ok || !f.isBytes() {
+		return scalar, ok
+	}
+	return &bytesScalar{ScalarField: scalar}, true
+}
+
+// AsArrayOfScalar records the location of each appended element, keyed by
+// index, as a child of the field.
+func (f *field) AsArrayOfScalar() (j5reflect.ArrayOfScalarField, bool) {
+	array, ok := f.Field.AsArrayOfScalar()
+	if !ok {
+		// Arrays of enums are arrays of scalars, which j5reflect doesn't
+		// report.
+		enums, isEnums := f.Field.(j5reflect.ArrayOfEnumField)
+		if !isEnums {
+			return array, ok
+		}
+		array = enums
+	}
+	if f.isBytes() {
+		array = &bytesArray{ArrayOfScalarField: array}
+	}
+	if f.location == nil {
+		return array, true
+	}
+	return &locatedArray{ArrayOfScalarField: array, location: f.location, origin: f.origin}, true
+}
+
+func (f *field) isBytes() bool {
+	fieldSchema := f.FieldSchema()
+	if array, ok := fieldSchema.(*schema_j5pb.Field_Array); ok {
+		fieldSchema = array.Array.Items.Type
+	}
+	_, ok := fieldSchema.(*schema_j5pb.Field_Bytes)
+	return ok
+}
+
+// bytesScalar sets a bytes field from a base64 string, which j5reflect takes
+// as a Go value but not from the AST.
+type bytesScalar struct {
+	j5reflect.ScalarField
+}
+
+func (bs *bytesScalar) SetASTValue(val j5reflect.ASTValue) error {
+	str, err := val.AsString()
+	if err != nil {
+		return err
+	}
+	return bs.SetGoValue(str)
+}
+
+type bytesArray struct {
+	j5reflect.ArrayOfScalarField
+}
+
+func (ba *bytesArray) AppendASTValue(val j5reflect.ASTValue) (int, error) {
+	str, err := val.AsString()
+	if err != nil {
+		return -1, err
+	}
+	return ba.AppendGoValue(str)
+}
+
+type locatedArray struct {
+	j5reflect.ArrayOfScalarField
+	location *bcl_j5pb.SourceLocation
+	origin   bcl_j5pb.Origin
+}
+
+func (la *locatedArray) AppendASTValue(val j5reflect.ASTValue) (int, error) {
+	idx, err := la.ArrayOfScalarField.AppendASTValue(val)
+	if err != nil {
+		return idx, err
+	}
+	if positioned, ok := val.(interface{ Position() errpos.Position }); ok {
+		childSourceLocation(la.location, strconv.Itoa(idx), positioned.Position(), la.origin)
+	}
+	return idx, nil
+}
+
+// AppendSynthesized appends a value copied from elsewhere in the file to the
+// array, recording the element as synthesized at pos. The value is checked
+// against the constraint of the field as a value written in the file is.
+func AppendSynthesized(array j5reflect.ArrayOfScalarField, val interface{}, pos SourceLocation) (int, error) {
+	if sa, ok := array.(synthesizedArray); ok {
+		return sa.appendSynthesized(val, pos)
+	}
+	return array.AppendGoValue(val)
+}
+
+// synthesizedArray is implemented by the wrappers of an array of scalars, to
+// pass synthesized values through each of them.
+type synthesizedArray interface {
+	appendSynthesized(val interface{}, pos SourceLocation) (int, error)
+}
+
+func (la *locatedArray) appendSynthesized(val interface{}, pos SourceLocation) (int, error) {
+	idx, err := AppendSynthesized(la.ArrayOfScalarField, val, pos)
+	if err != nil {
+		return idx, err
+	}
+	childSourceLocation(la.location, strconv.Itoa(idx), pos, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED)
+	return idx, nil
+}
+
+// SetSynthesized sets the named scalar of the innermost block to the value
+// from produce, recording it as synthesized at pos. A field the file already
+// set, even to its zero value, is kept, and produce isn't called.
+func (sw *Scope) SetSynthesized(name string, pos SourceLocation, produce func() (interface{}, error)) error {
+	block := sw.leafBlock
+	if !block.container.HasProperty(name) {
+		return fmt.Errorf("%s has no field %s", block.schemaName, name)
+	}
+	if sw.assigned.has(block.container, name) {
+		return nil
+	}
+	if prop, err := block.container.GetProperty(name); err != nil {
+		return err
+	} else if prop.IsSet() {
+		return nil
+	}
+	value, err := produce()
+	if err != nil {
+		return err
+	}
+	field, err := block.container.NewValue(name)
+	if err != nil {
+		return err
+	}
+	scalar, ok := field.AsScalar()
+	if !ok {
+		return fmt.Errorf("%s.%s is not a scalar", block.schemaName, name)
+	}
+	if err := scalar.SetGoValue(value); err != nil {
+		return err
+	}
+	location := block.location
+	for _, elem := range field.ProtoPath() {
+		location = childSourceLocation(location, elem, pos, bcl_j5pb.Origin_ORIGIN_SYNTHESIZED)
+	}
+	sw.counters.addValues(1)
+	return nil
 }
 
 type SourceLocation = errpos.Position
 
 type Scope struct {
-	blockSet  containerSet
+	blockSet  *containerSet
 	leafBlock *containerField
 	rootBlock *containerField
 	schemaSet *SchemaSet
+	counters  *Counters
+	assigned  *assignedFields
+
+	normalizers *Normalizers
+
+	// inherited scopes set the defaults of a parent block.
+	inherited bool
+}
+
+// Counters are incremented as the scope walks the reflection tree, shared by
+// all child scopes.
+type Counters struct {
+	// ReflectValues is the number of reflection fields fetched or created
+	ReflectValues int
+}
+
+func (c *Counters) addValues(n int) {
+	if c != nil {
+		c.ReflectValues += n
+	}
+}
+
+// SetCounters sets the counters for this scope and all child scopes created
+// after the call.
+func (sw *Scope) SetCounters(counters *Counters) {
+	sw.counters = counters
+}
+
+// assignedFields are the fields the file set, by the message holding them, so
+// that a field set to its zero value isn't taken as unset. Shared by all child
+// scopes of a root.
+type assignedFields struct {
+	fields map[assignedField]struct{}
+}
+
+type assignedField struct {
+	msg  protoreflect.Message
+	name string
+}
+
+// protoMessage is implemented by the object and oneof containers.
+type protoMessage interface {
+	ProtoReflect() protoreflect.Message
+}
+
+func (af *assignedFields) add(container j5PropSet, name string) {
+	if af == nil {
+		return
+	}
+	if pm, ok := container.(protoMessage); ok {
+		af.fields[assignedField{msg: pm.ProtoReflect(), name: name}] = struct{}{}
+	}
+}
+
+func (af *assignedFields) has(container j5PropSet, name string) bool {
+	if af == nil {
+		return false
+	}
+	pm, ok := container.(protoMessage)
+	if !ok {
+		return false
+	}
+	_, ok = af.fields[assignedField{msg: pm.ProtoReflect(), name: name}]
+	return ok
 }
 
 func (sw *Scope) CurrentBlock() Container {
@@ -44,15 +262,14 @@ func (sw *Scope) RootBlock() Container {
 	return sw.rootBlock
 }
 
+// NewRootSchemaWalker creates the scope for the root of a file. Source
+// locations are recorded into sourceLoc as the tree is walked, a nil sourceLoc
+// skips location tracking.
 func NewRootSchemaWalker(ss *SchemaSet, root j5reflect.Object, sourceLoc *bcl_j5pb.SourceLocation) (*Scope, error) {
 	if ss.givenSpecs == nil {
 		ss.givenSpecs = map[string]*BlockSpec{}
 	}
 
-	if sourceLoc == nil {
-		return nil, fmt.Errorf("source location required")
-	}
-
 	rootWrapped, err := ss.wrapContainer(root, []string{}, sourceLoc)
 	if err != nil {
 		return nil, err
@@ -61,25 +278,30 @@ func NewRootSchemaWalker(ss *SchemaSet, root j5reflect.Object, sourceLoc *bcl_j5
 	rootWrapped.isRoot = true
 	return &Scope{
 		schemaSet: ss,
+		assigned:  &assignedFields{fields: map[assignedField]struct{}{}},
 
-		blockSet:  containerSet{*rootWrapped},
+		blockSet:  newContainerSet(rootWrapped),
 		leafBlock: rootWrapped,
 		rootBlock: rootWrapped,
 	}, nil
 }
 
 func (sw *Scope) newChild(container *containerField, newScope bool) *Scope {
-	var newBlockSet containerSet
+	var newBlockSet *containerSet
 	if newScope {
-		newBlockSet = containerSet{*container}
+		newBlockSet = newContainerSet(container)
 	} else {
-		newBlockSet = append(sw.blockSet, *container)
+		newBlockSet = sw.blockSet.with(container)
 	}
 	return &Scope{
-		blockSet:  newBlockSet,
-		leafBlock: container,
-		rootBlock: container,
-		schemaSet: sw.schemaSet,
+		blockSet:    newBlockSet,
+		leafBlock:   container,
+		rootBlock:   container,
+		schemaSet:   sw.schemaSet,
+		counters:    sw.counters,
+		normalizers: sw.normalizers,
+		inherited:   sw.inherited,
+		assigned:    sw.assigned,
 	}
 }
 
@@ -95,6 +317,28 @@ func (sw *Scope) ListBlocks() []string {
 	return sw.blockSet.listBlocks()
 }
 
+// Children describes every name statements in the scope can use.
+func (sw *Scope) Children() []ChildInfo {
+	return sw.blockSet.children()
+}
+
+// SchemaName is the schema of the innermost block of the scope.
+func (sw *Scope) SchemaName() string {
+	if sw.leafBlock == nil {
+		return ""
+	}
+	return sw.leafBlock.schemaName
+}
+
+// BlockSpec is the spec of the innermost block of the scope.
+func (sw *Scope) BlockSpec() *BlockSpec {
+	if sw.leafBlock == nil {
+		return nil
+	}
+	spec := sw.leafBlock.spec
+	return &spec
+}
+
 func (sw *Scope) ChildBlock(name string, source SourceLocation) (*Scope, *WalkPathError) {
 	root, spec, ok := sw.findBlock(name)
 	if !ok {
@@ -200,18 +444,50 @@ func (sw *Scope) field(name string, source SourceLocation, existingIsOk bool) (F
 				Err:  err,
 			}
 		}
-		return field, nil, nil
+		sw.counters.addValues(1)
+		sw.assigned.add(parentScope.container, final)
+		field = withConstraint(field, parentScope.spec.Constraints[final])
+		return sw.normalizers.wrap(field, parentScope, final), nil, nil
 	}
 
 	finalField, newValErr := parentScope.newValue(final, source)
 	if newValErr != nil {
+		// HasProperty is checked above, so the only failure is a value which
+		// is already set.
 		return nil, nil, &WalkPathError{
 			Type: UnknownPathError,
-			Err:  newValErr,
+			Err:  errpos.WithCode(newValErr, errpos.CodeAlreadySet),
 		}
 	}
+	sw.counters.addValues(1)
+	sw.assigned.add(parentScope.container, final)
 
-	return finalField, spec, nil
+	if sw.inherited {
+		inherit(finalField)
+	}
+
+	finalField = withConstraint(finalField, parentScope.spec.Constraints[final])
+	return sw.normalizers.wrap(finalField, parentScope, final), spec, nil
+}
+
+// Inherited returns a copy of the scope which records the values it sets,
+// and those of the scopes walked from it, as inherited from the defaults of a
+// parent block.
+func (sw *Scope) Inherited() *Scope {
+	inherited := *sw
+	inherited.inherited = true
+	return &inherited
+}
+
+// inherit marks a new field, and each element later appended to it, as
+// inherited.
+func inherit(f Field) {
+	ff, ok := f.(*field)
+	if !ok || ff.location == nil {
+		return
+	}
+	ff.location.Origin = bcl_j5pb.Origin_ORIGIN_INHERITED
+	ff.origin = bcl_j5pb.Origin_ORIGIN_INHERITED
 }
 
 func (sw *Scope) walkToChild(blockSchema *containerField, path []string, sourceLocation SourceLocation) (*containerField, *WalkPathError) {
@@ -224,6 +500,7 @@ func (sw *Scope) walkToChild(blockSchema *containerField, path []string, sourceL
 	if pathErr != nil {
 		return nil, pathErr
 	}
+	sw.counters.addValues(len(visitedFields))
 
 	for _, field := range visitedFields {
 		spec, err := sw.schemaSet.blockSpec(field.container)
@@ -239,22 +516,162 @@ func (sw *Scope) walkToChild(blockSchema *containerField, path []string, sourceL
 }
 
 func (sw *Scope) findBlock(name string) (*containerField, *ChildSpec, bool) {
-	for _, blockSchema := range sw.blockSet {
-		pathToChild, ok := blockSchema.spec.Aliases[name]
+	var found *containerField
+	var pathToChild []string
+	sw.blockSet.each(func(blockSchema *containerField) bool {
+		path, ok := childPath(blockSchema, name)
 		if ok {
-			return &blockSchema, &ChildSpec{
-				Path: pathToChild,
-			}, true
+			found = blockSchema
+			pathToChild = path
 		}
+		return !ok
+	})
+	if found == nil {
+		return nil, nil, false
+	}
 
-		if blockSchema.container.HasProperty(name) {
-			return &blockSchema, &ChildSpec{
-				Path: []string{name},
-			}, true
+	return found, &ChildSpec{
+		Path: pathToChild,
+	}, true
+}
+
+// HasChild returns true when the name is a child or alias of a block in the
+// scope.
+func (sw *Scope) HasChild(name string) bool {
+	_, _, ok := sw.findBlock(name)
+	return ok
+}
+
+// DeprecatedAlias returns the path of the named child of the scope when the
+// name is an alias which its block spec deprecates.
+func (sw *Scope) DeprecatedAlias(name string) (PathSpec, bool) {
+	root, spec, ok := sw.findBlock(name)
+	if !ok || !root.spec.DeprecatedAliases[name] {
+		return nil, false
+	}
+	return spec.Path, true
+}
+
+// EnumSchema returns the enum of the named field of the scope, or of its
+// elements for arrays, without creating any values.
+func (sw *Scope) EnumSchema(name string) (*j5schema.EnumSchema, bool) {
+	root, spec, ok := sw.findBlock(name)
+	if !ok || len(spec.Path) == 0 {
+		return nil, false
+	}
+	final, parentPath := popLast(spec.Path)
+	container := root.container.ContainerSchema()
+	if len(parentPath) > 0 {
+		_, container, ok = walkBlockSchema(container, parentPath)
+		if !ok {
+			return nil, false
+		}
+	}
+	fieldSchema := container.PropertyField(final)
+	if array, ok := fieldSchema.(*j5schema.ArrayField); ok {
+		fieldSchema = array.Schema
+	}
+	enum, ok := fieldSchema.(*j5schema.EnumField)
+	if !ok {
+		return nil, false
+	}
+	return enum.Schema(), true
+}
+
+// FieldOwner returns the schema of the block holding the named child of the
+// scope, and the child's name in it, without creating any values.
+func (sw *Scope) FieldOwner(name string) (string, string, bool) {
+	root, spec, ok := sw.findBlock(name)
+	if !ok || len(spec.Path) == 0 {
+		return "", "", false
+	}
+	final, parentPath := popLast(spec.Path)
+	if len(parentPath) == 0 {
+		return root.schemaName, final, true
+	}
+	schemaName, _, ok := walkBlockSchema(root.container.ContainerSchema(), parentPath)
+	return schemaName, final, ok
+}
+
+// BlockSchemaName returns the schema of the block the path names from the
+// scope, as BuildScope resolves aliases, without creating any values.
+func (sw *Scope) BlockSchemaName(path []string) (string, bool) {
+	if len(path) == 0 {
+		return "", false
+	}
+	root, spec, ok := sw.findBlock(path[0])
+	if !ok {
+		return "", false
+	}
+	name, container, ok := walkBlockSchema(root.container.ContainerSchema(), spec.Path)
+	for _, elem := range path[1:] {
+		if !ok {
+			return "", false
+		}
+		childPath := PathSpec{elem}
+		if alias, found := sw.schemaSet.aliases(name)[elem]; found {
+			childPath = alias
 		}
+		name, container, ok = walkBlockSchema(container, childPath)
+	}
+	return name, ok
+}
+
+// walkBlockSchema walks the path through the properties of the container, and
+// the elements of arrays and maps, to the block at its end.
+func walkBlockSchema(container j5schema.Container, path []string) (string, j5schema.Container, bool) {
+	name := ""
+	for _, elem := range path {
+		if container == nil {
+			return "", nil, false
+		}
+		fieldSchema := container.PropertyField(elem)
+		if fieldSchema == nil {
+			return "", nil, false
+		}
+		var ok bool
+		name, container, ok = blockSchema(fieldSchema)
+		if !ok {
+			return "", nil, false
+		}
+	}
+	return name, container, name != ""
+}
+
+// blockSchema returns the name and properties of the object or oneof a
+// block of the field holds, the element of arrays and maps.
+func blockSchema(fieldSchema j5schema.FieldSchema) (string, j5schema.Container, bool) {
+	switch field := fieldSchema.(type) {
+	case *j5schema.ArrayField:
+		fieldSchema = field.Schema
+	case *j5schema.MapField:
+		fieldSchema = field.Schema
+	}
+	var name string
+	switch field := fieldSchema.(type) {
+	case *j5schema.ObjectField:
+		name = field.Ref.FullName()
+	case *j5schema.OneofField:
+		name = field.Ref.FullName()
+	default:
+		return "", nil, false
 	}
+	container, ok := fieldSchema.AsContainer()
+	return name, container, ok
+}
 
-	return nil, nil, false
+// childPath returns the path from the block to the named child, as an alias or
+// a direct property.
+func childPath(blockSchema *containerField, name string) ([]string, bool) {
+	pathToChild, ok := blockSchema.spec.Aliases[name]
+	if ok {
+		return pathToChild, true
+	}
+
+	if blockSchema.container.HasProperty(name) {
+		return []string{name}, true
+	}
+	return nil, false
 }
 
 func popLast[T any](list []T) (T, []T) {
@@ -263,25 +680,33 @@ func popLast[T any](list []T) (T, []T) {
 
 func (sw *Scope) TailScope() *Scope {
 	return &Scope{
-		blockSet:  containerSet{*sw.leafBlock},
-		leafBlock: sw.leafBlock,
-		schemaSet: sw.schemaSet,
+		blockSet:    newContainerSet(sw.leafBlock),
+		leafBlock:   sw.leafBlock,
+		schemaSet:   sw.schemaSet,
+		counters:    sw.counters,
+		normalizers: sw.normalizers,
+		inherited:   sw.inherited,
+		assigned:    sw.assigned,
 	}
 }
 
 func (sw *Scope) MergeScope(other *Scope) *Scope {
-	newBlockSet := append(sw.blockSet, other.blockSet...)
+	newBlockSet := sw.blockSet.concat(other.blockSet)
 	return &Scope{
-		blockSet:  newBlockSet,
-		leafBlock: other.leafBlock,
-		rootBlock: sw.rootBlock,
-		schemaSet: sw.schemaSet,
+		blockSet:    newBlockSet,
+		leafBlock:   other.leafBlock,
+		rootBlock:   sw.rootBlock,
+		schemaSet:   sw.schemaSet,
+		counters:    sw.counters,
+		normalizers: sw.normalizers,
+		inherited:   sw.inherited,
+		assigned:    sw.assigned,
 	}
 }
 
 func (sw *Scope) PrintScope(logf func(string, ...interface{})) {
 	logf("available blocks:")
-	for _, block := range sw.blockSet {
+	sw.blockSet.each(func(block *containerField) bool {
 		if block.spec.DebugName != "" {
 			logf("from %s : %s %q", block.schemaName, block.spec.source, block.spec.DebugName)
 		} else {
@@ -290,7 +715,8 @@ func (sw *Scope) PrintScope(logf func(string, ...interface{})) {
 		for name, block := range sw.blockSet.allChildFields() {
 			logf(" - [%s] %q %#v", name, schemaCan(block.GetType()))
 		}
-	}
+		return true
+	})
 
 	if sw.leafBlock == nil {
 		logf("no leaf spec")
@@ -0,0 +1,126 @@
+package walker
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+)
+
+// closestName returns the only available name within a small edit distance of
+// name, for 'did you mean' suggestions.
+func closestName(name string, available []string) (string, bool) {
+	maxDistance := len(name) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	best := ""
+	bestDistance := maxDistance + 1
+	ambiguous := false
+	for _, option := range available {
+		if option == name {
+			continue
+		}
+		distance := EditDistance(strings.ToLower(name), strings.ToLower(option))
+		if distance < bestDistance {
+			best = option
+			bestDistance = distance
+			ambiguous = false
+		} else if distance == bestDistance {
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return "", false
+	}
+	return best, true
+}
+
+// EditDistance is the Levenshtein distance between a and b in runes, which
+// also ranks the suggestions of the spelling check.
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// suggestName adds a 'did you mean' to the error, with a fix to replace the
+// name, when there is exactly one close match.
+func suggestName(err error, name string, pos errpos.Position, available []string) error {
+	best, ok := closestName(name, available)
+	if !ok {
+		return err
+	}
+	err = fmt.Errorf("%w, did you mean %q?", err, best)
+	return errpos.AddFix(err, errpos.ReplaceFix(fmt.Sprintf("Replace with %q", best), pos, best))
+}
+
+// suggestLiteral adds a fix for scalar values which were written as the wrong
+// literal type, e.g. a number for a string field.
+func suggestLiteral(err error, val parser.ASTValue) error {
+	typeErr := &parser.TypeError{}
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	value, ok := val.(parser.Value)
+	if !ok || !value.IsScalar() {
+		return err
+	}
+	tok := value.Token()
+
+	switch tok.Type {
+	case parser.INT, parser.DECIMAL, parser.BOOL:
+		if typeErr.Expected != "string" {
+			return err
+		}
+		fix := errpos.ReplaceFix("Quote value", value.Position(), strconv.Quote(tok.Lit))
+		fix.Safe = true
+		return errpos.AddFix(err, fix)
+
+	case parser.STRING:
+		if !literalParses(typeErr.Expected, tok.Lit) {
+			return err
+		}
+		fix := errpos.ReplaceFix("Unquote value", value.Position(), tok.Lit)
+		fix.Safe = true
+		return errpos.AddFix(err, fix)
+	}
+
+	return err
+}
+
+func literalParses(expected string, lit string) bool {
+	switch {
+	case expected == "bool":
+		return lit == "true" || lit == "false"
+	case strings.HasPrefix(expected, "uint"):
+		_, err := strconv.ParseUint(lit, 10, 64)
+		return err == nil
+	case strings.HasPrefix(expected, "int"):
+		_, err := strconv.ParseInt(lit, 10, 64)
+		return err == nil
+	case strings.HasPrefix(expected, "float"):
+		_, err := strconv.ParseFloat(lit, 64)
+		return err == nil
+	}
+	return false
+}
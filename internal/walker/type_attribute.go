@@ -0,0 +1,70 @@
+package walker
+
+import (
+	"fmt"
+
+	"github.com/pentops/bcl.go/bcl/errpos"
+	"github.com/pentops/bcl.go/internal/parser"
+	"github.com/pentops/bcl.go/internal/walker/schema"
+)
+
+// walkTypeAttribute selects the type of the block from the value of its type
+// attribute, e.g. kind = "http", then walks the rest of the body in the
+// selected type. The attribute must come before the other statements, which
+// may set fields of the type.
+func walkTypeAttribute(sc Context, spec schema.BlockSpec, bs *parser.Block) error {
+	typeAttr := *spec.TypeAttribute
+
+	var selector *parser.Assignment
+	var first parser.Statement
+	rest := make([]parser.Statement, 0, len(bs.Body.Statements))
+	for _, decl := range bs.Body.Statements {
+		assign, ok := decl.(*parser.Assignment)
+		if !ok || len(assign.Key.Idents) != 1 || assign.Key.Idents[0].Value != typeAttr.Attribute {
+			if first == nil && decl.StatementType() != parser.CommentStatement {
+				first = decl
+			}
+			rest = append(rest, decl)
+			continue
+		}
+		if selector != nil {
+			err := fmt.Errorf("%s is already set at %s", typeAttr.Attribute, selector.Position())
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeAlreadySet), assign)
+		}
+		if first != nil {
+			err := fmt.Errorf("%s selects the type of %s, so it must come before the statement at %s", typeAttr.Attribute, spec.ErrName(), first.Source().Position())
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeAttribute), assign)
+		}
+		selector = assign
+	}
+
+	if selector == nil {
+		err := fmt.Errorf("%s needs a %s attribute to select its type", spec.ErrName(), typeAttr.Attribute)
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeAttribute), bs.BlockHeader.Type)
+	}
+	sc.walkStats().Statements++
+
+	typeName, err := selector.Value.AsString()
+	if err != nil || selector.Append {
+		err := fmt.Errorf("%s needs a string naming a type of %s", typeAttr.Attribute, spec.ErrName())
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), selector.Value)
+	}
+
+	sc.Log("type_attribute", selector, "TypeAttribute %s = %s", typeAttr.Attribute, typeName)
+	pathToType := schema.PathSpec{typeAttr.FieldName}
+	if typeAttr.FieldName == "" || typeAttr.FieldName == "." {
+		pathToType = nil
+	}
+	typeIdent := parser.Ident{
+		Value:      typeName,
+		SourceNode: selector.Value.SourceNode,
+	}
+	typeScope, err := sc.BuildScope(pathToType, []parser.Ident{typeIdent}, KeepScope)
+	if err != nil {
+		return err
+	}
+
+	return sc.WithScope(typeScope, func(sc Context, spec schema.BlockSpec) error {
+		return doBody(sc, parser.Body{Statements: rest})
+	})
+}
@@ -3,13 +3,15 @@ package walker
 import (
 	"errors"
 	"fmt"
-	"log"
 	"slices"
 	"strings"
 
 	"github.com/pentops/bcl.go/bcl/errpos"
 	"github.com/pentops/bcl.go/internal/parser"
 	"github.com/pentops/bcl.go/internal/walker/schema"
+	"github.com/pentops/j5/lib/j5reflect"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 type ScopeFlag int
@@ -41,9 +43,24 @@ type Context interface {
 	AppendAttribute(path schema.PathSpec, ref []parser.Ident, value parser.ASTValue) error
 
 	setContainerFromScalar(bs schema.BlockSpec, vals parser.ASTValue) error
-
-	Logf(format string, args ...interface{})
+	qualifyName(name string, separator *string) string
+	interpolate(name string, pos HasPosition) (string, error)
+	anyBlock(decl *parser.Block) (bool, error)
+	extensionBlock(decl *parser.Block) (bool, error)
+	resolveOneof(err error) (bool, error)
+	withBlock(newScope *schema.Scope, pos errpos.Position, fn SpanCallback) error
+	computeChildren(pos errpos.Position) error
+	addDefaults(decl *parser.Block) error
+	inheritDefaults(blockType string) error
+	currentSpec() schema.BlockSpec
+	isKeyword(decl *parser.Block, keyword string) bool
+	freeFormType(ref parser.Reference) (string, bool)
+	deprecatedAlias(ref parser.Reference)
+	walkStats() *Stats
+
+	Log(event string, pos HasPosition, format string, args ...interface{})
 	WrapErr(err error, pos HasPosition) error
+	Suppress(err error) bool
 }
 
 type SpanCallback func(Context, schema.BlockSpec) error
@@ -59,11 +76,43 @@ type walkContext struct {
 	depth         int
 	blockLocation schema.SourceLocation
 
-	verbose bool
+	// scopeName is the name of the nearest enclosing named block, including
+	// any scope prefix, used to qualify scoped name tags.
+	scopeName string
+
+	// parent is the context which entered this scope, nil at the root.
+	parent *walkContext
+
+	// block is the block which opened this scope, nil for scopes opened by
+	// tags, qualifiers and paths within a block.
+	block *blockFrame
+
+	// defaults are the defaults blocks walked so far in this context.
+	defaults []blockDefaults
+
+	newAny     NewAny
+	extensions Extensions
+	functions  FunctionResolver
+	edition    parser.Edition
+	oneofs     OneofPolicy
+	warnings   *errpos.Errors
+
+	maxRecursion int
+	computed     map[string][]ComputedChild
+	freeForm     FreeForm
+
+	// dotted are the blocks walked into by dotted assignments, shared by
+	// the whole walk, see computeChildren.
+	dotted *[]dottedBlock
+
+	logger   Logger
+	filename string
+	suppress Suppressor
+	stats    *Stats
 }
 
 func newSchemaError(err error) error {
-	return fmt.Errorf("Schema Error): %w", err)
+	return errpos.WithCode(fmt.Errorf("Schema Error): %w", err), errpos.CodeSchemaError)
 }
 
 type pathElement struct {
@@ -137,9 +186,13 @@ func walkScope(scope *schema.Scope, path []pathElement, loc schema.SourceLocatio
 				werr.Available) //strings.Join(werr.Available, ", "))
 
 		default:
-			err = fmt.Errorf("%s", werr.LongMessage())
+			err = werr
 		}
 
+		if werr.Type == schema.RootNotFound || werr.Type == schema.NodeNotFound {
+			err = suggestName(err, werr.Field, *ident.position, werr.Available)
+		}
+		err = errpos.WithCode(err, werr.ErrorCode())
 		err = errpos.AddPosition(err, *ident.position)
 		return nil, err
 	}
@@ -179,23 +232,32 @@ func (bte BadTypeError) Error() string {
 	return fmt.Sprintf("bad type: want %s, got %s", bte.WantType, bte.GotType)
 }
 
+func (bte BadTypeError) ErrorCode() errpos.Code {
+	return errpos.CodeTypeMismatch
+}
+
+// Is matches errpos.ErrNotScalar when a scalar or array of scalars was wanted.
+func (bte BadTypeError) Is(target error) bool {
+	return target == errpos.ErrNotScalar && (bte.WantType == "Scalar" || bte.WantType == "ArrayOfScalar")
+}
+
 func (sc *walkContext) SetDescription(description parser.ASTValue) error {
 	root := sc.scope.RootBlock()
 	descSpec := root.Spec().Description
 	if descSpec == nil {
-		return newSchemaError(fmt.Errorf("no description field"))
+		return errpos.WithCode(fmt.Errorf("no description field"), errpos.CodeNoDescription)
 	}
 
 	return sc.SetAttribute(schema.PathSpec{*descSpec}, nil, description)
 }
 
 func (sc *walkContext) AppendAttribute(path schema.PathSpec, ref []parser.Ident, val parser.ASTValue) error {
-	sc.Logf("AppendAttribute(%#v, %#v, %#v, %s)", path, ref, val, val.Position())
+	sc.Log("append_attribute", val.Position(), "Append %s", attributeName(path, ref))
 	return sc.setAttribute(path, ref, val, true)
 }
 
 func (sc *walkContext) SetAttribute(path schema.PathSpec, ref []parser.Ident, val parser.ASTValue) error {
-	sc.Logf("SetAttribute(%#v, %#v, %#v, %s)", path, ref, val, val.Position())
+	sc.Log("set_attribute", val.Position(), "Set %s", attributeName(path, ref))
 	return sc.setAttribute(path, ref, val, false)
 }
 
@@ -208,25 +270,47 @@ func (sc *walkContext) setAttribute(path schema.PathSpec, ref []parser.Ident, va
 
 	last := fullPath[len(fullPath)-1]
 	pathToBlock := fullPath[:len(fullPath)-1]
-	parentScope, err := sc.walkScopePath(pathToBlock)
+	parentScope, err := sc.walkDotted(pathToBlock)
+	if err != nil {
+		return err
+	}
+
+	val, err = sc.callValue(val)
 	if err != nil {
 		return err
 	}
 
 	field, walkPathErr := parentScope.Field(last.name, val.Position(), appendValue)
+	if walkPathErr != nil && walkPathErr.Type == schema.RootNotFound {
+		if ok, err := sc.extensionAttribute(parentScope, last.name, val, appendValue); ok {
+			return err
+		}
+	}
 	if walkPathErr != nil {
-		sc.Logf("parentScope.Field(%q) failed: %s", last.name, walkPathErr)
+		sc.Log("field_not_found", val.Position(), "Field %q failed: %s", last.name, walkPathErr)
 		if last.position != nil {
-			return sc.WrapErr(walkPathErr, *last.position)
+			var err error = walkPathErr
+			if walkPathErr.Type == schema.RootNotFound || walkPathErr.Type == schema.NodeNotFound {
+				err = suggestName(err, last.name, *last.position, walkPathErr.Available)
+			}
+			return sc.WrapErr(err, *last.position)
 		} else {
 			return newSchemaError(walkPathErr)
 		}
 	}
 
+	if _, ok := field.AsArrayOfContainer(); ok {
+		return sc.setContainerElements(parentScope, last.name, val, appendValue)
+	}
+
+	if _, ok := field.AsMap(); ok {
+		return sc.WrapErr(mapValueError(last.name), val.Position())
+	}
+
 	_, ok := field.AsContainer()
 	if ok {
 		if appendValue {
-			return sc.WrapErr(fmt.Errorf("cannot append to container"), val.Position())
+			return sc.WrapErr(errpos.WithCode(fmt.Errorf("cannot append to container"), errpos.CodeTypeMismatch), val.Position())
 		}
 		containerScope, err := parentScope.ChildBlock(last.name, val.Position())
 		if err != nil {
@@ -248,14 +332,28 @@ func (sc *walkContext) setAttribute(path schema.PathSpec, ref []parser.Ident, va
 
 		if ok { // Field and Value are both arrays.
 			if !appendValue && fieldArray.Length() > 0 {
-				return sc.WrapErr(fmt.Errorf("value already set"), val.Position())
+				return sc.WrapErr(errpos.WithCode(fmt.Errorf("value already set"), errpos.CodeAlreadySet), val.Position())
 			}
 			for _, val := range vals {
-				_, err := fieldArray.AppendASTValue(val)
+				if ref, ok := splatOf(val); ok {
+					if err := sc.appendSplat(fieldArray, ref); err != nil {
+						return err
+					}
+					continue
+				}
+				val, err := sc.callValue(val)
 				if err != nil {
+					return err
+				}
+				_, err = fieldArray.AppendASTValue(val)
+				if result, ok := val.(callResult); ok && err != nil {
+					return sc.WrapErr(result.mismatch(err), val.Position())
+				} else if err != nil {
 					err = fmt.Errorf("SetAttribute %s, Append value: %w", field.FullTypeName(), err)
-					return sc.WrapErr(err, val.Position())
+					err = suggestEnum(err, parentScope, last.name, val)
+					return sc.WrapErr(errpos.WithCode(err, errpos.CodeInvalidValue), val.Position())
 				}
+				sc.enumName(parentScope, last.name, val)
 			}
 			return nil
 		}
@@ -266,7 +364,7 @@ func (sc *walkContext) setAttribute(path schema.PathSpec, ref []parser.Ident, va
 		}, val.Position())
 	}
 
-	sc.Logf("Attribute is not Array")
+	sc.Log("set_scalar", val.Position(), "Attribute is not Array")
 
 	scalarField, ok := field.AsScalar()
 	if !ok {
@@ -277,17 +375,153 @@ func (sc *walkContext) setAttribute(path schema.PathSpec, ref []parser.Ident, va
 	}
 
 	err = scalarField.SetASTValue(val)
-	if err != nil {
+	if result, ok := val.(callResult); ok && err != nil {
+		return sc.WrapErr(result.mismatch(err), val.Position())
+	} else if err != nil {
 		err = fmt.Errorf("SetAttribute %s: %w", field.FullTypeName(), err)
-		return sc.WrapErr(err, val.Position())
+		err = suggestLiteral(err, val)
+		err = suggestEnum(err, parentScope, last.name, val)
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeInvalidValue), val.Position())
 	}
+	sc.enumName(parentScope, last.name, val)
 	return nil
 }
 
+func splatOf(val parser.ASTValue) (*parser.Reference, bool) {
+	value, ok := val.(parser.Value)
+	if !ok {
+		return nil, false
+	}
+	return value.Splat()
+}
+
+// readField returns the field at the path, relative to the current block,
+// for reading. Every element of the path must have a position.
+func (sc *walkContext) readField(fullPath []pathElement, pos errpos.Position) (schema.Field, error) {
+	last := fullPath[len(fullPath)-1]
+	parentScope, err := sc.walkScopePath(fullPath[:len(fullPath)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	field, walkPathErr := parentScope.Field(last.name, pos, true)
+	if walkPathErr != nil {
+		var err error = walkPathErr
+		if walkPathErr.Type == schema.RootNotFound || walkPathErr.Type == schema.NodeNotFound {
+			err = suggestName(err, last.name, *last.position, walkPathErr.Available)
+		}
+		return nil, sc.WrapErr(err, pos)
+	}
+	return field, nil
+}
+
+// appendSplat appends the values of the array of scalars at ref, relative to
+// the current block, to fieldArray. The referenced field must already be set.
+func (sc *walkContext) appendSplat(fieldArray j5reflect.ArrayOfScalarField, ref *parser.Reference) error {
+	source, err := sc.readField(combinePath(nil, ref.Idents), ref.Position())
+	if err != nil {
+		return err
+	}
+
+	sourceArray, ok := source.AsArrayOfScalar()
+	if !ok {
+		err := fmt.Errorf("cannot splat %s, it is not an array of scalars", ref)
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), ref.Position())
+	}
+
+	// Values are read before appending, so a field can splat itself.
+	var values []interface{}
+	err = sourceArray.RangeValues(func(_ int, element j5reflect.Field) error {
+		scalar, ok := element.AsScalar()
+		if !ok {
+			return fmt.Errorf("element of %s is not a scalar", ref)
+		}
+		value, err := scalar.ToGoValue()
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return sc.WrapErr(err, ref.Position())
+	}
+
+	for _, value := range values {
+		if _, err := schema.AppendSynthesized(fieldArray, value, ref.Position()); err != nil {
+			err = fmt.Errorf("splat %s: %w", ref, err)
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), ref.Position())
+		}
+	}
+	return nil
+}
+
+// setContainerElements appends an element to the array of containers for each
+// element of the value, set from the element as for a container. Appending
+// adds the value as a single element.
+func (sc *walkContext) setContainerElements(parentScope *schema.Scope, name string, val parser.ASTValue, appendValue bool) error {
+	elements, isArray := val.AsArray()
+	if appendValue {
+		elements = []parser.ASTValue{val}
+	} else if !isArray {
+		err := fmt.Errorf("%s is an array of blocks, set it with an array of elements", name)
+		return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), val.Position())
+	}
+
+	for _, element := range elements {
+		if ref, ok := splatOf(element); ok {
+			err := fmt.Errorf("cannot splat %s into %s, which is an array of blocks", ref, name)
+			return sc.WrapErr(errpos.WithCode(err, errpos.CodeTypeMismatch), element.Position())
+		}
+		element, err := sc.callValue(element)
+		if err != nil {
+			return err
+		}
+		elementScope, walkPathErr := parentScope.ChildBlock(name, element.Position())
+		if walkPathErr != nil {
+			return sc.WrapErr(walkPathErr, element.Position())
+		}
+		err = sc.WithScope(elementScope, func(sc Context, bs schema.BlockSpec) error {
+			if err := sc.setContainerFromScalar(bs, element); err != nil {
+				return sc.WrapErr(err, element.Position())
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapValueError is the error for a value given for a map, which has no
+// literal form.
+func mapValueError(name string) error {
+	err := fmt.Errorf("%s is a map, which has no literal form, set its keys as %s.key = value or in a %s block", name, name, name)
+	return errpos.WithCode(err, errpos.CodeTypeMismatch)
+}
+
+// remainderField returns the field at the path of a scalar split remainder,
+// or nil when it can't be walked, which setting it reports.
+func (sc *walkContext) remainderField(path schema.PathSpec, pos schema.SourceLocation) schema.Field {
+	fullPath := combinePath(path, nil)
+	last := fullPath[len(fullPath)-1]
+	parentScope, err := sc.walkScopePath(fullPath[:len(fullPath)-1])
+	if err != nil {
+		return nil
+	}
+	field, walkPathErr := parentScope.Field(last.name, pos, true)
+	if walkPathErr != nil {
+		return nil
+	}
+	return field
+}
+
 func (sc *walkContext) setContainerFromScalar(bs schema.BlockSpec, val parser.ASTValue) error {
 	ss := bs.ScalarSplit
 	if ss == nil {
-		return fmt.Errorf("container %s has no method to set from array", bs.ErrName())
+		err := fmt.Errorf("%s has no scalar split, so it can't be set from a value, write it as a block", bs.ErrName())
+		return errpos.WithCode(err, errpos.CodeTypeMismatch)
 	}
 
 	var setVals []parser.ASTValue
@@ -297,7 +531,7 @@ func (sc *walkContext) setContainerFromScalar(bs schema.BlockSpec, val parser.AS
 		if err != nil {
 			return sc.WrapErr(err, val.Position())
 		}
-		sc.Logf("Splitting scalar %#v -> %q", val, strVal)
+		sc.Log("split_scalar", val.Position(), "Splitting scalar %q", strVal)
 		valStrings := strings.Split(strVal, *bs.ScalarSplit.Delimiter)
 		vals := make([]parser.ASTValue, len(valStrings))
 		for idx, str := range valStrings {
@@ -312,18 +546,20 @@ func (sc *walkContext) setContainerFromScalar(bs schema.BlockSpec, val parser.AS
 
 		vals, isArray := val.AsArray()
 		if !isArray {
-			return fmt.Errorf("container %s requires an array when setting from value, got a scalar", bs.ErrName())
+			err := fmt.Errorf("container %s requires an array when setting from value, got a scalar", bs.ErrName())
+			return errpos.WithCode(err, errpos.CodeTypeMismatch)
 		}
 		setVals = vals
 	}
-	sc.Logf("setContainerFromArray(%#v)", setVals)
+	sc.Log("set_container", val.Position(), "Setting %s from %d values", bs.ErrName(), len(setVals))
 
 	if ss.RightToLeft {
 		slices.Reverse(setVals)
 	}
 
 	if len(setVals) < len(ss.Required) {
-		return fmt.Errorf("container %s requires %d values, got %d", bs.ErrName(), len(ss.Required), len(setVals))
+		err := fmt.Errorf("container %s requires %d values, got %d", bs.ErrName(), len(ss.Required), len(setVals))
+		return errpos.WithCode(err, errpos.CodeValueCount)
 	}
 	intoRequired, remaining := setVals[:len(ss.Required)], setVals[len(ss.Required):]
 	for idx, val := range intoRequired {
@@ -356,7 +592,8 @@ func (sc *walkContext) setContainerFromScalar(bs schema.BlockSpec, val parser.AS
 	}
 
 	if ss.Remainder == nil {
-		return fmt.Errorf("container %s has more array fields than we know what to do with", bs.ErrName())
+		err := fmt.Errorf("container %s has more array fields than we know what to do with", bs.ErrName())
+		return errpos.WithCode(err, errpos.CodeValueCount)
 	}
 
 	// We reverse at the start to pop values from the end of the array, but when
@@ -367,6 +604,24 @@ func (sc *walkContext) setContainerFromScalar(bs schema.BlockSpec, val parser.AS
 		slices.Reverse(remaining)
 	}
 
+	if field := sc.remainderField(*ss.Remainder, remaining[0].Position()); field != nil {
+		_, isScalars := field.AsArrayOfScalar()
+		_, isBlocks := field.AsArrayOfContainer()
+		if isScalars || isBlocks {
+			// each remaining value is an element, an array for a block
+			for _, val := range remaining {
+				if err := sc.AppendAttribute(*ss.Remainder, nil, val); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if _, ok := field.AsMap(); ok {
+			last := (*ss.Remainder)[len(*ss.Remainder)-1]
+			return sc.WrapErr(mapValueError(last), remaining[0].Position())
+		}
+	}
+
 	remainingStr := make([]string, len(remaining))
 	for idx, val := range remaining {
 		var err error
@@ -398,11 +653,11 @@ func (wc *walkContext) run(fn func(Context) error) error {
 			return err
 		}
 
-		wc.Logf("New Error %s", err)
+		wc.Log("error", nil, "New Error %s", err)
 
 		posErr, ok := errpos.AsError(err)
 		if !ok {
-			wc.Logf("Not errpos")
+			wc.Log("error", nil, "Not errpos")
 			posErr = &errpos.Err{
 				Err: err,
 			}
@@ -417,30 +672,39 @@ func (wc *walkContext) run(fn func(Context) error) error {
 }
 
 func (wc *walkContext) WithScope(newScope *schema.Scope, fn SpanCallback) error {
-	return wc.withSchema(newScope, fn)
+	return wc.withSchema(newScope, nil, fn)
 }
-func (wc *walkContext) withSchema(newScope *schema.Scope, fn SpanCallback) error {
+func (wc *walkContext) withSchema(newScope *schema.Scope, block *blockFrame, fn SpanCallback) error {
 	lastBlock := newScope.CurrentBlock()
 
 	newPath := append(wc.path, lastBlock.Name())
 
-	if wc.verbose {
-		wc.Logf("|>>> Entering %q >>>", lastBlock.Name())
-		prefix := strings.Repeat("| ", wc.depth) + "|> "
-		entry := prefixer(log.Printf, prefix)
-		entry("Src = %q", strings.Join(lastBlock.Path(), "."))
-		entry("Path = %q", strings.Join(newPath, "."))
-
-		newScope.PrintScope(entry)
-	}
-
 	childContext := &walkContext{
 		scope:         newScope,
 		path:          newPath,
 		depth:         wc.depth + 1,
-		verbose:       wc.verbose,
+		logger:        wc.logger,
+		filename:      wc.filename,
+		suppress:      wc.suppress,
+		stats:         wc.stats,
 		blockLocation: wc.blockLocation,
-	}
+		scopeName:     wc.scopeName,
+		parent:        wc,
+		block:         block,
+		newAny:        wc.newAny,
+		extensions:    wc.extensions,
+		oneofs:        wc.oneofs,
+		warnings:      wc.warnings,
+		maxRecursion:  wc.maxRecursion,
+		computed:      wc.computed,
+		freeForm:      wc.freeForm,
+		dotted:        wc.dotted,
+		functions:     wc.functions,
+		edition:       wc.edition,
+	}
+
+	childContext.Log("enter_block", nil, "Entering %q from %q", lastBlock.Name(), strings.Join(lastBlock.Path(), "."))
+	childContext.logScope("scope", newScope.PrintScope)
 
 	err := childContext.run(func(sc Context) error {
 		return fn(sc, lastBlock.Spec())
@@ -448,9 +712,7 @@ func (wc *walkContext) withSchema(newScope *schema.Scope, fn SpanCallback) error
 	if err != nil {
 		return err
 	}
-	if wc.verbose {
-		wc.Logf("|<<< Exiting %q <<<", lastBlock.Name())
-	}
+	wc.Log("exit_block", nil, "Exiting %q", lastBlock.Name())
 	return nil
 }
 
@@ -463,26 +725,187 @@ func (wc *walkContext) WrapErr(err error, pos HasPosition) error {
 		panic("WrapErr called with nil error")
 	}
 
-	wc.Logf("Wrapping Error %s with %s", err, pos.Position())
+	wc.Log("wrap_error", pos, "Wrapping Error %s", err)
 	err = errpos.AddContext(err, strings.Join(wc.path, "."))
 	err = errpos.AddPosition(err, pos.Position())
 	return err
 }
 
-type logger func(format string, args ...interface{})
+func (wc *walkContext) walkStats() *Stats {
+	return wc.stats
+}
+
+func (wc *walkContext) Suppress(err error) bool {
+	if wc.suppress == nil {
+		return false
+	}
+	return wc.suppress.Suppress(err)
+}
+
+// currentSpec is the spec of the innermost block, which holds the statements
+// being walked.
+func (wc *walkContext) currentSpec() schema.BlockSpec {
+	return wc.scope.CurrentBlock().Spec()
+}
+
+// isKeyword is true for a block written as the keyword, when no block in the
+// scope has a child or alias of that name, which files written before the
+// keyword existed would mean instead.
+func (wc *walkContext) isKeyword(decl *parser.Block, keyword string) bool {
+	return isKeywordBlock(decl, keyword) && !wc.scope.HasChild(keyword)
+}
+
+// qualifyName records the name of the current block for nested blocks. When
+// a separator is given, the name is first prefixed with the name of the
+// enclosing block, and the qualified name is returned.
+func (wc *walkContext) qualifyName(name string, separator *string) string {
+	if separator != nil && wc.scopeName != "" {
+		name = wc.scopeName + *separator + name
+	}
+	wc.scopeName = name
+	return name
+}
+
+// interpolate replaces each ${path} in a block name with the value of the
+// scalar field at path in the enclosing block, which must be set by an
+// earlier statement. $${ is a literal ${. Names are only interpolated from
+// edition 2.
+func (wc *walkContext) interpolate(name string, pos HasPosition) (string, error) {
+	if wc.edition < parser.Edition2 || wc.parent == nil || !strings.Contains(name, "${") {
+		return name, nil
+	}
+	interpolationErr := func(format string, args ...interface{}) error {
+		err := errpos.WithCode(fmt.Errorf(format, args...), errpos.CodeInterpolation)
+		return wc.WrapErr(err, pos)
+	}
+
+	out := &strings.Builder{}
+	for {
+		idx := strings.Index(name, "${")
+		if idx < 0 {
+			out.WriteString(name)
+			return out.String(), nil
+		}
+		if idx > 0 && name[idx-1] == '$' {
+			out.WriteString(name[:idx-1] + "${")
+			name = name[idx+2:]
+			continue
+		}
+		out.WriteString(name[:idx])
+
+		end := strings.IndexByte(name[idx:], '}')
+		if end < 0 {
+			return "", interpolationErr("unterminated ${ in name")
+		}
+		path := name[idx+2 : idx+end]
+		name = name[idx+end+1:]
+
+		value, err := wc.parent.readScalar(path, pos.Position())
+		if err != nil {
+			if errpos.GetErrorCode(err) != "" {
+				return "", err
+			}
+			return "", interpolationErr("${%s}: %s", path, err)
+		}
+		out.WriteString(value)
+	}
+}
+
+// readScalar formats the value of the scalar field at the dotted path.
+func (wc *walkContext) readScalar(path string, pos errpos.Position) (string, error) {
+	parts := strings.Split(path, ".")
+	fullPath := make([]pathElement, len(parts))
+	for idx, part := range parts {
+		if part == "" {
+			return "", fmt.Errorf("invalid field path")
+		}
+		fullPath[idx] = pathElement{name: part, position: &pos}
+	}
 
-func prefixer(parent logger, prefix string) logger {
-	return func(format string, args ...interface{}) {
-		parent(prefix+format+"\n", args...)
+	field, err := wc.readField(fullPath, pos)
+	if err != nil {
+		return "", err
+	}
+	scalar, ok := field.AsScalar()
+	if !ok {
+		return "", fmt.Errorf("%s is not a scalar", path)
 	}
+	if !field.IsSet() {
+		return "", fmt.Errorf("%s is not set, set it before the block", path)
+	}
+	value, err := scalar.ToGoValue()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(value), nil
 }
 
-func (wc *walkContext) Logf(format string, args ...interface{}) {
-	if !wc.verbose {
-		return
+// anyBlock walks a block which sets a google.protobuf.Any field. The first
+// tag names the type of the packed message, the rest of the block is walked
+// into a new message of that type. It is false when the block is not for an
+// Any field.
+func (wc *walkContext) anyBlock(decl *parser.Block) (bool, error) {
+	idents := decl.Type.Idents
+	if len(idents) != 1 {
+		return false, nil
+	}
+	name := idents[0].String()
+	field, ok, walkPathErr := wc.scope.AnyField(name, decl.Type.Position())
+	if !ok {
+		return false, nil
+	}
+	if walkPathErr != nil {
+		return true, wc.WrapErr(walkPathErr, decl.Type)
+	}
+
+	hdr := decl.BlockHeader
+	if len(hdr.Tags) == 0 || hdr.Tags[0].Reference == nil {
+		var pos HasPosition = pointPosition(hdr.Type.End)
+		if len(hdr.Tags) > 0 {
+			pos = hdr.Tags[0]
+		}
+		return true, wc.WrapErr(&ErrExpectedTag{Label: "type", Schema: name}, pos)
+	}
+	typeTag := hdr.Tags[0]
+	typeName := typeTag.Reference.String()
+
+	if wc.newAny == nil {
+		err := fmt.Errorf("no message types to resolve %s", typeName)
+		return true, wc.WrapErr(errpos.WithCode(err, errpos.CodeUnknownType), typeTag)
+	}
+	obj, walked, err := wc.newAny(typeName)
+	if err != nil {
+		return true, wc.WrapErr(errpos.WithCode(err, errpos.CodeUnknownType), typeTag)
+	}
+	packedScope, err := field.Root(obj)
+	if err != nil {
+		return true, newSchemaError(err)
+	}
+
+	inner := *decl
+	inner.Tags = hdr.Tags[1:]
+	err = wc.withSchema(packedScope, nil, func(sc Context, spec schema.BlockSpec) error {
+		if err := doBlock(sc, spec, &inner); err != nil {
+			return err
+		}
+		return sc.computeChildren(decl.Type.Position())
+	})
+	if err != nil {
+		return true, err
+	}
+
+	value, err := proto.MarshalOptions{Deterministic: true}.Marshal(walked().Interface())
+	if err != nil {
+		return true, wc.WrapErr(err, typeTag)
+	}
+	err = field.SetProtoAny(&anypb.Any{
+		TypeUrl: "type.googleapis.com/" + typeName,
+		Value:   value,
+	})
+	if err != nil {
+		return true, wc.WrapErr(err, typeTag)
 	}
-	prefix := strings.Repeat("| ", wc.depth)
-	prefixer(log.Printf, prefix)(format, args...)
+	return true, nil
 }
 
 type scopedError struct {
@@ -497,18 +920,3 @@ func (se *scopedError) Error() string {
 func (se *scopedError) Unwrap() error {
 	return se.err
 }
-
-func logError(err error) {
-	scoped := &scopedError{}
-	if !errors.As(err, &scoped) {
-		fmt.Printf("Error %s\n", err)
-		return
-	}
-	pf := prefixer(log.Printf, "ERR | ")
-	msg := scoped.err.Err.Error()
-	pf("Error: %s", msg)
-	pf("Location: %s", scoped.err.Pos)
-	pf("Scope:")
-	scoped.schema.PrintScope(pf)
-	pf("Got Error %s\n", msg)
-}